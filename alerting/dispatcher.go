@@ -187,7 +187,18 @@ func (d *Dispatcher) meetsSeverityThreshold(sev sentinel.Severity) bool {
 		sentinel.SeverityHigh:     3,
 		sentinel.SeverityCritical: 4,
 	}
-	return order[sev] >= order[d.config.MinSeverity]
+	d.mu.Lock()
+	threshold := d.config.MinSeverity
+	d.mu.Unlock()
+	return order[sev] >= order[threshold]
+}
+
+// SetMinSeverity changes the severity threshold at runtime, e.g. from the
+// dashboard.
+func (d *Dispatcher) SetMinSeverity(sev sentinel.Severity) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.config.MinSeverity = sev
 }
 
 // ProviderCount returns the number of registered providers.
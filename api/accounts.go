@@ -0,0 +1,301 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// minPasswordLength is the shortest password accepted for a stored account.
+const minPasswordLength = 8
+
+var errAccountDisabled = errors.New("account disabled or deleted")
+
+// HashPassword returns the bcrypt hash stored for a dashboard account.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// burnPasswordCheck spends the time of a bcrypt comparison so a login for an
+// unknown username is not measurably faster than one for a stored account.
+func burnPasswordCheck(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("sentinel-dummy-password"), bcrypt.DefaultCost)
+	})
+	bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+// authenticate checks a username and password against the stored accounts
+// and the built-in DashboardConfig account, which always has the admin
+// role. It returns nil when the credentials are wrong.
+func (s *Server) authenticate(ctx context.Context, username, password string) (*sentinel.DashboardUser, error) {
+	user, err := s.store.GetDashboardUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil || user.Disabled {
+			return nil, nil
+		}
+		now := time.Now()
+		user.LastLoginAt = &now
+		if err := s.store.SaveDashboardUser(ctx, user); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	burnPasswordCheck(password)
+	// Constant-time comparison — a plain != leaks credential length/prefix
+	// timing. Compare both fields unconditionally so the failure path takes
+	// the same time regardless of which field is wrong.
+	userOK := subtle.ConstantTimeCompare([]byte(username), []byte(s.config.Dashboard.Username)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(password), []byte(s.config.Dashboard.Password)) == 1
+	if !userOK || !passOK {
+		return nil, nil
+	}
+	return &sentinel.DashboardUser{Username: username, Role: sentinel.RoleAdmin}, nil
+}
+
+// checkAccount re-reads a stored account on every request, so deleting or
+// disabling an account revokes its tokens at once and a role change applies
+// to tokens already issued.
+func (s *Server) checkAccount(c *gin.Context, id string) error {
+	user, err := s.store.GetDashboardUser(c.Request.Context(), id)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.Disabled {
+		return errAccountDisabled
+	}
+	c.Set(ctxSubject, user.Username)
+	c.Set(ctxRole, user.Role)
+	return nil
+}
+
+// accountSession runs after AuthMiddleware and applies checkAccount to
+// tokens issued for stored accounts.
+func (s *Server) accountSession(c *gin.Context) {
+	if id := c.GetString(ctxAccountID); id != "" {
+		if err := s.checkAccount(c, id); err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid or expired token",
+				"code":  "UNAUTHORIZED",
+			})
+			return
+		}
+	}
+	c.Next()
+}
+
+// --- Audit ---
+
+// ctxAuditResourceID lets a handler name the affected resource when it is
+// not the first path parameter, e.g. an IP taken from the request body.
+const ctxAuditResourceID = "sentinel_audit_resource_id"
+
+// audit records an AuditLog entry, attributed to the authenticated user,
+// once the handler has run. Failed attempts are recorded too.
+func (s *Server) audit(action, resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		s.writeAudit(c, currentSubject(c), currentRole(c), action, resource)
+	}
+}
+
+func (s *Server) writeAudit(c *gin.Context, subject string, role sentinel.DashboardRole, action, resource string) {
+	resourceID := c.GetString(ctxAuditResourceID)
+	if resourceID == "" && len(c.Params) > 0 {
+		resourceID = c.Params[0].Value
+	}
+	status := c.Writer.Status()
+	entry := &sentinel.AuditLog{
+		ID:         uuid.New().String(),
+		Timestamp:  time.Now(),
+		UserID:     subject,
+		UserRole:   string(role),
+		Action:     action,
+		Resource:   resource,
+		ResourceID: resourceID,
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+		Success:    status < http.StatusBadRequest,
+	}
+	if c.GetString(ctxAPIKeyID) != "" {
+		entry.UserRole = "api_key"
+	}
+	if !entry.Success {
+		entry.Error = http.StatusText(status)
+	}
+	s.store.SaveAuditLog(context.WithoutCancel(c.Request.Context()), entry)
+}
+
+// --- Account handlers ---
+
+func (s *Server) handleListAccounts(c *gin.Context) {
+	users, err := s.store.ListDashboardUsers(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list accounts", "code": "INTERNAL_ERROR"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": users})
+}
+
+func (s *Server) handleCreateAccount(c *gin.Context) {
+	var req struct {
+		Username string                 `json:"username"`
+		Email    string                 `json:"email"`
+		Password string                 `json:"password"`
+		Role     sentinel.DashboardRole `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "code": "BAD_REQUEST"})
+		return
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	c.Set(ctxAuditResourceID, req.Username)
+
+	switch {
+	case req.Username == "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username is required", "code": "BAD_REQUEST"})
+		return
+	case !req.Role.Valid():
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be viewer, analyst or admin", "code": "BAD_REQUEST"})
+		return
+	case len(req.Password) < minPasswordLength:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Password must be at least 8 characters", "code": "BAD_REQUEST"})
+		return
+	case req.Username == s.config.Dashboard.Username:
+		c.JSON(http.StatusConflict, gin.H{"error": "Username is reserved for the built-in account", "code": "CONFLICT"})
+		return
+	case strings.HasPrefix(req.Username, oidcSubjectPrefix) || strings.HasPrefix(req.Username, "apikey:"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Usernames starting with oidc: or apikey: are reserved", "code": "BAD_REQUEST"})
+		return
+	}
+
+	hash, err := HashPassword(req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password", "code": "INTERNAL_ERROR"})
+		return
+	}
+	now := time.Now()
+	user := &sentinel.DashboardUser{
+		ID:           uuid.New().String(),
+		Username:     req.Username,
+		Email:        req.Email,
+		Role:         req.Role,
+		PasswordHash: hash,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := s.store.SaveDashboardUser(c.Request.Context(), user); err != nil {
+		if errors.Is(err, storage.ErrDuplicateUsername) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Username already exists", "code": "CONFLICT"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create account", "code": "INTERNAL_ERROR"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": user})
+}
+
+func (s *Server) handleUpdateAccount(c *gin.Context) {
+	var req struct {
+		Email    *string                 `json:"email"`
+		Password *string                 `json:"password"`
+		Role     *sentinel.DashboardRole `json:"role"`
+		Disabled *bool                   `json:"disabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "code": "BAD_REQUEST"})
+		return
+	}
+
+	id := c.Param("id")
+	user, err := s.store.GetDashboardUser(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load account", "code": "INTERNAL_ERROR"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found", "code": "NOT_FOUND"})
+		return
+	}
+
+	// An admin cannot lock themselves out.
+	self := id == c.GetString(ctxAccountID)
+	if self && ((req.Role != nil && *req.Role != sentinel.RoleAdmin) || (req.Disabled != nil && *req.Disabled)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot demote or disable your own account", "code": "BAD_REQUEST"})
+		return
+	}
+
+	if req.Role != nil {
+		if !req.Role.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be viewer, analyst or admin", "code": "BAD_REQUEST"})
+			return
+		}
+		user.Role = *req.Role
+	}
+	if req.Password != nil {
+		if len(*req.Password) < minPasswordLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Password must be at least 8 characters", "code": "BAD_REQUEST"})
+			return
+		}
+		hash, err := HashPassword(*req.Password)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password", "code": "INTERNAL_ERROR"})
+			return
+		}
+		user.PasswordHash = hash
+	}
+	if req.Email != nil {
+		user.Email = *req.Email
+	}
+	if req.Disabled != nil {
+		user.Disabled = *req.Disabled
+	}
+	user.UpdatedAt = time.Now()
+
+	if err := s.store.SaveDashboardUser(c.Request.Context(), user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account", "code": "INTERNAL_ERROR"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": user})
+}
+
+func (s *Server) handleDeleteAccount(c *gin.Context) {
+	id := c.Param("id")
+	if id == c.GetString(ctxAccountID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot delete your own account", "code": "BAD_REQUEST"})
+		return
+	}
+	existed, err := s.store.DeleteDashboardUser(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account", "code": "INTERNAL_ERROR"})
+		return
+	}
+	if !existed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found", "code": "NOT_FOUND"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted"})
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/MUKE-coder/sentinel/v2/storage/memory"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newAccountsTestServer(t *testing.T) (*gin.Engine, *memory.Store) {
+	t.Helper()
+	store := memory.New()
+	pipe := pipeline.New(100)
+	pipe.Start(1)
+	t.Cleanup(func() { pipe.Stop() })
+
+	srv := NewServer(store, pipe, nil, nil, sentinel.Config{Dashboard: sentinel.DashboardConfig{
+		Prefix: "/sentinel", Username: "admin", Password: "builtin-pass", SecretKey: "test",
+	}})
+	r := gin.New()
+	srv.RegisterRoutes(r, "/sentinel")
+	return r, store
+}
+
+func doJSON(r *gin.Engine, token, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func login(t *testing.T, r *gin.Engine, username, password string) string {
+	t.Helper()
+	w := doJSON(r, "", http.MethodPost, "/sentinel/api/auth/login",
+		`{"username":"`+username+`","password":"`+password+`"}`)
+	var res struct {
+		Token string `json:"token"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &res)
+	if w.Code != http.StatusOK || res.Token == "" {
+		t.Fatalf("login %s: %d %s", username, w.Code, w.Body.String())
+	}
+	return res.Token
+}
+
+func TestAccountsRolesAndAudit(t *testing.T) {
+	r, store := newAccountsTestServer(t)
+	adminToken := login(t, r, "admin", "builtin-pass")
+
+	for _, acct := range []string{
+		`{"username":"vera","password":"viewer-pass","role":"viewer"}`,
+		`{"username":"ana","password":"analyst-pass","role":"analyst","email":"ana@example.com"}`,
+	} {
+		if w := doJSON(r, adminToken, http.MethodPost, "/sentinel/api/accounts", acct); w.Code != http.StatusCreated {
+			t.Fatalf("create account: %d %s", w.Code, w.Body.String())
+		}
+	}
+	if w := doJSON(r, adminToken, http.MethodPost, "/sentinel/api/accounts", `{"username":"ana","password":"another-pass","role":"viewer"}`); w.Code != http.StatusConflict {
+		t.Errorf("duplicate username: expected 409, got %d", w.Code)
+	}
+	if w := doJSON(r, adminToken, http.MethodPost, "/sentinel/api/accounts", `{"username":"oidc:ana@example.com","password":"another-pass","role":"admin"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("SSO-namespaced username: expected 400, got %d", w.Code)
+	}
+	if w := doJSON(r, adminToken, http.MethodPost, "/sentinel/api/accounts", `{"username":"bob","password":"short","role":"viewer"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("short password: expected 400, got %d", w.Code)
+	}
+
+	// The password hash is stored but never serialized.
+	w := doJSON(r, adminToken, http.MethodGet, "/sentinel/api/accounts", "")
+	if strings.Contains(w.Body.String(), "$2a$") || strings.Contains(w.Body.String(), "password") {
+		t.Errorf("account list leaks the password hash: %s", w.Body.String())
+	}
+	if u, _ := store.GetDashboardUserByUsername(context.Background(), "ana"); u == nil || !strings.HasPrefix(u.PasswordHash, "$2a$") {
+		t.Fatalf("expected a bcrypt hash, got %+v", u)
+	}
+
+	if w := doJSON(r, "", http.MethodPost, "/sentinel/api/auth/login", `{"username":"ana","password":"wrong-pass"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong password: expected 401, got %d", w.Code)
+	}
+	viewer := login(t, r, "vera", "viewer-pass")
+	analyst := login(t, r, "ana", "analyst-pass")
+
+	w = doJSON(r, analyst, http.MethodGet, "/sentinel/api/auth/verify", "")
+	if !strings.Contains(w.Body.String(), `"username":"ana"`) || !strings.Contains(w.Body.String(), `"role":"analyst"`) {
+		t.Errorf("verify should report the subject and role: %s", w.Body.String())
+	}
+
+	cases := []struct {
+		name, token, method, path, body string
+		want                            int
+	}{
+		{"viewer reads", viewer, http.MethodGet, "/sentinel/api/threats", "", http.StatusOK},
+		{"viewer cannot resolve", viewer, http.MethodPost, "/sentinel/api/threats/t1/resolve", "", http.StatusForbidden},
+		{"analyst resolves", analyst, http.MethodPost, "/sentinel/api/threats/t1/resolve", "", http.StatusOK},
+		{"analyst cannot edit WAF", analyst, http.MethodPut, "/sentinel/api/waf/rules", `{"mode":"block"}`, http.StatusForbidden},
+		{"analyst cannot unblock", analyst, http.MethodDelete, "/sentinel/api/ip/block/1.2.3.4", "", http.StatusForbidden},
+		{"analyst cannot list accounts", analyst, http.MethodGet, "/sentinel/api/accounts", "", http.StatusForbidden},
+		{"admin edits alerts", adminToken, http.MethodPut, "/sentinel/api/alerts/config", `{"min_severity":"High"}`, http.StatusOK},
+	}
+	for _, tc := range cases {
+		if w := doJSON(r, tc.token, tc.method, tc.path, tc.body); w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d (%s)", tc.name, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	logs, _, _ := store.ListAuditLogs(context.Background(), sentinel.AuditFilter{Action: "RESOLVE", Page: 1, PageSize: 10})
+	if len(logs) != 1 || logs[0].UserID != "ana" || logs[0].UserRole != "analyst" || logs[0].ResourceID != "t1" || !logs[0].Success {
+		t.Errorf("resolve not attributed to the analyst: %+v", logs)
+	}
+	logs, _, _ = store.ListAuditLogs(context.Background(), sentinel.AuditFilter{Action: "UPDATE", Page: 1, PageSize: 10})
+	if len(logs) != 1 || logs[0].UserID != "admin" || logs[0].Resource != "alert_config" {
+		t.Errorf("alert config update not audited: %+v", logs)
+	}
+	logs, _, _ = store.ListAuditLogs(context.Background(), sentinel.AuditFilter{Action: "LOGIN", Page: 1, PageSize: 10})
+	failed := 0
+	for _, l := range logs {
+		if !l.Success {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Errorf("expected one failed login in the audit log, got %d of %d", failed, len(logs))
+	}
+
+	// Role changes and disabling apply to tokens already issued.
+	ana, _ := store.GetDashboardUserByUsername(context.Background(), "ana")
+	if w := doJSON(r, adminToken, http.MethodPut, "/sentinel/api/accounts/"+ana.ID, `{"role":"viewer"}`); w.Code != http.StatusOK {
+		t.Fatalf("update account: %d %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(r, analyst, http.MethodPost, "/sentinel/api/threats/t1/resolve", ""); w.Code != http.StatusForbidden {
+		t.Errorf("demoted token should lose analyst access, got %d", w.Code)
+	}
+	if w := doJSON(r, adminToken, http.MethodPut, "/sentinel/api/accounts/"+ana.ID, `{"disabled":true}`); w.Code != http.StatusOK {
+		t.Fatalf("disable account: %d", w.Code)
+	}
+	if w := doJSON(r, analyst, http.MethodGet, "/sentinel/api/threats", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("disabled account should be rejected, got %d", w.Code)
+	}
+	if w := doJSON(r, "", http.MethodPost, "/sentinel/api/auth/login", `{"username":"ana","password":"analyst-pass"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("disabled account should not log in, got %d", w.Code)
+	}
+	if w := doJSON(r, adminToken, http.MethodDelete, "/sentinel/api/accounts/"+ana.ID, ""); w.Code != http.StatusOK {
+		t.Errorf("delete account: %d", w.Code)
+	}
+}
+
+// Tokens minted before roles existed carry no subject or role and must
+// not be accepted as admin tokens.
+func TestAuthRejectsTokenWithoutRole(t *testing.T) {
+	r, _ := newAccountsTestServer(t)
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss": "sentinel",
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := doJSON(r, legacy, http.MethodGet, "/sentinel/api/threats", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a token without a role, got %d", w.Code)
+	}
+}
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// API keys look like "snk_<id>_<secret>". The ID locates the stored key and
+// the secret is compared against its SHA-256 hash; the secret is 256 random
+// bits, so a slow password hash would add latency without adding safety.
+const apiKeyPrefix = "snk_"
+
+// apiKeyTouchInterval bounds how often a key's last-used time is written.
+const apiKeyTouchInterval = time.Minute
+
+var (
+	errInvalidAPIKey     = errors.New("invalid, expired or revoked API key")
+	errAPIKeyIPForbidden = errors.New("API key not allowed from this IP")
+)
+
+// APIKeyVerifier checks a key presented as "Authorization: ApiKey <key>".
+// *Server implements it on top of the store.
+type APIKeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, rawKey, clientIP string) (*sentinel.APIKey, error)
+}
+
+var _ APIKeyVerifier = (*Server)(nil)
+
+// apiKeyForbidden lists route resources API keys can never reach, whatever
+// their scopes: a key must not be able to mint keys or accounts.
+var apiKeyForbidden = map[string]bool{"accounts": true, "api-keys": true}
+
+// apiKeyActions ranks the actions of a scope; each implies those below it.
+// read covers GET routes, write the other routes analysts may use, and
+// admin the admin-only routes, such as unblocking or whitelisting an IP.
+var apiKeyActions = map[string]int{"read": 0, "write": 1, "admin": 2}
+
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func newAPIKeySecret() (id, secret, raw string) {
+	id = strings.ReplaceAll(uuid.New().String(), "-", "")
+	b := make([]byte, 32)
+	rand.Read(b)
+	secret = hex.EncodeToString(b)
+	return id, secret, apiKeyPrefix + id + "_" + secret
+}
+
+func parseAPIKey(raw string) (id, secret string, ok bool) {
+	rest, ok := strings.CutPrefix(raw, apiKeyPrefix)
+	if !ok {
+		return "", "", false
+	}
+	id, secret, ok = strings.Cut(rest, "_")
+	return id, secret, ok && id != "" && secret != ""
+}
+
+// VerifyAPIKey returns the stored key for rawKey when it is valid, not
+// expired or revoked, and allowed from clientIP. It records the use.
+func (s *Server) VerifyAPIKey(ctx context.Context, rawKey, clientIP string) (*sentinel.APIKey, error) {
+	id, secret, ok := parseAPIKey(rawKey)
+	if !ok {
+		return nil, errInvalidAPIKey
+	}
+	key, err := s.store.GetAPIKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil || subtle.ConstantTimeCompare([]byte(hashAPIKeySecret(secret)), []byte(key.Hash)) != 1 {
+		return nil, errInvalidAPIKey
+	}
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, errInvalidAPIKey
+	}
+	if !ipAllowed(key.AllowedIPs, clientIP) {
+		return nil, errAPIKeyIPForbidden
+	}
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		s.store.TouchAPIKey(ctx, key.ID, now)
+		key.LastUsedAt = &now
+	}
+	return key, nil
+}
+
+// ipAllowed reports whether ip matches one of the allowlist entries (IPs or
+// CIDRs). An empty allowlist allows every IP.
+func ipAllowed(allowlist []string, ip string) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, entry := range allowlist {
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			if prefix.Contains(addr) {
+				return true
+			}
+		} else if a, err := netip.ParseAddr(entry); err == nil && a.Unmap() == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// routeScope returns the scope a request needs: the first path segment
+// after /api/ plus ":read" for GET and HEAD or ":write" otherwise.
+func routeScope(c *gin.Context) (resource, scope string) {
+	full := c.FullPath()
+	if i := strings.Index(full, "/api/"); i >= 0 {
+		full = full[i+len("/api/"):]
+	}
+	resource, _, _ = strings.Cut(full, "/")
+	action := "write"
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		action = "read"
+	}
+	return resource, resource + ":" + action
+}
+
+// scopeAllows reports whether scopes grant scope. A scope implies the
+// lower actions on the same resource (see apiKeyActions).
+func scopeAllows(scopes []string, scope string) bool {
+	resource, action, _ := strings.Cut(scope, ":")
+	for _, s := range scopes {
+		r, a, _ := strings.Cut(s, ":")
+		if r == resource && apiKeyActions[a] >= apiKeyActions[action] {
+			return true
+		}
+	}
+	return false
+}
+
+// --- API key handlers ---
+
+func (s *Server) handleListAPIKeys(c *gin.Context) {
+	keys, err := s.store.ListAPIKeys(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list API keys", "code": "INTERNAL_ERROR"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": keys})
+}
+
+// handleCreateAPIKey creates a key and returns it in full. The secret is
+// not stored and cannot be shown again.
+func (s *Server) handleCreateAPIKey(c *gin.Context) {
+	var req struct {
+		Name       string     `json:"name"`
+		Scopes     []string   `json:"scopes"`
+		AllowedIPs []string   `json:"allowed_ips"`
+		ExpiresAt  *time.Time `json:"expires_at"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "code": "BAD_REQUEST"})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required", "code": "BAD_REQUEST"})
+		return
+	}
+	if len(req.Scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one scope is required", "code": "BAD_REQUEST"})
+		return
+	}
+	for _, scope := range req.Scopes {
+		resource, action, _ := strings.Cut(scope, ":")
+		if _, ok := apiKeyActions[action]; !ok || !s.apiKeyResources[resource] || apiKeyForbidden[resource] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown scope " + scope, "code": "BAD_REQUEST"})
+			return
+		}
+	}
+	for _, entry := range req.AllowedIPs {
+		if _, err := netip.ParsePrefix(entry); err != nil {
+			if _, err := netip.ParseAddr(entry); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid IP or CIDR " + entry, "code": "BAD_REQUEST"})
+				return
+			}
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future", "code": "BAD_REQUEST"})
+		return
+	}
+
+	id, secret, raw := newAPIKeySecret()
+	c.Set(ctxAuditResourceID, id)
+	key := &sentinel.APIKey{
+		ID:         id,
+		Name:       req.Name,
+		Hash:       hashAPIKeySecret(secret),
+		Scopes:     req.Scopes,
+		AllowedIPs: req.AllowedIPs,
+		CreatedBy:  currentSubject(c),
+		CreatedAt:  time.Now(),
+		ExpiresAt:  req.ExpiresAt,
+	}
+	if err := s.store.SaveAPIKey(c.Request.Context(), key); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key", "code": "INTERNAL_ERROR"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": key, "key": raw})
+}
+
+func (s *Server) handleRevokeAPIKey(c *gin.Context) {
+	key, err := s.store.GetAPIKey(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load API key", "code": "INTERNAL_ERROR"})
+		return
+	}
+	if key == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found", "code": "NOT_FOUND"})
+		return
+	}
+	if key.RevokedAt == nil {
+		now := time.Now()
+		key.RevokedAt = &now
+		if err := s.store.SaveAPIKey(c.Request.Context(), key); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key", "code": "INTERNAL_ERROR"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
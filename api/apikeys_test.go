@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/gin-gonic/gin"
+)
+
+func doAPIKey(r *gin.Engine, key, ip, method, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "ApiKey "+key)
+	req.RemoteAddr = ip + ":4000"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func createAPIKey(t *testing.T, r *gin.Engine, token, body string) (id, raw string) {
+	t.Helper()
+	w := doJSON(r, token, http.MethodPost, "/sentinel/api/api-keys", body)
+	var res struct {
+		Data sentinel.APIKey `json:"data"`
+		Key  string          `json:"key"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &res)
+	if w.Code != http.StatusCreated || res.Key == "" {
+		t.Fatalf("create API key: %d %s", w.Code, w.Body.String())
+	}
+	return res.Data.ID, res.Key
+}
+
+func TestAPIKeysScopesAndRevocation(t *testing.T) {
+	r, store := newAccountsTestServer(t)
+	admin := login(t, r, "admin", "builtin-pass")
+
+	for _, body := range []string{
+		`{"name":"bad","scopes":["nope:read"]}`,
+		`{"name":"bad","scopes":["threats:delete"]}`,
+		`{"name":"bad","scopes":["api-keys:write"]}`,
+		`{"name":"bad","scopes":["threats:read"],"allowed_ips":["not-an-ip"]}`,
+		`{"name":"bad","scopes":["threats:read"],"expires_at":"2001-01-01T00:00:00Z"}`,
+		`{"scopes":["threats:read"]}`,
+	} {
+		if w := doJSON(r, admin, http.MethodPost, "/sentinel/api/api-keys", body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+
+	id, key := createAPIKey(t, r, admin, `{"name":"siem","scopes":["threats:read","ip:write"],"allowed_ips":["10.0.0.0/8"]}`)
+	stored, _ := store.GetAPIKey(context.Background(), id)
+	if stored == nil || strings.Contains(key, stored.Hash) || stored.CreatedBy != "admin" {
+		t.Fatalf("unexpected stored key %+v", stored)
+	}
+	if w := doJSON(r, admin, http.MethodGet, "/sentinel/api/api-keys", ""); strings.Contains(w.Body.String(), stored.Hash) {
+		t.Errorf("key list leaks the hash: %s", w.Body.String())
+	}
+
+	cases := []struct {
+		name, key, ip, method, path string
+		want                        int
+	}{
+		{"read scope", key, "10.1.2.3", http.MethodGet, "/sentinel/api/threats", http.StatusOK},
+		{"write implies read", key, "10.1.2.3", http.MethodGet, "/sentinel/api/ip/blocked", http.StatusOK},
+		{"write scope on an analyst route", key, "10.1.2.3", http.MethodPost, "/sentinel/api/ip/block", http.StatusBadRequest},
+		{"write scope on an admin route", key, "10.1.2.3", http.MethodDelete, "/sentinel/api/ip/block/1.2.3.4", http.StatusForbidden},
+		{"missing scope", key, "10.1.2.3", http.MethodGet, "/sentinel/api/actors", http.StatusForbidden},
+		{"read-only scope cannot write", key, "10.1.2.3", http.MethodPost, "/sentinel/api/threats/t1/resolve", http.StatusForbidden},
+		{"never reaches api keys", key, "10.1.2.3", http.MethodGet, "/sentinel/api/api-keys", http.StatusForbidden},
+		{"outside the allowlist", key, "192.0.2.1", http.MethodGet, "/sentinel/api/threats", http.StatusForbidden},
+		{"wrong secret", key[:len(key)-4] + "AAAA", "10.1.2.3", http.MethodGet, "/sentinel/api/threats", http.StatusUnauthorized},
+		{"malformed", "garbage", "10.1.2.3", http.MethodGet, "/sentinel/api/threats", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		if w := doAPIKey(r, tc.key, tc.ip, tc.method, tc.path); w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d (%s)", tc.name, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	if stored, _ = store.GetAPIKey(context.Background(), id); stored.LastUsedAt == nil {
+		t.Error("expected the last-used time to be recorded")
+	}
+	logs, _, _ := store.ListAuditLogs(context.Background(), sentinel.AuditFilter{Action: "BLOCK", Page: 1, PageSize: 10})
+	if len(logs) != 1 || logs[0].UserID != "apikey:siem" || logs[0].UserRole != "api_key" {
+		t.Errorf("key request not attributed to the key: %+v", logs)
+	}
+
+	if w := doJSON(r, admin, http.MethodDelete, "/sentinel/api/api-keys/"+id, ""); w.Code != http.StatusOK {
+		t.Fatalf("revoke: %d", w.Code)
+	}
+	if w := doAPIKey(r, key, "10.1.2.3", http.MethodGet, "/sentinel/api/threats"); w.Code != http.StatusUnauthorized {
+		t.Errorf("revoked key: expected 401, got %d", w.Code)
+	}
+	if w := doJSON(r, admin, http.MethodDelete, "/sentinel/api/api-keys/missing", ""); w.Code != http.StatusNotFound {
+		t.Errorf("revoke unknown key: expected 404, got %d", w.Code)
+	}
+
+	// Expired keys are refused.
+	id, key = createAPIKey(t, r, admin, `{"name":"old","scopes":["threats:read"],"expires_at":"2999-01-01T00:00:00Z"}`)
+	stored, _ = store.GetAPIKey(context.Background(), id)
+	past := stored.CreatedAt.Add(-1)
+	stored.ExpiresAt = &past
+	store.SaveAPIKey(context.Background(), stored)
+	if w := doAPIKey(r, key, "10.1.2.3", http.MethodGet, "/sentinel/api/threats"); w.Code != http.StatusUnauthorized {
+		t.Errorf("expired key: expected 401, got %d", w.Code)
+	}
+}
+
+func TestAPIKeysAdminRoutesNeedAdminScope(t *testing.T) {
+	r, _ := newAccountsTestServer(t)
+	admin := login(t, r, "admin", "builtin-pass")
+
+	_, soar := createAPIKey(t, r, admin, `{"name":"soar","scopes":["ip:write","config:write","waf:write","campaigns:write"]}`)
+	_, ipAdmin := createAPIKey(t, r, admin, `{"name":"ops","scopes":["ip:admin"]}`)
+
+	cases := []struct {
+		name, key, method, path string
+		want                    int
+	}{
+		{"unblock an IP", soar, http.MethodDelete, "/sentinel/api/ip/block/1.2.3.4", http.StatusForbidden},
+		{"whitelist an IP", soar, http.MethodPost, "/sentinel/api/ip/whitelist", http.StatusForbidden},
+		{"unwhitelist an IP", soar, http.MethodDelete, "/sentinel/api/ip/whitelist/1.2.3.4", http.StatusForbidden},
+		{"roll back config", soar, http.MethodPost, "/sentinel/api/config/versions/1/rollback", http.StatusForbidden},
+		{"replace WAF rules", soar, http.MethodPut, "/sentinel/api/waf/rules", http.StatusForbidden},
+		{"unblock a campaign", soar, http.MethodDelete, "/sentinel/api/campaigns/c1/block", http.StatusForbidden},
+		{"admin scope on an admin route", ipAdmin, http.MethodDelete, "/sentinel/api/ip/block/1.2.3.4", http.StatusOK},
+		{"admin implies write", ipAdmin, http.MethodPost, "/sentinel/api/ip/block", http.StatusBadRequest},
+		{"admin implies read", ipAdmin, http.MethodGet, "/sentinel/api/ip/blocked", http.StatusOK},
+		{"admin scope is per resource", ipAdmin, http.MethodPut, "/sentinel/api/waf/rules", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		if w := doAPIKey(r, tc.key, "10.1.2.3", tc.method, tc.path); w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d (%s)", tc.name, tc.want, w.Code, w.Body.String())
+		}
+	}
+}
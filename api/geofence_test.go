@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/middleware"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/MUKE-coder/sentinel/v2/storage/memory"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGeoPolicyEdits(t *testing.T) {
+	pipe := pipeline.New(100)
+	pipe.Start(1)
+	t.Cleanup(func() { pipe.Stop() })
+
+	cfg := sentinel.Config{
+		Dashboard: sentinel.DashboardConfig{Prefix: "/sentinel", Username: "admin", Password: "builtin-pass", SecretKey: "test"},
+		GeoFence:  sentinel.GeoFenceConfig{Policies: []sentinel.GeoPolicy{{ID: "sanctions", Deny: []string{"KP"}}}},
+	}
+	fence, err := middleware.NewGeoFence(cfg.GeoFence.Policies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(memory.New(), pipe, nil, nil, cfg)
+	srv.SetGeoFence(fence)
+	r := gin.New()
+	srv.RegisterRoutes(r, "/sentinel")
+	token := login(t, r, "admin", "builtin-pass")
+
+	ids := func() []string {
+		var out []string
+		for _, p := range fence.Policies() {
+			out = append(out, p.ID)
+		}
+		return out
+	}
+
+	w := doJSON(r, token, http.MethodPost, "/sentinel/api/geofence/policies",
+		`{"id":"admin","routes":["/admin/**"],"allow":["DE"],"mode":"challenge","fail_closed":true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("save policy: %d %s", w.Code, w.Body.String())
+	}
+	if got := ids(); len(got) != 2 || got[1] != "admin" {
+		t.Errorf("live policies after save: %v", got)
+	}
+
+	if w := doJSON(r, token, http.MethodPost, "/sentinel/api/geofence/policies",
+		`{"id":"bad","allow":["Germany"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid policy: expected 400, got %d", w.Code)
+	}
+
+	// Deleting a code-supplied policy records a tombstone, so it stays gone.
+	if w := doJSON(r, token, http.MethodDelete, "/sentinel/api/geofence/policies/sanctions", ""); w.Code != http.StatusOK {
+		t.Fatalf("delete policy: %d %s", w.Code, w.Body.String())
+	}
+	if got := ids(); len(got) != 1 || got[0] != "admin" {
+		t.Errorf("live policies after delete: %v", got)
+	}
+	if w := doJSON(r, token, http.MethodDelete, "/sentinel/api/geofence/policies/sanctions", ""); w.Code != http.StatusNotFound {
+		t.Errorf("second delete: expected 404, got %d", w.Code)
+	}
+
+	w = doJSON(r, token, http.MethodGet, "/sentinel/api/geofence/check?ip=192.168.1.1&path=/admin/users", "")
+	var res struct {
+		Data struct {
+			Allowed bool                `json:"allowed"`
+			Policy  *sentinel.GeoPolicy `json:"policy"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("check: %d %s", w.Code, w.Body.String())
+	}
+	if res.Data.Allowed || res.Data.Policy == nil || res.Data.Policy.ID != "admin" {
+		t.Errorf("unlocated client on a fail-closed route: %s", w.Body.String())
+	}
+}
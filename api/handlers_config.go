@@ -0,0 +1,229 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/gin-gonic/gin"
+)
+
+// errConfigNotFound is returned by an edit func to answer 404 instead of 400.
+var errConfigNotFound = errors.New("not found")
+
+// --- Persisted config edits ---
+
+// updateConfig runs one dashboard edit: it applies edit to a copy of the
+// latest persisted overrides, saves the result as a new ConfigVersion and
+// then pushes the new effective config to the live components. Nothing is
+// saved or applied when edit fails. It writes the error response itself
+// and reports whether the edit went through.
+func (s *Server) updateConfig(c *gin.Context, change string, edit func(o *sentinel.ConfigOverrides) error) bool {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+
+	ctx := c.Request.Context()
+	latest, err := s.store.LatestConfigVersion(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load config", "code": "INTERNAL_ERROR"})
+		return false
+	}
+	var overrides sentinel.ConfigOverrides
+	if latest != nil {
+		overrides = latest.Overrides.Clone()
+	}
+	if err := edit(&overrides); err != nil {
+		if errors.Is(err, errConfigNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": "NOT_FOUND"})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "BAD_REQUEST"})
+		}
+		return false
+	}
+	return s.commitConfig(c, change, overrides)
+}
+
+// commitConfig saves overrides as a new version and applies them. The
+// caller holds configMu.
+func (s *Server) commitConfig(c *gin.Context, change string, overrides sentinel.ConfigOverrides) bool {
+	cfg := s.baseConfig
+	overrides.Apply(&cfg)
+
+	// Compile the rules before saving so a version that cannot be applied
+	// is never persisted.
+	if s.customRuleEngine != nil {
+		if err := s.customRuleEngine.ReplaceRules(cfg.WAF.CustomRules); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid custom rule: " + err.Error(), "code": "BAD_REQUEST"})
+			return false
+		}
+	}
+	if s.geoFence != nil {
+		if err := s.geoFence.SetPolicies(cfg.GeoFence.Policies); err != nil {
+			if s.customRuleEngine != nil {
+				s.customRuleEngine.ReplaceRules(s.config.WAF.CustomRules)
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid geofence policy: " + err.Error(), "code": "BAD_REQUEST"})
+			return false
+		}
+	}
+
+	v := &sentinel.ConfigVersion{
+		Author:    currentSubject(c),
+		Change:    change,
+		Overrides: overrides,
+	}
+	if err := s.store.SaveConfigVersion(c.Request.Context(), v); err != nil {
+		// Put the engine back in step with s.config.
+		if s.customRuleEngine != nil {
+			s.customRuleEngine.ReplaceRules(s.config.WAF.CustomRules)
+		}
+		if s.geoFence != nil {
+			s.geoFence.SetPolicies(s.config.GeoFence.Policies)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save config", "code": "INTERNAL_ERROR"})
+		return false
+	}
+
+	if s.wafSettings != nil {
+		s.wafSettings.SetMode(cfg.WAF.Mode)
+	}
+	if s.rateLimiter != nil {
+		s.rateLimiter.SetRouteLimits(cfg.RateLimit.ByRoute)
+	}
+	if s.alertDispatch != nil {
+		s.alertDispatch.SetMinSeverity(cfg.Alerts.MinSeverity)
+	}
+	s.config.WAF.Mode = cfg.WAF.Mode
+	s.config.WAF.Rules = cfg.WAF.Rules
+	s.config.WAF.CustomRules = cfg.WAF.CustomRules
+	s.config.RateLimit.ByRoute = cfg.RateLimit.ByRoute
+	s.config.Alerts.MinSeverity = cfg.Alerts.MinSeverity
+	s.config.GeoFence.Policies = cfg.GeoFence.Policies
+
+	c.Set("config_version", v.Version)
+	return true
+}
+
+// baseHasGeoPolicy reports whether the code-supplied config defines id.
+func (s *Server) baseHasGeoPolicy(id string) bool {
+	for _, p := range s.baseConfig.GeoFence.Policies {
+		if p.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// baseHasCustomRule reports whether the code-supplied config defines id.
+func (s *Server) baseHasCustomRule(id string) bool {
+	for _, r := range s.baseConfig.WAF.CustomRules {
+		if r.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(list []string, v string) []string {
+	out := list[:0]
+	for _, s := range list {
+		if s != v {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func validWAFMode(m sentinel.WAFMode) bool {
+	switch m {
+	case sentinel.ModeLog, sentinel.ModeBlock, sentinel.ModeChallenge:
+		return true
+	}
+	return false
+}
+
+func validSensitivity(r sentinel.RuleSensitivity) bool {
+	switch r {
+	case "", sentinel.RuleOff, sentinel.RuleLow, sentinel.RuleMedium, sentinel.RuleStrict:
+		return true
+	}
+	return false
+}
+
+func validSeverity(sev sentinel.Severity) bool {
+	switch sev {
+	case sentinel.SeverityLow, sentinel.SeverityMedium, sentinel.SeverityHigh, sentinel.SeverityCritical:
+		return true
+	}
+	return false
+}
+
+// --- Config version handlers ---
+
+func (s *Server) handleListConfigVersions(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	versions, err := s.store.ListConfigVersions(c.Request.Context(), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list config versions", "code": "INTERNAL_ERROR"})
+		return
+	}
+	if versions == nil {
+		versions = []*sentinel.ConfigVersion{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": versions})
+}
+
+func (s *Server) handleGetConfigVersion(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version", "code": "BAD_REQUEST"})
+		return
+	}
+	v, err := s.store.GetConfigVersion(c.Request.Context(), version)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load config version", "code": "INTERNAL_ERROR"})
+		return
+	}
+	if v == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Config version not found", "code": "NOT_FOUND"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": v})
+}
+
+// handleRollbackConfig restores an earlier version by saving its overrides
+// as a new version. Version 0 discards every override and returns to the
+// code-supplied config.
+func (s *Server) handleRollbackConfig(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version", "code": "BAD_REQUEST"})
+		return
+	}
+
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+
+	var overrides sentinel.ConfigOverrides
+	if version > 0 {
+		v, err := s.store.GetConfigVersion(c.Request.Context(), version)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load config version", "code": "INTERNAL_ERROR"})
+			return
+		}
+		if v == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Config version not found", "code": "NOT_FOUND"})
+			return
+		}
+		overrides = v.Overrides.Clone()
+	}
+	if !s.commitConfig(c, fmt.Sprintf("rollback to version %d", version), overrides) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Config rolled back", "version": c.GetInt("config_version")})
+}
@@ -1,12 +1,19 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/MUKE-coder/sentinel/v2/ai"
 	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/detection"
+	"github.com/MUKE-coder/sentinel/v2/intelligence"
+	"github.com/MUKE-coder/sentinel/v2/middleware"
 	"github.com/gin-gonic/gin"
 )
 
@@ -162,6 +169,19 @@ func (s *Server) handleTopTargets(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": targets})
 }
 
+func (s *Server) handleBotStats(c *gin.Context) {
+	if s.bots == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bot detection not enabled", "code": "NOT_FOUND"})
+		return
+	}
+	window, err := time.ParseDuration(c.DefaultQuery("window", "24h"))
+	if err != nil {
+		window = 24 * time.Hour
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": s.bots.Stats(window)})
+}
+
 // --- IP Reputation handler ---
 
 func (s *Server) handleIPReputation(c *gin.Context) {
@@ -184,12 +204,98 @@ func (s *Server) handleIPReputation(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": result})
 }
 
+// handleIPStatus reports whether ip is blocked or whitelisted, the entry
+// deciding it, and which threat feed lists it.
+func (s *Server) handleIPStatus(c *gin.Context) {
+	ip := c.Param("ip")
+	if s.ipManager == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "IP manager not available", "code": "NOT_FOUND"})
+		return
+	}
+	status := gin.H{
+		"ip":          ip,
+		"blocked":     s.ipManager.IsBlocked(ip),
+		"whitelisted": s.ipManager.IsWhitelisted(ip),
+	}
+	if b := s.ipManager.BlockedBy(ip); b != nil {
+		status["blocked_by"] = b
+	}
+	if w := s.ipManager.WhitelistedBy(ip); w != nil {
+		status["whitelisted_by"] = w
+	}
+	if s.feeds != nil {
+		if match := s.feeds.Lookup(ip); match != nil {
+			status["feed"] = match
+		}
+	}
+	if s.networks != nil {
+		if match := s.networks.Lookup(ip); match != nil {
+			status["network"] = match
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": status})
+}
+
+// --- Threat feed handlers ---
+
+func (s *Server) handleListFeeds(c *gin.Context) {
+	if s.feeds == nil {
+		c.JSON(http.StatusOK, gin.H{"data": []sentinel.FeedStats{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": s.feeds.Stats()})
+}
+
+func (s *Server) handleRefreshFeed(c *gin.Context) {
+	name := c.Param("name")
+	c.Set(ctxAuditResourceID, name)
+	if s.feeds == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Threat feed not found", "code": "NOT_FOUND"})
+		return
+	}
+	err := s.feeds.Refresh(c.Request.Context(), name)
+	if errors.Is(err, intelligence.ErrUnknownFeed) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Threat feed not found", "code": "NOT_FOUND"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "code": "BAD_GATEWAY"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Threat feed refreshed"})
+}
+
+// --- Network type handlers ---
+
+func (s *Server) handleListNetworkLists(c *gin.Context) {
+	if s.networks == nil {
+		c.JSON(http.StatusOK, gin.H{"data": []sentinel.NetworkListStats{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": s.networks.Stats()})
+}
+
+func (s *Server) handleReloadNetworkLists(c *gin.Context) {
+	if s.networks == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Network type detection not enabled", "code": "NOT_FOUND"})
+		return
+	}
+	if err := s.networks.Reload(); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "code": "BAD_GATEWAY"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Network lists reloaded"})
+}
+
 // --- Alert handlers ---
 
 func (s *Server) handleGetAlertConfig(c *gin.Context) {
+	s.configMu.RLock()
+	minSeverity := s.config.Alerts.MinSeverity
+	s.configMu.RUnlock()
 	c.JSON(http.StatusOK, gin.H{
 		"data": gin.H{
-			"min_severity": s.config.Alerts.MinSeverity,
+			"min_severity": minSeverity,
 			"slack": gin.H{
 				"enabled":     s.config.Alerts.Slack != nil && s.config.Alerts.Slack.WebhookURL != "",
 				"webhook_url": maskURL(s.config.Alerts.Slack),
@@ -207,7 +313,6 @@ func (s *Server) handleGetAlertConfig(c *gin.Context) {
 }
 
 func (s *Server) handleUpdateAlertConfig(c *gin.Context) {
-	// Alert config updates are in-memory only (restart resets to config file values)
 	var req struct {
 		MinSeverity string `json:"min_severity"`
 	}
@@ -217,7 +322,17 @@ func (s *Server) handleUpdateAlertConfig(c *gin.Context) {
 	}
 
 	if req.MinSeverity != "" {
-		s.config.Alerts.MinSeverity = sentinel.Severity(req.MinSeverity)
+		sev := sentinel.Severity(req.MinSeverity)
+		ok := s.updateConfig(c, "set alert min severity to "+req.MinSeverity, func(o *sentinel.ConfigOverrides) error {
+			if !validSeverity(sev) {
+				return fmt.Errorf("invalid min_severity %q", req.MinSeverity)
+			}
+			o.AlertMinSeverity = sev
+			return nil
+		})
+		if !ok {
+			return
+		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Alert config updated"})
@@ -278,6 +393,176 @@ func (s *Server) handleUnblockUser(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "User unblocked", "username": username})
 }
 
+// --- Baseline handlers ---
+
+func (s *Server) handleGetUserBaseline(c *gin.Context) {
+	if s.anomalyDetector == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Anomaly detection not enabled", "code": "NOT_FOUND"})
+		return
+	}
+	bl, err := s.anomalyDetector.Baseline(c.Request.Context(), c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "code": "INTERNAL_ERROR"})
+		return
+	}
+	if bl == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No baseline for this user", "code": "NOT_FOUND"})
+		return
+	}
+	learning, until := s.anomalyDetector.LearningStatus(bl)
+	meta := gin.H{"learning": learning}
+	if !until.IsZero() {
+		meta["learning_until"] = until
+	}
+	c.JSON(http.StatusOK, gin.H{"data": bl, "meta": meta})
+}
+
+func (s *Server) handleResetUserBaseline(c *gin.Context) {
+	userID := c.Param("user_id")
+	c.Set(ctxAuditResourceID, userID)
+	if s.anomalyDetector == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Anomaly detection not enabled", "code": "NOT_FOUND"})
+		return
+	}
+	existed, err := s.anomalyDetector.ResetBaseline(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "code": "INTERNAL_ERROR"})
+		return
+	}
+	if !existed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No baseline for this user", "code": "NOT_FOUND"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Baseline reset", "user_id": userID})
+}
+
+// --- Session risk handlers ---
+
+func (s *Server) handleListSessions(c *gin.Context) {
+	if s.sessionRisk == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session risk scoring not enabled", "code": "NOT_FOUND"})
+		return
+	}
+	sessions := s.sessionRisk.Sessions(c.Query("user_id"))
+	if c.Query("flagged") == "true" {
+		flagged := sessions[:0]
+		for _, r := range sessions {
+			if r.Flagged {
+				flagged = append(flagged, r)
+			}
+		}
+		sessions = flagged
+	}
+	c.JSON(http.StatusOK, gin.H{"data": sessions, "meta": gin.H{"total": len(sessions)}})
+}
+
+// --- Campaign handlers ---
+
+func (s *Server) handleListCampaigns(c *gin.Context) {
+	if s.campaigns == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Campaign clustering not enabled", "code": "NOT_FOUND"})
+		return
+	}
+	filter := sentinel.CampaignFilter{
+		Status: sentinel.CampaignStatus(c.Query("status")),
+	}
+	filter.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filter.PageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	filter.MinRisk, _ = strconv.Atoi(c.Query("min_risk"))
+
+	campaigns, total, err := s.store.ListCampaigns(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "code": "INTERNAL_ERROR"})
+		return
+	}
+	if campaigns == nil {
+		campaigns = []*sentinel.Campaign{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": campaigns,
+		"meta": gin.H{
+			"total":     total,
+			"page":      filter.Page,
+			"page_size": filter.PageSize,
+		},
+	})
+}
+
+func (s *Server) handleGetCampaign(c *gin.Context) {
+	if s.campaigns == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Campaign clustering not enabled", "code": "NOT_FOUND"})
+		return
+	}
+	campaign, err := s.store.GetCampaign(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "code": "INTERNAL_ERROR"})
+		return
+	}
+	if campaign == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Campaign not found", "code": "NOT_FOUND"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": campaign})
+}
+
+// handleBlockCampaign blocks every actor of a campaign and those that join
+// it later. Like handleBlockActor, the block expires after
+// defaultBlockDuration unless the body asks for a permanent one.
+func (s *Server) handleBlockCampaign(c *gin.Context) {
+	if s.campaigns == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Campaign clustering not enabled", "code": "NOT_FOUND"})
+		return
+	}
+	var req struct {
+		Reason    string `json:"reason"`
+		Permanent bool   `json:"permanent,omitempty"`
+	}
+	_ = c.ShouldBindJSON(&req) // body is optional; ignore absence
+	if req.Reason == "" {
+		req.Reason = "Blocked via dashboard"
+	}
+
+	var expiry *time.Time
+	if !req.Permanent {
+		t := time.Now().Add(defaultBlockDuration)
+		expiry = &t
+	}
+
+	campaign, err := s.campaigns.Block(c.Request.Context(), c.Param("id"), req.Reason, expiry)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "code": "INTERNAL_ERROR"})
+		return
+	}
+	if campaign == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Campaign not found", "code": "NOT_FOUND"})
+		return
+	}
+
+	resp := gin.H{"message": "Campaign blocked", "data": campaign}
+	if expiry != nil {
+		resp["expires_at"] = expiry.Format(time.RFC3339)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) handleUnblockCampaign(c *gin.Context) {
+	if s.campaigns == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Campaign clustering not enabled", "code": "NOT_FOUND"})
+		return
+	}
+	campaign, err := s.campaigns.Unblock(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "code": "INTERNAL_ERROR"})
+		return
+	}
+	if campaign == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Campaign not found", "code": "NOT_FOUND"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Campaign unblocked", "data": campaign})
+}
+
 // --- Audit Log handlers ---
 
 func (s *Server) handleListAuditLogs(c *gin.Context) {
@@ -362,10 +647,13 @@ func (s *Server) handleSOC2Report(c *gin.Context) {
 // --- WAF Rules handlers ---
 
 func (s *Server) handleGetWAFRules(c *gin.Context) {
+	s.configMu.RLock()
+	mode, rules := s.config.WAF.Mode, s.config.WAF.Rules
+	s.configMu.RUnlock()
 	c.JSON(http.StatusOK, gin.H{
 		"data": gin.H{
-			"mode":  s.config.WAF.Mode,
-			"rules": s.config.WAF.Rules,
+			"mode":  mode,
+			"rules": rules,
 		},
 	})
 }
@@ -380,10 +668,30 @@ func (s *Server) handleUpdateWAFRules(c *gin.Context) {
 		return
 	}
 
-	if req.Mode != "" {
-		s.config.WAF.Mode = sentinel.WAFMode(req.Mode)
+	ok := s.updateConfig(c, "update WAF rules", func(o *sentinel.ConfigOverrides) error {
+		if req.Mode != "" {
+			mode := sentinel.WAFMode(req.Mode)
+			if !validWAFMode(mode) {
+				return fmt.Errorf("invalid mode %q", req.Mode)
+			}
+			o.WAFMode = mode
+		}
+		for _, r := range []sentinel.RuleSensitivity{
+			req.Rules.SQLInjection, req.Rules.XSS, req.Rules.PathTraversal,
+			req.Rules.CommandInjection, req.Rules.SSRF, req.Rules.XXE,
+			req.Rules.LFI, req.Rules.OpenRedirect,
+		} {
+			if !validSensitivity(r) {
+				return fmt.Errorf("invalid rule sensitivity %q", r)
+			}
+		}
+		rules := req.Rules
+		o.WAFRules = &rules
+		return nil
+	})
+	if !ok {
+		return
 	}
-	s.config.WAF.Rules = req.Rules
 
 	c.JSON(http.StatusOK, gin.H{"message": "WAF rules updated"})
 }
@@ -403,8 +711,9 @@ func (s *Server) handleAddCustomRule(c *gin.Context) {
 		return
 	}
 
-	if rule.ID == "" || rule.Pattern == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID and pattern are required", "code": "BAD_REQUEST"})
+	c.Set(ctxAuditResourceID, rule.ID)
+	if rule.ID == "" || (rule.Pattern == "" && rule.When == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID and a pattern or when condition are required", "code": "BAD_REQUEST"})
 		return
 	}
 
@@ -413,8 +722,15 @@ func (s *Server) handleAddCustomRule(c *gin.Context) {
 		return
 	}
 
-	if err := s.customRuleEngine.AddRule(rule); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid regex pattern: " + err.Error(), "code": "BAD_REQUEST"})
+	ok := s.updateConfig(c, "add custom rule "+rule.ID, func(o *sentinel.ConfigOverrides) error {
+		if o.CustomRules == nil {
+			o.CustomRules = make(map[string]sentinel.WAFRule)
+		}
+		o.CustomRules[rule.ID] = rule
+		o.DeletedCustomRules = removeString(o.DeletedCustomRules, rule.ID)
+		return nil
+	})
+	if !ok {
 		return
 	}
 
@@ -428,8 +744,17 @@ func (s *Server) handleDeleteCustomRule(c *gin.Context) {
 		return
 	}
 
-	if !s.customRuleEngine.RemoveRule(id) {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found", "code": "NOT_FOUND"})
+	ok := s.updateConfig(c, "delete custom rule "+id, func(o *sentinel.ConfigOverrides) error {
+		if _, exists := s.customRuleEngine.GetRule(id); !exists {
+			return fmt.Errorf("rule %w", errConfigNotFound)
+		}
+		delete(o.CustomRules, id)
+		if s.baseHasCustomRule(id) {
+			o.DeletedCustomRules = append(o.DeletedCustomRules, id)
+		}
+		return nil
+	})
+	if !ok {
 		return
 	}
 
@@ -437,41 +762,204 @@ func (s *Server) handleDeleteCustomRule(c *gin.Context) {
 }
 
 func (s *Server) handleTestWAFPayload(c *gin.Context) {
+	// A bare payload is scanned as path, query and body at once. A full
+	// request is evaluated as the WAF would see it, so rules with When
+	// conditions can be tried out.
 	var req struct {
 		Payload string `json:"payload"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil || req.Payload == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Payload is required", "code": "BAD_REQUEST"})
+		Request *struct {
+			Method   string              `json:"method"`
+			Path     string              `json:"path"`
+			RawQuery string              `json:"query"`
+			Headers  map[string][]string `json:"headers"`
+			Body     string              `json:"body"`
+			IP       string              `json:"ip"`
+			Country  string              `json:"country"`
+		} `json:"request"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Payload == "" && req.Request == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payload or request is required", "code": "BAD_REQUEST"})
 		return
 	}
 
-	var matches []gin.H
+	var found []detection.ThreatMatch
 	if s.customRuleEngine != nil {
-		for _, m := range s.customRuleEngine.TestPayload(req.Payload) {
-			matches = append(matches, gin.H{
-				"pattern":    m.PatternName,
-				"threat_type": string(m.ThreatType),
-				"matched":   m.Matched,
-				"location":  m.Location,
-				"severity":  string(m.BaseSeverity),
-				"confidence": m.BaseConfidence,
+		if r := req.Request; r != nil {
+			headers := http.Header{}
+			for name, values := range r.Headers {
+				for _, v := range values {
+					headers.Add(name, v)
+				}
+			}
+			found = s.customRuleEngine.TestRequest(sentinel.InspectedRequest{
+				Method:    strings.ToUpper(r.Method),
+				Path:      r.Path,
+				RawQuery:  r.RawQuery,
+				Headers:   headers,
+				Body:      r.Body,
+				IP:        r.IP,
+				UserAgent: headers.Get("User-Agent"),
+				BodySize:  int64(len(r.Body)),
+				Country:   r.Country,
 			})
+		} else {
+			found = s.customRuleEngine.TestPayload(req.Payload)
 		}
 	}
+	// Under anomaly scoring, report the points each match would add.
+	scoring := s.config.WAF.Scoring
+	var total int
+	var points []int
+	if scoring != nil {
+		total, points = detection.AnomalyScore(found, scoring.SeverityPoints)
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": gin.H{
-			"payload":     req.Payload,
-			"matches":     matches,
-			"match_count": len(matches),
-		},
+	var matches []gin.H
+	for i, m := range found {
+		match := gin.H{
+			"pattern":    m.PatternName,
+			"threat_type": string(m.ThreatType),
+			"matched":   m.Matched,
+			"location":  m.Location,
+			"severity":  string(m.BaseSeverity),
+			"confidence": m.BaseConfidence,
+		}
+		if scoring != nil {
+			match["score"] = points[i]
+		}
+		if len(m.Conditions) > 0 {
+			match["conditions"] = m.Conditions
+		}
+		matches = append(matches, match)
+	}
+
+	data := gin.H{
+		"matches":     matches,
+		"match_count": len(matches),
+	}
+	if req.Request != nil {
+		data["request"] = req.Request
+	} else {
+		data["payload"] = req.Payload
+	}
+	if scoring != nil {
+		data["anomaly_score"] = total
+		// A request gets its route's threshold, as at runtime; a bare
+		// payload has no route and gets the inbound threshold.
+		threshold := scoring.InboundThreshold
+		if req.Request != nil && req.Request.Path != "" {
+			threshold = middleware.AnomalyThreshold(scoring, req.Request.Path)
+		}
+		data["anomaly_threshold"] = threshold
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// --- Geofencing handlers ---
+
+func (s *Server) handleListGeoPolicies(c *gin.Context) {
+	s.configMu.RLock()
+	policies := s.config.GeoFence.Policies
+	s.configMu.RUnlock()
+	if policies == nil {
+		policies = []sentinel.GeoPolicy{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": policies})
+}
+
+// handleSaveGeoPolicy adds a policy, or replaces the one with the same ID.
+func (s *Server) handleSaveGeoPolicy(c *gin.Context) {
+	var policy sentinel.GeoPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "code": "BAD_REQUEST"})
+		return
+	}
+
+	c.Set(ctxAuditResourceID, policy.ID)
+	if err := middleware.ValidateGeoPolicy(policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "BAD_REQUEST"})
+		return
+	}
+	if s.geoFence == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Geofencing not initialized", "code": "INTERNAL_ERROR"})
+		return
+	}
+
+	ok := s.updateConfig(c, "save geofence policy "+policy.ID, func(o *sentinel.ConfigOverrides) error {
+		if o.GeoPolicies == nil {
+			o.GeoPolicies = make(map[string]sentinel.GeoPolicy)
+		}
+		o.GeoPolicies[policy.ID] = policy
+		o.DeletedGeoPolicies = removeString(o.DeletedGeoPolicies, policy.ID)
+		return nil
 	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Geofence policy saved", "policy": policy})
+}
+
+func (s *Server) handleDeleteGeoPolicy(c *gin.Context) {
+	id := c.Param("id")
+	if s.geoFence == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Geofencing not initialized", "code": "NOT_FOUND"})
+		return
+	}
+
+	ok := s.updateConfig(c, "delete geofence policy "+id, func(o *sentinel.ConfigOverrides) error {
+		found := false
+		for _, p := range s.geoFence.Policies() {
+			found = found || p.ID == id
+		}
+		if !found {
+			return fmt.Errorf("geofence policy %w", errConfigNotFound)
+		}
+		delete(o.GeoPolicies, id)
+		if s.baseHasGeoPolicy(id) {
+			o.DeletedGeoPolicies = append(o.DeletedGeoPolicies, id)
+		}
+		return nil
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Geofence policy deleted"})
+}
+
+// handleCheckGeoFence reports how the current policies treat a client IP on
+// a path, so a policy can be tried out before it locks anyone out.
+func (s *Server) handleCheckGeoFence(c *gin.Context) {
+	ip, path := c.Query("ip"), c.DefaultQuery("path", "/")
+	if net.ParseIP(ip) == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A valid ip query parameter is required", "code": "BAD_REQUEST"})
+		return
+	}
+
+	var location *sentinel.GeoResult
+	if s.geoLocator != nil {
+		location, _ = s.geoLocator.LookupIP(c.Request.Context(), ip)
+	}
+	var policy *sentinel.GeoPolicy
+	if s.geoFence != nil {
+		policy = s.geoFence.Check(path, location)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"ip":       ip,
+		"path":     path,
+		"location": location,
+		"allowed":  policy == nil || policy.Mode == sentinel.ModeLog,
+		"policy":   policy,
+	}})
 }
 
 // --- Rate Limit handlers ---
 
 func (s *Server) handleGetRateLimits(c *gin.Context) {
+	s.configMu.RLock()
 	cfg := s.config.RateLimit
+	s.configMu.RUnlock()
 	data := gin.H{
 		"enabled":  cfg.Enabled,
 		"strategy": cfg.Strategy,
@@ -482,6 +970,26 @@ func (s *Server) handleGetRateLimits(c *gin.Context) {
 	if cfg.ByUser != nil {
 		data["by_user"] = gin.H{"requests": cfg.ByUser.Requests, "window": cfg.ByUser.Window.String()}
 	}
+	if len(cfg.ByBot) > 0 {
+		byBot := make(map[sentinel.BotVerdict]gin.H)
+		for verdict, limit := range cfg.ByBot {
+			byBot[verdict] = gin.H{"requests": limit.Requests, "window": limit.Window.String()}
+		}
+		data["by_bot"] = byBot
+	}
+	if len(cfg.ExemptBots) > 0 {
+		data["exempt_bots"] = cfg.ExemptBots
+	}
+	if len(cfg.ByNetwork) > 0 {
+		byNetwork := make(map[sentinel.NetworkType]gin.H)
+		for network, limit := range cfg.ByNetwork {
+			byNetwork[network] = gin.H{"requests": limit.Requests, "window": limit.Window.String()}
+		}
+		data["by_network"] = byNetwork
+	}
+	if cfg.ByFingerprint != nil {
+		data["by_fingerprint"] = gin.H{"requests": cfg.ByFingerprint.Requests, "window": cfg.ByFingerprint.Window.String()}
+	}
 	if cfg.Global != nil {
 		data["global"] = gin.H{"requests": cfg.Global.Requests, "window": cfg.Global.Window.String()}
 	}
@@ -507,23 +1015,30 @@ func (s *Server) handleUpdateRateLimits(c *gin.Context) {
 		return
 	}
 
-	if req.ByRoute != nil {
-		if s.config.RateLimit.ByRoute == nil {
-			s.config.RateLimit.ByRoute = make(map[string]sentinel.Limit)
-		}
-		for route, limit := range req.ByRoute {
-			window, err := time.ParseDuration(limit.Window)
-			if err != nil {
-				continue
-			}
-			if limit.Requests <= 0 {
-				delete(s.config.RateLimit.ByRoute, route)
-			} else {
-				s.config.RateLimit.ByRoute[route] = sentinel.Limit{
-					Requests: limit.Requests,
-					Window:   window,
+	if len(req.ByRoute) > 0 {
+		ok := s.updateConfig(c, "update route rate limits", func(o *sentinel.ConfigOverrides) error {
+			for route, limit := range req.ByRoute {
+				if limit.Requests <= 0 {
+					delete(o.RouteLimits, route)
+					if _, inBase := s.baseConfig.RateLimit.ByRoute[route]; inBase {
+						o.DeletedRouteLimits = append(removeString(o.DeletedRouteLimits, route), route)
+					}
+					continue
+				}
+				window, err := time.ParseDuration(limit.Window)
+				if err != nil || window <= 0 {
+					return fmt.Errorf("invalid window %q for route %s", limit.Window, route)
+				}
+				if o.RouteLimits == nil {
+					o.RouteLimits = make(map[string]sentinel.Limit)
 				}
+				o.RouteLimits[route] = sentinel.Limit{Requests: limit.Requests, Window: window}
+				o.DeletedRouteLimits = removeString(o.DeletedRouteLimits, route)
 			}
+			return nil
+		})
+		if !ok {
+			return
 		}
 	}
 
@@ -535,7 +1050,11 @@ func (s *Server) handleGetRateLimitStates(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"data": []interface{}{}})
 		return
 	}
-	states := s.rateLimiter.GetCurrentStates()
+	states, err := s.rateLimiter.States(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read rate limit states", "code": "INTERNAL_ERROR"})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"data": states})
 }
 
@@ -545,7 +1064,12 @@ func (s *Server) handleResetRateLimit(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"message": "Rate limiter not configured"})
 		return
 	}
-	if s.rateLimiter.ResetKey(key) {
+	existed, err := s.rateLimiter.Reset(c.Request.Context(), key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset rate limit", "code": "INTERNAL_ERROR"})
+		return
+	}
+	if existed {
 		c.JSON(http.StatusOK, gin.H{"message": "Rate limit reset", "key": key})
 	} else {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Key not found", "code": "NOT_FOUND"})
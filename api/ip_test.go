@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/intelligence"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/MUKE-coder/sentinel/v2/storage/memory"
+	"github.com/gin-gonic/gin"
+)
+
+func TestASNEntriesNeedLocalDatabase(t *testing.T) {
+	pipe := pipeline.New(100)
+	pipe.Start(1)
+	t.Cleanup(func() { pipe.Stop() })
+
+	srv := NewServer(memory.New(), pipe, nil, nil, sentinel.Config{Dashboard: sentinel.DashboardConfig{
+		Prefix: "/sentinel", Username: "admin", Password: "builtin-pass", SecretKey: "test",
+	}})
+	// ip-api.com is never asked for ASNs, so ASN entries could not match.
+	srv.SetGeoLocator(intelligence.NewGeoLocator(sentinel.GeoConfig{Enabled: true, Provider: sentinel.GeoIPAPI}))
+	r := gin.New()
+	srv.RegisterRoutes(r, "/sentinel")
+	token := login(t, r, "admin", "builtin-pass")
+
+	for _, tc := range []struct {
+		path, body string
+		want       int
+	}{
+		{"/sentinel/api/ip/block", `{"ip":"AS64496","permanent":true}`, http.StatusBadRequest},
+		{"/sentinel/api/ip/whitelist", `{"ip":"AS64496"}`, http.StatusBadRequest},
+		{"/sentinel/api/ip/block", `{"ip":"192.0.2.0/24","permanent":true}`, http.StatusOK},
+	} {
+		if w := doJSON(r, token, http.MethodPost, tc.path, tc.body); w.Code != tc.want {
+			t.Errorf("%s %s: expected %d, got %d (%s)", tc.path, tc.body, tc.want, w.Code, w.Body.String())
+		}
+	}
+}
@@ -1,17 +1,37 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
 
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// AuthMiddleware creates JWT authentication middleware for API routes.
-func AuthMiddleware(secretKey string) gin.HandlerFunc {
+// Context keys set by AuthMiddleware for the authenticated dashboard user.
+const (
+	ctxSubject      = "sentinel_subject"
+	ctxRole         = "sentinel_role"
+	ctxAccountID    = "sentinel_account_id"
+	ctxAPIKeyID     = "sentinel_api_key_id"
+	ctxAPIKeyScopes = "sentinel_api_key_scopes"
+)
+
+// tokenTTL is how long a dashboard token stays valid.
+const tokenTTL = 24 * time.Hour
+
+// AuthMiddleware creates JWT authentication middleware for API routes. It
+// stores the token's subject and role on the context for the role checks
+// and audit entries further down the chain.
+//
+// When a verifier is passed, "Authorization: ApiKey <key>" is accepted as
+// well. The key's read or write scope is checked here against the route;
+// RequireRole checks the admin scope on admin-only routes.
+func AuthMiddleware(secretKey string, keys ...APIKeyVerifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -23,6 +43,10 @@ func AuthMiddleware(secretKey string) gin.HandlerFunc {
 		}
 
 		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) == 2 && parts[0] == "ApiKey" && len(keys) > 0 {
+			authenticateAPIKey(c, keys[0], parts[1])
+			return
+		}
 		if len(parts) != 2 || parts[0] != "Bearer" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error": "Invalid authorization header format",
@@ -31,15 +55,8 @@ func AuthMiddleware(secretKey string) gin.HandlerFunc {
 			return
 		}
 
-		tokenStr := parts[1]
-		token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(secretKey), nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := parseToken(secretKey, parts[1])
+		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error": "Invalid or expired token",
 				"code":  "UNAUTHORIZED",
@@ -47,22 +64,148 @@ func AuthMiddleware(secretKey string) gin.HandlerFunc {
 			return
 		}
 
+		c.Set(ctxSubject, claims.Subject)
+		c.Set(ctxRole, claims.Role)
+		c.Set(ctxAccountID, claims.AccountID)
 		c.Next()
 	}
 }
 
-// GenerateToken creates a JWT token for dashboard access.
+// authenticateAPIKey verifies rawKey and checks its scopes against the
+// matched route before continuing the chain.
+func authenticateAPIKey(c *gin.Context, keys APIKeyVerifier, rawKey string) {
+	key, err := keys.VerifyAPIKey(c.Request.Context(), rawKey, c.ClientIP())
+	if errors.Is(err, errAPIKeyIPForbidden) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+			"code":  "FORBIDDEN",
+		})
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid, expired or revoked API key",
+			"code":  "UNAUTHORIZED",
+		})
+		return
+	}
+	resource, scope := routeScope(c)
+	if apiKeyForbidden[resource] || !scopeAllows(key.Scopes, scope) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "API key lacks the " + scope + " scope",
+			"code":  "FORBIDDEN",
+		})
+		return
+	}
+
+	c.Set(ctxSubject, "apikey:"+key.Name)
+	c.Set(ctxAPIKeyID, key.ID)
+	c.Set(ctxAPIKeyScopes, key.Scopes)
+	c.Next()
+}
+
+// tokenClaims are the dashboard session claims carried in the JWT.
+type tokenClaims struct {
+	Subject   string
+	Role      sentinel.DashboardRole
+	AccountID string // empty for the built-in DashboardConfig account
+}
+
+// parseToken validates a dashboard JWT and returns its claims. Tokens
+// without a known role — including those issued before roles existed —
+// are rejected.
+func parseToken(secretKey, tokenStr string) (tokenClaims, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return []byte(secretKey), nil
+	})
+	if err != nil || !token.Valid {
+		return tokenClaims{}, jwt.ErrTokenInvalidClaims
+	}
+	mc, _ := token.Claims.(jwt.MapClaims)
+	sub, _ := mc["sub"].(string)
+	role, _ := mc["role"].(string)
+	uid, _ := mc["uid"].(string)
+	claims := tokenClaims{Subject: sub, Role: sentinel.DashboardRole(role), AccountID: uid}
+	if claims.Subject == "" || !claims.Role.Valid() {
+		return tokenClaims{}, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
+
+// GenerateToken creates an admin JWT for the built-in dashboard account.
 func GenerateToken(secretKey string) (string, error) {
+	return GenerateUserToken(secretKey, "admin", sentinel.RoleAdmin)
+}
+
+// GenerateUserToken creates a dashboard JWT for subject with the given
+// role. accountID identifies a stored DashboardUser; leave it empty for
+// the built-in account.
+func GenerateUserToken(secretKey, subject string, role sentinel.DashboardRole, accountID ...string) (string, error) {
+	id := ""
+	if len(accountID) > 0 {
+		id = accountID[0]
+	}
+	return generateToken(secretKey, subject, role, id, tokenTTL)
+}
+
+func generateToken(secretKey, subject string, role sentinel.DashboardRole, accountID string, ttl time.Duration) (string, error) {
 	claims := jwt.MapClaims{
-		"iss": "sentinel",
-		"iat": time.Now().Unix(),
-		"exp": time.Now().Add(24 * time.Hour).Unix(),
+		"iss":  "sentinel",
+		"sub":  subject,
+		"role": string(role),
+		"iat":  time.Now().Unix(),
+		"exp":  time.Now().Add(ttl).Unix(),
+	}
+	if accountID != "" {
+		claims["uid"] = accountID
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(secretKey))
 }
 
+// RequireRole rejects requests whose authenticated role does not include
+// role. It must run after AuthMiddleware. API keys carry no role: an
+// admin-only route needs the key to have the admin scope of its resource,
+// while the write scope AuthMiddleware checked covers analyst routes.
+func RequireRole(role sentinel.DashboardRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString(ctxAPIKeyID) != "" {
+			if role == sentinel.RoleAdmin {
+				resource, _ := routeScope(c)
+				if scope := resource + ":admin"; !scopeAllows(c.GetStringSlice(ctxAPIKeyScopes), scope) {
+					c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+						"error": "API key lacks the " + scope + " scope",
+						"code":  "FORBIDDEN",
+					})
+					return
+				}
+			}
+			c.Next()
+			return
+		}
+		if !currentRole(c).Allows(role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "This action requires the " + string(role) + " role",
+				"code":  "FORBIDDEN",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+func currentSubject(c *gin.Context) string { return c.GetString(ctxSubject) }
+
+func currentRole(c *gin.Context) sentinel.DashboardRole {
+	role, _ := c.Get(ctxRole)
+	r, _ := role.(sentinel.DashboardRole)
+	return r
+}
+
 // LoginRateLimiter tracks failed login attempts per IP.
 type LoginRateLimiter struct {
 	mu       sync.RWMutex
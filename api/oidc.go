@@ -0,0 +1,491 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// oidcStateCookie carries the state, nonce and PKCE verifier between
+	// the redirect to the provider and the callback.
+	oidcStateCookie = "sentinel_oidc_state"
+	// oidcSessionCookie hands the dashboard token to the UI after the
+	// callback; the UI trades it for the token once.
+	oidcSessionCookie = "sentinel_oidc_session"
+
+	oidcFlowTTL = 10 * time.Minute
+	// oidcSubjectPrefix namespaces SSO usernames apart from local accounts.
+	oidcSubjectPrefix = "oidc:"
+	// jwksRefreshInterval bounds how often an unknown key ID triggers a
+	// JWKS refetch.
+	jwksRefreshInterval = 30 * time.Second
+)
+
+// oidcDiscovery is the subset of the provider metadata Sentinel uses.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcProvider runs the authorization-code + PKCE flow against one
+// OpenID Connect provider. Discovery and keys are fetched on first use and
+// cached; keys are refetched when a token names an unknown key ID.
+type oidcProvider struct {
+	cfg    sentinel.OIDCConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]any
+	keysAt    time.Time
+}
+
+func newOIDCProvider(cfg sentinel.OIDCConfig) *oidcProvider {
+	if cfg.SessionTTL <= 0 {
+		cfg.SessionTTL = time.Hour
+	}
+	return &oidcProvider{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (p *oidcProvider) getDiscovery() (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+	var d oidcDiscovery
+	wellKnown := strings.TrimSuffix(p.cfg.IssuerURL, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(wellKnown, &d); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if d.Issuer != p.cfg.IssuerURL && d.Issuer != strings.TrimSuffix(p.cfg.IssuerURL, "/") {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match %q", d.Issuer, p.cfg.IssuerURL)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("oidc discovery: document lacks an authorization, token or JWKS endpoint")
+	}
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+func (p *oidcProvider) getJSON(u string, v any) error {
+	resp, err := p.client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// key returns the verification key for kid, refetching the JWKS when the
+// key is unknown and the last fetch is old enough.
+func (p *oidcProvider) key(jwksURI, kid string) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if k, ok := p.keys[kid]; ok {
+		return k, nil
+	}
+	if p.keys != nil && time.Since(p.keysAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("fetch JWKS: %w", err)
+	}
+	keys := make(map[string]any, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if k, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = k
+		}
+	}
+	p.keys, p.keysAt = keys, time.Now()
+	if k, ok := keys[kid]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// jsonWebKey is an RSA or EC public key from a JWKS (RFC 7517).
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (any, error) {
+	b64 := base64.RawURLEncoding
+	switch k.Kty {
+	case "RSA":
+		n, err1 := b64.DecodeString(k.N)
+		e, err2 := b64.DecodeString(k.E)
+		if err1 != nil || err2 != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("malformed RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err1 := b64.DecodeString(k.X)
+		y, err2 := b64.DecodeString(k.Y)
+		if err1 != nil || err2 != nil {
+			return nil, errors.New("malformed EC key")
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("EC point not on curve")
+		}
+		return pub, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// exchange trades an authorization code for the provider's ID token.
+func (p *oidcProvider) exchange(d *oidcDiscovery, code, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"client_id":     {p.cfg.ClientID},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequest(http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return "", fmt.Errorf("token endpoint: %s %s %s", resp.Status, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return "", errors.New("token response has no id_token")
+	}
+	return body.IDToken, nil
+}
+
+// verify checks the ID token's signature, issuer, audience, expiry and
+// nonce, and returns its claims.
+func (p *oidcProvider) verify(d *oidcDiscovery, rawIDToken, nonce string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(d.JWKSURI, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(d.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if got, _ := claims["nonce"].(string); !hmac.Equal([]byte(got), []byte(nonce)) {
+		return nil, errors.New("nonce mismatch")
+	}
+	if aud, _ := claims.GetAudience(); len(aud) > 1 {
+		if azp, _ := claims["azp"].(string); azp != p.cfg.ClientID {
+			return nil, errors.New("azp does not name this client")
+		}
+	}
+	return claims, nil
+}
+
+// identity maps verified claims to a dashboard username and role. The
+// username is prefixed with oidcSubjectPrefix, and is empty when it would
+// come from an unverified email. The role is the highest one mapped from
+// RoleClaim, else DefaultRole.
+func (p *oidcProvider) identity(claims jwt.MapClaims) (string, sentinel.DashboardRole) {
+	username, _ := claims[p.cfg.UsernameClaim].(string)
+	if username != "" && p.cfg.UsernameClaim == "email" && !emailVerified(claims) {
+		username = ""
+	} else if username == "" {
+		username, _ = claims["sub"].(string)
+	}
+	if username != "" {
+		username = oidcSubjectPrefix + username
+	}
+
+	var values []string
+	switch v := claims[p.cfg.RoleClaim].(type) {
+	case string:
+		values = []string{v}
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	var role sentinel.DashboardRole
+	for _, v := range values {
+		if mapped, ok := p.cfg.RoleMapping[v]; ok && mapped.Valid() && !role.Allows(mapped) {
+			role = mapped
+		}
+	}
+	if role == "" {
+		role = p.cfg.DefaultRole
+	}
+	return username, role
+}
+
+// emailVerified reports whether the email_verified claim is set. Some
+// providers send it as a string.
+func emailVerified(claims jwt.MapClaims) bool {
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// --- Signed flow cookies ---
+
+type oidcFlowState struct {
+	State    string `json:"s"`
+	Nonce    string `json:"n"`
+	Verifier string `json:"v"`
+	Expires  int64  `json:"e"`
+}
+
+func randomToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// signCookie and openCookie protect flow cookies with an HMAC keyed by the
+// dashboard secret, so they cannot be forged or altered by the client.
+func signCookie(secret string, v any) string {
+	payload, _ := json.Marshal(v)
+	enc := base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte("oidc-cookie:"+secret))
+	mac.Write([]byte(enc))
+	return enc + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func openCookie(secret, raw string, v any) bool {
+	enc, sig, ok := strings.Cut(raw, ".")
+	if !ok {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte("oidc-cookie:"+secret))
+	mac.Write([]byte(enc))
+	want := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(sig), []byte(want)) {
+		return false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(enc)
+	return err == nil && json.Unmarshal(payload, v) == nil
+}
+
+func (s *Server) setOIDCCookie(c *gin.Context, name, value string, maxAge int) {
+	secure := c.Request.TLS != nil || strings.HasPrefix(s.oidc.cfg.RedirectURL, "https://")
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     s.config.Dashboard.Prefix + "/api/auth/oidc",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// --- OIDC handlers ---
+
+func (s *Server) handleAuthProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"password": s.passwordLoginEnabled(),
+		"oidc":     s.oidc != nil,
+	}})
+}
+
+func (s *Server) passwordLoginEnabled() bool {
+	return s.oidc == nil || !s.oidc.cfg.DisablePasswordLogin
+}
+
+// handleOIDCLogin redirects the browser to the provider.
+func (s *Server) handleOIDCLogin(c *gin.Context) {
+	d, err := s.oidc.getDiscovery()
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "SSO provider unavailable", "code": "BAD_GATEWAY"})
+		return
+	}
+
+	flow := oidcFlowState{
+		State:    randomToken(),
+		Nonce:    randomToken(),
+		Verifier: randomToken(),
+		Expires:  time.Now().Add(oidcFlowTTL).Unix(),
+	}
+	challenge := sha256.Sum256([]byte(flow.Verifier))
+	s.setOIDCCookie(c, oidcStateCookie, signCookie(s.config.Dashboard.SecretKey, flow), int(oidcFlowTTL.Seconds()))
+
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {s.oidc.cfg.ClientID},
+		"redirect_uri":          {s.oidc.cfg.RedirectURL},
+		"scope":                 {strings.Join(append([]string{"openid"}, s.oidc.cfg.Scopes...), " ")},
+		"state":                 {flow.State},
+		"nonce":                 {flow.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	c.Redirect(http.StatusFound, d.AuthorizationEndpoint+sep+q.Encode())
+}
+
+// handleOIDCCallback completes the flow: it checks state, redeems the code
+// with the PKCE verifier, verifies the ID token and issues a dashboard token.
+func (s *Server) handleOIDCCallback(c *gin.Context) {
+	c.Set(ctxAuditResourceID, "oidc")
+	fail := func(status int, code, msg string) {
+		c.JSON(status, gin.H{"error": msg, "code": code})
+	}
+
+	raw, err := c.Cookie(oidcStateCookie)
+	var flow oidcFlowState
+	if err != nil || !openCookie(s.config.Dashboard.SecretKey, raw, &flow) || time.Now().Unix() > flow.Expires {
+		fail(http.StatusBadRequest, "BAD_REQUEST", "SSO login expired — start again")
+		return
+	}
+	s.setOIDCCookie(c, oidcStateCookie, "", -1)
+	if !hmac.Equal([]byte(c.Query("state")), []byte(flow.State)) {
+		fail(http.StatusBadRequest, "BAD_REQUEST", "SSO state mismatch")
+		return
+	}
+	if e := c.Query("error"); e != "" {
+		fail(http.StatusUnauthorized, "UNAUTHORIZED", "SSO provider returned "+e)
+		return
+	}
+	code := c.Query("code")
+	if code == "" {
+		fail(http.StatusBadRequest, "BAD_REQUEST", "SSO callback has no code")
+		return
+	}
+
+	d, err := s.oidc.getDiscovery()
+	if err != nil {
+		fail(http.StatusBadGateway, "BAD_GATEWAY", "SSO provider unavailable")
+		return
+	}
+	idToken, err := s.oidc.exchange(d, code, flow.Verifier)
+	if err != nil {
+		fail(http.StatusBadGateway, "BAD_GATEWAY", "SSO code exchange failed")
+		return
+	}
+	claims, err := s.oidc.verify(d, idToken, flow.Nonce)
+	if err != nil {
+		fail(http.StatusUnauthorized, "UNAUTHORIZED", "SSO ID token rejected")
+		s.writeAudit(c, "", "", "LOGIN", "session")
+		return
+	}
+	username, role := s.oidc.identity(claims)
+	if username == "" {
+		fail(http.StatusForbidden, "FORBIDDEN", "Your SSO account has no verified email address")
+		s.writeAudit(c, "", "", "LOGIN", "session")
+		return
+	}
+	if !role.Valid() {
+		fail(http.StatusForbidden, "FORBIDDEN", "Your SSO account is not mapped to a dashboard role")
+		s.writeAudit(c, username, "", "LOGIN", "session")
+		return
+	}
+
+	token, err := generateToken(s.config.Dashboard.SecretKey, username, role, "", s.oidc.cfg.SessionTTL)
+	if err != nil {
+		fail(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to generate token")
+		return
+	}
+	s.setOIDCCookie(c, oidcSessionCookie, signCookie(s.config.Dashboard.SecretKey, token), 60)
+	c.Redirect(http.StatusFound, s.config.Dashboard.Prefix+"/ui/")
+	s.writeAudit(c, username, role, "LOGIN", "session")
+}
+
+// handleOIDCSession hands the token issued by the callback to the
+// dashboard UI, once.
+func (s *Server) handleOIDCSession(c *gin.Context) {
+	raw, err := c.Cookie(oidcSessionCookie)
+	var token string
+	if err != nil || !openCookie(s.config.Dashboard.SecretKey, raw, &token) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No SSO session", "code": "UNAUTHORIZED"})
+		return
+	}
+	s.setOIDCCookie(c, oidcSessionCookie, "", -1)
+	claims, err := parseToken(s.config.Dashboard.SecretKey, token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No SSO session", "code": "UNAUTHORIZED"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"token":      token,
+		"expires_in": int(s.oidc.cfg.SessionTTL.Seconds()),
+		"username":   claims.Subject,
+		"role":       claims.Role,
+	})
+}
@@ -0,0 +1,265 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/MUKE-coder/sentinel/v2/storage/memory"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockIssuer is a minimal OpenID provider: discovery, JWKS and a token
+// endpoint that enforces PKCE. Tests register the claims to issue for a
+// code with authorize.
+type mockIssuer struct {
+	t      *testing.T
+	srv    *httptest.Server
+	key    *rsa.PrivateKey
+	mu     sync.Mutex
+	grants map[string]mockGrant
+}
+
+type mockGrant struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockIssuer{t: t, key: key, grants: map[string]mockGrant{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.srv.URL,
+			"authorization_endpoint": m.srv.URL + "/authorize",
+			"token_endpoint":         m.srv.URL + "/token",
+			"jwks_uri":               m.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		b64 := base64.RawURLEncoding
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "k1", "use": "sig", "alg": "RS256",
+			"n": b64.EncodeToString(key.N.Bytes()),
+			"e": b64.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		m.mu.Lock()
+		grant, ok := m.grants[r.PostForm.Get("code")]
+		delete(m.grants, r.PostForm.Get("code"))
+		m.mu.Unlock()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		user, pass, _ := r.BasicAuth()
+		if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge ||
+			r.PostForm.Get("grant_type") != "authorization_code" || user != "dash" || pass != "s3cret" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "at", "id_token": m.sign(grant.claims)})
+	})
+	m.srv = httptest.NewServer(mux)
+	t.Cleanup(m.srv.Close)
+	return m
+}
+
+func (m *mockIssuer) sign(claims jwt.MapClaims) string {
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = "k1"
+	s, err := tok.SignedString(m.key)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	return s
+}
+
+// authorize plays the user approving the login at the provider: it reads
+// the authorization request and returns a code bound to its PKCE challenge.
+func (m *mockIssuer) authorize(authURL string, claims jwt.MapClaims) (code, state string) {
+	u, _ := url.Parse(authURL)
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("client_id") != "dash" || !strings.Contains(q.Get("scope"), "openid") {
+		m.t.Fatalf("unexpected authorization request %s", authURL)
+	}
+	full := jwt.MapClaims{
+		"iss":   m.srv.URL,
+		"aud":   "dash",
+		"sub":   "user-123",
+		"exp":   time.Now().Add(5 * time.Minute).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": q.Get("nonce"),
+	}
+	for k, v := range claims {
+		full[k] = v
+	}
+	code = randomToken()
+	m.mu.Lock()
+	m.grants[code] = mockGrant{challenge: q.Get("code_challenge"), claims: full}
+	m.mu.Unlock()
+	return code, q.Get("state")
+}
+
+func newOIDCTestServer(t *testing.T, issuer *mockIssuer) *gin.Engine {
+	t.Helper()
+	pipe := pipeline.New(100)
+	pipe.Start(1)
+	t.Cleanup(func() { pipe.Stop() })
+	cfg := sentinel.Config{Dashboard: sentinel.DashboardConfig{
+		Prefix: "/sentinel", Username: "admin", Password: "builtin-pass", SecretKey: "test",
+		OIDC: &sentinel.OIDCConfig{
+			IssuerURL:     issuer.srv.URL,
+			ClientID:      "dash",
+			ClientSecret:  "s3cret",
+			RedirectURL:   "http://dashboard.test/sentinel/api/auth/oidc/callback",
+			UsernameClaim: "email",
+			RoleClaim:     "groups",
+			RoleMapping: map[string]sentinel.DashboardRole{
+				"secops":   sentinel.RoleAnalyst,
+				"platform": sentinel.RoleAdmin,
+			},
+			DisablePasswordLogin: true,
+		},
+	}}
+	srv := NewServer(memory.New(), pipe, nil, nil, cfg)
+	r := gin.New()
+	srv.RegisterRoutes(r, "/sentinel")
+	return r
+}
+
+// ssoLogin drives the browser side of the flow and returns the callback
+// response and the cookies the browser would hold afterwards.
+func ssoLogin(t *testing.T, r *gin.Engine, issuer *mockIssuer, claims jwt.MapClaims, tamper func(code, state string) (string, string)) (*httptest.ResponseRecorder, []*http.Cookie) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sentinel/api/auth/oidc/login", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("login: expected redirect, got %d %s", w.Code, w.Body.String())
+	}
+	cookies := w.Result().Cookies()
+	code, state := issuer.authorize(w.Header().Get("Location"), claims)
+	if tamper != nil {
+		code, state = tamper(code, state)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/sentinel/api/auth/oidc/callback?code="+url.QueryEscape(code)+"&state="+url.QueryEscape(state), nil)
+	for _, ck := range cookies {
+		req.AddCookie(ck)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w, w.Result().Cookies()
+}
+
+func TestOIDCLoginMapsClaimsToRole(t *testing.T) {
+	issuer := newMockIssuer(t)
+	r := newOIDCTestServer(t, issuer)
+
+	w, cookies := ssoLogin(t, r, issuer, jwt.MapClaims{"email": "ana@example.com", "email_verified": true, "groups": []string{"staff", "secops"}}, nil)
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/sentinel/ui/" {
+		t.Fatalf("callback: expected redirect to the UI, got %d %s", w.Code, w.Body.String())
+	}
+
+	// The UI trades the session cookie for the dashboard token, once.
+	req := httptest.NewRequest(http.MethodGet, "/sentinel/api/auth/oidc/session", nil)
+	for _, ck := range cookies {
+		if ck.MaxAge >= 0 {
+			req.AddCookie(ck)
+		}
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var session struct {
+		Token     string `json:"token"`
+		ExpiresIn int    `json:"expires_in"`
+		Username  string `json:"username"`
+		Role      string `json:"role"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &session)
+	if w.Code != http.StatusOK || session.Username != "oidc:ana@example.com" || session.Role != "analyst" {
+		t.Fatalf("session: %d %s", w.Code, w.Body.String())
+	}
+
+	// The role is not re-checked with the provider, so the token is short-lived.
+	claims := jwt.MapClaims{}
+	jwt.ParseWithClaims(session.Token, claims, func(*jwt.Token) (any, error) { return []byte("test"), nil })
+	exp, _ := claims.GetExpirationTime()
+	if session.ExpiresIn != 3600 || exp == nil || time.Until(exp.Time) > time.Hour {
+		t.Errorf("SSO token lifetime: expires_in %d, exp %v", session.ExpiresIn, exp)
+	}
+
+	// The token goes through AuthMiddleware like any other.
+	if w := doJSON(r, session.Token, http.MethodGet, "/sentinel/api/threats", ""); w.Code != http.StatusOK {
+		t.Errorf("SSO token rejected: %d", w.Code)
+	}
+	if w := doJSON(r, session.Token, http.MethodPost, "/sentinel/api/threats/t1/resolve", ""); w.Code != http.StatusOK {
+		t.Errorf("analyst SSO token should resolve threats: %d", w.Code)
+	}
+	if w := doJSON(r, session.Token, http.MethodPut, "/sentinel/api/waf/rules", `{"mode":"block"}`); w.Code != http.StatusForbidden {
+		t.Errorf("analyst SSO token should not edit WAF rules: %d", w.Code)
+	}
+
+	// Password login is disabled by config.
+	if w := doJSON(r, "", http.MethodPost, "/sentinel/api/auth/login", `{"username":"admin","password":"builtin-pass"}`); w.Code != http.StatusForbidden {
+		t.Errorf("password login should be disabled, got %d", w.Code)
+	}
+	w = doJSON(r, "", http.MethodGet, "/sentinel/api/auth/providers", "")
+	if !strings.Contains(w.Body.String(), `"oidc":true`) || !strings.Contains(w.Body.String(), `"password":false`) {
+		t.Errorf("unexpected providers response %s", w.Body.String())
+	}
+}
+
+func TestOIDCCallbackRejections(t *testing.T) {
+	issuer := newMockIssuer(t)
+	r := newOIDCTestServer(t, issuer)
+
+	cases := []struct {
+		name   string
+		claims jwt.MapClaims
+		tamper func(code, state string) (string, string)
+		want   int
+	}{
+		{"unmapped user", jwt.MapClaims{"email": "eve@example.com", "email_verified": true, "groups": []string{"staff"}}, nil, http.StatusForbidden},
+		{"unverified email", jwt.MapClaims{"email": "admin@example.com", "email_verified": false, "groups": "platform"}, nil, http.StatusForbidden},
+		{"state mismatch", jwt.MapClaims{"groups": "platform"}, func(code, _ string) (string, string) { return code, "forged" }, http.StatusBadRequest},
+		{"unknown code", jwt.MapClaims{"groups": "platform"}, func(_, state string) (string, string) { return "bogus", state }, http.StatusBadGateway},
+		{"wrong audience", jwt.MapClaims{"groups": "platform", "aud": "other-client"}, nil, http.StatusUnauthorized},
+		{"wrong nonce", jwt.MapClaims{"groups": "platform", "nonce": "replayed"}, nil, http.StatusUnauthorized},
+		{"expired token", jwt.MapClaims{"groups": "platform", "exp": time.Now().Add(-time.Hour).Unix()}, nil, http.StatusUnauthorized},
+		{"wrong issuer", jwt.MapClaims{"groups": "platform", "iss": "https://evil.example.com"}, nil, http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, _ := ssoLogin(t, r, issuer, tc.claims, tc.tamper)
+			if w.Code != tc.want {
+				t.Errorf("expected %d, got %d %s", tc.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// A callback without the state cookie is refused.
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sentinel/api/auth/oidc/callback?code=x&state=y", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("callback without cookie: expected 400, got %d", w.Code)
+	}
+}
@@ -3,15 +3,17 @@ package api
 
 import (
 	"context"
-	"crypto/subtle"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	sentinel "github.com/MUKE-coder/sentinel/v2/core"
 	"github.com/MUKE-coder/sentinel/v2/ai"
 	"github.com/MUKE-coder/sentinel/v2/alerting"
+	"github.com/MUKE-coder/sentinel/v2/bots"
 	"github.com/MUKE-coder/sentinel/v2/detection"
 	"github.com/MUKE-coder/sentinel/v2/intelligence"
 	"github.com/MUKE-coder/sentinel/v2/middleware"
@@ -33,22 +35,47 @@ type Server struct {
 	authShield   *middleware.AuthShield
 	reportGen       *reports.Generator
 	customRuleEngine *detection.CustomRuleEngine
+	anomalyDetector  *intelligence.AnomalyDetector
+	sessionRisk      *intelligence.SessionRiskEngine
+	campaigns        *intelligence.CampaignEngine
+	bots             *bots.Detector
+	feeds            *intelligence.FeedManager
+	networks         *intelligence.NetworkDetector
 	aiProvider       ai.Provider
 	rateLimiter      *middleware.RateLimiter
 	config          sentinel.Config
 	wsHub        *WSHub
 	loginRL      *LoginRateLimiter
+
+	// configMu guards the dashboard-editable parts of config. baseConfig is
+	// the code-supplied config that persisted overrides are layered over.
+	configMu    sync.RWMutex
+	baseConfig  sentinel.Config
+	wafSettings *middleware.WAFSettings
+	geoFence    *middleware.GeoFence
+
+	oidc *oidcProvider // nil unless Dashboard.OIDC is set
+
+	// apiKeyResources holds the route resources API key scopes may name,
+	// collected from the registered routes.
+	apiKeyResources map[string]bool
 }
 
 // NewServer creates a new API server.
 func NewServer(store storage.Store, pipe *pipeline.Pipeline, ipMgr *intelligence.IPManager, scoreEngine *intelligence.ScoreEngine, config sentinel.Config) *Server {
+	var oidc *oidcProvider
+	if config.Dashboard.OIDC != nil {
+		oidc = newOIDCProvider(*config.Dashboard.OIDC)
+	}
 	return &Server{
+		oidc:        oidc,
 		store:       store,
 		pipe:        pipe,
 		ipManager:   ipMgr,
 		scoreEngine: scoreEngine,
 		reportGen:   reports.NewGenerator(store),
 		config:      config,
+		baseConfig:  config,
 		wsHub:       NewWSHub(),
 		loginRL:     NewLoginRateLimiter(),
 	}
@@ -79,6 +106,42 @@ func (s *Server) SetCustomRuleEngine(e *detection.CustomRuleEngine) {
 	s.customRuleEngine = e
 }
 
+// SetAnomalyDetector sets the anomaly detector whose baselines the API
+// inspects and resets.
+func (s *Server) SetAnomalyDetector(ad *intelligence.AnomalyDetector) {
+	s.anomalyDetector = ad
+}
+
+// SetSessionRiskEngine sets the session risk engine whose sessions the API
+// lists.
+func (s *Server) SetSessionRiskEngine(e *intelligence.SessionRiskEngine) {
+	s.sessionRisk = e
+}
+
+// SetCampaignEngine sets the campaign engine whose campaigns the API lists
+// and blocks.
+func (s *Server) SetCampaignEngine(e *intelligence.CampaignEngine) {
+	s.campaigns = e
+}
+
+// SetBotDetector sets the bot detector whose counts the bot analytics
+// endpoint reports.
+func (s *Server) SetBotDetector(d *bots.Detector) {
+	s.bots = d
+}
+
+// SetNetworkDetector sets the network type lists the network endpoints
+// report on and reload.
+func (s *Server) SetNetworkDetector(d *intelligence.NetworkDetector) {
+	s.networks = d
+}
+
+// SetFeedManager sets the threat feeds the feed endpoints report on and
+// refresh.
+func (s *Server) SetFeedManager(f *intelligence.FeedManager) {
+	s.feeds = f
+}
+
 // SetAIProvider sets the AI provider for the API server.
 func (s *Server) SetAIProvider(p ai.Provider) {
 	s.aiProvider = p
@@ -89,6 +152,23 @@ func (s *Server) SetRateLimiter(rl *middleware.RateLimiter) {
 	s.rateLimiter = rl
 }
 
+// SetConfigBase sets the code-supplied config that dashboard edits are
+// layered over. Mount passes the config as it was before persisted
+// overrides were applied; without it, the config given to NewServer is used.
+func (s *Server) SetConfigBase(base sentinel.Config) {
+	s.baseConfig = base
+}
+
+// SetWAFSettings sets the live WAF settings updated by dashboard edits.
+func (s *Server) SetWAFSettings(ws *middleware.WAFSettings) {
+	s.wafSettings = ws
+}
+
+// SetGeoFence sets the live geofencing policies updated by dashboard edits.
+func (s *Server) SetGeoFence(f *middleware.GeoFence) {
+	s.geoFence = f
+}
+
 // RegisterRoutes registers all API routes on the Gin router.
 func (s *Server) RegisterRoutes(r *gin.Engine, prefix string) {
 	// CSP violation receiver. Mounted at <prefix>/csp-report (NOT under /api)
@@ -103,28 +183,46 @@ func (s *Server) RegisterRoutes(r *gin.Engine, prefix string) {
 	{
 		auth.POST("/login", s.handleLogin)
 		auth.POST("/logout", s.handleLogout)
-		auth.GET("/verify", AuthMiddleware(s.config.Dashboard.SecretKey), s.handleVerify)
+		auth.GET("/verify", AuthMiddleware(s.config.Dashboard.SecretKey), s.accountSession, s.handleVerify)
+		auth.GET("/providers", s.handleAuthProviders)
+		if s.oidc != nil {
+			auth.GET("/oidc/login", s.handleOIDCLogin)
+			auth.GET("/oidc/callback", s.handleOIDCCallback)
+			auth.GET("/oidc/session", s.handleOIDCSession)
+		}
 	}
 
-	// Protected routes
+	// Protected routes. Every role may read; analyst and admin routes are
+	// split out below, and each mutating route records an audit entry.
 	protected := api.Group("")
-	protected.Use(AuthMiddleware(s.config.Dashboard.SecretKey))
+	protected.Use(AuthMiddleware(s.config.Dashboard.SecretKey, s), s.accountSession)
+	analyst := protected.Group("", RequireRole(sentinel.RoleAnalyst))
+	admin := protected.Group("", RequireRole(sentinel.RoleAdmin))
 	{
 		// Threats
 		protected.GET("/threats", s.handleListThreats)
 		protected.GET("/threats/:id", s.handleGetThreat)
-		protected.POST("/threats/:id/resolve", s.handleResolveThreat)
-		protected.POST("/threats/:id/false-positive", s.handleFalsePositive)
+		analyst.POST("/threats/:id/resolve", s.audit("RESOLVE", "threat"), s.handleResolveThreat)
+		analyst.POST("/threats/:id/false-positive", s.audit("FALSE_POSITIVE", "threat"), s.handleFalsePositive)
 
 		// Actors
 		protected.GET("/actors", s.handleListActors)
 		protected.GET("/actors/:ip", s.handleGetActor)
-		protected.POST("/actors/:ip/block", s.handleBlockActor)
+		analyst.POST("/actors/:ip/block", s.audit("BLOCK", "actor"), s.handleBlockActor)
+
+		// Campaigns
+		protected.GET("/campaigns", s.handleListCampaigns)
+		protected.GET("/campaigns/:id", s.handleGetCampaign)
+		analyst.POST("/campaigns/:id/block", s.audit("BLOCK", "campaign"), s.handleBlockCampaign)
+		admin.DELETE("/campaigns/:id/block", s.audit("UNBLOCK", "campaign"), s.handleUnblockCampaign)
 
 		// IP Management
 		protected.GET("/ip/blocked", s.handleListBlockedIPs)
-		protected.POST("/ip/block", s.handleBlockIP)
-		protected.DELETE("/ip/block/:ip", s.handleUnblockIP)
+		analyst.POST("/ip/block", s.audit("BLOCK", "ip"), s.handleBlockIP)
+		admin.DELETE("/ip/block/:ip", s.audit("UNBLOCK", "ip"), s.handleUnblockIP)
+		protected.GET("/ip/whitelisted", s.handleListWhitelistedIPs)
+		admin.POST("/ip/whitelist", s.audit("WHITELIST", "ip"), s.handleWhitelistIP)
+		admin.DELETE("/ip/whitelist/:ip", s.audit("UNWHITELIST", "ip"), s.handleUnwhitelistIP)
 
 		// Performance
 		protected.GET("/performance/overview", s.handlePerformanceOverview)
@@ -138,23 +236,34 @@ func (s *Server) RegisterRoutes(r *gin.Engine, prefix string) {
 		protected.GET("/analytics/attack-trends", s.handleAttackTrends)
 		protected.GET("/analytics/geographic", s.handleGeoStats)
 		protected.GET("/analytics/top-targets", s.handleTopTargets)
+		protected.GET("/analytics/bots", s.handleBotStats)
 
 		// Users
 		protected.GET("/users", s.handleListUsers)
 		protected.GET("/users/:user_id/activity", s.handleUserActivity)
 		protected.GET("/users/:user_id/threats", s.handleUserThreats)
+		protected.GET("/users/:user_id/baseline", s.handleGetUserBaseline)
+		analyst.DELETE("/users/:user_id/baseline", s.audit("RESET", "baseline"), s.handleResetUserBaseline)
+		protected.GET("/sessions", s.handleListSessions)
 
 		// Actor requests (threat history for an IP)
 		protected.GET("/actors/:ip/requests", s.handleActorRequests)
 
 		// IP Reputation
 		protected.GET("/ip/:ip/reputation", s.handleIPReputation)
+		protected.GET("/ip/:ip/status", s.handleIPStatus)
+
+		// Threat feeds
+		protected.GET("/intel/feeds", s.handleListFeeds)
+		admin.POST("/intel/feeds/:name/refresh", s.audit("REFRESH", "feed"), s.handleRefreshFeed)
+		protected.GET("/intel/networks", s.handleListNetworkLists)
+		admin.POST("/intel/networks/reload", s.audit("REFRESH", "network_lists"), s.handleReloadNetworkLists)
 
 		// Audit Logs
 		protected.GET("/audit-logs", s.handleListAuditLogs)
 
 		// Auth Shield
-		protected.POST("/auth/unblock-user/:username", s.handleUnblockUser)
+		admin.POST("/auth/unblock-user/:username", s.audit("UNBLOCK", "user"), s.handleUnblockUser)
 		protected.GET("/auth-shield/status", s.handleAuthShieldStatus)
 
 		// CSP violations
@@ -167,30 +276,59 @@ func (s *Server) RegisterRoutes(r *gin.Engine, prefix string) {
 
 		// WAF Rules
 		protected.GET("/waf/rules", s.handleGetWAFRules)
-		protected.PUT("/waf/rules", s.handleUpdateWAFRules)
+		admin.PUT("/waf/rules", s.audit("UPDATE", "waf_rules"), s.handleUpdateWAFRules)
 		protected.GET("/waf/custom-rules", s.handleListCustomRules)
-		protected.POST("/waf/custom-rules", s.handleAddCustomRule)
-		protected.DELETE("/waf/custom-rules/:id", s.handleDeleteCustomRule)
+		admin.POST("/waf/custom-rules", s.audit("CREATE", "custom_rule"), s.handleAddCustomRule)
+		admin.DELETE("/waf/custom-rules/:id", s.audit("DELETE", "custom_rule"), s.handleDeleteCustomRule)
 		protected.POST("/waf/test", s.handleTestWAFPayload)
 
+		// Geofencing
+		protected.GET("/geofence/policies", s.handleListGeoPolicies)
+		admin.POST("/geofence/policies", s.audit("CREATE", "geo_policy"), s.handleSaveGeoPolicy)
+		admin.DELETE("/geofence/policies/:id", s.audit("DELETE", "geo_policy"), s.handleDeleteGeoPolicy)
+		protected.GET("/geofence/check", s.handleCheckGeoFence)
+
 		// Alerts
 		protected.GET("/alerts/config", s.handleGetAlertConfig)
-		protected.PUT("/alerts/config", s.handleUpdateAlertConfig)
-		protected.POST("/alerts/test", s.handleTestAlert)
+		admin.PUT("/alerts/config", s.audit("UPDATE", "alert_config"), s.handleUpdateAlertConfig)
+		analyst.POST("/alerts/test", s.audit("TEST", "alert"), s.handleTestAlert)
 		protected.GET("/alerts/history", s.handleAlertHistory)
 
 		// AI Analysis
-		protected.POST("/ai/analyze-threat/:id", s.handleAIAnalyzeThreat)
+		analyst.POST("/ai/analyze-threat/:id", s.handleAIAnalyzeThreat)
 		protected.GET("/ai/analyze-actor/:ip", s.handleAIAnalyzeActor)
 		protected.GET("/ai/daily-summary", s.handleAIDailySummary)
-		protected.POST("/ai/query", s.handleAIQuery)
+		analyst.POST("/ai/query", s.handleAIQuery)
 		protected.GET("/ai/waf-recommendations", s.handleAIWAFRecommendations)
 
 		// Rate Limits
 		protected.GET("/rate-limits", s.handleGetRateLimits)
-		protected.PUT("/rate-limits", s.handleUpdateRateLimits)
+		admin.PUT("/rate-limits", s.audit("UPDATE", "rate_limits"), s.handleUpdateRateLimits)
 		protected.GET("/rate-limits/current", s.handleGetRateLimitStates)
-		protected.POST("/rate-limits/reset/:key", s.handleResetRateLimit)
+		analyst.POST("/rate-limits/reset/:key", s.audit("RESET", "rate_limit"), s.handleResetRateLimit)
+
+		// Config versions
+		protected.GET("/config/versions", s.handleListConfigVersions)
+		protected.GET("/config/versions/:version", s.handleGetConfigVersion)
+		admin.POST("/config/versions/:version/rollback", s.audit("ROLLBACK", "config"), s.handleRollbackConfig)
+
+		// Dashboard accounts
+		admin.GET("/accounts", s.handleListAccounts)
+		admin.POST("/accounts", s.audit("CREATE", "account"), s.handleCreateAccount)
+		admin.PUT("/accounts/:id", s.audit("UPDATE", "account"), s.handleUpdateAccount)
+		admin.DELETE("/accounts/:id", s.audit("DELETE", "account"), s.handleDeleteAccount)
+
+		// API keys
+		admin.GET("/api-keys", s.handleListAPIKeys)
+		admin.POST("/api-keys", s.audit("CREATE", "api_key"), s.handleCreateAPIKey)
+		admin.DELETE("/api-keys/:id", s.audit("REVOKE", "api_key"), s.handleRevokeAPIKey)
+	}
+	s.apiKeyResources = make(map[string]bool)
+	for _, route := range r.Routes() {
+		if rest, ok := strings.CutPrefix(route.Path, prefix+"/api/"); ok {
+			resource, _, _ := strings.Cut(rest, "/")
+			s.apiKeyResources[resource] = true
+		}
 	}
 
 	// WebSocket routes
@@ -213,6 +351,14 @@ func (s *Server) RegisterRoutes(r *gin.Engine, prefix string) {
 func (s *Server) handleLogin(c *gin.Context) {
 	clientIP := c.ClientIP()
 
+	if !s.passwordLoginEnabled() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Password login is disabled; sign in with SSO",
+			"code":  "FORBIDDEN",
+		})
+		return
+	}
+
 	if !s.loginRL.Check(clientIP) {
 		c.JSON(http.StatusTooManyRequests, gin.H{
 			"error": "Too many login attempts",
@@ -234,21 +380,25 @@ func (s *Server) handleLogin(c *gin.Context) {
 		return
 	}
 
-	// Constant-time comparison — a plain != leaks credential length/prefix
-	// timing. Compare both fields unconditionally so the failure path takes
-	// the same time regardless of which field is wrong.
-	userOK := subtle.ConstantTimeCompare([]byte(req.Username), []byte(s.config.Dashboard.Username)) == 1
-	passOK := subtle.ConstantTimeCompare([]byte(req.Password), []byte(s.config.Dashboard.Password)) == 1
-	if !userOK || !passOK {
+	user, err := s.authenticate(c.Request.Context(), req.Username, req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check credentials",
+			"code":  "INTERNAL_ERROR",
+		})
+		return
+	}
+	if user == nil {
 		s.loginRL.RecordFailure(clientIP)
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": "Invalid credentials",
 			"code":  "UNAUTHORIZED",
 		})
+		s.writeAudit(c, req.Username, "", "LOGIN", "session")
 		return
 	}
 
-	token, err := GenerateToken(s.config.Dashboard.SecretKey)
+	token, err := GenerateUserToken(s.config.Dashboard.SecretKey, user.Username, user.Role, user.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to generate token",
@@ -259,8 +409,11 @@ func (s *Server) handleLogin(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"token":      token,
-		"expires_in": 86400,
+		"expires_in": int(tokenTTL.Seconds()),
+		"username":   user.Username,
+		"role":       user.Role,
 	})
+	s.writeAudit(c, user.Username, user.Role, "LOGIN", "session")
 }
 
 func (s *Server) handleLogout(c *gin.Context) {
@@ -268,7 +421,7 @@ func (s *Server) handleLogout(c *gin.Context) {
 }
 
 func (s *Server) handleVerify(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"valid": true})
+	c.JSON(http.StatusOK, gin.H{"valid": true, "username": currentSubject(c), "role": currentRole(c)})
 }
 
 // --- Threat handlers ---
@@ -278,6 +431,7 @@ func (s *Server) handleListThreats(c *gin.Context) {
 		Severity:  sentinel.Severity(c.Query("severity")),
 		Type:      c.Query("type"),
 		IP:        c.Query("ip"),
+		ActorID:   c.Query("actor_id"),
 		Search:    c.Query("search"),
 		SortBy:    c.DefaultQuery("sort_by", "timestamp"),
 		SortOrder: c.DefaultQuery("sort_order", "desc"),
@@ -463,10 +617,17 @@ func (s *Server) handleBlockIP(c *gin.Context) {
 		return
 	}
 
+	c.Set(ctxAuditResourceID, req.IP)
 	if req.IP == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "IP is required", "code": "BAD_REQUEST"})
 		return
 	}
+	entry, err := s.parseIPEntry(req.IP)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "BAD_REQUEST"})
+		return
+	}
+	c.Set(ctxAuditResourceID, entry)
 
 	var expiry *time.Time
 	if req.Expiry != "" {
@@ -482,19 +643,29 @@ func (s *Server) handleBlockIP(c *gin.Context) {
 	}
 
 	if s.ipManager != nil {
-		if err := s.ipManager.BlockIP(c.Request.Context(), req.IP, req.Reason, expiry); err != nil {
+		if err := s.ipManager.BlockIP(c.Request.Context(), entry, req.Reason, expiry); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "code": "INTERNAL_ERROR"})
 			return
 		}
 	}
 
-	resp := gin.H{"message": "IP blocked"}
+	resp := gin.H{"message": "IP blocked", "ip": entry}
 	if expiry != nil {
 		resp["expires_at"] = expiry.Format(time.RFC3339)
 	}
 	c.JSON(http.StatusOK, resp)
 }
 
+// parseIPEntry parses a block or whitelist entry, refusing ASN entries
+// when no local database can resolve them, since they would never match.
+func (s *Server) parseIPEntry(raw string) (string, error) {
+	entry, err := sentinel.ParseIPEntry(raw)
+	if err == nil && sentinel.IsASNEntry(entry) && (s.geoLocator == nil || !s.geoLocator.ResolvesASNs()) {
+		err = errors.New("ASN entries need geolocation with a local ASN database (Geo.ASNDatabasePath)")
+	}
+	return entry, err
+}
+
 func (s *Server) handleUnblockIP(c *gin.Context) {
 	ip := c.Param("ip")
 
@@ -511,6 +682,63 @@ func (s *Server) handleUnblockIP(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "IP unblocked"})
 }
 
+func (s *Server) handleListWhitelistedIPs(c *gin.Context) {
+	whitelisted, err := s.store.ListWhitelistedIPs(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "code": "INTERNAL_ERROR"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": whitelisted})
+}
+
+// handleWhitelistIP whitelists an address, CIDR prefix or ASN. Whitelist
+// entries do not expire; a more specific block inside a whitelisted range
+// still applies.
+func (s *Server) handleWhitelistIP(c *gin.Context) {
+	var req struct {
+		IP string `json:"ip"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "code": "BAD_REQUEST"})
+		return
+	}
+
+	c.Set(ctxAuditResourceID, req.IP)
+	if req.IP == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "IP is required", "code": "BAD_REQUEST"})
+		return
+	}
+	entry, err := s.parseIPEntry(req.IP)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "BAD_REQUEST"})
+		return
+	}
+	c.Set(ctxAuditResourceID, entry)
+
+	if s.ipManager != nil {
+		if err := s.ipManager.WhitelistIP(c.Request.Context(), entry); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "code": "INTERNAL_ERROR"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "IP whitelisted", "ip": entry})
+}
+
+func (s *Server) handleUnwhitelistIP(c *gin.Context) {
+	// Handle CIDR in URL (replace _ with /)
+	ip := strings.ReplaceAll(c.Param("ip"), "_", "/")
+
+	if s.ipManager != nil {
+		if err := s.ipManager.UnwhitelistIP(c.Request.Context(), ip); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "code": "INTERNAL_ERROR"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "IP removed from whitelist"})
+}
+
 // --- Performance handlers ---
 
 func (s *Server) handlePerformanceOverview(c *gin.Context) {
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/detection"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/MUKE-coder/sentinel/v2/storage/memory"
+	"github.com/gin-gonic/gin"
+)
+
+func TestWAFTestReportsConditions(t *testing.T) {
+	pipe := pipeline.New(100)
+	pipe.Start(1)
+	t.Cleanup(func() { pipe.Stop() })
+
+	srv := NewServer(memory.New(), pipe, nil, nil, sentinel.Config{Dashboard: sentinel.DashboardConfig{
+		Prefix: "/sentinel", Username: "admin", Password: "builtin-pass", SecretKey: "test",
+	}})
+	srv.SetCustomRuleEngine(detection.NewCustomRuleEngine([]sentinel.WAFRule{{
+		ID: "export-patch", Name: "Debug SQL export", Severity: sentinel.SeverityHigh, Enabled: true,
+		When: &sentinel.RuleCondition{All: []sentinel.RuleCondition{
+			{Type: sentinel.ConditionPath, Values: []string{"/api/v1/export"}},
+			{Type: sentinel.ConditionHeader, Name: "X-Debug"},
+		}},
+	}}))
+	r := gin.New()
+	srv.RegisterRoutes(r, "/sentinel")
+	token := login(t, r, "admin", "builtin-pass")
+
+	w := doJSON(r, token, http.MethodPost, "/sentinel/api/waf/test",
+		`{"request":{"method":"post","path":"/api/v1/export","headers":{"x-debug":["1"]}}}`)
+	var res struct {
+		Data struct {
+			Matches []struct {
+				Pattern    string   `json:"pattern"`
+				Conditions []string `json:"conditions"`
+			} `json:"matches"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("waf test: %d %s", w.Code, w.Body.String())
+	}
+	if len(res.Data.Matches) != 1 || res.Data.Matches[0].Pattern != "Debug SQL export" ||
+		len(res.Data.Matches[0].Conditions) != 2 || res.Data.Matches[0].Conditions[1] != "header X-Debug exists" {
+		t.Errorf("unexpected matches: %s", w.Body.String())
+	}
+
+	if w := doJSON(r, token, http.MethodPost, "/sentinel/api/waf/test", `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("empty test: expected 400, got %d", w.Code)
+	}
+}
+
+func TestWAFTestReportsRouteThreshold(t *testing.T) {
+	pipe := pipeline.New(100)
+	pipe.Start(1)
+	t.Cleanup(func() { pipe.Stop() })
+
+	srv := NewServer(memory.New(), pipe, nil, nil, sentinel.Config{
+		Dashboard: sentinel.DashboardConfig{Prefix: "/sentinel", Username: "admin", Password: "builtin-pass", SecretKey: "test"},
+		WAF: sentinel.WAFConfig{Scoring: &sentinel.AnomalyScoringConfig{
+			InboundThreshold: 5,
+			RouteThresholds:  map[string]int{"/api/cms/**": 10, "/api/login": 3},
+		}},
+	})
+	srv.SetCustomRuleEngine(detection.NewCustomRuleEngine(nil))
+	r := gin.New()
+	srv.RegisterRoutes(r, "/sentinel")
+	token := login(t, r, "admin", "builtin-pass")
+
+	for _, tc := range []struct {
+		body string
+		want int
+	}{
+		{`{"payload":"' OR 1=1--"}`, 5},
+		{`{"request":{"method":"GET","path":"/api/cms/pages","query":"q=' OR 1=1--"}}`, 10},
+		{`{"request":{"method":"POST","path":"/api/login","body":"' OR 1=1--"}}`, 3},
+		{`{"request":{"method":"GET","path":"/api/orders","query":"q=' OR 1=1--"}}`, 5},
+	} {
+		w := doJSON(r, token, http.MethodPost, "/sentinel/api/waf/test", tc.body)
+		var res struct {
+			Data struct {
+				Threshold int `json:"anomaly_threshold"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("waf test: %d %s", w.Code, w.Body.String())
+		}
+		if res.Data.Threshold != tc.want {
+			t.Errorf("%s: threshold %d, want %d", tc.body, res.Data.Threshold, tc.want)
+		}
+	}
+}
@@ -8,7 +8,6 @@ import (
 
 	"github.com/MUKE-coder/sentinel/v2/pipeline"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/websocket"
 )
 
@@ -97,12 +96,10 @@ func (s *Server) wsAuthorized(c *gin.Context) bool {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing token", "code": "UNAUTHORIZED"})
 		return false
 	}
-	_, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return []byte(s.config.Dashboard.SecretKey), nil
-	})
+	claims, err := parseToken(s.config.Dashboard.SecretKey, tokenStr)
+	if err == nil && claims.AccountID != "" {
+		err = s.checkAccount(c, claims.AccountID)
+	}
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token", "code": "UNAUTHORIZED"})
 		return false
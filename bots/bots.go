@@ -0,0 +1,321 @@
+// Package bots classifies the clients of HTTP requests as humans, verified
+// crawlers, unverified bots or bad bots.
+//
+// A client whose User-Agent claims to be a known crawler is verified the
+// way the search engines document: the PTR record of its IP must name a
+// host under the crawler's domains, and that host must resolve back to the
+// IP. Scrapers borrowing Googlebot's User-Agent fail the check and are
+// judged bad. Other clients get a bot score from User-Agent and header
+// tells: HTTP libraries, headless browsers, self-declared bots, and
+// missing headers every browser sends.
+//
+// Lookups go through a sentinel.BotResolver, so tests can run offline with
+// a fake, and results are cached per IP.
+package bots
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+)
+
+const (
+	// retryAfter is how long a verification that could not complete (a
+	// timeout, a SERVFAIL) is remembered before it is tried again.
+	retryAfter = time.Minute
+
+	// maxCacheEntries bounds the verification cache.
+	maxCacheEntries = 50000
+)
+
+// outcome is the result of verifying a crawler claim.
+type outcome int
+
+const (
+	unresolved outcome = iota // DNS could not answer
+	verified                  // PTR under the crawler's domains, resolving back to the IP
+	spoofed                   // DNS answered, and the IP is not the crawler's
+)
+
+type cached struct {
+	outcome outcome
+	expires time.Time
+}
+
+// Detector classifies requests. Safe for concurrent use.
+type Detector struct {
+	config   sentinel.BotConfig
+	crawlers []sentinel.Crawler
+	resolver sentinel.BotResolver
+
+	mu    sync.Mutex
+	cache map[string]cached
+
+	stats *stats
+}
+
+// New returns a Detector for config. Zero durations and threshold take
+// the defaults of Config.ApplyDefaults.
+func New(config sentinel.BotConfig) *Detector {
+	if config.Threshold == 0 {
+		config.Threshold = 50
+	}
+	if config.VerifyTimeout == 0 {
+		config.VerifyTimeout = 2 * time.Second
+	}
+	if config.CacheTTL == 0 {
+		config.CacheTTL = 24 * time.Hour
+	}
+	resolver := config.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	var crawlers []sentinel.Crawler
+	for _, c := range append(append([]sentinel.Crawler(nil), config.Crawlers...), DefaultCrawlers...) {
+		dup := false
+		for _, seen := range crawlers {
+			if strings.EqualFold(seen.Name, c.Name) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			crawlers = append(crawlers, c)
+		}
+	}
+	return &Detector{
+		config:   config,
+		crawlers: crawlers,
+		resolver: resolver,
+		cache:    make(map[string]cached),
+		stats:    newStats(),
+	}
+}
+
+// Classify scores the client of r, whose IP is clientIP, and counts the
+// result in the detector's stats. A crawler claim is verified by DNS,
+// which may block for up to BotConfig.VerifyTimeout the first time an IP
+// is seen.
+func (d *Detector) Classify(ctx context.Context, r *http.Request, clientIP string) *sentinel.BotResult {
+	res := d.classify(ctx, r, clientIP)
+	d.stats.add(res, time.Now())
+	return res
+}
+
+func (d *Detector) classify(ctx context.Context, r *http.Request, clientIP string) *sentinel.BotResult {
+	ua := strings.ToLower(r.UserAgent())
+
+	if c, ok := d.claimedCrawler(ua); ok {
+		res := &sentinel.BotResult{Score: 100, Crawler: c.Name}
+		switch d.verify(ctx, c, clientIP) {
+		case verified:
+			res.Verdict = sentinel.BotVerified
+			res.Signals = []string{"verified_crawler"}
+		case spoofed:
+			res.Verdict = sentinel.BotBad
+			res.Signals = []string{"spoofed_crawler"}
+		default:
+			res.Verdict = sentinel.BotUnverified
+			res.Signals = []string{"unverified_crawler"}
+		}
+		return res
+	}
+
+	res := &sentinel.BotResult{Verdict: sentinel.BotHuman}
+	add := func(signal string, score int) {
+		res.Signals = append(res.Signals, signal)
+		res.Score += score
+	}
+	switch {
+	case ua == "":
+		add("empty_ua", 50)
+	case containsAny(ua, attackTools):
+		add("attack_tool", 100)
+	case containsAny(ua, headlessTells) || strings.Contains(strings.ToLower(r.Header.Get("Sec-CH-UA")), "headless"):
+		add("headless_ua", 60)
+	case containsAny(ua, automationTools):
+		add("automation_ua", 60)
+	case containsAny(ua, declaredBotTokens):
+		add("declared_bot", 60)
+	}
+	if r.Header.Get("Accept") == "" {
+		add("no_accept", 10)
+	}
+	if r.Header.Get("Accept-Language") == "" {
+		add("no_accept_language", 15)
+	}
+	// Chromium sends client hints to every secure origin. A Chrome
+	// User-Agent without them over HTTPS is usually a script borrowing it.
+	if strings.Contains(ua, "chrome/") && r.Header.Get("Sec-CH-UA") == "" && isSecure(r) {
+		add("no_client_hints", 25)
+	}
+	res.Score = min(res.Score, 100)
+
+	switch {
+	case containsSignal(res.Signals, "attack_tool"):
+		res.Verdict = sentinel.BotBad
+	case res.Score >= d.config.Threshold:
+		res.Verdict = sentinel.BotUnverified
+	}
+	return res
+}
+
+// claimedCrawler returns the crawler ua claims to be.
+func (d *Detector) claimedCrawler(ua string) (sentinel.Crawler, bool) {
+	for _, c := range d.crawlers {
+		for _, token := range c.UserAgents {
+			if token != "" && strings.Contains(ua, strings.ToLower(token)) {
+				return c, true
+			}
+		}
+	}
+	return sentinel.Crawler{}, false
+}
+
+// Stats returns the classification counts of the last window, at hour
+// granularity and up to seven days back. Counts are kept in memory, per
+// process.
+func (d *Detector) Stats(window time.Duration) *sentinel.BotStats {
+	return d.stats.sum(window, time.Now())
+}
+
+// --- Crawler verification ---
+
+// verify checks that ip belongs to crawler c, from the cache when it can.
+func (d *Detector) verify(ctx context.Context, c sentinel.Crawler, ip string) outcome {
+	key := c.Name + "|" + ip
+	now := time.Now()
+
+	d.mu.Lock()
+	e, ok := d.cache[key]
+	d.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.outcome
+	}
+
+	// A client hanging up must not leave its verification half-done, or
+	// the next request from it would pay for the lookups again.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), d.config.VerifyTimeout)
+	defer cancel()
+	out := lookup(ctx, d.resolver, c, ip)
+
+	ttl := d.config.CacheTTL
+	if out == unresolved {
+		ttl = retryAfter
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.cache) >= maxCacheEntries {
+		for k, e := range d.cache {
+			if now.After(e.expires) {
+				delete(d.cache, k)
+			}
+		}
+		if len(d.cache) >= maxCacheEntries {
+			clear(d.cache)
+		}
+	}
+	d.cache[key] = cached{outcome: out, expires: now.Add(ttl)}
+	return out
+}
+
+// lookup runs reverse-then-forward DNS verification of ip against c.
+func lookup(ctx context.Context, resolver sentinel.BotResolver, c sentinel.Crawler, ip string) outcome {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return spoofed
+	}
+	names, err := resolver.LookupAddr(ctx, ip)
+	if err != nil {
+		if isNotFound(err) {
+			return spoofed
+		}
+		return unresolved
+	}
+	failed := false
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSuffix(name, "."))
+		if !underDomains(name, c.Domains) {
+			continue
+		}
+		addrs, err := resolver.LookupHost(ctx, name)
+		if err != nil {
+			if !isNotFound(err) {
+				failed = true
+			}
+			continue
+		}
+		for _, a := range addrs {
+			if got := net.ParseIP(a); got != nil && got.Equal(addr) {
+				return verified
+			}
+		}
+	}
+	if failed {
+		return unresolved
+	}
+	return spoofed
+}
+
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// underDomains reports whether host is one of domains or a subdomain of
+// one.
+func underDomains(host string, domains []string) bool {
+	for _, d := range domains {
+		d = strings.ToLower(strings.Trim(d, "."))
+		if d != "" && (host == d || strings.HasSuffix(host, "."+d)) {
+			return true
+		}
+	}
+	return false
+}
+
+// --- Request context ---
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying res.
+func NewContext(ctx context.Context, res *sentinel.BotResult) context.Context {
+	return context.WithValue(ctx, contextKey{}, res)
+}
+
+// FromContext returns the bot result attached to ctx by the bot
+// middleware, or nil.
+func FromContext(ctx context.Context) *sentinel.BotResult {
+	res, _ := ctx.Value(contextKey{}).(*sentinel.BotResult)
+	return res
+}
+
+// --- Helpers ---
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsSignal(signals []string, s string) bool {
+	for _, v := range signals {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func isSecure(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
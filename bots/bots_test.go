@@ -0,0 +1,143 @@
+package bots
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+)
+
+// fakeResolver answers from fixed PTR and A records and counts PTR lookups.
+type fakeResolver struct {
+	ptr     map[string][]string
+	hosts   map[string][]string
+	fail    bool
+	lookups atomic.Int32
+}
+
+func (f *fakeResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	f.lookups.Add(1)
+	if f.fail {
+		return nil, &net.DNSError{Err: "server misbehaving", Name: addr, IsTemporary: true}
+	}
+	if names, ok := f.ptr[addr]; ok {
+		return names, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: addr, IsNotFound: true}
+}
+
+func (f *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if addrs, ok := f.hosts[host]; ok {
+		return addrs, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+const googlebotUA = "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+
+func googleResolver() *fakeResolver {
+	return &fakeResolver{
+		ptr: map[string][]string{
+			"66.249.66.1": {"crawl-66-249-66-1.googlebot.com."},
+			// A spoofer can set any PTR for its own IP, but cannot make
+			// Google's name resolve back to it.
+			"203.0.113.9": {"crawl-66-249-66-1.googlebot.com."},
+		},
+		hosts: map[string][]string{
+			"crawl-66-249-66-1.googlebot.com": {"66.249.66.1"},
+		},
+	}
+}
+
+func classify(d *Detector, ip, ua string, headers map[string]string) *sentinel.BotResult {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", ua)
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	return d.Classify(context.Background(), r, ip)
+}
+
+func TestVerifiesCrawlerByReverseThenForwardDNS(t *testing.T) {
+	resolver := googleResolver()
+	d := New(sentinel.BotConfig{Resolver: resolver})
+
+	res := classify(d, "66.249.66.1", googlebotUA, nil)
+	if res.Verdict != sentinel.BotVerified || res.Crawler != "Googlebot" {
+		t.Fatalf("real Googlebot: got %+v", res)
+	}
+
+	res = classify(d, "203.0.113.9", googlebotUA, nil)
+	if res.Verdict != sentinel.BotBad || res.Signals[0] != "spoofed_crawler" {
+		t.Fatalf("forged PTR: got %+v", res)
+	}
+
+	res = classify(d, "198.51.100.7", googlebotUA, nil)
+	if res.Verdict != sentinel.BotBad {
+		t.Fatalf("no PTR: got %+v", res)
+	}
+
+	// Results are cached per IP.
+	before := resolver.lookups.Load()
+	classify(d, "66.249.66.1", googlebotUA, nil)
+	if n := resolver.lookups.Load() - before; n != 0 {
+		t.Errorf("expected a cached verdict, got %d lookups", n)
+	}
+}
+
+func TestUnresolvedClaimIsUnverified(t *testing.T) {
+	d := New(sentinel.BotConfig{Resolver: &fakeResolver{fail: true}})
+	res := classify(d, "66.249.66.1", googlebotUA, nil)
+	if res.Verdict != sentinel.BotUnverified {
+		t.Fatalf("DNS failure should leave the claim unverified, got %+v", res)
+	}
+}
+
+func TestScoresAutomationAndHeadlessTells(t *testing.T) {
+	d := New(sentinel.BotConfig{Resolver: &fakeResolver{}})
+	browser := map[string]string{"Accept": "text/html", "Accept-Language": "en-US"}
+
+	cases := []struct {
+		name    string
+		ua      string
+		headers map[string]string
+		want    sentinel.BotVerdict
+	}{
+		{"browser", "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0", browser, sentinel.BotHuman},
+		{"curl", "curl/8.5.0", map[string]string{"Accept": "*/*"}, sentinel.BotUnverified},
+		{"headless", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 HeadlessChrome/120.0.0.0 Safari/537.36", browser, sentinel.BotUnverified},
+		{"scanner", "sqlmap/1.8#stable (https://sqlmap.org)", browser, sentinel.BotBad},
+		{"empty", "", nil, sentinel.BotUnverified},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if res := classify(d, "192.0.2.1", tc.ua, tc.headers); res.Verdict != tc.want {
+				t.Errorf("got %+v, want %s", res, tc.want)
+			}
+		})
+	}
+}
+
+func TestStatsCountByVerdictAndCrawler(t *testing.T) {
+	d := New(sentinel.BotConfig{Resolver: googleResolver()})
+	classify(d, "66.249.66.1", googlebotUA, nil)
+	classify(d, "203.0.113.9", googlebotUA, nil)
+	classify(d, "192.0.2.1", "curl/8.5.0", nil)
+
+	s := d.Stats(time.Hour)
+	if s.Total != 3 || s.Verdicts[sentinel.BotVerified] != 1 || s.Verdicts[sentinel.BotBad] != 1 ||
+		s.Verdicts[sentinel.BotUnverified] != 1 || s.Crawlers["Googlebot"] != 2 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+
+	// A bucket from a week ago is not counted.
+	old := newStats()
+	old.add(&sentinel.BotResult{Verdict: sentinel.BotHuman}, time.Now().Add(-statsHours*time.Hour))
+	if got := old.sum(statsHours*time.Hour, time.Now()); got.Total != 0 {
+		t.Errorf("stale bucket counted: %+v", got)
+	}
+}
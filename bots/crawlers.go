@@ -0,0 +1,70 @@
+package bots
+
+import sentinel "github.com/MUKE-coder/sentinel/v2/core"
+
+// DefaultCrawlers are the crawlers verified out of the box. Each publishes
+// the domains its PTR records end in.
+var DefaultCrawlers = []sentinel.Crawler{
+	{
+		Name: "Googlebot",
+		UserAgents: []string{
+			"googlebot", "adsbot-google", "mediapartners-google", "google-inspectiontool",
+			"googleother", "storebot-google", "apis-google", "feedfetcher-google",
+		},
+		Domains: []string{"googlebot.com", "google.com", "googleusercontent.com"},
+	},
+	{
+		Name:       "Bingbot",
+		UserAgents: []string{"bingbot", "msnbot", "bingpreview", "adidxbot"},
+		Domains:    []string{"search.msn.com"},
+	},
+	{
+		Name:       "Applebot",
+		UserAgents: []string{"applebot"},
+		Domains:    []string{"applebot.apple.com"},
+	},
+	{
+		Name:       "YandexBot",
+		UserAgents: []string{"yandexbot", "yandex.com/bots"},
+		Domains:    []string{"yandex.ru", "yandex.net", "yandex.com"},
+	},
+	{
+		Name:       "Baiduspider",
+		UserAgents: []string{"baiduspider"},
+		Domains:    []string{"baidu.com", "baidu.jp"},
+	},
+	{
+		Name:       "Amazonbot",
+		UserAgents: []string{"amazonbot"},
+		Domains:    []string{"crawl.amazonbot.amazon"},
+	},
+	{
+		Name:       "SeznamBot",
+		UserAgents: []string{"seznambot"},
+		Domains:    []string{"seznam.cz"},
+	},
+}
+
+// User-Agent substrings, lowercase. attackTools are vulnerability scanners
+// and exploitation tools; automationTools are HTTP libraries and command
+// line clients; headlessTells are headless browsers and the frameworks
+// driving them; declaredBotTokens catch the many crawlers that say what
+// they are but cannot be verified.
+var (
+	attackTools = []string{
+		"sqlmap", "nikto", "nmap", "masscan", "zgrab", "nuclei", "wpscan",
+		"dirbuster", "gobuster", "ffuf", "feroxbuster", "acunetix", "nessus",
+		"openvas", "zmeu", "havij", "w3af", "arachni", "netsparker", "jaeles",
+	}
+	automationTools = []string{
+		"curl/", "wget/", "python-requests", "python-urllib", "python-httpx",
+		"aiohttp", "go-http-client", "java/", "okhttp", "apache-httpclient",
+		"axios/", "node-fetch", "undici", "libwww-perl", "scrapy", "httpie",
+		"postmanruntime", "insomnia", "guzzlehttp",
+	}
+	headlessTells = []string{
+		"headlesschrome", "phantomjs", "slimerjs", "puppeteer", "playwright",
+		"selenium", "webdriver",
+	}
+	declaredBotTokens = []string{"bot/", "bot;", "bot)", "crawler", "spider", "+http"}
+)
@@ -0,0 +1,80 @@
+package bots
+
+import (
+	"sync"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+)
+
+// statsHours is how many hourly buckets are kept: seven days.
+const statsHours = 7 * 24
+
+// stats counts classifications in a ring of hourly buckets.
+type stats struct {
+	mu      sync.Mutex
+	buckets [statsHours]bucket
+}
+
+type bucket struct {
+	hour     int64 // hours since the Unix epoch; a mismatch means stale
+	total    int64
+	verdicts map[sentinel.BotVerdict]int64
+	crawlers map[string]int64
+}
+
+func newStats() *stats {
+	return &stats{}
+}
+
+func (s *stats) add(res *sentinel.BotResult, now time.Time) {
+	h := now.Unix() / 3600
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := &s.buckets[h%statsHours]
+	if b.hour != h || b.verdicts == nil {
+		*b = bucket{
+			hour:     h,
+			verdicts: make(map[sentinel.BotVerdict]int64),
+			crawlers: make(map[string]int64),
+		}
+	}
+	b.total++
+	b.verdicts[res.Verdict]++
+	if res.Crawler != "" {
+		b.crawlers[res.Crawler]++
+	}
+}
+
+// sum adds up the buckets covering window, which is rounded up to whole
+// hours and capped at statsHours.
+func (s *stats) sum(window time.Duration, now time.Time) *sentinel.BotStats {
+	hours := int64((window + time.Hour - 1) / time.Hour)
+	hours = max(1, min(hours, statsHours))
+	out := &sentinel.BotStats{
+		Window:   (time.Duration(hours) * time.Hour).String(),
+		Verdicts: map[sentinel.BotVerdict]int64{},
+		Crawlers: map[string]int64{},
+	}
+	for _, v := range []sentinel.BotVerdict{sentinel.BotHuman, sentinel.BotVerified, sentinel.BotUnverified, sentinel.BotBad} {
+		out.Verdicts[v] = 0
+	}
+
+	h := now.Unix() / 3600
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := int64(0); i < hours; i++ {
+		b := &s.buckets[(h-i)%statsHours]
+		if b.hour != h-i || b.verdicts == nil {
+			continue
+		}
+		out.Total += b.total
+		for v, n := range b.verdicts {
+			out.Verdicts[v] += n
+		}
+		for c, n := range b.crawlers {
+			out.Crawlers[c] += n
+		}
+	}
+	return out
+}
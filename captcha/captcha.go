@@ -43,9 +43,10 @@ var ErrInvalid = errors.New("captcha: invalid token")
 
 // HCaptchaProvider verifies tokens with hCaptcha's siteverify endpoint.
 type HCaptchaProvider struct {
-	secret string
-	client *http.Client
-	url    string
+	secret  string
+	siteKey string
+	client  *http.Client
+	url     string
 }
 
 // NewHCaptchaProvider returns a Provider that verifies against hCaptcha.
@@ -69,9 +70,10 @@ func (h *HCaptchaProvider) Verify(ctx context.Context, token, clientIP string) e
 
 // TurnstileProvider verifies tokens with Cloudflare Turnstile.
 type TurnstileProvider struct {
-	secret string
-	client *http.Client
-	url    string
+	secret  string
+	siteKey string
+	client  *http.Client
+	url     string
 }
 
 // NewTurnstileProvider returns a Provider that verifies against Cloudflare Turnstile.
@@ -95,9 +97,10 @@ func (t *TurnstileProvider) Verify(ctx context.Context, token, clientIP string)
 
 // RecaptchaProvider verifies tokens with Google reCAPTCHA v2.
 type RecaptchaProvider struct {
-	secret string
-	client *http.Client
-	url    string
+	secret  string
+	siteKey string
+	client  *http.Client
+	url     string
 }
 
 // NewRecaptchaProvider returns a Provider that verifies against Google reCAPTCHA.
@@ -25,8 +25,11 @@ type SelfHostedChallenge struct {
 // IssueSelfHostedChallenge produces a fresh arithmetic challenge plus the
 // signing-aware ID the client must echo back along with their answer.
 //
-// Token returned by the client: "<id>.<answer>.<signature>" base64-url
-// encoded.
+// ID shape: "<nonce>.<expires-at>.<signature>", where signature is an HMAC
+// over the nonce, the correct answer and the expiry. The answer itself is
+// never in the ID — the page serving the challenge hands the ID to the
+// client, so anything in it is readable by a bot. Token returned by the
+// client: "<id>.<answer>".
 func IssueSelfHostedChallenge(secret string) (SelfHostedChallenge, error) {
 	if secret == "" {
 		return SelfHostedChallenge{}, errors.New("captcha: empty secret")
@@ -37,37 +40,39 @@ func IssueSelfHostedChallenge(secret string) (SelfHostedChallenge, error) {
 	}
 	a := int(buf[0])%9 + 1
 	b := int(buf[1])%9 + 1
-	answer := a + b
 	expiresAt := time.Now().Add(2 * time.Minute).Unix()
 
-	id := base64.RawURLEncoding.EncodeToString(buf[:])
-	mac := hmac.New(sha256.New, []byte(secret))
-	expBytes := make([]byte, 8)
-	binary.BigEndian.PutUint64(expBytes, uint64(expiresAt))
-	mac.Write([]byte(id))
-	mac.Write([]byte(strconv.Itoa(answer)))
-	mac.Write(expBytes)
-	sig := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
-
-	// Embed answer + expiry into the id so the verifier doesn't need state.
-	token := fmt.Sprintf("%s.%d.%d.%s", id, answer, expiresAt, sig)
+	nonce := base64.RawURLEncoding.EncodeToString(buf[:])
+	sig := selfHostedSignature(secret, nonce, strconv.Itoa(a+b), expiresAt)
 
 	return SelfHostedChallenge{
-		ID:        token,
+		ID:        fmt.Sprintf("%s.%d.%s", nonce, expiresAt, sig),
 		Question:  fmt.Sprintf("%d + %d = ?", a, b),
 		ExpiresAt: expiresAt,
 	}, nil
 }
 
+func selfHostedSignature(secret, nonce, answer string, expiresAt int64) string {
+	expBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(expBytes, uint64(expiresAt))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(nonce))
+	mac.Write([]byte(answer))
+	mac.Write(expBytes)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
 // verifySelfHostedToken parses and validates a token of shape produced by
 // IssueSelfHostedChallenge plus the user's claimed answer in the last segment.
-// Expected token: "<id>.<correct-answer>.<expires-at>.<sig>.<user-answer>"
+// Expected token: "<nonce>.<expires-at>.<sig>.<user-answer>". The signature
+// only matches when it is recomputed with the correct answer, so a wrong
+// answer and a forged signature are indistinguishable.
 func verifySelfHostedToken(secret, token string) error {
 	parts := strings.Split(token, ".")
-	if len(parts) != 5 {
+	if len(parts) != 4 {
 		return errors.New("malformed token")
 	}
-	id, correctStr, expStr, sig, userStr := parts[0], parts[1], parts[2], parts[3], parts[4]
+	nonce, expStr, sig, userStr := parts[0], parts[1], parts[2], parts[3]
 
 	expiresAt, err := strconv.ParseInt(expStr, 10, 64)
 	if err != nil {
@@ -77,18 +82,8 @@ func verifySelfHostedToken(secret, token string) error {
 		return errors.New("expired")
 	}
 
-	expBytes := make([]byte, 8)
-	binary.BigEndian.PutUint64(expBytes, uint64(expiresAt))
-	mac := hmac.New(sha256.New, []byte(secret))
-	mac.Write([]byte(id))
-	mac.Write([]byte(correctStr))
-	mac.Write(expBytes)
-	expected := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	expected := selfHostedSignature(secret, nonce, userStr, expiresAt)
 	if !hmac.Equal([]byte(sig), []byte(expected)) {
-		return errors.New("bad signature")
-	}
-
-	if correctStr != userStr {
 		return errors.New("wrong answer")
 	}
 	return nil
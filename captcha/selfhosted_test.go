@@ -3,24 +3,32 @@ package captcha
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 	"testing"
 )
 
+// solve answers the "a + b = ?" question the way a human would.
+func solve(t *testing.T, question string) int {
+	t.Helper()
+	var a, b int
+	if _, err := fmt.Sscanf(question, "%d + %d = ?", &a, &b); err != nil {
+		t.Fatalf("unexpected question %q: %v", question, err)
+	}
+	return a + b
+}
+
 func TestSelfHosted_RoundTrip(t *testing.T) {
 	secret := "test-secret"
 	ch, err := IssueSelfHostedChallenge(secret)
 	if err != nil {
 		t.Fatalf("issue: %v", err)
 	}
-	// Question is "a + b = ?" — extract the correct answer baked into the ID.
-	parts := strings.Split(ch.ID, ".")
-	if len(parts) != 4 {
+	if parts := strings.Split(ch.ID, "."); len(parts) != 3 {
 		t.Fatalf("issued token shape unexpected: %q", ch.ID)
 	}
-	correct := parts[1]
-	token := ch.ID + "." + correct
+	token := ch.ID + "." + strconv.Itoa(solve(t, ch.Question))
 
 	p := NewSelfHostedProvider(secret)
 	if err := p.Verify(context.Background(), token, ""); err != nil {
@@ -28,12 +36,26 @@ func TestSelfHosted_RoundTrip(t *testing.T) {
 	}
 }
 
+// The ID is handed to the client, so it must not give the answer away.
+func TestSelfHosted_IDDoesNotRevealAnswer(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		ch, err := IssueSelfHostedChallenge("test-secret")
+		if err != nil {
+			t.Fatalf("issue: %v", err)
+		}
+		answer := strconv.Itoa(solve(t, ch.Question))
+		for _, part := range strings.Split(ch.ID, ".") {
+			if part == answer {
+				t.Fatalf("challenge ID %q carries the answer %s", ch.ID, answer)
+			}
+		}
+	}
+}
+
 func TestSelfHosted_WrongAnswer(t *testing.T) {
 	secret := "test-secret"
 	ch, _ := IssueSelfHostedChallenge(secret)
-	parts := strings.Split(ch.ID, ".")
-	correct, _ := strconv.Atoi(parts[1])
-	wrong := strconv.Itoa(correct + 1)
+	wrong := strconv.Itoa(solve(t, ch.Question) + 1)
 
 	p := NewSelfHostedProvider(secret)
 	if err := p.Verify(context.Background(), ch.ID+"."+wrong, ""); !errors.Is(err, ErrInvalid) {
@@ -43,8 +65,7 @@ func TestSelfHosted_WrongAnswer(t *testing.T) {
 
 func TestSelfHosted_BadSignature(t *testing.T) {
 	ch, _ := IssueSelfHostedChallenge("real-secret")
-	parts := strings.Split(ch.ID, ".")
-	token := ch.ID + "." + parts[1]
+	token := ch.ID + "." + strconv.Itoa(solve(t, ch.Question))
 
 	p := NewSelfHostedProvider("wrong-secret")
 	if err := p.Verify(context.Background(), token, ""); !errors.Is(err, ErrInvalid) {
@@ -0,0 +1,113 @@
+package captcha
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
+)
+
+// Widget is implemented by providers that can render their browser-side
+// widget. The WAF challenge page needs it: unlike the AuthShield tier, it has
+// no application frontend to host the widget, so Sentinel renders the form
+// itself.
+type Widget interface {
+	// WidgetHTML returns the markup (script tag included) placed inside
+	// the challenge <form>. It fails when the provider lacks what it needs
+	// to render, e.g. a commercial provider without a site key.
+	WidgetHTML() (template.HTML, error)
+
+	// TokenFromForm extracts the token the widget submitted with the form,
+	// in the shape Verify expects.
+	TokenFromForm(form url.Values) string
+}
+
+// ErrNoSiteKey is returned by WidgetHTML when a commercial provider was
+// built without a site key. Verification works without one; rendering does
+// not.
+var ErrNoSiteKey = errors.New("captcha: no site key configured")
+
+// Compile-time checks that every built-in provider can render.
+var (
+	_ Widget = (*HCaptchaProvider)(nil)
+	_ Widget = (*TurnstileProvider)(nil)
+	_ Widget = (*RecaptchaProvider)(nil)
+	_ Widget = (*SelfHostedProvider)(nil)
+)
+
+// commercialWidget renders the script + container markup all three
+// commercial providers share.
+func commercialWidget(script, class, siteKey string) (template.HTML, error) {
+	if siteKey == "" {
+		return "", ErrNoSiteKey
+	}
+	return template.HTML(fmt.Sprintf(
+		`<script src="%s" async defer></script><div class="%s" data-sitekey="%s"></div>`,
+		script, class, template.HTMLEscapeString(siteKey))), nil
+}
+
+// SetSiteKey sets the public site key used to render the widget.
+func (h *HCaptchaProvider) SetSiteKey(key string) { h.siteKey = key }
+
+// WidgetHTML implements Widget.
+func (h *HCaptchaProvider) WidgetHTML() (template.HTML, error) {
+	return commercialWidget("https://js.hcaptcha.com/1/api.js", "h-captcha", h.siteKey)
+}
+
+// TokenFromForm implements Widget.
+func (h *HCaptchaProvider) TokenFromForm(form url.Values) string {
+	return form.Get("h-captcha-response")
+}
+
+// SetSiteKey sets the public site key used to render the widget.
+func (t *TurnstileProvider) SetSiteKey(key string) { t.siteKey = key }
+
+// WidgetHTML implements Widget.
+func (t *TurnstileProvider) WidgetHTML() (template.HTML, error) {
+	return commercialWidget("https://challenges.cloudflare.com/turnstile/v0/api.js", "cf-turnstile", t.siteKey)
+}
+
+// TokenFromForm implements Widget.
+func (t *TurnstileProvider) TokenFromForm(form url.Values) string {
+	return form.Get("cf-turnstile-response")
+}
+
+// SetSiteKey sets the public site key used to render the widget.
+func (r *RecaptchaProvider) SetSiteKey(key string) { r.siteKey = key }
+
+// WidgetHTML implements Widget.
+func (r *RecaptchaProvider) WidgetHTML() (template.HTML, error) {
+	return commercialWidget("https://www.google.com/recaptcha/api.js", "g-recaptcha", r.siteKey)
+}
+
+// TokenFromForm implements Widget.
+func (r *RecaptchaProvider) TokenFromForm(form url.Values) string {
+	return form.Get("g-recaptcha-response")
+}
+
+// WidgetHTML implements Widget. Each call issues a fresh arithmetic
+// challenge; the signed challenge ID rides along in a hidden field, so no
+// server-side state is kept.
+func (s *SelfHostedProvider) WidgetHTML() (template.HTML, error) {
+	ch, err := IssueSelfHostedChallenge(s.secret)
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(fmt.Sprintf(
+		`<label for="captcha_answer">%s</label>`+
+			`<input id="captcha_answer" name="captcha_answer" inputmode="numeric" autocomplete="off" required autofocus>`+
+			`<input type="hidden" name="captcha_challenge" value="%s">`,
+		template.HTMLEscapeString(ch.Question), template.HTMLEscapeString(ch.ID))), nil
+}
+
+// TokenFromForm implements Widget, joining the echoed challenge ID and the
+// user's answer into the token shape verifySelfHostedToken expects.
+func (s *SelfHostedProvider) TokenFromForm(form url.Values) string {
+	challenge := form.Get("captcha_challenge")
+	answer := strings.TrimSpace(form.Get("captcha_answer"))
+	if challenge == "" || answer == "" {
+		return ""
+	}
+	return challenge + "." + answer
+}
@@ -4,26 +4,44 @@ import "github.com/MUKE-coder/sentinel/v2/core"
 
 // Type aliases — re-export all config types from core.
 type (
-	Config             = core.Config
-	DashboardConfig    = core.DashboardConfig
-	StorageConfig      = core.StorageConfig
-	WAFConfig          = core.WAFConfig
-	RuleSet            = core.RuleSet
-	WAFRule            = core.WAFRule
-	Limit              = core.Limit
-	RateLimitConfig    = core.RateLimitConfig
-	AuthShieldConfig   = core.AuthShieldConfig
-	HeaderConfig       = core.HeaderConfig
-	AnomalyConfig      = core.AnomalyConfig
-	IPReputationConfig = core.IPReputationConfig
-	GeoConfig          = core.GeoConfig
-	AlertConfig        = core.AlertConfig
-	SlackConfig        = core.SlackConfig
-	EmailConfig        = core.EmailConfig
-	WebhookConfig      = core.WebhookConfig
-	PagerDutyConfig    = core.PagerDutyConfig
-	AIConfig           = core.AIConfig
-	UserContext        = core.UserContext
-	PerformanceConfig  = core.PerformanceConfig
-	CAPTCHAConfig      = core.CAPTCHAConfig
+	Config                   = core.Config
+	DashboardConfig          = core.DashboardConfig
+	StorageConfig            = core.StorageConfig
+	WAFConfig                = core.WAFConfig
+	RuleSet                  = core.RuleSet
+	WAFRule                  = core.WAFRule
+	RuleCondition            = core.RuleCondition
+	ChallengeConfig          = core.ChallengeConfig
+	AnomalyScoringConfig     = core.AnomalyScoringConfig
+	Limit                    = core.Limit
+	RateLimitConfig          = core.RateLimitConfig
+	RedisConfig              = core.RedisConfig
+	AuthShieldConfig         = core.AuthShieldConfig
+	HeaderConfig             = core.HeaderConfig
+	DLPConfig                = core.DLPConfig
+	AnomalyConfig            = core.AnomalyConfig
+	CredentialStuffingConfig = core.CredentialStuffingConfig
+	SessionRiskConfig        = core.SessionRiskConfig
+	CampaignConfig           = core.CampaignConfig
+	FingerprintConfig        = core.FingerprintConfig
+	BotConfig                = core.BotConfig
+	GeoFenceConfig           = core.GeoFenceConfig
+	GeoPolicy                = core.GeoPolicy
+	Crawler                  = core.Crawler
+	BotResolver              = core.BotResolver
+	IPReputationConfig       = core.IPReputationConfig
+	ThreatFeed               = core.ThreatFeed
+	NetworkConfig            = core.NetworkConfig
+	GeoConfig                = core.GeoConfig
+	AlertConfig              = core.AlertConfig
+	SlackConfig              = core.SlackConfig
+	EmailConfig              = core.EmailConfig
+	WebhookConfig            = core.WebhookConfig
+	PagerDutyConfig          = core.PagerDutyConfig
+	AIConfig                 = core.AIConfig
+	UserContext              = core.UserContext
+	PerformanceConfig        = core.PerformanceConfig
+	CAPTCHAConfig            = core.CAPTCHAConfig
+	ConfigOverrides          = core.ConfigOverrides
+	OIDCConfig               = core.OIDCConfig
 )
@@ -6,8 +6,10 @@ import "github.com/MUKE-coder/sentinel/v2/core"
 type (
 	Severity           = core.Severity
 	WAFMode            = core.WAFMode
+	ChallengeOutcome   = core.ChallengeOutcome
 	StorageDriver      = core.StorageDriver
 	ActorStatus        = core.ActorStatus
+	CampaignStatus     = core.CampaignStatus
 	RateLimitStrategy  = core.RateLimitStrategy
 	RuleSensitivity    = core.RuleSensitivity
 	AnomalyCheckType   = core.AnomalyCheckType
@@ -15,6 +17,18 @@ type (
 	AIProvider         = core.AIProvider
 	GeoProvider        = core.GeoProvider
 	ThreatType         = core.ThreatType
+	DashboardRole      = core.DashboardRole
+	Transform          = core.Transform
+	DLPClass           = core.DLPClass
+	DLPAction          = core.DLPAction
+	SessionRiskAction  = core.SessionRiskAction
+	BotVerdict         = core.BotVerdict
+	BotAction          = core.BotAction
+	NetworkType        = core.NetworkType
+	FeedFormat         = core.FeedFormat
+	FeedAction         = core.FeedAction
+	ConditionType      = core.ConditionType
+	ConditionOp        = core.ConditionOp
 )
 
 // Constant re-exports.
@@ -28,6 +42,9 @@ const (
 	ModeBlock     = core.ModeBlock
 	ModeChallenge = core.ModeChallenge
 
+	ChallengeIssued  = core.ChallengeIssued
+	ChallengeCleared = core.ChallengeCleared
+
 	SQLite   = core.SQLite
 	Postgres = core.Postgres
 	MySQL    = core.MySQL
@@ -37,6 +54,9 @@ const (
 	ActorBlocked     = core.ActorBlocked
 	ActorWhitelisted = core.ActorWhitelisted
 
+	CampaignActive  = core.CampaignActive
+	CampaignBlocked = core.CampaignBlocked
+
 	FixedWindow   = core.FixedWindow
 	SlidingWindow = core.SlidingWindow
 	TokenBucket   = core.TokenBucket
@@ -63,6 +83,67 @@ const (
 
 	GeoIPFree = core.GeoIPFree
 	GeoIPPaid = core.GeoIPPaid
+	GeoIPAPI  = core.GeoIPAPI
+
+	RoleViewer  = core.RoleViewer
+	RoleAnalyst = core.RoleAnalyst
+	RoleAdmin   = core.RoleAdmin
+
+	TransformURLDecode          = core.TransformURLDecode
+	TransformHTMLEntityDecode   = core.TransformHTMLEntityDecode
+	TransformNormalizeUnicode   = core.TransformNormalizeUnicode
+	TransformRemoveNulls        = core.TransformRemoveNulls
+	TransformRemoveComments     = core.TransformRemoveComments
+	TransformCompressWhitespace = core.TransformCompressWhitespace
+	TransformLowercase          = core.TransformLowercase
+
+	DLPDatabaseError = core.DLPDatabaseError
+	DLPStackTrace    = core.DLPStackTrace
+	DLPCardNumber    = core.DLPCardNumber
+	DLPSecretKey     = core.DLPSecretKey
+	DLPActionLog     = core.DLPActionLog
+	DLPActionMask    = core.DLPActionMask
+	DLPActionReplace = core.DLPActionReplace
+
+	SessionActionLog       = core.SessionActionLog
+	SessionActionChallenge = core.SessionActionChallenge
+	SessionActionRevoke    = core.SessionActionRevoke
+
+	BotHuman           = core.BotHuman
+	BotVerified        = core.BotVerified
+	BotUnverified      = core.BotUnverified
+	BotBad             = core.BotBad
+	BotActionInspect   = core.BotActionInspect
+	BotActionAllow     = core.BotActionAllow
+	BotActionChallenge = core.BotActionChallenge
+	BotActionBlock     = core.BotActionBlock
+
+	NetworkResidential = core.NetworkResidential
+	NetworkHosting     = core.NetworkHosting
+	NetworkVPN         = core.NetworkVPN
+	NetworkTor         = core.NetworkTor
+
+	FeedText        = core.FeedText
+	FeedCSV         = core.FeedCSV
+	FeedSTIX        = core.FeedSTIX
+	FeedActionBlock = core.FeedActionBlock
+	FeedActionFlag  = core.FeedActionFlag
+	FeedActionScore = core.FeedActionScore
+
+	ConditionMethod   = core.ConditionMethod
+	ConditionPath     = core.ConditionPath
+	ConditionHeader   = core.ConditionHeader
+	ConditionParam    = core.ConditionParam
+	ConditionIP       = core.ConditionIP
+	ConditionCountry  = core.ConditionCountry
+	ConditionBodySize = core.ConditionBodySize
+	ConditionExists   = core.ConditionExists
+	ConditionEquals   = core.ConditionEquals
+	ConditionContains = core.ConditionContains
+	ConditionPrefix   = core.ConditionPrefix
+	ConditionRegex    = core.ConditionRegex
+	ConditionGreater  = core.ConditionGreater
+	ConditionLess     = core.ConditionLess
 
 	ThreatSQLi               = core.ThreatSQLi
 	ThreatXSS                = core.ThreatXSS
@@ -77,6 +158,14 @@ const (
 	ThreatBruteForce         = core.ThreatBruteForce
 	ThreatAnomalyDetected    = core.ThreatAnomalyDetected
 	ThreatScanning           = core.ThreatScanning
+	ThreatDataLeak           = core.ThreatDataLeak
+	ThreatCredentialStuffing = core.ThreatCredentialStuffing
+	ThreatAccountTakeover    = core.ThreatAccountTakeover
+	ThreatDeniedFingerprint  = core.ThreatDeniedFingerprint
+	ThreatBadBot             = core.ThreatBadBot
+	ThreatIntelMatch         = core.ThreatIntelMatch
+	ThreatGeoFenceViolation  = core.ThreatGeoFenceViolation
+	ThreatNetworkPolicy      = core.ThreatNetworkPolicy
 )
 
 // Var re-exports.
@@ -1,6 +1,10 @@
 package core
 
 import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"sort"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -9,20 +13,27 @@ import (
 // Config is the main configuration struct for Sentinel.
 // All fields have sensible defaults — core.Config{} works out of the box.
 type Config struct {
-	Dashboard   DashboardConfig
-	Storage     StorageConfig
-	WAF         WAFConfig
-	RateLimit   RateLimitConfig
-	AuthShield  AuthShieldConfig
-	Headers     HeaderConfig
-	Anomaly     AnomalyConfig
-	IPReputation IPReputationConfig
-	Geo         GeoConfig
-	Alerts      AlertConfig
-	AI          *AIConfig
+	Dashboard     DashboardConfig
+	Storage       StorageConfig
+	WAF           WAFConfig
+	RateLimit     RateLimitConfig
+	AuthShield    AuthShieldConfig
+	Headers       HeaderConfig
+	DLP           DLPConfig
+	Anomaly       AnomalyConfig
+	SessionRisk   SessionRiskConfig
+	Campaigns     CampaignConfig
+	Fingerprint   FingerprintConfig
+	Bots          BotConfig
+	IPReputation  IPReputationConfig
+	Networks      NetworkConfig
+	Geo           GeoConfig
+	GeoFence      GeoFenceConfig
+	Alerts        AlertConfig
+	AI            *AIConfig
 	UserExtractor func(c *gin.Context) *UserContext
-	Performance PerformanceConfig
-	CAPTCHA     CAPTCHAConfig
+	Performance   PerformanceConfig
+	CAPTCHA       CAPTCHAConfig
 }
 
 // CAPTCHAConfig configures the CAPTCHA tier used by AuthShield. Pick exactly
@@ -34,6 +45,13 @@ type CAPTCHAConfig struct {
 	TurnstileSecret  string
 	RecaptchaSecret  string
 	SelfHostedSecret string
+
+	// Site keys are the public half of each commercial provider's key
+	// pair. Verification only needs the secret; rendering the widget on
+	// the WAF challenge page also needs the matching site key.
+	HCaptchaSiteKey  string
+	TurnstileSiteKey string
+	RecaptchaSiteKey string
 }
 
 // DashboardConfig configures the embedded security dashboard.
@@ -49,6 +67,55 @@ type DashboardConfig struct {
 	// (GIN_MODE=release) Mount will refuse to start unless this is true,
 	// preventing accidental deployment with forgeable admin tokens.
 	AllowInsecureDefaults bool
+
+	// OIDC enables single sign-on through an OpenID Connect provider.
+	// Nil keeps password login only.
+	OIDC *OIDCConfig
+}
+
+// OIDCConfig configures dashboard login through an OpenID Connect
+// provider using the authorization-code flow with PKCE. Signed-in users
+// get a regular dashboard token, with a role mapped from an ID token claim.
+type OIDCConfig struct {
+	// IssuerURL is the provider's issuer; its discovery document is read
+	// from IssuerURL + "/.well-known/openid-configuration".
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string // empty for a public client
+
+	// RedirectURL is the absolute callback URL registered with the
+	// provider: the public origin + Dashboard.Prefix + "/api/auth/oidc/callback".
+	RedirectURL string
+
+	// Scopes requested in addition to "openid". Default: profile, email.
+	Scopes []string
+
+	// UsernameClaim names the ID token claim used as the dashboard
+	// username. Default "email"; "sub" is used when the claim is missing.
+	// An email is only accepted with email_verified set. The username is
+	// prefixed with "oidc:", so it never matches a local account.
+	UsernameClaim string
+
+	// RoleClaim names the claim whose value(s) are looked up in
+	// RoleMapping. It may hold a string or a list of strings. Default "groups".
+	RoleClaim string
+
+	// RoleMapping maps RoleClaim values to dashboard roles. A user matching
+	// several entries gets the highest role.
+	RoleMapping map[string]DashboardRole
+
+	// DefaultRole is given to users matching no RoleMapping entry. Empty
+	// refuses them.
+	DefaultRole DashboardRole
+
+	// DisablePasswordLogin turns off /api/auth/login so the IdP is the only
+	// way in.
+	DisablePasswordLogin bool
+
+	// SessionTTL is how long a dashboard token issued after SSO stays
+	// valid. The role is not re-checked with the provider, so a user
+	// removed from a mapped group keeps it until then. Default 1h.
+	SessionTTL time.Duration
 }
 
 // StorageConfig configures the storage backend.
@@ -67,6 +134,12 @@ type WAFConfig struct {
 	Rules       RuleSet
 	CustomRules []WAFRule
 
+	// RuleFiles lists ModSecurity rule files to import as custom rules at
+	// mount. Only a subset of SecLang is understood (see
+	// detection.ParseSecLang); anything else is logged and skipped.
+	// Imported rules are not editable from the dashboard.
+	RuleFiles []string
+
 	// ExcludeRoutes lists paths the WAF will not inspect. Entries may be
 	// exact ("/health"), trailing-wildcard prefixes ("/v1/*" or "/v1/**" —
 	// both match "/v1" and everything under "/v1/"), segment globs
@@ -75,7 +148,12 @@ type WAFConfig struct {
 	// ("/api/apps/*/products/**" — the parameterised subtree and any depth
 	// below it).
 	ExcludeRoutes []string
-	ExcludeIPs    []string
+
+	// ExcludeIPs lists clients the WAF lets through uninspected: addresses
+	// ("203.0.113.7"), CIDR prefixes ("10.0.0.0/8", "2001:db8::/32") or
+	// ASNs ("AS64496", matched through Geo). Unparseable entries are
+	// logged and ignored.
+	ExcludeIPs []string
 
 	// MaxBodyBytes is the maximum number of bytes the WAF will read and inspect
 	// from a request body. Default: 65536 (64 KB). Requests with bodies larger
@@ -93,6 +171,69 @@ type WAFConfig struct {
 	// ignored and only the direct connection IP is used. Strongly
 	// recommended in production behind a known reverse proxy.
 	TrustedProxies []string
+
+	// Challenge tunes ModeChallenge. Only used in that mode.
+	Challenge ChallengeConfig
+
+	// Scoring, when set, switches the WAF to anomaly scoring: ModeBlock
+	// and ModeChallenge act only on requests whose total score reaches the
+	// inbound threshold, instead of on any single match.
+	Scoring *AnomalyScoringConfig
+
+	// BotPolicy maps bot verdicts to what the WAF does with them, e.g.
+	// allow verified crawlers, challenge unverified bots and block bad
+	// ones. Missing verdicts get BotActionInspect. Needs BotConfig.Enabled.
+	BotPolicy map[BotVerdict]BotAction
+
+	// NetworkPolicy maps network types to what the WAF does with their
+	// requests: ModeBlock refuses them with 403, ModeChallenge serves the
+	// challenge, ModeLog records them; all three raise a NetworkPolicy
+	// threat. Missing types are inspected as usual. Requests BotPolicy
+	// allows skip it, so verified crawlers on hosting ranges get through.
+	// Needs NetworkConfig.Enabled.
+	NetworkPolicy map[NetworkType]WAFMode
+}
+
+// AnomalyScoringConfig configures OWASP CRS-style anomaly scoring. Every
+// built-in pattern and custom rule that matches a request adds its points
+// once, however many inputs it matched. A request whose total reaches the
+// threshold for its route is blocked or challenged according to Mode; one
+// below it passes and is recorded as in ModeLog. Each ThreatEvent carries
+// the total, the threshold applied and the points per Evidence entry.
+type AnomalyScoringConfig struct {
+	// InboundThreshold is the score at which a request is acted on.
+	// Default: 5 — one critical match, or any two lesser ones.
+	InboundThreshold int
+
+	// RouteThresholds overrides InboundThreshold for route groups, e.g.
+	// a higher threshold for "/api/cms/**" where rich content is normal.
+	// Keys use the WAFConfig.ExcludeRoutes pattern shapes. An exact key
+	// wins over patterns, and the longest matching pattern wins over
+	// shorter ones.
+	RouteThresholds map[string]int
+
+	// SeverityPoints scores matches whose pattern or rule sets no points
+	// of its own. Missing severities default to the CRS values: Critical
+	// 5, High 4, Medium 3, Low 2.
+	SeverityPoints map[Severity]int
+}
+
+// ChallengeConfig tunes WAF challenge mode. A browser request that trips a
+// rule gets an interstitial CAPTCHA page (rendered by the CAPTCHA provider
+// configured in CAPTCHAConfig); solving it sets a signed clearance cookie,
+// bound to the client IP, that lets the WAF pass that client's requests
+// until it expires. Non-browser clients get a 429 JSON response instead.
+type ChallengeConfig struct {
+	// Path is where the challenge form posts. The WAF intercepts it
+	// itself — no route needs registering. Default: "/__sentinel/challenge".
+	Path string
+
+	// ClearanceTTL is how long a solved challenge lets the client through.
+	// Default: 30 minutes.
+	ClearanceTTL time.Duration
+
+	// CookieName names the clearance cookie. Default: "sentinel_clearance".
+	CookieName string
 }
 
 // RuleSet configures the sensitivity of each built-in WAF rule.
@@ -116,6 +257,48 @@ type WAFRule struct {
 	Severity  Severity `json:"severity"`
 	Action    string   `json:"action"`
 	Enabled   bool     `json:"enabled"`
+
+	// Score is the rule's anomaly points under WAFConfig.Scoring. Zero
+	// scores the rule by its Severity.
+	Score int `json:"score,omitempty"`
+
+	// Transforms normalize each input, in order, before Pattern is matched
+	// against it, e.g. urlDecode then lowercase.
+	Transforms []Transform `json:"transforms,omitempty"`
+
+	// When restricts the rule to requests matching a condition tree. With
+	// an empty Pattern the rule fires whenever When holds, which is how a
+	// virtual patch for a known-vulnerable endpoint is written; with a
+	// Pattern, the pattern is only scanned for on matching requests.
+	When *RuleCondition `json:"when,omitempty"`
+}
+
+// RuleCondition is a node of a WAFRule's When tree. Set exactly one of All
+// (every child holds), Any (at least one holds), Not (the child does not
+// hold) or Type, which makes the node a leaf testing one request property.
+//
+// A leaf holds when the property matches any of Values. Op defaults to
+// equals, or to exists for a header or param leaf without Values. Method
+// and country compare case-insensitively with equals; path takes route
+// patterns (or regex); ip takes IPs and CIDRs; body_size takes gt or lt and
+// one number; header and param take every op but gt and lt.
+//
+//	// Block POST /api/v1/export?format=sql when X-Debug is present.
+//	When: &sentinel.RuleCondition{All: []sentinel.RuleCondition{
+//	    {Type: sentinel.ConditionMethod, Values: []string{"POST"}},
+//	    {Type: sentinel.ConditionPath, Values: []string{"/api/v1/export"}},
+//	    {Type: sentinel.ConditionHeader, Name: "X-Debug"},
+//	    {Type: sentinel.ConditionParam, Name: "format", Values: []string{"sql"}},
+//	}},
+type RuleCondition struct {
+	All []RuleCondition `json:"all,omitempty"`
+	Any []RuleCondition `json:"any,omitempty"`
+	Not *RuleCondition  `json:"not,omitempty"`
+
+	Type   ConditionType `json:"type,omitempty"`
+	Name   string        `json:"name,omitempty"`
+	Op     ConditionOp   `json:"op,omitempty"`
+	Values []string      `json:"values,omitempty"`
 }
 
 // Limit defines a rate limit with requests per time window.
@@ -140,22 +323,59 @@ type RateLimitConfig struct {
 	Global   *Limit
 	Strategy RateLimitStrategy
 
+	// ByFingerprint limits all requests sharing one TLS fingerprint (JA4,
+	// falling back to JA3), whatever their IP. Requests without a
+	// fingerprint are not counted; see FingerprintConfig.
+	ByFingerprint *Limit
+
+	// ByBot adds a per-IP limit for requests with the given bot verdict,
+	// e.g. a tight one for BotUnverified. ExemptBots lists verdicts that
+	// skip rate limiting altogether, such as BotVerified. Both need
+	// BotConfig.Enabled.
+	ByBot      map[BotVerdict]Limit
+	ExemptBots []BotVerdict
+
+	// ByNetwork adds a per-IP limit for requests from the given network
+	// type, e.g. a tight one for NetworkTor. Needs NetworkConfig.Enabled.
+	ByNetwork map[NetworkType]Limit
+
 	// ExcludeRoutes lists paths exempt from rate limiting. Plain entries
 	// match by prefix ("/static" also exempts "/static/app.js" — historical
 	// behavior, kept for compatibility); entries containing wildcards use
 	// the same pattern shapes as WAFConfig.ExcludeRoutes.
 	ExcludeRoutes   []string
 	UserIDExtractor func(c *gin.Context) string
+
+	// Redis, when set, keeps counters in a shared Redis-protocol server so
+	// every replica enforces one limit together. Nil keeps counters in
+	// process, which is correct only for a single replica.
+	Redis *RedisConfig
+}
+
+// RedisConfig points the rate limiter at a shared Redis-protocol server
+// (Redis, Valkey, KeyDB, Dragonfly).
+type RedisConfig struct {
+	Addr     string // host:port
+	Username string // ACL user; leave empty to authenticate with Password alone
+	Password string
+	DB       int
+
+	// KeyPrefix namespaces counter keys. Give each application sharing a
+	// server its own prefix. Default: "sentinel:rl:".
+	KeyPrefix string
+
+	// TLS, when non-nil, connects over TLS with this configuration.
+	TLS *tls.Config
 }
 
 // AuthShieldConfig configures authentication protection.
 type AuthShieldConfig struct {
-	Enabled                    bool
-	LoginRoute                 string
-	MaxFailedAttempts          int
-	LockoutDuration            time.Duration
+	Enabled                     bool
+	LoginRoute                  string
+	MaxFailedAttempts           int
+	LockoutDuration             time.Duration
 	CredentialStuffingDetection bool
-	BruteForceDetection        bool
+	BruteForceDetection         bool
 
 	// CAPTCHAThreshold is the failure count after which a CAPTCHA token is
 	// required on the next login attempt — the suspicious-but-not-locked
@@ -168,6 +388,12 @@ type AuthShieldConfig struct {
 	// the CAPTCHA token from. Header "X-Captcha-Token" is always also
 	// accepted. Default: "captcha_token".
 	CAPTCHATokenField string
+
+	// PasswordField is the form / JSON field holding the submitted
+	// password. AuthShield fingerprints it on failed logins so the
+	// credential stuffing check can see one password tried against many
+	// accounts; the password itself is never stored. Default: "password".
+	PasswordField string
 }
 
 // HeaderConfig configures security header injection.
@@ -181,12 +407,254 @@ type HeaderConfig struct {
 	PermissionsPolicy       string
 }
 
+// DLPConfig configures outbound response inspection. Responses are buffered
+// and checked for data that should never leave the application — database
+// errors, stack traces, card numbers and secret keys. Each leaking response
+// is recorded as a DataLeak threat and, depending on the action, masked or
+// replaced before it is sent.
+type DLPConfig struct {
+	Enabled bool
+
+	// Classes limits inspection to these classes. Empty checks all of them.
+	Classes []DLPClass
+
+	// Action applies to every class without an entry in ClassActions.
+	// Default: DLPActionLog. Masking keeps the body's length: card numbers
+	// keep their last four digits, secret keys their first four characters,
+	// and everything else in a finding becomes "*". When findings call for
+	// different actions, replace wins over mask and mask over log.
+	Action       DLPAction
+	ClassActions map[DLPClass]DLPAction
+
+	// ContentTypes lists the media types inspected, exactly or as "type/*".
+	// Other responses, and compressed ones, stream straight through.
+	// Default: JSON, problem+JSON, HTML, plain text and XML.
+	ContentTypes []string
+
+	// MaxBodyBytes bounds the buffer. A response that grows past it is
+	// flushed as is and streams through uninspected. Default: 256 KB.
+	MaxBodyBytes int64
+
+	// ExcludeRoutes lists routes whose responses are never inspected, in
+	// the shapes RouteMatcher accepts. Mount always excludes the dashboard.
+	ExcludeRoutes []string
+
+	// ReplaceStatus, ReplaceContentType and ReplaceBody make up the
+	// response DLPActionReplace sends instead. Defaults: 500 and a generic
+	// JSON error.
+	ReplaceStatus      int
+	ReplaceContentType string
+	ReplaceBody        string
+}
+
 // AnomalyConfig configures behavioral anomaly detection.
 type AnomalyConfig struct {
-	Enabled        bool
+	Enabled bool
+
+	// LearningPeriod is how long a user's baseline learns before checks
+	// run against it, and the time constant with which old activity
+	// fades out of it. Default: 7 days.
 	LearningPeriod time.Duration
-	Sensitivity    AnomalySensitivity
-	Checks         []AnomalyCheckType
+
+	Sensitivity AnomalySensitivity
+	Checks      []AnomalyCheckType
+
+	// CredentialStuffing tunes CheckCredentialStuffing, which looks at
+	// AuthShield's login attempts across all client IPs.
+	CredentialStuffing CredentialStuffingConfig
+}
+
+// CredentialStuffingConfig sets the thresholds of the cross-IP credential
+// stuffing check. Each applies to the login attempts of the last Window;
+// crossing any of them is reported. Zero values take the defaults.
+type CredentialStuffingConfig struct {
+	// Window is how far back login attempts are counted. Default: 10 minutes.
+	Window time.Duration
+
+	// NetworkUsernames is the number of distinct usernames failing from a
+	// single ASN, or from a single /24 (IPv6: /48) when the ASN is
+	// unknown. Default: 20.
+	NetworkUsernames int
+
+	// FailureRate is the share of failed login attempts, across all IPs,
+	// above which a distributed attack is reported: one where no single IP
+	// is fast enough for AuthShield's lockout. Default: 0.8.
+	FailureRate float64
+
+	// MinAttempts is the number of attempts in the window FailureRate
+	// needs before it applies, so a quiet site with two typos does not
+	// report. Default: 50.
+	MinAttempts int
+
+	// PasswordReuse is the number of distinct usernames one password
+	// failed against. Default: 5.
+	PasswordReuse int
+}
+
+// SessionRiskConfig configures account takeover detection: each session of a
+// user returned by Config.UserExtractor is scored on how it differs from the
+// user's earlier sessions. Signals and their points:
+//
+//   - new_device (25): a user agent the user has not used before
+//   - new_asn (25): a network (ASN) the user has not used before
+//   - impossible_travel (50): faster travel from the previous session's
+//     location than MaxTravelSpeed allows
+//   - sensitive_change (30): a write to one of SensitiveRoutes, such as a
+//     password or email change, within SensitiveWindow of the session's
+//     first request
+//
+// A session reaching Threshold raises an AccountTakeover threat and Action
+// is taken. Users' known devices and networks are learned from sessions
+// that stay below the threshold, and seeded from stored user activity.
+type SessionRiskConfig struct {
+	Enabled bool
+
+	// SessionIDExtractor returns the session a request belongs to, or "" for
+	// none. The default uses the Authorization header, or else the cookie
+	// named SessionCookie. Only a hash of the value is kept.
+	SessionIDExtractor func(c *gin.Context) string
+
+	// SessionCookie names the session cookie the default extractor reads.
+	// Default: "session".
+	SessionCookie string
+
+	// Threshold is the score at which Action is taken. Default: 50, so
+	// either a new device on a new network or impossible travel is enough.
+	Threshold int
+
+	// Action is taken once per session, when it reaches Threshold.
+	// SessionActionChallenge needs a CAPTCHA provider and falls back to
+	// logging without one. Default: SessionActionLog.
+	Action SessionRiskAction
+
+	// OnRevoke is called when SessionActionRevoke fires, to revoke the
+	// session in the host application. Sentinel also rejects the session's
+	// requests with 401 until it expires.
+	OnRevoke func(c *gin.Context, risk *SessionRisk)
+
+	// SensitiveRoutes are route patterns, as RouteMatcher accepts them, of
+	// account changes an attacker makes first: password, email, MFA.
+	// Requests to them other than GET and HEAD count.
+	SensitiveRoutes []string
+
+	// SensitiveWindow is how soon after a session starts a sensitive change
+	// counts. Default: 30 minutes.
+	SensitiveWindow time.Duration
+
+	// MaxTravelSpeed is the fastest plausible travel between sessions, in
+	// km/h. Default: 1000.
+	MaxTravelSpeed float64
+
+	// SessionTTL is how long an idle session is remembered. Default: 24 hours.
+	SessionTTL time.Duration
+}
+
+// CampaignConfig configures campaign clustering: threat actors (one per IP)
+// that share enough signals are grouped into one Campaign, so an attack
+// spread over many IPs is seen, scored and blocked as a whole. Signals and
+// their weights:
+//
+//   - payload (2): the same attack payload, normalized
+//   - user_agent (1): the same User-Agent
+//   - headers (1): the same set of request header names
+//   - asn (1): the same network (needs geolocation with an ASN database)
+//   - timing (1): threats within the same TimingBucket
+//   - tls (1): the same TLS fingerprint (needs FingerprintConfig.Source)
+//
+// Two actors are linked when the weights of the signals they share reach
+// LinkScore and include a payload, header set or TLS fingerprint.
+type CampaignConfig struct {
+	Enabled bool
+
+	// LinkScore is the shared signal weight that links two actors.
+	// Default: 3, so a shared UA and header set need a third signal.
+	LinkScore int
+
+	// Window is how long an actor's signals are remembered after its last
+	// threat. Default: 24 hours.
+	Window time.Duration
+
+	// TimingBucket is the width of the time slots the timing signal
+	// compares. Default: 10 minutes.
+	TimingBucket time.Duration
+}
+
+// FingerprintConfig configures TLS client fingerprinting. Sentinel sees the
+// ClientHello only when the application terminates TLS itself; the
+// fingerprint package provides a Recorder whose Source method plugs in
+// here, and a header Source for proxies that forward JA3/JA4 values.
+//
+// The request's fingerprint is recorded on every threat event and actor,
+// can be rate limited with RateLimitConfig.ByFingerprint, and can be
+// denied outright. Allow and Deny entries are JA3 or JA4 values; an entry
+// ending in "*" matches by prefix ("t13d1516h2_*").
+type FingerprintConfig struct {
+	// Source returns the fingerprint of the client that sent r, or nil when
+	// it is unknown (plain HTTP, or a connection seen before the hook was
+	// installed). Nil disables fingerprinting.
+	Source func(r *http.Request) *TLSFingerprint
+
+	// Deny lists fingerprints refused with 403 and a DeniedFingerprint
+	// threat.
+	Deny []string
+
+	// Allow lists fingerprints exempt from Deny and from
+	// RateLimitConfig.ByFingerprint, e.g. a trusted partner's client.
+	Allow []string
+}
+
+// BotConfig configures bot detection. Every request gets a bot score (0–100,
+// how likely it is automated) and a BotVerdict, which WAFConfig.BotPolicy
+// and RateLimitConfig.ByBot act on. A client whose User-Agent claims to be
+// a known crawler is checked with reverse-then-forward DNS: the IP's PTR
+// name must be under one of the crawler's domains and resolve back to the
+// IP.
+type BotConfig struct {
+	Enabled bool
+
+	// Crawlers are checked before the built-in list (Googlebot, Bingbot,
+	// Applebot, YandexBot, Baiduspider, Amazonbot, SeznamBot), so an entry
+	// with a built-in name replaces it.
+	Crawlers []Crawler
+
+	// Resolver answers the DNS lookups. Nil uses net.DefaultResolver;
+	// tests pass a fake to run offline.
+	Resolver BotResolver
+
+	// Threshold is the bot score at which a client that is not a crawler
+	// is judged BotUnverified. Default: 50.
+	Threshold int
+
+	// VerifyTimeout bounds one crawler verification. A lookup that times
+	// out leaves the client BotUnverified. Default: 2 seconds.
+	VerifyTimeout time.Duration
+
+	// CacheTTL is how long a verification result is reused for an IP.
+	// Default: 24 hours. Failed lookups are retried after a minute.
+	CacheTTL time.Duration
+
+	// ExcludeRoutes are route patterns whose requests are not classified.
+	ExcludeRoutes []string
+}
+
+// Crawler is a search engine or other well-behaved bot verified by DNS.
+type Crawler struct {
+	Name string
+
+	// UserAgents are case-insensitive substrings of the User-Agent that
+	// claim to be this crawler, e.g. "googlebot".
+	UserAgents []string
+
+	// Domains are the domains the crawler's PTR names end in, e.g.
+	// "googlebot.com".
+	Domains []string
+}
+
+// BotResolver performs the lookups of crawler verification.
+// *net.Resolver satisfies it.
+type BotResolver interface {
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
 }
 
 // IPReputationConfig configures IP reputation checking.
@@ -195,12 +663,139 @@ type IPReputationConfig struct {
 	AbuseIPDBKey  string
 	AutoBlock     bool
 	MinAbuseScore int
+
+	// Feeds are threat intelligence blocklists loaded into memory and
+	// refreshed on a schedule. They work without Enabled, which only
+	// governs AbuseIPDB lookups.
+	Feeds []ThreatFeed
+}
+
+// NetworkConfig configures network type detection. Every request from a
+// public IP is tagged with a NetworkType: NetworkTor, NetworkVPN or
+// NetworkHosting when one of the lists covers the IP, NetworkResidential
+// otherwise. The type is recorded on threat events and actors, adds to
+// actor risk scores, and can be acted on by WAFConfig.NetworkPolicy and
+// RateLimitConfig.ByNetwork.
+type NetworkConfig struct {
+	Enabled bool
+
+	// TorExitPaths, VPNPaths and HostingPaths are files of addresses and
+	// CIDR prefixes, one per line in the FeedText format — e.g. the Tor
+	// Project's bulk exit list, or a datacenter range list. An IP on
+	// several lists takes the most anonymizing type: Tor, then VPN, then
+	// hosting.
+	TorExitPaths []string
+	VPNPaths     []string
+	HostingPaths []string
+
+	// Refresh is how often the files are checked for changes. A replaced
+	// file is loaded without a restart; one that fails to load keeps its
+	// previous entries. Default: 1 hour.
+	Refresh time.Duration
+
+	// ScoreBoost is added to the risk score of actors seen on each network
+	// type. Missing types default to 20 for Tor, 10 for VPN, 5 for hosting
+	// and 0 for residential; negative disables the boost.
+	ScoreBoost map[NetworkType]int
+
+	// ExcludeRoutes are route patterns whose requests are not classified.
+	ExcludeRoutes []string
+}
+
+// ThreatFeed is a list of malicious IPs, CIDRs and domains, read from a
+// file or URL. IP and CIDR indicators are matched against the client IP;
+// domain indicators against the host of the Referer and Origin headers.
+type ThreatFeed struct {
+	// Name identifies the feed in stats, BlockedIP.Reason and
+	// ThreatEvent.FeedSource. Required and unique.
+	Name string
+
+	// URL or Path is where the feed is read from; set exactly one. URLs
+	// are fetched with conditional GETs, so an unchanged feed costs a 304.
+	URL  string
+	Path string
+
+	// Headers are sent with every fetch of URL, e.g. an API key.
+	Headers map[string]string
+
+	// Format defaults to FeedText.
+	Format FeedFormat
+
+	// Column is the zero-based CSV column holding the indicator.
+	Column int
+
+	// Action defaults to FeedActionBlock.
+	Action FeedAction
+
+	// ScoreBoost is added to the risk score of actors the feed lists,
+	// whatever the action. Defaults to 20, the weight of an AbuseIPDB
+	// known bad actor; negative disables it.
+	ScoreBoost int
+
+	// Refresh is how often the feed is reloaded. Defaults to one hour.
+	Refresh time.Duration
+
+	// Expiry drops the feed's indicators once this long has passed
+	// since it last loaded, so a feed whose source went away does not
+	// block forever on stale data. Zero keeps them until the next
+	// successful load. STIX indicators also expire at their valid_until.
+	Expiry time.Duration
 }
 
 // GeoConfig configures IP geolocation.
 type GeoConfig struct {
 	Enabled  bool
 	Provider GeoProvider
+
+	// DatabasePath is the MaxMind DB file (.mmdb) read by the GeoIPFree and
+	// GeoIPPaid providers: a GeoLite2 or GeoIP2 City or Country database.
+	DatabasePath string
+
+	// ASNDatabasePath optionally names a GeoLite2-ASN or GeoIP2-ISP
+	// database, used to fill in ASN and ISP.
+	ASNDatabasePath string
+
+	// ReloadInterval is how often the database files are checked for
+	// changes. A replaced file (e.g. by geoipupdate) is loaded without a
+	// restart. Defaults to one minute; negative disables reloading.
+	ReloadInterval time.Duration
+}
+
+// GeoFenceConfig restricts routes by client country or region. It needs
+// Geo enabled, ideally with a local database: every fenced request is
+// looked up. Policies can also be added and edited from the dashboard.
+type GeoFenceConfig struct {
+	Policies []GeoPolicy
+}
+
+// GeoPolicy allows or denies the clients of a set of routes by location.
+// Locations are ISO 3166-1 alpha-2 country codes ("US") or ISO 3166-2
+// region codes ("UA-43"); a country code covers all of its regions.
+//
+// A client is refused when Allow is set and lists neither its country nor
+// its region, or when Deny lists either. When several policies refuse a
+// request, the strictest mode applies. Clients on the IP whitelist are
+// never fenced.
+type GeoPolicy struct {
+	// ID names the policy in threat evidence and on the dashboard.
+	ID string `json:"id"`
+
+	// Routes lists the route patterns the policy covers (see
+	// RouteMatcher). Empty covers every route.
+	Routes []string `json:"routes,omitempty"`
+
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+
+	// Mode is ModeBlock (403, the default), ModeChallenge (the WAF
+	// challenge page; needs a CAPTCHA provider) or ModeLog (record a
+	// GeoFenceViolation threat and let the request through).
+	Mode WAFMode `json:"mode,omitempty"`
+
+	// FailClosed refuses clients whose location cannot be determined —
+	// lookup errors, addresses the database does not cover, and private
+	// addresses. By default they are let through.
+	FailClosed bool `json:"fail_closed,omitempty"`
 }
 
 // AlertConfig configures the alerting system.
@@ -295,6 +890,20 @@ func (c *Config) ApplyDefaults() {
 	if c.Dashboard.SecretKey == "" {
 		c.Dashboard.SecretKey = DefaultInsecureSecretKey
 	}
+	if o := c.Dashboard.OIDC; o != nil {
+		if o.Scopes == nil {
+			o.Scopes = []string{"profile", "email"}
+		}
+		if o.UsernameClaim == "" {
+			o.UsernameClaim = "email"
+		}
+		if o.RoleClaim == "" {
+			o.RoleClaim = "groups"
+		}
+		if o.SessionTTL <= 0 {
+			o.SessionTTL = time.Hour
+		}
+	}
 
 	if c.Storage.Driver == "" {
 		c.Storage.Driver = SQLite
@@ -315,6 +924,18 @@ func (c *Config) ApplyDefaults() {
 	if c.WAF.Mode == "" {
 		c.WAF.Mode = ModeLog
 	}
+	if c.WAF.Scoring != nil && c.WAF.Scoring.InboundThreshold == 0 {
+		c.WAF.Scoring.InboundThreshold = 5
+	}
+	if c.WAF.Challenge.Path == "" {
+		c.WAF.Challenge.Path = "/__sentinel/challenge"
+	}
+	if c.WAF.Challenge.ClearanceTTL == 0 {
+		c.WAF.Challenge.ClearanceTTL = 30 * time.Minute
+	}
+	if c.WAF.Challenge.CookieName == "" {
+		c.WAF.Challenge.CookieName = "sentinel_clearance"
+	}
 	if c.WAF.Rules.SQLInjection == "" {
 		c.WAF.Rules.SQLInjection = RuleStrict
 	}
@@ -359,6 +980,9 @@ func (c *Config) ApplyDefaults() {
 	if c.AuthShield.CAPTCHATokenField == "" {
 		c.AuthShield.CAPTCHATokenField = "captcha_token"
 	}
+	if c.AuthShield.PasswordField == "" {
+		c.AuthShield.PasswordField = "password"
+	}
 
 	if c.Headers.Enabled == nil {
 		enabled := true
@@ -372,12 +996,91 @@ func (c *Config) ApplyDefaults() {
 	}
 	c.Headers.XContentTypeOptions = true
 
+	if c.DLP.Action == "" {
+		c.DLP.Action = DLPActionLog
+	}
+	if c.DLP.ContentTypes == nil {
+		c.DLP.ContentTypes = []string{"application/json", "application/problem+json", "text/html", "text/plain", "application/xml", "text/xml"}
+	}
+	if c.DLP.MaxBodyBytes == 0 {
+		c.DLP.MaxBodyBytes = 256 * 1024
+	}
+	if c.DLP.ReplaceStatus == 0 {
+		c.DLP.ReplaceStatus = 500
+	}
+	if c.DLP.ReplaceBody == "" {
+		c.DLP.ReplaceBody = `{"error":"Internal Server Error","code":"INTERNAL_ERROR"}`
+		if c.DLP.ReplaceContentType == "" {
+			c.DLP.ReplaceContentType = "application/json; charset=utf-8"
+		}
+	}
+	if c.DLP.ReplaceContentType == "" {
+		c.DLP.ReplaceContentType = "text/plain; charset=utf-8"
+	}
+
 	if c.Anomaly.LearningPeriod == 0 {
 		c.Anomaly.LearningPeriod = 7 * 24 * time.Hour
 	}
 	if c.Anomaly.Sensitivity == "" {
 		c.Anomaly.Sensitivity = AnomalySensitivityMedium
 	}
+	if c.Anomaly.CredentialStuffing.Window == 0 {
+		c.Anomaly.CredentialStuffing.Window = 10 * time.Minute
+	}
+	if c.Anomaly.CredentialStuffing.NetworkUsernames == 0 {
+		c.Anomaly.CredentialStuffing.NetworkUsernames = 20
+	}
+	if c.Anomaly.CredentialStuffing.FailureRate == 0 {
+		c.Anomaly.CredentialStuffing.FailureRate = 0.8
+	}
+	if c.Anomaly.CredentialStuffing.MinAttempts == 0 {
+		c.Anomaly.CredentialStuffing.MinAttempts = 50
+	}
+	if c.Anomaly.CredentialStuffing.PasswordReuse == 0 {
+		c.Anomaly.CredentialStuffing.PasswordReuse = 5
+	}
+
+	if c.SessionRisk.SessionCookie == "" {
+		c.SessionRisk.SessionCookie = "session"
+	}
+	if c.SessionRisk.Threshold == 0 {
+		c.SessionRisk.Threshold = 50
+	}
+	if c.SessionRisk.Action == "" {
+		c.SessionRisk.Action = SessionActionLog
+	}
+	if c.SessionRisk.SensitiveWindow == 0 {
+		c.SessionRisk.SensitiveWindow = 30 * time.Minute
+	}
+	if c.SessionRisk.MaxTravelSpeed == 0 {
+		c.SessionRisk.MaxTravelSpeed = 1000
+	}
+	if c.SessionRisk.SessionTTL == 0 {
+		c.SessionRisk.SessionTTL = 24 * time.Hour
+	}
+
+	if c.Networks.Refresh == 0 {
+		c.Networks.Refresh = time.Hour
+	}
+	if c.Bots.Threshold == 0 {
+		c.Bots.Threshold = 50
+	}
+	if c.Bots.VerifyTimeout == 0 {
+		c.Bots.VerifyTimeout = 2 * time.Second
+	}
+	if c.Bots.CacheTTL == 0 {
+		c.Bots.CacheTTL = 24 * time.Hour
+	}
+
+	if c.Campaigns.LinkScore == 0 {
+		c.Campaigns.LinkScore = 3
+	}
+	if c.Campaigns.Window == 0 {
+		c.Campaigns.Window = 24 * time.Hour
+	}
+	if c.Campaigns.TimingBucket == 0 {
+		c.Campaigns.TimingBucket = 10 * time.Minute
+	}
 
 	if c.IPReputation.MinAbuseScore == 0 {
 		c.IPReputation.MinAbuseScore = 80
@@ -386,6 +1089,9 @@ func (c *Config) ApplyDefaults() {
 	if c.Geo.Provider == "" {
 		c.Geo.Provider = GeoIPFree
 	}
+	if c.Geo.ReloadInterval == 0 {
+		c.Geo.ReloadInterval = time.Minute
+	}
 
 	if c.Alerts.MinSeverity == "" {
 		c.Alerts.MinSeverity = SeverityHigh
@@ -406,3 +1112,156 @@ func (c *Config) ApplyDefaults() {
 		c.Performance.SlowRequestThreshold = 2 * time.Second
 	}
 }
+
+// ConfigOverrides is the part of Config an operator can change from the
+// dashboard. Overrides are persisted by a ConfigStore and layered over the
+// code-supplied Config on Mount, so dashboard edits survive a restart while
+// everything the operator never touched keeps following the code.
+//
+// Zero values mean "not overridden". Custom rules, route limits and
+// geofencing policies are layered per entry: code-supplied entries the
+// operator did not edit are kept, and the Deleted* lists record
+// code-supplied entries removed from the dashboard.
+type ConfigOverrides struct {
+	WAFMode            WAFMode              `json:"waf_mode,omitempty"`
+	WAFRules           *RuleSet             `json:"waf_rules,omitempty"`
+	CustomRules        map[string]WAFRule   `json:"custom_rules,omitempty"`
+	DeletedCustomRules []string             `json:"deleted_custom_rules,omitempty"`
+	RouteLimits        map[string]Limit     `json:"route_limits,omitempty"`
+	DeletedRouteLimits []string             `json:"deleted_route_limits,omitempty"`
+	AlertMinSeverity   Severity             `json:"alert_min_severity,omitempty"`
+	GeoPolicies        map[string]GeoPolicy `json:"geo_policies,omitempty"`
+	DeletedGeoPolicies []string             `json:"deleted_geo_policies,omitempty"`
+}
+
+// IsZero reports whether o overrides nothing.
+func (o ConfigOverrides) IsZero() bool {
+	return o.WAFMode == "" && o.WAFRules == nil && len(o.CustomRules) == 0 &&
+		len(o.DeletedCustomRules) == 0 && len(o.RouteLimits) == 0 &&
+		len(o.DeletedRouteLimits) == 0 && o.AlertMinSeverity == "" &&
+		len(o.GeoPolicies) == 0 && len(o.DeletedGeoPolicies) == 0
+}
+
+// Clone returns a deep copy of o.
+func (o ConfigOverrides) Clone() ConfigOverrides {
+	out := o
+	if o.WAFRules != nil {
+		rules := *o.WAFRules
+		out.WAFRules = &rules
+	}
+	if o.CustomRules != nil {
+		out.CustomRules = make(map[string]WAFRule, len(o.CustomRules))
+		for id, r := range o.CustomRules {
+			r.AppliesTo = append([]string(nil), r.AppliesTo...)
+			out.CustomRules[id] = r
+		}
+	}
+	if o.RouteLimits != nil {
+		out.RouteLimits = make(map[string]Limit, len(o.RouteLimits))
+		for route, l := range o.RouteLimits {
+			out.RouteLimits[route] = l
+		}
+	}
+	if o.GeoPolicies != nil {
+		out.GeoPolicies = make(map[string]GeoPolicy, len(o.GeoPolicies))
+		for id, p := range o.GeoPolicies {
+			p.Routes = append([]string(nil), p.Routes...)
+			p.Allow = append([]string(nil), p.Allow...)
+			p.Deny = append([]string(nil), p.Deny...)
+			out.GeoPolicies[id] = p
+		}
+	}
+	out.DeletedCustomRules = append([]string(nil), o.DeletedCustomRules...)
+	out.DeletedRouteLimits = append([]string(nil), o.DeletedRouteLimits...)
+	out.DeletedGeoPolicies = append([]string(nil), o.DeletedGeoPolicies...)
+	return out
+}
+
+// Apply layers o over c. Slices and maps in c are replaced, never modified
+// in place, so the code-supplied Config can be kept as the base for later
+// layering.
+func (o ConfigOverrides) Apply(c *Config) {
+	if o.WAFMode != "" {
+		c.WAF.Mode = o.WAFMode
+	}
+	if o.WAFRules != nil {
+		c.WAF.Rules = *o.WAFRules
+	}
+	if o.AlertMinSeverity != "" {
+		c.Alerts.MinSeverity = o.AlertMinSeverity
+	}
+
+	if len(o.CustomRules) > 0 || len(o.DeletedCustomRules) > 0 {
+		deleted := make(map[string]bool, len(o.DeletedCustomRules))
+		for _, id := range o.DeletedCustomRules {
+			deleted[id] = true
+		}
+		rules := make([]WAFRule, 0, len(c.WAF.CustomRules)+len(o.CustomRules))
+		seen := make(map[string]bool)
+		for _, r := range c.WAF.CustomRules {
+			if deleted[r.ID] {
+				continue
+			}
+			if edited, ok := o.CustomRules[r.ID]; ok {
+				r = edited
+			}
+			rules = append(rules, r)
+			seen[r.ID] = true
+		}
+		added := make([]string, 0, len(o.CustomRules))
+		for id := range o.CustomRules {
+			if !seen[id] {
+				added = append(added, id)
+			}
+		}
+		sort.Strings(added)
+		for _, id := range added {
+			rules = append(rules, o.CustomRules[id])
+		}
+		c.WAF.CustomRules = rules
+	}
+
+	if len(o.RouteLimits) > 0 || len(o.DeletedRouteLimits) > 0 {
+		limits := make(map[string]Limit, len(c.RateLimit.ByRoute)+len(o.RouteLimits))
+		for route, l := range c.RateLimit.ByRoute {
+			limits[route] = l
+		}
+		for _, route := range o.DeletedRouteLimits {
+			delete(limits, route)
+		}
+		for route, l := range o.RouteLimits {
+			limits[route] = l
+		}
+		c.RateLimit.ByRoute = limits
+	}
+
+	if len(o.GeoPolicies) > 0 || len(o.DeletedGeoPolicies) > 0 {
+		deleted := make(map[string]bool, len(o.DeletedGeoPolicies))
+		for _, id := range o.DeletedGeoPolicies {
+			deleted[id] = true
+		}
+		policies := make([]GeoPolicy, 0, len(c.GeoFence.Policies)+len(o.GeoPolicies))
+		seen := make(map[string]bool)
+		for _, p := range c.GeoFence.Policies {
+			if deleted[p.ID] {
+				continue
+			}
+			if edited, ok := o.GeoPolicies[p.ID]; ok {
+				p = edited
+			}
+			policies = append(policies, p)
+			seen[p.ID] = true
+		}
+		added := make([]string, 0, len(o.GeoPolicies))
+		for id := range o.GeoPolicies {
+			if !seen[id] {
+				added = append(added, id)
+			}
+		}
+		sort.Strings(added)
+		for _, id := range added {
+			policies = append(policies, o.GeoPolicies[id])
+		}
+		c.GeoFence.Policies = policies
+	}
+}
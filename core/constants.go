@@ -22,6 +22,14 @@ const (
 	ModeChallenge WAFMode = "challenge"
 )
 
+// ChallengeOutcome is what the WAF did with a request in challenge mode.
+type ChallengeOutcome string
+
+const (
+	ChallengeIssued  ChallengeOutcome = "issued"  // served the challenge page
+	ChallengeCleared ChallengeOutcome = "cleared" // let through on a valid clearance cookie
+)
+
 // StorageDriver specifies the storage backend to use.
 type StorageDriver string
 
@@ -41,6 +49,14 @@ const (
 	ActorWhitelisted ActorStatus = "Whitelisted"
 )
 
+// CampaignStatus represents the current status of a campaign.
+type CampaignStatus string
+
+const (
+	CampaignActive  CampaignStatus = "Active"
+	CampaignBlocked CampaignStatus = "Blocked"
+)
+
 // RateLimitStrategy defines the algorithm used for rate limiting.
 type RateLimitStrategy string
 
@@ -102,6 +118,311 @@ type GeoProvider string
 const (
 	GeoIPFree GeoProvider = "geolite2"
 	GeoIPPaid GeoProvider = "geoip2"
+
+	// GeoIPAPI queries ip-api.com over plain HTTP. It sends every looked-up
+	// IP to a third party and is rate-limited, so it must be chosen
+	// explicitly.
+	GeoIPAPI GeoProvider = "ip-api"
+)
+
+// DashboardRole is the access level of a dashboard account. Each role
+// includes everything the roles below it may do.
+type DashboardRole string
+
+const (
+	// RoleViewer can read every dashboard page but change nothing.
+	RoleViewer DashboardRole = "viewer"
+	// RoleAnalyst can also triage: resolve threats, mark false positives,
+	// block IPs and actors, and reset rate-limit counters.
+	RoleAnalyst DashboardRole = "analyst"
+	// RoleAdmin can also change configuration, unblock, edit WAF rules and
+	// manage dashboard accounts.
+	RoleAdmin DashboardRole = "admin"
+)
+
+// Valid reports whether r is one of the known roles.
+func (r DashboardRole) Valid() bool {
+	return r.rank() > 0
+}
+
+// Allows reports whether r grants at least the access of required.
+func (r DashboardRole) Allows(required DashboardRole) bool {
+	return r.rank() > 0 && r.rank() >= required.rank()
+}
+
+func (r DashboardRole) rank() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleAnalyst:
+		return 2
+	case RoleAdmin:
+		return 3
+	}
+	return 0
+}
+
+// Transform is a normalization step the WAF applies to an input before a
+// pattern or rule is matched against it. Names follow the ModSecurity t:
+// actions where one exists.
+type Transform string
+
+const (
+	// TransformURLDecode decodes %XX, IIS-style %uXXXX and "+", repeating
+	// until the value stops changing so double-encoding is undone too.
+	// Invalid escapes are left as they are.
+	TransformURLDecode Transform = "urlDecode"
+	// TransformHTMLEntityDecode decodes named and numeric HTML entities.
+	TransformHTMLEntityDecode Transform = "htmlEntityDecode"
+	// TransformNormalizeUnicode decodes overlong UTF-8 encodings of ASCII
+	// ("\xc0\xae" is ".") and applies NFKC, which folds fullwidth and
+	// other compatibility forms ("＜script＞") to their plain equivalents.
+	TransformNormalizeUnicode Transform = "normalizeUnicode"
+	// TransformRemoveNulls drops NUL bytes.
+	TransformRemoveNulls Transform = "removeNulls"
+	// TransformRemoveComments drops /* ... */ and <!-- ... --> comments,
+	// so "UN/**/ION" reads as "UNION".
+	TransformRemoveComments Transform = "removeComments"
+	// TransformCompressWhitespace turns each run of whitespace into a
+	// single space.
+	TransformCompressWhitespace Transform = "compressWhitespace"
+	// TransformLowercase lowercases the value.
+	TransformLowercase Transform = "lowercase"
+)
+
+// Valid reports whether t is one of the known transforms.
+func (t Transform) Valid() bool {
+	switch t {
+	case TransformURLDecode, TransformHTMLEntityDecode, TransformNormalizeUnicode, TransformRemoveNulls,
+		TransformRemoveComments, TransformCompressWhitespace, TransformLowercase:
+		return true
+	}
+	return false
+}
+
+// DLPClass is a kind of sensitive data the response inspector looks for.
+type DLPClass string
+
+const (
+	// DLPDatabaseError matches database driver error messages, which
+	// reveal the schema and confirm injection points.
+	DLPDatabaseError DLPClass = "db_error"
+	// DLPStackTrace matches Go, Java, Python, .NET, Node.js and PHP stack
+	// traces and fatal errors.
+	DLPStackTrace DLPClass = "stack_trace"
+	// DLPCardNumber matches payment card numbers that pass the Luhn check
+	// and carry a known card network prefix.
+	DLPCardNumber DLPClass = "card_number"
+	// DLPSecretKey matches private keys and well-known credential formats
+	// (AWS, GitHub, Slack, Stripe, Google).
+	DLPSecretKey DLPClass = "secret_key"
+)
+
+// Valid reports whether c is one of the known classes.
+func (c DLPClass) Valid() bool {
+	switch c {
+	case DLPDatabaseError, DLPStackTrace, DLPCardNumber, DLPSecretKey:
+		return true
+	}
+	return false
+}
+
+// DLPAction is what the response inspector does with a leaking response.
+type DLPAction string
+
+const (
+	// DLPActionLog records the leak and sends the response unchanged.
+	DLPActionLog DLPAction = "log"
+	// DLPActionMask overwrites each finding with "*" before sending.
+	DLPActionMask DLPAction = "mask"
+	// DLPActionReplace discards the response and sends a generic one.
+	DLPActionReplace DLPAction = "replace"
+)
+
+// Valid reports whether a is one of the known actions.
+func (a DLPAction) Valid() bool {
+	switch a {
+	case DLPActionLog, DLPActionMask, DLPActionReplace:
+		return true
+	}
+	return false
+}
+
+// SessionRiskAction is what happens when a session's risk score reaches
+// SessionRiskConfig.Threshold.
+type SessionRiskAction string
+
+const (
+	// SessionActionLog records an AccountTakeover threat and lets the
+	// session continue.
+	SessionActionLog SessionRiskAction = "log"
+	// SessionActionChallenge also requires a CAPTCHA on the session's next
+	// request; the session continues once it is solved.
+	SessionActionChallenge SessionRiskAction = "challenge"
+	// SessionActionRevoke also calls SessionRiskConfig.OnRevoke and
+	// rejects the session's requests from then on.
+	SessionActionRevoke SessionRiskAction = "revoke"
+)
+
+// Valid reports whether a is one of the known actions.
+func (a SessionRiskAction) Valid() bool {
+	switch a {
+	case SessionActionLog, SessionActionChallenge, SessionActionRevoke:
+		return true
+	}
+	return false
+}
+
+// BotVerdict is what bot detection concluded about a request's client.
+type BotVerdict string
+
+const (
+	// BotHuman has no automation signals, or too few to reach
+	// BotConfig.Threshold.
+	BotHuman BotVerdict = "human"
+	// BotVerified claims to be a known crawler, and reverse-then-forward
+	// DNS confirmed the IP belongs to it.
+	BotVerified BotVerdict = "verified"
+	// BotUnverified looks automated (an HTTP library, a headless browser)
+	// or claims to be a crawler whose DNS could not be checked.
+	BotUnverified BotVerdict = "unverified"
+	// BotBad is an attack tool, or claims to be a crawler and DNS says it
+	// is not.
+	BotBad BotVerdict = "bad"
+)
+
+// Valid reports whether v is one of the known verdicts.
+func (v BotVerdict) Valid() bool {
+	switch v {
+	case BotHuman, BotVerified, BotUnverified, BotBad:
+		return true
+	}
+	return false
+}
+
+// BotAction is what the WAF does with a request, given its bot verdict.
+type BotAction string
+
+const (
+	// BotActionInspect runs the WAF's usual inspection. Verdicts missing
+	// from WAFConfig.BotPolicy get this.
+	BotActionInspect BotAction = "inspect"
+	// BotActionAllow skips inspection, so a verified crawler fetching odd
+	// URLs does not trip the WAF. ValidateConfig accepts it only for
+	// BotVerified.
+	BotActionAllow BotAction = "allow"
+	// BotActionChallenge serves the WAF challenge until the client solves
+	// it, then inspects as usual.
+	BotActionChallenge BotAction = "challenge"
+	// BotActionBlock refuses the request with 403 and a BadBot threat.
+	BotActionBlock BotAction = "block"
+)
+
+// Valid reports whether a is one of the known actions.
+func (a BotAction) Valid() bool {
+	switch a {
+	case BotActionInspect, BotActionAllow, BotActionChallenge, BotActionBlock:
+		return true
+	}
+	return false
+}
+
+// NetworkType is the kind of network a client IP belongs to, as network
+// type detection sees it; see NetworkConfig.
+type NetworkType string
+
+const (
+	// NetworkResidential is a public IP on none of the lists — most often
+	// a home or mobile connection.
+	NetworkResidential NetworkType = "residential"
+	// NetworkHosting is a datacenter or cloud provider range.
+	NetworkHosting NetworkType = "hosting"
+	// NetworkVPN is a commercial VPN or proxy service.
+	NetworkVPN NetworkType = "vpn"
+	// NetworkTor is a Tor exit node.
+	NetworkTor NetworkType = "tor"
+)
+
+// Valid reports whether t is one of the known network types.
+func (t NetworkType) Valid() bool {
+	switch t {
+	case NetworkResidential, NetworkHosting, NetworkVPN, NetworkTor:
+		return true
+	}
+	return false
+}
+
+// FeedFormat is the file format of a threat intelligence feed.
+type FeedFormat string
+
+const (
+	// FeedText is one indicator per line: an IP, CIDR or domain, with
+	// anything after it on the line and lines starting with # or ;
+	// ignored. Spamhaus DROP, FireHOL and most plain blocklists use it.
+	FeedText FeedFormat = "text"
+	// FeedCSV reads the indicator from one column of a CSV file.
+	FeedCSV FeedFormat = "csv"
+	// FeedSTIX reads the indicators of a STIX 2.1 bundle.
+	FeedSTIX FeedFormat = "stix"
+)
+
+// Valid reports whether f is one of the known formats.
+func (f FeedFormat) Valid() bool {
+	switch f {
+	case FeedText, FeedCSV, FeedSTIX:
+		return true
+	}
+	return false
+}
+
+// FeedAction is what happens to requests matching a threat feed.
+type FeedAction string
+
+const (
+	// FeedActionBlock refuses the request with 403, like a blocked IP.
+	FeedActionBlock FeedAction = "block"
+	// FeedActionFlag lets the request through and records a
+	// ThreatIntelMatch threat.
+	FeedActionFlag FeedAction = "flag"
+	// FeedActionScore only tags the request's threat events, raising the
+	// risk score of the actor by the feed's ScoreBoost.
+	FeedActionScore FeedAction = "score"
+)
+
+// Valid reports whether a is one of the known actions.
+func (a FeedAction) Valid() bool {
+	switch a {
+	case FeedActionBlock, FeedActionFlag, FeedActionScore:
+		return true
+	}
+	return false
+}
+
+// ConditionType is the request property a RuleCondition leaf tests.
+type ConditionType string
+
+const (
+	ConditionMethod   ConditionType = "method"    // request method, case-insensitive
+	ConditionPath     ConditionType = "path"      // route patterns, as RouteMatcher accepts them
+	ConditionHeader   ConditionType = "header"    // the header called Name
+	ConditionParam    ConditionType = "param"     // query parameter or decoded body field called Name
+	ConditionIP       ConditionType = "ip"        // client IP within an IP or CIDR
+	ConditionCountry  ConditionType = "country"   // ISO country code of the client IP; needs geolocation
+	ConditionBodySize ConditionType = "body_size" // request body size in bytes
+)
+
+// ConditionOp is how a RuleCondition leaf compares the property to its
+// values.
+type ConditionOp string
+
+const (
+	ConditionExists   ConditionOp = "exists"
+	ConditionEquals   ConditionOp = "equals"
+	ConditionContains ConditionOp = "contains"
+	ConditionPrefix   ConditionOp = "prefix"
+	ConditionRegex    ConditionOp = "regex"
+	ConditionGreater  ConditionOp = "gt"
+	ConditionLess     ConditionOp = "lt"
 )
 
 // Default insecure credential constants. These are populated by ApplyDefaults
@@ -130,4 +451,12 @@ const (
 	ThreatAnomalyDetected    ThreatType = "AnomalyDetected"
 	ThreatScanning           ThreatType = "Scanning"
 	ThreatCSPViolation       ThreatType = "CSPViolation"
+	ThreatDataLeak           ThreatType = "DataLeak"
+	ThreatCredentialStuffing ThreatType = "CredentialStuffing"
+	ThreatAccountTakeover    ThreatType = "AccountTakeover"
+	ThreatDeniedFingerprint  ThreatType = "DeniedFingerprint"
+	ThreatBadBot             ThreatType = "BadBot"
+	ThreatIntelMatch         ThreatType = "ThreatIntelMatch"
+	ThreatGeoFenceViolation  ThreatType = "GeoFenceViolation"
+	ThreatNetworkPolicy      ThreatType = "NetworkPolicy"
 )
@@ -72,6 +72,49 @@ var defaultCVSS = map[ThreatType]CVSS{
 		Score:  3.1,
 		Vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:U/C:L/I:N/A:N",
 	},
+	ThreatDataLeak: {
+		Score:  7.5,
+		Vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N",
+	},
+	ThreatCredentialStuffing: {
+		// Leaked credentials that work give full access to the account.
+		Score:  8.1,
+		Vector: "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:H/I:H/A:N",
+	},
+	ThreatAccountTakeover: {
+		Score:  8.1,
+		Vector: "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:H/I:H/A:N",
+	},
+	ThreatBadBot: {
+		// Scrapers and attack tools harvest content or probe for
+		// weaknesses; the request itself is refused.
+		Score:  5.3,
+		Vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:N/A:N",
+	},
+	ThreatIntelMatch: {
+		// The client is listed by a threat feed; the request itself may
+		// be harmless.
+		Score:  5.3,
+		Vector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:N/A:N",
+	},
+	ThreatGeoFenceViolation: {
+		// A client from a refused country or region; the request itself
+		// may be harmless.
+		Score:  3.1,
+		Vector: "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:L/I:N/A:N",
+	},
+	ThreatNetworkPolicy: {
+		// A client on an anonymizing or hosting network the WAF refuses;
+		// the request itself may be harmless.
+		Score:  3.1,
+		Vector: "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:L/I:N/A:N",
+	},
+	ThreatDeniedFingerprint: {
+		// The client's TLS stack is on a deny list; the request itself
+		// may be harmless.
+		Score:  3.1,
+		Vector: "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:L/I:N/A:N",
+	},
 }
 
 // DefaultCVSSForType returns the default CVSS score + vector for a given
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// Block and whitelist entries name the clients they cover in one of three
+// shapes:
+//
+//   - address:  "203.0.113.7", "2001:db8::1"
+//   - prefix:   "198.51.100.0/24", "2001:db8::/32"
+//   - ASN:      "AS64496" — every address the autonomous system announces
+//
+// When entries overlap, the most specific one decides: a longer prefix beats
+// a shorter one, any address or prefix beats an ASN, and on a tie (the same
+// entry on both lists) the whitelist wins. So a whitelisted /32 inside a
+// blocked /16 is let through, and a blocked /32 inside a whitelisted /16 is
+// refused. An expired block no longer covers anything, and never shadows a
+// shorter live block. Whitelist entries do not expire.
+//
+// ASN entries need geolocation with ASN data (GeoConfig.ASNDatabasePath, or
+// the ip-api fallback); storage lookups ignore them.
+
+// ParseIPEntry returns the canonical form of a block or whitelist entry:
+// a bare address for an address or host prefix ("10.0.0.1/32" becomes
+// "10.0.0.1"), a masked prefix ("10.1.2.3/16" becomes "10.1.0.0/16"), or
+// "AS<number>" for an ASN ("as64496" and "AS64496 Example Net" become
+// "AS64496"). IPv4-mapped IPv6 addresses are written as IPv4.
+func ParseIPEntry(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if asn := ASNEntry(s); asn != "" {
+		return asn, nil
+	}
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return "", fmt.Errorf("%q is not an IP address, CIDR prefix or ASN", s)
+		}
+		return addr.Unmap().WithZone("").String(), nil
+	}
+	p, err := netip.ParsePrefix(s)
+	if err != nil {
+		return "", fmt.Errorf("%q is not an IP address, CIDR prefix or ASN", s)
+	}
+	return formatPrefix(p), nil
+}
+
+// IsASNEntry reports whether a canonical entry names an ASN.
+func IsASNEntry(entry string) bool {
+	return strings.HasPrefix(entry, "AS")
+}
+
+// ASNEntry returns the canonical ASN entry for a GeoResult.ASN value such
+// as "AS15169 Google LLC", or "" if it names none.
+func ASNEntry(asn string) string {
+	if f := strings.Fields(asn); len(f) > 0 {
+		if entry, ok := parseASN(f[0]); ok {
+			return entry
+		}
+	}
+	return ""
+}
+
+// CoveringIPEntries returns the canonical address and prefix entries that
+// cover ip, most specific first: the address itself, then every enclosing
+// prefix down to /0. It returns nil if ip is not an address.
+func CoveringIPEntries(ip string) []string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil
+	}
+	addr = addr.Unmap().WithZone("")
+	entries := make([]string, 0, addr.BitLen()+1)
+	for bits := addr.BitLen(); bits >= 0; bits-- {
+		p, _ := addr.Prefix(bits)
+		entries = append(entries, formatPrefix(p))
+	}
+	return entries
+}
+
+// formatPrefix writes a host prefix as a bare address and any other prefix
+// masked, IPv4-mapped prefixes as IPv4.
+func formatPrefix(p netip.Prefix) string {
+	addr, bits := p.Addr(), p.Bits()
+	if addr.Is4In6() && bits >= 96 {
+		addr, bits = addr.Unmap(), bits-96
+	}
+	if bits == addr.BitLen() {
+		return addr.WithZone("").String()
+	}
+	return netip.PrefixFrom(addr.WithZone(""), bits).Masked().String()
+}
+
+// parseASN accepts "AS64496" in any case.
+func parseASN(s string) (string, bool) {
+	if len(s) < 3 || !strings.EqualFold(s[:2], "AS") {
+		return "", false
+	}
+	n, err := strconv.ParseUint(s[2:], 10, 32)
+	if err != nil {
+		return "", false
+	}
+	return "AS" + strconv.FormatUint(n, 10), true
+}
@@ -26,6 +26,12 @@ type InspectedRequest struct {
 	Body      string
 	IP        string
 	UserAgent string
+
+	// BodySize is the full body length; Body may be cut at the inspection
+	// cap. Country is the client's ISO country code, when the WAF has
+	// geolocation and a rule needs it.
+	BodySize int64
+	Country  string
 }
 
 // Evidence represents a single piece of evidence from a threat detection match.
@@ -34,6 +40,15 @@ type Evidence struct {
 	Matched   string `json:"matched"`
 	Location  string `json:"location"`
 	Parameter string `json:"parameter,omitempty"`
+
+	// Transforms lists the normalization steps applied before matching;
+	// when set, Matched is taken from the transformed input.
+	Transforms []Transform `json:"transforms,omitempty"`
+
+	// Score is the anomaly points this match added to the request's total
+	// under WAFConfig.Scoring: 0 when scoring is off or when the same
+	// pattern already scored on another input.
+	Score int `json:"score,omitempty"`
 }
 
 // ThreatEvent represents a single detected security threat.
@@ -73,6 +88,145 @@ type ThreatEvent struct {
 	// "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:N") describing how the
 	// score was derived. Useful for SOC2 evidence and security spreadsheets.
 	CVSSVector string `json:"cvss_vector,omitempty"`
+
+	// Challenge is set on events the WAF handled in challenge mode. It
+	// records what happened to this request and the client IP's running
+	// challenge tallies, so the solve and fail rates of an actor are
+	// visible on every event it raises.
+	Challenge *ChallengeStats `json:"challenge,omitempty"`
+
+	// AnomalyScore and AnomalyThreshold are set on events the WAF raised
+	// under WAFConfig.Scoring: the request's total score and the threshold
+	// of its route. The request was acted on only if the score reached the
+	// threshold.
+	AnomalyScore     int `json:"anomaly_score,omitempty"`
+	AnomalyThreshold int `json:"anomaly_threshold,omitempty"`
+
+	// HeaderFingerprint is a hash of the names of the request's headers,
+	// set on events raised by the WAF. Clients built on the same tool send
+	// the same set, whatever their IP.
+	HeaderFingerprint string `json:"header_fingerprint,omitempty"`
+
+	// JA3 and JA4 are the TLS client fingerprints of the connection the
+	// request arrived on. They are set only when Sentinel can see the
+	// ClientHello; see FingerprintConfig.
+	JA3 string `json:"ja3,omitempty"`
+	JA4 string `json:"ja4,omitempty"`
+
+	// BotVerdict and BotScore are bot detection's view of the client, set
+	// when BotConfig is enabled.
+	BotVerdict BotVerdict `json:"bot_verdict,omitempty"`
+	BotScore   int        `json:"bot_score,omitempty"`
+
+	// FeedSource names the threat feed listing the client IP or the
+	// Referer/Origin domain, and FeedScore is that feed's ScoreBoost.
+	FeedSource string `json:"feed_source,omitempty"`
+	FeedScore  int    `json:"feed_score,omitempty"`
+
+	// NetworkType is the kind of network the client IP belongs to, and
+	// NetworkScore its NetworkConfig.ScoreBoost. Set when NetworkConfig is
+	// enabled and the IP is public.
+	NetworkType  NetworkType `json:"network_type,omitempty"`
+	NetworkScore int         `json:"network_score,omitempty"`
+}
+
+// NetworkMatch is network type detection's view of a client IP.
+type NetworkMatch struct {
+	Type       NetworkType `json:"type"`
+	ScoreBoost int         `json:"score_boost"`
+	// List is the file whose entry matched, and Indicator that entry. Both
+	// are empty for NetworkResidential.
+	List      string `json:"list,omitempty"`
+	Indicator string `json:"indicator,omitempty"`
+}
+
+// NetworkListStats describes the state of one network type list file.
+type NetworkListStats struct {
+	Type NetworkType `json:"type"`
+	Path string      `json:"path"`
+	// Prefixes counts the addresses and prefixes in memory; Skipped the
+	// lines of the last load that held none.
+	Prefixes int `json:"prefixes"`
+	Skipped  int `json:"skipped"`
+	// Matches counts requests classified by the list since startup.
+	Matches    int64      `json:"matches"`
+	LastLoaded *time.Time `json:"last_loaded,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+}
+
+// FeedMatch is a threat feed entry matching a request.
+type FeedMatch struct {
+	Feed       string     `json:"feed"`
+	Action     FeedAction `json:"action"`
+	ScoreBoost int        `json:"score_boost"`
+	// Indicator is the matching entry: a CIDR, or a domain.
+	Indicator string `json:"indicator"`
+}
+
+// FeedStats describes the state of one threat feed.
+type FeedStats struct {
+	Name   string     `json:"name"`
+	Source string     `json:"source"`
+	Format FeedFormat `json:"format"`
+	Action FeedAction `json:"action"`
+	// Prefixes and Domains count the indicators in memory; Skipped the
+	// lines of the last load that held none.
+	Prefixes int `json:"prefixes"`
+	Domains  int `json:"domains"`
+	Skipped  int `json:"skipped"`
+	// Matches counts requests that matched since startup.
+	Matches     int64      `json:"matches"`
+	LastLoaded  *time.Time `json:"last_loaded,omitempty"`
+	LastAttempt *time.Time `json:"last_attempt,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	// ExpiresAt is when the indicators are dropped unless the feed loads
+	// again; see ThreatFeed.Expiry.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// BotResult is bot detection's view of one request.
+type BotResult struct {
+	Verdict BotVerdict `json:"verdict"`
+	// Score is how likely the client is automated, 0–100.
+	Score int `json:"score"`
+	// Crawler names the crawler the client claims to be, if any.
+	Crawler string `json:"crawler,omitempty"`
+	// Signals are the reasons for the score, e.g. "automation_ua".
+	Signals []string `json:"signals,omitempty"`
+}
+
+// BotStats counts classified requests over a window.
+type BotStats struct {
+	Window   string               `json:"window"`
+	Total    int64                `json:"total"`
+	Verdicts map[BotVerdict]int64 `json:"verdicts"`
+	// Crawlers counts requests claiming each crawler, verified or not.
+	Crawlers map[string]int64 `json:"crawlers"`
+}
+
+// TLSFingerprint identifies the TLS client library behind a connection.
+// Both hashes are computed from the ClientHello and do not change when a
+// client rotates its IP or User-Agent.
+type TLSFingerprint struct {
+	// JA3 is the MD5 hex digest of the JA3 string.
+	JA3 string `json:"ja3"`
+	// JA4 is the JA4 fingerprint, e.g. "t13d1516h2_8daaf6152771_e5627efa2ab1".
+	JA4 string `json:"ja4"`
+}
+
+// ChallengeStats are one client IP's challenge tallies as of an event.
+type ChallengeStats struct {
+	Outcome ChallengeOutcome `json:"outcome"`
+	Issued  int64            `json:"issued"`
+	Solved  int64            `json:"solved"`
+	Failed  int64            `json:"failed"`
+
+	// SolveRate is Solved / Issued: how many challenges this client was
+	// shown that it went on to solve. Near 0 for bots that never try.
+	SolveRate float64 `json:"solve_rate"`
+	// FailRate is Failed / (Solved + Failed): how many submitted answers
+	// were wrong. High for bots that guess.
+	FailRate float64 `json:"fail_rate"`
 }
 
 // ThreatActor represents a persistent profile of an attacker.
@@ -94,6 +248,56 @@ type ThreatActor struct {
 	AbuseScore      int         `json:"abuse_score"`
 	Lat             float64     `json:"lat"`
 	Lng             float64     `json:"lng"`
+
+	// CampaignID is the campaign the actor was grouped into, if any.
+	CampaignID string `json:"campaign_id,omitempty"`
+
+	// JA3 and JA4 are the distinct TLS fingerprints seen from the actor,
+	// most recent last, at most ten of each.
+	JA3 []string `json:"ja3,omitempty"`
+	JA4 []string `json:"ja4,omitempty"`
+
+	// ThreatFeeds are the threat feeds that listed the actor, and
+	// FeedScore the largest of their ScoreBoosts, which ComputeRiskScore
+	// adds.
+	ThreatFeeds []string `json:"threat_feeds,omitempty"`
+	FeedScore   int      `json:"feed_score,omitempty"`
+
+	// NetworkType is the kind of network the actor was last seen on, and
+	// NetworkScore its ScoreBoost, which ComputeRiskScore adds.
+	NetworkType  NetworkType `json:"network_type,omitempty"`
+	NetworkScore int         `json:"network_score,omitempty"`
+}
+
+// Campaign is a group of threat actors linked by shared signals, such as a
+// scanner rotating through many IPs. It is scored and can be blocked as a
+// whole.
+type Campaign struct {
+	ID             string           `json:"id"`
+	FirstSeen      time.Time        `json:"first_seen"`
+	LastSeen       time.Time        `json:"last_seen"`
+	ActorIPs       []string         `json:"actor_ips"`
+	ActorCount     int              `json:"actor_count"`
+	ThreatCount    int              `json:"threat_count"`
+	AttackTypes    []string         `json:"attack_types"`
+	TargetedRoutes []string         `json:"targeted_routes"`
+	Countries      []string         `json:"countries"`
+	Signals        []CampaignSignal `json:"signals"`
+	RiskScore      int              `json:"risk_score"`
+	Status         CampaignStatus   `json:"status"`
+
+	// BlockReason and BlockExpiry are set while the campaign is blocked.
+	// Actors joining a blocked campaign are blocked with them.
+	BlockReason string     `json:"block_reason,omitempty"`
+	BlockExpiry *time.Time `json:"block_expiry,omitempty"`
+}
+
+// CampaignSignal is a signal value that linked actors of a campaign, with
+// the number of links it took part in.
+type CampaignSignal struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+	Links int    `json:"links"`
 }
 
 // AuditLog represents an immutable audit trail entry.
@@ -132,6 +336,104 @@ type UserActivity struct {
 	Country    string    `json:"country,omitempty"`
 }
 
+// LoginAttempt is one POST to the AuthShield login route and its outcome.
+// PasswordFingerprint is set on failures only: a keyed hash of the
+// submitted password that stays the same for one process, so reuse of a
+// password across accounts is visible without the password being kept.
+type LoginAttempt struct {
+	Timestamp           time.Time `json:"timestamp"`
+	IP                  string    `json:"ip"`
+	Username            string    `json:"username,omitempty"`
+	Method              string    `json:"method"`
+	Path                string    `json:"path"`
+	UserAgent           string    `json:"user_agent"`
+	Success             bool      `json:"success"`
+	PasswordFingerprint string    `json:"-"`
+}
+
+// SessionRisk is the account takeover risk of one authenticated session:
+// the signals it raised and what was done about them.
+type SessionRisk struct {
+	SessionID string          `json:"session_id"` // a hash of the session token
+	UserID    string          `json:"user_id"`
+	IP        string          `json:"ip"`
+	UserAgent string          `json:"user_agent"`
+	ASN       string          `json:"asn,omitempty"`
+	Country   string          `json:"country,omitempty"`
+	StartedAt time.Time       `json:"started_at"`
+	Score     int             `json:"score"`
+	Signals   []SessionSignal `json:"signals,omitempty"`
+
+	// Flagged is set once Score reaches the threshold. Flagging marks the
+	// request that reached it; StepUpRequired and Revoked are the state
+	// the session was left in.
+	Flagged        bool `json:"flagged"`
+	Flagging       bool `json:"-"`
+	StepUpRequired bool `json:"step_up_required"`
+	Revoked        bool `json:"revoked"`
+}
+
+// SessionObservation is one request of an authenticated session, as the
+// session risk engine sees it. SessionID is already hashed.
+type SessionObservation struct {
+	SessionID string
+	UserID    string
+	IP        string
+	UserAgent string
+	Method    string
+	Path      string
+	Timestamp time.Time
+}
+
+// SessionSignal is one reason a session is risky.
+type SessionSignal struct {
+	Name   string `json:"name"`
+	Score  int    `json:"score"`
+	Detail string `json:"detail"`
+}
+
+// UserBaseline is a user's normal behavior, learned by the anomaly detector
+// one activity at a time. Hour and route weights are activity counts that
+// decay exponentially with AnomalyConfig.LearningPeriod as the time
+// constant, so the baseline follows recent habits without rescanning the
+// user's history.
+type UserBaseline struct {
+	UserID string `json:"user_id"`
+
+	// HourWeights holds the decayed activity count per hour of day and
+	// Weight their sum.
+	HourWeights [24]float64 `json:"hour_weights"`
+	Weight      float64     `json:"weight"`
+
+	// Routes holds the decayed count per "METHOD /path". Routes whose
+	// weight decays to almost nothing are forgotten.
+	Routes map[string]float64 `json:"routes"`
+
+	// SourceIPs and Countries map each value to when it was last seen.
+	// Values not seen for a learning period are forgotten.
+	SourceIPs map[string]time.Time `json:"source_ips"`
+	Countries map[string]time.Time `json:"countries"`
+
+	// RequestsPerHour is a moving average of the request count over the
+	// hours in which the user was active. HourStart and HourCount track
+	// the hour being counted.
+	RequestsPerHour float64   `json:"requests_per_hour"`
+	HourStart       time.Time `json:"hour_start"`
+	HourCount       int       `json:"hour_count"`
+
+	// AvgDuration is a moving average of request duration in milliseconds.
+	AvgDuration float64 `json:"avg_duration_ms"`
+
+	Samples   int64     `json:"samples"`
+	FirstSeen time.Time `json:"first_seen"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Version is the store's revision of the baseline, checked on save so
+	// that replicas do not overwrite each other's updates. Zero for a
+	// baseline that was never saved.
+	Version int64 `json:"version"`
+}
+
 // SubScore represents a sub-component of the security score.
 type SubScore struct {
 	Score   int     `json:"score"`
@@ -206,7 +508,8 @@ type RouteMetric struct {
 	RequestCount int64   `json:"request_count"`
 }
 
-// BlockedIP represents a blocked IP address.
+// BlockedIP represents a block list entry: an IP address, a CIDR prefix or
+// an ASN (see ParseIPEntry).
 type BlockedIP struct {
 	IP        string     `json:"ip"`
 	Reason    string     `json:"reason"`
@@ -215,10 +518,12 @@ type BlockedIP struct {
 	CIDR      bool       `json:"cidr"`
 }
 
-// WhitelistedIP represents a whitelisted IP address.
+// WhitelistedIP represents a whitelist entry: an IP address, a CIDR prefix
+// or an ASN (see ParseIPEntry).
 type WhitelistedIP struct {
 	IP          string    `json:"ip"`
 	WhitelistAt time.Time `json:"whitelisted_at"`
+	CIDR        bool      `json:"cidr"`
 }
 
 // ThreatStats contains aggregated threat statistics.
@@ -245,6 +550,7 @@ type ThreatFilter struct {
 	Severity  Severity   `json:"severity,omitempty"`
 	Type      string     `json:"type,omitempty"`
 	IP        string     `json:"ip,omitempty"`
+	ActorID   string     `json:"actor_id,omitempty"`
 	UserID    string     `json:"user_id,omitempty"`
 	StartTime *time.Time `json:"start_time,omitempty"`
 	EndTime   *time.Time `json:"end_time,omitempty"`
@@ -267,6 +573,14 @@ type ActorFilter struct {
 	SortOrder string      `json:"sort_order"`
 }
 
+// CampaignFilter defines query filters for listing campaigns.
+type CampaignFilter struct {
+	Status   CampaignStatus `json:"status,omitempty"`
+	MinRisk  int            `json:"min_risk,omitempty"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+}
+
 // ActivityFilter defines query filters for listing user activity.
 type ActivityFilter struct {
 	StartTime *time.Time `json:"start_time,omitempty"`
@@ -310,6 +624,7 @@ type GeoResult struct {
 	IP          string  `json:"ip"`
 	Country     string  `json:"country"`
 	CountryCode string  `json:"country_code"`
+	RegionCode  string  `json:"region_code,omitempty"` // ISO 3166-2, e.g. "UA-43"
 	City        string  `json:"city"`
 	Lat         float64 `json:"lat"`
 	Lng         float64 `json:"lng"`
@@ -361,3 +676,44 @@ type TopTarget struct {
 	Method string `json:"method"`
 	Count  int64  `json:"count"`
 }
+
+// DashboardUser is a dashboard account. Only a bcrypt hash of the password
+// is stored, and it is never serialized.
+type DashboardUser struct {
+	ID           string        `json:"id"`
+	Username     string        `json:"username"`
+	Email        string        `json:"email,omitempty"`
+	Role         DashboardRole `json:"role"`
+	PasswordHash string        `json:"-"`
+	Disabled     bool          `json:"disabled"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+	LastLoginAt  *time.Time    `json:"last_login_at,omitempty"`
+}
+
+// APIKey is a long-lived credential for machine access to the REST API.
+// Only a hash of the secret is stored; the full key is shown once, when it
+// is created.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Hash       string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	AllowedIPs []string   `json:"allowed_ips,omitempty"` // IPs or CIDRs; empty allows any
+	CreatedBy  string     `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// ConfigVersion is one saved state of the dashboard-editable configuration.
+// Every edit and every rollback appends a version; Overrides holds the full
+// state at that point, not a delta.
+type ConfigVersion struct {
+	Version   int             `json:"version"`
+	CreatedAt time.Time       `json:"created_at"`
+	Author    string          `json:"author,omitempty"`
+	Change    string          `json:"change"`
+	Overrides ConfigOverrides `json:"overrides"`
+}
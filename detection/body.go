@@ -0,0 +1,185 @@
+package detection
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Body decoding bounds. The body is already capped at WAFConfig.MaxBodyBytes;
+// these stop a small but deeply nested or key-heavy document from turning
+// into thousands of scans.
+const (
+	maxBodyParams = 1000
+	maxBodyDepth  = 32
+)
+
+// BodyParam is one named value decoded from a request body. Name is the
+// parameter's path prefixed with "body", e.g. "body.user.name",
+// "body.items[2].sku" or "body.order.@id" for an XML attribute.
+type BodyParam struct {
+	Name  string
+	Value string
+}
+
+// DecodeBody flattens a request body into named parameters according to
+// its Content-Type: JSON (application/json and any +json type), urlencoded
+// forms, multipart forms and XML (application/xml, text/xml and any +xml
+// type). Only string values are returned — numbers and booleans cannot
+// carry a payload. Multipart file contents are skipped; their filenames are
+// returned as "body.<field>.filename". Unknown content types and bodies that
+// fail to parse yield whatever was decoded before the error, possibly
+// nothing; the raw body is scanned regardless.
+func DecodeBody(contentType, body string) []BodyParam {
+	if body == "" || contentType == "" {
+		return nil
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+	d := &bodyDecoder{}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		d.decodeJSON(body)
+	case mediaType == "application/x-www-form-urlencoded":
+		d.decodeForm(body)
+	case mediaType == "multipart/form-data":
+		d.decodeMultipart(body, params["boundary"])
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		d.decodeXML(body)
+	}
+	return d.params
+}
+
+type bodyDecoder struct {
+	params []BodyParam
+}
+
+// add records a parameter and reports whether there is room for more.
+func (d *bodyDecoder) add(name, value string) bool {
+	if len(d.params) >= maxBodyParams {
+		return false
+	}
+	if value != "" {
+		d.params = append(d.params, BodyParam{Name: name, Value: value})
+	}
+	return len(d.params) < maxBodyParams
+}
+
+func (d *bodyDecoder) decodeJSON(body string) {
+	var v interface{}
+	if json.Unmarshal([]byte(body), &v) != nil {
+		return
+	}
+	d.walkJSON("body", v, 0)
+}
+
+func (d *bodyDecoder) walkJSON(name string, v interface{}, depth int) bool {
+	if depth > maxBodyDepth {
+		return true
+	}
+	switch val := v.(type) {
+	case string:
+		return d.add(name, val)
+	case map[string]interface{}:
+		for k, child := range val {
+			if !d.walkJSON(name+"."+k, child, depth+1) {
+				return false
+			}
+		}
+	case []interface{}:
+		for i, child := range val {
+			if !d.walkJSON(name+"["+strconv.Itoa(i)+"]", child, depth+1) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (d *bodyDecoder) decodeForm(body string) {
+	values, _ := url.ParseQuery(body)
+	for key, vals := range values {
+		for _, v := range vals {
+			if !d.add("body."+key, v) {
+				return
+			}
+		}
+	}
+}
+
+func (d *bodyDecoder) decodeMultipart(body, boundary string) {
+	if boundary == "" {
+		return
+	}
+	mr := multipart.NewReader(strings.NewReader(body), boundary)
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			return
+		}
+		name := "body." + part.FormName()
+		// FileName() strips directories, which is exactly where a
+		// traversal payload would sit, so read the raw parameter.
+		_, disposition, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+		if filename := disposition["filename"]; filename != "" {
+			// File contents are the application's business, not ours.
+			if !d.add(name+".filename", filename) {
+				return
+			}
+			continue
+		}
+		value, err := io.ReadAll(part)
+		if err != nil || !d.add(name, string(value)) {
+			return
+		}
+	}
+}
+
+func (d *bodyDecoder) decodeXML(body string) {
+	dec := xml.NewDecoder(strings.NewReader(body))
+	// Entities are never expanded: unknown ones are left as-is, and the
+	// raw body is scanned for XXE separately.
+	dec.Strict = false
+	var path []string
+	var text strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if len(path) >= maxBodyDepth {
+				return
+			}
+			path = append(path, t.Name.Local)
+			text.Reset()
+			name := "body." + strings.Join(path, ".")
+			for _, attr := range t.Attr {
+				if !d.add(name+".@"+attr.Name.Local, attr.Value) {
+					return
+				}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if len(path) == 0 {
+				return
+			}
+			if v := strings.TrimSpace(text.String()); v != "" {
+				if !d.add("body."+strings.Join(path, "."), v) {
+					return
+				}
+			}
+			text.Reset()
+			path = path[:len(path)-1]
+		}
+	}
+}
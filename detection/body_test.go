@@ -0,0 +1,125 @@
+package detection
+
+import (
+	"strings"
+	"testing"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+)
+
+func paramMap(params []BodyParam) map[string]string {
+	m := make(map[string]string, len(params))
+	for _, p := range params {
+		m[p.Name] = p.Value
+	}
+	return m
+}
+
+func TestDecodeBody(t *testing.T) {
+	multipartBody := strings.Join([]string{
+		"--XYZ",
+		`Content-Disposition: form-data; name="comment"`,
+		"",
+		"hello",
+		"--XYZ",
+		`Content-Disposition: form-data; name="avatar"; filename="../../etc/passwd"`,
+		"Content-Type: application/octet-stream",
+		"",
+		"<script>file contents are not scanned</script>",
+		"--XYZ--",
+		"",
+	}, "\r\n")
+
+	cases := []struct {
+		name, contentType, body string
+		want                    map[string]string
+	}{
+		{"json", "application/json; charset=utf-8", `{"user":{"name":"ana","age":3,"tags":["a","b"]}}`,
+			map[string]string{"body.user.name": "ana", "body.user.tags[0]": "a", "body.user.tags[1]": "b"}},
+		{"json suffix", "application/vnd.api+json", `[{"id":"x"}]`,
+			map[string]string{"body[0].id": "x"}},
+		{"form", "application/x-www-form-urlencoded", "q=a%27b&page=2",
+			map[string]string{"body.q": "a'b", "body.page": "2"}},
+		{"multipart", "multipart/form-data; boundary=XYZ", multipartBody,
+			map[string]string{"body.comment": "hello", "body.avatar.filename": "../../etc/passwd"}},
+		{"xml", "application/xml", `<order id="7"><item sku="a1">pen</item><note> hi </note></order>`,
+			map[string]string{"body.order.@id": "7", "body.order.item.@sku": "a1", "body.order.item": "pen", "body.order.note": "hi"}},
+		{"unknown type", "application/octet-stream", `{"a":"b"}`, map[string]string{}},
+		{"malformed json", "application/json", `{"a":`, map[string]string{}},
+	}
+	for _, tc := range cases {
+		got := paramMap(DecodeBody(tc.contentType, tc.body))
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+			continue
+		}
+		for k, v := range tc.want {
+			if got[k] != v {
+				t.Errorf("%s: %s = %q, want %q", tc.name, k, got[k], v)
+			}
+		}
+	}
+}
+
+func TestDecodeBodyBounded(t *testing.T) {
+	body := `{"a":[` + strings.Repeat(`"x",`, 2*maxBodyParams) + `"x"]}`
+	if n := len(DecodeBody("application/json", body)); n != maxBodyParams {
+		t.Errorf("expected %d params at most, got %d", maxBodyParams, n)
+	}
+}
+
+func TestClassifyRequestNamesBodyField(t *testing.T) {
+	// \u0027 is a JSON-escaped quote: invisible to the raw body scan.
+	req := sentinel.InspectedRequest{
+		Method:  "POST",
+		Path:    "/api/users",
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    `{"user":{"name":"x\u0027 or \u00271\u0027=\u00271"}}`,
+	}
+	for _, m := range ClassifyRequest(req) {
+		if m.ThreatType == sentinel.ThreatSQLi && m.Location == "body" && m.Parameter == "body.user.name" {
+			return
+		}
+	}
+	t.Errorf("expected an SQLi match on body.user.name, got %+v", ClassifyRequest(req))
+}
+
+func TestClassifyRequestCountsBodyPayloadOnce(t *testing.T) {
+	// The payload is visible both in the raw body and in its decoded field.
+	req := sentinel.InspectedRequest{
+		Method:  "POST",
+		Path:    "/api/search",
+		Headers: map[string][]string{"Content-Type": {"application/x-www-form-urlencoded"}},
+		Body:    "q=1'+UNION+SELECT+password+FROM+users--&page=2",
+	}
+	seen := map[string]int{}
+	for _, m := range ClassifyRequest(req) {
+		if m.Location != "body" {
+			continue
+		}
+		seen[m.PatternName]++
+		if m.Parameter != "body.q" {
+			t.Errorf("%s: expected the match to name body.q, got %q", m.PatternName, m.Parameter)
+		}
+	}
+	if len(seen) == 0 {
+		t.Fatal("expected body matches")
+	}
+	for name, n := range seen {
+		if n != 1 {
+			t.Errorf("%s matched %d times, want once", name, n)
+		}
+	}
+
+	// A second field carrying its own payload is still reported.
+	req.Body = "q=1'+UNION+SELECT+password+FROM+users--&sort=1'+UNION+SELECT+password+FROM+users--"
+	fields := map[string]bool{}
+	for _, m := range ClassifyRequest(req) {
+		if m.Location == "body" && m.ThreatType == sentinel.ThreatSQLi {
+			fields[m.Parameter] = true
+		}
+	}
+	if !fields["body.q"] || !fields["body.sort"] {
+		t.Errorf("expected SQLi matches on q and sort, got %v", fields)
+	}
+}
@@ -1,6 +1,7 @@
 package detection
 
 import (
+	"net/http"
 	"net/url"
 	"strings"
 
@@ -29,6 +30,18 @@ type ThreatMatch struct {
 
 	// BaseConfidence is the confidence from the pattern definition.
 	BaseConfidence int
+
+	// Transforms are the normalization steps applied to the input before
+	// matching; Matched comes from the transformed value.
+	Transforms []sentinel.Transform
+
+	// Score is the anomaly points from the pattern or rule definition;
+	// zero means "score by BaseSeverity".
+	Score int
+
+	// Conditions describes the When conditions that held for a custom
+	// rule, e.g. `param format equals "sql"`.
+	Conditions []string
 }
 
 // ClassifyRequest scans all input vectors of a request and returns all matches.
@@ -71,22 +84,48 @@ func ClassifyRequest(req sentinel.InspectedRequest) []ThreatMatch {
 
 	// Scan body (first 10KB already truncated in InspectedRequest)
 	if req.Body != "" {
-		matches = append(matches, scanInput(req.Body, "body", "")...)
+		raw := scanInput(req.Body, "body", "")
+
+		// Also scan each decoded field, so evidence names the field and
+		// payloads hidden by the encoding (JSON \u escapes, multipart
+		// parts) are seen decoded. A payload visible in the raw body
+		// matches there too; the first field matching the same pattern
+		// takes the place of the raw match rather than being counted twice.
+		unnamed := make(map[string]int, len(raw))
+		for i, m := range raw {
+			unnamed[m.PatternName] = i
+		}
+		var fields []ThreatMatch
+		contentType := http.Header(req.Headers).Get("Content-Type")
+		for _, p := range DecodeBody(contentType, req.Body) {
+			for _, m := range scanInput(p.Value, "body", p.Name) {
+				if i, ok := unnamed[m.PatternName]; ok {
+					raw[i] = m
+					delete(unnamed, m.PatternName)
+					continue
+				}
+				fields = append(fields, m)
+			}
+		}
+		matches = append(matches, raw...)
+		matches = append(matches, fields...)
 	}
 
 	return matches
 }
 
 // scanInput checks a single string against all patterns that apply to the
-// given location. Patterns scoped via Locations are skipped elsewhere — e.g.
-// SSRF host patterns never run against User-Agent or Cookie values.
+// given location, each after its own Transforms. Patterns scoped via
+// Locations are skipped elsewhere — e.g. SSRF host patterns never run
+// against User-Agent or Cookie values.
 func scanInput(input, location, parameter string) []ThreatMatch {
 	var matches []ThreatMatch
+	cache := transformCache{}
 	for _, pattern := range Patterns {
 		if !pattern.AppliesTo(location) {
 			continue
 		}
-		loc := pattern.Regex.FindString(input)
+		loc := pattern.Regex.FindString(cache.get(input, pattern.Transforms))
 		if loc != "" {
 			matches = append(matches, ThreatMatch{
 				PatternName:    pattern.Name,
@@ -96,6 +135,8 @@ func scanInput(input, location, parameter string) []ThreatMatch {
 				Parameter:      parameter,
 				BaseSeverity:   pattern.BaseSeverity,
 				BaseConfidence: pattern.BaseConfidence,
+				Transforms:     pattern.Transforms,
+				Score:          pattern.Score,
 			})
 		}
 	}
@@ -0,0 +1,362 @@
+package detection
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+)
+
+// maxConditionDepth bounds how deeply All/Any/Not may nest.
+const maxConditionDepth = 16
+
+// compiledCondition is a RuleCondition with its values parsed, ready to be
+// evaluated per request.
+type compiledCondition struct {
+	all, any []*compiledCondition
+	not      *compiledCondition
+
+	typ      sentinel.ConditionType
+	name     string
+	op       sentinel.ConditionOp
+	values   []string
+	regexes  []*regexp.Regexp
+	routes   []*sentinel.RouteMatcher
+	prefixes []netip.Prefix
+	size     int64
+}
+
+// ValidateCondition returns an error describing the first problem in a
+// condition tree, or nil if CustomRuleEngine can compile it.
+func ValidateCondition(c sentinel.RuleCondition) error {
+	_, err := compileCondition(c, 0)
+	return err
+}
+
+func compileCondition(c sentinel.RuleCondition, depth int) (*compiledCondition, error) {
+	if depth > maxConditionDepth {
+		return nil, fmt.Errorf("conditions nest deeper than %d levels", maxConditionDepth)
+	}
+	kinds := 0
+	for _, set := range []bool{len(c.All) > 0, len(c.Any) > 0, c.Not != nil, c.Type != ""} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return nil, errors.New("a condition needs exactly one of all, any, not or type")
+	}
+
+	cc := &compiledCondition{}
+	switch {
+	case len(c.All) > 0 || len(c.Any) > 0:
+		for _, child := range c.All {
+			compiled, err := compileCondition(child, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			cc.all = append(cc.all, compiled)
+		}
+		for _, child := range c.Any {
+			compiled, err := compileCondition(child, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			cc.any = append(cc.any, compiled)
+		}
+		return cc, nil
+	case c.Not != nil:
+		compiled, err := compileCondition(*c.Not, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		cc.not = compiled
+		return cc, nil
+	}
+
+	cc.typ, cc.name, cc.op, cc.values = c.Type, c.Name, c.Op, c.Values
+	if cc.op == "" {
+		cc.op = sentinel.ConditionEquals
+		if len(c.Values) == 0 && (c.Type == sentinel.ConditionHeader || c.Type == sentinel.ConditionParam) {
+			cc.op = sentinel.ConditionExists
+		}
+	}
+	if cc.op != sentinel.ConditionExists && len(c.Values) == 0 {
+		return nil, fmt.Errorf("%s condition has no values", c.Type)
+	}
+	badOp := fmt.Errorf("%s condition does not support op %q", c.Type, cc.op)
+
+	switch c.Type {
+	case sentinel.ConditionMethod, sentinel.ConditionCountry:
+		if cc.op != sentinel.ConditionEquals {
+			return nil, badOp
+		}
+	case sentinel.ConditionPath:
+		switch cc.op {
+		case sentinel.ConditionEquals:
+			for _, v := range c.Values {
+				if err := sentinel.ValidateRoutePattern(v); err != nil {
+					return nil, err
+				}
+				cc.routes = append(cc.routes, sentinel.NewRouteMatcher([]string{v}))
+			}
+		case sentinel.ConditionRegex:
+		default:
+			return nil, badOp
+		}
+	case sentinel.ConditionHeader, sentinel.ConditionParam:
+		if c.Name == "" {
+			return nil, fmt.Errorf("%s condition needs a name", c.Type)
+		}
+		switch cc.op {
+		case sentinel.ConditionExists, sentinel.ConditionEquals, sentinel.ConditionContains,
+			sentinel.ConditionPrefix, sentinel.ConditionRegex:
+		default:
+			return nil, badOp
+		}
+	case sentinel.ConditionIP:
+		if cc.op != sentinel.ConditionEquals {
+			return nil, badOp
+		}
+		for _, v := range c.Values {
+			prefix, err := netip.ParsePrefix(v)
+			if err != nil {
+				addr, errAddr := netip.ParseAddr(v)
+				if errAddr != nil {
+					return nil, fmt.Errorf("ip condition: %q is neither an IP nor a CIDR", v)
+				}
+				addr = addr.Unmap()
+				prefix = netip.PrefixFrom(addr, addr.BitLen())
+			}
+			cc.prefixes = append(cc.prefixes, prefix.Masked())
+		}
+	case sentinel.ConditionBodySize:
+		if cc.op != sentinel.ConditionGreater && cc.op != sentinel.ConditionLess {
+			return nil, badOp
+		}
+		if len(c.Values) != 1 {
+			return nil, errors.New("body_size condition takes exactly one value")
+		}
+		size, err := strconv.ParseInt(c.Values[0], 10, 64)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("body_size condition: %q is not a byte count", c.Values[0])
+		}
+		cc.size = size
+	default:
+		return nil, fmt.Errorf("unknown condition type %q", c.Type)
+	}
+
+	if cc.op == sentinel.ConditionRegex {
+		for _, v := range c.Values {
+			re, err := regexp.Compile(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s condition: %w", c.Type, err)
+			}
+			cc.regexes = append(cc.regexes, re)
+		}
+	}
+	return cc, nil
+}
+
+// usesCountry reports whether any leaf tests the client's country.
+func (cc *compiledCondition) usesCountry() bool {
+	if cc.typ == sentinel.ConditionCountry {
+		return true
+	}
+	for _, child := range append(append([]*compiledCondition{cc.not}, cc.all...), cc.any...) {
+		if child != nil && child.usesCountry() {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionInput is the request as conditions see it. Query and body
+// parameters are parsed on first use and shared by every rule.
+type conditionInput struct {
+	req    sentinel.InspectedRequest
+	parsed bool
+	query  url.Values
+	body   []BodyParam
+}
+
+func (in *conditionInput) params(name string) []string {
+	if !in.parsed {
+		in.parsed = true
+		in.query, _ = url.ParseQuery(in.req.RawQuery)
+		in.body = DecodeBody(http.Header(in.req.Headers).Get("Content-Type"), in.req.Body)
+	}
+	values := in.query[name]
+	for _, p := range in.body {
+		if p.Name == "body."+name {
+			values = append(values, p.Value)
+		}
+	}
+	return values
+}
+
+// eval reports whether the condition holds for the request and, if so,
+// which leaves made it hold, described for evidence and /waf/test.
+func (cc *compiledCondition) eval(in *conditionInput) (bool, []string) {
+	switch {
+	case len(cc.all) > 0:
+		var reasons []string
+		for _, child := range cc.all {
+			ok, r := child.eval(in)
+			if !ok {
+				return false, nil
+			}
+			reasons = append(reasons, r...)
+		}
+		return true, reasons
+	case len(cc.any) > 0:
+		for _, child := range cc.any {
+			if ok, r := child.eval(in); ok {
+				return true, r
+			}
+		}
+		return false, nil
+	case cc.not != nil:
+		if ok, _ := cc.not.eval(in); ok {
+			return false, nil
+		}
+		return true, []string{"not " + cc.not.describe()}
+	}
+	if v, ok := cc.matchLeaf(in); ok {
+		return true, []string{cc.describeValue(v)}
+	}
+	return false, nil
+}
+
+// matchLeaf returns the configured value that matched.
+func (cc *compiledCondition) matchLeaf(in *conditionInput) (string, bool) {
+	req := in.req
+	switch cc.typ {
+	case sentinel.ConditionMethod:
+		return matchFold(req.Method, cc.values)
+	case sentinel.ConditionCountry:
+		return matchFold(req.Country, cc.values)
+	case sentinel.ConditionPath:
+		if cc.op == sentinel.ConditionRegex {
+			return cc.matchString(req.Path)
+		}
+		for i, m := range cc.routes {
+			if m.Matches(req.Path) {
+				return cc.values[i], true
+			}
+		}
+	case sentinel.ConditionHeader:
+		values, present := http.Header(req.Headers)[http.CanonicalHeaderKey(cc.name)]
+		return cc.matchNamed(values, present)
+	case sentinel.ConditionParam:
+		values := in.params(cc.name)
+		return cc.matchNamed(values, len(values) > 0)
+	case sentinel.ConditionIP:
+		addr, err := netip.ParseAddr(req.IP)
+		if err != nil {
+			return "", false
+		}
+		addr = addr.Unmap()
+		for i, p := range cc.prefixes {
+			if p.Contains(addr) {
+				return cc.values[i], true
+			}
+		}
+	case sentinel.ConditionBodySize:
+		size := req.BodySize
+		if size == 0 {
+			size = int64(len(req.Body))
+		}
+		if cc.op == sentinel.ConditionGreater && size > cc.size || cc.op == sentinel.ConditionLess && size < cc.size {
+			return cc.values[0], true
+		}
+	}
+	return "", false
+}
+
+func (cc *compiledCondition) matchNamed(values []string, present bool) (string, bool) {
+	if cc.op == sentinel.ConditionExists {
+		return "", present
+	}
+	for _, v := range values {
+		if m, ok := cc.matchString(v); ok {
+			return m, true
+		}
+	}
+	return "", false
+}
+
+func (cc *compiledCondition) matchString(s string) (string, bool) {
+	for i, want := range cc.values {
+		var ok bool
+		switch cc.op {
+		case sentinel.ConditionEquals:
+			ok = s == want
+		case sentinel.ConditionContains:
+			ok = strings.Contains(s, want)
+		case sentinel.ConditionPrefix:
+			ok = strings.HasPrefix(s, want)
+		case sentinel.ConditionRegex:
+			ok = cc.regexes[i].MatchString(s)
+		}
+		if ok {
+			return want, true
+		}
+	}
+	return "", false
+}
+
+func matchFold(s string, values []string) (string, bool) {
+	for _, v := range values {
+		if strings.EqualFold(s, v) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func (cc *compiledCondition) subject() string {
+	if cc.name != "" {
+		return string(cc.typ) + " " + cc.name
+	}
+	return string(cc.typ)
+}
+
+// describeValue renders a leaf that held because of value, e.g.
+// `param format equals "sql"` or `header X-Debug exists`.
+func (cc *compiledCondition) describeValue(value string) string {
+	if cc.op == sentinel.ConditionExists {
+		return cc.subject() + " exists"
+	}
+	return fmt.Sprintf("%s %s %q", cc.subject(), cc.op, value)
+}
+
+// describe renders the whole condition, for Not.
+func (cc *compiledCondition) describe() string {
+	join := func(op string, children []*compiledCondition) string {
+		parts := make([]string, len(children))
+		for i, child := range children {
+			parts[i] = child.describe()
+		}
+		return op + "(" + strings.Join(parts, ", ") + ")"
+	}
+	switch {
+	case len(cc.all) > 0:
+		return join("all", cc.all)
+	case len(cc.any) > 0:
+		return join("any", cc.any)
+	case cc.not != nil:
+		return "not " + cc.not.describe()
+	case cc.op == sentinel.ConditionExists:
+		return cc.subject() + " exists"
+	case len(cc.values) == 1:
+		return fmt.Sprintf("%s %s %q", cc.subject(), cc.op, cc.values[0])
+	}
+	return fmt.Sprintf("%s %s %q", cc.subject(), cc.op, cc.values)
+}
@@ -1,22 +1,59 @@
 package detection
 
 import (
+	"errors"
+	"fmt"
 	"regexp"
+	"strings"
 	"sync"
 
 	sentinel "github.com/MUKE-coder/sentinel/v2/core"
 )
 
-// CompiledRule is a custom WAF rule with a compiled regex.
+// CompiledRule is a custom WAF rule with a compiled regex. Regex is nil
+// for a rule with only a When condition.
 type CompiledRule struct {
 	Rule  sentinel.WAFRule
 	Regex *regexp.Regexp
+
+	when *compiledCondition
+}
+
+// compileRule checks and compiles a rule: its pattern, transforms and
+// condition tree. A rule needs a pattern, a condition or both.
+func compileRule(rule sentinel.WAFRule) (*CompiledRule, error) {
+	cr := &CompiledRule{Rule: rule}
+	if rule.Pattern == "" && rule.When == nil {
+		return nil, errors.New("rule needs a pattern or a when condition")
+	}
+	if rule.Pattern != "" {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		cr.Regex = re
+	}
+	if err := ValidateTransforms(rule.Transforms); err != nil {
+		return nil, err
+	}
+	if rule.When != nil {
+		when, err := compileCondition(*rule.When, 0)
+		if err != nil {
+			return nil, err
+		}
+		cr.when = when
+	}
+	return cr, nil
 }
 
 // CustomRuleEngine manages custom WAF rules that can be added/removed at runtime.
+// Rules imported from SecLang files are held apart from the configured
+// ones, so replacing the configured set from the dashboard or a rollback
+// leaves them in place.
 type CustomRuleEngine struct {
-	mu    sync.RWMutex
-	rules map[string]*CompiledRule // keyed by rule ID
+	mu       sync.RWMutex
+	rules    map[string]*CompiledRule // keyed by rule ID
+	imported []*CompiledRule
 }
 
 // NewCustomRuleEngine creates a new custom rule engine with optional initial rules.
@@ -30,18 +67,64 @@ func NewCustomRuleEngine(initial []sentinel.WAFRule) *CustomRuleEngine {
 	return e
 }
 
-// AddRule compiles and adds a custom rule. Returns error if regex is invalid.
+// AddRule compiles and adds a custom rule. Returns error if regex is invalid,
+// a transform is unknown or the When condition does not compile.
 func (e *CustomRuleEngine) AddRule(rule sentinel.WAFRule) error {
-	compiled, err := regexp.Compile(rule.Pattern)
+	compiled, err := compileRule(rule)
 	if err != nil {
 		return err
 	}
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	e.rules[rule.ID] = &CompiledRule{Rule: rule, Regex: compiled}
+	e.rules[rule.ID] = compiled
+	return nil
+}
+
+// ReplaceRules swaps the whole rule set, e.g. when a persisted config
+// version is rolled back. Nothing changes unless every pattern compiles.
+func (e *CustomRuleEngine) ReplaceRules(rules []sentinel.WAFRule) error {
+	compiled := make(map[string]*CompiledRule, len(rules))
+	for _, r := range rules {
+		cr, err := compileRule(r)
+		if err != nil {
+			return fmt.Errorf("rule %q: %w", r.ID, err)
+		}
+		compiled[r.ID] = cr
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rules = compiled
+	return nil
+}
+
+// ImportRules adds rules parsed from a rule file, e.g. by ParseSecLang,
+// to the imported set. Nothing is added unless every rule compiles.
+func (e *CustomRuleEngine) ImportRules(rules []sentinel.WAFRule) error {
+	compiled := make([]*CompiledRule, 0, len(rules))
+	for _, r := range rules {
+		cr, err := compileRule(r)
+		if err != nil {
+			return fmt.Errorf("rule %q: %w", r.ID, err)
+		}
+		compiled = append(compiled, cr)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.imported = append(e.imported, compiled...)
 	return nil
 }
 
+// ImportedRules returns the rules added by ImportRules, in import order.
+func (e *CustomRuleEngine) ImportedRules() []sentinel.WAFRule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	result := make([]sentinel.WAFRule, len(e.imported))
+	for i, cr := range e.imported {
+		result[i] = cr.Rule
+	}
+	return result
+}
+
 // RemoveRule removes a custom rule by ID.
 func (e *CustomRuleEngine) RemoveRule(id string) bool {
 	e.mu.Lock()
@@ -51,7 +134,8 @@ func (e *CustomRuleEngine) RemoveRule(id string) bool {
 	return exists
 }
 
-// ListRules returns all custom rules.
+// ListRules returns all configured custom rules; see ImportedRules for the
+// imported ones.
 func (e *CustomRuleEngine) ListRules() []sentinel.WAFRule {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
@@ -73,17 +157,42 @@ func (e *CustomRuleEngine) GetRule(id string) (sentinel.WAFRule, bool) {
 	return cr.Rule, true
 }
 
-// ClassifyRequest scans a request against all enabled custom rules.
+// UsesCountry reports whether an enabled rule has a country condition, so
+// the WAF only geolocates requests when a rule will look at the result.
+func (e *CustomRuleEngine) UsesCountry() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for _, cr := range e.rules {
+		if cr.Rule.Enabled && cr.when != nil && cr.when.usesCountry() {
+			return true
+		}
+	}
+	for _, cr := range e.imported {
+		if cr.Rule.Enabled && cr.when != nil && cr.when.usesCountry() {
+			return true
+		}
+	}
+	return false
+}
+
+// ClassifyRequest scans a request against all enabled custom rules,
+// configured and imported.
 func (e *CustomRuleEngine) ClassifyRequest(req sentinel.InspectedRequest) []ThreatMatch {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
+	in := &conditionInput{req: req}
 	var matches []ThreatMatch
 	for _, cr := range e.rules {
 		if !cr.Rule.Enabled {
 			continue
 		}
-		matches = append(matches, e.scanRule(cr, req)...)
+		matches = append(matches, e.scanRule(cr, in)...)
+	}
+	for _, cr := range e.imported {
+		if cr.Rule.Enabled {
+			matches = append(matches, e.scanRule(cr, in)...)
+		}
 	}
 	return matches
 }
@@ -105,7 +214,38 @@ func (e *CustomRuleEngine) TestPayload(payload string) []ThreatMatch {
 	return append(builtinMatches, customMatches...)
 }
 
-func (e *CustomRuleEngine) scanRule(cr *CompiledRule, req sentinel.InspectedRequest) []ThreatMatch {
+// TestRequest runs a whole request through the built-in patterns and all
+// enabled custom rules, as the WAF would, so rules with When conditions can
+// be tried out.
+func (e *CustomRuleEngine) TestRequest(req sentinel.InspectedRequest) []ThreatMatch {
+	return append(ClassifyRequest(req), e.ClassifyRequest(req)...)
+}
+
+func (e *CustomRuleEngine) scanRule(cr *CompiledRule, in *conditionInput) []ThreatMatch {
+	req := in.req
+	var conditions []string
+	if cr.when != nil {
+		ok, reasons := cr.when.eval(in)
+		if !ok {
+			return nil
+		}
+		conditions = reasons
+	}
+	// A condition-only rule is a virtual patch: the request shape is the
+	// whole signature.
+	if cr.Regex == nil {
+		return []ThreatMatch{{
+			PatternName:    cr.Rule.Name,
+			ThreatType:     sentinel.ThreatType("CustomRule"),
+			Matched:        truncate(strings.Join(conditions, "; "), 200),
+			Location:       "request",
+			BaseSeverity:   cr.Rule.Severity,
+			BaseConfidence: 85,
+			Score:          cr.Rule.Score,
+			Conditions:     conditions,
+		}}
+	}
+
 	var matches []ThreatMatch
 	appliesTo := make(map[string]bool)
 	for _, a := range cr.Rule.AppliesTo {
@@ -115,64 +255,40 @@ func (e *CustomRuleEngine) scanRule(cr *CompiledRule, req sentinel.InspectedRequ
 	// If AppliesTo is empty, check everything
 	checkAll := len(appliesTo) == 0
 
-	if checkAll || appliesTo["path"] {
-		if m := cr.Regex.FindString(req.Path); m != "" {
+	scan := func(input, location string) {
+		if input == "" {
+			return
+		}
+		if m := cr.Regex.FindString(ApplyTransforms(input, cr.Rule.Transforms)); m != "" {
 			matches = append(matches, ThreatMatch{
 				PatternName:    cr.Rule.Name,
 				ThreatType:     sentinel.ThreatType("CustomRule"),
 				Matched:        truncate(m, 200),
-				Location:       "path",
+				Location:       location,
 				BaseSeverity:   cr.Rule.Severity,
 				BaseConfidence: 85,
+				Transforms:     cr.Rule.Transforms,
+				Score:          cr.Rule.Score,
+				Conditions:     conditions,
 			})
 		}
 	}
 
+	if checkAll || appliesTo["path"] {
+		scan(req.Path, "path")
+	}
 	if checkAll || appliesTo["query"] {
-		if req.RawQuery != "" {
-			if m := cr.Regex.FindString(req.RawQuery); m != "" {
-				matches = append(matches, ThreatMatch{
-					PatternName:    cr.Rule.Name,
-					ThreatType:     sentinel.ThreatType("CustomRule"),
-					Matched:        truncate(m, 200),
-					Location:       "query",
-					BaseSeverity:   cr.Rule.Severity,
-					BaseConfidence: 85,
-				})
-			}
-		}
+		scan(req.RawQuery, "query")
 	}
-
 	if checkAll || appliesTo["header"] {
 		for _, values := range req.Headers {
 			for _, val := range values {
-				if m := cr.Regex.FindString(val); m != "" {
-					matches = append(matches, ThreatMatch{
-						PatternName:    cr.Rule.Name,
-						ThreatType:     sentinel.ThreatType("CustomRule"),
-						Matched:        truncate(m, 200),
-						Location:       "header",
-						BaseSeverity:   cr.Rule.Severity,
-						BaseConfidence: 85,
-					})
-				}
+				scan(val, "header")
 			}
 		}
 	}
-
 	if checkAll || appliesTo["body"] {
-		if req.Body != "" {
-			if m := cr.Regex.FindString(req.Body); m != "" {
-				matches = append(matches, ThreatMatch{
-					PatternName:    cr.Rule.Name,
-					ThreatType:     sentinel.ThreatType("CustomRule"),
-					Matched:        truncate(m, 200),
-					Location:       "body",
-					BaseSeverity:   cr.Rule.Severity,
-					BaseConfidence: 85,
-				})
-			}
-		}
+		scan(req.Body, "body")
 	}
 
 	return matches
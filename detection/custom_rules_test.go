@@ -169,3 +169,115 @@ func TestCustomRuleEngine_AppliesTo(t *testing.T) {
 		t.Error("expected 1 match for body")
 	}
 }
+
+func TestCustomRuleEngine_Conditions(t *testing.T) {
+	engine := detection.NewCustomRuleEngine(nil)
+	patch := sentinel.WAFRule{
+		ID:       "export-patch",
+		Name:     "Debug SQL export",
+		Severity: sentinel.SeverityHigh,
+		Enabled:  true,
+		When: &sentinel.RuleCondition{All: []sentinel.RuleCondition{
+			{Type: sentinel.ConditionMethod, Values: []string{"post"}},
+			{Type: sentinel.ConditionPath, Values: []string{"/api/v1/export"}},
+			{Type: sentinel.ConditionHeader, Name: "x-debug"},
+			{Type: sentinel.ConditionParam, Name: "format", Values: []string{"sql"}},
+			{Not: &sentinel.RuleCondition{Type: sentinel.ConditionIP, Values: []string{"10.0.0.0/8"}}},
+		}},
+	}
+	if err := engine.AddRule(patch); err != nil {
+		t.Fatalf("AddRule failed: %v", err)
+	}
+
+	req := sentinel.InspectedRequest{
+		Method:   "POST",
+		Path:     "/api/v1/export",
+		RawQuery: "format=sql",
+		Headers:  map[string][]string{"X-Debug": {"1"}},
+		IP:       "203.0.113.7",
+	}
+	matches := engine.ClassifyRequest(req)
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(matches))
+	}
+	want := []string{`method equals "post"`, `path equals "/api/v1/export"`, "header x-debug exists",
+		`param format equals "sql"`, `not ip equals "10.0.0.0/8"`}
+	if got := matches[0].Conditions; len(got) != len(want) {
+		t.Fatalf("conditions: got %q, want %q", got, want)
+	} else {
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("condition %d: got %q, want %q", i, got[i], want[i])
+			}
+		}
+	}
+
+	// The format can also arrive in a JSON body.
+	jsonReq := req
+	jsonReq.RawQuery = ""
+	jsonReq.Headers = map[string][]string{"X-Debug": {"1"}, "Content-Type": {"application/json"}}
+	jsonReq.Body = `{"format":"sql"}`
+	if len(engine.ClassifyRequest(jsonReq)) != 1 {
+		t.Error("expected the body parameter to satisfy the param condition")
+	}
+
+	for name, mutate := range map[string]func(r *sentinel.InspectedRequest){
+		"other method":  func(r *sentinel.InspectedRequest) { r.Method = "GET" },
+		"no header":     func(r *sentinel.InspectedRequest) { r.Headers = nil },
+		"other format":  func(r *sentinel.InspectedRequest) { r.RawQuery = "format=csv" },
+		"internal IP":   func(r *sentinel.InspectedRequest) { r.IP = "10.1.2.3" },
+		"other path":    func(r *sentinel.InspectedRequest) { r.Path = "/api/v1/exports" },
+		"param in path": func(r *sentinel.InspectedRequest) { r.RawQuery = ""; r.Path = "/api/v1/export?format=sql" },
+	} {
+		r := req
+		mutate(&r)
+		if got := engine.ClassifyRequest(r); len(got) != 0 {
+			t.Errorf("%s: expected no match, got %v", name, got[0].Conditions)
+		}
+	}
+
+	// Any, body size and country, scoping a pattern.
+	scoped := sentinel.WAFRule{
+		ID:      "scoped",
+		Name:    "Large upload from RU or KP",
+		Pattern: `(?i)<\?php`,
+		Enabled: true,
+		When: &sentinel.RuleCondition{All: []sentinel.RuleCondition{
+			{Any: []sentinel.RuleCondition{
+				{Type: sentinel.ConditionCountry, Values: []string{"RU"}},
+				{Type: sentinel.ConditionCountry, Values: []string{"KP"}},
+			}},
+			{Type: sentinel.ConditionBodySize, Op: sentinel.ConditionGreater, Values: []string{"10"}},
+		}},
+	}
+	if err := engine.AddRule(scoped); err != nil {
+		t.Fatalf("AddRule failed: %v", err)
+	}
+	if !engine.UsesCountry() {
+		t.Error("expected UsesCountry to report the country condition")
+	}
+	upload := sentinel.InspectedRequest{Path: "/upload", Body: "<?php system($_GET['c']);", BodySize: 4096, Country: "kp"}
+	if got := engine.ClassifyRequest(upload); len(got) != 1 || got[0].Location != "body" || got[0].Conditions[0] != `country equals "KP"` {
+		t.Errorf("expected a scoped body match, got %+v", got)
+	}
+	upload.Country = "DE"
+	if got := engine.ClassifyRequest(upload); len(got) != 0 {
+		t.Errorf("expected no match outside the countries, got %d", len(got))
+	}
+
+	for name, when := range map[string]sentinel.RuleCondition{
+		"two kinds":           {Type: sentinel.ConditionMethod, Values: []string{"GET"}, Any: []sentinel.RuleCondition{{Type: sentinel.ConditionIP, Values: []string{"1.2.3.4"}}}},
+		"unknown type":        {Type: "cookie", Values: []string{"x"}},
+		"bad cidr":            {Type: sentinel.ConditionIP, Values: []string{"10.0.0.0/33"}},
+		"size without op":     {Type: sentinel.ConditionBodySize, Values: []string{"10"}},
+		"header without name": {Type: sentinel.ConditionHeader},
+		"bad route":           {Type: sentinel.ConditionPath, Values: []string{"/a/**/b"}},
+	} {
+		if err := engine.AddRule(sentinel.WAFRule{ID: "bad", When: &when}); err == nil {
+			t.Errorf("%s: expected a compile error", name)
+		}
+	}
+	if err := engine.AddRule(sentinel.WAFRule{ID: "empty"}); err == nil {
+		t.Error("expected an error for a rule with neither pattern nor condition")
+	}
+}
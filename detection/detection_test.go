@@ -262,6 +262,26 @@ func TestScoreThreats(t *testing.T) {
 	}
 }
 
+func TestAnomalyScore(t *testing.T) {
+	matches := []ThreatMatch{
+		{PatternName: "SQLi_Basic", ThreatType: sentinel.ThreatSQLi, BaseSeverity: sentinel.SeverityHigh, Location: "query"},
+		{PatternName: "SQLi_Basic", ThreatType: sentinel.ThreatSQLi, BaseSeverity: sentinel.SeverityHigh, Location: "query", Parameter: "id"},
+		{PatternName: "SQLi_Comment", ThreatType: sentinel.ThreatSQLi, BaseSeverity: sentinel.SeverityMedium},
+		{PatternName: "block-admin", ThreatType: "CustomRule", BaseSeverity: sentinel.SeverityLow, Score: 9},
+	}
+	total, points := AnomalyScore(matches, nil)
+	// The repeated SQLi_Basic match scores once; the custom rule's own
+	// score overrides its severity.
+	if total != 4+3+9 || points[0] != 4 || points[1] != 0 || points[2] != 3 || points[3] != 9 {
+		t.Errorf("unexpected score %d %v", total, points)
+	}
+
+	total, _ = AnomalyScore(matches[:3], map[sentinel.Severity]int{sentinel.SeverityHigh: 1})
+	if total != 1+3 {
+		t.Errorf("severity points should override only the severities they list, got %d", total)
+	}
+}
+
 func TestMatchesToEvidence(t *testing.T) {
 	matches := []ThreatMatch{
 		{PatternName: "SQLi_Basic", Matched: "' OR 1=1--", Location: "query", Parameter: "id"},
@@ -0,0 +1,214 @@
+package detection
+
+import (
+	"regexp"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+)
+
+// Leak is one finding from ScanResponse: a named signature of Class found
+// at body[Start:End].
+type Leak struct {
+	Class sentinel.DLPClass
+	Name  string
+	Start int
+	End   int
+}
+
+// leakPattern is a named response signature. Card numbers are found by
+// cardCandidate and confirmed in code instead.
+type leakPattern struct {
+	Class sentinel.DLPClass
+	Name  string
+	Regex *regexp.Regexp
+}
+
+// Stack trace signatures are unanchored: in a JSON body the line breaks
+// between frames are "\n" escapes, not newlines.
+var leakPatterns = []leakPattern{
+	{sentinel.DLPDatabaseError, "MySQLError", regexp.MustCompile(`You have an error in your SQL syntax|mysql_fetch_(?:array|assoc|row|object)\(|Warning: mysqli?_[a-z_]+\(`)},
+	{sentinel.DLPDatabaseError, "PostgreSQLError", regexp.MustCompile(`PG::[A-Za-z]+Error|org\.postgresql\.util\.PSQLException|\bpq: (?:syntax error|relation|column|duplicate key|invalid input)|ERROR:\s+syntax error at or near`)},
+	{sentinel.DLPDatabaseError, "OracleError", regexp.MustCompile(`\bORA-\d{5}\b`)},
+	{sentinel.DLPDatabaseError, "MSSQLError", regexp.MustCompile(`Unclosed quotation mark after the character string|Microsoft OLE DB Provider for SQL Server|\[Microsoft\]\[ODBC SQL Server Driver\]|System\.Data\.SqlClient\.SqlException`)},
+	{sentinel.DLPDatabaseError, "SQLiteError", regexp.MustCompile(`SQLITE_ERROR|sqlite3\.OperationalError|SQLite3::SQLException|near "[^"\n]{1,64}": syntax error`)},
+	{sentinel.DLPDatabaseError, "SQLState", regexp.MustCompile(`SQLSTATE\[[0-9A-Z]{5}\]`)},
+
+	{sentinel.DLPStackTrace, "GoPanic", regexp.MustCompile(`goroutine \d+ \[[a-z ,0-9]+\]:`)},
+	{sentinel.DLPStackTrace, "JavaStackTrace", regexp.MustCompile(`at [\w$]+(?:\.[\w$<>]+)+\([\w$]+\.(?:java|kt|scala):\d+\)`)},
+	{sentinel.DLPStackTrace, "PythonTraceback", regexp.MustCompile(`Traceback \(most recent call last\):`)},
+	{sentinel.DLPStackTrace, "DotNetStackTrace", regexp.MustCompile(`at [\w.` + "`" + `<>]+\([^)]*\) in [^\s"]+:line \d+`)},
+	{sentinel.DLPStackTrace, "NodeStackTrace", regexp.MustCompile(`at [^\s()]+ \((?:/|[A-Za-z]:\\|node:)[^)\s]*:\d+:\d+\)`)},
+	{sentinel.DLPStackTrace, "PHPError", regexp.MustCompile(`(?:Fatal error|Parse error|Warning|Notice)(?:</b>)?: .{1,300}? in (?:<b>)?/[^\s<]{1,256}(?:</b>)? on line (?:<b>)?\d+`)},
+
+	{sentinel.DLPSecretKey, "AWSAccessKey", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{sentinel.DLPSecretKey, "PrivateKey", regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |ENCRYPTED |PGP )?PRIVATE KEY(?: BLOCK)?-----(?s:.*?)(?:-----END [A-Z ]*PRIVATE KEY(?: BLOCK)?-----|$)`)},
+	{sentinel.DLPSecretKey, "GitHubToken", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{82})\b`)},
+	{sentinel.DLPSecretKey, "SlackToken", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,72}`)},
+	{sentinel.DLPSecretKey, "StripeSecretKey", regexp.MustCompile(`\b[sr]k_live_[A-Za-z0-9]{20,99}\b`)},
+	{sentinel.DLPSecretKey, "GoogleAPIKey", regexp.MustCompile(`\bAIza[A-Za-z0-9_-]{35}`)},
+}
+
+// cardCandidate finds 13-19 digit runs, optionally grouped by single spaces
+// or dashes. Candidates are confirmed by isCardNumber.
+var cardCandidate = regexp.MustCompile(`\d(?:[ -]?\d){12,18}`)
+
+// ScanResponse returns every leak of the given classes in body, in order of
+// class and then position. Empty classes means all of them.
+func ScanResponse(body []byte, classes []sentinel.DLPClass) []Leak {
+	enabled := func(c sentinel.DLPClass) bool {
+		if len(classes) == 0 {
+			return true
+		}
+		for _, want := range classes {
+			if want == c {
+				return true
+			}
+		}
+		return false
+	}
+
+	var leaks []Leak
+	for _, p := range leakPatterns {
+		if !enabled(p.Class) {
+			continue
+		}
+		for _, loc := range p.Regex.FindAllIndex(body, -1) {
+			leaks = append(leaks, Leak{Class: p.Class, Name: p.Name, Start: loc[0], End: loc[1]})
+		}
+	}
+	if enabled(sentinel.DLPCardNumber) {
+		for _, loc := range cardCandidate.FindAllIndex(body, -1) {
+			// Part of a longer number (an ID, a timestamp) is not a card.
+			if loc[0] > 0 && isDigit(body[loc[0]-1]) || loc[1] < len(body) && isDigit(body[loc[1]]) {
+				continue
+			}
+			if isCardNumber(body[loc[0]:loc[1]]) {
+				leaks = append(leaks, Leak{Class: sentinel.DLPCardNumber, Name: "CardNumber", Start: loc[0], End: loc[1]})
+			}
+		}
+	}
+	return leaks
+}
+
+// LeakSeverity returns the severity of a leak class: card numbers and
+// secrets are data loss in themselves, errors and traces are
+// reconnaissance.
+func LeakSeverity(c sentinel.DLPClass) sentinel.Severity {
+	switch c {
+	case sentinel.DLPCardNumber, sentinel.DLPSecretKey:
+		return sentinel.SeverityCritical
+	default:
+		return sentinel.SeverityMedium
+	}
+}
+
+// MaskLeaks overwrites every leak in body with "*", in place. Card numbers
+// keep their last four digits and their separators; secret keys keep their
+// first four characters, which name the key type but not the key.
+func MaskLeaks(body []byte, leaks []Leak) {
+	for _, l := range leaks {
+		maskLeak(body[l.Start:l.End], l.Class)
+	}
+}
+
+// RedactLeak returns the text of l in a form safe to store as evidence.
+func RedactLeak(body []byte, l Leak) string {
+	end := l.End
+	if end-l.Start > 200 {
+		end = l.Start + 200
+	}
+	out := append([]byte(nil), body[l.Start:end]...)
+	if l.Class == sentinel.DLPCardNumber || l.Class == sentinel.DLPSecretKey {
+		// Mask the full match, not the truncated copy, so a long secret
+		// never keeps more than its prefix.
+		full := append([]byte(nil), body[l.Start:l.End]...)
+		maskLeak(full, l.Class)
+		out = full[:end-l.Start]
+	}
+	return string(out)
+}
+
+func maskLeak(b []byte, class sentinel.DLPClass) {
+	switch class {
+	case sentinel.DLPCardNumber:
+		keep := 4
+		for i := len(b) - 1; i >= 0; i-- {
+			if !isDigit(b[i]) {
+				continue
+			}
+			if keep > 0 {
+				keep--
+				continue
+			}
+			b[i] = '*'
+		}
+	case sentinel.DLPSecretKey:
+		for i := 4; i < len(b); i++ {
+			b[i] = '*'
+		}
+	default:
+		for i := range b {
+			b[i] = '*'
+		}
+	}
+}
+
+// isCardNumber reports whether s (digits with optional separators) has a
+// card length, a known network prefix and a valid Luhn checksum.
+func isCardNumber(s []byte) bool {
+	digits := make([]byte, 0, 19)
+	for _, c := range s {
+		if isDigit(c) {
+			digits = append(digits, c-'0')
+		}
+	}
+	n := len(digits)
+	if n < 13 || n > 19 || !knownCardPrefix(digits) {
+		return false
+	}
+	sum := 0
+	for i := 0; i < n; i++ {
+		d := int(digits[n-1-i])
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// knownCardPrefix checks the issuer prefixes of Visa, Mastercard, American
+// Express, Discover, JCB and Diners Club. Without it every Luhn-valid
+// number — one in ten — would count as a card.
+func knownCardPrefix(d []byte) bool {
+	prefix := func(n int) int {
+		v := 0
+		for _, c := range d[:n] {
+			v = v*10 + int(c)
+		}
+		return v
+	}
+	n := len(d)
+	switch p2, p4 := prefix(2), prefix(4); {
+	case d[0] == 4:
+		return n == 13 || n == 16 || n == 19
+	case p2 >= 51 && p2 <= 55, p4 >= 2221 && p4 <= 2720:
+		return n == 16
+	case p2 == 34 || p2 == 37:
+		return n == 15
+	case p4 == 6011, p2 == 65, prefix(3) >= 644 && prefix(3) <= 649:
+		return n >= 16
+	case p4 >= 3528 && p4 <= 3589:
+		return n >= 16
+	case prefix(3) >= 300 && prefix(3) <= 305, p2 == 36, p2 == 38:
+		return n >= 14 && n <= 19
+	}
+	return false
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
@@ -25,6 +25,15 @@ type PatternDef struct {
 	// BaseConfidence is the default confidence score (0-100) for this pattern.
 	BaseConfidence int
 
+	// Transforms normalize each input, in order, before Regex is matched
+	// against it. Patterns that look for an encoding itself (XSS_Encoded,
+	// SQLi_Comment) must not decode it away.
+	Transforms []sentinel.Transform
+
+	// Score is the pattern's anomaly points under WAFConfig.Scoring. Zero
+	// scores it by BaseSeverity (see AnomalyScore).
+	Score int
+
 	// Locations restricts which request locations this pattern is evaluated
 	// against: "path", "query", "header", "body". Empty means all locations.
 	// Patterns for vulnerabilities that only exist in attacker-supplied URLs
@@ -52,6 +61,26 @@ func (p PatternDef) AppliesTo(location string) bool {
 // Patterns are compiled at package init time, not per-request.
 var Patterns []PatternDef
 
+// Transform chains shared by the built-in patterns. None lowercases: every
+// pattern that would need it is already case-insensitive.
+var (
+	// sqlTransforms undoes the usual SQLi evasions: double encoding,
+	// fullwidth keywords, NUL padding and "UN/**/ION" splitting.
+	sqlTransforms = []sentinel.Transform{
+		sentinel.TransformURLDecode, sentinel.TransformNormalizeUnicode, sentinel.TransformRemoveNulls,
+		sentinel.TransformRemoveComments, sentinel.TransformCompressWhitespace,
+	}
+	markupTransforms = []sentinel.Transform{
+		sentinel.TransformURLDecode, sentinel.TransformHTMLEntityDecode, sentinel.TransformNormalizeUnicode,
+		sentinel.TransformRemoveNulls,
+	}
+	// pathTransforms also turn overlong "%c0%ae" dots into plain ones.
+	pathTransforms = []sentinel.Transform{
+		sentinel.TransformURLDecode, sentinel.TransformNormalizeUnicode, sentinel.TransformRemoveNulls,
+	}
+	decodeTransforms = []sentinel.Transform{sentinel.TransformURLDecode, sentinel.TransformRemoveNulls}
+)
+
 func init() {
 	Patterns = []PatternDef{
 		// --- SQL Injection ---
@@ -72,6 +101,7 @@ func init() {
 			BaseSeverity:   sentinel.SeverityHigh,
 			BaseConfidence: 80,
 			Locations:      []string{"query", "body"},
+			Transforms:     sqlTransforms,
 		},
 		{
 			Name:           "SQLi_Blind",
@@ -80,14 +110,17 @@ func init() {
 			BaseSeverity:   sentinel.SeverityHigh,
 			BaseConfidence: 75,
 			Locations:      []string{"query", "body"},
+			Transforms:     sqlTransforms,
 		},
 		{
+			// Looks for comments, so must not strip them first.
 			Name:           "SQLi_Comment",
 			Regex:          regexp.MustCompile(`(?i)(/\*.*\*/|--\s|#\s*$)`),
 			ThreatType:     sentinel.ThreatSQLi,
 			BaseSeverity:   sentinel.SeverityMedium,
 			BaseConfidence: 50,
 			Locations:      []string{"query", "body"},
+			Transforms:     decodeTransforms,
 		},
 		{
 			Name:           "SQLi_Stacked",
@@ -96,6 +129,7 @@ func init() {
 			BaseSeverity:   sentinel.SeverityHigh,
 			BaseConfidence: 85,
 			Locations:      []string{"query", "body"},
+			Transforms:     sqlTransforms,
 		},
 
 		// --- Cross-Site Scripting (XSS) ---
@@ -112,8 +146,10 @@ func init() {
 			BaseSeverity:   sentinel.SeverityHigh,
 			BaseConfidence: 80,
 			Locations:      []string{"path", "query", "header", "body"},
+			Transforms:     markupTransforms,
 		},
 		{
+			// Matches the encodings themselves; no transforms.
 			Name:           "XSS_Encoded",
 			Regex:          regexp.MustCompile(`(?i)(%3cscript|%3c%2fscript|&#x3[cC];script|&lt;script|%253cscript)`),
 			ThreatType:     sentinel.ThreatXSS,
@@ -128,6 +164,7 @@ func init() {
 			BaseSeverity:   sentinel.SeverityHigh,
 			BaseConfidence: 75,
 			Locations:      []string{"path", "query", "header", "body"},
+			Transforms:     markupTransforms,
 		},
 
 		// --- Path Traversal ---
@@ -138,6 +175,7 @@ func init() {
 			BaseSeverity:   sentinel.SeverityHigh,
 			BaseConfidence: 85,
 			Locations:      []string{"path", "query", "body"},
+			Transforms:     pathTransforms,
 		},
 
 		// --- Command Injection ---
@@ -148,6 +186,7 @@ func init() {
 			BaseSeverity:   sentinel.SeverityCritical,
 			BaseConfidence: 85,
 			Locations:      []string{"query", "body"},
+			Transforms:     decodeTransforms,
 		},
 
 		// --- SSRF ---
@@ -185,6 +224,7 @@ func init() {
 			BaseSeverity:   sentinel.SeverityHigh,
 			BaseConfidence: 80,
 			Locations:      []string{"path", "query", "body"},
+			Transforms:     pathTransforms,
 		},
 
 		// --- Open Redirect ---
@@ -207,6 +247,7 @@ func init() {
 			BaseSeverity:   sentinel.SeverityMedium,
 			BaseConfidence: 75,
 			Locations:      []string{"query", "body"},
+			Transforms:     pathTransforms,
 		},
 	}
 }
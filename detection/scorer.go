@@ -63,15 +63,61 @@ func ScoreThreats(matches []ThreatMatch) (sentinel.Severity, int) {
 	return maxSeverity, confidence
 }
 
+// DefaultSeverityPoints are the anomaly points of a match whose pattern or
+// rule sets no Score, following the OWASP CRS critical/error/warning/notice
+// values.
+var DefaultSeverityPoints = map[sentinel.Severity]int{
+	sentinel.SeverityCritical: 5,
+	sentinel.SeverityHigh:     4,
+	sentinel.SeverityMedium:   3,
+	sentinel.SeverityLow:      2,
+}
+
+// AnomalyScore totals the anomaly points of matches. Each pattern or rule
+// counts once, at its highest-scoring match: the raw query and every parsed
+// parameter are scanned separately, so one payload would otherwise score
+// twice. severityPoints scores matches without their own Score, falling
+// back to DefaultSeverityPoints. The second result holds the points each
+// match contributed, aligned with matches, with 0 for repeats.
+func AnomalyScore(matches []ThreatMatch, severityPoints map[sentinel.Severity]int) (int, []int) {
+	points := make([]int, len(matches))
+	best := make(map[string]int) // pattern key -> index of its scoring match
+	for i, m := range matches {
+		p := m.Score
+		if p == 0 {
+			var ok bool
+			if p, ok = severityPoints[m.BaseSeverity]; !ok {
+				p = DefaultSeverityPoints[m.BaseSeverity]
+			}
+		}
+		key := string(m.ThreatType) + "|" + m.PatternName
+		if j, seen := best[key]; seen {
+			if p <= points[j] {
+				continue
+			}
+			points[j] = 0
+		}
+		best[key] = i
+		points[i] = p
+	}
+
+	total := 0
+	for _, p := range points {
+		total += p
+	}
+	return total, points
+}
+
 // MatchesToEvidence converts threat matches to evidence entries for storage.
 func MatchesToEvidence(matches []ThreatMatch) []sentinel.Evidence {
 	evidence := make([]sentinel.Evidence, 0, len(matches))
 	for _, m := range matches {
 		evidence = append(evidence, sentinel.Evidence{
-			Pattern:   m.PatternName,
-			Matched:   m.Matched,
-			Location:  m.Location,
-			Parameter: m.Parameter,
+			Pattern:    m.PatternName,
+			Matched:    m.Matched,
+			Location:   m.Location,
+			Parameter:  m.Parameter,
+			Transforms: m.Transforms,
 		})
 	}
 	return evidence
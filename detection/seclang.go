@@ -0,0 +1,379 @@
+package detection
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+)
+
+// SecLangIssue reports something in a SecLang file that was not imported as
+// written. Skipped issues dropped a whole rule or directive; the others
+// dropped one action or transform and kept the rule.
+type SecLangIssue struct {
+	Line    int    `json:"line"`
+	RuleID  string `json:"rule_id,omitempty"`
+	Message string `json:"message"`
+	Skipped bool   `json:"skipped"`
+}
+
+func (i SecLangIssue) String() string {
+	s := fmt.Sprintf("line %d", i.Line)
+	if i.RuleID != "" {
+		s += fmt.Sprintf(" (rule %s)", i.RuleID)
+	}
+	if i.Skipped {
+		return s + ": skipped: " + i.Message
+	}
+	return s + ": " + i.Message
+}
+
+// secLangVariables maps the supported SecRule variables to the locations a
+// WAFRule scans. REQUEST_URI is the path and the query string.
+var secLangVariables = map[string][]string{
+	"REQUEST_URI":     {"path", "query"},
+	"ARGS":            {"query", "body"},
+	"REQUEST_HEADERS": {"header"},
+	"REQUEST_BODY":    {"body"},
+}
+
+// secLangTransforms maps t: names to transforms where ModSecurity's name
+// differs from ours; the rest are used as they are.
+var secLangTransforms = map[string]sentinel.Transform{
+	"urlDecodeUni": sentinel.TransformURLDecode,
+}
+
+// secLangIgnoredActions are metadata actions with no effect on matching.
+// They are dropped without an issue; every other unknown action is
+// reported.
+var secLangIgnoredActions = map[string]bool{
+	"tag": true, "ver": true, "rev": true, "maturity": true, "accuracy": true,
+	"log": true, "nolog": true, "auditlog": true, "noauditlog": true, "logdata": true,
+}
+
+// ParseSecLangFile reads a ModSecurity rule file; see ParseSecLang.
+func ParseSecLangFile(path string) ([]sentinel.WAFRule, []SecLangIssue, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	return ParseSecLang(f)
+}
+
+// ParseSecLang translates ModSecurity rules into WAFRules. It supports the
+// subset of SecLang that maps onto a custom rule:
+//
+//   - SecRule directives; every other directive is reported and skipped
+//   - variables REQUEST_URI, ARGS, REQUEST_HEADERS and REQUEST_BODY, joined
+//     with "|", without selectors (ARGS:id) or counts (&ARGS)
+//   - operators @rx (also the default), @pm and @contains, not negated;
+//     @rx patterns must be valid RE2, so PCRE lookarounds and
+//     backreferences are reported
+//   - actions id, phase (1 or 2), severity, msg, block/deny/pass and t:
+//     transforms that have a Sentinel equivalent
+//
+// Rules that use anything else are reported and skipped rather than
+// imported with different meaning, except for unknown transforms and
+// actions, which are reported and dropped from an otherwise imported rule.
+// Chained rules are skipped. The error is non-nil only if r fails.
+func ParseSecLang(r io.Reader) ([]sentinel.WAFRule, []SecLangIssue, error) {
+	var rules []sentinel.WAFRule
+	var issues []SecLangIssue
+	skipChain := false
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo, startLine := 0, 0
+	var pending strings.Builder
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if pending.Len() == 0 {
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			startLine = lineNo
+		}
+		if strings.HasSuffix(line, "\\") {
+			pending.WriteString(strings.TrimSuffix(line, "\\"))
+			pending.WriteByte(' ')
+			continue
+		}
+		pending.WriteString(line)
+		directive := pending.String()
+		pending.Reset()
+
+		rule, ruleIssues, chained := parseSecLangDirective(directive, startLine, skipChain)
+		issues = append(issues, ruleIssues...)
+		if rule != nil {
+			rules = append(rules, *rule)
+		}
+		skipChain = chained
+	}
+	if err := sc.Err(); err != nil {
+		return rules, issues, err
+	}
+	if pending.Len() > 0 {
+		issues = append(issues, SecLangIssue{Line: startLine, Message: "file ends inside a continued line", Skipped: true})
+	}
+	return rules, issues, nil
+}
+
+// parseSecLangDirective translates one directive. chained reports whether
+// the directive's rule continues into the next one. inChain marks a rule
+// that is part of a skipped chain.
+func parseSecLangDirective(directive string, line int, inChain bool) (rule *sentinel.WAFRule, issues []SecLangIssue, chained bool) {
+	args, err := splitSecLangArgs(directive)
+	if err != nil {
+		return nil, []SecLangIssue{{Line: line, Message: err.Error(), Skipped: true}}, false
+	}
+	if !strings.EqualFold(args[0], "SecRule") {
+		return nil, []SecLangIssue{{Line: line, Message: "unsupported directive " + args[0], Skipped: true}}, false
+	}
+	if len(args) < 3 || len(args) > 4 {
+		return nil, []SecLangIssue{{Line: line, Message: "SecRule needs variables, an operator and optional actions", Skipped: true}}, false
+	}
+
+	var actions []secLangAction
+	if len(args) == 4 {
+		actions = splitSecLangActions(args[3])
+	}
+	rule = &sentinel.WAFRule{Severity: sentinel.SeverityMedium, Action: "log", Enabled: true}
+	for _, a := range actions {
+		if a.name == "id" && a.value != "" {
+			rule.ID = secLangRuleID(a.value)
+		}
+		if a.name == "chain" {
+			chained = true
+		}
+	}
+	skip := func(format string, args ...any) (*sentinel.WAFRule, []SecLangIssue, bool) {
+		issues = append(issues, SecLangIssue{Line: line, RuleID: rule.ID, Message: fmt.Sprintf(format, args...), Skipped: true})
+		return nil, issues, chained
+	}
+	switch {
+	case inChain:
+		return skip("part of a chained rule; chains are not supported")
+	case chained:
+		return skip("chained rules are not supported")
+	case rule.ID == "":
+		return skip("rule has no id action")
+	}
+
+	for _, v := range strings.Split(args[1], "|") {
+		locations, ok := secLangVariables[v]
+		if !ok {
+			return skip("unsupported variable %s", v)
+		}
+		rule.AppliesTo = append(rule.AppliesTo, locations...)
+	}
+	rule.AppliesTo = dedupe(rule.AppliesTo)
+
+	pattern, err := secLangOperator(args[2])
+	if err != nil {
+		return skip("%v", err)
+	}
+	rule.Pattern = pattern
+
+	for _, a := range actions {
+		switch a.name {
+		case "id", "chain":
+		case "phase":
+			if a.value != "1" && a.value != "2" && a.value != "request" {
+				return skip("phase %s is not a request phase", a.value)
+			}
+		case "msg":
+			rule.Name = a.value
+		case "severity":
+			sev, ok := secLangSeverity(a.value)
+			if !ok {
+				issues = append(issues, SecLangIssue{Line: line, RuleID: rule.ID, Message: "unknown severity " + a.value})
+				continue
+			}
+			rule.Severity = sev
+		case "block", "deny", "drop":
+			rule.Action = "block"
+		case "pass":
+			rule.Action = "log"
+		case "t":
+			if a.value == "none" {
+				rule.Transforms = nil
+				continue
+			}
+			t, ok := secLangTransforms[a.value]
+			if !ok {
+				t = sentinel.Transform(a.value)
+			}
+			if !t.Valid() {
+				issues = append(issues, SecLangIssue{Line: line, RuleID: rule.ID, Message: "unsupported transform t:" + a.value + " dropped"})
+				continue
+			}
+			rule.Transforms = append(rule.Transforms, t)
+		default:
+			if !secLangIgnoredActions[a.name] {
+				issues = append(issues, SecLangIssue{Line: line, RuleID: rule.ID, Message: "unsupported action " + a.name + " dropped"})
+			}
+		}
+	}
+	if rule.Name == "" {
+		rule.Name = "SecRule " + rule.ID
+	}
+	return rule, issues, chained
+}
+
+// secLangOperator turns a SecRule operator into a Go regex.
+func secLangOperator(op string) (string, error) {
+	if strings.HasPrefix(op, "!") {
+		return "", fmt.Errorf("negated operator %s is not supported", op)
+	}
+	name, arg := "@rx", op
+	if strings.HasPrefix(op, "@") {
+		name, arg, _ = strings.Cut(op, " ")
+	}
+	switch name {
+	case "@rx":
+		if _, err := regexp.Compile(arg); err != nil {
+			return "", fmt.Errorf("@rx pattern is not valid RE2: %v", err)
+		}
+		return arg, nil
+	case "@pm":
+		var phrases []string
+		for _, p := range strings.Fields(arg) {
+			phrases = append(phrases, regexp.QuoteMeta(p))
+		}
+		if len(phrases) == 0 {
+			return "", fmt.Errorf("@pm has no phrases")
+		}
+		return "(?i)(?:" + strings.Join(phrases, "|") + ")", nil
+	case "@contains":
+		if arg == "" {
+			return "", fmt.Errorf("@contains has no argument")
+		}
+		return regexp.QuoteMeta(arg), nil
+	}
+	return "", fmt.Errorf("unsupported operator %s", name)
+}
+
+// secLangSeverity maps a ModSecurity severity name or syslog level.
+func secLangSeverity(s string) (sentinel.Severity, bool) {
+	switch strings.ToUpper(s) {
+	case "0", "EMERGENCY", "1", "ALERT", "2", "CRITICAL":
+		return sentinel.SeverityCritical, true
+	case "3", "ERROR":
+		return sentinel.SeverityHigh, true
+	case "4", "WARNING":
+		return sentinel.SeverityMedium, true
+	case "5", "NOTICE", "6", "INFO", "7", "DEBUG":
+		return sentinel.SeverityLow, true
+	}
+	return "", false
+}
+
+// splitSecLangArgs splits a directive into whitespace-separated arguments,
+// honoring double quotes and backslash-escaped quotes inside them.
+func splitSecLangArgs(s string) ([]string, error) {
+	var args []string
+	for i := 0; i < len(s); {
+		if s[i] == ' ' || s[i] == '\t' {
+			i++
+			continue
+		}
+		if s[i] != '"' {
+			j := strings.IndexAny(s[i:], " \t")
+			if j < 0 {
+				j = len(s) - i
+			}
+			args = append(args, s[i:i+j])
+			i += j
+			continue
+		}
+		var b strings.Builder
+		i++
+		closed := false
+		for i < len(s) {
+			if s[i] == '\\' && i+1 < len(s) && s[i+1] == '"' {
+				b.WriteByte('"')
+				i += 2
+				continue
+			}
+			if s[i] == '"' {
+				closed = true
+				i++
+				break
+			}
+			b.WriteByte(s[i])
+			i++
+		}
+		if !closed {
+			return nil, fmt.Errorf("unterminated quoted argument")
+		}
+		args = append(args, b.String())
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty directive")
+	}
+	return args, nil
+}
+
+type secLangAction struct {
+	name, value string
+}
+
+// splitSecLangActions splits "id:1,msg:'a, b',t:lowercase" on the commas
+// outside single quotes.
+func splitSecLangActions(s string) []secLangAction {
+	var actions []secLangAction
+	add := func(part string) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return
+		}
+		name, value, _ := strings.Cut(part, ":")
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = strings.ReplaceAll(value[1:len(value)-1], `\'`, "'")
+		}
+		actions = append(actions, secLangAction{name: strings.TrimSpace(name), value: value})
+	}
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s):
+			i++
+		case s[i] == '\'':
+			quoted = !quoted
+		case s[i] == ',' && !quoted:
+			add(s[start:i])
+			start = i + 1
+		}
+	}
+	add(s[start:])
+	return actions
+}
+
+func dedupe(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := values[:0]
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// secLangRuleID prefixes numeric ModSecurity IDs, so imported rules read
+// as such in events and cannot be mistaken for configured ones.
+func secLangRuleID(id string) string {
+	if _, err := strconv.Atoi(id); err == nil {
+		return "secrule-" + id
+	}
+	return id
+}
@@ -0,0 +1,100 @@
+package detection_test
+
+import (
+	"strings"
+	"testing"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/detection"
+)
+
+const secLangFixture = `
+# Application rules
+SecRuleEngine On
+
+SecRule REQUEST_URI|ARGS "@rx (?i)union\s+select" \
+    "id:1001,phase:2,block,severity:CRITICAL,msg:'SQL injection, union',t:none,t:urlDecodeUni,t:lowercase"
+
+SecRule REQUEST_HEADERS "@pm sqlmap nikto" "id:1002,phase:1,log,tag:'scanner',severity:WARNING"
+SecRule REQUEST_BODY "@contains <!ENTITY" "id:1003,deny,t:removeNulls,ctl:auditEngine=On"
+
+SecRule ARGS:id "@rx ^\d+$" "id:1004,block"
+SecRule REQUEST_URI "@rx (?<=admin)panel" "id:1005,block"
+SecRule REQUEST_URI "!@rx ^/api" "id:1006,block"
+SecRule RESPONSE_BODY "@rx secret" "id:1007,phase:4,block"
+SecRule REQUEST_URI "@rx /x" "id:1008,phase:3,block"
+SecRule REQUEST_URI "@rx /a" "id:1009,chain,block"
+    SecRule ARGS "@rx b" "t:none"
+SecRule REQUEST_URI "@rx /noid" "block"
+SecRule REQUEST_URI "@beginsWith /x" "id:1010"
+`
+
+func TestParseSecLang(t *testing.T) {
+	rules, issues, err := detection.ParseSecLang(strings.NewReader(secLangFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 3 {
+		t.Fatalf("expected 3 rules, got %d: %+v", len(rules), rules)
+	}
+
+	union := rules[0]
+	if union.ID != "secrule-1001" || union.Name != "SQL injection, union" || union.Severity != sentinel.SeverityCritical ||
+		union.Action != "block" || !union.Enabled {
+		t.Errorf("unexpected rule: %+v", union)
+	}
+	if got := union.AppliesTo; len(got) != 3 || got[0] != "path" || got[1] != "query" || got[2] != "body" {
+		t.Errorf("AppliesTo: got %v", got)
+	}
+	if got := union.Transforms; len(got) != 2 || got[0] != sentinel.TransformURLDecode || got[1] != sentinel.TransformLowercase {
+		t.Errorf("Transforms: got %v", got)
+	}
+	if rules[1].Severity != sentinel.SeverityMedium || rules[1].Action != "log" || rules[1].Pattern != "(?i)(?:sqlmap|nikto)" {
+		t.Errorf("unexpected @pm rule: %+v", rules[1])
+	}
+	if rules[2].Pattern != "<!ENTITY" || rules[2].Action != "block" {
+		t.Errorf("unexpected @contains rule: %+v", rules[2])
+	}
+
+	// Every dropped directive, rule and action is accounted for.
+	skipped := map[string]bool{}
+	var dropped []string
+	for _, i := range issues {
+		if i.Skipped {
+			skipped[i.RuleID] = true
+		} else {
+			dropped = append(dropped, i.RuleID+": "+i.Message)
+		}
+	}
+	for _, id := range []string{"", "secrule-1004", "secrule-1005", "secrule-1006", "secrule-1007", "secrule-1008", "secrule-1009", "secrule-1010"} {
+		if !skipped[id] {
+			t.Errorf("expected %q to be reported as skipped, got %v", id, issues)
+		}
+	}
+	if len(dropped) != 1 || !strings.Contains(dropped[0], "ctl") {
+		t.Errorf("expected only the ctl action to be reported as dropped, got %v", dropped)
+	}
+}
+
+func TestImportedRulesSurviveReplace(t *testing.T) {
+	rules, _, err := detection.ParseSecLang(strings.NewReader(secLangFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := detection.NewCustomRuleEngine(nil)
+	if err := engine.ImportRules(rules); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.ReplaceRules(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	req := sentinel.InspectedRequest{Path: "/search", RawQuery: "q=1%20UNION%20%20SELECT%20password"}
+	matches := engine.ClassifyRequest(req)
+	if len(matches) != 1 || matches[0].PatternName != "SQL injection, union" || matches[0].Location != "query" {
+		t.Errorf("expected the imported rule to match the query, got %+v", matches)
+	}
+	if len(engine.ListRules()) != 0 || len(engine.ImportedRules()) != 3 {
+		t.Errorf("imported rules should be listed apart: %d configured, %d imported", len(engine.ListRules()), len(engine.ImportedRules()))
+	}
+}
@@ -0,0 +1,213 @@
+package detection
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxURLDecodeRounds bounds TransformURLDecode. Three layers of encoding
+// is already far past anything a legitimate client sends.
+const maxURLDecodeRounds = 4
+
+// ApplyTransforms runs transforms over s in order and returns the result.
+// Unknown transforms are skipped; ValidateTransforms reports them.
+func ApplyTransforms(s string, transforms []sentinel.Transform) string {
+	for _, t := range transforms {
+		switch t {
+		case sentinel.TransformURLDecode:
+			s = urlDecode(s)
+		case sentinel.TransformHTMLEntityDecode:
+			if strings.IndexByte(s, '&') >= 0 {
+				s = html.UnescapeString(s)
+			}
+		case sentinel.TransformNormalizeUnicode:
+			s = normalizeUnicode(s)
+		case sentinel.TransformRemoveNulls:
+			if strings.IndexByte(s, 0) >= 0 {
+				s = strings.ReplaceAll(s, "\x00", "")
+			}
+		case sentinel.TransformRemoveComments:
+			s = removeComments(s)
+		case sentinel.TransformCompressWhitespace:
+			s = compressWhitespace(s)
+		case sentinel.TransformLowercase:
+			s = strings.ToLower(s)
+		}
+	}
+	return s
+}
+
+// ValidateTransforms returns an error naming the first unknown transform.
+func ValidateTransforms(transforms []sentinel.Transform) error {
+	for _, t := range transforms {
+		if !t.Valid() {
+			return fmt.Errorf("unknown transform %q", t)
+		}
+	}
+	return nil
+}
+
+// transformCache memoizes one input's transformed forms for the patterns
+// scanning it, so patterns sharing a transform list share the work.
+type transformCache map[string]string
+
+func (c transformCache) get(input string, transforms []sentinel.Transform) string {
+	if len(transforms) == 0 {
+		return input
+	}
+	var key strings.Builder
+	for _, t := range transforms {
+		key.WriteString(string(t))
+		key.WriteByte(',')
+	}
+	if v, ok := c[key.String()]; ok {
+		return v
+	}
+	v := ApplyTransforms(input, transforms)
+	c[key.String()] = v
+	return v
+}
+
+func urlDecode(s string) string {
+	for i := 0; i < maxURLDecodeRounds; i++ {
+		if strings.IndexByte(s, '%') < 0 && strings.IndexByte(s, '+') < 0 {
+			return s
+		}
+		decoded := urlDecodeOnce(s)
+		if decoded == s {
+			return s
+		}
+		s = decoded
+	}
+	return s
+}
+
+// urlDecodeOnce decodes one layer of %XX, %uXXXX and "+" escapes, leaving
+// malformed escapes untouched instead of failing like url.QueryUnescape.
+func urlDecodeOnce(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '+':
+			b.WriteByte(' ')
+		case c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			b.WriteByte(unhex(s[i+1])<<4 | unhex(s[i+2]))
+			i += 2
+		case c == '%' && i+5 < len(s) && (s[i+1] == 'u' || s[i+1] == 'U') &&
+			isHex(s[i+2]) && isHex(s[i+3]) && isHex(s[i+4]) && isHex(s[i+5]):
+			r := rune(unhex(s[i+2]))<<12 | rune(unhex(s[i+3]))<<8 | rune(unhex(s[i+4]))<<4 | rune(unhex(s[i+5]))
+			b.WriteRune(r)
+			i += 5
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
+// normalizeUnicode decodes overlong two-byte encodings of ASCII and then
+// applies NFKC. Pure ASCII input is returned as is.
+func normalizeUnicode(s string) string {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		// 0xC0 and 0xC1 lead bytes only ever start overlong forms of
+		// U+0000..U+007F, which strict decoders reject but lenient
+		// ones (and some path handlers) accept.
+		if (s[i] == 0xC0 || s[i] == 0xC1) && i+1 < len(s) && s[i+1]&0xC0 == 0x80 {
+			b.WriteByte((s[i]&0x1F)<<6 | s[i+1]&0x3F)
+			i++
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return norm.NFKC.String(b.String())
+}
+
+func removeComments(s string) string {
+	if !strings.Contains(s, "/*") && !strings.Contains(s, "<!--") {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		var start, end string
+		switch {
+		case strings.HasPrefix(s[i:], "/*"):
+			start, end = "/*", "*/"
+		case strings.HasPrefix(s[i:], "<!--"):
+			start, end = "<!--", "-->"
+		default:
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+		// An unterminated comment runs to the end, as in MySQL.
+		j := strings.Index(s[i+len(start):], end)
+		if j < 0 {
+			j = len(s) - i - len(start)
+		}
+		body := s[i+len(start) : i+len(start)+j]
+		// MySQL runs the body of /*!...*/ (after an optional version
+		// number), so it is code, not a comment.
+		if start == "/*" && strings.HasPrefix(body, "!") {
+			b.WriteString(strings.TrimLeft(body[1:], "0123456789"))
+		}
+		i += len(start) + j + len(end)
+	}
+	return b.String()
+}
+
+func compressWhitespace(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	space := false
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if unicode.IsSpace(r) {
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+		} else {
+			// Copy the original bytes: invalid UTF-8 must survive for
+			// patterns that look for it.
+			b.WriteString(s[i : i+size])
+			space = false
+		}
+		i += size
+	}
+	return b.String()
+}
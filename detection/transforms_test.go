@@ -0,0 +1,86 @@
+package detection
+
+import (
+	"testing"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+)
+
+func TestApplyTransforms(t *testing.T) {
+	cases := []struct {
+		name       string
+		in         string
+		transforms []sentinel.Transform
+		want       string
+	}{
+		{"double url encoding", "%2527%2520OR", []sentinel.Transform{sentinel.TransformURLDecode}, "' OR"},
+		{"iis unicode escape and plus", "%u002e%u002e/a+b", []sentinel.Transform{sentinel.TransformURLDecode}, "../a b"},
+		{"malformed escape kept", "100%zz%4", []sentinel.Transform{sentinel.TransformURLDecode}, "100%zz%4"},
+		{"html entities", "&lt;script&#x3e;&#39;", []sentinel.Transform{sentinel.TransformHTMLEntityDecode}, "<script>'"},
+		{"fullwidth", "＜ｓｃｒｉｐｔ＞", []sentinel.Transform{sentinel.TransformNormalizeUnicode}, "<script>"},
+		{"overlong utf-8", "\xc0\xae\xc0\xae\xc0\xaf", []sentinel.Transform{sentinel.TransformNormalizeUnicode}, "../"},
+		{"nulls", "un\x00ion", []sentinel.Transform{sentinel.TransformRemoveNulls}, "union"},
+		{"comments", "UN/**/ION SEL/*x*/ECT <!-- c -->1", []sentinel.Transform{sentinel.TransformRemoveComments}, "UNION SELECT 1"},
+		{"mysql executable comment", "/*!50000UNION*/ SELECT", []sentinel.Transform{sentinel.TransformRemoveComments}, "UNION SELECT"},
+		{"unterminated comment", "1 /* rest", []sentinel.Transform{sentinel.TransformRemoveComments}, "1 "},
+		{"whitespace", "a \t\n b  c", []sentinel.Transform{sentinel.TransformCompressWhitespace}, "a b c"},
+		{"lowercase", "UnIoN", []sentinel.Transform{sentinel.TransformLowercase}, "union"},
+		{"chained in order", "UN%252F%252A%252A%252FION", []sentinel.Transform{sentinel.TransformURLDecode, sentinel.TransformRemoveComments, sentinel.TransformLowercase}, "union"},
+	}
+	for _, tc := range cases {
+		if got := ApplyTransforms(tc.in, tc.transforms); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+	if err := ValidateTransforms([]sentinel.Transform{"base64Decode"}); err == nil {
+		t.Error("expected an error for an unknown transform")
+	}
+}
+
+func TestClassifyRequestDefeatsEvasions(t *testing.T) {
+	cases := []struct {
+		name, query string
+		want        sentinel.ThreatType
+	}{
+		{"comment-split keywords", "id=1%20UN/**/ION%20SEL/**/ECT%20password", sentinel.ThreatSQLi},
+		{"double-encoded traversal", "file=%252e%252e%255c%252e%252e%255cwin.ini", sentinel.ThreatPathTraversal},
+		{"overlong utf-8 traversal", "file=%c0%ae%c0%ae/%c0%ae%c0%ae/etc/hosts", sentinel.ThreatPathTraversal},
+		{"html-entity script", "q=%26lt;img%20src=x%20onerror=alert(1)%26gt;", sentinel.ThreatXSS},
+		{"fullwidth script", "q=%EF%BC%9Cscript%EF%BC%9E", sentinel.ThreatXSS},
+	}
+	for _, tc := range cases {
+		var found *ThreatMatch
+		for _, m := range ClassifyRequest(sentinel.InspectedRequest{Path: "/search", RawQuery: tc.query}) {
+			if m.ThreatType == tc.want && len(m.Transforms) > 0 {
+				found = &m
+				break
+			}
+		}
+		if found == nil {
+			t.Errorf("%s: expected a %s match after transforms", tc.name, tc.want)
+		}
+	}
+}
+
+func TestCustomRuleTransforms(t *testing.T) {
+	engine := NewCustomRuleEngine(nil)
+	rule := sentinel.WAFRule{
+		ID: "r1", Name: "admin probe", Pattern: `/wp-admin`, Enabled: true, Severity: sentinel.SeverityMedium,
+		Transforms: []sentinel.Transform{sentinel.TransformURLDecode, sentinel.TransformLowercase},
+	}
+	if err := engine.AddRule(rule); err != nil {
+		t.Fatal(err)
+	}
+	matches := engine.ClassifyRequest(sentinel.InspectedRequest{Path: "/WP-%41dmin/"})
+	if len(matches) != 1 || matches[0].Matched != "/wp-admin" || len(matches[0].Transforms) != 2 {
+		t.Fatalf("expected one transformed match, got %+v", matches)
+	}
+	if ev := MatchesToEvidence(matches); len(ev[0].Transforms) != 2 {
+		t.Errorf("transforms not recorded in evidence: %+v", ev)
+	}
+
+	rule.Transforms = []sentinel.Transform{"rot13"}
+	if err := engine.AddRule(rule); err == nil {
+		t.Error("expected AddRule to reject an unknown transform")
+	}
+}
@@ -34,7 +34,7 @@ export default function AnomalyDetection() {
           },
           {
             q: 'How does baseline learning work in Sentinel anomaly detection?',
-            a: 'Sentinel builds per-user behavioral baselines from historical activity within a configurable learning period (default 7 days). Baselines track active hours, typical routes, request velocity, source IPs, countries, and average response sizes. A minimum of 10 activity records is required.',
+            a: 'Sentinel keeps a per-user behavioral baseline in storage and updates it with every activity. Baselines track active hours, typical routes, request velocity, source IPs, countries, and average response durations. Older activity fades out over the learning period (default 7 days). A baseline is only used once it spans the learning period and holds at least 10 activity records.',
           },
           {
             q: 'Does Sentinel anomaly detection generate alerts?',
@@ -146,7 +146,7 @@ func main() {
             <td><code>LearningPeriod</code></td>
             <td><code>time.Duration</code></td>
             <td><code>7 * 24 * time.Hour</code></td>
-            <td>How far back to look when computing a user's behavioral baseline. Longer periods produce more stable baselines but are slower to adapt.</td>
+            <td>How long a new baseline learns before it is used, and how quickly old behavior fades out of it. Longer periods produce more stable baselines but are slower to adapt.</td>
           </tr>
           <tr>
             <td><code>Checks</code></td>
@@ -154,6 +154,12 @@ func main() {
             <td>All checks enabled</td>
             <td>Which anomaly checks to run. If empty, all check types are enabled. Specify a subset to narrow detection scope.</td>
           </tr>
+          <tr>
+            <td><code>CredentialStuffing</code></td>
+            <td><code>CredentialStuffingConfig</code></td>
+            <td>See <a href="#credential-stuffing">Credential Stuffing</a></td>
+            <td>Window and thresholds of the cross-IP credential stuffing check.</td>
+          </tr>
         </tbody>
       </table>
 
@@ -278,7 +284,7 @@ func main() {
             <td>Credential Stuffing</td>
             <td><code>CheckCredentialStuffing</code></td>
             <td>--</td>
-            <td>Reserved check type for detecting credential stuffing patterns. Configure via Auth Shield for full brute-force protection.</td>
+            <td>Login attempts from many IPs that add up to an attack: many usernames from one network, a high overall failure rate, or one password against many accounts. Runs on Auth Shield's login attempts rather than on user activity, and reports on its own. See <a href="#credential-stuffing">below</a>.</td>
           </tr>
         </tbody>
       </table>
@@ -295,9 +301,10 @@ Checks: []sentinel.AnomalyCheckType{
       />
 
       <Callout type="warning" title="Minimum Baseline Requirement">
-        The anomaly detector requires at least <strong>10 historical activity records</strong> for a
-        user before it will evaluate checks. Users with fewer records are silently skipped. This
-        prevents false positives on new users or accounts with very little history.
+        The anomaly detector evaluates checks only once a user's baseline spans the{' '}
+        <code>LearningPeriod</code> and holds at least <strong>10 activity records</strong>. Until
+        then, activity is learned but never reported. This prevents false positives on new users
+        or accounts with very little history.
       </Callout>
 
       {/* ------------------------------------------------------------------ */}
@@ -312,7 +319,8 @@ Checks: []sentinel.AnomalyCheckType{
 
       <ol>
         <li>
-          <strong>Activity Tracking</strong> — When a request arrives, Sentinel records a{' '}
+          <strong>Activity Tracking</strong> — When a request of a user your{' '}
+          <code>UserExtractor</code> recognizes arrives, Sentinel records a{' '}
           <code>UserActivity</code> event containing the user ID, timestamp, IP address, HTTP
           method, path, response duration, and geographic country (if geo is enabled).
         </li>
@@ -321,14 +329,18 @@ Checks: []sentinel.AnomalyCheckType{
           a non-blocking ring buffer. This decouples detection from request handling.
         </li>
         <li>
-          <strong>Baseline Computation</strong> — The detector loads the user's historical activity
-          from storage (within the configured <code>LearningPeriod</code>) and computes a{' '}
-          <code>UserBaseline</code>. Baselines are cached for 1 hour to avoid repeated computation.
+          <strong>Baseline Lookup</strong> — The detector loads the user's{' '}
+          <code>UserBaseline</code> from storage. A user without one is seeded once from their
+          stored activity within the <code>LearningPeriod</code>.
         </li>
         <li>
           <strong>Check Evaluation</strong> — Each enabled check compares the current activity
           against the baseline and returns a score. Scores are summed and capped at 100.
         </li>
+        <li>
+          <strong>Baseline Update</strong> — The activity is folded into the baseline, which is
+          saved back to storage. The activity being checked never counts toward its own baseline.
+        </li>
         <li>
           <strong>Threshold Comparison</strong> — If the total score meets or exceeds the
           sensitivity threshold, a <code>ThreatEvent</code> is emitted with type{' '}
@@ -349,10 +361,10 @@ Checks: []sentinel.AnomalyCheckType{
 # HTTP Request ──> Record UserActivity ──> Async Pipeline
 #       │                                       │
 #       v                                       v
-#   Response sent                    Load/Compute Baseline
+#   Response sent                        Load Baseline
 #   (no added latency)                          │
 #                                               v
-#                                    Run Checks (score each)
+#                              Run Checks, then update Baseline
 #                                               │
 #                                    ┌──────────┴──────────┐
 #                                    v                      v
@@ -367,8 +379,7 @@ Checks: []sentinel.AnomalyCheckType{
 
       <Callout type="info" title="Non-Blocking Detection">
         The anomaly detector runs entirely outside the HTTP request path. Activity recording uses a
-        non-blocking ring buffer, so detection never slows down your API responses, even when
-        computing baselines from large activity histories.
+        non-blocking ring buffer, so detection never slows down your API responses.
       </Callout>
 
       {/* ------------------------------------------------------------------ */}
@@ -377,9 +388,16 @@ Checks: []sentinel.AnomalyCheckType{
 
       <h2 id="baselines">Baselines</h2>
       <p>
-        A <code>UserBaseline</code> captures the behavioral profile for a single user. It is
-        computed from all activity within the <code>LearningPeriod</code> and cached in memory for
-        1 hour before being recomputed.
+        A <code>UserBaseline</code> captures the behavioral profile for a single user. It is stored
+        with the rest of Sentinel's data, in every storage backend, so baselines survive restarts
+        and are shared by replicas that use the same database. Each activity updates the baseline
+        in place; the user's history is never rescanned.
+      </p>
+      <p>
+        Each save checks the baseline's <code>Version</code>. When two replicas update the same
+        user at once, the later save fails with <code>storage.ErrBaselineConflict</code> and the
+        detector applies its activity again to the newer baseline, so no update is lost. A
+        custom <code>BaselineStore</code> must implement the same check.
       </p>
 
       <table>
@@ -392,42 +410,44 @@ Checks: []sentinel.AnomalyCheckType{
         </thead>
         <tbody>
           <tr>
-            <td><code>ActiveHours</code></td>
-            <td>Distribution of activity across hours of the day (0-23)</td>
+            <td><code>HourWeights</code></td>
+            <td>Decaying activity count per hour of the day (0-23)</td>
             <td><code>CheckOffHoursAccess</code></td>
           </tr>
           <tr>
-            <td><code>TypicalRoutes</code></td>
-            <td>Set of method+path combinations the user has accessed</td>
+            <td><code>Routes</code></td>
+            <td>Decaying count per method+path; routes whose count fades away are forgotten</td>
             <td><code>CheckUnusualAccess</code></td>
           </tr>
           <tr>
-            <td><code>AvgRequestsPerHour</code></td>
-            <td>Average request rate across the learning period</td>
+            <td><code>RequestsPerHour</code></td>
+            <td>Moving average of the request count in hours when the user was active</td>
             <td><code>CheckVelocityAnomaly</code></td>
           </tr>
           <tr>
             <td><code>SourceIPs</code></td>
-            <td>Set of IP addresses the user has connected from</td>
+            <td>IP addresses seen within the learning period</td>
             <td><code>CheckImpossibleTravel</code></td>
           </tr>
           <tr>
             <td><code>Countries</code></td>
-            <td>Set of countries (by geo lookup) the user has connected from</td>
+            <td>Countries (by geo lookup) seen within the learning period</td>
             <td><code>CheckImpossibleTravel</code></td>
           </tr>
           <tr>
-            <td><code>AvgResponseSize</code></td>
-            <td>Average response duration/size across the learning period</td>
+            <td><code>AvgDuration</code></td>
+            <td>Moving average of response duration</td>
             <td><code>CheckDataExfiltration</code></td>
           </tr>
         </tbody>
       </table>
 
       <p>
-        The <code>LearningPeriod</code> determines the time window for baseline computation. The
-        default is 7 days. A longer period (e.g., 14 or 30 days) produces more stable baselines but
-        adapts more slowly to legitimate changes in user behavior.
+        The <code>LearningPeriod</code> plays two parts. A new baseline learns for that long
+        before any check runs against it. After that, it is the time constant of the decay: an
+        activity counts about a third as much one learning period later. The default is 7 days. A
+        longer period (e.g., 14 or 30 days) produces more stable baselines but adapts more slowly to
+        legitimate changes in user behavior.
       </p>
 
       <CodeBlock
@@ -443,6 +463,188 @@ LearningPeriod: 7 * 24 * time.Hour,  // 7 days
 LearningPeriod: 30 * 24 * time.Hour, // 30 days`}
       />
 
+      <h3 id="inspecting-baselines">Inspecting and Resetting Baselines</h3>
+      <p>
+        <code>GET /api/users/:user_id/baseline</code> returns a user's baseline, with{' '}
+        <code>meta.learning</code> and <code>meta.learning_until</code> showing whether it is still
+        learning. Analysts can reset it with <code>DELETE /api/users/:user_id/baseline</code>, for
+        example after a user relocates. The user then learns from scratch for a full learning
+        period, without replaying their history.
+      </p>
+      <CodeBlock
+        language="bash"
+        showLineNumbers={false}
+        code={`curl -X DELETE http://localhost:8080/sentinel/api/users/user-42/baseline \\
+  -H "Authorization: Bearer <token>"`}
+      />
+
+      {/* ------------------------------------------------------------------ */}
+      {/*  CREDENTIAL STUFFING                                                */}
+      {/* ------------------------------------------------------------------ */}
+
+      <h2 id="credential-stuffing">Credential Stuffing</h2>
+      <p>
+        Stuffing tools rotate through proxies so that no single IP fails often enough for Auth
+        Shield's lockout. With <code>CheckCredentialStuffing</code> enabled, the detector receives
+        every login attempt <a href="/docs/auth-shield">Auth Shield</a> observes and counts the
+        attempts of the last <code>Window</code> across all IPs. It reports three patterns:
+      </p>
+      <table>
+        <thead>
+          <tr>
+            <th>Evidence Pattern</th>
+            <th>Reported When</th>
+            <th>Default</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr>
+            <td><code>network_usernames</code></td>
+            <td>Distinct usernames failing from one ASN (or one /24, /48 for IPv6, when geolocation has no ASN) reach <code>NetworkUsernames</code></td>
+            <td>20</td>
+          </tr>
+          <tr>
+            <td><code>distributed_failures</code></td>
+            <td>At least <code>MinAttempts</code> logins from at least 10 failing IPs, with a failure share of <code>FailureRate</code> or more</td>
+            <td>50 attempts, 0.8</td>
+          </tr>
+          <tr>
+            <td><code>password_reuse</code></td>
+            <td>One password fails for <code>PasswordReuse</code> distinct usernames, from any IPs</td>
+            <td>5</td>
+          </tr>
+        </tbody>
+      </table>
+      <p>
+        Passwords are compared by a keyed fingerprint that Auth Shield computes from the{' '}
+        <code>PasswordField</code> of failed logins. The key is random per process, so fingerprints
+        are neither stored with the password nor comparable across restarts.
+      </p>
+      <CodeBlock
+        language="go"
+        showLineNumbers={false}
+        code={`AuthShield: sentinel.AuthShieldConfig{
+    Enabled:    true,
+    LoginRoute: "/api/login",
+},
+Anomaly: sentinel.AnomalyConfig{
+    Enabled: true,
+    Checks:  []sentinel.AnomalyCheckType{sentinel.CheckCredentialStuffing},
+    CredentialStuffing: sentinel.CredentialStuffingConfig{
+        Window:           10 * time.Minute,
+        NetworkUsernames: 20,
+        FailureRate:      0.8,
+        MinAttempts:      50,
+        PasswordReuse:    5,
+    },
+},`}
+      />
+      <p>
+        A finding emits one <code>CredentialStuffing</code> threat event per IP involved, and one more
+        for each IP that joins later, up to 50 per finding. The events of a finding share an{' '}
+        <code>actor_id</code> starting with <code>stuffing_</code>, so the campaign can be listed as a
+        whole with <code>GET /api/threats?actor_id=stuffing_…</code>. The evidence names the pattern
+        and, in <code>parameter</code>, the group: <code>asn:AS64500</code>,{' '}
+        <code>net:203.0.113.0/24</code>, <code>password:&lt;fingerprint&gt;</code> or{' '}
+        <code>all</code>. The events are not blocked; combine them with alerting or block the IPs
+        from the dashboard.
+      </p>
+      <Callout type="warning" title="Requires Auth Shield">
+        The check sees only the login route Auth Shield protects. <code>ValidateConfig</code> warns
+        when <code>CheckCredentialStuffing</code> is enabled without Auth Shield.
+      </Callout>
+
+      {/* ------------------------------------------------------------------ */}
+      {/*  SESSION RISK                                                       */}
+      {/* ------------------------------------------------------------------ */}
+
+      <h2 id="session-risk">Session Risk</h2>
+      <p>
+        The checks above judge single requests against a user's baseline. <code>SessionRisk</code>{' '}
+        scores whole authenticated sessions for account takeover: a stolen token used from another
+        device or network, often followed quickly by a password or email change. It runs in the
+        request path. Each session gets a score from 0 to 100, summed from these signals:
+      </p>
+      <table>
+        <thead>
+          <tr>
+            <th>Signal</th>
+            <th>Points</th>
+            <th>Raised When</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr>
+            <td><code>new_device</code></td>
+            <td>25</td>
+            <td>The session's User-Agent, ignoring version numbers, was not seen for the user in the last 30 days.</td>
+          </tr>
+          <tr>
+            <td><code>new_asn</code></td>
+            <td>25</td>
+            <td>The session's network (ASN) was not seen for the user. Needs geolocation with an ASN database.</td>
+          </tr>
+          <tr>
+            <td><code>impossible_travel</code></td>
+            <td>50</td>
+            <td>The session starts over 500 km from the user's previous session, faster than <code>MaxTravelSpeed</code>. Needs geolocation.</td>
+          </tr>
+          <tr>
+            <td><code>sensitive_change</code></td>
+            <td>30</td>
+            <td>A non-GET request to one of <code>SensitiveRoutes</code> within <code>SensitiveWindow</code> of the session starting.</td>
+          </tr>
+        </tbody>
+      </table>
+      <p>
+        A user's devices and networks are learned from their stored activity and from each session
+        that stays below the threshold. Networks and locations are only seeded from stored activity
+        with a local GeoIP database; with <code>GeoIPAPI</code> they are learned from new sessions,
+        so a restart never sends a user's past IPs to ip-api.com. When a session reaches <code>Threshold</code>, Sentinel emits
+        an <code>AccountTakeover</code> threat event with one evidence entry per signal and applies{' '}
+        <code>Action</code>:
+      </p>
+      <ul>
+        <li><code>sentinel.SessionActionLog</code> — only the event (default).</li>
+        <li>
+          <code>sentinel.SessionActionChallenge</code> — later requests get{' '}
+          <code>403 SESSION_STEP_UP_REQUIRED</code> until they carry a CAPTCHA token in{' '}
+          <code>X-Captcha-Token</code>. Solving it clears the step-up and trusts the device.
+          Needs a CAPTCHA provider; without one, the action falls back to log.
+        </li>
+        <li>
+          <code>sentinel.SessionActionRevoke</code> — later requests get{' '}
+          <code>401 SESSION_REVOKED</code>, and <code>OnRevoke</code> is called once so your app can
+          delete the session on its side.
+        </li>
+      </ul>
+      <CodeBlock
+        language="go"
+        showLineNumbers={false}
+        code={`UserExtractor: func(c *gin.Context) *sentinel.UserContext {
+    // ... return the authenticated user, or nil
+},
+SessionRisk: sentinel.SessionRiskConfig{
+    Enabled:         true,
+    Threshold:       50,
+    Action:          sentinel.SessionActionRevoke,
+    SensitiveRoutes: []string{"/api/account/password", "/api/account/email"},
+    OnRevoke: func(c *gin.Context, risk *sentinel.SessionRisk) {
+        sessions.Delete(risk.UserID) // your session store
+    },
+},`}
+      />
+      <p>
+        Sessions are identified by the <code>Authorization</code> header or the{' '}
+        <code>SessionCookie</code> cookie, or by <code>SessionIDExtractor</code>. Only a hash of
+        the value is kept. <code>GET /api/sessions</code> lists the scored sessions, riskiest first.
+      </p>
+      <Callout type="info" title="Requires a UserExtractor">
+        Session risk and user activity both depend on <code>UserExtractor</code>. If your auth
+        middleware runs after Sentinel's, the user is found only after the handler, so a step-up or
+        revocation applies from the session's next request.
+      </Callout>
+
       {/* ------------------------------------------------------------------ */}
       {/*  EVENTS                                                             */}
       {/* ------------------------------------------------------------------ */}
@@ -583,7 +785,8 @@ func main() {
 
         // Enable geo for country-based anomaly checks
         Geo: sentinel.GeoConfig{
-            Enabled: true,
+            Enabled:      true,
+            DatabasePath: "/var/lib/GeoIP/GeoLite2-City.mmdb",
         },
 
         // Alert on high-severity anomalies
@@ -616,9 +819,10 @@ func main() {
 
       <h3>Building a Baseline</h3>
       <p>
-        The detector requires at least 10 activity records for a user before it will evaluate
-        checks. In a test environment, you can seed activity data by sending authenticated requests
-        over a consistent pattern, then introducing an anomalous request to verify detection.
+        The detector requires a full learning period and at least 10 activity records for a user
+        before it will evaluate checks. In a test environment, you can seed activity data by
+        sending authenticated requests over a consistent pattern with a short learning period,
+        then introducing an anomalous request to verify detection.
       </p>
       <CodeBlock
         language="bash"
@@ -636,8 +840,8 @@ curl -s -H "X-User-ID: testuser" http://localhost:8080/admin/settings
 
       <h3>Shorter Learning Period for Tests</h3>
       <p>
-        Use a short <code>LearningPeriod</code> in test environments so baselines are computed from
-        a smaller window of data:
+        Use a short <code>LearningPeriod</code> in test environments so baselines finish learning
+        quickly:
       </p>
       <CodeBlock
         language="go"
@@ -652,8 +856,9 @@ Anomaly: sentinel.AnomalyConfig{
 
       <h3>Unit Testing</h3>
       <p>
-        You can test the anomaly detector programmatically by creating a store, seeding activity
-        data, and calling <code>CheckActivity</code> directly:
+        You can test the anomaly detector programmatically by creating a store and calling{' '}
+        <code>CheckActivity</code> directly. Activity timestamps drive the learning period, so a
+        test can replay days of activity instantly:
       </p>
       <CodeBlock
         language="go"
@@ -670,7 +875,7 @@ Anomaly: sentinel.AnomalyConfig{
 
     detector := intelligence.NewAnomalyDetector(store, pipe, geo, sentinel.AnomalyConfig{
         Enabled:        true,
-        LearningPeriod: 7 * 24 * time.Hour,
+        LearningPeriod: 2 * 24 * time.Hour,
         Sensitivity:    sentinel.AnomalySensitivityMedium,
         Checks: []sentinel.AnomalyCheckType{
             sentinel.CheckOffHoursAccess,
@@ -679,13 +884,12 @@ Anomaly: sentinel.AnomalyConfig{
         },
     })
 
-    // Seed baseline: weekday 9-5 activity from US IP
-    for day := 1; day <= 7; day++ {
+    // Learn three days of 9-5 activity from a US IP
+    start := time.Date(2025, 1, 12, 0, 0, 0, 0, time.UTC)
+    for day := 0; day < 3; day++ {
         for hour := 9; hour <= 17; hour++ {
-            ts := time.Now().Add(-time.Duration(day) * 24 * time.Hour)
-            store.SaveUserActivity(ctx, &sentinel.UserActivity{
-                ID:        fmt.Sprintf("act-%d-%d", day, hour),
-                Timestamp: time.Date(ts.Year(), ts.Month(), ts.Day(), hour, 0, 0, 0, ts.Location()),
+            detector.CheckActivity(ctx, &sentinel.UserActivity{
+                Timestamp: start.Add(time.Duration(day*24+hour) * time.Hour),
                 UserID:    "user1",
                 Path:      "/api/data",
                 Method:    "GET",
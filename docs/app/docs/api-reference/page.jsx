@@ -164,11 +164,141 @@ export default function ApiReference() {
           <tr>
             <td><code>GET</code></td>
             <td><code>/api/auth/verify</code></td>
-            <td>Verify that the current token is still valid. Returns HTTP 200 if valid, 401 if not.</td>
+            <td>Verify that the current token is still valid. Returns HTTP 200 with the <code>username</code> and <code>role</code> if valid, 401 if not.</td>
+          </tr>
+          <tr>
+            <td><code>GET</code></td>
+            <td><code>/api/auth/providers</code></td>
+            <td>Report which login methods are enabled: <code>password</code> and <code>oidc</code>. <strong>No auth header required.</strong></td>
+          </tr>
+          <tr>
+            <td><code>GET</code></td>
+            <td><code>/api/auth/oidc/login</code></td>
+            <td>Start an SSO login: redirects to the OIDC provider. Only registered when <code>Dashboard.OIDC</code> is set.</td>
+          </tr>
+          <tr>
+            <td><code>GET</code></td>
+            <td><code>/api/auth/oidc/callback</code></td>
+            <td>Provider redirect target. Verifies the login and redirects to the dashboard.</td>
+          </tr>
+          <tr>
+            <td><code>GET</code></td>
+            <td><code>/api/auth/oidc/session</code></td>
+            <td>Return the dashboard token issued by the callback, once, in the same shape as <code>/api/auth/login</code>.</td>
+          </tr>
+        </tbody>
+      </table>
+
+      <h3 id="roles">Accounts and Roles</h3>
+      <p>
+        The <code>Dashboard.Username</code> / <code>Dashboard.Password</code> pair from your config is a
+        built-in account with the <code>admin</code> role. Admins can add further accounts, each stored
+        with a bcrypt password hash and one of three roles:
+      </p>
+      <ul>
+        <li><strong>viewer</strong> &mdash; read every page, change nothing.</li>
+        <li><strong>analyst</strong> &mdash; also resolve threats, mark false positives, block IPs and actors, reset rate-limit counters and run AI analysis.</li>
+        <li><strong>admin</strong> &mdash; also edit WAF rules, rate limits and alert settings, unblock IPs and users, roll back config and manage accounts.</li>
+      </ul>
+      <p>
+        A request without the required role gets <code>403</code> with code <code>FORBIDDEN</code>.
+        Role changes, disabling and deletion apply to tokens already issued. Every mutating request,
+        and every login attempt, writes an audit log entry attributed to the acting user.
+      </p>
+
+      <table>
+        <thead>
+          <tr>
+            <th>Method</th>
+            <th>Path</th>
+            <th>Description</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr>
+            <td><code>GET</code></td>
+            <td><code>/api/accounts</code></td>
+            <td>List dashboard accounts. Admin only.</td>
+          </tr>
+          <tr>
+            <td><code>POST</code></td>
+            <td><code>/api/accounts</code></td>
+            <td>Create an account from <code>username</code>, <code>password</code> (8+ characters), <code>role</code> and optional <code>email</code>. Admin only.</td>
+          </tr>
+          <tr>
+            <td><code>PUT</code></td>
+            <td><code>/api/accounts/:id</code></td>
+            <td>Change <code>role</code>, <code>password</code>, <code>email</code> or <code>disabled</code>. Admin only.</td>
+          </tr>
+          <tr>
+            <td><code>DELETE</code></td>
+            <td><code>/api/accounts/:id</code></td>
+            <td>Delete an account. Admin only.</td>
+          </tr>
+        </tbody>
+      </table>
+
+      <h3 id="api-keys">API Keys</h3>
+      <p>
+        Scripts and integrations can authenticate with a long-lived API key instead of a login token,
+        sent as <code>Authorization: ApiKey &lt;key&gt;</code>. Keys are created by an admin and shown
+        in full only once; Sentinel stores a SHA-256 hash of the secret.
+      </p>
+      <p>
+        Each key carries a list of scopes of the form <code>&lt;resource&gt;:read</code>,{' '}
+        <code>&lt;resource&gt;:write</code> or <code>&lt;resource&gt;:admin</code>, where the resource
+        is the first path segment after <code>/api/</code> (<code>threats</code>, <code>ip</code>,{' '}
+        <code>waf</code>, <code>config</code>, ...). <code>GET</code> requests need the read scope and
+        everything else the write scope, except routes only admins may use, which need the admin
+        scope: <code>ip:write</code> can block an IP but only <code>ip:admin</code> can unblock or
+        whitelist one. Admin implies write and write implies read. Keys can never reach <code>/api/accounts</code>{' '}
+        or <code>/api/api-keys</code>. A key may be restricted to an IP/CIDR allowlist and given an
+        expiry; its last use is recorded (at most once a minute). Requests made with a key are audited
+        as <code>apikey:&lt;name&gt;</code>.
+      </p>
+
+      <table>
+        <thead>
+          <tr>
+            <th>Method</th>
+            <th>Path</th>
+            <th>Description</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr>
+            <td><code>GET</code></td>
+            <td><code>/api/api-keys</code></td>
+            <td>List API keys, including revoked ones. Admin only.</td>
+          </tr>
+          <tr>
+            <td><code>POST</code></td>
+            <td><code>/api/api-keys</code></td>
+            <td>Create a key from <code>name</code>, <code>scopes</code> and optional <code>allowed_ips</code> and <code>expires_at</code> (RFC 3339). The response's <code>key</code> field holds the full key. Admin only.</td>
+          </tr>
+          <tr>
+            <td><code>DELETE</code></td>
+            <td><code>/api/api-keys/:id</code></td>
+            <td>Revoke a key. Admin only.</td>
           </tr>
         </tbody>
       </table>
 
+      <CodeBlock
+        language="bash"
+        filename="API Key"
+        showLineNumbers={false}
+        code={`curl -X POST http://localhost:8080/sentinel/api/api-keys \\
+  -H "Authorization: Bearer <token>" \\
+  -H "Content-Type: application/json" \\
+  -d '{"name": "siem", "scopes": ["threats:read", "ip:write"], "allowed_ips": ["10.0.0.0/8"]}'
+
+# {"data": {"id": "3f2a...", "name": "siem", ...}, "key": "snk_3f2a..._9c1e..."}
+
+curl http://localhost:8080/sentinel/api/threats \\
+  -H "Authorization: ApiKey snk_3f2a..._9c1e..."`}
+      />
+
       <CodeBlock
         language="bash"
         filename="Login"
@@ -179,7 +309,7 @@ curl -X POST http://localhost:8080/sentinel/api/auth/login \\
   -d '{"username": "admin", "password": "your-password"}'
 
 # Response:
-# {"token": "eyJhbGciOiJIUzI1NiIs..."}`}
+# {"token": "eyJhbGciOiJIUzI1NiIs...", "expires_in": 86400, "username": "admin", "role": "admin"}`}
       />
 
       <CodeBlock
@@ -251,7 +381,7 @@ curl http://localhost:8080/sentinel/api/auth/verify \\
           <tr>
             <td><code>GET</code></td>
             <td><code>/api/threats</code></td>
-            <td>List threat events with pagination and filtering. Supports query parameters: <code>page</code>, <code>page_size</code>, <code>severity</code> (low, medium, high, critical), and <code>type</code> (sqli, xss, path_traversal, etc.).</td>
+            <td>List threat events with pagination and filtering. Supports query parameters: <code>page</code>, <code>page_size</code>, <code>severity</code> (low, medium, high, critical), <code>type</code> (sqli, xss, path_traversal, etc.), <code>ip</code>, and <code>actor_id</code> (e.g. the shared <code>stuffing_…</code> ID of a credential stuffing campaign).</td>
           </tr>
           <tr>
             <td><code>GET</code></td>
@@ -356,13 +486,58 @@ curl -X POST http://localhost:8080/sentinel/api/actors/203.0.113.42/block \\
   -H "Authorization: Bearer <token>"`}
       />
 
+      {/* ------------------------------------------------------------------ */}
+      {/*  CAMPAIGNS                                                         */}
+      {/* ------------------------------------------------------------------ */}
+
+      <h2 id="campaigns">Campaigns</h2>
+      <p>
+        Groups of actors linked by shared signals. See{' '}
+        <a href="/docs/threat-intelligence#campaigns">Campaigns</a>. All four endpoints return 404
+        when <code>Campaigns.Enabled</code> is false.
+      </p>
+
+      <table>
+        <thead>
+          <tr>
+            <th>Method</th>
+            <th>Path</th>
+            <th>Description</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr>
+            <td><code>GET</code></td>
+            <td><code>/api/campaigns</code></td>
+            <td>List campaigns, riskiest first. Supports <code>status</code> (<code>Active</code> or <code>Blocked</code>), <code>min_risk</code>, <code>page</code> and <code>page_size</code>.</td>
+          </tr>
+          <tr>
+            <td><code>GET</code></td>
+            <td><code>/api/campaigns/:id</code></td>
+            <td>Get a campaign: its actor IPs, threat count, attack types, routes, countries and the signals that linked it.</td>
+          </tr>
+          <tr>
+            <td><code>POST</code></td>
+            <td><code>/api/campaigns/:id/block</code></td>
+            <td>Block every actor of the campaign, and those joining it later. Optional body <code>{'{"reason": "...", "permanent": false}'}</code>. Expires after 24 hours unless permanent. Analyst role or above.</td>
+          </tr>
+          <tr>
+            <td><code>DELETE</code></td>
+            <td><code>/api/campaigns/:id/block</code></td>
+            <td>Lift the blocks placed with the campaign. Admin role.</td>
+          </tr>
+        </tbody>
+      </table>
+
       {/* ------------------------------------------------------------------ */}
       {/*  IP MANAGEMENT                                                     */}
       {/* ------------------------------------------------------------------ */}
 
       <h2 id="ip-management">IP Management</h2>
       <p>
-        Endpoints for managing IP whitelists and blacklists.
+        Endpoints for managing the IP blocklist and whitelist. Entries are IP addresses, CIDR ranges
+        (IPv4 or IPv6) or ASNs such as <code>AS64496</code>; the most specific entry covering an
+        address decides, and the whitelist wins ties.
       </p>
 
       <table>
@@ -376,31 +551,91 @@ curl -X POST http://localhost:8080/sentinel/api/actors/203.0.113.42/block \\
         <tbody>
           <tr>
             <td><code>GET</code></td>
-            <td><code>/api/ip-lists</code></td>
-            <td>List all whitelist and blacklist entries with their type, creation timestamp, and reason.</td>
+            <td><code>/api/ip/blocked</code></td>
+            <td>List live blocklist entries with their reason, block time, expiry and whether they are CIDR ranges.</td>
+          </tr>
+          <tr>
+            <td><code>POST</code></td>
+            <td><code>/api/ip/block</code></td>
+            <td>Block an IP, CIDR range or ASN. Body requires <code>ip</code>, with optional <code>reason</code>, <code>expiry</code> (RFC3339) or <code>permanent</code>; blocks last 24 hours by default. Returns the canonical entry.</td>
+          </tr>
+          <tr>
+            <td><code>DELETE</code></td>
+            <td><code>/api/ip/block/:ip</code></td>
+            <td>Remove a blocklist entry. Admin only. Write <code>/</code> as <code>_</code> for CIDR ranges.</td>
+          </tr>
+          <tr>
+            <td><code>GET</code></td>
+            <td><code>/api/ip/whitelisted</code></td>
+            <td>List whitelist entries.</td>
+          </tr>
+          <tr>
+            <td><code>POST</code></td>
+            <td><code>/api/ip/whitelist</code></td>
+            <td>Whitelist an IP, CIDR range or ASN. Admin only. Body requires <code>ip</code>. Whitelist entries do not expire.</td>
+          </tr>
+          <tr>
+            <td><code>DELETE</code></td>
+            <td><code>/api/ip/whitelist/:ip</code></td>
+            <td>Remove a whitelist entry. Admin only. Write <code>/</code> as <code>_</code> for CIDR ranges.</td>
+          </tr>
+          <tr>
+            <td><code>GET</code></td>
+            <td><code>/api/ip/:ip/status</code></td>
+            <td>Report whether an IP is blocked or whitelisted. <code>blocked_by</code> or <code>whitelisted_by</code> carries the deciding entry — a blocklist, whitelist or threat feed entry — <code>feed</code> any threat feed match, and <code>network</code> the client&apos;s network type when network detection is enabled.</td>
+          </tr>
+          <tr>
+            <td><code>GET</code></td>
+            <td><code>/api/intel/feeds</code></td>
+            <td>List configured threat feeds with their indicator counts, match counts, last load time, last error, and expiry.</td>
+          </tr>
+          <tr>
+            <td><code>POST</code></td>
+            <td><code>/api/intel/feeds/:name/refresh</code></td>
+            <td>Reload a threat feed immediately. Admin only. Returns 404 for an unknown feed and 502 when the load fails.</td>
+          </tr>
+          <tr>
+            <td><code>GET</code></td>
+            <td><code>/api/intel/networks</code></td>
+            <td>List the Tor, VPN and hosting list files with their prefix counts, match counts, last load time and last error. Returns 404 when network detection is disabled.</td>
+          </tr>
+          <tr>
+            <td><code>POST</code></td>
+            <td><code>/api/intel/networks/reload</code></td>
+            <td>Re-read every network list file now. Admin only. Returns 502 when a file fails to load; the other lists are still reloaded.</td>
+          </tr>
+          <tr>
+            <td><code>GET</code></td>
+            <td><code>/api/geofence/policies</code></td>
+            <td>List the live geofencing policies.</td>
           </tr>
           <tr>
             <td><code>POST</code></td>
-            <td><code>/api/ip-lists</code></td>
-            <td>Add an IP address or CIDR range to the whitelist or blacklist. Body requires <code>ip</code>, <code>type</code> (block or allow), and optional <code>reason</code>.</td>
+            <td><code>/api/geofence/policies</code></td>
+            <td>Add a geofencing policy, or replace the one with the same <code>id</code>. Admin only. Returns 400 for an invalid policy. Versioned with the rest of the configuration.</td>
           </tr>
           <tr>
             <td><code>DELETE</code></td>
-            <td><code>/api/ip-lists/:id</code></td>
-            <td>Remove an IP entry from the whitelist or blacklist by its ID.</td>
+            <td><code>/api/geofence/policies/:id</code></td>
+            <td>Delete a geofencing policy. Admin only. Returns 404 for an unknown policy.</td>
+          </tr>
+          <tr>
+            <td><code>GET</code></td>
+            <td><code>/api/geofence/check</code></td>
+            <td>Report how the policies treat a client. Query requires <code>ip</code>, with optional <code>path</code> (default <code>/</code>). Returns the resolved <code>location</code>, whether the client is <code>allowed</code> and the refusing <code>policy</code>, if any.</td>
           </tr>
         </tbody>
       </table>
 
       <CodeBlock
         language="bash"
-        filename="Add IP to Blacklist"
+        filename="Block an IP range"
         showLineNumbers={false}
         code={`# Block an IP range
-curl -X POST http://localhost:8080/sentinel/api/ip-lists \\
+curl -X POST http://localhost:8080/sentinel/api/ip/block \\
   -H "Authorization: Bearer <token>" \\
   -H "Content-Type: application/json" \\
-  -d '{"ip": "198.51.100.0/24", "type": "block", "reason": "Known botnet range"}'`}
+  -d '{"ip": "198.51.100.0/24", "reason": "Known botnet range"}'`}
       />
 
       {/* ------------------------------------------------------------------ */}
@@ -444,7 +679,7 @@ curl -X POST http://localhost:8080/sentinel/api/ip-lists \\
           <tr>
             <td><code>POST</code></td>
             <td><code>/api/waf/test</code></td>
-            <td>Test arbitrary input against the active rule set. Returns which rules would match and their details. Useful for validating rules before deployment.</td>
+            <td>Test a <code>payload</code> string, or a whole <code>request</code> (method, path, query, headers, body, ip, country), against the active rule set. Returns which rules would match, with the <code>conditions</code> that held for rules with <code>When</code> conditions.</td>
           </tr>
         </tbody>
       </table>
@@ -457,7 +692,13 @@ curl -X POST http://localhost:8080/sentinel/api/ip-lists \\
 curl -X POST http://localhost:8080/sentinel/api/waf/test \\
   -H "Authorization: Bearer <token>" \\
   -H "Content-Type: application/json" \\
-  -d '{"input": "SELECT * FROM users WHERE id=1 OR 1=1"}'`}
+  -d '{"payload": "SELECT * FROM users WHERE id=1 OR 1=1"}'
+
+# Test a whole request against rules with When conditions
+curl -X POST http://localhost:8080/sentinel/api/waf/test \\
+  -H "Authorization: Bearer <token>" \\
+  -H "Content-Type: application/json" \\
+  -d '{"request": {"method": "POST", "path": "/api/v1/export", "headers": {"X-Debug": ["1"]}}}'`}
       />
 
       {/* ------------------------------------------------------------------ */}
@@ -486,7 +727,7 @@ curl -X POST http://localhost:8080/sentinel/api/waf/test \\
           <tr>
             <td><code>PUT</code></td>
             <td><code>/api/rate-limits</code></td>
-            <td>Update the rate limit configuration. Changes take effect immediately.</td>
+            <td>Update per-route limits. Changes take effect immediately and are saved as a config version. An invalid window is rejected with <code>400</code>.</td>
           </tr>
           <tr>
             <td><code>GET</code></td>
@@ -510,6 +751,45 @@ curl http://localhost:8080/sentinel/api/rate-limits/current \\
   -H "Authorization: Bearer <token>"`}
       />
 
+      {/* ------------------------------------------------------------------ */}
+      {/*  CONFIG VERSIONS                                                   */}
+      {/* ------------------------------------------------------------------ */}
+
+      <h2 id="config-versions">Config Versions</h2>
+      <p>
+        Every dashboard edit to the WAF mode and rules, custom rules, per-route rate limits and the
+        alert severity threshold is saved as a numbered config version and layered over your Go
+        config on the next start. Rolling back saves the older version&apos;s state as a new version,
+        so history is never rewritten.
+      </p>
+
+      <table>
+        <thead>
+          <tr>
+            <th>Method</th>
+            <th>Path</th>
+            <th>Description</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr>
+            <td><code>GET</code></td>
+            <td><code>/api/config/versions</code></td>
+            <td>List saved versions, newest first. Supports <code>limit</code> (default 50).</td>
+          </tr>
+          <tr>
+            <td><code>GET</code></td>
+            <td><code>/api/config/versions/:version</code></td>
+            <td>Get one version with its full set of overrides.</td>
+          </tr>
+          <tr>
+            <td><code>POST</code></td>
+            <td><code>/api/config/versions/:version/rollback</code></td>
+            <td>Restore a version. Version <code>0</code> discards every dashboard edit and returns to the Go config.</td>
+          </tr>
+        </tbody>
+      </table>
+
       {/* ------------------------------------------------------------------ */}
       {/*  AI                                                                */}
       {/* ------------------------------------------------------------------ */}
@@ -668,6 +948,11 @@ curl "http://localhost:8080/sentinel/api/reports/gdpr?window=720h" \\
             <td><code>/api/analytics/time-pattern</code></td>
             <td>Get attack distribution by hour of day and day of week to identify automated attack patterns.</td>
           </tr>
+          <tr>
+            <td><code>GET</code></td>
+            <td><code>/api/analytics/bots</code></td>
+            <td>Get request counts by bot verdict and by verified crawler. Supports <code>window</code> (default <code>24h</code>, up to <code>168h</code>). Returns 404 unless <code>Bots.Enabled</code> is set.</td>
+          </tr>
         </tbody>
       </table>
 
@@ -703,6 +988,21 @@ curl "http://localhost:8080/sentinel/api/analytics/trends?window=24h&interval=ho
             <td><code>/api/users</code></td>
             <td>List tracked users extracted by your <code>UserExtractor</code> function. Shows request counts, last active timestamps, and associated security events.</td>
           </tr>
+          <tr>
+            <td><code>GET</code></td>
+            <td><code>/api/users/:user_id/baseline</code></td>
+            <td>Get the user's behavioral baseline used by anomaly detection. <code>meta.learning</code> is true until the baseline spans the learning period. 404 when anomaly detection is off or the user has no baseline.</td>
+          </tr>
+          <tr>
+            <td><code>DELETE</code></td>
+            <td><code>/api/users/:user_id/baseline</code></td>
+            <td>Reset the user's baseline so it learns from scratch. Analyst role or above.</td>
+          </tr>
+          <tr>
+            <td><code>GET</code></td>
+            <td><code>/api/sessions</code></td>
+            <td>List the sessions scored by session risk, riskiest first. Filter with <code>user_id</code> and <code>flagged=true</code>. 404 when session risk is off.</td>
+          </tr>
           <tr>
             <td><code>GET</code></td>
             <td><code>/api/audit-logs</code></td>
@@ -868,9 +1168,23 @@ curl --include \\
           <tr><td><code>GET</code></td><td><code>/api/actors/:ip</code></td><td>Yes</td></tr>
           <tr><td><code>GET</code></td><td><code>/api/actors/:ip/threats</code></td><td>Yes</td></tr>
           <tr><td><code>POST</code></td><td><code>/api/actors/:ip/block</code></td><td>Yes</td></tr>
-          <tr><td><code>GET</code></td><td><code>/api/ip-lists</code></td><td>Yes</td></tr>
-          <tr><td><code>POST</code></td><td><code>/api/ip-lists</code></td><td>Yes</td></tr>
-          <tr><td><code>DELETE</code></td><td><code>/api/ip-lists/:id</code></td><td>Yes</td></tr>
+          <tr><td><code>GET</code></td><td><code>/api/campaigns</code></td><td>Yes</td></tr>
+          <tr><td><code>GET</code></td><td><code>/api/campaigns/:id</code></td><td>Yes</td></tr>
+          <tr><td><code>POST</code></td><td><code>/api/campaigns/:id/block</code></td><td>Yes</td></tr>
+          <tr><td><code>DELETE</code></td><td><code>/api/campaigns/:id/block</code></td><td>Yes</td></tr>
+          <tr><td><code>GET</code></td><td><code>/api/ip/blocked</code></td><td>Yes</td></tr>
+          <tr><td><code>POST</code></td><td><code>/api/ip/block</code></td><td>Yes</td></tr>
+          <tr><td><code>DELETE</code></td><td><code>/api/ip/block/:ip</code></td><td>Yes</td></tr>
+          <tr><td><code>GET</code></td><td><code>/api/ip/whitelisted</code></td><td>Yes</td></tr>
+          <tr><td><code>POST</code></td><td><code>/api/ip/whitelist</code></td><td>Yes</td></tr>
+          <tr><td><code>DELETE</code></td><td><code>/api/ip/whitelist/:ip</code></td><td>Yes</td></tr>
+          <tr><td><code>GET</code></td><td><code>/api/ip/:ip/status</code></td><td>Yes</td></tr>
+          <tr><td><code>GET</code></td><td><code>/api/intel/feeds</code></td><td>Yes</td></tr>
+          <tr><td><code>POST</code></td><td><code>/api/intel/feeds/:name/refresh</code></td><td>Yes</td></tr>
+          <tr><td><code>GET</code></td><td><code>/api/geofence/policies</code></td><td>Yes</td></tr>
+          <tr><td><code>POST</code></td><td><code>/api/geofence/policies</code></td><td>Yes</td></tr>
+          <tr><td><code>DELETE</code></td><td><code>/api/geofence/policies/:id</code></td><td>Yes</td></tr>
+          <tr><td><code>GET</code></td><td><code>/api/geofence/check</code></td><td>Yes</td></tr>
           <tr><td><code>GET</code></td><td><code>/api/waf/rules</code></td><td>Yes</td></tr>
           <tr><td><code>POST</code></td><td><code>/api/waf/rules</code></td><td>Yes</td></tr>
           <tr><td><code>PUT</code></td><td><code>/api/waf/rules/:id</code></td><td>Yes</td></tr>
@@ -880,6 +1194,16 @@ curl --include \\
           <tr><td><code>PUT</code></td><td><code>/api/rate-limits</code></td><td>Yes</td></tr>
           <tr><td><code>GET</code></td><td><code>/api/rate-limits/current</code></td><td>Yes</td></tr>
           <tr><td><code>POST</code></td><td><code>/api/rate-limits/reset/:key</code></td><td>Yes</td></tr>
+          <tr><td><code>GET</code></td><td><code>/api/config/versions</code></td><td>Yes</td></tr>
+          <tr><td><code>GET</code></td><td><code>/api/config/versions/:version</code></td><td>Yes</td></tr>
+          <tr><td><code>POST</code></td><td><code>/api/config/versions/:version/rollback</code></td><td>Yes</td></tr>
+          <tr><td><code>GET</code></td><td><code>/api/accounts</code></td><td>Yes</td></tr>
+          <tr><td><code>POST</code></td><td><code>/api/accounts</code></td><td>Yes</td></tr>
+          <tr><td><code>PUT</code></td><td><code>/api/accounts/:id</code></td><td>Yes</td></tr>
+          <tr><td><code>DELETE</code></td><td><code>/api/accounts/:id</code></td><td>Yes</td></tr>
+          <tr><td><code>GET</code></td><td><code>/api/api-keys</code></td><td>Yes</td></tr>
+          <tr><td><code>POST</code></td><td><code>/api/api-keys</code></td><td>Yes</td></tr>
+          <tr><td><code>DELETE</code></td><td><code>/api/api-keys/:id</code></td><td>Yes</td></tr>
           <tr><td><code>POST</code></td><td><code>/api/ai/analyze-threat/:id</code></td><td>Yes</td></tr>
           <tr><td><code>GET</code></td><td><code>/api/ai/analyze-actor/:ip</code></td><td>Yes</td></tr>
           <tr><td><code>GET</code></td><td><code>/api/ai/daily-summary</code></td><td>Yes</td></tr>
@@ -892,7 +1216,11 @@ curl --include \\
           <tr><td><code>GET</code></td><td><code>/api/analytics/geographic</code></td><td>Yes</td></tr>
           <tr><td><code>GET</code></td><td><code>/api/analytics/top-routes</code></td><td>Yes</td></tr>
           <tr><td><code>GET</code></td><td><code>/api/analytics/time-pattern</code></td><td>Yes</td></tr>
+          <tr><td><code>GET</code></td><td><code>/api/analytics/bots</code></td><td>Yes</td></tr>
           <tr><td><code>GET</code></td><td><code>/api/users</code></td><td>Yes</td></tr>
+          <tr><td><code>GET</code></td><td><code>/api/users/:user_id/baseline</code></td><td>Yes</td></tr>
+          <tr><td><code>DELETE</code></td><td><code>/api/users/:user_id/baseline</code></td><td>Yes</td></tr>
+          <tr><td><code>GET</code></td><td><code>/api/sessions</code></td><td>Yes</td></tr>
           <tr><td><code>GET</code></td><td><code>/api/audit-logs</code></td><td>Yes</td></tr>
           <tr><td><code>GET</code></td><td><code>/api/alerts</code></td><td>Yes</td></tr>
           <tr><td><code>PUT</code></td><td><code>/api/alerts/config</code></td><td>Yes</td></tr>
@@ -121,6 +121,12 @@ export default function AuthShield() {
             <td><code>false</code></td>
             <td>When enabled, detects repeated password guessing against the same username.</td>
           </tr>
+          <tr>
+            <td><code>PasswordField</code></td>
+            <td><code>string</code></td>
+            <td><code>"password"</code></td>
+            <td>Form or JSON field holding the submitted password. Failed logins carry a keyed fingerprint of it to the anomaly detector, which uses it to spot one password tried against many accounts. The password itself is never stored.</td>
+          </tr>
         </tbody>
       </table>
 
@@ -336,6 +342,13 @@ func main() {
         evidence detailing the specific detection pattern.
       </p>
 
+      <Callout type="info" title="Attacks Spread Over Many IPs">
+        The checks above look at one IP at a time. Auth Shield also sends every login attempt to the
+        anomaly detector, whose <a href="/docs/anomaly-detection#credential-stuffing">credential
+        stuffing check</a> correlates them across IPs: many usernames from one network, a failure rate
+        that is high in total but low per IP, and one password tried against many accounts.
+      </Callout>
+
       {/* ------------------------------------------------------------------ */}
       {/*  TESTING                                                           */}
       {/* ------------------------------------------------------------------ */}
@@ -162,6 +162,11 @@ func main() {
             PermissionsPolicy:       "camera=(), microphone=(), geolocation=()",
         },
 
+        DLP: sentinel.DLPConfig{
+            Enabled: true,
+            Action:  sentinel.DLPActionMask,
+        },
+
         Anomaly: sentinel.AnomalyConfig{
             Enabled:        true,
             Sensitivity:    sentinel.AnomalySensitivityMedium,
@@ -183,8 +188,9 @@ func main() {
         },
 
         Geo: sentinel.GeoConfig{
-            Enabled:  true,
-            Provider: sentinel.GeoIPFree,
+            Enabled:      true,
+            Provider:     sentinel.GeoIPFree,
+            DatabasePath: "/var/lib/GeoIP/GeoLite2-City.mmdb",
         },
 
         Alerts: sentinel.AlertConfig{
@@ -279,13 +285,13 @@ func main() {
             <td><code>Username</code></td>
             <td><code>string</code></td>
             <td><code>admin</code></td>
-            <td>Username for dashboard login.</td>
+            <td>Username of the built-in dashboard account, which always has the <code>admin</code> role. Further accounts are managed from the dashboard.</td>
           </tr>
           <tr>
             <td><code>Password</code></td>
             <td><code>string</code></td>
             <td><code>sentinel</code></td>
-            <td>Password for dashboard login.</td>
+            <td>Password of the built-in dashboard account.</td>
           </tr>
           <tr>
             <td><code>SecretKey</code></td>
@@ -293,6 +299,12 @@ func main() {
             <td><code>sentinel-default-secret-change-me</code></td>
             <td>Secret key used to sign JWT tokens for dashboard sessions.</td>
           </tr>
+          <tr>
+            <td><code>OIDC</code></td>
+            <td><code>*OIDCConfig</code></td>
+            <td><code>nil</code></td>
+            <td>Single sign-on through an OpenID Connect provider. See below.</td>
+          </tr>
         </tbody>
       </table>
 
@@ -307,6 +319,43 @@ func main() {
 }`}
       />
 
+      <h3 id="oidc">Single Sign-On (OIDC)</h3>
+      <p>
+        With <code>OIDC</code> set, the login page offers &quot;Sign in with SSO&quot;. Sentinel runs
+        the authorization-code flow with PKCE against the provider found at{' '}
+        <code>IssuerURL/.well-known/openid-configuration</code>, verifies the ID token against the
+        provider&apos;s JWKS, and issues a normal dashboard token. The role comes from the{' '}
+        <code>RoleClaim</code> claim (default <code>groups</code>) through <code>RoleMapping</code>;
+        users matching several entries get the highest role, and users matching none get{' '}
+        <code>DefaultRole</code> or are refused. The username comes from <code>UsernameClaim</code>{' '}
+        (default <code>email</code>, accepted only with <code>email_verified</code>) and is prefixed
+        with <code>oidc:</code>, so it never matches a local account. Roles are not re-checked with
+        the provider, so SSO tokens expire after <code>SessionTTL</code> (default 1h) rather than
+        24h. Register{' '}
+        <code>RedirectURL</code> with the provider; it must point at{' '}
+        <code>&lt;Prefix&gt;/api/auth/oidc/callback</code>.
+      </p>
+
+      <CodeBlock
+        language="go"
+        filename="config.go"
+        code={`Dashboard: sentinel.DashboardConfig{
+    SecretKey: "a-strong-random-secret",
+    OIDC: &sentinel.OIDCConfig{
+        IssuerURL:    "https://login.example.com",
+        ClientID:     "sentinel-dashboard",
+        ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+        RedirectURL:  "https://app.example.com/sentinel/api/auth/oidc/callback",
+        RoleMapping: map[string]sentinel.DashboardRole{
+            "secops":         sentinel.RoleAnalyst,
+            "security-admin": sentinel.RoleAdmin,
+        },
+        DefaultRole:          sentinel.RoleViewer,
+        DisablePasswordLogin: true, // the IdP is the only way in
+    },
+}`}
+      />
+
       <Callout type="warning" title="Change Default Credentials">
         The default username/password (<code>admin</code>/<code>sentinel</code>) and secret key are
         intended for development only. Always set strong values in production. The{' '}
@@ -491,6 +540,12 @@ Storage: sentinel.StorageConfig{
             <td><code>nil</code></td>
             <td>Custom regex-based rules for application-specific patterns.</td>
           </tr>
+          <tr>
+            <td><code>RuleFiles</code></td>
+            <td><code>[]string</code></td>
+            <td><code>nil</code></td>
+            <td>ModSecurity rule files imported as custom rules at mount. See <a href="/docs/waf#seclang">Importing ModSecurity Rules</a>.</td>
+          </tr>
           <tr>
             <td><code>ExcludeRoutes</code></td>
             <td><code>[]string</code></td>
@@ -501,7 +556,19 @@ Storage: sentinel.StorageConfig{
             <td><code>ExcludeIPs</code></td>
             <td><code>[]string</code></td>
             <td><code>nil</code></td>
-            <td>IP addresses or CIDR ranges to exclude from WAF inspection.</td>
+            <td>IP addresses, CIDR ranges (IPv4 or IPv6) or ASNs (<code>"AS64496"</code>, resolved through <code>Geo</code>) to exclude from WAF inspection. Unparseable entries are logged and ignored; <code>ValidateConfig</code> reports them.</td>
+          </tr>
+          <tr>
+            <td><code>BotPolicy</code></td>
+            <td><code>map[BotVerdict]BotAction</code></td>
+            <td><code>nil</code></td>
+            <td>What to do with each bot verdict: <code>allow</code> (skip inspection; <code>verified</code> only), <code>challenge</code>, <code>block</code> or <code>inspect</code>. Requires <code>Bots.Enabled</code>. See <a href="/docs/waf#bots">Bot Management</a>.</td>
+          </tr>
+          <tr>
+            <td><code>NetworkPolicy</code></td>
+            <td><code>map[NetworkType]WAFMode</code></td>
+            <td><code>nil</code></td>
+            <td>Log, challenge or block requests by network type (<code>tor</code>, <code>vpn</code>, <code>hosting</code>, <code>residential</code>). Requires <code>Networks.Enabled</code>. See <a href="/docs/threat-intelligence#network-types">Network Types</a>.</td>
           </tr>
         </tbody>
       </table>
@@ -738,6 +805,30 @@ Storage: sentinel.StorageConfig{
             <td><code>nil</code></td>
             <td>Per-user rate limit. Requires a <code>UserIDExtractor</code> or <code>UserExtractor</code>.</td>
           </tr>
+          <tr>
+            <td><code>ByFingerprint</code></td>
+            <td><code>*Limit</code></td>
+            <td><code>nil</code></td>
+            <td>Per-TLS-fingerprint rate limit, shared by every IP presenting the fingerprint. Requires <code>Fingerprint.Source</code>.</td>
+          </tr>
+          <tr>
+            <td><code>ByBot</code></td>
+            <td><code>map[BotVerdict]Limit</code></td>
+            <td><code>nil</code></td>
+            <td>Per-IP limits keyed on the request&apos;s bot verdict. Requires <code>Bots.Enabled</code>.</td>
+          </tr>
+          <tr>
+            <td><code>ByNetwork</code></td>
+            <td><code>map[NetworkType]Limit</code></td>
+            <td><code>nil</code></td>
+            <td>Per-IP limits keyed on the request&apos;s network type. Requires <code>Networks.Enabled</code>.</td>
+          </tr>
+          <tr>
+            <td><code>ExemptBots</code></td>
+            <td><code>[]BotVerdict</code></td>
+            <td><code>nil</code></td>
+            <td>Bot verdicts that skip every rate limit.</td>
+          </tr>
           <tr>
             <td><code>ByRoute</code></td>
             <td><code>map[string]Limit</code></td>
@@ -1128,6 +1219,104 @@ Storage: sentinel.StorageConfig{
         period may produce more false positives; a longer period provides more accurate baselines.
       </Callout>
 
+      <h3>Session Risk</h3>
+      <p>
+        The <code>SessionRiskConfig</code> scores authenticated sessions for account takeover. It
+        needs a <code>UserExtractor</code>. See{' '}
+        <a href="/docs/anomaly-detection#session-risk">Session Risk</a> for the signals.
+      </p>
+      <table>
+        <thead>
+          <tr>
+            <th>Field</th>
+            <th>Type</th>
+            <th>Default</th>
+            <th>Description</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr><td><code>Enabled</code></td><td><code>bool</code></td><td><code>false</code></td><td>Enable session scoring.</td></tr>
+          <tr><td><code>Threshold</code></td><td><code>int</code></td><td><code>50</code></td><td>Score at which a session is flagged.</td></tr>
+          <tr><td><code>Action</code></td><td><code>SessionRiskAction</code></td><td><code>log</code></td><td><code>log</code>, <code>challenge</code> (CAPTCHA step-up) or <code>revoke</code>.</td></tr>
+          <tr><td><code>OnRevoke</code></td><td><code>func(*gin.Context, *SessionRisk)</code></td><td><code>nil</code></td><td>Called once when a session is revoked.</td></tr>
+          <tr><td><code>SessionIDExtractor</code></td><td><code>func(*gin.Context) string</code></td><td><code>nil</code></td><td>Identifies the session. Defaults to the Authorization header, then <code>SessionCookie</code>.</td></tr>
+          <tr><td><code>SessionCookie</code></td><td><code>string</code></td><td><code>"session"</code></td><td>Cookie read by the default session ID.</td></tr>
+          <tr><td><code>SensitiveRoutes</code></td><td><code>[]string</code></td><td><code>nil</code></td><td>Route patterns of password, email and similar changes.</td></tr>
+          <tr><td><code>SensitiveWindow</code></td><td><code>time.Duration</code></td><td><code>30m</code></td><td>How soon after a session starts a sensitive change is scored.</td></tr>
+          <tr><td><code>MaxTravelSpeed</code></td><td><code>float64</code></td><td><code>1000</code></td><td>km/h above which travel between sessions is impossible.</td></tr>
+          <tr><td><code>SessionTTL</code></td><td><code>time.Duration</code></td><td><code>24h</code></td><td>How long an idle session is remembered.</td></tr>
+        </tbody>
+      </table>
+
+      <h3>Campaigns</h3>
+      <p>
+        The <code>CampaignConfig</code> groups threat actors that share signals into campaigns. See{' '}
+        <a href="/docs/threat-intelligence#campaigns">Campaigns</a>.
+      </p>
+      <table>
+        <thead>
+          <tr>
+            <th>Field</th>
+            <th>Type</th>
+            <th>Default</th>
+            <th>Description</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr><td><code>Enabled</code></td><td><code>bool</code></td><td><code>false</code></td><td>Enable campaign clustering.</td></tr>
+          <tr><td><code>LinkScore</code></td><td><code>int</code></td><td><code>3</code></td><td>Shared signal weight that links two actors (at most 7).</td></tr>
+          <tr><td><code>Window</code></td><td><code>time.Duration</code></td><td><code>24h</code></td><td>How long an actor's signals are remembered after its last threat.</td></tr>
+          <tr><td><code>TimingBucket</code></td><td><code>time.Duration</code></td><td><code>10m</code></td><td>Width of the time slots the timing signal compares.</td></tr>
+        </tbody>
+      </table>
+
+      <h3>Fingerprint</h3>
+      <p>
+        The <code>FingerprintConfig</code> attaches TLS client fingerprints (JA3/JA4) to requests
+        and threat events. See{' '}
+        <a href="/docs/threat-intelligence#tls-fingerprinting">TLS Fingerprinting</a>.
+      </p>
+      <table>
+        <thead>
+          <tr>
+            <th>Field</th>
+            <th>Type</th>
+            <th>Default</th>
+            <th>Description</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr><td><code>Source</code></td><td><code>func(*http.Request) *TLSFingerprint</code></td><td><code>nil</code></td><td>Returns the request&apos;s fingerprint, e.g. <code>fingerprint.Recorder.Source</code> or <code>fingerprint.Headers</code>. Nil disables fingerprinting.</td></tr>
+          <tr><td><code>Deny</code></td><td><code>[]string</code></td><td><code>nil</code></td><td>JA3 or JA4 values refused with 403 and a <code>DeniedFingerprint</code> threat. A trailing <code>*</code> matches by prefix.</td></tr>
+          <tr><td><code>Allow</code></td><td><code>[]string</code></td><td><code>nil</code></td><td>Fingerprints exempt from <code>Deny</code> and from <code>RateLimit.ByFingerprint</code>.</td></tr>
+        </tbody>
+      </table>
+
+      <h3>Bots</h3>
+      <p>
+        The <code>BotConfig</code> classifies every request as human, verified crawler, unverified
+        bot or bad bot. See <a href="/docs/waf#bots">Bot Management</a>.
+      </p>
+      <table>
+        <thead>
+          <tr>
+            <th>Field</th>
+            <th>Type</th>
+            <th>Default</th>
+            <th>Description</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr><td><code>Enabled</code></td><td><code>bool</code></td><td><code>false</code></td><td>Enables bot classification.</td></tr>
+          <tr><td><code>Crawlers</code></td><td><code>[]Crawler</code></td><td><code>nil</code></td><td>Extra crawlers to verify, each with User-Agent tokens and PTR domains. Added to the built-in list; an entry with a built-in name replaces it.</td></tr>
+          <tr><td><code>Resolver</code></td><td><code>BotResolver</code></td><td><code>net.DefaultResolver</code></td><td>DNS resolver used for verification. Any type with <code>LookupAddr</code> and <code>LookupHost</code>, such as <code>*net.Resolver</code>.</td></tr>
+          <tr><td><code>Threshold</code></td><td><code>int</code></td><td><code>50</code></td><td>Bot score (0–100) at which a client is judged an unverified bot.</td></tr>
+          <tr><td><code>VerifyTimeout</code></td><td><code>time.Duration</code></td><td><code>2s</code></td><td>Time allowed for the reverse and forward lookups of one verification.</td></tr>
+          <tr><td><code>CacheTTL</code></td><td><code>time.Duration</code></td><td><code>24h</code></td><td>How long a verification result is cached per IP. Lookups that fail are retried after a minute.</td></tr>
+          <tr><td><code>ExcludeRoutes</code></td><td><code>[]string</code></td><td><code>nil</code></td><td>Routes that are not classified.</td></tr>
+        </tbody>
+      </table>
+
       {/* ------------------------------------------------------------------ */}
       {/*  IP REPUTATION CONFIG                                               */}
       {/* ------------------------------------------------------------------ */}
@@ -1172,6 +1361,35 @@ Storage: sentinel.StorageConfig{
             <td><code>80</code></td>
             <td>Minimum AbuseIPDB confidence score (0-100) to consider an IP malicious.</td>
           </tr>
+          <tr>
+            <td><code>Feeds</code></td>
+            <td><code>[]ThreatFeed</code></td>
+            <td><code>nil</code></td>
+            <td>Threat intelligence blocklists loaded from files or URLs and refreshed on a schedule. They work without <code>Enabled</code>. See <a href="/docs/threat-intelligence#threat-feeds">Threat Feeds</a>.</td>
+          </tr>
+        </tbody>
+      </table>
+
+      <h3>ThreatFeed</h3>
+      <table>
+        <thead>
+          <tr>
+            <th>Field</th>
+            <th>Type</th>
+            <th>Default</th>
+            <th>Description</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr><td><code>Name</code></td><td><code>string</code></td><td>required</td><td>Unique name, recorded on threat events and in <code>BlockedIP.Reason</code>.</td></tr>
+          <tr><td><code>URL</code> / <code>Path</code></td><td><code>string</code></td><td><code>""</code></td><td>Where the feed is read from. Set exactly one.</td></tr>
+          <tr><td><code>Headers</code></td><td><code>map[string]string</code></td><td><code>nil</code></td><td>Sent with every fetch of <code>URL</code>, e.g. an API key.</td></tr>
+          <tr><td><code>Format</code></td><td><code>FeedFormat</code></td><td><code>FeedText</code></td><td><code>FeedText</code>, <code>FeedCSV</code> or <code>FeedSTIX</code>.</td></tr>
+          <tr><td><code>Column</code></td><td><code>int</code></td><td><code>0</code></td><td>Zero-based CSV column holding the indicator.</td></tr>
+          <tr><td><code>Action</code></td><td><code>FeedAction</code></td><td><code>FeedActionBlock</code></td><td><code>FeedActionBlock</code>, <code>FeedActionFlag</code> or <code>FeedActionScore</code>.</td></tr>
+          <tr><td><code>ScoreBoost</code></td><td><code>int</code></td><td><code>20</code></td><td>Added to the risk score of actors the feed lists. Negative disables it.</td></tr>
+          <tr><td><code>Refresh</code></td><td><code>time.Duration</code></td><td><code>1h</code></td><td>How often the feed is reloaded.</td></tr>
+          <tr><td><code>Expiry</code></td><td><code>time.Duration</code></td><td><code>0</code></td><td>Drops the indicators once this long has passed since the last successful load. Zero keeps them.</td></tr>
         </tbody>
       </table>
 
@@ -1223,7 +1441,25 @@ Storage: sentinel.StorageConfig{
             <td><code>Provider</code></td>
             <td><code>GeoProvider</code></td>
             <td><code>sentinel.GeoIPFree</code></td>
-            <td>Geolocation provider. Options: <code>sentinel.GeoIPFree</code> (GeoLite2), <code>sentinel.GeoIPPaid</code> (GeoIP2).</td>
+            <td>Geolocation provider. Options: <code>sentinel.GeoIPFree</code> (GeoLite2), <code>sentinel.GeoIPPaid</code> (GeoIP2) — both read a local <code>.mmdb</code> file — or the opt-in <code>sentinel.GeoIPAPI</code> (ip-api.com).</td>
+          </tr>
+          <tr>
+            <td><code>DatabasePath</code></td>
+            <td><code>string</code></td>
+            <td>—</td>
+            <td>City or Country <code>.mmdb</code> file for the MaxMind providers.</td>
+          </tr>
+          <tr>
+            <td><code>ASNDatabasePath</code></td>
+            <td><code>string</code></td>
+            <td>—</td>
+            <td>Optional ASN/ISP <code>.mmdb</code> file.</td>
+          </tr>
+          <tr>
+            <td><code>ReloadInterval</code></td>
+            <td><code>time.Duration</code></td>
+            <td><code>1m</code></td>
+            <td>How often the files are checked for changes and hot-reloaded.</td>
           </tr>
         </tbody>
       </table>
@@ -1232,8 +1468,77 @@ Storage: sentinel.StorageConfig{
         language="go"
         filename="config.go"
         code={`Geo: sentinel.GeoConfig{
-    Enabled:  true,
-    Provider: sentinel.GeoIPFree,
+    Enabled:      true,
+    Provider:     sentinel.GeoIPFree,
+    DatabasePath: "/var/lib/GeoIP/GeoLite2-City.mmdb",
+}`}
+      />
+
+      <h3>GeoFence</h3>
+      <p>
+        <code>GeoFenceConfig.Policies</code> restricts routes to, or bars them from, countries and
+        regions. Policies need <code>Geo</code> enabled and can also be edited from the dashboard.
+        See <a href="/docs/threat-intelligence#geofencing">Geofencing</a>.
+      </p>
+      <table>
+        <thead>
+          <tr>
+            <th>Field</th>
+            <th>Type</th>
+            <th>Default</th>
+            <th>Description</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr><td><code>ID</code></td><td><code>string</code></td><td>required</td><td>Unique policy name.</td></tr>
+          <tr><td><code>Routes</code></td><td><code>[]string</code></td><td><code>nil</code></td><td>Route patterns covered. Empty covers every route.</td></tr>
+          <tr><td><code>Allow</code> / <code>Deny</code></td><td><code>[]string</code></td><td><code>nil</code></td><td>ISO 3166-1 country or ISO 3166-2 region codes. At least one list is required.</td></tr>
+          <tr><td><code>Mode</code></td><td><code>WAFMode</code></td><td><code>ModeBlock</code></td><td><code>ModeBlock</code>, <code>ModeChallenge</code> or <code>ModeLog</code>.</td></tr>
+          <tr><td><code>FailClosed</code></td><td><code>bool</code></td><td><code>false</code></td><td>Refuse clients whose location cannot be resolved.</td></tr>
+        </tbody>
+      </table>
+
+      <CodeBlock
+        language="go"
+        filename="config.go"
+        code={`GeoFence: sentinel.GeoFenceConfig{
+    Policies: []sentinel.GeoPolicy{
+        {ID: "sanctions", Deny: []string{"KP", "IR", "UA-43"}},
+        {ID: "admin", Routes: []string{"/admin/**"}, Allow: []string{"DE"}, FailClosed: true},
+    },
+}`}
+      />
+
+      <h3>Networks</h3>
+      <p>
+        <code>NetworkConfig</code> classifies each client as residential, hosting, VPN or Tor from
+        local list files. See <a href="/docs/threat-intelligence#network-types">Network Types</a>.
+      </p>
+      <table>
+        <thead>
+          <tr>
+            <th>Field</th>
+            <th>Type</th>
+            <th>Default</th>
+            <th>Description</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr><td><code>Enabled</code></td><td><code>bool</code></td><td><code>false</code></td><td>Enables network type detection.</td></tr>
+          <tr><td><code>TorExitPaths</code> / <code>VPNPaths</code> / <code>HostingPaths</code></td><td><code>[]string</code></td><td><code>nil</code></td><td>Files of addresses and CIDR prefixes, one per line. An IP on several lists takes the first of Tor, VPN, hosting.</td></tr>
+          <tr><td><code>Refresh</code></td><td><code>time.Duration</code></td><td><code>1h</code></td><td>How often the files are checked for changes.</td></tr>
+          <tr><td><code>ScoreBoost</code></td><td><code>map[NetworkType]int</code></td><td>Tor 20, VPN 10, hosting 5</td><td>Risk score points for actors seen on each type.</td></tr>
+          <tr><td><code>ExcludeRoutes</code></td><td><code>[]string</code></td><td><code>nil</code></td><td>Route patterns whose requests are not classified.</td></tr>
+        </tbody>
+      </table>
+
+      <CodeBlock
+        language="go"
+        filename="config.go"
+        code={`Networks: sentinel.NetworkConfig{
+    Enabled:      true,
+    TorExitPaths: []string{"/var/lib/sentinel/tor-exits.txt"},
+    HostingPaths: []string{"/var/lib/sentinel/datacenters.txt"},
 }`}
       />
 
@@ -1872,6 +2177,11 @@ UserExtractor: func(c *gin.Context) *sentinel.UserContext {
             <td><code>Provider</code></td>
             <td><code>sentinel.GeoIPFree</code></td>
           </tr>
+          <tr>
+            <td>Geo</td>
+            <td><code>ReloadInterval</code></td>
+            <td><code>1m</code></td>
+          </tr>
           <tr>
             <td>Alerts</td>
             <td><code>MinSeverity</code></td>
@@ -1924,6 +2234,7 @@ sentinel.Mount(r, nil, sentinel.Config{
     // RateLimit: disabled
     // AuthShield: disabled
     // Headers:   enabled with safe defaults
+    // DLP:       disabled
     // Anomaly:   disabled
     // Performance: enabled
 })`}
@@ -179,6 +179,57 @@ ByRoute: map[string]sentinel.Limit{
 },`}
       />
 
+      <h3>Per-Fingerprint (<code>ByFingerprint</code>)</h3>
+      <p>
+        One counter per TLS client fingerprint (JA4, else JA3), shared by every IP that presents it.
+        A bot farm rotating IPs with one TLS stack spends one budget. Requests without a
+        fingerprint are not counted. Fingerprints on <code>Fingerprint.Allow</code> are exempt.
+        This requires <code>Fingerprint.Source</code>; see{' '}
+        <a href="/docs/threat-intelligence#tls-fingerprinting">TLS Fingerprinting</a>.
+      </p>
+      <CodeBlock
+        language="go"
+        showLineNumbers={false}
+        code={`// 600 requests per minute per TLS client fingerprint
+ByFingerprint: &sentinel.Limit{Requests: 600, Window: time.Minute}`}
+      />
+
+      <h3>Per-Bot-Verdict (<code>ByBot</code>, <code>ExemptBots</code>)</h3>
+      <p>
+        Keys a limit on the verdict of the{' '}
+        <a href="/docs/waf#bots">bot detector</a>: each IP with that verdict gets its own counter
+        under the verdict&apos;s limit, on top of <code>ByIP</code>. Verdicts in{' '}
+        <code>ExemptBots</code> skip every limit, which keeps verified search engine crawlers out of
+        the budget meant for people. Both require <code>Bots.Enabled</code>.
+      </p>
+      <CodeBlock
+        language="go"
+        showLineNumbers={false}
+        code={`// Scripts and headless browsers get 30 requests per minute per IP;
+// DNS-verified crawlers are never limited.
+ByBot: map[sentinel.BotVerdict]sentinel.Limit{
+    sentinel.BotUnverified: {Requests: 30, Window: time.Minute},
+},
+ExemptBots: []sentinel.BotVerdict{sentinel.BotVerified},`}
+      />
+
+      <h3>Per-Network-Type (<code>ByNetwork</code>)</h3>
+      <p>
+        Keys a limit on the request&apos;s{' '}
+        <a href="/docs/threat-intelligence#network-types">network type</a> — residential, hosting,
+        VPN or Tor. Each IP of that type gets its own counter under the type&apos;s limit, on top
+        of <code>ByIP</code>. Requires <code>Networks.Enabled</code>.
+      </p>
+      <CodeBlock
+        language="go"
+        showLineNumbers={false}
+        code={`// Datacenter and Tor clients get a tighter budget than people at home.
+ByNetwork: map[sentinel.NetworkType]sentinel.Limit{
+    sentinel.NetworkHosting: {Requests: 20, Window: time.Minute},
+    sentinel.NetworkTor:     {Requests: 10, Window: time.Minute},
+},`}
+      />
+
       <h3>Global</h3>
       <p>
         A single counter shared across all requests regardless of source. This is a safety net to
@@ -260,6 +311,30 @@ Global: &sentinel.Limit{Requests: 5000, Window: time.Minute}`}
             <td><code>nil</code></td>
             <td>Per-user rate limit. Requires a <code>UserIDExtractor</code>.</td>
           </tr>
+          <tr>
+            <td><code>ByFingerprint</code></td>
+            <td><code>*Limit</code></td>
+            <td><code>nil</code></td>
+            <td>Per-TLS-fingerprint rate limit shared across IPs. Requires <code>Fingerprint.Source</code>.</td>
+          </tr>
+          <tr>
+            <td><code>ByBot</code></td>
+            <td><code>map[BotVerdict]Limit</code></td>
+            <td><code>nil</code></td>
+            <td>Per-IP limits for requests with the given bot verdict. Requires <code>Bots.Enabled</code>.</td>
+          </tr>
+          <tr>
+            <td><code>ByNetwork</code></td>
+            <td><code>map[NetworkType]Limit</code></td>
+            <td><code>nil</code></td>
+            <td>Per-IP limits for requests from the given network type. Requires <code>Networks.Enabled</code>.</td>
+          </tr>
+          <tr>
+            <td><code>ExemptBots</code></td>
+            <td><code>[]BotVerdict</code></td>
+            <td><code>nil</code></td>
+            <td>Bot verdicts exempt from every limit, e.g. <code>sentinel.BotVerified</code>.</td>
+          </tr>
           <tr>
             <td><code>ByRoute</code></td>
             <td><code>map[string]Limit</code></td>
@@ -320,7 +395,25 @@ Global: &sentinel.Limit{Requests: 5000, Window: time.Minute}`}
             <td>Checked third. Only applies when <code>ByUser</code> is set and <code>UserIDExtractor</code> returns a non-empty string.</td>
           </tr>
           <tr>
-            <td><strong>4 (lowest)</strong></td>
+            <td><strong>4</strong></td>
+            <td>Per-Bot-Verdict</td>
+            <td><code>bot:verdict:IP</code></td>
+            <td>Only applies when <code>ByBot</code> has a limit for the request&apos;s verdict.</td>
+          </tr>
+          <tr>
+            <td><strong>5</strong></td>
+            <td>Per-Network-Type</td>
+            <td><code>net:type:IP</code></td>
+            <td>Only applies when <code>ByNetwork</code> has a limit for the request&apos;s network type.</td>
+          </tr>
+          <tr>
+            <td><strong>6</strong></td>
+            <td>Per-Fingerprint</td>
+            <td><code>fp:JA4</code></td>
+            <td>Only applies when <code>ByFingerprint</code> is set and the request has a fingerprint that is not allow-listed.</td>
+          </tr>
+          <tr>
+            <td><strong>7 (lowest)</strong></td>
             <td>Global</td>
             <td><code>global</code></td>
             <td>Checked last. A single counter shared across all requests.</td>
@@ -332,7 +425,8 @@ Global: &sentinel.Limit{Requests: 5000, Window: time.Minute}`}
         Route limits do not replace IP or user limits — they are additive. A request to{' '}
         <code>/api/login</code> is checked against the route limit <strong>and</strong> the IP
         limit <strong>and</strong> the user limit <strong>and</strong> the global limit (if all are
-        configured). The request must pass every applicable check.
+        configured). The request must pass every applicable check. The only way around them is
+        <code>ExemptBots</code>, which skips every dimension.
       </Callout>
 
       {/* ------------------------------------------------------------------ */}
@@ -520,6 +614,8 @@ func (rl *RateLimiter) cleanup() {
         You can edit per-route limits directly from the dashboard without restarting your application.
         This is useful for responding to traffic spikes or adjusting thresholds after observing
         real-world patterns.
+        Edited limits are saved to storage and still apply after a restart; routes you never edited
+        keep following your Go configuration.
       </p>
 
       <h3>Reset Individual Counters</h3>
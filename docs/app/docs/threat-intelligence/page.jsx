@@ -158,6 +158,11 @@ export default function ThreatIntelligence() {
             <td><code>int</code></td>
             <td>Abuse confidence score from AbuseIPDB (0-100).</td>
           </tr>
+          <tr>
+            <td><code>JA3</code> / <code>JA4</code></td>
+            <td><code>[]string</code></td>
+            <td>Distinct TLS fingerprints seen from the IP, most recent last, up to 10 each. See <a href="#tls-fingerprinting">TLS Fingerprinting</a>.</td>
+          </tr>
         </tbody>
       </table>
 
@@ -189,6 +194,9 @@ type ThreatActor struct {
     AbuseScore      int         \`json:"abuse_score"\`
     Lat             float64     \`json:"lat"\`
     Lng             float64     \`json:"lng"\`
+    CampaignID      string      \`json:"campaign_id,omitempty"\`
+    JA3             []string    \`json:"ja3,omitempty"\`
+    JA4             []string    \`json:"ja4,omitempty"\`
 }`}
       />
 
@@ -222,6 +230,16 @@ type ThreatActor struct {
             <td>+20</td>
             <td>IP has been flagged by AbuseIPDB (<code>IsKnownBadActor == true</code>).</td>
           </tr>
+          <tr>
+            <td>Threat Feeds</td>
+            <td>+ScoreBoost (default 20)</td>
+            <td>The largest <code>ScoreBoost</code> of the <a href="#threat-feeds">threat feeds</a> that listed the actor (<code>FeedScore</code>).</td>
+          </tr>
+          <tr>
+            <td>Network Type</td>
+            <td>+ScoreBoost (Tor 20, VPN 10, hosting 5)</td>
+            <td>The <a href="#network-types">network type</a> the actor was last seen on (<code>NetworkScore</code>).</td>
+          </tr>
           <tr>
             <td>Recency</td>
             <td>+10</td>
@@ -259,6 +277,12 @@ func ComputeRiskScore(actor *sentinel.ThreatActor) int {
         score += 20
     }
 
+    // Threat feed listings
+    score += actor.FeedScore
+
+    // Anonymizing or hosting network
+    score += actor.NetworkScore
+
     // +10 if attacked in last hour
     if time.Since(actor.LastSeen) < time.Hour {
         score += 10
@@ -283,6 +307,160 @@ func ComputeRiskScore(actor *sentinel.ThreatActor) int {
         <strong>81-100:</strong> Critical risk, consider immediate blocking.
       </Callout>
 
+      {/* ------------------------------------------------------------------ */}
+      {/*  CAMPAIGNS                                                         */}
+      {/* ------------------------------------------------------------------ */}
+
+      <h2 id="campaigns">Campaigns</h2>
+      <p>
+        Actors are keyed by IP, so a scanner rotating through 500 residential IPs shows up as 500
+        low-risk actors. With <code>Campaigns</code> enabled, Sentinel links actors that share
+        signals and groups them into a <code>Campaign</code> with its own risk score. Each signal
+        kind has a weight, counted once per pair of actors:
+      </p>
+      <table>
+        <thead>
+          <tr>
+            <th>Signal</th>
+            <th>Weight</th>
+            <th>Compares</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr>
+            <td><code>payload</code></td>
+            <td>2</td>
+            <td>Matched attack payloads, lowercased, with whitespace collapsed and numbers replaced.</td>
+          </tr>
+          <tr>
+            <td><code>user_agent</code></td>
+            <td>1</td>
+            <td>The exact User-Agent.</td>
+          </tr>
+          <tr>
+            <td><code>headers</code></td>
+            <td>1</td>
+            <td>A hash of the set of request header names (<code>header_fingerprint</code> on WAF events). Go does not keep header order, so only the set is compared.</td>
+          </tr>
+          <tr>
+            <td><code>asn</code></td>
+            <td>1</td>
+            <td>The network, when geolocation has an ASN database.</td>
+          </tr>
+          <tr>
+            <td><code>timing</code></td>
+            <td>1</td>
+            <td>Threats in the same <code>TimingBucket</code>. Never links actors on its own.</td>
+          </tr>
+          <tr>
+            <td><code>tls</code></td>
+            <td>1</td>
+            <td>The TLS fingerprint (JA4, else JA3), when <a href="#tls-fingerprinting">fingerprinting</a> is configured.</td>
+          </tr>
+        </tbody>
+      </table>
+      <p>
+        Two actors are linked when their shared weight reaches <code>LinkScore</code> (default 3)
+        and includes a payload, header set or TLS fingerprint. A User-Agent, ASN and time slot alone
+        never link actors, since unrelated users of one browser on one ISP share them. Linked actors join one campaign. If an actor links two campaigns, they are merged into the
+        larger one. Signals are kept in memory for <code>Window</code> after an actor's last
+        threat. Campaigns, and each actor's <code>campaign_id</code>, are stored.
+      </p>
+      <CodeBlock
+        language="go"
+        showLineNumbers={false}
+        code={`Campaigns: sentinel.CampaignConfig{
+    Enabled:      true,
+    LinkScore:    3,                // shared weight that links two actors
+    Window:       24 * time.Hour,   // how long signals are remembered
+    TimingBucket: 10 * time.Minute, // width of the timing slots
+},`}
+      />
+      <p>
+        A campaign's risk score is +10 per attack type (max 40), +10/+20/+30 for 2, 10 and 100
+        actors, +20 above 100 threats, and +10 if active in the last hour, capped at 100.
+      </p>
+      <Callout type="info" title="Blocking a Campaign">
+        <code>POST /api/campaigns/:id/block</code> blocks every actor of the campaign, and every
+        actor that joins it later, for 24 hours unless <code>{'{"permanent": true}'}</code> is sent.
+        The blocks carry the reason <code>campaign &lt;id&gt;: …</code>.{' '}
+        <code>DELETE /api/campaigns/:id/block</code> lifts only those blocks. IPs that were already
+        blocked or whitelisted keep their own entry.
+      </Callout>
+
+      {/* ------------------------------------------------------------------ */}
+      {/*  TLS FINGERPRINTING                                                */}
+      {/* ------------------------------------------------------------------ */}
+
+      <h2 id="tls-fingerprinting">TLS Fingerprinting</h2>
+      <p>
+        A bot farm can rotate IPs and User-Agents cheaply, but it usually keeps one TLS library.
+        The ClientHello that library sends gives a stable fingerprint. Sentinel computes two
+        standard ones: <strong>JA3</strong> (an MD5 hash) and <strong>JA4</strong> (readable, e.g.{' '}
+        <code>t13d1516h2_8daaf6152771_e5627efa2ab1</code>). GREASE values are ignored, so a
+        client's fingerprint does not change between connections.
+      </p>
+      <p>
+        Sentinel only sees the ClientHello when your application terminates TLS. The{' '}
+        <code>fingerprint</code> package provides a <code>Recorder</code>. It hooks the
+        server&apos;s <code>tls.Config</code> and wraps its listener, and its <code>Source</code>{' '}
+        method plugs into <code>FingerprintConfig</code>:
+      </p>
+      <CodeBlock
+        language="go"
+        filename="main.go"
+        code={`import "github.com/MUKE-coder/sentinel/v2/fingerprint"
+
+rec := fingerprint.NewRecorder()
+
+sentinel.Mount(r, nil, sentinel.Config{
+    Fingerprint: sentinel.FingerprintConfig{
+        Source: rec.Source,
+        Deny:   []string{"t13d3112h2_*"},               // JA3 or JA4; "*" suffix matches a prefix
+        Allow:  []string{"773906b0efdefa24a7f2b8eb6985bf37"}, // exempt from Deny and ByFingerprint
+    },
+    RateLimit: sentinel.RateLimitConfig{
+        Enabled:       true,
+        ByFingerprint: &sentinel.Limit{Requests: 600, Window: time.Minute},
+    },
+})
+
+srv := &http.Server{Addr: ":443", Handler: r, TLSConfig: rec.TLSConfig(nil)}
+ln, _ := net.Listen("tcp", srv.Addr)
+log.Fatal(srv.ServeTLS(rec.Listener(ln), "cert.pem", "key.pem"))`}
+      />
+      <p>
+        <code>rec.TLSConfig(base)</code> clones your config and records every handshake. It still
+        calls any <code>GetConfigForClient</code> you set. <code>rec.Listener</code> forgets a
+        connection&apos;s fingerprint when it closes. Behind a proxy that terminates TLS and
+        forwards fingerprints as headers, use{' '}
+        <code>fingerprint.Headers(&quot;X-JA3&quot;, &quot;X-JA4&quot;)</code> as the source instead.
+      </p>
+      <p>With a source configured, the fingerprint middleware runs before every other Sentinel middleware:</p>
+      <ul>
+        <li>
+          It attaches the fingerprint to the request context. Read it with{' '}
+          <code>fingerprint.FromContext(ctx)</code> or <code>middleware.RequestFingerprint(c)</code>.
+        </li>
+        <li>
+          It refuses <code>Deny</code> matches with <code>403 FINGERPRINT_DENIED</code> and raises a{' '}
+          <code>DeniedFingerprint</code> threat.
+        </li>
+        <li>
+          WAF, rate-limit, DLP and session-risk events record <code>ja3</code> and{' '}
+          <code>ja4</code>. Actor profiles keep the fingerprints they have used, and campaigns link
+          actors on them.
+        </li>
+      </ul>
+      <Callout type="warning" title="Header sources can be spoofed">
+        Clients can set any header they like. Use <code>fingerprint.Headers</code> only when every
+        request passes through the proxy that sets those headers.
+      </Callout>
+      <Callout type="info" title="No HTTP/2 fingerprint">
+        HTTP/2 fingerprints (SETTINGS values, frame and pseudo-header order) are not computed. Go&apos;s
+        HTTP/2 server consumes those frames without exposing them to handlers.
+      </Callout>
+
       {/* ------------------------------------------------------------------ */}
       {/*  IP REPUTATION                                                     */}
       {/* ------------------------------------------------------------------ */}
@@ -359,6 +537,99 @@ func ComputeRiskScore(actor *sentinel.ThreatActor) int {
         low-confidence threats.
       </Callout>
 
+      {/* ------------------------------------------------------------------ */}
+      {/*  THREAT FEEDS                                                      */}
+      {/* ------------------------------------------------------------------ */}
+
+      <h2 id="threat-feeds">Threat Feeds</h2>
+      <p>
+        AbuseIPDB is asked about an IP after it has attacked. Threat feeds work the other way round:
+        blocklists such as Spamhaus DROP, FireHOL or your own STIX exports are loaded into memory
+        up front, so a listed client is recognized on its first request. Each feed is read from a
+        file or URL, refreshed on its own schedule, and held in a radix tree, so a lookup costs the
+        same with ten entries or a million. Feeds work without <code>IPReputation.Enabled</code>,
+        which only governs AbuseIPDB.
+      </p>
+      <CodeBlock
+        language="go"
+        filename="main.go"
+        code={`IPReputation: sentinel.IPReputationConfig{
+    Feeds: []sentinel.ThreatFeed{
+        {
+            Name:    "spamhaus-drop",
+            URL:     "https://www.spamhaus.org/drop/drop.txt",
+            Refresh: 12 * time.Hour,
+            Expiry:  72 * time.Hour, // drop the list if it stops updating
+        },
+        {
+            Name:   "internal-watchlist",
+            Path:   "/etc/sentinel/watchlist.json",
+            Format: sentinel.FeedSTIX,
+            Action: sentinel.FeedActionFlag,
+        },
+        {
+            Name:       "scanners",
+            Path:       "/etc/sentinel/scanners.csv",
+            Format:     sentinel.FeedCSV,
+            Column:     1,
+            Action:     sentinel.FeedActionScore,
+            ScoreBoost: 30,
+        },
+    },
+},`}
+      />
+      <table>
+        <thead>
+          <tr>
+            <th>Format</th>
+            <th>Reads</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr><td><code>FeedText</code> (default)</td><td>One IP, CIDR or domain per line. Text after <code>#</code> or <code>;</code> is ignored, and hosts-file lines (<code>0.0.0.0 example.com</code>) yield the domain.</td></tr>
+          <tr><td><code>FeedCSV</code></td><td>The indicator in column <code>Column</code> (zero-based). A header row is skipped.</td></tr>
+          <tr><td><code>FeedSTIX</code></td><td>A STIX 2.1 bundle. Indicators whose pattern only compares <code>ipv4-addr</code>, <code>ipv6-addr</code> or <code>domain-name</code> values, joined by <code>OR</code>, are used. Patterns combining them with other conditions are skipped. Revoked indicators are dropped, and <code>valid_until</code> is honored.</td></tr>
+        </tbody>
+      </table>
+      <table>
+        <thead>
+          <tr>
+            <th>Action</th>
+            <th>Effect</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr><td><code>FeedActionBlock</code> (default)</td><td>403 with <code>IP_BLOCKED</code>, and a blocked <code>ThreatIntelMatch</code> threat. <code>IPManager.IsBlocked</code> reports the IP as blocked.</td></tr>
+          <tr><td><code>FeedActionFlag</code></td><td>The request goes through; a <code>ThreatIntelMatch</code> threat is recorded.</td></tr>
+          <tr><td><code>FeedActionScore</code></td><td>The request goes through, and only the events it raises for other reasons are tagged.</td></tr>
+        </tbody>
+      </table>
+      <p>
+        IP and CIDR indicators are matched against the client IP. Domain indicators are matched
+        against the hosts of the <code>Referer</code> and <code>Origin</code> headers, including
+        subdomains. When several feeds match, the most severe action wins. Every threat event of a
+        matching request carries <code>feed_source</code> and <code>feed_score</code>. The actor
+        keeps the feed in <code>threat_feeds</code>, and the feed&apos;s <code>ScoreBoost</code> is
+        added to its risk score.
+      </p>
+      <p>
+        Whitelisted IPs are never matched. <code>GET /sentinel/api/ip/:ip/status</code> shows the
+        feed entry blocking an IP, with the feed named in <code>Reason</code>.
+      </p>
+      <p>
+        URL feeds are fetched with conditional GETs, so an unchanged list costs a 304. A failed
+        refresh keeps the previous indicators. Files load before <code>Mount</code> returns; URLs
+        load in the background. <code>GET /sentinel/api/intel/feeds</code> reports each feed&apos;s
+        indicator count, last load, last error and match count.{' '}
+        <code>POST /sentinel/api/intel/feeds/:name/refresh</code> reloads a feed now; it needs the
+        admin role.
+      </p>
+      <Callout type="warning" title="Third-Party Lists Block Real Users">
+        A feed listing a shared address, such as a carrier NAT or a VPN exit, blocks everyone behind
+        it. Start new feeds on <code>FeedActionFlag</code>, and whitelist addresses you must never
+        block.
+      </Callout>
+
       {/* ------------------------------------------------------------------ */}
       {/*  GEOLOCATION                                                       */}
       {/* ------------------------------------------------------------------ */}
@@ -391,7 +662,29 @@ func ComputeRiskScore(actor *sentinel.ThreatActor) int {
             <td><code>Provider</code></td>
             <td><code>GeoProvider</code></td>
             <td><code>GeoIPFree</code></td>
-            <td>The geolocation provider. Default uses the free <code>ip-api.com</code> service (no API key required).</td>
+            <td>
+              <code>GeoIPFree</code> / <code>GeoIPPaid</code> read a local MaxMind DB (GeoLite2 or
+              GeoIP2). <code>GeoIPAPI</code> queries <code>ip-api.com</code> and must be chosen
+              explicitly.
+            </td>
+          </tr>
+          <tr>
+            <td><code>DatabasePath</code></td>
+            <td><code>string</code></td>
+            <td>—</td>
+            <td>Path to a City or Country <code>.mmdb</code> file.</td>
+          </tr>
+          <tr>
+            <td><code>ASNDatabasePath</code></td>
+            <td><code>string</code></td>
+            <td>—</td>
+            <td>Optional GeoLite2-ASN or GeoIP2-ISP <code>.mmdb</code> file for ASN and ISP.</td>
+          </tr>
+          <tr>
+            <td><code>ReloadInterval</code></td>
+            <td><code>time.Duration</code></td>
+            <td><code>1m</code></td>
+            <td>How often the files are checked for changes. A replaced file is loaded without a restart; negative disables reloading.</td>
           </tr>
         </tbody>
       </table>
@@ -401,15 +694,17 @@ func ComputeRiskScore(actor *sentinel.ThreatActor) int {
         filename="main.go"
         code={`sentinel.Mount(r, nil, sentinel.Config{
     Geo: sentinel.GeoConfig{
-        Enabled:  true,
-        Provider: sentinel.GeoIPFree, // Free provider, no API key needed
+        Enabled:         true,
+        Provider:        sentinel.GeoIPFree,
+        DatabasePath:    "/var/lib/GeoIP/GeoLite2-City.mmdb",
+        ASNDatabasePath: "/var/lib/GeoIP/GeoLite2-ASN.mmdb",
     },
 })`}
       />
 
       <p>
-        Geolocation results are cached in an LRU cache (default 10,000 entries) to minimize
-        external API calls. Private and loopback IPs (e.g., <code>127.0.0.1</code>,{' '}
+        Geolocation results are cached in an LRU cache (default 10,000 entries) in front of
+        either provider; the cache is cleared when a database file is reloaded. Private and loopback IPs (e.g., <code>127.0.0.1</code>,{' '}
         <code>10.x.x.x</code>, <code>192.168.x.x</code>) are skipped automatically.
       </p>
 
@@ -433,6 +728,11 @@ func ComputeRiskScore(actor *sentinel.ThreatActor) int {
             <td><code>string</code></td>
             <td>ISO 3166-1 alpha-2 country code (e.g., <code>US</code>).</td>
           </tr>
+          <tr>
+            <td><code>RegionCode</code></td>
+            <td><code>string</code></td>
+            <td>ISO 3166-2 code of the first subdivision (e.g., <code>US-CA</code>), when known.</td>
+          </tr>
           <tr>
             <td><code>City</code></td>
             <td><code>string</code></td>
@@ -456,10 +756,244 @@ func ComputeRiskScore(actor *sentinel.ThreatActor) int {
         </tbody>
       </table>
 
-      <Callout type="info" title="Production Geolocation">
-        The free <code>ip-api.com</code> provider is rate-limited to 45 requests per minute. For
-        production workloads with high traffic, consider using a local MaxMind GeoLite2 database
-        for offline lookups with no rate limits.
+      <Callout type="info" title="Keeping the database fresh">
+        Lookups never leave the process with the MMDB providers. Run <code>geoipupdate</code> (or
+        any job that replaces the file) on a schedule and Sentinel picks up the new file within{' '}
+        <code>ReloadInterval</code>. A missing or corrupt file fails <code>Mount</code>; a corrupt
+        replacement at runtime is logged and the loaded version is kept. The opt-in{' '}
+        <code>GeoIPAPI</code> provider sends every looked-up IP to <code>ip-api.com</code> over
+        plain HTTP and is rate-limited to 45 requests per minute.
+      </Callout>
+
+      {/* ------------------------------------------------------------------ */}
+      {/*  GEOFENCING                                                        */}
+      {/* ------------------------------------------------------------------ */}
+
+      <h2 id="geofencing">Geofencing</h2>
+      <p>
+        Geofencing policies restrict where clients may reach your application from. Each policy
+        covers a set of routes and lists the countries (ISO 3166-1, e.g. <code>DE</code>) or
+        regions (ISO 3166-2, e.g. <code>UA-43</code>) it allows or denies. A deny list refuses
+        the listed locations; an allow list refuses everything it does not list. Region codes need
+        the MaxMind City database or the <code>GeoIPAPI</code> provider. Every refusal raises a{' '}
+        <code>GeoFenceViolation</code> threat event whose evidence names the policy
+        (<code>GeoFence_&lt;id&gt;</code>) and the resolved location.
+      </p>
+
+      <h3>GeoPolicy</h3>
+      <table>
+        <thead>
+          <tr>
+            <th>Field</th>
+            <th>Type</th>
+            <th>Default</th>
+            <th>Description</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr>
+            <td><code>ID</code></td>
+            <td><code>string</code></td>
+            <td>—</td>
+            <td>Required. Names the policy in events, the API and the dashboard.</td>
+          </tr>
+          <tr>
+            <td><code>Routes</code></td>
+            <td><code>[]string</code></td>
+            <td>all routes</td>
+            <td>Route patterns the policy covers, in the same syntax as <code>ExcludeRoutes</code>.</td>
+          </tr>
+          <tr>
+            <td><code>Allow</code></td>
+            <td><code>[]string</code></td>
+            <td>—</td>
+            <td>Country or region codes allowed; any other location is refused.</td>
+          </tr>
+          <tr>
+            <td><code>Deny</code></td>
+            <td><code>[]string</code></td>
+            <td>—</td>
+            <td>Country or region codes refused. Checked before <code>Allow</code>.</td>
+          </tr>
+          <tr>
+            <td><code>Mode</code></td>
+            <td><code>WAFMode</code></td>
+            <td><code>ModeBlock</code></td>
+            <td>
+              <code>ModeBlock</code> answers 403 (<code>GEO_BLOCKED</code>),{' '}
+              <code>ModeChallenge</code> serves the WAF challenge page, <code>ModeLog</code> only
+              records the event.
+            </td>
+          </tr>
+          <tr>
+            <td><code>FailClosed</code></td>
+            <td><code>bool</code></td>
+            <td><code>false</code></td>
+            <td>
+              Refuse clients whose location cannot be resolved — lookup errors, private addresses,
+              or addresses missing from the database. By default they are let through.
+            </td>
+          </tr>
+        </tbody>
+      </table>
+
+      <CodeBlock
+        language="go"
+        filename="main.go"
+        code={`sentinel.Mount(r, nil, sentinel.Config{
+    Geo: sentinel.GeoConfig{
+        Enabled:      true,
+        DatabasePath: "/var/lib/GeoIP/GeoLite2-City.mmdb",
+    },
+    GeoFence: sentinel.GeoFenceConfig{
+        Policies: []sentinel.GeoPolicy{
+            // Sanctioned regions are refused everywhere.
+            {ID: "sanctions", Deny: []string{"KP", "IR", "CU", "SY", "UA-43"}},
+            // The admin area is only reachable from Germany and Austria.
+            {
+                ID:         "admin",
+                Routes:     []string{"/admin/**"},
+                Allow:      []string{"DE", "AT"},
+                Mode:       sentinel.ModeChallenge,
+                FailClosed: true,
+            },
+        },
+    },
+})`}
+      />
+
+      <p>
+        When several policies refuse a request, the strictest mode wins (block, then challenge,
+        then log). Whitelisted IPs and the dashboard are never fenced, so a policy cannot lock you
+        out of the dashboard. The geofence runs ahead of the WAF and rate
+        limiter, and shares the WAF&apos;s challenger, so a client that clears one challenge passes
+        both. Policies can be added, replaced and deleted from the dashboard; edits are versioned
+        with the rest of the configuration and can be rolled back.
+      </p>
+
+      <Callout type="warning" title="Fail-open allow lists">
+        An allow-list policy that fails open lets through every client it cannot locate.
+        <code>ValidateConfig</code> warns about this; set <code>FailClosed</code> on policies that
+        guard sensitive routes, and whitelist your own internal ranges so they are never refused.
+      </Callout>
+
+      {/* ------------------------------------------------------------------ */}
+      {/*  NETWORK TYPES                                                     */}
+      {/* ------------------------------------------------------------------ */}
+
+      <h2 id="network-types">Network Types</h2>
+      <p>
+        Sentinel can tell whether a client connects from a residential network, a hosting
+        provider, a VPN or a Tor exit node. The lists come from local files — the Tor
+        Project&apos;s bulk exit list, a datacenter range list, a commercial VPN list — in the
+        same one-entry-per-line format as <a href="#threat-feeds">threat feeds</a>. Each file is
+        re-read when it changes. An IP on several lists takes the most anonymizing type: Tor,
+        then VPN, then hosting. Any other public IP is residential; private and loopback
+        addresses have no network type.
+      </p>
+      <p>
+        Every threat event and threat actor carries the <code>NetworkType</code>, and the
+        type&apos;s <code>ScoreBoost</code> is added to the actor&apos;s{' '}
+        <a href="#risk-scoring">risk score</a>. <code>WAF.NetworkPolicy</code> can log, challenge
+        or block each type, and <code>RateLimit.ByNetwork</code> can give each type its own per-IP
+        limit.
+      </p>
+
+      <h3>NetworkConfig</h3>
+      <table>
+        <thead>
+          <tr>
+            <th>Field</th>
+            <th>Type</th>
+            <th>Default</th>
+            <th>Description</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr>
+            <td><code>Enabled</code></td>
+            <td><code>bool</code></td>
+            <td><code>false</code></td>
+            <td>Turn on network type detection.</td>
+          </tr>
+          <tr>
+            <td><code>TorExitPaths</code></td>
+            <td><code>[]string</code></td>
+            <td><code>nil</code></td>
+            <td>Files of Tor exit node addresses.</td>
+          </tr>
+          <tr>
+            <td><code>VPNPaths</code></td>
+            <td><code>[]string</code></td>
+            <td><code>nil</code></td>
+            <td>Files of VPN provider addresses and CIDR prefixes.</td>
+          </tr>
+          <tr>
+            <td><code>HostingPaths</code></td>
+            <td><code>[]string</code></td>
+            <td><code>nil</code></td>
+            <td>Files of datacenter and hosting provider CIDR prefixes.</td>
+          </tr>
+          <tr>
+            <td><code>Refresh</code></td>
+            <td><code>time.Duration</code></td>
+            <td><code>1h</code></td>
+            <td>How often the files are checked for changes.</td>
+          </tr>
+          <tr>
+            <td><code>ScoreBoost</code></td>
+            <td><code>map[NetworkType]int</code></td>
+            <td>Tor 20, VPN 10, hosting 5</td>
+            <td>Risk score points for actors seen on each type. Negative disables the boost.</td>
+          </tr>
+          <tr>
+            <td><code>ExcludeRoutes</code></td>
+            <td><code>[]string</code></td>
+            <td><code>nil</code></td>
+            <td>Route patterns whose requests are not classified.</td>
+          </tr>
+        </tbody>
+      </table>
+
+      <CodeBlock
+        language="go"
+        filename="main.go"
+        code={`sentinel.Mount(r, nil, sentinel.Config{
+    Networks: sentinel.NetworkConfig{
+        Enabled:      true,
+        TorExitPaths: []string{"/var/lib/sentinel/tor-exits.txt"},
+        VPNPaths:     []string{"/var/lib/sentinel/vpn.txt"},
+        HostingPaths: []string{"/var/lib/sentinel/datacenters.txt"},
+    },
+    WAF: sentinel.WAFConfig{
+        Enabled: true,
+        Mode:    sentinel.ModeBlock,
+        NetworkPolicy: map[sentinel.NetworkType]sentinel.WAFMode{
+            sentinel.NetworkTor: sentinel.ModeChallenge,
+            sentinel.NetworkVPN: sentinel.ModeLog,
+        },
+    },
+    RateLimit: sentinel.RateLimitConfig{
+        Enabled: true,
+        ByIP:    &sentinel.Limit{Requests: 100, Window: time.Minute},
+        ByNetwork: map[sentinel.NetworkType]sentinel.Limit{
+            sentinel.NetworkHosting: {Requests: 20, Window: time.Minute},
+        },
+    },
+})`}
+      />
+
+      <p>
+        A list that fails to load keeps its previous entries and reports the error. The lists and
+        their match counts are listed at <code>GET /api/intel/networks</code>, and{' '}
+        <code>POST /api/intel/networks/reload</code> re-reads every file at once — handy after a
+        cron job has fetched a fresh copy.
+      </p>
+
+      <Callout type="warning" title="Hosting is not hostile">
+        Search engine crawlers, uptime monitors and your own services run from hosting
+        providers. Verified crawlers allowed by <code>WAF.BotPolicy</code> skip the network
+        policy; whitelist other known services rather than blocking hosting outright.
       </Callout>
 
       {/* ------------------------------------------------------------------ */}
@@ -468,15 +1002,37 @@ func ComputeRiskScore(actor *sentinel.ThreatActor) int {
 
       <h2 id="ip-management">IP Management</h2>
       <p>
-        The IP Manager provides a centralized system for blocking and whitelisting IP addresses and
-        CIDR ranges. It maintains an in-memory cache that syncs from storage every 30 seconds,
-        ensuring fast per-request lookups with no database overhead on the hot path.
+        The IP Manager provides a centralized system for blocking and whitelisting IP addresses,
+        CIDR ranges and whole autonomous systems. It maintains an in-memory cache that syncs from
+        storage every 30 seconds, ensuring fast per-request lookups with no database overhead on the
+        hot path.
+      </p>
+
+      <h3>Entry Shapes</h3>
+      <p>
+        Block list, whitelist and <code>WAF.ExcludeIPs</code> entries all take the same three shapes.
+        Entries are stored in canonical form, so <code>10.1.2.3/16</code> is saved as{' '}
+        <code>10.1.0.0/16</code> and <code>as64496</code> as <code>AS64496</code>.
       </p>
+      <table>
+        <thead>
+          <tr>
+            <th>Shape</th>
+            <th>Examples</th>
+            <th>Covers</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr><td>Address</td><td><code>203.0.113.7</code>, <code>2001:db8::1</code></td><td>That address. <code>/32</code> and <code>/128</code> prefixes are stored as bare addresses.</td></tr>
+          <tr><td>CIDR prefix</td><td><code>198.51.100.0/24</code>, <code>2001:db8::/32</code></td><td>Every address in the range, IPv4 or IPv6.</td></tr>
+          <tr><td>ASN</td><td><code>AS64496</code></td><td>Every address the autonomous system announces. Needs geolocation with a local ASN database (<code>Geo.ASNDatabasePath</code>). The ip-api provider is never asked, so ASN entries are refused with it.</td></tr>
+        </tbody>
+      </table>
 
       <h3>Blocking IPs</h3>
       <p>
-        Blocked IPs are rejected by the middleware before reaching your application handlers. You
-        can block individual IPs or entire CIDR ranges. Blocks can optionally have an expiration time.
+        Blocked IPs are rejected by the middleware before reaching your application handlers. Blocks
+        can optionally have an expiration time.
       </p>
       <CodeBlock
         language="go"
@@ -486,6 +1042,9 @@ ipManager.BlockIP(ctx, "203.0.113.50", "Repeated SQL injection attempts", nil)
 // Block a CIDR range
 ipManager.BlockIP(ctx, "198.51.100.0/24", "Known botnet range", nil)
 
+// Block a hosting provider's network
+ipManager.BlockIP(ctx, "AS64496", "Scraper farm", nil)
+
 // Block with expiration (auto-unblock after 24 hours)
 expiry := time.Now().Add(24 * time.Hour)
 ipManager.BlockIP(ctx, "203.0.113.75", "Temporary block", &expiry)`}
@@ -493,27 +1052,69 @@ ipManager.BlockIP(ctx, "203.0.113.75", "Temporary block", &expiry)`}
 
       <h3>Whitelisting IPs</h3>
       <p>
-        Whitelisted IPs bypass all security checks including the WAF, rate limiting, and Auth
-        Shield. Use this for trusted internal services, monitoring systems, and CI/CD pipelines.
+        Whitelisted IPs are exempt from the block list, auto-blocking and threat feeds. Whitelist
+        entries do not expire. To also skip WAF inspection for trusted clients such as monitoring
+        systems or CI/CD pipelines, list them in <code>WAF.ExcludeIPs</code>.
       </p>
       <CodeBlock
         language="go"
-        code={`// Whitelist a trusted IP
-ipManager.WhitelistIP(ctx, "10.0.0.5")`}
+        code={`// Whitelist a trusted IP, range or network
+ipManager.WhitelistIP(ctx, "10.0.0.5")
+ipManager.WhitelistIP(ctx, "192.0.2.0/24")
+
+// Remove it again
+ipManager.UnwhitelistIP(ctx, "192.0.2.0/24")`}
       />
 
+      <h3>Overlapping Entries</h3>
+      <p>
+        When several entries cover an address, the most specific one decides:
+      </p>
+      <ul>
+        <li>A longer prefix beats a shorter one, and any address or prefix beats an ASN.</li>
+        <li>On a tie — the same entry on both lists — the whitelist wins.</li>
+        <li>
+          An expired block covers nothing, so it never shadows a shorter live block. Expiry is
+          checked on every lookup, not only at the next sync.
+        </li>
+        <li>Threat feeds rank below every entry: any matching whitelist entry overrides them.</li>
+      </ul>
+      <table>
+        <thead>
+          <tr>
+            <th>Entries</th>
+            <th>Client</th>
+            <th>Result</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr><td>Block <code>10.1.0.0/16</code>, whitelist <code>10.1.2.3</code></td><td><code>10.1.2.3</code></td><td>Allowed</td></tr>
+          <tr><td>Whitelist <code>192.168.0.0/16</code>, block <code>192.168.5.5</code></td><td><code>192.168.5.5</code></td><td>Blocked</td></tr>
+          <tr><td>Block <code>AS64496</code>, whitelist <code>81.2.69.0/24</code></td><td><code>81.2.69.160</code> in AS64496</td><td>Allowed</td></tr>
+          <tr><td>Block <code>172.16.0.0/12</code>, expired block <code>172.16.1.0/24</code></td><td><code>172.16.1.1</code></td><td>Blocked by the /12</td></tr>
+        </tbody>
+      </table>
+      <p>
+        Unblocking removes only the entry itself: unblocking an address inside a blocked range leaves
+        the range blocked. To let one address through a blocked range, whitelist it.{' '}
+        <code>GET /sentinel/api/ip/:ip/status</code> shows which entry decides a given address.
+      </p>
+
       <h3>How the Cache Works</h3>
       <p>
-        The IP Manager loads all blocked and whitelisted IPs into memory at startup. A background
+        The IP Manager loads all blocked and whitelisted entries into memory at startup. A background
         goroutine re-syncs from storage every 30 seconds to pick up changes made via the API or
         dashboard. Blocking and whitelisting operations update both storage and the in-memory cache
         immediately, so changes take effect without waiting for the next sync cycle.
       </p>
 
-      <Callout type="warning" title="CIDR Matching">
-        When you block a CIDR range like <code>198.51.100.0/24</code>, every IP within that range
-        is matched. The IP Manager parses CIDR notation and performs subnet containment checks.
-        Individual IP blocks use exact string matching for maximum performance.
+      <Callout type="info" title="Radix Tree Lookups">
+        Addresses and prefixes are held in a radix tree per address family, so a lookup costs at
+        most one step per prefix bit — about half a microsecond with tens of thousands of entries.
+        ASN entries add one lookup in the local ASN database, and only for clients no address or
+        prefix entry covers; it never leaves the process. Storage lookups (<code>IsIPBlocked</code>, used by the WAF when no IP Manager
+        is wired) apply the same overlap rules with one indexed query per list, but ignore ASN
+        entries.
       </Callout>
 
       {/* ------------------------------------------------------------------ */}
@@ -555,8 +1156,9 @@ func main() {
 
         // Geolocation for geographic attribution
         Geo: sentinel.GeoConfig{
-            Enabled:  true,
-            Provider: sentinel.GeoIPFree,
+            Enabled:      true,
+            Provider:     sentinel.GeoIPFree,
+            DatabasePath: "/var/lib/GeoIP/GeoLite2-City.mmdb",
         },
     })
 
@@ -838,7 +1440,9 @@ curl -s -H "Authorization: Bearer <token>" \\
 
       <h3>Block IP</h3>
       <p>
-        Adds an IP or CIDR range to the blocklist.
+        Adds an IP, CIDR range or ASN to the blocklist. The entry is validated and stored in
+        canonical form, which the response echoes as <code>ip</code>; an unparseable entry is
+        rejected with 400.
       </p>
       <table>
         <thead>
@@ -909,6 +1513,51 @@ curl -s -X DELETE -H "Authorization: Bearer <token>" \\
   "http://localhost:8080/sentinel/api/ip/block/198.51.100.0_24"`}
       />
 
+      <h3>Whitelist</h3>
+      <p>
+        Lists, adds and removes whitelist entries — IPs, CIDR ranges or ASNs. Adding and removing
+        entries is admin only.
+      </p>
+      <table>
+        <thead>
+          <tr>
+            <th>Endpoint</th>
+            <th>Description</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr><td><code>GET /sentinel/api/ip/whitelisted</code></td><td>All whitelist entries with <code>ip</code>, <code>whitelisted_at</code> and <code>cidr</code>.</td></tr>
+          <tr><td><code>POST /sentinel/api/ip/whitelist</code></td><td>Body <code>{"{"}"ip": "..."{"}"}</code>. Returns the canonical entry.</td></tr>
+          <tr><td><code>DELETE /sentinel/api/ip/whitelist/:ip</code></td><td>Removes an entry; write <code>/</code> as <code>_</code> for CIDR ranges.</td></tr>
+        </tbody>
+      </table>
+      <CodeBlock
+        language="bash"
+        showLineNumbers={false}
+        code={`# Let one host through a blocked range
+curl -s -X POST -H "Authorization: Bearer <token>" \\
+  -H "Content-Type: application/json" \\
+  -d '{"ip": "198.51.100.10"}' \\
+  "http://localhost:8080/sentinel/api/ip/whitelist"
+
+# Check which entry decides an address
+curl -s -H "Authorization: Bearer <token>" \\
+  "http://localhost:8080/sentinel/api/ip/198.51.100.10/status"`}
+      />
+      <CodeBlock
+        language="json"
+        filename="Response"
+        showLineNumbers={false}
+        code={`{
+  "data": {
+    "ip": "198.51.100.10",
+    "blocked": false,
+    "whitelisted": true,
+    "whitelisted_by": { "ip": "198.51.100.10", "whitelisted_at": "2025-01-15T15:00:00Z", "cidr": false }
+  }
+}`}
+      />
+
       <h3>IP Reputation Lookup</h3>
       <p>
         Retrieves the AbuseIPDB reputation data for a specific IP (requires IP reputation to be
@@ -202,6 +202,36 @@ Content-Type: application/json
         then switch to <code>sentinel.ModeBlock</code> when you are confident in the configuration.
       </Callout>
 
+      <h3 id="anomaly-scoring">Anomaly Scoring</h3>
+      <p>
+        By default, block and challenge modes act on the first match, however weak. Set{' '}
+        <code>Scoring</code> to act on a cumulative score instead, in the style of the OWASP Core Rule
+        Set. Every built-in pattern and custom rule that matches adds its points once (Critical 5, High
+        4, Medium 3, Low 2 unless the rule sets <code>Score</code> or you override{' '}
+        <code>SeverityPoints</code>). The request is blocked or challenged only when the total reaches
+        the threshold for its route; below it, the request passes and is recorded as in log mode.
+      </p>
+      <CodeBlock
+        language="go"
+        code={`WAF: sentinel.WAFConfig{
+    Enabled: true,
+    Mode:    sentinel.ModeBlock,
+    Scoring: &sentinel.AnomalyScoringConfig{
+        InboundThreshold: 5, // default: one critical match or two lesser ones
+        RouteThresholds: map[string]int{
+            "/api/cms/**": 10, // rich content: require stronger evidence
+        },
+    },
+}`}
+      />
+      <p>
+        <code>RouteThresholds</code> keys use the same patterns as <code>ExcludeRoutes</code>; an exact
+        key wins, then the longest matching pattern. Each threat event records{' '}
+        <code>anomaly_score</code>, <code>anomaly_threshold</code> and the points of every evidence
+        entry, and <code>POST /api/waf/test</code> reports the score a payload would get, against
+        the threshold of the test request&apos;s <code>path</code>.
+      </p>
+
       {/* ------------------------------------------------------------------ */}
       {/*  BUILT-IN RULES                                                     */}
       {/* ------------------------------------------------------------------ */}
@@ -390,6 +420,21 @@ Content-Type: application/json
             <td><code>bool</code></td>
             <td>Whether the rule is active. Set to <code>false</code> to disable a rule without removing it from the configuration.</td>
           </tr>
+          <tr>
+            <td><code>Transforms</code></td>
+            <td><code>[]Transform</code></td>
+            <td>Normalization applied to each input, in order, before <code>Pattern</code> is matched. See <a href="#transforms">Input Normalization</a>.</td>
+          </tr>
+          <tr>
+            <td><code>Score</code></td>
+            <td><code>int</code></td>
+            <td>Anomaly points the rule adds under <a href="#anomaly-scoring">anomaly scoring</a>. Zero scores the rule by its <code>Severity</code>.</td>
+          </tr>
+          <tr>
+            <td><code>When</code></td>
+            <td><code>*RuleCondition</code></td>
+            <td>Conditions the request must meet. Without a <code>Pattern</code>, the rule fires whenever they hold. See <a href="#virtual-patching">Virtual Patching</a>.</td>
+          </tr>
         </tbody>
       </table>
 
@@ -470,6 +515,107 @@ Content-Type: application/json
         individual custom rules that block, or vice versa. Built-in rules always follow the global mode.
       </Callout>
 
+      <h3 id="virtual-patching">Virtual Patching</h3>
+      <p>
+        A single regex cannot say &quot;block <code>POST /api/v1/export</code> when the{' '}
+        <code>X-Debug</code> header is present and <code>format=sql</code>&quot;. <code>When</code>{' '}
+        can: it is a tree of conditions combined with <code>All</code> (AND), <code>Any</code> (OR)
+        and <code>Not</code>, whose leaves test one property of the request. A rule with{' '}
+        <code>When</code> and no <code>Pattern</code> fires whenever the conditions hold; with both,
+        the pattern is only scanned for on requests that meet the conditions.
+      </p>
+      <CodeBlock
+        language="go"
+        code={`{
+    ID:       "patch-export-debug",
+    Name:     "Block debug SQL export",
+    Severity: sentinel.SeverityHigh,
+    Action:   "block",
+    Enabled:  true,
+    When: &sentinel.RuleCondition{All: []sentinel.RuleCondition{
+        {Type: sentinel.ConditionMethod, Values: []string{"POST"}},
+        {Type: sentinel.ConditionPath, Values: []string{"/api/v1/export"}},
+        {Type: sentinel.ConditionHeader, Name: "X-Debug"}, // exists
+        {Type: sentinel.ConditionParam, Name: "format", Values: []string{"sql"}},
+        {Not: &sentinel.RuleCondition{Type: sentinel.ConditionIP, Values: []string{"10.0.0.0/8"}}},
+    }},
+}`}
+      />
+      <table>
+        <thead>
+          <tr>
+            <th>Type</th>
+            <th>Values</th>
+            <th>Ops</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr><td><code>method</code></td><td>Methods, case-insensitive</td><td><code>equals</code></td></tr>
+          <tr><td><code>path</code></td><td>Route patterns, as in <code>ExcludeRoutes</code></td><td><code>equals</code>, <code>regex</code></td></tr>
+          <tr><td><code>header</code></td><td>Values of the header called <code>Name</code></td><td><code>exists</code>, <code>equals</code>, <code>contains</code>, <code>prefix</code>, <code>regex</code></td></tr>
+          <tr><td><code>param</code></td><td>Values of the query parameter or decoded body field called <code>Name</code> (e.g. <code>user.role</code>)</td><td>as header</td></tr>
+          <tr><td><code>ip</code></td><td>IPs and CIDRs</td><td><code>equals</code></td></tr>
+          <tr><td><code>country</code></td><td>ISO country codes; needs <a href="/docs/threat-intelligence">geolocation</a></td><td><code>equals</code></td></tr>
+          <tr><td><code>body_size</code></td><td>One byte count</td><td><code>gt</code>, <code>lt</code></td></tr>
+        </tbody>
+      </table>
+      <p>
+        A leaf holds when any of its values matches. <code>Op</code> defaults to <code>equals</code>,
+        or to <code>exists</code> for a header or param without values. Rules are checked when they are
+        added, and <code>ValidateConfig</code> reports a rule whose conditions do not compile. For a rule
+        without a pattern, the event's evidence lists the conditions that matched;{' '}
+        <code>POST /api/waf/test</code> reports them for every rule. Pass it a whole{' '}
+        <code>request</code> to try conditions out:
+      </p>
+      <CodeBlock
+        language="bash"
+        showLineNumbers={false}
+        code={`curl -X POST http://localhost:8080/sentinel/api/waf/test \\
+  -H "Authorization: Bearer <token>" -H "Content-Type: application/json" \\
+  -d '{"request": {"method": "POST", "path": "/api/v1/export", "query": "format=sql",
+       "headers": {"X-Debug": ["1"]}, "ip": "203.0.113.7"}}'
+# matches[0].conditions: ["method equals \\"POST\\"", "path equals \\"/api/v1/export\\"",
+#   "header X-Debug exists", "param format equals \\"sql\\"", "not ip equals \\"10.0.0.0/8\\""]`}
+      />
+
+      <h3 id="seclang">Importing ModSecurity Rules</h3>
+      <p>
+        <code>RuleFiles</code> imports existing ModSecurity rules as custom rules at mount. Sentinel
+        understands the subset of SecLang that maps onto a <code>WAFRule</code>:
+      </p>
+      <ul>
+        <li><code>SecRule</code> directives only</li>
+        <li>Variables <code>REQUEST_URI</code> (path and query), <code>ARGS</code> (query and body),{' '}
+          <code>REQUEST_HEADERS</code> and <code>REQUEST_BODY</code>, joined with <code>|</code></li>
+        <li>Operators <code>@rx</code> (RE2 syntax), <code>@pm</code> and <code>@contains</code></li>
+        <li>Actions <code>id</code>, <code>phase:1</code>/<code>phase:2</code>, <code>severity</code>,{' '}
+          <code>msg</code>, <code>block</code>/<code>deny</code>/<code>pass</code> and <code>t:</code>{' '}
+          transforms with a Sentinel equivalent</li>
+      </ul>
+      <CodeBlock
+        language="go"
+        showLineNumbers={false}
+        code={`WAF: sentinel.WAFConfig{
+    Enabled:   true,
+    Mode:      sentinel.ModeBlock,
+    RuleFiles: []string{"/etc/sentinel/rules/app.conf"},
+},
+
+// app.conf
+// SecRule REQUEST_URI|ARGS "@rx (?i)union\\s+select" \\
+//     "id:1001,phase:2,block,severity:CRITICAL,msg:'SQL injection',t:urlDecodeUni,t:lowercase"`}
+      />
+      <p>
+        Imported rules get IDs like <code>secrule-1001</code>. Nothing is skipped silently: other
+        directives, selectors such as <code>ARGS:id</code>, negated or other operators, PCRE-only
+        regex features, response phases and chained rules skip the rule, and unknown actions or
+        transforms are dropped from it. Each case is logged at mount and reported by{' '}
+        <code>ValidateConfig</code>. To see the report in code, call <code>detection.ParseSecLangFile</code>,
+        which returns the rules and a list of issues with line numbers. Imported rules are kept apart
+        from <code>CustomRules</code>: editing rules from the dashboard or rolling back the
+        configuration does not remove them.
+      </p>
+
       {/* ------------------------------------------------------------------ */}
       {/*  EXCLUDING ROUTES AND IPS                                           */}
       {/* ------------------------------------------------------------------ */}
@@ -503,9 +649,11 @@ Content-Type: application/json
 
       <h3>ExcludeIPs</h3>
       <p>
-        Use <code>ExcludeIPs</code> to skip WAF inspection for trusted IP addresses or CIDR ranges.
-        This is useful for internal services, monitoring systems, or CI/CD pipelines that might
-        trigger false positives.
+        Use <code>ExcludeIPs</code> to skip WAF inspection for trusted IP addresses, CIDR ranges
+        (IPv4 or IPv6) or ASNs. This is useful for internal services, monitoring systems, or CI/CD
+        pipelines that might trigger false positives. Ranges are matched with a radix tree, so long
+        lists cost nothing extra per request. ASN entries such as <code>"AS64496"</code> need
+        geolocation with ASN data and are only looked up when no address or range matches.
       </p>
       <CodeBlock
         language="go"
@@ -518,6 +666,8 @@ Content-Type: application/json
         "172.16.0.0/12",   // Docker networks
         "192.168.0.0/16",  // Private network
         "203.0.113.50",    // Monitoring server
+        "2001:db8::/32",   // IPv6 office range
+        "AS64496",         // Uptime monitoring provider
     },
 }`}
       />
@@ -549,6 +699,12 @@ Content-Type: application/json
           <strong>Request Decomposition</strong> — The classifier extracts four components from the
           request: the URL <strong>path</strong>, the raw <strong>query string</strong>, all
           HTTP <strong>headers</strong> (concatenated), and the request <strong>body</strong> (if present).
+          Bodies are also decoded by <code>Content-Type</code> — JSON, urlencoded forms, multipart
+          forms (field values and filenames, never file contents) and XML — and each field is scanned
+          on its own, decoded. Evidence for a field match names it in <code>parameter</code>, e.g.{' '}
+          <code>body.user.name</code>, <code>body.items[0].sku</code> or <code>body.order.@id</code>{' '}
+          for an XML attribute. A payload hidden behind an encoding, such as a JSON{' '}
+          <code>\u0027</code> quote, is caught in the decoded field.
         </li>
         <li>
           <strong>Pattern Matching</strong> — Each extracted component is evaluated against the
@@ -601,6 +757,48 @@ Content-Type: application/json
         requests even under heavy attack traffic.
       </Callout>
 
+      <h3 id="transforms">Input Normalization</h3>
+      <p>
+        Attackers hide payloads behind encodings the regexes do not expect: double URL-encoding,
+        HTML entities, fullwidth or overlong UTF-8 characters, NUL bytes and comment-split keywords
+        such as <code>UN/**/ION</code>. Each built-in pattern and custom rule declares the transforms
+        to run on an input before matching it. The built-in SQLi, XSS, path traversal, command
+        injection, LFI and prototype pollution patterns already do.
+      </p>
+      <table>
+        <thead>
+          <tr>
+            <th>Transform</th>
+            <th>Effect</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr><td><code>urlDecode</code></td><td>Decodes <code>%XX</code>, <code>%uXXXX</code> and <code>+</code>, repeatedly, so double encoding is undone. Malformed escapes are kept.</td></tr>
+          <tr><td><code>htmlEntityDecode</code></td><td>Decodes named and numeric HTML entities.</td></tr>
+          <tr><td><code>normalizeUnicode</code></td><td>Decodes overlong UTF-8 forms of ASCII and applies NFKC (fullwidth <code>＜</code> becomes <code>&lt;</code>).</td></tr>
+          <tr><td><code>removeNulls</code></td><td>Drops NUL bytes.</td></tr>
+          <tr><td><code>removeComments</code></td><td>Drops <code>/* */</code> and <code>&lt;!-- --&gt;</code> comments; keeps the body of MySQL <code>/*! */</code> comments.</td></tr>
+          <tr><td><code>compressWhitespace</code></td><td>Turns each run of whitespace into one space.</td></tr>
+          <tr><td><code>lowercase</code></td><td>Lowercases the value.</td></tr>
+        </tbody>
+      </table>
+      <CodeBlock
+        language="go"
+        code={`sentinel.WAFRule{
+    ID:         "wp-admin-probe",
+    Name:       "WordPress admin probe",
+    Pattern:    \`/wp-(admin|login)\`,
+    Transforms: []sentinel.Transform{sentinel.TransformURLDecode, sentinel.TransformLowercase},
+    Severity:   sentinel.SeverityMedium,
+    Enabled:    true,
+}`}
+      />
+      <p>
+        When a match came from a transformed input, its evidence lists the <code>transforms</code>{' '}
+        applied and <code>matched</code> holds the transformed text, e.g. <code>UNION SELECT</code>{' '}
+        for <code>UN/**/ION%20SELECT</code>.
+      </p>
+
       {/* ------------------------------------------------------------------ */}
       {/*  TESTING THE WAF                                                    */}
       {/* ------------------------------------------------------------------ */}
@@ -854,6 +1052,167 @@ curl -v "http://localhost:8080/api/ping?host=\\\`whoami\\\`"`}
 })`}
       />
 
+      {/* ------------------------------------------------------------------ */}
+      {/*  BOT MANAGEMENT                                                     */}
+      {/* ------------------------------------------------------------------ */}
+
+      <h2 id="bots">Bot Management</h2>
+      <p>
+        With <code>Bots.Enabled</code>, every request gets a bot verdict and a 0–100 bot score
+        before it reaches the WAF. The verdict is recorded on threat events
+        (<code>bot_verdict</code>, <code>bot_score</code>) and can drive both WAF and rate limit
+        policy.
+      </p>
+      <table>
+        <thead>
+          <tr>
+            <th>Verdict</th>
+            <th>Meaning</th>
+          </tr>
+        </thead>
+        <tbody>
+          <tr><td><code>sentinel.BotHuman</code></td><td>No bot tells, or a score below <code>Bots.Threshold</code> (default 50).</td></tr>
+          <tr><td><code>sentinel.BotVerified</code></td><td>Claims to be a known crawler and passed DNS verification.</td></tr>
+          <tr><td><code>sentinel.BotUnverified</code></td><td>An HTTP library, headless browser or self-declared bot; or a crawler claim DNS could not check.</td></tr>
+          <tr><td><code>sentinel.BotBad</code></td><td>A crawler claim that failed verification, or a known attack tool.</td></tr>
+        </tbody>
+      </table>
+
+      <h3>Crawler Verification</h3>
+      <p>
+        A User-Agent naming Googlebot, Bingbot, Applebot, YandexBot, Baiduspider, Amazonbot or
+        SeznamBot is checked the way those search engines document: the PTR record of the client
+        IP must name a host under the crawler&apos;s domains, and that host must resolve back to the
+        same IP. Scrapers borrowing Googlebot&apos;s User-Agent fail and are judged bad. Results are
+        cached per IP for <code>CacheTTL</code>; a lookup that times out or fails is retried after a
+        minute and leaves the client unverified in the meantime. Add your own crawlers with{' '}
+        <code>Bots.Crawlers</code>.
+      </p>
+      <p>
+        Lookups go through <code>Bots.Resolver</code>, any type with <code>LookupAddr</code> and{' '}
+        <code>LookupHost</code>. Pass a <code>*net.Resolver</code> to pick a DNS server, or a fake
+        to test offline.
+      </p>
+
+      <h3>Bot Score</h3>
+      <p>
+        Other clients are scored from User-Agent and header tells: an empty User-Agent (50), HTTP
+        libraries and command line clients (60), headless browsers and automation frameworks
+        (60), self-declared bots (60), a missing <code>Accept</code> (10) or{' '}
+        <code>Accept-Language</code> (15), and a Chrome User-Agent without client hints over HTTPS
+        (25). Vulnerability scanners such as sqlmap and nikto are bad regardless of score.
+      </p>
+
+      <h3>Bot Policy</h3>
+      <p>
+        <code>WAF.BotPolicy</code> maps a verdict to an action. <code>allow</code> skips WAF
+        inspection and the network policy, and is only accepted for <code>verified</code>;{' '}
+        <code>challenge</code> sends the client to the CAPTCHA challenge (a 429 when no
+        provider is configured), <code>block</code> refuses it with 403, and <code>inspect</code>{' '}
+        (or no entry) inspects as usual. Challenged and blocked clients are recorded as{' '}
+        <code>BadBot</code> threats. In <code>ModeLog</code> the policy records without acting.
+      </p>
+      <CodeBlock
+        language="go"
+        code={`sentinel.Mount(r, nil, sentinel.Config{
+    Bots: sentinel.BotConfig{Enabled: true},
+    WAF: sentinel.WAFConfig{
+        Enabled: true,
+        Mode:    sentinel.ModeBlock,
+        BotPolicy: map[sentinel.BotVerdict]sentinel.BotAction{
+            sentinel.BotVerified:   sentinel.BotActionAllow,
+            sentinel.BotUnverified: sentinel.BotActionChallenge,
+            sentinel.BotBad:        sentinel.BotActionBlock,
+        },
+    },
+    RateLimit: sentinel.RateLimitConfig{
+        Enabled:    true,
+        ByIP:       &sentinel.Limit{Requests: 100, Window: time.Minute},
+        ExemptBots: []sentinel.BotVerdict{sentinel.BotVerified},
+    },
+})`}
+      />
+      <p>
+        <code>GET /api/analytics/bots</code> returns request counts by verdict and by verified
+        crawler for a <code>window</code> of up to seven days. Counts are kept in memory, per
+        process, and start over on restart.
+      </p>
+      <Callout type="warning" title="First Request Pays for DNS">
+        Verification runs inline on the first request from a crawler IP and can take up to{' '}
+        <code>VerifyTimeout</code> (2s by default). Later requests from the same IP are answered
+        from the cache.
+      </Callout>
+
+      <h3 id="network-policy">Network Policy</h3>
+      <p>
+        With <a href="/docs/threat-intelligence#network-types">network type detection</a> enabled,{' '}
+        <code>WAF.NetworkPolicy</code> maps a network type to <code>ModeLog</code>,{' '}
+        <code>ModeChallenge</code> or <code>ModeBlock</code>. Blocked requests get 403 with code{' '}
+        <code>NETWORK_BLOCKED</code>; logged requests are recorded and then inspected as usual.
+        Every action raises a <code>NetworkPolicy</code> threat event. The policy runs after the
+        bot policy, so crawlers the bot policy allows are never caught by a hosting rule, and
+        in <code>ModeLog</code> the whole policy only records.
+      </p>
+      <CodeBlock
+        language="go"
+        showLineNumbers={false}
+        code={`NetworkPolicy: map[sentinel.NetworkType]sentinel.WAFMode{
+    sentinel.NetworkTor:     sentinel.ModeChallenge,
+    sentinel.NetworkHosting: sentinel.ModeLog,
+},`}
+      />
+
+      {/* ------------------------------------------------------------------ */}
+      {/*  RESPONSE INSPECTION                                                */}
+      {/* ------------------------------------------------------------------ */}
+
+      <h2 id="response-inspection">Response Inspection (DLP)</h2>
+      <p>
+        The WAF looks at what comes in; <code>DLP</code> looks at what goes out. When enabled,
+        responses are buffered and scanned for data that should never reach a client: database error
+        messages, stack traces, payment card numbers (Luhn-checked, with a known network prefix) and
+        secret keys (private keys, AWS, GitHub, Slack, Stripe and Google formats). Each leaking
+        response is recorded as a <code>DataLeak</code> threat with redacted evidence — the leaked
+        value itself is never stored.
+      </p>
+      <CodeBlock
+        language="go"
+        code={`DLP: sentinel.DLPConfig{
+    Enabled: true,
+    Action:  sentinel.DLPActionMask, // log (default), mask or replace
+    ClassActions: map[sentinel.DLPClass]sentinel.DLPAction{
+        sentinel.DLPStackTrace:    sentinel.DLPActionReplace,
+        sentinel.DLPDatabaseError: sentinel.DLPActionReplace,
+    },
+    MaxBodyBytes:  256 * 1024,               // larger responses stream through uninspected
+    ExcludeRoutes: []string{"/api/export/**"},
+}`}
+      />
+      <ul>
+        <li>
+          <strong>mask</strong> overwrites each finding with <code>*</code>, keeping the body's length:
+          card numbers keep their last four digits and secret keys their first four characters. A card
+          number sent as a bare JSON number becomes invalid JSON, so prefer replace for such APIs.
+        </li>
+        <li>
+          <strong>replace</strong> discards the response and sends <code>ReplaceStatus</code> (500)
+          with <code>ReplaceBody</code>, a generic JSON error unless set. The handler&apos;s headers
+          go too — cookies, redirects and cache validators included — except the security,
+          rate-limit and CORS headers; the replacement is sent with{' '}
+          <code>Cache-Control: no-store</code>. The event is marked blocked.
+        </li>
+        <li>
+          When findings of several classes call for different actions, replace wins over mask and mask
+          over log.
+        </li>
+      </ul>
+      <p>
+        Only the media types in <code>ContentTypes</code> are buffered (default JSON, problem+JSON,
+        HTML, plain text and XML; <code>"text/*"</code> entries are allowed). Compressed responses,
+        responses larger than <code>MaxBodyBytes</code>, and handlers that flush or hijack the
+        connection stream straight through. The dashboard's own routes are never inspected.
+      </p>
+
       {/* ------------------------------------------------------------------ */}
       {/*  DASHBOARD                                                          */}
       {/* ------------------------------------------------------------------ */}
@@ -896,10 +1255,11 @@ curl -v "http://localhost:8080/api/ping?host=\\\`whoami\\\`"`}
       </p>
 
       <Callout type="info" title="Dashboard Rule Changes">
-        Rules created or modified via the dashboard are persisted to storage and take effect
-        immediately. They are evaluated alongside rules defined in your Go configuration. If you
-        restart the application, rules from the Go config are always re-applied, while dashboard-created
-        rules are loaded from storage.
+        Rules created, edited or deleted via the dashboard take effect immediately and are saved
+        to storage as a config version. On restart they are layered over your Go configuration: an
+        edited or deleted Go rule stays edited or deleted, and Go rules you never touched follow the
+        code. Earlier versions can be listed and rolled back through{' '}
+        <code>/api/config/versions</code>.
       </Callout>
 
       {/* ------------------------------------------------------------------ */}
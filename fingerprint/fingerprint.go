@@ -0,0 +1,282 @@
+// Package fingerprint computes TLS client fingerprints (JA3 and JA4) from
+// the ClientHello and makes them available to Sentinel.
+//
+// A fingerprint identifies the TLS library and settings a client uses. A
+// scanner or bot farm that rotates IPs and User-Agents usually keeps the
+// same TLS stack, so its fingerprint is a stable signal where the others
+// are not.
+//
+// Sentinel only sees the ClientHello when the application terminates TLS
+// itself. Wire a Recorder into the server's tls.Config and listener, then
+// pass its Source method as sentinel.FingerprintConfig.Source:
+//
+//	rec := fingerprint.NewRecorder()
+//	srv := &http.Server{Handler: r, TLSConfig: rec.TLSConfig(nil)}
+//	ln, _ := net.Listen("tcp", ":443")
+//	go srv.ServeTLS(rec.Listener(ln), "cert.pem", "key.pem")
+//
+// Behind a TLS-terminating proxy that forwards fingerprints in request
+// headers, use Headers instead.
+//
+// HTTP/2 fingerprints (SETTINGS, WINDOW_UPDATE and pseudo-header order) are
+// not computed: net/http consumes those frames without exposing them.
+package fingerprint
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+)
+
+// TLS extension numbers JA4 treats specially.
+const (
+	extServerName        = 0x0000
+	extALPN              = 0x0010
+	extSupportedVersions = 0x002b
+)
+
+// Compute returns the JA3 and JA4 fingerprints of hello.
+func Compute(hello *tls.ClientHelloInfo) *sentinel.TLSFingerprint {
+	return &sentinel.TLSFingerprint{
+		JA3: JA3(hello),
+		JA4: JA4(hello),
+	}
+}
+
+// JA3 returns the JA3 fingerprint of hello: the MD5 hex digest of
+// JA3String.
+func JA3(hello *tls.ClientHelloInfo) string {
+	sum := md5.Sum([]byte(JA3String(hello)))
+	return hex.EncodeToString(sum[:])
+}
+
+// JA3String returns the JA3 string of hello:
+// "version,ciphers,extensions,curves,point_formats" with list values in
+// decimal joined by "-" and GREASE values removed.
+//
+// crypto/tls does not expose the ClientHello's legacy version field. It is
+// 771 (TLS 1.2) whenever the supported_versions extension is present, as
+// RFC 8446 requires; otherwise crypto/tls derives SupportedVersions from
+// it, so the highest of those is the legacy version.
+func JA3String(hello *tls.ClientHelloInfo) string {
+	version := uint16(tls.VersionTLS12)
+	if !hasExtension(hello, extSupportedVersions) {
+		version = maxVersion(hello.SupportedVersions)
+	}
+
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(int(version)))
+	b.WriteByte(',')
+	writeDecimal(&b, hello.CipherSuites)
+	b.WriteByte(',')
+	writeDecimal(&b, hello.Extensions)
+	b.WriteByte(',')
+	curves := make([]uint16, len(hello.SupportedCurves))
+	for i, c := range hello.SupportedCurves {
+		curves[i] = uint16(c)
+	}
+	writeDecimal(&b, curves)
+	b.WriteByte(',')
+	for i, p := range hello.SupportedPoints {
+		if i > 0 {
+			b.WriteByte('-')
+		}
+		b.WriteString(strconv.Itoa(int(p)))
+	}
+	return b.String()
+}
+
+// JA4 returns the JA4 fingerprint of hello, e.g.
+// "t13d1516h2_8daaf6152771_e5627efa2ab1": protocol, TLS version, SNI
+// presence, cipher and extension counts and ALPN, then truncated SHA-256
+// hashes of the sorted cipher suites and of the sorted extensions with the
+// signature algorithms.
+func JA4(hello *tls.ClientHelloInfo) string {
+	ciphers := withoutGREASE(hello.CipherSuites)
+	exts := withoutGREASE(hello.Extensions)
+
+	sni := "i"
+	if hello.ServerName != "" || hasExtension(hello, extServerName) {
+		sni = "d"
+	}
+
+	a := fmt.Sprintf("t%s%s%02d%02d%s",
+		ja4Version(maxVersion(hello.SupportedVersions)),
+		sni,
+		min(len(ciphers), 99),
+		min(len(exts), 99),
+		ja4ALPN(hello.SupportedProtos),
+	)
+
+	sort.Slice(ciphers, func(i, j int) bool { return ciphers[i] < ciphers[j] })
+	b := ja4Hash(hexList(ciphers))
+
+	var sorted []uint16
+	for _, e := range exts {
+		if e != extServerName && e != extALPN {
+			sorted = append(sorted, e)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	c := hexList(sorted)
+	if len(sorted) > 0 {
+		schemes := make([]uint16, 0, len(hello.SignatureSchemes))
+		for _, s := range hello.SignatureSchemes {
+			if !isGREASE(uint16(s)) {
+				schemes = append(schemes, uint16(s))
+			}
+		}
+		if len(schemes) > 0 {
+			c += "_" + hexList(schemes)
+		}
+	}
+
+	return a + "_" + b + "_" + ja4Hash(c)
+}
+
+// Headers returns a FingerprintConfig.Source that reads fingerprints a
+// TLS-terminating proxy forwards in the named request headers. Either name
+// may be empty. Only use it when every request passes through that proxy:
+// clients can set these headers themselves.
+func Headers(ja3Header, ja4Header string) func(r *http.Request) *sentinel.TLSFingerprint {
+	return func(r *http.Request) *sentinel.TLSFingerprint {
+		var fp sentinel.TLSFingerprint
+		if ja3Header != "" {
+			fp.JA3 = r.Header.Get(ja3Header)
+		}
+		if ja4Header != "" {
+			fp.JA4 = r.Header.Get(ja4Header)
+		}
+		if fp.JA3 == "" && fp.JA4 == "" {
+			return nil
+		}
+		return &fp
+	}
+}
+
+// --- Request context ---
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying fp.
+func NewContext(ctx context.Context, fp *sentinel.TLSFingerprint) context.Context {
+	return context.WithValue(ctx, contextKey{}, fp)
+}
+
+// FromContext returns the fingerprint attached to ctx by the fingerprint
+// middleware, or nil.
+func FromContext(ctx context.Context) *sentinel.TLSFingerprint {
+	fp, _ := ctx.Value(contextKey{}).(*sentinel.TLSFingerprint)
+	return fp
+}
+
+// --- Helpers ---
+
+// isGREASE reports whether v is one of the reserved GREASE values (RFC
+// 8701), which clients insert at random and fingerprints must ignore.
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+func withoutGREASE(vs []uint16) []uint16 {
+	out := make([]uint16, 0, len(vs))
+	for _, v := range vs {
+		if !isGREASE(v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func hasExtension(hello *tls.ClientHelloInfo, ext uint16) bool {
+	for _, e := range hello.Extensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+func maxVersion(versions []uint16) uint16 {
+	var v uint16
+	for _, s := range versions {
+		if !isGREASE(s) && s > v {
+			v = s
+		}
+	}
+	return v
+}
+
+func ja4Version(v uint16) string {
+	switch v {
+	case tls.VersionTLS13:
+		return "13"
+	case tls.VersionTLS12:
+		return "12"
+	case tls.VersionTLS11:
+		return "11"
+	case tls.VersionTLS10:
+		return "10"
+	case 0x0300:
+		return "s3"
+	}
+	return "00"
+}
+
+// ja4ALPN returns the first and last characters of the first ALPN value,
+// or of its hex encoding when either is not alphanumeric.
+func ja4ALPN(protos []string) string {
+	if len(protos) == 0 || protos[0] == "" {
+		return "00"
+	}
+	p := protos[0]
+	first, last := p[0], p[len(p)-1]
+	if !isAlnum(first) || !isAlnum(last) {
+		h := hex.EncodeToString([]byte(p))
+		return string([]byte{h[0], h[len(h)-1]})
+	}
+	return string([]byte{first, last})
+}
+
+func isAlnum(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func ja4Hash(s string) string {
+	if s == "" {
+		return "000000000000"
+	}
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func hexList(vs []uint16) string {
+	parts := make([]string, len(vs))
+	for i, v := range vs {
+		parts[i] = fmt.Sprintf("%04x", v)
+	}
+	return strings.Join(parts, ",")
+}
+
+func writeDecimal(b *strings.Builder, vs []uint16) {
+	first := true
+	for _, v := range vs {
+		if isGREASE(v) {
+			continue
+		}
+		if !first {
+			b.WriteByte('-')
+		}
+		first = false
+		b.WriteString(strconv.Itoa(int(v)))
+	}
+}
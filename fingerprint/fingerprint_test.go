@@ -0,0 +1,128 @@
+package fingerprint
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chromeHello is a Chrome ClientHello whose JA4 the JA4 specification
+// documents as t13d1516h2_8daaf6152771_e5627efa2ab1.
+func chromeHello() *tls.ClientHelloInfo {
+	return &tls.ClientHelloInfo{
+		ServerName: "example.com",
+		CipherSuites: []uint16{
+			0x8a8a, // GREASE
+			0x1301, 0x1302, 0x1303, 0xc02b, 0xc02f, 0xc02c, 0xc030, 0xcca9,
+			0xcca8, 0xc013, 0xc014, 0x009c, 0x009d, 0x002f, 0x0035,
+		},
+		Extensions: []uint16{
+			0x2a2a, // GREASE
+			0x0000, 0x0017, 0xff01, 0x000a, 0x000b, 0x0023, 0x0010, 0x0005,
+			0x000d, 0x0012, 0x0033, 0x002d, 0x002b, 0x001b, 0x0015, 0x4469,
+		},
+		SupportedVersions: []uint16{0x6a6a, tls.VersionTLS13, tls.VersionTLS12},
+		SupportedCurves:   []tls.CurveID{0x0a0a, tls.X25519, tls.CurveP256, tls.CurveP384},
+		SupportedPoints:   []uint8{0},
+		SupportedProtos:   []string{"h2", "http/1.1"},
+		SignatureSchemes: []tls.SignatureScheme{
+			0x0403, 0x0804, 0x0401, 0x0503, 0x0805, 0x0501, 0x0806, 0x0601,
+		},
+	}
+}
+
+func TestJA4KnownVector(t *testing.T) {
+	got := JA4(chromeHello())
+	if want := "t13d1516h2_8daaf6152771_e5627efa2ab1"; got != want {
+		t.Fatalf("JA4 = %q, want %q", got, want)
+	}
+}
+
+func TestJA3KnownVector(t *testing.T) {
+	// The TLS 1.0 example from the JA3 specification, which documents
+	// its hash as ada70206e40642a3e4461f35503241d5.
+	hello := &tls.ClientHelloInfo{
+		CipherSuites:      []uint16{47, 53, 5, 10, 49161, 49162, 49171, 49172, 50, 56, 19, 4},
+		Extensions:        []uint16{0, 10, 11},
+		SupportedVersions: []uint16{tls.VersionTLS10},
+		SupportedCurves:   []tls.CurveID{23, 24, 25},
+		SupportedPoints:   []uint8{0},
+	}
+	if got, want := JA3String(hello), "769,47-53-5-10-49161-49162-49171-49172-50-56-19-4,0-10-11,23-24-25,0"; got != want {
+		t.Fatalf("JA3String = %q, want %q", got, want)
+	}
+	if got, want := JA3(hello), "ada70206e40642a3e4461f35503241d5"; got != want {
+		t.Fatalf("JA3 = %q, want %q", got, want)
+	}
+}
+
+func TestJA3IgnoresGREASE(t *testing.T) {
+	hello := chromeHello()
+	a := JA3(hello)
+	hello.CipherSuites[0] = 0xdada
+	hello.Extensions[0] = 0x1a1a
+	if b := JA3(hello); a != b {
+		t.Fatalf("changing GREASE values changed JA3: %s != %s", a, b)
+	}
+	if !strings.HasPrefix(JA3String(hello), "771,4865-") {
+		t.Fatalf("TLS 1.3 hello should report legacy version 771: %s", JA3String(hello))
+	}
+}
+
+func TestRecorderServesFingerprintPerConnection(t *testing.T) {
+	rec := NewRecorder()
+	var got string
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fp := rec.Source(r); fp != nil {
+			got = fp.JA4
+		}
+	}))
+	srv.TLS = rec.TLSConfig(nil)
+	srv.Listener = rec.Listener(srv.Listener)
+	srv.StartTLS()
+	defer srv.Close()
+
+	client := srv.Client()
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if !strings.HasPrefix(got, "t13") {
+		t.Fatalf("handler saw JA4 %q, want a TLS 1.3 fingerprint", got)
+	}
+
+	// Closing the connection forgets it.
+	client.CloseIdleConnections()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		rec.mu.RLock()
+		n := len(rec.conns)
+		rec.mu.RUnlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d fingerprints still recorded after the connection closed", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHeadersSource(t *testing.T) {
+	src := Headers("X-JA3", "X-JA4")
+	r := httptest.NewRequest("GET", "/", nil)
+	if src(r) != nil {
+		t.Fatal("no headers should yield no fingerprint")
+	}
+	r.Header.Set("X-JA4", "t13d1516h2_8daaf6152771_e5627efa2ab1")
+	if fp := src(r); fp == nil || fp.JA4 != "t13d1516h2_8daaf6152771_e5627efa2ab1" || fp.JA3 != "" {
+		t.Fatalf("unexpected fingerprint %+v", fp)
+	}
+}
@@ -0,0 +1,137 @@
+package fingerprint
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+)
+
+const (
+	// maxEntries bounds the fingerprints a Recorder remembers. It only comes
+	// into play when connections are not accepted through Listener, which
+	// forgets each connection as it closes.
+	maxEntries = 100000
+
+	// staleAfter is how old an entry must be before a full Recorder drops it.
+	staleAfter = 10 * time.Minute
+)
+
+// Recorder fingerprints TLS handshakes and remembers each connection's
+// fingerprint by its remote address, which is also the RemoteAddr of every
+// request the connection carries.
+type Recorder struct {
+	mu    sync.RWMutex
+	conns map[string]recorded
+}
+
+type recorded struct {
+	fp *sentinel.TLSFingerprint
+	at time.Time
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{conns: make(map[string]recorded)}
+}
+
+// TLSConfig returns a clone of base (or of an empty config when base is
+// nil) that records the fingerprint of every handshake. A
+// GetConfigForClient already set on base still runs afterwards.
+func (r *Recorder) TLSConfig(base *tls.Config) *tls.Config {
+	var cfg *tls.Config
+	if base != nil {
+		cfg = base.Clone()
+	} else {
+		cfg = &tls.Config{}
+	}
+	next := cfg.GetConfigForClient
+	cfg.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		r.Record(hello)
+		if next != nil {
+			return next(hello)
+		}
+		return nil, nil
+	}
+	return cfg
+}
+
+// Record fingerprints hello and stores the result under the remote address
+// of its connection.
+func (r *Recorder) Record(hello *tls.ClientHelloInfo) *sentinel.TLSFingerprint {
+	fp := Compute(hello)
+	if hello.Conn == nil {
+		return fp
+	}
+	addr := hello.Conn.RemoteAddr().String()
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.conns[addr]; !ok && len(r.conns) >= maxEntries {
+		for k, e := range r.conns {
+			if now.Sub(e.at) > staleAfter {
+				delete(r.conns, k)
+			}
+		}
+		if len(r.conns) >= maxEntries {
+			return fp
+		}
+	}
+	r.conns[addr] = recorded{fp: fp, at: now}
+	return fp
+}
+
+// Lookup returns the fingerprint recorded for the connection from
+// remoteAddr, or nil.
+func (r *Recorder) Lookup(remoteAddr string) *sentinel.TLSFingerprint {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.conns[remoteAddr].fp
+}
+
+// Source looks up the fingerprint of the connection req arrived on. Pass it
+// as sentinel.FingerprintConfig.Source.
+func (r *Recorder) Source(req *http.Request) *sentinel.TLSFingerprint {
+	return r.Lookup(req.RemoteAddr)
+}
+
+func (r *Recorder) forget(remoteAddr string) {
+	r.mu.Lock()
+	delete(r.conns, remoteAddr)
+	r.mu.Unlock()
+}
+
+// Listener wraps ln so a connection's fingerprint is forgotten when the
+// connection closes. Accept TLS connections through it, either with
+// http.Server.ServeTLS or by wrapping it in tls.NewListener.
+func (r *Recorder) Listener(ln net.Listener) net.Listener {
+	return &listener{Listener: ln, rec: r}
+}
+
+type listener struct {
+	net.Listener
+	rec *Recorder
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: c, rec: l.rec}, nil
+}
+
+type conn struct {
+	net.Conn
+	rec  *Recorder
+	once sync.Once
+}
+
+func (c *conn) Close() error {
+	c.once.Do(func() { c.rec.forget(c.RemoteAddr().String()) })
+	return c.Conn.Close()
+}
@@ -2,9 +2,12 @@ package intelligence
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"log"
 	"math"
+	"sort"
 	"sync"
 	"time"
 
@@ -14,38 +17,60 @@ import (
 )
 
 // AnomalyDetector maintains per-user behavioral baselines and detects anomalies
-// by comparing new activity against established patterns.
+// by comparing new activity against established patterns. Baselines live in
+// the store and are updated incrementally from each activity.
 type AnomalyDetector struct {
-	store     storage.Store
-	pipe      *pipeline.Pipeline
-	geoLoc    *GeoLocator
-	config    sentinel.AnomalyConfig
-	baselines map[string]*UserBaseline
-	mu        sync.RWMutex
+	store          storage.Store
+	pipe           *pipeline.Pipeline
+	geoLoc         *GeoLocator
+	config         sentinel.AnomalyConfig
+	learningPeriod time.Duration
+
+	// locks serializes the read-modify-write of a baseline per user within
+	// this process; users share a lock when their IDs hash alike. Replicas
+	// sharing a store rely on the store's version check instead.
+	locks [64]sync.Mutex
+
+	// stuffing is the cross-IP credential stuffing check, fed by
+	// AuthShield's login attempts rather than by user activity. Nil unless
+	// CheckCredentialStuffing is enabled.
+	stuffing *stuffingDetector
 }
 
-// UserBaseline represents a user's normal behavioral pattern computed from historical data.
-type UserBaseline struct {
-	UserID         string
-	ActiveHours    map[int]int     // hour-of-day -> activity count
-	TypicalRoutes  map[string]int  // route -> access count
-	AvgRequestsPerHour float64
-	SourceIPs      map[string]bool
-	Countries      map[string]bool
-	AvgResponseSize float64
-	TotalRequests  int
-	ComputedAt     time.Time
-}
+// UserBaseline represents a user's normal behavioral pattern.
+type UserBaseline = sentinel.UserBaseline
+
+// minBaselineSamples is the number of activities a baseline needs before
+// it is used, on top of the learning period.
+const minBaselineSamples = 10
+
+// baselineSaveAttempts bounds how often a baseline update is retried when
+// another replica saved the baseline first.
+const baselineSaveAttempts = 5
+
+// velocityFloor is the hourly request count below which a burst is not
+// worth reporting, however quiet the user usually is.
+const velocityFloor = 10
 
 // NewAnomalyDetector creates a new anomaly detector.
 func NewAnomalyDetector(store storage.Store, pipe *pipeline.Pipeline, geoLoc *GeoLocator, config sentinel.AnomalyConfig) *AnomalyDetector {
-	return &AnomalyDetector{
-		store:     store,
-		pipe:      pipe,
-		geoLoc:    geoLoc,
-		config:    config,
-		baselines: make(map[string]*UserBaseline),
+	learningPeriod := config.LearningPeriod
+	if learningPeriod <= 0 {
+		learningPeriod = 7 * 24 * time.Hour
+	}
+	ad := &AnomalyDetector{
+		store:          store,
+		pipe:           pipe,
+		geoLoc:         geoLoc,
+		config:         config,
+		learningPeriod: learningPeriod,
+	}
+	for _, check := range config.Checks {
+		if check == sentinel.CheckCredentialStuffing {
+			ad.stuffing = newStuffingDetector(config.CredentialStuffing)
+		}
 	}
+	return ad
 }
 
 // Handle processes pipeline events to detect anomalies in user activity.
@@ -53,6 +78,12 @@ func (ad *AnomalyDetector) Handle(ctx context.Context, event pipeline.Event) err
 	if !ad.config.Enabled {
 		return nil
 	}
+	if event.Type == pipeline.EventLoginAttempt {
+		if attempt, ok := event.Payload.(*sentinel.LoginAttempt); ok && attempt != nil {
+			ad.CheckLoginAttempt(ctx, attempt)
+		}
+		return nil
+	}
 	if event.Type != pipeline.EventUserActivity {
 		return nil
 	}
@@ -65,14 +96,41 @@ func (ad *AnomalyDetector) Handle(ctx context.Context, event pipeline.Event) err
 	return ad.CheckActivity(ctx, activity)
 }
 
-// CheckActivity checks a user activity event against the user's behavioral baseline.
+// CheckActivity checks a user activity event against the user's behavioral
+// baseline, then folds the activity into the baseline. A baseline is only
+// used once it spans the learning period and has enough samples; until
+// then activity is learned but never reported. If another replica updates
+// the baseline concurrently, the activity is folded into its version; it
+// is scored only once.
 func (ad *AnomalyDetector) CheckActivity(ctx context.Context, activity *sentinel.UserActivity) error {
-	baseline := ad.getOrComputeBaseline(ctx, activity.UserID)
-	if baseline == nil || baseline.TotalRequests < 10 {
-		// Not enough data for a baseline
-		return nil
+	lock := ad.lockFor(activity.UserID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		baseline, err := ad.loadBaseline(ctx, activity)
+		if err != nil {
+			return err
+		}
+		if attempt == 1 && ad.learned(baseline, activity.Timestamp) {
+			ad.score(activity, baseline)
+		}
+		observeActivity(baseline, activity, ad.learningPeriod)
+		err = ad.store.SaveBaseline(ctx, baseline)
+		if !errors.Is(err, storage.ErrBaselineConflict) || attempt == baselineSaveAttempts {
+			return err
+		}
 	}
+}
+
+// learned reports whether a baseline has finished its learning period.
+func (ad *AnomalyDetector) learned(b *UserBaseline, at time.Time) bool {
+	return b.Samples >= minBaselineSamples && at.Sub(b.FirstSeen) >= ad.learningPeriod
+}
 
+// score runs the configured checks and emits a threat if the total
+// reaches the sensitivity threshold.
+func (ad *AnomalyDetector) score(activity *sentinel.UserActivity, baseline *UserBaseline) {
 	var totalScore int
 	var anomalies []string
 
@@ -90,6 +148,8 @@ func (ad *AnomalyDetector) CheckActivity(ctx context.Context, activity *sentinel
 			score = ad.checkImpossibleTravel(activity, baseline)
 		case sentinel.CheckDataExfiltration:
 			score = ad.checkDataExfiltration(activity, baseline)
+		case sentinel.CheckCredentialStuffing:
+			// Runs on login attempts, across users; see CheckLoginAttempt.
 		}
 		if score > 0 {
 			totalScore += score
@@ -130,22 +190,38 @@ func (ad *AnomalyDetector) CheckActivity(ctx context.Context, activity *sentinel
 		log.Printf("[sentinel] anomaly: detected for user %s (score: %d, checks: %v)",
 			activity.UserID, totalScore, anomalies)
 	}
+}
 
-	return nil
+// CheckLoginAttempt feeds a login attempt to the credential stuffing check
+// and emits a ThreatCredentialStuffing event for each IP newly involved in
+// a finding. It does nothing unless CheckCredentialStuffing is enabled.
+func (ad *AnomalyDetector) CheckLoginAttempt(ctx context.Context, attempt *sentinel.LoginAttempt) {
+	if ad.stuffing == nil {
+		return
+	}
+	var asn string
+	if ad.geoLoc != nil {
+		if geo, err := ad.geoLoc.LookupIP(ctx, attempt.IP); err == nil && geo != nil {
+			asn = geo.ASN
+		}
+	}
+	for _, f := range ad.stuffing.observe(attempt, asn) {
+		log.Printf("[sentinel] anomaly: credential stuffing (%s): %s", f.signal, f.detail)
+		for _, te := range f.threats(attempt) {
+			ad.pipe.EmitThreat(te)
+		}
+	}
 }
 
 // checkOffHours detects access outside the user's normal active hours.
 // Returns 0-30 based on how unusual the hour is.
 func (ad *AnomalyDetector) checkOffHours(activity *sentinel.UserActivity, baseline *UserBaseline) int {
-	hour := activity.Timestamp.Hour()
-	count := baseline.ActiveHours[hour]
-
-	if baseline.TotalRequests == 0 {
+	if baseline.Weight <= 0 {
 		return 0
 	}
 
 	// Calculate what percentage of activity happens at this hour
-	hourPct := float64(count) / float64(baseline.TotalRequests) * 100
+	hourPct := baseline.HourWeights[activity.Timestamp.Hour()] / baseline.Weight * 100
 
 	// If this hour has < 1% of activity, it's off-hours
 	if hourPct < 1.0 {
@@ -159,36 +235,28 @@ func (ad *AnomalyDetector) checkOffHours(activity *sentinel.UserActivity, baseli
 	return 0
 }
 
-// checkUnusualAccess detects access to routes the user has never accessed before.
+// checkUnusualAccess detects access to routes the user has not accessed
+// within the learning period.
 // Returns 0-25 based on how unusual the route is.
 func (ad *AnomalyDetector) checkUnusualAccess(activity *sentinel.UserActivity, baseline *UserBaseline) int {
 	route := activity.Method + " " + activity.Path
-	if _, known := baseline.TypicalRoutes[route]; !known {
+	if _, known := baseline.Routes[route]; !known {
 		return 25
 	}
 	return 0
 }
 
-// checkVelocity detects if request rate exceeds 3x the baseline for this time of day.
+// checkVelocity detects if the request count in the current hour exceeds 3x
+// the user's usual count for an active hour.
 // Returns 0-25 based on the velocity anomaly.
 func (ad *AnomalyDetector) checkVelocity(activity *sentinel.UserActivity, baseline *UserBaseline) int {
-	if baseline.AvgRequestsPerHour <= 0 {
+	if baseline.RequestsPerHour <= 0 {
 		return 0
 	}
-
-	// Get current hourly rate from baseline
-	hour := activity.Timestamp.Hour()
-	hourlyRate := float64(baseline.ActiveHours[hour])
-	daysInBaseline := float64(baseline.TotalRequests) / (24 * baseline.AvgRequestsPerHour)
-	if daysInBaseline < 1 {
-		daysInBaseline = 1
-	}
-	avgForHour := hourlyRate / daysInBaseline
-
-	if avgForHour > 0 && baseline.AvgRequestsPerHour > 3*avgForHour {
+	count := currentHourCount(baseline, activity.Timestamp)
+	if count >= velocityFloor && float64(count) > 3*baseline.RequestsPerHour {
 		return 25
 	}
-
 	return 0
 }
 
@@ -196,10 +264,10 @@ func (ad *AnomalyDetector) checkVelocity(activity *sentinel.UserActivity, baseli
 // Returns 0-30 based on whether impossible travel is detected.
 func (ad *AnomalyDetector) checkImpossibleTravel(activity *sentinel.UserActivity, baseline *UserBaseline) int {
 	// If user has known source IPs and current IP is new
-	if len(baseline.SourceIPs) > 0 && !baseline.SourceIPs[activity.IP] {
+	if _, known := baseline.SourceIPs[activity.IP]; len(baseline.SourceIPs) > 0 && !known {
 		// New IP detected — could indicate impossible travel
 		// Without real-time geo distance calculation between recent IPs, we give a moderate score
-		if activity.Country != "" && len(baseline.Countries) > 0 && !baseline.Countries[activity.Country] {
+		if _, seen := baseline.Countries[activity.Country]; activity.Country != "" && len(baseline.Countries) > 0 && !seen {
 			return 30 // Different country = strong signal
 		}
 		return 10 // New IP but same/unknown country
@@ -210,93 +278,85 @@ func (ad *AnomalyDetector) checkImpossibleTravel(activity *sentinel.UserActivity
 // checkDataExfiltration detects if response size per session exceeds 5x baseline average.
 // Returns 0-20 based on the size anomaly.
 func (ad *AnomalyDetector) checkDataExfiltration(activity *sentinel.UserActivity, baseline *UserBaseline) int {
-	if baseline.AvgResponseSize <= 0 {
-		return 0
-	}
-
 	// We use duration_ms as a proxy — if we had response size we'd use that
 	// For now, check if the activity duration is abnormally high
-	avgDuration := baseline.AvgResponseSize // stored as avg response size
-	if avgDuration > 0 && float64(activity.Duration) > 5*avgDuration {
+	if baseline.AvgDuration > 0 && float64(activity.Duration) > 5*baseline.AvgDuration {
 		return 20
 	}
 
 	return 0
 }
 
-// getOrComputeBaseline retrieves or computes the behavioral baseline for a user.
-func (ad *AnomalyDetector) getOrComputeBaseline(ctx context.Context, userID string) *UserBaseline {
-	ad.mu.RLock()
-	bl, ok := ad.baselines[userID]
-	ad.mu.RUnlock()
-
-	// Use cached baseline if it's less than 1 hour old
-	if ok && time.Since(bl.ComputedAt) < time.Hour {
-		return bl
+// loadBaseline returns the user's stored baseline. A user without one is
+// seeded once from their activity over the learning period, so upgrading
+// does not restart learning for existing users.
+func (ad *AnomalyDetector) loadBaseline(ctx context.Context, activity *sentinel.UserActivity) (*UserBaseline, error) {
+	bl, err := ad.store.GetBaseline(ctx, activity.UserID)
+	if err != nil || bl != nil {
+		return bl, err
 	}
 
-	// Compute from storage
-	baseline := ad.computeBaseline(ctx, userID)
-	if baseline != nil {
-		ad.mu.Lock()
-		ad.baselines[userID] = baseline
-		ad.mu.Unlock()
-	}
-
-	return baseline
-}
-
-// computeBaseline builds a behavioral baseline from the user's activity over the learning period.
-func (ad *AnomalyDetector) computeBaseline(ctx context.Context, userID string) *UserBaseline {
-	start := time.Now().Add(-ad.config.LearningPeriod)
+	bl = &UserBaseline{UserID: activity.UserID}
+	start := time.Now().Add(-ad.learningPeriod)
 	filter := sentinel.ActivityFilter{
 		StartTime: &start,
 		Page:      1,
-		PageSize:  10000, // Get all activity in the window
+		PageSize:  10000,
 	}
-
-	activities, _, err := ad.store.ListUserActivity(ctx, userID, filter)
+	history, _, err := ad.store.ListUserActivity(ctx, activity.UserID, filter)
 	if err != nil {
-		log.Printf("[sentinel] anomaly: failed to load activity for user %s: %v", userID, err)
-		return nil
+		log.Printf("[sentinel] anomaly: failed to load activity for user %s: %v", activity.UserID, err)
+		return bl, nil
 	}
-
-	if len(activities) == 0 {
-		return nil
+	sort.Slice(history, func(i, j int) bool { return history[i].Timestamp.Before(history[j].Timestamp) })
+	for _, a := range history {
+		// The storage handler may have saved the activity being checked.
+		if a.ID != "" && a.ID == activity.ID {
+			continue
+		}
+		observeActivity(bl, a, ad.learningPeriod)
 	}
+	return bl, nil
+}
 
-	bl := &UserBaseline{
-		UserID:        userID,
-		ActiveHours:   make(map[int]int),
-		TypicalRoutes: make(map[string]int),
-		SourceIPs:     make(map[string]bool),
-		Countries:     make(map[string]bool),
-		TotalRequests: len(activities),
-		ComputedAt:    time.Now(),
+// LearningStatus reports whether a baseline is still learning and when
+// its learning period ends. until is zero for a baseline with no samples.
+func (ad *AnomalyDetector) LearningStatus(b *UserBaseline) (learning bool, until time.Time) {
+	if !b.FirstSeen.IsZero() {
+		until = b.FirstSeen.Add(ad.learningPeriod)
 	}
+	return !ad.learned(b, time.Now()), until
+}
 
-	var totalDuration int64
-	for _, a := range activities {
-		bl.ActiveHours[a.Timestamp.Hour()]++
-		route := a.Method + " " + a.Path
-		bl.TypicalRoutes[route]++
-		bl.SourceIPs[a.IP] = true
-		if a.Country != "" {
-			bl.Countries[a.Country] = true
-		}
-		totalDuration += a.Duration
-	}
+// Baseline returns a user's stored baseline, or nil if there is none.
+func (ad *AnomalyDetector) Baseline(ctx context.Context, userID string) (*UserBaseline, error) {
+	return ad.store.GetBaseline(ctx, userID)
+}
 
-	// Compute averages
-	hours := time.Since(start).Hours()
-	if hours > 0 {
-		bl.AvgRequestsPerHour = float64(len(activities)) / hours
-	}
-	if len(activities) > 0 {
-		bl.AvgResponseSize = float64(totalDuration) / float64(len(activities))
+// ResetBaseline discards a user's baseline and starts the learning period
+// over from their next activity. The user's history is not replayed, so
+// the behavior that prompted the reset is not learned again. It reports
+// whether the user had a baseline.
+func (ad *AnomalyDetector) ResetBaseline(ctx context.Context, userID string) (bool, error) {
+	lock := ad.lockFor(userID)
+	lock.Lock()
+	defer lock.Unlock()
+	for attempt := 1; ; attempt++ {
+		bl, err := ad.store.GetBaseline(ctx, userID)
+		if err != nil || bl == nil {
+			return false, err
+		}
+		err = ad.store.SaveBaseline(ctx, &UserBaseline{UserID: userID, Version: bl.Version})
+		if !errors.Is(err, storage.ErrBaselineConflict) || attempt == baselineSaveAttempts {
+			return true, err
+		}
 	}
+}
 
-	return bl
+func (ad *AnomalyDetector) lockFor(userID string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(userID))
+	return &ad.locks[h.Sum32()%uint32(len(ad.locks))]
 }
 
 func (ad *AnomalyDetector) getThreshold() int {
@@ -248,3 +248,284 @@ func TestAnomalyDetector_NewCountry(t *testing.T) {
 		t.Fatalf("CheckActivity failed: %v", err)
 	}
 }
+
+func TestAnomalyDetector_PersistentBaseline(t *testing.T) {
+	store := memory.New()
+	store.Migrate(context.Background())
+	pipe := pipeline.New(100)
+	threats := make(chan *sentinel.ThreatEvent, 10)
+	pipe.AddHandler(pipeline.HandlerFunc(func(ctx context.Context, event pipeline.Event) error {
+		if te, ok := event.Payload.(*sentinel.ThreatEvent); ok {
+			threats <- te
+		}
+		return nil
+	}))
+	pipe.Start(1)
+	defer pipe.Stop()
+
+	config := sentinel.AnomalyConfig{
+		Enabled:        true,
+		LearningPeriod: 48 * time.Hour,
+		Sensitivity:    sentinel.AnomalySensitivityMedium,
+		Checks: []sentinel.AnomalyCheckType{
+			sentinel.CheckOffHoursAccess,
+			sentinel.CheckUnusualAccess,
+			sentinel.CheckImpossibleTravel,
+		},
+	}
+	geo := intelligence.NewGeoLocator(sentinel.GeoConfig{Enabled: false})
+	detector := intelligence.NewAnomalyDetector(store, pipe, geo, config)
+	ctx := context.Background()
+	start := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	at := func(day, hour int) time.Time { return start.Add(time.Duration(day*24+hour) * time.Hour) }
+
+	for day := 0; day < 3; day++ {
+		for hour := 9; hour <= 17; hour++ {
+			for i := 0; i < 3; i++ {
+				detector.CheckActivity(ctx, &sentinel.UserActivity{
+					UserID: "u1", Timestamp: at(day, hour).Add(time.Duration(i) * time.Minute),
+					Method: "GET", Path: "/api/data", IP: "10.0.0.1", Country: "US", Duration: 100,
+				})
+			}
+		}
+		if day == 0 {
+			// Unusual, but still within the learning period: learned, not reported.
+			detector.CheckActivity(ctx, &sentinel.UserActivity{
+				UserID: "u1", Timestamp: at(0, 22), Method: "GET", Path: "/admin/learning", IP: "198.51.100.1", Country: "BR",
+			})
+		}
+	}
+	select {
+	case te := <-threats:
+		t.Fatalf("no threat expected during the learning period, got %+v", te)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	bl, err := store.GetBaseline(ctx, "u1")
+	if err != nil || bl == nil || bl.Samples != 82 || bl.Routes["GET /admin/learning"] == 0 {
+		t.Fatalf("unexpected stored baseline: %+v, %v", bl, err)
+	}
+
+	// A new detector, as after a restart, picks up the stored baseline.
+	detector = intelligence.NewAnomalyDetector(store, pipe, geo, config)
+	detector.CheckActivity(ctx, &sentinel.UserActivity{
+		UserID: "u1", Timestamp: at(3, 3), Method: "GET", Path: "/admin/export", IP: "203.0.113.9", Country: "RU",
+	})
+	select {
+	case te := <-threats:
+		if te.UserID != "u1" || te.Confidence != 85 {
+			t.Errorf("unexpected threat: %+v", te)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected an anomaly once the learning period is over")
+	}
+
+	if ok, err := detector.ResetBaseline(ctx, "u1"); !ok || err != nil {
+		t.Fatalf("ResetBaseline = %v, %v", ok, err)
+	}
+	if learning, _ := detector.LearningStatus(mustBaseline(t, store, "u1")); !learning {
+		t.Error("a reset baseline should be learning again")
+	}
+}
+
+func TestAnomalyDetector_SharedStore(t *testing.T) {
+	store := memory.New()
+	store.Migrate(context.Background())
+	pipe := pipeline.New(100)
+	pipe.Start(1)
+	defer pipe.Stop()
+
+	config := sentinel.AnomalyConfig{
+		Enabled:        true,
+		LearningPeriod: 48 * time.Hour,
+		Sensitivity:    sentinel.AnomalySensitivityMedium,
+		Checks:         []sentinel.AnomalyCheckType{sentinel.CheckUnusualAccess},
+	}
+	ctx := context.Background()
+	start := time.Now()
+
+	// Two detectors over one store, as on two replicas, must not lose
+	// each other's updates.
+	var wg sync.WaitGroup
+	for r := 0; r < 2; r++ {
+		detector := intelligence.NewAnomalyDetector(store, pipe, nil, config)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if err := detector.CheckActivity(ctx, &sentinel.UserActivity{
+					UserID: "u1", Timestamp: start.Add(time.Duration(i) * time.Second), Method: "GET", Path: "/api/data",
+				}); err != nil {
+					t.Errorf("CheckActivity: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if bl := mustBaseline(t, store, "u1"); bl.Samples != 200 {
+		t.Errorf("expected 200 samples, got %d", bl.Samples)
+	}
+}
+
+func mustBaseline(t *testing.T, store *memory.Store, userID string) *sentinel.UserBaseline {
+	t.Helper()
+	bl, err := store.GetBaseline(context.Background(), userID)
+	if err != nil || bl == nil {
+		t.Fatalf("GetBaseline(%s) = %+v, %v", userID, bl, err)
+	}
+	return bl
+}
+
+func TestAnomalyDetector_CredentialStuffing(t *testing.T) {
+	start := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	failed := func(i int, ip, username, fingerprint string) *sentinel.LoginAttempt {
+		return &sentinel.LoginAttempt{
+			Timestamp: start.Add(time.Duration(i) * time.Second), IP: ip, Username: username,
+			Method: "POST", Path: "/login", PasswordFingerprint: fingerprint,
+		}
+	}
+
+	cases := []struct {
+		name     string
+		attempts func() []*sentinel.LoginAttempt
+		signal   string
+		ips      int
+	}{
+		{
+			name: "many usernames from one subnet",
+			attempts: func() (out []*sentinel.LoginAttempt) {
+				for i := 0; i < 20; i++ {
+					out = append(out, failed(i, fmt.Sprintf("203.0.113.%d", i%5+1), fmt.Sprintf("user%d", i), ""))
+				}
+				return out
+			},
+			signal: "network_usernames",
+			ips:    5,
+		},
+		{
+			name: "one password against many accounts",
+			attempts: func() (out []*sentinel.LoginAttempt) {
+				for i := 0; i < 5; i++ {
+					out = append(out, failed(i, fmt.Sprintf("198.51.%d.7", i), fmt.Sprintf("user%d", i), "a1b2c3d4"))
+				}
+				return out
+			},
+			signal: "password_reuse",
+			ips:    5,
+		},
+		{
+			name: "low per-IP rate, high global failure rate",
+			attempts: func() (out []*sentinel.LoginAttempt) {
+				for i := 0; i < 50; i++ {
+					a := failed(i, fmt.Sprintf("192.0.%d.9", i%25), fmt.Sprintf("user%d", i), "")
+					a.Success = i%10 == 0
+					out = append(out, a)
+				}
+				return out
+			},
+			signal: "distributed_failures",
+			ips:    25,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := memory.New()
+			pipe := pipeline.New(100)
+			var mu sync.Mutex
+			var threats []*sentinel.ThreatEvent
+			pipe.AddHandler(pipeline.HandlerFunc(func(ctx context.Context, event pipeline.Event) error {
+				if te, ok := event.Payload.(*sentinel.ThreatEvent); ok {
+					mu.Lock()
+					threats = append(threats, te)
+					mu.Unlock()
+				}
+				return nil
+			}))
+			pipe.Start(1)
+
+			config := sentinel.AnomalyConfig{
+				Enabled: true,
+				Checks:  []sentinel.AnomalyCheckType{sentinel.CheckCredentialStuffing},
+			}
+			geo := intelligence.NewGeoLocator(sentinel.GeoConfig{Enabled: false})
+			detector := intelligence.NewAnomalyDetector(store, pipe, geo, config)
+			for _, a := range tc.attempts() {
+				detector.Handle(context.Background(), pipeline.Event{Type: pipeline.EventLoginAttempt, Payload: a})
+			}
+			pipe.Stop() // drains the queued threats
+
+			if len(threats) != tc.ips {
+				t.Fatalf("expected one threat per IP (%d), got %d", tc.ips, len(threats))
+			}
+			for _, te := range threats {
+				if te.ThreatTypes[0] != string(sentinel.ThreatCredentialStuffing) || te.Evidence[0].Pattern != tc.signal {
+					t.Errorf("unexpected threat %v / %+v", te.ThreatTypes, te.Evidence)
+				}
+				if te.ActorID == "" || te.ActorID != threats[0].ActorID {
+					t.Errorf("IPs of one finding should share an actor: %q vs %q", te.ActorID, threats[0].ActorID)
+				}
+			}
+		})
+	}
+}
+
+func TestAnomalyDetector_CredentialStuffingWindow(t *testing.T) {
+	pipe := pipeline.New(100)
+	threats := make(chan *sentinel.ThreatEvent, 10)
+	pipe.AddHandler(pipeline.HandlerFunc(func(ctx context.Context, event pipeline.Event) error {
+		if te, ok := event.Payload.(*sentinel.ThreatEvent); ok {
+			threats <- te
+		}
+		return nil
+	}))
+	pipe.Start(1)
+	defer pipe.Stop()
+
+	config := sentinel.AnomalyConfig{
+		Enabled: true,
+		Checks:  []sentinel.AnomalyCheckType{sentinel.CheckCredentialStuffing},
+		CredentialStuffing: sentinel.CredentialStuffingConfig{
+			Window:           time.Minute,
+			NetworkUsernames: 3,
+		},
+	}
+	detector := intelligence.NewAnomalyDetector(memory.New(), pipe, nil, config)
+	start := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	attempt := func(at time.Duration, username string) {
+		detector.CheckLoginAttempt(context.Background(), &sentinel.LoginAttempt{
+			Timestamp: start.Add(at), IP: "203.0.113.10", Username: username, Method: "POST", Path: "/login",
+		})
+	}
+
+	// Three usernames, but never three within a minute.
+	attempt(0, "a")
+	attempt(40*time.Second, "b")
+	attempt(70*time.Second, "c")
+	// Retrying a username inside the window adds no username.
+	attempt(80*time.Second, "b")
+	select {
+	case te := <-threats:
+		t.Fatalf("expected no threat, got %+v", te.Evidence)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	attempt(90*time.Second, "d")
+	select {
+	case te := <-threats:
+		if te.Evidence[0].Parameter != "net:203.0.113.0/24" {
+			t.Errorf("expected the /24 as the group, got %q", te.Evidence[0].Parameter)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a credential stuffing threat")
+	}
+	// The IP is reported once per finding.
+	attempt(95*time.Second, "e")
+	select {
+	case te := <-threats:
+		t.Fatalf("IP reported twice: %+v", te.Evidence)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,135 @@
+package intelligence
+
+import (
+	"math"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+)
+
+const (
+	// minRouteWeight is the decayed count below which a route is forgotten:
+	// one visit that has aged about three learning periods.
+	minRouteWeight = 0.05
+
+	// maxBaselineRoutes and maxBaselineIPs bound a baseline's size for
+	// users who touch many routes or addresses; the weakest are dropped.
+	maxBaselineRoutes = 500
+	maxBaselineIPs    = 100
+
+	// hourlyRateAlpha and durationAlpha weight the newest hour and the
+	// newest request in the moving averages.
+	hourlyRateAlpha = 0.1
+	durationAlpha   = 0.05
+)
+
+// observeActivity folds one activity into a baseline. tau is the learning
+// period, the time constant of the decay. Activities may arrive slightly
+// out of order; an older one is counted but decays nothing.
+func observeActivity(b *sentinel.UserBaseline, a *sentinel.UserActivity, tau time.Duration) {
+	at := a.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	if b.Routes == nil {
+		b.Routes = make(map[string]float64)
+	}
+	if b.SourceIPs == nil {
+		b.SourceIPs = make(map[string]time.Time)
+	}
+	if b.Countries == nil {
+		b.Countries = make(map[string]time.Time)
+	}
+
+	if !b.UpdatedAt.IsZero() && at.After(b.UpdatedAt) {
+		decay := math.Exp(-at.Sub(b.UpdatedAt).Seconds() / tau.Seconds())
+		for h := range b.HourWeights {
+			b.HourWeights[h] *= decay
+		}
+		b.Weight *= decay
+		for route, w := range b.Routes {
+			if w *= decay; w < minRouteWeight {
+				delete(b.Routes, route)
+			} else {
+				b.Routes[route] = w
+			}
+		}
+	}
+	b.HourWeights[at.Hour()]++
+	b.Weight++
+	b.Routes[a.Method+" "+a.Path]++
+	if len(b.Routes) > maxBaselineRoutes {
+		dropWeakestRoute(b.Routes)
+	}
+
+	b.SourceIPs[a.IP] = at
+	forgetStale(b.SourceIPs, at, tau, maxBaselineIPs)
+	if a.Country != "" {
+		b.Countries[a.Country] = at
+		forgetStale(b.Countries, at, tau, maxBaselineIPs)
+	}
+
+	switch hour := at.Truncate(time.Hour); {
+	case hour.Equal(b.HourStart):
+		b.HourCount++
+	case hour.After(b.HourStart):
+		if b.HourCount > 0 {
+			b.RequestsPerHour = movingAverage(b.RequestsPerHour, float64(b.HourCount), hourlyRateAlpha, b.RequestsPerHour == 0)
+		}
+		b.HourStart, b.HourCount = hour, 1
+	}
+
+	b.AvgDuration = movingAverage(b.AvgDuration, float64(a.Duration), durationAlpha, b.Samples == 0)
+	b.Samples++
+	if b.FirstSeen.IsZero() || at.Before(b.FirstSeen) {
+		b.FirstSeen = at
+	}
+	if at.After(b.UpdatedAt) {
+		b.UpdatedAt = at
+	}
+}
+
+// currentHourCount is the request count of the activity's hour, the
+// activity included.
+func currentHourCount(b *sentinel.UserBaseline, at time.Time) int {
+	if at.Truncate(time.Hour).Equal(b.HourStart) {
+		return b.HourCount + 1
+	}
+	return 1
+}
+
+func movingAverage(avg, v, alpha float64, first bool) float64 {
+	if first {
+		return v
+	}
+	return (1-alpha)*avg + alpha*v
+}
+
+func dropWeakestRoute(routes map[string]float64) {
+	weakest, lowest := "", math.Inf(1)
+	for route, w := range routes {
+		if w < lowest {
+			weakest, lowest = route, w
+		}
+	}
+	delete(routes, weakest)
+}
+
+// forgetStale drops values not seen within tau of now, then the oldest
+// ones beyond limit.
+func forgetStale(seen map[string]time.Time, now time.Time, tau time.Duration, limit int) {
+	for v, at := range seen {
+		if now.Sub(at) > tau {
+			delete(seen, v)
+		}
+	}
+	for len(seen) > limit {
+		oldest, oldestAt := "", now
+		for v, at := range seen {
+			if oldest == "" || at.Before(oldestAt) {
+				oldest, oldestAt = v, at
+			}
+		}
+		delete(seen, oldest)
+	}
+}
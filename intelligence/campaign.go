@@ -0,0 +1,883 @@
+package intelligence
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/storage"
+	"github.com/google/uuid"
+)
+
+// Campaign signal kinds, as they appear in CampaignSignal.Kind.
+const (
+	CampaignSignalPayload   = "payload"
+	CampaignSignalUserAgent = "user_agent"
+	CampaignSignalHeaders   = "headers"
+	CampaignSignalASN       = "asn"
+	CampaignSignalTiming    = "timing"
+	CampaignSignalTLS       = "tls"
+)
+
+// campaignSignalWeights are the weights of the signal kinds. Each kind
+// counts once per pair of actors, however many values of it they share.
+var campaignSignalWeights = map[string]int{
+	CampaignSignalPayload:   2,
+	CampaignSignalUserAgent: 1,
+	CampaignSignalHeaders:   1,
+	CampaignSignalASN:       1,
+	CampaignSignalTiming:    1,
+	CampaignSignalTLS:       1,
+}
+
+// campaignStrongSignals are the kinds that point to one tool or operator.
+// A link needs one of them: a User-Agent, network and time slot alone are
+// shared by unrelated users of one browser on one ISP.
+var campaignStrongSignals = map[string]bool{
+	CampaignSignalPayload: true,
+	CampaignSignalHeaders: true,
+	CampaignSignalTLS:     true,
+}
+
+const (
+	// maxActorSignals bounds the signal values remembered per actor; the
+	// least recently seen go first.
+	maxActorSignals = 50
+
+	// maxIndexedActors bounds the actors indexed under one signal value, so
+	// a value every client shares does not make each threat compare
+	// against every actor.
+	maxIndexedActors = 500
+
+	// maxCampaignActorIPs bounds Campaign.ActorIPs. ActorCount keeps
+	// counting past it.
+	maxCampaignActorIPs = 1000
+
+	// maxCampaignRoutes and maxCampaignSignals bound the other lists of a
+	// campaign.
+	maxCampaignRoutes  = 50
+	maxCampaignSignals = 20
+
+	// minPayloadLength is the shortest normalized payload used as a signal;
+	// shorter ones, like a lone quote, are shared by unrelated attackers.
+	minPayloadLength = 8
+
+	// campaignBlockPrefix starts the BlockedIP.Reason of IPs blocked with a
+	// campaign, so unblocking the campaign leaves other blocks alone.
+	campaignBlockPrefix = "campaign "
+)
+
+// CampaignEngine groups threat actors into campaigns. Each threat adds the
+// signals of its request to its actor: the attack payloads, User-Agent,
+// header set, ASN and time slot. An actor is linked to every actor whose
+// shared signals weigh at least LinkScore, and linked actors end up in one
+// campaign, merging campaigns where needed.
+//
+// The signals of the last Window are kept in memory; campaigns and actors'
+// campaign IDs are stored. It runs inside the Profiler, which passes it
+// each threat with the actor's updated profile.
+type CampaignEngine struct {
+	store     storage.Store
+	geoLoc    *GeoLocator
+	ipManager *IPManager
+	config    sentinel.CampaignConfig
+
+	loadOnce  sync.Once
+	mu        sync.Mutex
+	actors    map[string]*campaignActor
+	index     map[string]map[string]struct{} // signal key -> actor IPs
+	merged    map[string]campaignMerge       // merged campaign ID -> survivor
+	lastSweep time.Time
+
+	// ioMu serializes the block and actor writes done outside mu, so a
+	// campaign's blocks are only placed or lifted by one caller at a time.
+	ioMu sync.Mutex
+}
+
+type campaignMerge struct {
+	into string
+	at   time.Time
+}
+
+// campaignIO holds the block and actor writes of an update, collected
+// under the engine's lock and done by flush once it is released.
+type campaignIO struct {
+	blocks map[string][]string // campaign ID -> actor IPs to block
+	moved  []string            // IDs of blocked campaigns merged away
+	actors []string            // IPs of actors whose campaign changed
+}
+
+func (io *campaignIO) block(id string, ips ...string) {
+	if io.blocks == nil {
+		io.blocks = make(map[string][]string)
+	}
+	io.blocks[id] = append(io.blocks[id], ips...)
+}
+
+type campaignActor struct {
+	ip         string
+	campaignID string
+	signals    map[string]time.Time // signal key -> last seen
+	lastSeen   time.Time
+}
+
+// NewCampaignEngine creates a campaign engine. geoLoc may be nil, which
+// disables the ASN signal; ipManager may be nil, in which case blocks go
+// straight to the store.
+func NewCampaignEngine(store storage.Store, geoLoc *GeoLocator, ipManager *IPManager, config sentinel.CampaignConfig) *CampaignEngine {
+	if config.LinkScore <= 0 {
+		config.LinkScore = 3
+	}
+	if config.Window <= 0 {
+		config.Window = 24 * time.Hour
+	}
+	if config.TimingBucket <= 0 {
+		config.TimingBucket = 10 * time.Minute
+	}
+	return &CampaignEngine{
+		store:     store,
+		geoLoc:    geoLoc,
+		ipManager: ipManager,
+		config:    config,
+		actors:    make(map[string]*campaignActor),
+		index:     make(map[string]map[string]struct{}),
+		merged:    make(map[string]campaignMerge),
+	}
+}
+
+// Observe records a threat of actor and returns the ID of the campaign the
+// actor belongs to afterwards, or "". actor is the actor's profile already
+// updated with te; the caller stores it with the returned ID.
+func (e *CampaignEngine) Observe(ctx context.Context, te *sentinel.ThreatEvent, actor *sentinel.ThreatActor) string {
+	if te.IP == "" {
+		return actor.CampaignID
+	}
+	e.loadOnce.Do(func() { e.load(ctx) })
+
+	at := te.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	keys := e.signalKeys(ctx, te, at)
+
+	var io campaignIO
+	defer e.flush(ctx, &io)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sweep(at)
+
+	a := e.actors[te.IP]
+	if a == nil {
+		a = &campaignActor{ip: te.IP, campaignID: actor.CampaignID, signals: make(map[string]time.Time)}
+		e.actors[te.IP] = a
+	}
+	a.lastSeen = at
+	for _, key := range keys {
+		e.addSignal(a, key, at)
+	}
+
+	// Threats of an actor already in a campaign count towards it.
+	if a.campaignID != "" {
+		if c := e.getCampaign(ctx, a.campaignID); c != nil {
+			foldThreat(c, te, at)
+			e.save(ctx, c)
+		} else {
+			a.campaignID = ""
+		}
+	}
+
+	for _, ip := range e.candidates(a, keys) {
+		other := e.actors[ip]
+		if other.campaignID != "" && other.campaignID == a.campaignID {
+			continue
+		}
+		shared := e.shared(a, other)
+		if shared == nil {
+			continue
+		}
+		e.link(ctx, &io, a, actor, other, shared, at)
+	}
+	return a.campaignID
+}
+
+// Block blocks every actor of a campaign, and the actors that join it
+// later, until expiry (nil: permanently).
+func (e *CampaignEngine) Block(ctx context.Context, id, reason string, expiry *time.Time) (*sentinel.Campaign, error) {
+	return e.setBlock(ctx, id, func(c *sentinel.Campaign) {
+		c.Status = sentinel.CampaignBlocked
+		c.BlockReason = reason
+		c.BlockExpiry = expiry
+	})
+}
+
+// Unblock lifts a campaign's block. Only the IPs blocked with the campaign
+// are unblocked; blocks placed on them otherwise stay.
+func (e *CampaignEngine) Unblock(ctx context.Context, id string) (*sentinel.Campaign, error) {
+	return e.setBlock(ctx, id, func(c *sentinel.Campaign) {
+		c.Status = sentinel.CampaignActive
+		c.BlockReason = ""
+		c.BlockExpiry = nil
+	})
+}
+
+// setBlock applies update to a campaign's block settings, then replaces
+// the blocks the campaign placed with blocks on all its actors if it is
+// blocked. The blocks are written without holding mu.
+func (e *CampaignEngine) setBlock(ctx context.Context, id string, update func(*sentinel.Campaign)) (*sentinel.Campaign, error) {
+	e.loadOnce.Do(func() { e.load(ctx) })
+	e.ioMu.Lock()
+	defer e.ioMu.Unlock()
+
+	e.mu.Lock()
+	c, err := e.store.GetCampaign(ctx, id)
+	if err != nil || c == nil {
+		e.mu.Unlock()
+		return nil, err
+	}
+	update(c)
+	ips := e.memberIPs(c)
+	err = e.store.SaveCampaign(ctx, c)
+	e.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.liftBlocks(ctx, c); err != nil {
+		return nil, err
+	}
+	if c.Status == sentinel.CampaignBlocked {
+		for _, ip := range ips {
+			if err := e.blockIP(ctx, c, ip); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return c, nil
+}
+
+// memberIPs returns the IPs of a campaign's actors: those stored with it
+// and those past maxCampaignActorIPs, which are only known in memory.
+func (e *CampaignEngine) memberIPs(c *sentinel.Campaign) []string {
+	ips := append([]string(nil), c.ActorIPs...)
+	seen := make(map[string]bool, len(ips))
+	for _, ip := range ips {
+		seen[ip] = true
+	}
+	for ip, a := range e.actors {
+		if a.campaignID == c.ID && !seen[ip] {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+// flush does the writes an update collected, once mu is released. Each
+// write is checked against the campaigns' current state, as they may have
+// been merged, blocked or unblocked since.
+func (e *CampaignEngine) flush(ctx context.Context, io *campaignIO) {
+	if len(io.blocks) == 0 && len(io.moved) == 0 && len(io.actors) == 0 {
+		return
+	}
+	e.ioMu.Lock()
+	defer e.ioMu.Unlock()
+
+	e.mu.Lock()
+	blocks := make(map[string][]string, len(io.blocks))
+	for id, ips := range io.blocks {
+		id = e.survivor(id)
+		blocks[id] = append(blocks[id], ips...)
+	}
+	moved := make(map[string]string, len(io.moved))
+	for _, id := range io.moved {
+		moved[id] = e.survivor(id)
+	}
+	actors := make(map[string]string, len(io.actors))
+	for _, ip := range io.actors {
+		if a := e.actors[ip]; a != nil && a.campaignID != "" {
+			actors[ip] = a.campaignID
+		}
+	}
+	e.mu.Unlock()
+
+	for id, ips := range blocks {
+		c := e.getCampaign(ctx, id)
+		if c == nil || c.Status != sentinel.CampaignBlocked {
+			continue
+		}
+		for _, ip := range ips {
+			e.blockOrLog(ctx, c, ip)
+		}
+	}
+	for from, into := range moved {
+		e.moveBlocks(ctx, from, e.getCampaign(ctx, into))
+	}
+	for ip, id := range actors {
+		if profile := e.actorProfile(ctx, ip); profile != nil && profile.CampaignID != id {
+			e.setActorCampaign(ctx, profile, id)
+		}
+	}
+}
+
+// survivor returns the campaign a campaign ID was merged into, or the ID
+// itself if it was not merged.
+func (e *CampaignEngine) survivor(id string) string {
+	for {
+		m, ok := e.merged[id]
+		if !ok {
+			return id
+		}
+		id = m.into
+	}
+}
+
+// liftBlocks unblocks the IPs blocked with a campaign.
+func (e *CampaignEngine) liftBlocks(ctx context.Context, c *sentinel.Campaign) error {
+	blocked, err := e.store.ListBlockedIPs(ctx)
+	if err != nil {
+		return err
+	}
+	prefix := campaignBlockPrefix + c.ID
+	for _, b := range blocked {
+		if !strings.HasPrefix(b.Reason, prefix) {
+			continue
+		}
+		if err := e.unblockIP(ctx, b.IP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ComputeCampaignRiskScore calculates a risk score (0-100) for a campaign:
+//   - +10 for each unique attack type (max 40)
+//   - +10 for 2-9 actors, +20 for 10-99, +30 for 100 or more
+//   - +20 if threat count > 100
+//   - +10 if active in the last hour
+//   - Capped at 100
+func ComputeCampaignRiskScore(c *sentinel.Campaign) int {
+	score := len(c.AttackTypes) * 10
+	if score > 40 {
+		score = 40
+	}
+	switch {
+	case c.ActorCount >= 100:
+		score += 30
+	case c.ActorCount >= 10:
+		score += 20
+	case c.ActorCount >= 2:
+		score += 10
+	}
+	if c.ThreatCount > 100 {
+		score += 20
+	}
+	if time.Since(c.LastSeen) < time.Hour {
+		score += 10
+	}
+	if score > 100 {
+		score = 100
+	}
+	return score
+}
+
+// link puts two linked actors in one campaign: a new one, the campaign of
+// either, or, if both have one, the larger one with the other merged in.
+// cur is the profile of a, the actor of the current threat.
+func (e *CampaignEngine) link(ctx context.Context, io *campaignIO, a *campaignActor, cur *sentinel.ThreatActor, other *campaignActor, shared []string, at time.Time) {
+	var c *sentinel.Campaign
+	switch {
+	case a.campaignID == "" && other.campaignID == "":
+		c = &sentinel.Campaign{
+			ID:        uuid.New().String(),
+			FirstSeen: at,
+			LastSeen:  at,
+			Status:    sentinel.CampaignActive,
+		}
+		e.join(ctx, io, c, a, cur, false)
+		e.join(ctx, io, c, other, e.actorProfile(ctx, other.ip), true)
+	case other.campaignID == "":
+		if c = e.getCampaign(ctx, a.campaignID); c == nil {
+			return
+		}
+		e.join(ctx, io, c, other, e.actorProfile(ctx, other.ip), true)
+	case a.campaignID == "":
+		if c = e.getCampaign(ctx, other.campaignID); c == nil {
+			return
+		}
+		e.join(ctx, io, c, a, cur, false)
+	default:
+		c = e.getCampaign(ctx, a.campaignID)
+		from := e.getCampaign(ctx, other.campaignID)
+		if c == nil || from == nil {
+			return
+		}
+		if from.ActorCount > c.ActorCount {
+			c, from = from, c
+		}
+		e.merge(ctx, io, c, from, a.ip)
+	}
+
+	for _, key := range shared {
+		addCampaignSignal(c, key)
+	}
+	e.save(ctx, c)
+}
+
+// join adds an actor, with its profile so far, to a campaign. persist
+// stores the profile with the campaign ID; the current threat's actor is
+// stored by the Profiler instead.
+func (e *CampaignEngine) join(ctx context.Context, io *campaignIO, c *sentinel.Campaign, a *campaignActor, profile *sentinel.ThreatActor, persist bool) {
+	a.campaignID = c.ID
+	c.ActorCount++
+	if len(c.ActorIPs) < maxCampaignActorIPs {
+		c.ActorIPs = append(c.ActorIPs, a.ip)
+	}
+	if profile != nil {
+		c.ThreatCount += profile.ThreatCount
+		if !profile.FirstSeen.IsZero() && profile.FirstSeen.Before(c.FirstSeen) {
+			c.FirstSeen = profile.FirstSeen
+		}
+		if profile.LastSeen.After(c.LastSeen) {
+			c.LastSeen = profile.LastSeen
+		}
+		c.AttackTypes = appendUnique(c.AttackTypes, profile.AttackTypes, 0)
+		c.TargetedRoutes = appendUnique(c.TargetedRoutes, profile.TargetedRoutes, maxCampaignRoutes)
+		if profile.Country != "" {
+			c.Countries = appendUnique(c.Countries, []string{profile.Country}, 0)
+		}
+	}
+	if c.Status == sentinel.CampaignBlocked {
+		io.block(c.ID, a.ip)
+	}
+	if persist && profile != nil {
+		e.setActorCampaign(ctx, profile, c.ID)
+	}
+}
+
+// merge moves the actors of from into c and deletes from. curIP is the IP
+// of the current threat's actor.
+func (e *CampaignEngine) merge(ctx context.Context, io *campaignIO, c, from *sentinel.Campaign, curIP string) {
+	c.ActorCount += from.ActorCount
+	c.ThreatCount += from.ThreatCount
+	if from.FirstSeen.Before(c.FirstSeen) {
+		c.FirstSeen = from.FirstSeen
+	}
+	if from.LastSeen.After(c.LastSeen) {
+		c.LastSeen = from.LastSeen
+	}
+	c.AttackTypes = appendUnique(c.AttackTypes, from.AttackTypes, 0)
+	c.TargetedRoutes = appendUnique(c.TargetedRoutes, from.TargetedRoutes, maxCampaignRoutes)
+	c.Countries = appendUnique(c.Countries, from.Countries, 0)
+	for _, s := range from.Signals {
+		addCampaignSignalLinks(c, s.Kind, s.Value, s.Links)
+	}
+
+	// A block on either campaign carries over to the merged one.
+	blockAll := c.Status != sentinel.CampaignBlocked && from.Status == sentinel.CampaignBlocked
+	if blockAll {
+		c.Status, c.BlockReason, c.BlockExpiry = from.Status, from.BlockReason, from.BlockExpiry
+	}
+	blockFrom := c.Status == sentinel.CampaignBlocked && from.Status != sentinel.CampaignBlocked
+	if from.Status == sentinel.CampaignBlocked {
+		io.moved = append(io.moved, from.ID)
+	}
+
+	for _, ip := range from.ActorIPs {
+		if len(c.ActorIPs) < maxCampaignActorIPs {
+			c.ActorIPs = append(c.ActorIPs, ip)
+		}
+		a := e.actors[ip]
+		if a == nil {
+			// Stored with from but forgotten here: remember it, so flush
+			// can store the actor's new campaign.
+			a = &campaignActor{ip: ip, signals: make(map[string]time.Time), lastSeen: from.LastSeen}
+			e.actors[ip] = a
+		}
+		a.campaignID = c.ID
+		if ip != curIP {
+			io.actors = append(io.actors, ip)
+		}
+	}
+	// Actors past maxCampaignActorIPs are only known in memory.
+	for _, a := range e.actors {
+		if a.campaignID == from.ID {
+			a.campaignID = c.ID
+		}
+	}
+	if blockAll || blockFrom {
+		io.block(c.ID, e.memberIPs(c)...)
+	}
+	e.merged[from.ID] = campaignMerge{into: c.ID, at: time.Now()}
+	if err := e.store.DeleteCampaign(ctx, from.ID); err != nil {
+		log.Printf("[sentinel] campaigns: failed to delete merged campaign %s: %v", from.ID, err)
+	}
+}
+
+// candidates returns the IPs of the actors sharing one of keys with a,
+// other than by timing alone.
+func (e *CampaignEngine) candidates(a *campaignActor, keys []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, key := range keys {
+		if signalKind(key) == CampaignSignalTiming {
+			continue
+		}
+		for ip := range e.index[key] {
+			if ip != a.ip && !seen[ip] {
+				seen[ip] = true
+				out = append(out, ip)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// shared returns the keys two actors share, one per kind, if their weight
+// reaches LinkScore and they include a strong signal; otherwise nil.
+func (e *CampaignEngine) shared(a, b *campaignActor) []string {
+	small, large := a.signals, b.signals
+	if len(large) < len(small) {
+		small, large = large, small
+	}
+	byKind := make(map[string]string)
+	for key := range small {
+		if _, ok := large[key]; !ok {
+			continue
+		}
+		kind := signalKind(key)
+		if prev, ok := byKind[kind]; !ok || key < prev {
+			byKind[kind] = key
+		}
+	}
+	weight, strong := 0, false
+	for kind := range byKind {
+		weight += campaignSignalWeights[kind]
+		strong = strong || campaignStrongSignals[kind]
+	}
+	if weight < e.config.LinkScore || !strong {
+		return nil
+	}
+	keys := make([]string, 0, len(byKind))
+	for _, key := range byKind {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// signalKeys returns the signal keys ("kind:value") of a threat.
+func (e *CampaignEngine) signalKeys(ctx context.Context, te *sentinel.ThreatEvent, at time.Time) []string {
+	var keys []string
+	for _, ev := range te.Evidence {
+		if p := normalizePayload(ev.Matched); len(p) >= minPayloadLength {
+			keys = append(keys, CampaignSignalPayload+":"+p)
+		}
+	}
+	if ua := strings.TrimSpace(te.UserAgent); ua != "" {
+		keys = append(keys, CampaignSignalUserAgent+":"+truncate(ua, 200))
+	}
+	if te.HeaderFingerprint != "" {
+		keys = append(keys, CampaignSignalHeaders+":"+te.HeaderFingerprint)
+	}
+	if te.JA4 != "" {
+		keys = append(keys, CampaignSignalTLS+":"+te.JA4)
+	} else if te.JA3 != "" {
+		keys = append(keys, CampaignSignalTLS+":"+te.JA3)
+	}
+	if e.geoLoc != nil {
+		if geo, err := e.geoLoc.LookupIP(ctx, te.IP); err == nil && geo != nil {
+			if asn := asnNumber(geo.ASN); asn != "" {
+				keys = append(keys, CampaignSignalASN+":"+asn)
+			}
+		}
+	}
+	slot := at.Truncate(e.config.TimingBucket)
+	keys = append(keys, CampaignSignalTiming+":"+slot.UTC().Format(time.RFC3339))
+	return keys
+}
+
+// addSignal records a signal of an actor and indexes the actor under it.
+func (e *CampaignEngine) addSignal(a *campaignActor, key string, at time.Time) {
+	if _, ok := a.signals[key]; !ok && len(a.signals) >= maxActorSignals {
+		oldest, oldestAt := "", time.Time{}
+		for k, t := range a.signals {
+			if oldest == "" || t.Before(oldestAt) {
+				oldest, oldestAt = k, t
+			}
+		}
+		delete(a.signals, oldest)
+		e.unindex(oldest, a.ip)
+	}
+	a.signals[key] = at
+	set := e.index[key]
+	if set == nil {
+		set = make(map[string]struct{})
+		e.index[key] = set
+	}
+	if len(set) < maxIndexedActors {
+		set[a.ip] = struct{}{}
+	}
+}
+
+func (e *CampaignEngine) unindex(key, ip string) {
+	if set := e.index[key]; set != nil {
+		delete(set, ip)
+		if len(set) == 0 {
+			delete(e.index, key)
+		}
+	}
+}
+
+// sweep forgets the signals and actors idle for longer than the window, at
+// most once a minute.
+func (e *CampaignEngine) sweep(now time.Time) {
+	if now.Sub(e.lastSweep) < time.Minute {
+		return
+	}
+	e.lastSweep = now
+	cutoff := now.Add(-e.config.Window)
+	for ip, a := range e.actors {
+		for key, t := range a.signals {
+			if t.Before(cutoff) {
+				delete(a.signals, key)
+				e.unindex(key, ip)
+			}
+		}
+		if a.lastSeen.Before(cutoff) {
+			delete(e.actors, ip)
+		}
+	}
+	for id, m := range e.merged {
+		if m.at.Before(cutoff) {
+			delete(e.merged, id)
+		}
+	}
+}
+
+// load restores which campaign each actor of a recent campaign belongs to.
+func (e *CampaignEngine) load(ctx context.Context) {
+	cutoff := time.Now().Add(-e.config.Window)
+	for page := 1; page <= 100; page++ {
+		campaigns, _, err := e.store.ListCampaigns(ctx, sentinel.CampaignFilter{Page: page, PageSize: 100})
+		if err != nil {
+			log.Printf("[sentinel] campaigns: failed to load campaigns: %v", err)
+			return
+		}
+		e.mu.Lock()
+		for _, c := range campaigns {
+			if c.LastSeen.Before(cutoff) {
+				continue
+			}
+			for _, ip := range c.ActorIPs {
+				if e.actors[ip] == nil {
+					e.actors[ip] = &campaignActor{ip: ip, campaignID: c.ID, signals: make(map[string]time.Time), lastSeen: c.LastSeen}
+				}
+			}
+		}
+		e.mu.Unlock()
+		if len(campaigns) < 100 {
+			return
+		}
+	}
+}
+
+func (e *CampaignEngine) getCampaign(ctx context.Context, id string) *sentinel.Campaign {
+	c, err := e.store.GetCampaign(ctx, id)
+	if err != nil {
+		log.Printf("[sentinel] campaigns: failed to load campaign %s: %v", id, err)
+		return nil
+	}
+	return c
+}
+
+func (e *CampaignEngine) save(ctx context.Context, c *sentinel.Campaign) {
+	if c.Status == sentinel.CampaignBlocked && c.BlockExpiry != nil && time.Now().After(*c.BlockExpiry) {
+		c.Status, c.BlockReason, c.BlockExpiry = sentinel.CampaignActive, "", nil
+	}
+	c.RiskScore = ComputeCampaignRiskScore(c)
+	if err := e.store.SaveCampaign(ctx, c); err != nil {
+		log.Printf("[sentinel] campaigns: failed to save campaign %s: %v", c.ID, err)
+	}
+}
+
+func (e *CampaignEngine) actorProfile(ctx context.Context, ip string) *sentinel.ThreatActor {
+	actor, err := e.store.GetActor(ctx, ip)
+	if err != nil {
+		log.Printf("[sentinel] campaigns: failed to load actor %s: %v", ip, err)
+		return nil
+	}
+	return actor
+}
+
+func (e *CampaignEngine) setActorCampaign(ctx context.Context, actor *sentinel.ThreatActor, id string) {
+	actor.CampaignID = id
+	if err := e.store.UpsertActor(ctx, actor); err != nil {
+		log.Printf("[sentinel] campaigns: failed to update actor %s: %v", actor.IP, err)
+	}
+}
+
+// moveBlocks hands the IPs blocked with from, a campaign merged into c,
+// over to c, so that unblocking c lifts them too. If c is no longer
+// blocked, or is nil, the blocks are lifted instead.
+func (e *CampaignEngine) moveBlocks(ctx context.Context, from string, c *sentinel.Campaign) {
+	blocked, err := e.store.ListBlockedIPs(ctx)
+	if err != nil {
+		log.Printf("[sentinel] campaigns: failed to move blocks of campaign %s: %v", from, err)
+		return
+	}
+	keep := c != nil && c.Status == sentinel.CampaignBlocked &&
+		(c.BlockExpiry == nil || time.Now().Before(*c.BlockExpiry))
+	prefix := campaignBlockPrefix + from
+	for _, b := range blocked {
+		if !strings.HasPrefix(b.Reason, prefix) {
+			continue
+		}
+		if keep {
+			err = e.writeBlock(ctx, c, b.IP)
+		} else {
+			err = e.unblockIP(ctx, b.IP)
+		}
+		if err != nil {
+			log.Printf("[sentinel] campaigns: failed to move block of %s from campaign %s: %v", b.IP, from, err)
+		}
+	}
+}
+
+// blockIP blocks one IP of a blocked campaign, unless it is whitelisted or
+// already blocked: an existing block keeps its own reason and expiry.
+func (e *CampaignEngine) blockIP(ctx context.Context, c *sentinel.Campaign, ip string) error {
+	if c.BlockExpiry != nil && time.Now().After(*c.BlockExpiry) {
+		return nil
+	}
+	if e.ipManager != nil {
+		if e.ipManager.IsWhitelisted(ip) || e.ipManager.IsBlocked(ip) {
+			return nil
+		}
+	} else {
+		if ok, _ := e.store.IsIPWhitelisted(ctx, ip); ok {
+			return nil
+		}
+		if ok, _ := e.store.IsIPBlocked(ctx, ip); ok {
+			return nil
+		}
+	}
+	return e.writeBlock(ctx, c, ip)
+}
+
+// writeBlock blocks ip with campaign c's reason and expiry, replacing any
+// block it has.
+func (e *CampaignEngine) writeBlock(ctx context.Context, c *sentinel.Campaign, ip string) error {
+	reason := campaignBlockPrefix + c.ID
+	if c.BlockReason != "" {
+		reason += ": " + c.BlockReason
+	}
+	if e.ipManager != nil {
+		return e.ipManager.BlockIP(ctx, ip, reason, c.BlockExpiry)
+	}
+	return e.store.BlockIP(ctx, ip, reason, c.BlockExpiry)
+}
+
+func (e *CampaignEngine) unblockIP(ctx context.Context, ip string) error {
+	if e.ipManager != nil {
+		return e.ipManager.UnblockIP(ctx, ip)
+	}
+	return e.store.UnblockIP(ctx, ip)
+}
+
+func (e *CampaignEngine) blockOrLog(ctx context.Context, c *sentinel.Campaign, ip string) {
+	if err := e.blockIP(ctx, c, ip); err != nil {
+		log.Printf("[sentinel] campaigns: failed to block %s with campaign %s: %v", ip, c.ID, err)
+	}
+}
+
+// foldThreat counts a threat of a member actor towards its campaign.
+func foldThreat(c *sentinel.Campaign, te *sentinel.ThreatEvent, at time.Time) {
+	c.ThreatCount++
+	if at.After(c.LastSeen) {
+		c.LastSeen = at
+	}
+	c.AttackTypes = appendUnique(c.AttackTypes, te.ThreatTypes, 0)
+	c.TargetedRoutes = appendUnique(c.TargetedRoutes, []string{te.Method + " " + te.Path}, maxCampaignRoutes)
+	if te.Country != "" {
+		c.Countries = appendUnique(c.Countries, []string{te.Country}, 0)
+	}
+}
+
+func addCampaignSignal(c *sentinel.Campaign, key string) {
+	kind, value, _ := strings.Cut(key, ":")
+	addCampaignSignalLinks(c, kind, value, 1)
+}
+
+// addCampaignSignalLinks adds links to a campaign's signal, keeping the
+// maxCampaignSignals signals with the most links.
+func addCampaignSignalLinks(c *sentinel.Campaign, kind, value string, links int) {
+	for i := range c.Signals {
+		if c.Signals[i].Kind == kind && c.Signals[i].Value == value {
+			c.Signals[i].Links += links
+			sortCampaignSignals(c.Signals)
+			return
+		}
+	}
+	c.Signals = append(c.Signals, sentinel.CampaignSignal{Kind: kind, Value: value, Links: links})
+	sortCampaignSignals(c.Signals)
+	if len(c.Signals) > maxCampaignSignals {
+		c.Signals = c.Signals[:maxCampaignSignals]
+	}
+}
+
+func sortCampaignSignals(signals []sentinel.CampaignSignal) {
+	sort.SliceStable(signals, func(i, j int) bool { return signals[i].Links > signals[j].Links })
+}
+
+// appendUnique appends the values of add not in list, up to limit entries
+// (0: no limit).
+func appendUnique(list, add []string, limit int) []string {
+	for _, v := range add {
+		if limit > 0 && len(list) >= limit {
+			break
+		}
+		if !containsStr(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// normalizePayload lowercases a matched payload, collapses whitespace and
+// replaces runs of digits with 0, so the same exploit with another ID or
+// spacing compares equal.
+func normalizePayload(s string) string {
+	var b strings.Builder
+	space, digit := false, false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		switch {
+		case unicode.IsSpace(r):
+			if !space {
+				b.WriteByte(' ')
+			}
+			space, digit = true, false
+		case unicode.IsDigit(r):
+			if !digit {
+				b.WriteByte('0')
+			}
+			space, digit = false, true
+		default:
+			b.WriteRune(r)
+			space, digit = false, false
+		}
+		if b.Len() >= 200 {
+			break
+		}
+	}
+	return b.String()
+}
+
+func signalKind(key string) string {
+	kind, _, _ := strings.Cut(key, ":")
+	return kind
+}
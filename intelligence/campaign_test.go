@@ -0,0 +1,299 @@
+package intelligence_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/intelligence"
+	"github.com/MUKE-coder/sentinel/v2/storage/memory"
+)
+
+func setupCampaignTest(t *testing.T) (*memory.Store, *intelligence.Profiler, *intelligence.CampaignEngine, *intelligence.IPManager) {
+	t.Helper()
+	store := memory.New()
+	store.Migrate(context.Background())
+	ipManager := intelligence.NewIPManager(store)
+	t.Cleanup(ipManager.Stop)
+
+	engine := intelligence.NewCampaignEngine(store, nil, ipManager, sentinel.CampaignConfig{Enabled: true})
+	profiler := intelligence.NewProfiler(store)
+	profiler.SetCampaignEngine(engine)
+	return store, profiler, engine, ipManager
+}
+
+// scan is a threat from one node of a scanner: the same tool and header set
+// from a different IP each time.
+func scan(ip, ua, headers string, at time.Time) *sentinel.ThreatEvent {
+	return &sentinel.ThreatEvent{
+		ID:                "te-" + ip + "-" + ua,
+		Timestamp:         at,
+		IP:                ip,
+		Method:            "GET",
+		Path:              "/api/users",
+		UserAgent:         ua,
+		HeaderFingerprint: headers,
+		ThreatTypes:       []string{"SQLInjection"},
+		Evidence:          []sentinel.Evidence{{Matched: "1' OR '1'='1", Location: "query"}},
+	}
+}
+
+func campaignOf(t *testing.T, store *memory.Store, ip string) string {
+	t.Helper()
+	actor, _ := store.GetActor(context.Background(), ip)
+	if actor == nil {
+		t.Fatalf("no actor for %s", ip)
+	}
+	return actor.CampaignID
+}
+
+func TestCampaigns_GroupsRotatingIPs(t *testing.T) {
+	store, profiler, _, _ := setupCampaignTest(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	for i := 1; i <= 5; i++ {
+		profiler.ProcessThreat(ctx, scan(fmt.Sprintf("198.51.100.%d", i), "scanner/1.0", "hdr-a", now))
+	}
+	// Same time slot and payload, but another tool: linked by payload and
+	// timing alone, which reach the link score.
+	profiler.ProcessThreat(ctx, scan("203.0.113.9", "othertool/2.0", "hdr-b", now))
+	// Another tool, another payload, hours later: unrelated.
+	loner := scan("192.0.2.7", "curl/8.0", "hdr-c", now.Add(3*time.Hour))
+	loner.Evidence = []sentinel.Evidence{{Matched: "<script>alert(document.cookie)</script>", Location: "query"}}
+	profiler.ProcessThreat(ctx, loner)
+
+	id := campaignOf(t, store, "198.51.100.1")
+	if id == "" {
+		t.Fatal("scanner actors should be grouped into a campaign")
+	}
+	for i := 2; i <= 5; i++ {
+		if got := campaignOf(t, store, fmt.Sprintf("198.51.100.%d", i)); got != id {
+			t.Errorf("198.51.100.%d: campaign %q, want %q", i, got, id)
+		}
+	}
+	if got := campaignOf(t, store, "203.0.113.9"); got != id {
+		t.Errorf("shared payload and timing should link: campaign %q, want %q", got, id)
+	}
+	if got := campaignOf(t, store, "192.0.2.7"); got != "" {
+		t.Errorf("unrelated actor should not join a campaign, got %q", got)
+	}
+
+	c, _ := store.GetCampaign(ctx, id)
+	if c == nil || c.ActorCount != 6 || c.ThreatCount != 6 || len(c.ActorIPs) != 6 {
+		t.Fatalf("unexpected campaign %+v", c)
+	}
+	if c.RiskScore != intelligence.ComputeCampaignRiskScore(c) || c.RiskScore == 0 {
+		t.Errorf("risk score %d not computed", c.RiskScore)
+	}
+	kinds := map[string]bool{}
+	for _, s := range c.Signals {
+		kinds[s.Kind] = true
+	}
+	for _, kind := range []string{intelligence.CampaignSignalUserAgent, intelligence.CampaignSignalHeaders, intelligence.CampaignSignalPayload} {
+		if !kinds[kind] {
+			t.Errorf("campaign signals %+v lack %s", c.Signals, kind)
+		}
+	}
+
+	// Later threats of members count towards the campaign.
+	profiler.ProcessThreat(ctx, scan("198.51.100.3", "scanner/1.0", "hdr-a", now.Add(time.Minute)))
+	if c, _ = store.GetCampaign(ctx, id); c.ThreatCount != 7 || c.ActorCount != 6 {
+		t.Errorf("after a member's threat: threats %d actors %d", c.ThreatCount, c.ActorCount)
+	}
+}
+
+func TestCampaigns_Merge(t *testing.T) {
+	store, profiler, _, _ := setupCampaignTest(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	for i := 1; i <= 3; i++ {
+		profiler.ProcessThreat(ctx, scan(fmt.Sprintf("198.51.100.%d", i), "tool-a", "hdr-a", now))
+		profiler.ProcessThreat(ctx, scan(fmt.Sprintf("203.0.113.%d", i), "tool-b", "hdr-b", now.Add(2*time.Hour)))
+	}
+	a, b := campaignOf(t, store, "198.51.100.1"), campaignOf(t, store, "203.0.113.1")
+	if a == "" || b == "" || a == b {
+		t.Fatalf("expected two campaigns, got %q and %q", a, b)
+	}
+
+	// One node running both tools links the campaigns.
+	profiler.ProcessThreat(ctx, scan("192.0.2.50", "tool-a", "hdr-a", now))
+	profiler.ProcessThreat(ctx, scan("192.0.2.50", "tool-b", "hdr-b", now.Add(2*time.Hour)))
+
+	merged := campaignOf(t, store, "192.0.2.50")
+	if merged == "" {
+		t.Fatal("bridging actor should be in a campaign")
+	}
+	for _, ip := range []string{"198.51.100.1", "198.51.100.3", "203.0.113.1", "203.0.113.3"} {
+		if got := campaignOf(t, store, ip); got != merged {
+			t.Errorf("%s: campaign %q, want merged %q", ip, got, merged)
+		}
+	}
+	campaigns, total, _ := store.ListCampaigns(ctx, sentinel.CampaignFilter{})
+	if total != 1 || campaigns[0].ActorCount != 7 {
+		t.Fatalf("expected one campaign of 7 actors, got %d: %+v", total, campaigns)
+	}
+}
+
+func TestCampaigns_BlockAsUnit(t *testing.T) {
+	store, profiler, engine, ipManager := setupCampaignTest(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	for i := 1; i <= 3; i++ {
+		profiler.ProcessThreat(ctx, scan(fmt.Sprintf("198.51.100.%d", i), "scanner/1.0", "hdr-a", now))
+	}
+	id := campaignOf(t, store, "198.51.100.1")
+	// Blocked on its own: unblocking the campaign must leave it blocked.
+	ipManager.BlockIP(ctx, "198.51.100.2", "manual", nil)
+
+	expiry := now.Add(time.Hour)
+	c, err := engine.Block(ctx, id, "botnet", &expiry)
+	if err != nil || c == nil || c.Status != sentinel.CampaignBlocked {
+		t.Fatalf("Block = %+v, %v", c, err)
+	}
+	for i := 1; i <= 3; i++ {
+		if ip := fmt.Sprintf("198.51.100.%d", i); !ipManager.IsBlocked(ip) {
+			t.Errorf("%s should be blocked with its campaign", ip)
+		}
+	}
+
+	// A node joining a blocked campaign is blocked too.
+	profiler.ProcessThreat(ctx, scan("198.51.100.4", "scanner/1.0", "hdr-a", now))
+	if !ipManager.IsBlocked("198.51.100.4") {
+		t.Error("an actor joining a blocked campaign should be blocked")
+	}
+	blocked, _ := store.ListBlockedIPs(ctx)
+	for _, b := range blocked {
+		if b.IP == "198.51.100.4" && !strings.Contains(b.Reason, id) {
+			t.Errorf("block reason %q should name the campaign", b.Reason)
+		}
+	}
+
+	if c, err = engine.Unblock(ctx, id); err != nil || c.Status != sentinel.CampaignActive {
+		t.Fatalf("Unblock = %+v, %v", c, err)
+	}
+	if ipManager.IsBlocked("198.51.100.1") || ipManager.IsBlocked("198.51.100.4") {
+		t.Error("campaign blocks should be lifted")
+	}
+	if !ipManager.IsBlocked("198.51.100.2") {
+		t.Error("a block placed outside the campaign should stay")
+	}
+
+	if c, err = engine.Block(ctx, "no-such-campaign", "", nil); c != nil || err != nil {
+		t.Errorf("Block(unknown) = %+v, %v", c, err)
+	}
+}
+
+func TestCampaigns_UnblockAfterMerge(t *testing.T) {
+	store, profiler, engine, ipManager := setupCampaignTest(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	for i := 1; i <= 3; i++ {
+		profiler.ProcessThreat(ctx, scan(fmt.Sprintf("198.51.100.%d", i), "tool-a", "hdr-a", now))
+	}
+	for i := 1; i <= 2; i++ {
+		profiler.ProcessThreat(ctx, scan(fmt.Sprintf("203.0.113.%d", i), "tool-b", "hdr-b", now.Add(2*time.Hour)))
+	}
+	a, b := campaignOf(t, store, "198.51.100.1"), campaignOf(t, store, "203.0.113.1")
+	if _, err := engine.Block(ctx, b, "botnet", nil); err != nil {
+		t.Fatalf("Block: %v", err)
+	}
+
+	// A shared actor merges the blocked campaign into the larger one.
+	profiler.ProcessThreat(ctx, scan("192.0.2.50", "tool-a", "hdr-a", now))
+	profiler.ProcessThreat(ctx, scan("192.0.2.50", "tool-b", "hdr-b", now.Add(2*time.Hour)))
+	if got := campaignOf(t, store, "203.0.113.1"); got != a {
+		t.Fatalf("campaign %q should have been merged into %q, got %q", b, a, got)
+	}
+	for _, ip := range []string{"198.51.100.1", "203.0.113.1", "203.0.113.2", "192.0.2.50"} {
+		if !ipManager.IsBlocked(ip) {
+			t.Errorf("%s should be blocked with the merged campaign", ip)
+		}
+	}
+
+	if _, err := engine.Unblock(ctx, a); err != nil {
+		t.Fatalf("Unblock: %v", err)
+	}
+	for _, ip := range []string{"198.51.100.1", "203.0.113.1", "203.0.113.2", "192.0.2.50"} {
+		if ipManager.IsBlocked(ip) {
+			t.Errorf("%s should be unblocked with the merged campaign", ip)
+		}
+	}
+}
+
+func TestCampaigns_BlockBeyondStoredActors(t *testing.T) {
+	store, profiler, engine, ipManager := setupCampaignTest(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	ip := func(i int) string { return fmt.Sprintf("10.%d.%d.%d", i/65536, i/256%256, i%256) }
+	const n = 1010
+	for i := 1; i <= n; i++ {
+		profiler.ProcessThreat(ctx, scan(ip(i), "botnet/1.0", "hdr-a", now))
+	}
+	id := campaignOf(t, store, ip(1))
+	c, _ := store.GetCampaign(ctx, id)
+	if c == nil || c.ActorCount != n || len(c.ActorIPs) >= n {
+		t.Fatalf("expected %d actors, not all stored with the campaign, got %+v", n, c)
+	}
+
+	if _, err := engine.Block(ctx, id, "botnet", nil); err != nil {
+		t.Fatalf("Block: %v", err)
+	}
+	for _, i := range []int{1, 1000, n} {
+		if !ipManager.IsBlocked(ip(i)) {
+			t.Errorf("%s should be blocked with its campaign", ip(i))
+		}
+	}
+	if _, err := engine.Unblock(ctx, id); err != nil {
+		t.Fatalf("Unblock: %v", err)
+	}
+	if ipManager.IsBlocked(ip(n)) {
+		t.Errorf("%s should be unblocked with its campaign", ip(n))
+	}
+}
+
+func TestCampaigns_WeakSignalsDoNotLink(t *testing.T) {
+	cityPath, asnPath := writeGeoDBs(t, t.TempDir(), "London")
+	geo := intelligence.NewGeoLocator(sentinel.GeoConfig{
+		Enabled:         true,
+		Provider:        sentinel.GeoIPFree,
+		DatabasePath:    cityPath,
+		ASNDatabasePath: asnPath,
+	})
+	if err := geo.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	store := memory.New()
+	store.Migrate(context.Background())
+	profiler := intelligence.NewProfiler(store)
+	profiler.SetCampaignEngine(intelligence.NewCampaignEngine(store, geo, nil, sentinel.CampaignConfig{Enabled: true}))
+	ctx := context.Background()
+	now := time.Now()
+
+	// Two users of one ISP with the same stock browser, each tripping a
+	// different rule in the same time slot: UA, ASN and timing weigh 3.
+	const chrome = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
+	first := scan("81.2.69.10", chrome, "hdr-a", now)
+	second := scan("81.2.70.20", chrome, "hdr-b", now)
+	second.Evidence = []sentinel.Evidence{{Matched: "<script>alert(document.cookie)</script>", Location: "query"}}
+	profiler.ProcessThreat(ctx, first)
+	profiler.ProcessThreat(ctx, second)
+	for _, ip := range []string{"81.2.69.10", "81.2.70.20"} {
+		if got := campaignOf(t, store, ip); got != "" {
+			t.Errorf("%s: weak signals alone should not link, got campaign %q", ip, got)
+		}
+	}
+
+	// The same payload from a third user is a strong signal.
+	profiler.ProcessThreat(ctx, scan("81.2.71.30", chrome, "hdr-c", now))
+	if a, b := campaignOf(t, store, "81.2.69.10"), campaignOf(t, store, "81.2.71.30"); a == "" || a != b {
+		t.Errorf("a shared payload should link, got campaigns %q and %q", a, b)
+	}
+}
@@ -0,0 +1,603 @@
+package intelligence
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/iptrie"
+)
+
+// maxFeedBytes bounds the size of one feed download or file.
+const maxFeedBytes = 64 << 20
+
+// ErrUnknownFeed is returned by FeedManager.Refresh for a name no feed
+// has.
+var ErrUnknownFeed = errors.New("unknown threat feed")
+
+// FeedManager loads threat intelligence feeds into memory, refreshes them
+// on their schedules and matches requests against them. IP and CIDR
+// indicators are held in a radix tree per feed, so a lookup costs the
+// same with ten entries or a million. Safe for concurrent use.
+type FeedManager struct {
+	feeds  []*feed
+	client *http.Client
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+type feed struct {
+	config  sentinel.ThreatFeed
+	data    atomic.Pointer[feedData]
+	loaded  atomic.Int64 // UnixNano of the last successful load, or 0
+	matches atomic.Int64
+
+	mu           sync.Mutex // guards the fields below; held across a load
+	lastAttempt  time.Time
+	lastErr      string
+	etag         string
+	lastModified string
+}
+
+// feedData is one load of a feed. It is never modified once published.
+type feedData struct {
+	prefixes iptrie.Trie[time.Time] // indicator expiry; zero for none
+	domains  map[string]time.Time
+	skipped  int
+}
+
+// NewFeedManager returns a manager for feeds, with the defaults of
+// ThreatFeed applied. Nothing is loaded until Start or Refresh.
+func NewFeedManager(feeds []sentinel.ThreatFeed) *FeedManager {
+	m := &FeedManager{
+		client: &http.Client{Timeout: time.Minute},
+		stopCh: make(chan struct{}),
+	}
+	for _, fc := range feeds {
+		if fc.Format == "" {
+			fc.Format = sentinel.FeedText
+		}
+		if fc.Action == "" {
+			fc.Action = sentinel.FeedActionBlock
+		}
+		if fc.ScoreBoost == 0 {
+			fc.ScoreBoost = 20
+		} else if fc.ScoreBoost < 0 {
+			fc.ScoreBoost = 0
+		}
+		if fc.Refresh <= 0 {
+			fc.Refresh = time.Hour
+		}
+		m.feeds = append(m.feeds, &feed{config: fc})
+	}
+	return m
+}
+
+// Start loads every feed and keeps refreshing each on its schedule until
+// Stop. File feeds are loaded before Start returns; URL feeds load in the
+// background, so a slow server does not hold up startup.
+func (m *FeedManager) Start() {
+	for _, f := range m.feeds {
+		if f.config.URL == "" {
+			m.refresh(context.Background(), f)
+		}
+		m.wg.Add(1)
+		go m.run(f, f.config.URL != "")
+	}
+}
+
+// Stop ends the refresh loops.
+func (m *FeedManager) Stop() {
+	close(m.stopCh)
+	m.wg.Wait()
+}
+
+func (m *FeedManager) run(f *feed, loadNow bool) {
+	defer m.wg.Done()
+	if loadNow {
+		m.refresh(context.Background(), f)
+	}
+	ticker := time.NewTicker(f.config.Refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+			m.refresh(context.Background(), f)
+		}
+	}
+}
+
+// Refresh reloads the feed called name now.
+func (m *FeedManager) Refresh(ctx context.Context, name string) error {
+	for _, f := range m.feeds {
+		if f.config.Name == name {
+			return m.refresh(ctx, f)
+		}
+	}
+	return ErrUnknownFeed
+}
+
+// refresh loads f, keeping its current indicators if the load fails.
+func (m *FeedManager) refresh(ctx context.Context, f *feed) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lastAttempt = time.Now()
+
+	err := m.load(ctx, f)
+	if err != nil {
+		f.lastErr = err.Error()
+		log.Printf("[sentinel] threat feed %s: %v", f.config.Name, err)
+		return err
+	}
+	f.lastErr = ""
+	f.loaded.Store(time.Now().UnixNano())
+	return nil
+}
+
+func (m *FeedManager) load(ctx context.Context, f *feed) error {
+	body, validators, err := m.read(ctx, f)
+	if err != nil || body == nil {
+		// A nil body is a 304: the feed is unchanged, and counts as
+		// loaded.
+		return err
+	}
+	data, err := parseFeed(f.config, body, time.Now())
+	if err != nil {
+		return err
+	}
+	f.data.Store(data)
+	f.etag, f.lastModified = validators.Get("ETag"), validators.Get("Last-Modified")
+	return nil
+}
+
+// read returns the feed's contents and the response headers to validate
+// them with next time, or a nil body if the server says they have not
+// changed since the last load.
+func (m *FeedManager) read(ctx context.Context, f *feed) ([]byte, http.Header, error) {
+	if f.config.URL == "" {
+		file, err := os.Open(f.config.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer file.Close()
+		body, err := readLimited(file)
+		return body, nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.config.URL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range f.config.Headers {
+		req.Header.Set(k, v)
+	}
+	if f.data.Load() != nil {
+		if f.etag != "" {
+			req.Header.Set("If-None-Match", f.etag)
+		}
+		if f.lastModified != "" {
+			req.Header.Set("If-Modified-Since", f.lastModified)
+		}
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		if f.data.Load() != nil {
+			return nil, nil, nil
+		}
+		fallthrough
+	default:
+		return nil, nil, fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+	body, err := readLimited(resp.Body)
+	return body, resp.Header, err
+}
+
+func readLimited(r io.Reader) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, maxFeedBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxFeedBytes {
+		return nil, fmt.Errorf("feed is larger than %d MiB", maxFeedBytes>>20)
+	}
+	return body, nil
+}
+
+// --- Lookups ---
+
+// Lookup returns the most severe match for ip across all feeds, without
+// counting it in the feeds' stats.
+func (m *FeedManager) Lookup(ip string) *sentinel.FeedMatch {
+	match, _ := m.lookupIP(ip, time.Now())
+	return match
+}
+
+// MatchRequest returns the most severe match for a request from ip whose
+// Referer and Origin name hosts, and counts it against the matching
+// feed.
+func (m *FeedManager) MatchRequest(ip string, hosts ...string) *sentinel.FeedMatch {
+	now := time.Now()
+	match, from := m.lookupIP(ip, now)
+	for _, h := range hosts {
+		if dm, df := m.lookupDomain(h, now); dm != nil && (match == nil || feedRank(dm.Action) > feedRank(match.Action)) {
+			match, from = dm, df
+		}
+	}
+	if from != nil {
+		from.matches.Add(1)
+	}
+	return match
+}
+
+func (m *FeedManager) lookupIP(ip string, now time.Time) (*sentinel.FeedMatch, *feed) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, nil
+	}
+	addr = addr.Unmap().WithZone("")
+	var (
+		best *sentinel.FeedMatch
+		from *feed
+	)
+	for _, f := range m.feeds {
+		data := f.current(now)
+		if data == nil || (best != nil && feedRank(f.config.Action) <= feedRank(best.Action)) {
+			continue
+		}
+		// The longest prefix that has not expired.
+		var hit netip.Prefix
+		data.prefixes.Matches(addr, func(p netip.Prefix, expires time.Time) bool {
+			if expires.IsZero() || now.Before(expires) {
+				hit = p
+			}
+			return true
+		})
+		if hit.IsValid() {
+			best, from = f.match(hit.String()), f
+		}
+	}
+	return best, from
+}
+
+func (m *FeedManager) lookupDomain(host string, now time.Time) (*sentinel.FeedMatch, *feed) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return nil, nil
+	}
+	var (
+		best *sentinel.FeedMatch
+		from *feed
+	)
+	for _, f := range m.feeds {
+		data := f.current(now)
+		if data == nil || len(data.domains) == 0 || (best != nil && feedRank(f.config.Action) <= feedRank(best.Action)) {
+			continue
+		}
+		// The host itself, then each parent domain.
+		for d := host; d != ""; {
+			if expires, ok := data.domains[d]; ok && (expires.IsZero() || now.Before(expires)) {
+				best, from = f.match(d), f
+				break
+			}
+			_, parent, ok := strings.Cut(d, ".")
+			if !ok {
+				break
+			}
+			d = parent
+		}
+	}
+	return best, from
+}
+
+// current returns the feed's indicators, or nil if it has none or they
+// have expired.
+func (f *feed) current(now time.Time) *feedData {
+	data := f.data.Load()
+	if data == nil {
+		return nil
+	}
+	if f.config.Expiry > 0 && now.Sub(time.Unix(0, f.loaded.Load())) > f.config.Expiry {
+		return nil
+	}
+	return data
+}
+
+func (f *feed) match(indicator string) *sentinel.FeedMatch {
+	return &sentinel.FeedMatch{
+		Feed:       f.config.Name,
+		Action:     f.config.Action,
+		ScoreBoost: f.config.ScoreBoost,
+		Indicator:  indicator,
+	}
+}
+
+// BlockedIP describes the feed block on ip as a blocked IP entry, with the
+// feed named in Reason, or returns nil if no blocking feed lists it.
+func (m *FeedManager) BlockedIP(ip string) *sentinel.BlockedIP {
+	match, f := m.lookupIP(ip, time.Now())
+	if match == nil || match.Action != sentinel.FeedActionBlock {
+		return nil
+	}
+	b := &sentinel.BlockedIP{
+		IP:        match.Indicator,
+		Reason:    "Threat feed: " + match.Feed,
+		BlockedAt: time.Unix(0, f.loaded.Load()),
+		CIDR:      !isHostPrefix(match.Indicator),
+	}
+	if f.config.Expiry > 0 {
+		t := b.BlockedAt.Add(f.config.Expiry)
+		b.ExpiresAt = &t
+	}
+	return b
+}
+
+func isHostPrefix(s string) bool {
+	p, err := netip.ParsePrefix(s)
+	return err == nil && p.IsSingleIP()
+}
+
+// feedRank orders actions by severity.
+func feedRank(a sentinel.FeedAction) int {
+	switch a {
+	case sentinel.FeedActionBlock:
+		return 3
+	case sentinel.FeedActionFlag:
+		return 2
+	case sentinel.FeedActionScore:
+		return 1
+	}
+	return 0
+}
+
+// Stats returns the state of every feed, in configuration order.
+func (m *FeedManager) Stats() []sentinel.FeedStats {
+	now := time.Now()
+	out := make([]sentinel.FeedStats, 0, len(m.feeds))
+	for _, f := range m.feeds {
+		s := sentinel.FeedStats{
+			Name:    f.config.Name,
+			Source:  f.config.URL,
+			Format:  f.config.Format,
+			Action:  f.config.Action,
+			Matches: f.matches.Load(),
+		}
+		if s.Source == "" {
+			s.Source = f.config.Path
+		}
+		if data := f.current(now); data != nil {
+			s.Prefixes = data.prefixes.Len()
+			s.Domains = len(data.domains)
+			s.Skipped = data.skipped
+		}
+		if n := f.loaded.Load(); n != 0 {
+			loaded := time.Unix(0, n)
+			s.LastLoaded = &loaded
+			if f.config.Expiry > 0 {
+				expires := loaded.Add(f.config.Expiry)
+				s.ExpiresAt = &expires
+			}
+		}
+		// A load in progress holds the lock; report what was there before.
+		if f.mu.TryLock() {
+			if !f.lastAttempt.IsZero() {
+				attempt := f.lastAttempt
+				s.LastAttempt = &attempt
+			}
+			s.LastError = f.lastErr
+			f.mu.Unlock()
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// --- Parsing ---
+
+func parseFeed(config sentinel.ThreatFeed, body []byte, now time.Time) (*feedData, error) {
+	data := &feedData{domains: make(map[string]time.Time)}
+	var err error
+	switch config.Format {
+	case sentinel.FeedCSV:
+		err = parseCSV(data, body, config.Column)
+	case sentinel.FeedSTIX:
+		err = parseSTIX(data, body, now)
+	default:
+		err = parseText(data, body)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// add stores one indicator, counting it as skipped if it is not an IP,
+// CIDR or domain.
+func (d *feedData) add(indicator string, expires time.Time) {
+	indicator = strings.Trim(strings.TrimSpace(indicator), `"'`)
+	if p, err := iptrie.ParsePrefix(indicator); err == nil {
+		d.prefixes.Insert(p, expires)
+		return
+	}
+	domain := strings.TrimSuffix(strings.TrimPrefix(strings.ToLower(indicator), "*."), ".")
+	if isDomainName(domain) {
+		d.domains[domain] = expires
+		return
+	}
+	d.skipped++
+}
+
+// parseText reads one indicator per line. Comments start with # or ;,
+// and hosts-file lines ("0.0.0.0 example.com") yield the domain.
+func parseText(d *feedData, body []byte) error {
+	sc := bufio.NewScanner(bytes.NewReader(body))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexAny(line, "#;"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ' ' || r == '\t' || r == ','
+		})
+		if len(fields) == 0 {
+			continue
+		}
+		indicator := fields[0]
+		if len(fields) > 1 && (indicator == "0.0.0.0" || indicator == "127.0.0.1" || indicator == "::") {
+			indicator = fields[1]
+		}
+		d.add(indicator, time.Time{})
+	}
+	return sc.Err()
+}
+
+// parseCSV reads the indicator from column of each record. A header row
+// is counted as skipped.
+func parseCSV(d *feedData, body []byte, column int) error {
+	r := csv.NewReader(bytes.NewReader(body))
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("csv: %w", err)
+		}
+		if column >= len(record) {
+			d.skipped++
+			continue
+		}
+		d.add(record[column], time.Time{})
+	}
+}
+
+type stixObject struct {
+	Type        string `json:"type"`
+	Pattern     string `json:"pattern"`
+	PatternType string `json:"pattern_type"`
+	ValidUntil  string `json:"valid_until"`
+	Revoked     bool   `json:"revoked"`
+	Value       string `json:"value"`
+}
+
+// stixComparison matches the comparisons of a STIX pattern that name an
+// indicator outright.
+var stixComparison = regexp.MustCompile(`(ipv4-addr|ipv6-addr|domain-name):value\s*(?:=|ISSUBSET)\s*'((?:[^'\\]|\\.)*)'`)
+
+// parseSTIX reads a STIX 2.1 bundle, or a TAXII envelope of objects.
+// Indicators whose pattern is a disjunction of IP, CIDR and domain
+// comparisons are used; patterns that combine them with other conditions
+// are skipped, since matching on the address alone would be too broad.
+// Revoked and expired indicators are dropped. Bare ipv4-addr, ipv6-addr
+// and domain-name objects are used as well.
+func parseSTIX(d *feedData, body []byte, now time.Time) error {
+	var bundle struct {
+		Type    string       `json:"type"`
+		Objects []stixObject `json:"objects"`
+	}
+	if err := json.Unmarshal(body, &bundle); err != nil {
+		return fmt.Errorf("stix: %w", err)
+	}
+	if bundle.Type != "bundle" && bundle.Objects == nil {
+		return errors.New("stix: not a bundle")
+	}
+	for _, obj := range bundle.Objects {
+		switch obj.Type {
+		case "ipv4-addr", "ipv6-addr", "domain-name":
+			d.add(obj.Value, time.Time{})
+		case "indicator":
+			if obj.Revoked {
+				continue
+			}
+			if obj.PatternType != "" && obj.PatternType != "stix" {
+				d.skipped++
+				continue
+			}
+			var expires time.Time
+			if obj.ValidUntil != "" {
+				t, err := time.Parse(time.RFC3339Nano, obj.ValidUntil)
+				if err == nil && !t.After(now) {
+					continue
+				}
+				expires = t
+			}
+			values, ok := stixValues(obj.Pattern)
+			if !ok {
+				d.skipped++
+				continue
+			}
+			for _, v := range values {
+				d.add(v, expires)
+			}
+		}
+	}
+	return nil
+}
+
+// stixValues returns the values compared in pattern, if it consists of
+// nothing but such comparisons joined by OR.
+func stixValues(pattern string) ([]string, bool) {
+	matches := stixComparison.FindAllStringSubmatch(pattern, -1)
+	if len(matches) == 0 {
+		return nil, false
+	}
+	rest := strings.NewReplacer("[", " ", "]", " ", "(", " ", ")", " ").Replace(stixComparison.ReplaceAllString(pattern, ""))
+	for _, tok := range strings.Fields(rest) {
+		if tok != "OR" {
+			return nil, false
+		}
+	}
+	values := make([]string, len(matches))
+	for i, m := range matches {
+		values[i] = strings.NewReplacer(`\'`, `'`, `\\`, `\`).Replace(m[2])
+	}
+	return values, true
+}
+
+// isDomainName reports whether s looks like a fully qualified domain
+// name.
+func isDomainName(s string) bool {
+	if len(s) == 0 || len(s) > 253 || !strings.Contains(s, ".") || net.ParseIP(s) != nil {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
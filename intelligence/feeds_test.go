@@ -0,0 +1,190 @@
+package intelligence
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/storage/memory"
+)
+
+func writeFeed(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "feed")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFeeds_ParseFormats(t *testing.T) {
+	text := `# Spamhaus-style list
+198.51.100.0/24 ; SBL123
+203.0.113.7
+2001:db8:bad::/48
+0.0.0.0 malware.example
+not an indicator
+`
+	csv := `ip,first_seen,tag
+192.0.2.10,2024-01-01,c2
+"192.0.2.11",2024-01-02,c2
+`
+	stix := `{"type":"bundle","id":"bundle--1","objects":[
+ {"type":"indicator","pattern_type":"stix","pattern":"[ipv4-addr:value = '198.18.0.0/15' OR domain-name:value = 'phish.example']"},
+ {"type":"indicator","pattern_type":"stix","pattern":"[ipv4-addr:value = '198.18.5.5' AND network-traffic:dst_port = 22]"},
+ {"type":"indicator","pattern_type":"stix","pattern":"[ipv4-addr:value = '192.0.2.99']","valid_until":"2001-01-01T00:00:00Z"},
+ {"type":"indicator","pattern_type":"stix","pattern":"[ipv4-addr:value = '192.0.2.98']","revoked":true},
+ {"type":"indicator","pattern_type":"sigma","pattern":"title: x"},
+ {"type":"ipv6-addr","value":"2001:db8:c2::1"},
+ {"type":"malware","name":"x"}
+]}`
+
+	m := NewFeedManager([]sentinel.ThreatFeed{
+		{Name: "text", Path: writeFeed(t, text)},
+		{Name: "csv", Path: writeFeed(t, csv), Format: sentinel.FeedCSV},
+		{Name: "stix", Path: writeFeed(t, stix), Format: sentinel.FeedSTIX, Action: sentinel.FeedActionFlag},
+	})
+	for _, name := range []string{"text", "csv", "stix"} {
+		if err := m.Refresh(context.Background(), name); err != nil {
+			t.Fatalf("refresh %s: %v", name, err)
+		}
+	}
+
+	cases := []struct{ ip, feed string }{
+		{"198.51.100.42", "text"},
+		{"203.0.113.7", "text"},
+		{"203.0.113.8", ""},
+		{"2001:db8:bad:1::1", "text"},
+		{"192.0.2.11", "csv"},
+		{"198.19.1.1", "stix"},
+		{"2001:db8:c2::1", "stix"},
+		{"192.0.2.99", ""},
+		{"192.0.2.98", ""},
+	}
+	for _, tc := range cases {
+		got := ""
+		if match := m.Lookup(tc.ip); match != nil {
+			got = match.Feed
+		}
+		if got != tc.feed {
+			t.Errorf("Lookup(%s) = %q, want %q", tc.ip, got, tc.feed)
+		}
+	}
+
+	if match := m.MatchRequest("192.0.2.1", "cdn.malware.example"); match == nil || match.Feed != "text" || match.Indicator != "malware.example" {
+		t.Errorf("subdomain of a listed domain: got %+v", match)
+	}
+
+	stats := m.Stats()
+	if stats[0].Prefixes != 3 || stats[0].Domains != 1 || stats[0].Skipped != 1 || stats[0].Matches != 1 {
+		t.Errorf("text stats: %+v", stats[0])
+	}
+	if stats[1].Prefixes != 2 || stats[1].Skipped != 1 {
+		t.Errorf("csv stats: %+v", stats[1])
+	}
+	// The AND pattern and the sigma pattern are skipped.
+	if stats[2].Prefixes != 2 || stats[2].Domains != 1 || stats[2].Skipped != 2 {
+		t.Errorf("stix stats: %+v", stats[2])
+	}
+}
+
+func TestFeeds_MostSevereActionWins(t *testing.T) {
+	m := NewFeedManager([]sentinel.ThreatFeed{
+		{Name: "score", Path: writeFeed(t, "10.0.0.0/8\n"), Action: sentinel.FeedActionScore, ScoreBoost: 5},
+		{Name: "block", Path: writeFeed(t, "10.1.0.0/16\n")},
+	})
+	m.Start()
+	defer m.Stop()
+
+	if match := m.Lookup("10.1.2.3"); match == nil || match.Feed != "block" || match.ScoreBoost != 20 {
+		t.Errorf("got %+v, want the blocking feed with the default boost", match)
+	}
+	if match := m.Lookup("10.2.2.3"); match == nil || match.Feed != "score" || match.ScoreBoost != 5 {
+		t.Errorf("got %+v, want the scoring feed", match)
+	}
+}
+
+func TestFeeds_URLConditionalGetAndExpiry(t *testing.T) {
+	var fetches, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if r.Header.Get("Authorization") != "Bearer k" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("192.0.2.1\n"))
+	}))
+	defer srv.Close()
+
+	m := NewFeedManager([]sentinel.ThreatFeed{{
+		Name:    "remote",
+		URL:     srv.URL,
+		Headers: map[string]string{"Authorization": "Bearer k"},
+		Expiry:  2 * time.Hour,
+	}})
+	ctx := context.Background()
+	if err := m.Refresh(ctx, "remote"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Refresh(ctx, "remote"); err != nil {
+		t.Fatal(err)
+	}
+	if fetches.Load() != 2 || notModified.Load() != 1 {
+		t.Fatalf("fetches %d, 304s %d; want 2 and 1", fetches.Load(), notModified.Load())
+	}
+	if m.Lookup("192.0.2.1") == nil {
+		t.Fatal("indicator missing after a 304")
+	}
+	if err := m.Refresh(ctx, "nope"); err != ErrUnknownFeed {
+		t.Errorf("unknown feed: got %v", err)
+	}
+
+	// Once the last load is older than Expiry the indicators lapse.
+	m.feeds[0].loaded.Store(time.Now().Add(-3 * time.Hour).UnixNano())
+	if m.Lookup("192.0.2.1") != nil {
+		t.Error("expired feed still matches")
+	}
+}
+
+func TestIPManager_BlockingFeeds(t *testing.T) {
+	ctx := context.Background()
+	mgr := NewIPManager(memory.New())
+	defer mgr.Stop()
+	feeds := NewFeedManager([]sentinel.ThreatFeed{
+		{Name: "drop", Path: writeFeed(t, "198.51.100.0/24\n")},
+		{Name: "watch", Path: writeFeed(t, "203.0.113.0/24\n"), Action: sentinel.FeedActionFlag},
+	})
+	feeds.Start()
+	defer feeds.Stop()
+	mgr.SetFeeds(feeds)
+
+	if !mgr.IsBlocked("198.51.100.9") {
+		t.Error("IP on a blocking feed is not blocked")
+	}
+	if b := mgr.BlockedBy("198.51.100.9"); b == nil || b.Reason != "Threat feed: drop" || b.IP != "198.51.100.0/24" || !b.CIDR {
+		t.Errorf("BlockedBy = %+v", b)
+	}
+	if mgr.IsBlocked("203.0.113.9") {
+		t.Error("IP on a flagging feed is blocked")
+	}
+
+	// The whitelist overrides feeds.
+	if err := mgr.WhitelistIP(ctx, "198.51.100.9"); err != nil {
+		t.Fatal(err)
+	}
+	if mgr.IsBlocked("198.51.100.9") || mgr.BlockedBy("198.51.100.9") != nil {
+		t.Error("whitelisted IP is blocked by a feed")
+	}
+}
@@ -3,25 +3,37 @@ package intelligence
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net"
 	"net/http"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/intelligence/mmdb"
 )
 
 // GeoLocator provides IP geolocation lookups with an LRU cache.
-// It uses the ip-api.com free API for lookups (no API key required).
-// For production with high volume, configure a MaxMind GeoLite2 database path.
+//
+// The GeoIPFree and GeoIPPaid providers read a local MaxMind DB
+// (GeoConfig.DatabasePath, plus an optional ASN database) and pick up a
+// replaced file without a restart. GeoIPAPI queries ip-api.com instead; it is
+// never used unless configured. The cache sits in front of both.
 type GeoLocator struct {
-	cache   map[string]*geoCacheEntry
-	order   []string // LRU order (most recent at end)
-	maxSize int
-	mu      sync.RWMutex
-	client  *http.Client
-	enabled bool
+	cache    map[string]*geoCacheEntry
+	order    []string // LRU order (most recent at end)
+	maxSize  int
+	mu       sync.RWMutex
+	client   *http.Client
+	enabled  bool
+	provider sentinel.GeoProvider
+
+	city *mmdbSource
+	asn  *mmdbSource
 }
 
 type geoCacheEntry struct {
@@ -31,18 +43,70 @@ type geoCacheEntry struct {
 
 const defaultGeoCacheSize = 10000
 
-// NewGeoLocator creates a new geolocation service.
+// NewGeoLocator creates a new geolocation service. MMDB files are loaded
+// here; a file that is missing or invalid is logged and retried on the
+// reload interval. Call Reload to surface the error instead.
 func NewGeoLocator(config sentinel.GeoConfig) *GeoLocator {
-	return &GeoLocator{
-		cache:   make(map[string]*geoCacheEntry),
-		maxSize: defaultGeoCacheSize,
-		client:  &http.Client{Timeout: 5 * time.Second},
-		enabled: config.Enabled,
+	g := &GeoLocator{
+		cache:    make(map[string]*geoCacheEntry),
+		maxSize:  defaultGeoCacheSize,
+		enabled:  config.Enabled,
+		provider: config.Provider,
+	}
+	if !config.Enabled {
+		return g
+	}
+
+	if config.Provider == sentinel.GeoIPAPI {
+		g.client = &http.Client{Timeout: 5 * time.Second}
+		return g
+	}
+	if config.DatabasePath != "" {
+		g.city = &mmdbSource{path: config.DatabasePath, interval: config.ReloadInterval}
+	}
+	if config.ASNDatabasePath != "" {
+		g.asn = &mmdbSource{path: config.ASNDatabasePath, interval: config.ReloadInterval}
 	}
+	for _, src := range g.sources() {
+		if _, err := src.reload(); err != nil {
+			log.Printf("[sentinel] geo: failed to load %s: %v", src.path, err)
+		}
+	}
+	return g
+}
+
+// Reload re-reads any database file that changed since it was last loaded
+// and clears the cache if one did. It returns the errors for files that
+// could not be loaded; lookups keep using the previous version of those.
+func (g *GeoLocator) Reload() error {
+	var errs []error
+	changed := false
+	for _, src := range g.sources() {
+		ok, err := src.reload()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("geo: load %s: %w", src.path, err))
+		}
+		changed = changed || ok
+	}
+	if changed {
+		g.purgeCache()
+	}
+	return errors.Join(errs...)
+}
+
+func (g *GeoLocator) sources() []*mmdbSource {
+	var out []*mmdbSource
+	for _, src := range []*mmdbSource{g.city, g.asn} {
+		if src != nil {
+			out = append(out, src)
+		}
+	}
+	return out
 }
 
 // LookupIP returns geolocation data for an IP address.
-// Returns nil for private/loopback IPs. Results are cached in an LRU cache.
+// Returns nil for private/loopback IPs and for IPs the database does not
+// cover. Results are cached in an LRU cache.
 func (g *GeoLocator) LookupIP(ctx context.Context, ip string) (*sentinel.GeoResult, error) {
 	if !g.enabled {
 		return nil, nil
@@ -53,6 +117,8 @@ func (g *GeoLocator) LookupIP(ctx context.Context, ip string) (*sentinel.GeoResu
 		return nil, nil
 	}
 
+	g.maybeReload()
+
 	// Check cache
 	g.mu.RLock()
 	entry, ok := g.cache[ip]
@@ -66,9 +132,14 @@ func (g *GeoLocator) LookupIP(ctx context.Context, ip string) (*sentinel.GeoResu
 		return entry.result, nil
 	}
 
-	// Query the API
-	result, err := g.queryIPAPI(ctx, ip)
-	if err != nil {
+	var result *sentinel.GeoResult
+	var err error
+	if g.provider == sentinel.GeoIPAPI {
+		result, err = g.queryIPAPI(ctx, ip)
+	} else {
+		result, err = g.queryMMDB(ip)
+	}
+	if err != nil || result == nil {
 		return nil, err
 	}
 
@@ -80,11 +151,215 @@ func (g *GeoLocator) LookupIP(ctx context.Context, ip string) (*sentinel.GeoResu
 	return result, nil
 }
 
+// LookupASN returns the ASN entry ("AS15169") of an IP address from the
+// local MaxMind databases, or "". It never queries ip-api.com and skips
+// the cache, so it is cheap enough to call several times per request.
+func (g *GeoLocator) LookupASN(ip string) string {
+	if !g.ResolvesASNs() || isPrivateIP(ip) {
+		return ""
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	g.maybeReload()
+	var result sentinel.GeoResult
+	if r := g.asn.current(); r != nil {
+		if rec, err := r.Lookup(parsed); err == nil {
+			setASN(&result, rec)
+		}
+	}
+	if r := g.city.current(); r != nil && result.ASN == "" {
+		if rec, err := r.Lookup(parsed); err == nil {
+			// GeoIP2 Enterprise carries network data in traits.
+			setASN(&result, mmdbField(rec, "traits"))
+		}
+	}
+	return sentinel.ASNEntry(result.ASN)
+}
+
+// ResolvesASNs reports whether LookupASN can resolve ASNs: geolocation is
+// enabled with a local database rather than ip-api.com.
+func (g *GeoLocator) ResolvesASNs() bool {
+	return g.enabled && g.provider != sentinel.GeoIPAPI && (g.asn != nil || g.city != nil)
+}
+
+// --- MMDB provider ---
+
+// mmdbSource is one database file and the reader currently loaded from it.
+type mmdbSource struct {
+	path     string
+	interval time.Duration
+	reader   atomic.Pointer[mmdb.Reader]
+
+	lastCheck atomic.Int64 // unix nanos of the last change check
+
+	mu      sync.Mutex // serializes reloads; guards modTime and size
+	modTime time.Time
+	size    int64
+}
+
+// reload loads the file if it changed since the last successful load. A
+// failed load leaves the previous reader in place.
+func (s *mmdbSource) reload() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fi, err := os.Stat(s.path)
+	if err != nil {
+		return false, err
+	}
+	if s.reader.Load() != nil && fi.ModTime().Equal(s.modTime) && fi.Size() == s.size {
+		return false, nil
+	}
+	r, err := mmdb.Open(s.path)
+	if err != nil {
+		return false, err
+	}
+	s.reader.Store(r)
+	s.modTime, s.size = fi.ModTime(), fi.Size()
+	return true, nil
+}
+
+// due reports whether the file should be checked now, claiming the check so
+// concurrent lookups don't all stat the file.
+func (s *mmdbSource) due(now time.Time) bool {
+	if s.interval <= 0 {
+		return false
+	}
+	last := s.lastCheck.Load()
+	if now.UnixNano()-last < int64(s.interval) {
+		return false
+	}
+	return s.lastCheck.CompareAndSwap(last, now.UnixNano())
+}
+
+// maybeReload checks the database files once per ReloadInterval. The check
+// and any reload run in the background so no request waits on disk.
+func (g *GeoLocator) maybeReload() {
+	now := time.Now()
+	for _, src := range g.sources() {
+		if !src.due(now) {
+			continue
+		}
+		go func(src *mmdbSource) {
+			changed, err := src.reload()
+			if err != nil {
+				log.Printf("[sentinel] geo: failed to reload %s (keeping the loaded version): %v", src.path, err)
+				return
+			}
+			if changed {
+				g.purgeCache()
+				log.Printf("[sentinel] geo: reloaded %s", src.path)
+			}
+		}(src)
+	}
+}
+
+func (g *GeoLocator) queryMMDB(ip string) (*sentinel.GeoResult, error) {
+	parsed := net.ParseIP(ip)
+	result := &sentinel.GeoResult{IP: ip}
+	found := false
+
+	if r := g.city.current(); r != nil {
+		rec, err := r.Lookup(parsed)
+		if err != nil {
+			return nil, fmt.Errorf("geo: %w", err)
+		}
+		if m, ok := rec.(map[string]any); ok {
+			found = true
+			country := mmdbField(m, "country")
+			if country == nil {
+				country = mmdbField(m, "registered_country")
+			}
+			result.CountryCode = mmdbString(country, "iso_code")
+			result.Country = mmdbString(country, "names", "en")
+			result.City = mmdbString(m, "city", "names", "en")
+			if subs, ok := m["subdivisions"].([]any); ok && len(subs) > 0 && result.CountryCode != "" {
+				if code := mmdbString(subs[0], "iso_code"); code != "" {
+					result.RegionCode = result.CountryCode + "-" + code
+				}
+			}
+			result.Lat = mmdbFloat(m, "location", "latitude")
+			result.Lng = mmdbFloat(m, "location", "longitude")
+			// GeoIP2 Enterprise carries network data in traits.
+			setASN(result, mmdbField(m, "traits"))
+		}
+	}
+
+	if r := g.asn.current(); r != nil {
+		rec, err := r.Lookup(parsed)
+		if err != nil {
+			return nil, fmt.Errorf("geo: %w", err)
+		}
+		if m, ok := rec.(map[string]any); ok {
+			found = true
+			setASN(result, m)
+		}
+	}
+
+	if !found {
+		return nil, nil
+	}
+	return result, nil
+}
+
+func (s *mmdbSource) current() *mmdb.Reader {
+	if s == nil {
+		return nil
+	}
+	return s.reader.Load()
+}
+
+// setASN fills ASN and ISP from a GeoLite2-ASN / GeoIP2-ISP record (or
+// GeoIP2 traits). ASN is formatted like ip-api's: "AS15169 Google LLC".
+func setASN(result *sentinel.GeoResult, rec any) {
+	num, _ := mmdbField(rec, "autonomous_system_number").(uint64)
+	org := mmdbString(rec, "autonomous_system_organization")
+	if num != 0 {
+		result.ASN = fmt.Sprintf("AS%d", num)
+		if org != "" {
+			result.ASN += " " + org
+		}
+	}
+	for _, key := range []string{"isp", "organization", "autonomous_system_organization"} {
+		if v := mmdbString(rec, key); v != "" {
+			result.ISP = v
+			break
+		}
+	}
+}
+
+// mmdbField walks nested maps in a decoded record.
+func mmdbField(v any, path ...string) any {
+	for _, key := range path {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
+
+func mmdbString(v any, path ...string) string {
+	s, _ := mmdbField(v, path...).(string)
+	return s
+}
+
+func mmdbFloat(v any, path ...string) float64 {
+	f, _ := mmdbField(v, path...).(float64)
+	return f
+}
+
+// --- ip-api provider ---
+
 // ipAPIResponse matches the ip-api.com JSON response.
 type ipAPIResponse struct {
 	Status      string  `json:"status"`
 	Country     string  `json:"country"`
 	CountryCode string  `json:"countryCode"`
+	Region      string  `json:"region"`
 	City        string  `json:"city"`
 	Lat         float64 `json:"lat"`
 	Lon         float64 `json:"lon"`
@@ -94,7 +369,7 @@ type ipAPIResponse struct {
 }
 
 func (g *GeoLocator) queryIPAPI(ctx context.Context, ip string) (*sentinel.GeoResult, error) {
-	url := fmt.Sprintf("http://ip-api.com/json/%s?fields=status,message,country,countryCode,city,lat,lon,isp,as", ip)
+	url := fmt.Sprintf("http://ip-api.com/json/%s?fields=status,message,country,countryCode,region,city,lat,lon,isp,as", ip)
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
@@ -115,7 +390,7 @@ func (g *GeoLocator) queryIPAPI(ctx context.Context, ip string) (*sentinel.GeoRe
 		return nil, fmt.Errorf("geo: API returned status %s: %s", apiResp.Status, apiResp.Message)
 	}
 
-	return &sentinel.GeoResult{
+	result := &sentinel.GeoResult{
 		IP:          ip,
 		Country:     apiResp.Country,
 		CountryCode: apiResp.CountryCode,
@@ -124,9 +399,15 @@ func (g *GeoLocator) queryIPAPI(ctx context.Context, ip string) (*sentinel.GeoRe
 		Lng:         apiResp.Lon,
 		ISP:         apiResp.ISP,
 		ASN:         apiResp.AS,
-	}, nil
+	}
+	if apiResp.Region != "" && apiResp.CountryCode != "" {
+		result.RegionCode = apiResp.CountryCode + "-" + apiResp.Region
+	}
+	return result, nil
 }
 
+// --- cache ---
+
 // addToCache adds a result to the LRU cache, evicting the oldest if at capacity.
 // Must be called with g.mu held.
 func (g *GeoLocator) addToCache(ip string, result *sentinel.GeoResult) {
@@ -145,6 +426,14 @@ func (g *GeoLocator) addToCache(ip string, result *sentinel.GeoResult) {
 	g.order = append(g.order, ip)
 }
 
+// purgeCache drops every cached result, e.g. after a database reload.
+func (g *GeoLocator) purgeCache() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.cache = make(map[string]*geoCacheEntry)
+	g.order = nil
+}
+
 // touchLRU moves an IP to the end of the LRU order.
 // Must be called with g.mu held.
 func (g *GeoLocator) touchLRU(ip string) {
@@ -2,10 +2,14 @@ package intelligence_test
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	sentinel "github.com/MUKE-coder/sentinel/v2/core"
 	"github.com/MUKE-coder/sentinel/v2/intelligence"
+	"github.com/MUKE-coder/sentinel/v2/intelligence/mmdb/mmdbtest"
 )
 
 func TestGeoLocator_Disabled(t *testing.T) {
@@ -62,3 +66,129 @@ func TestGeoLocator_CacheSize(t *testing.T) {
 		t.Errorf("expected empty cache, got %d", geo.CacheSize())
 	}
 }
+
+func writeGeoDBs(t *testing.T, dir, city string) (string, string) {
+	t.Helper()
+	cityPath := filepath.Join(dir, "GeoLite2-City.mmdb")
+	asnPath := filepath.Join(dir, "GeoLite2-ASN.mmdb")
+	err := mmdbtest.Write(cityPath, mmdbtest.Options{DatabaseType: "GeoLite2-City"}, map[string]any{
+		"81.2.69.0/24": map[string]any{
+			"country":  map[string]any{"iso_code": "GB", "names": map[string]any{"en": "United Kingdom"}},
+			"city":     map[string]any{"names": map[string]any{"en": city}},
+			"location": map[string]any{"latitude": 51.5142, "longitude": -0.0931},
+		},
+		// Anycast ranges often carry only a registered country.
+		"2001:db8::/32": map[string]any{
+			"registered_country": map[string]any{"iso_code": "US", "names": map[string]any{"en": "United States"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mmdbtest.Write(asnPath, mmdbtest.Options{DatabaseType: "GeoLite2-ASN"}, map[string]any{
+		"81.2.0.0/16": map[string]any{
+			"autonomous_system_number":       uint32(20712),
+			"autonomous_system_organization": "Andrews & Arnold Ltd",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cityPath, asnPath
+}
+
+func TestGeoLocator_MMDB(t *testing.T) {
+	cityPath, asnPath := writeGeoDBs(t, t.TempDir(), "London")
+	geo := intelligence.NewGeoLocator(sentinel.GeoConfig{
+		Enabled:         true,
+		Provider:        sentinel.GeoIPFree,
+		DatabasePath:    cityPath,
+		ASNDatabasePath: asnPath,
+	})
+	if err := geo.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := geo.LookupIP(context.Background(), "81.2.69.160")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &sentinel.GeoResult{
+		IP: "81.2.69.160", Country: "United Kingdom", CountryCode: "GB", City: "London",
+		Lat: 51.5142, Lng: -0.0931, ISP: "Andrews & Arnold Ltd", ASN: "AS20712 Andrews & Arnold Ltd",
+	}
+	if got == nil || *got != *want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if geo.CacheSize() != 1 {
+		t.Errorf("expected the result to be cached, cache size %d", geo.CacheSize())
+	}
+
+	got, _ = geo.LookupIP(context.Background(), "2001:db8::1")
+	if got == nil || got.CountryCode != "US" || got.ASN != "" {
+		t.Errorf("registered-country fallback: got %+v", got)
+	}
+
+	got, err = geo.LookupIP(context.Background(), "8.8.8.8")
+	if err != nil || got != nil {
+		t.Errorf("IP outside the database: got %+v, %v", got, err)
+	}
+}
+
+func TestGeoLocator_HotReload(t *testing.T) {
+	dir := t.TempDir()
+	cityPath, _ := writeGeoDBs(t, dir, "London")
+	geo := intelligence.NewGeoLocator(sentinel.GeoConfig{
+		Enabled:        true,
+		Provider:       sentinel.GeoIPFree,
+		DatabasePath:   cityPath,
+		ReloadInterval: 10 * time.Millisecond,
+	})
+	ctx := context.Background()
+	if got, _ := geo.LookupIP(ctx, "81.2.69.1"); got == nil || got.City != "London" {
+		t.Fatalf("initial lookup: got %+v", got)
+	}
+
+	// Replace the file the way geoipupdate does; the cached result must not
+	// outlive the database it came from.
+	writeGeoDBs(t, dir, "Manchester")
+	os.Chtimes(cityPath, time.Now(), time.Now().Add(time.Hour))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got, _ := geo.LookupIP(ctx, "81.2.69.1")
+		if got != nil && got.City == "Manchester" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("replaced database never picked up, still %+v", got)
+		}
+		time.Sleep(15 * time.Millisecond)
+	}
+
+	// A corrupt replacement is rejected and the loaded version kept.
+	if err := os.WriteFile(cityPath, []byte("truncated download"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := geo.Reload(); err == nil {
+		t.Error("expected Reload to report the corrupt file")
+	}
+	if got, _ := geo.LookupIP(ctx, "81.2.69.1"); got == nil || got.City != "Manchester" {
+		t.Errorf("after a failed reload: got %+v", got)
+	}
+}
+
+func TestGeoLocator_MissingDatabase(t *testing.T) {
+	geo := intelligence.NewGeoLocator(sentinel.GeoConfig{
+		Enabled:      true,
+		Provider:     sentinel.GeoIPFree,
+		DatabasePath: filepath.Join(t.TempDir(), "missing.mmdb"),
+	})
+	if err := geo.Reload(); err == nil {
+		t.Error("expected Reload to report the missing file")
+	}
+	got, err := geo.LookupIP(context.Background(), "81.2.69.1")
+	if err != nil || got != nil {
+		t.Errorf("expected an empty result without a database, got %+v, %v", got, err)
+	}
+}
@@ -2,32 +2,42 @@ package intelligence
 
 import (
 	"context"
-	"net"
+	"net/netip"
 	"strings"
 	"sync"
 	"time"
 
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/iptrie"
 	"github.com/MUKE-coder/sentinel/v2/storage"
 )
 
 // IPManager maintains an in-memory cache of blocked and whitelisted IPs,
 // syncing from storage periodically for fast per-request lookups.
+//
+// Entries are addresses, CIDR prefixes or ASNs (see core.ParseIPEntry for
+// the accepted shapes and how overlapping entries are resolved). Addresses
+// and prefixes live in radix trees, so a lookup costs O(prefix length)
+// however many entries there are.
 type IPManager struct {
-	store      storage.Store
-	mu         sync.RWMutex
-	blockedIPs map[string]bool
-	blockedCIDRs []*net.IPNet
-	whitelistedIPs map[string]bool
-	stopCh     chan struct{}
+	store           storage.Store
+	geoLoc          *GeoLocator
+	mu              sync.RWMutex
+	blocked         iptrie.Trie[*sentinel.BlockedIP]
+	blockedASNs     map[string]*sentinel.BlockedIP
+	whitelisted     iptrie.Trie[*sentinel.WhitelistedIP]
+	whitelistedASNs map[string]*sentinel.WhitelistedIP
+	feeds           *FeedManager
+	stopCh          chan struct{}
 }
 
 // NewIPManager creates a new IP manager that caches blocked/whitelisted IPs.
 func NewIPManager(store storage.Store) *IPManager {
 	mgr := &IPManager{
-		store:          store,
-		blockedIPs:     make(map[string]bool),
-		whitelistedIPs: make(map[string]bool),
-		stopCh:         make(chan struct{}),
+		store:           store,
+		blockedASNs:     make(map[string]*sentinel.BlockedIP),
+		whitelistedASNs: make(map[string]*sentinel.WhitelistedIP),
+		stopCh:          make(chan struct{}),
 	}
 	// Initial sync
 	mgr.sync()
@@ -41,117 +51,253 @@ func (m *IPManager) Stop() {
 	close(m.stopCh)
 }
 
-// IsBlocked checks if an IP is blocked (exact match or CIDR membership).
-func (m *IPManager) IsBlocked(ip string) bool {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+// SetFeeds makes IsBlocked also refuse IPs listed by threat feeds whose
+// action is FeedActionBlock. It must be called before the manager is used.
+func (m *IPManager) SetFeeds(feeds *FeedManager) {
+	m.feeds = feeds
+}
+
+// SetGeoLocator resolves client ASNs for ASN entries, from its local
+// databases only (see GeoLocator.LookupASN). Without one, or with the
+// ip-api.com provider, ASN entries are kept but never match. It must be
+// called before the manager is used.
+func (m *IPManager) SetGeoLocator(geoLoc *GeoLocator) {
+	m.geoLoc = geoLoc
+}
 
-	if m.blockedIPs[ip] {
+// IsBlocked reports whether the most specific entry covering ip is a live
+// block, or, when no whitelist entry covers it, whether a blocking threat
+// feed lists it.
+func (m *IPManager) IsBlocked(ip string) bool {
+	block, allow := m.match(ip)
+	if block != nil {
 		return true
 	}
-
-	// Check CIDR ranges
-	parsedIP := net.ParseIP(ip)
-	if parsedIP != nil {
-		for _, cidr := range m.blockedCIDRs {
-			if cidr.Contains(parsedIP) {
-				return true
-			}
-		}
+	if allow != nil || m.feeds == nil {
+		return false
 	}
+	match := m.feeds.Lookup(ip)
+	return match != nil && match.Action == sentinel.FeedActionBlock
+}
 
-	return false
+// BlockedBy returns the entry blocking ip — a block list entry, or a threat
+// feed entry with the feed named in Reason — or nil if ip is not blocked.
+func (m *IPManager) BlockedBy(ip string) *sentinel.BlockedIP {
+	block, allow := m.match(ip)
+	if block != nil {
+		return block
+	}
+	if allow != nil || m.feeds == nil {
+		return nil
+	}
+	return m.feeds.BlockedIP(ip)
 }
 
-// IsWhitelisted checks if an IP is whitelisted.
+// IsWhitelisted reports whether the most specific entry covering ip is a
+// whitelist entry.
 func (m *IPManager) IsWhitelisted(ip string) bool {
+	return m.WhitelistedBy(ip) != nil
+}
+
+// WhitelistedBy returns the whitelist entry letting ip through, or nil.
+func (m *IPManager) WhitelistedBy(ip string) *sentinel.WhitelistedIP {
+	_, allow := m.match(ip)
+	return allow
+}
+
+// match returns the entry deciding ip: the most specific live block or
+// whitelist entry covering it, with prefixes ahead of ASNs and the
+// whitelist winning ties. At most one of the results is non-nil.
+func (m *IPManager) match(ip string) (*sentinel.BlockedIP, *sentinel.WhitelistedIP) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, nil
+	}
+	now := time.Now()
+
+	m.mu.RLock()
+	var block *sentinel.BlockedIP
+	blockBits := -1
+	m.blocked.Matches(addr, func(p netip.Prefix, b *sentinel.BlockedIP) bool {
+		if blockLive(b, now) {
+			block, blockBits = b, p.Bits()
+		}
+		return true
+	})
+	allowPrefix, allow, allowed := m.whitelisted.Lookup(addr)
+	hasASNs := len(m.blockedASNs) > 0 || len(m.whitelistedASNs) > 0
+	m.mu.RUnlock()
+
+	if allowed && allowPrefix.Bits() >= blockBits {
+		return nil, allow
+	}
+	if block != nil {
+		return block, nil
+	}
+	if !hasASNs || m.geoLoc == nil {
+		return nil, nil
+	}
+
+	asn := m.geoLoc.LookupASN(ip)
+	if asn == "" {
+		return nil, nil
+	}
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	return m.whitelistedIPs[ip]
+	if w := m.whitelistedASNs[asn]; w != nil {
+		return nil, w
+	}
+	if b := m.blockedASNs[asn]; b != nil && blockLive(b, now) {
+		return b, nil
+	}
+	return nil, nil
 }
 
-// BlockIP blocks an IP with immediate cache update.
+// BlockIP blocks an IP, CIDR prefix or ASN with immediate cache update.
+// Blocking an entry that is already blocked replaces its reason and expiry.
 func (m *IPManager) BlockIP(ctx context.Context, ip, reason string, expiry *time.Time) error {
-	if err := m.store.BlockIP(ctx, ip, reason, expiry); err != nil {
+	entry, err := sentinel.ParseIPEntry(ip)
+	if err != nil {
+		return err
+	}
+	if err := m.store.BlockIP(ctx, entry, reason, expiry); err != nil {
 		return err
 	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
-
-	if strings.Contains(ip, "/") {
-		_, cidr, err := net.ParseCIDR(ip)
-		if err == nil {
-			m.blockedCIDRs = append(m.blockedCIDRs, cidr)
-		}
-	} else {
-		m.blockedIPs[ip] = true
-	}
-
+	m.addBlocked(&sentinel.BlockedIP{
+		IP:        entry,
+		Reason:    reason,
+		BlockedAt: time.Now(),
+		ExpiresAt: expiry,
+		CIDR:      strings.Contains(entry, "/"),
+	})
 	return nil
 }
 
-// UnblockIP removes a block with immediate cache update.
+// UnblockIP removes a block with immediate cache update. Only the entry
+// itself is removed: unblocking an address inside a blocked prefix leaves
+// the prefix blocked.
 func (m *IPManager) UnblockIP(ctx context.Context, ip string) error {
-	if err := m.store.UnblockIP(ctx, ip); err != nil {
+	entry, err := sentinel.ParseIPEntry(ip)
+	if err != nil {
+		// Let a malformed legacy row still be removed from storage.
+		return m.store.UnblockIP(ctx, ip)
+	}
+	if err := m.store.UnblockIP(ctx, entry); err != nil {
 		return err
 	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	delete(m.blockedIPs, ip)
-
-	// Remove CIDR if applicable
-	if strings.Contains(ip, "/") {
-		var filtered []*net.IPNet
-		for _, cidr := range m.blockedCIDRs {
-			if cidr.String() != ip {
-				filtered = append(filtered, cidr)
-			}
-		}
-		m.blockedCIDRs = filtered
+	if sentinel.IsASNEntry(entry) {
+		delete(m.blockedASNs, entry)
+	} else if p, err := iptrie.ParsePrefix(entry); err == nil {
+		m.blocked.Delete(p)
 	}
-
 	return nil
 }
 
-// WhitelistIP adds an IP to the whitelist with immediate cache update.
+// WhitelistIP whitelists an IP, CIDR prefix or ASN with immediate cache
+// update.
 func (m *IPManager) WhitelistIP(ctx context.Context, ip string) error {
-	if err := m.store.WhitelistIP(ctx, ip); err != nil {
+	entry, err := sentinel.ParseIPEntry(ip)
+	if err != nil {
+		return err
+	}
+	if err := m.store.WhitelistIP(ctx, entry); err != nil {
 		return err
 	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.whitelistedIPs[ip] = true
+	m.addWhitelisted(&sentinel.WhitelistedIP{
+		IP:          entry,
+		WhitelistAt: time.Now(),
+		CIDR:        strings.Contains(entry, "/"),
+	})
 	return nil
 }
 
+// UnwhitelistIP removes a whitelist entry with immediate cache update.
+func (m *IPManager) UnwhitelistIP(ctx context.Context, ip string) error {
+	entry, err := sentinel.ParseIPEntry(ip)
+	if err != nil {
+		return m.store.UnwhitelistIP(ctx, ip)
+	}
+	if err := m.store.UnwhitelistIP(ctx, entry); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if sentinel.IsASNEntry(entry) {
+		delete(m.whitelistedASNs, entry)
+	} else if p, err := iptrie.ParsePrefix(entry); err == nil {
+		m.whitelisted.Delete(p)
+	}
+	return nil
+}
+
+// addBlocked caches b. Callers hold m.mu.
+func (m *IPManager) addBlocked(b *sentinel.BlockedIP) {
+	entry, err := sentinel.ParseIPEntry(b.IP)
+	if err != nil {
+		return
+	}
+	if sentinel.IsASNEntry(entry) {
+		m.blockedASNs[entry] = b
+	} else if p, err := iptrie.ParsePrefix(entry); err == nil {
+		m.blocked.Insert(p, b)
+	}
+}
+
+// addWhitelisted caches w. Callers hold m.mu.
+func (m *IPManager) addWhitelisted(w *sentinel.WhitelistedIP) {
+	entry, err := sentinel.ParseIPEntry(w.IP)
+	if err != nil {
+		return
+	}
+	if sentinel.IsASNEntry(entry) {
+		m.whitelistedASNs[entry] = w
+	} else if p, err := iptrie.ParsePrefix(entry); err == nil {
+		m.whitelisted.Insert(p, w)
+	}
+}
+
+// blockLive reports whether b has not expired by now.
+func blockLive(b *sentinel.BlockedIP, now time.Time) bool {
+	return b.ExpiresAt == nil || b.ExpiresAt.After(now)
+}
+
 func (m *IPManager) sync() {
 	ctx := context.Background()
 
-	// Sync blocked IPs
 	blocked, err := m.store.ListBlockedIPs(ctx)
 	if err != nil {
 		return
 	}
+	whitelisted, err := m.store.ListWhitelistedIPs(ctx)
+	if err != nil {
+		return
+	}
 
-	newBlocked := make(map[string]bool)
-	var newCIDRs []*net.IPNet
+	// Build into a scratch manager, then swap the caches in one step.
+	next := &IPManager{
+		blockedASNs:     make(map[string]*sentinel.BlockedIP),
+		whitelistedASNs: make(map[string]*sentinel.WhitelistedIP),
+	}
 	for _, b := range blocked {
-		if strings.Contains(b.IP, "/") {
-			_, cidr, err := net.ParseCIDR(b.IP)
-			if err == nil {
-				newCIDRs = append(newCIDRs, cidr)
-			}
-		} else {
-			newBlocked[b.IP] = true
-		}
+		next.addBlocked(b)
+	}
+	for _, w := range whitelisted {
+		next.addWhitelisted(w)
 	}
 
 	m.mu.Lock()
-	m.blockedIPs = newBlocked
-	m.blockedCIDRs = newCIDRs
+	m.blocked, m.blockedASNs = next.blocked, next.blockedASNs
+	m.whitelisted, m.whitelistedASNs = next.whitelisted, next.whitelistedASNs
 	m.mu.Unlock()
 }
 
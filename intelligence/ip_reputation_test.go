@@ -0,0 +1,118 @@
+package intelligence_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/intelligence"
+	"github.com/MUKE-coder/sentinel/v2/storage/memory"
+)
+
+func TestIPManager_OverlappingEntries(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	mgr := intelligence.NewIPManager(store)
+	defer mgr.Stop()
+
+	past := time.Now().Add(-time.Hour)
+	mustDo(t, mgr.BlockIP(ctx, "10.1.0.0/16", "range", nil))
+	mustDo(t, mgr.WhitelistIP(ctx, "10.1.2.3/32"))
+	mustDo(t, mgr.WhitelistIP(ctx, "192.168.0.0/16"))
+	mustDo(t, mgr.BlockIP(ctx, "192.168.5.5", "compromised host", nil))
+	mustDo(t, mgr.BlockIP(ctx, "172.16.0.0/12", "range", nil))
+	mustDo(t, mgr.BlockIP(ctx, "172.16.1.0/24", "lapsed", &past))
+	mustDo(t, mgr.BlockIP(ctx, "2001:db8::/32", "v6 range", nil))
+	mustDo(t, mgr.BlockIP(ctx, "203.0.113.7", "both lists", nil))
+	mustDo(t, mgr.WhitelistIP(ctx, "203.0.113.7"))
+
+	cases := []struct {
+		ip                   string
+		blocked, whitelisted bool
+	}{
+		{"10.1.9.9", true, false},
+		{"10.1.2.3", false, true}, // whitelisted /32 inside a blocked /16
+		{"192.168.9.9", false, true},
+		{"192.168.5.5", true, false},     // blocked /32 inside a whitelisted /16
+		{"172.16.1.1", true, false},      // an expired /24 does not shadow the live /12
+		{"2001:db8:0:1::1", true, false}, // IPv6 prefix
+		{"::ffff:10.1.9.9", true, false}, // IPv4-mapped
+		{"203.0.113.7", false, true},     // the whitelist wins a tie
+		{"198.51.100.1", false, false},
+	}
+	for _, tc := range cases {
+		if got := mgr.IsBlocked(tc.ip); got != tc.blocked {
+			t.Errorf("IsBlocked(%s) = %v, want %v", tc.ip, got, tc.blocked)
+		}
+		if got := mgr.IsWhitelisted(tc.ip); got != tc.whitelisted {
+			t.Errorf("IsWhitelisted(%s) = %v, want %v", tc.ip, got, tc.whitelisted)
+		}
+		if got, _ := store.IsIPBlocked(ctx, tc.ip); got != tc.blocked {
+			t.Errorf("store.IsIPBlocked(%s) = %v, want %v", tc.ip, got, tc.blocked)
+		}
+	}
+	if b := mgr.BlockedBy("10.1.9.9"); b == nil || b.IP != "10.1.0.0/16" || !b.CIDR {
+		t.Errorf("BlockedBy = %+v", b)
+	}
+
+	// Entries are canonicalized, so any spelling of a prefix removes it.
+	mustDo(t, mgr.UnblockIP(ctx, "10.1.77.77/16"))
+	if mgr.IsBlocked("10.1.9.9") {
+		t.Error("prefix still blocked after unblock")
+	}
+	if err := mgr.BlockIP(ctx, "10.0.0.0/33", "", nil); err == nil {
+		t.Error("invalid prefix accepted")
+	}
+
+	// A fresh manager loads both lists from storage.
+	fresh := intelligence.NewIPManager(store)
+	defer fresh.Stop()
+	if !fresh.IsWhitelisted("10.1.2.3") || !fresh.IsBlocked("192.168.5.5") {
+		t.Error("entries not synced from storage")
+	}
+}
+
+func TestIPManager_ASNEntries(t *testing.T) {
+	ctx := context.Background()
+	cityPath, asnPath := writeGeoDBs(t, t.TempDir(), "London")
+	geo := intelligence.NewGeoLocator(sentinel.GeoConfig{
+		Enabled:         true,
+		Provider:        sentinel.GeoIPFree,
+		DatabasePath:    cityPath,
+		ASNDatabasePath: asnPath,
+	})
+	if err := geo.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	mgr := intelligence.NewIPManager(memory.New())
+	defer mgr.Stop()
+	mgr.SetGeoLocator(geo)
+
+	mustDo(t, mgr.BlockIP(ctx, "as20712", "hosting network", nil))
+	if !mgr.IsBlocked("81.2.69.160") {
+		t.Error("address in a blocked ASN is not blocked")
+	}
+	if b := mgr.BlockedBy("81.2.69.160"); b == nil || b.IP != "AS20712" {
+		t.Errorf("BlockedBy = %+v", b)
+	}
+	if mgr.IsBlocked("8.8.8.8") {
+		t.Error("address outside the ASN is blocked")
+	}
+	if geo.CacheSize() != 0 {
+		t.Errorf("ASN checks should not go through the geolocation cache, size %d", geo.CacheSize())
+	}
+
+	// Any prefix is more specific than an ASN.
+	mustDo(t, mgr.WhitelistIP(ctx, "81.2.69.0/24"))
+	if mgr.IsBlocked("81.2.69.160") {
+		t.Error("whitelisted prefix inside a blocked ASN is blocked")
+	}
+}
+
+func mustDo(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,217 @@
+package mmdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Data section field types, as numbered by the MaxMind DB spec.
+const (
+	typeExtended  = 0
+	typePointer   = 1
+	typeString    = 2
+	typeDouble    = 3
+	typeBytes     = 4
+	typeUint16    = 5
+	typeUint32    = 6
+	typeMap       = 7
+	typeInt32     = 8
+	typeUint64    = 9
+	typeUint128   = 10
+	typeSlice     = 11
+	typeContainer = 12
+	typeEndMarker = 13
+	typeBool      = 14
+	typeFloat     = 15
+)
+
+// maxDecodeDepth bounds nesting (maps, arrays and pointer hops) so a crafted
+// file cannot recurse the decoder off the stack.
+const maxDecodeDepth = 64
+
+// decoder reads values from one section of the file. Pointers are offsets
+// relative to the start of buf.
+type decoder struct {
+	buf []byte
+}
+
+// decode returns the value at off and the offset just past it.
+func (d *decoder) decode(off uint) (any, uint, error) {
+	return d.decodeDepth(off, 0)
+}
+
+func (d *decoder) decodeDepth(off uint, depth int) (any, uint, error) {
+	if depth > maxDecodeDepth {
+		return nil, 0, corrupt("data nested more than %d levels deep", maxDecodeDepth)
+	}
+	if off >= uint(len(d.buf)) {
+		return nil, 0, corrupt("offset %d is past the end of the data section", off)
+	}
+	ctrl := d.buf[off]
+	off++
+
+	typ := uint(ctrl >> 5)
+	if typ == typePointer {
+		target, next, err := d.pointer(ctrl, off)
+		if err != nil {
+			return nil, 0, err
+		}
+		v, _, err := d.decodeDepth(target, depth+1)
+		return v, next, err
+	}
+	if typ == typeExtended {
+		if off >= uint(len(d.buf)) {
+			return nil, 0, corrupt("truncated extended type")
+		}
+		typ = 7 + uint(d.buf[off])
+		off++
+		if typ <= typeMap {
+			return nil, 0, corrupt("invalid extended type %d", typ)
+		}
+	}
+
+	size, off, err := d.size(ctrl, off)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch typ {
+	case typeMap:
+		// size comes from the file; don't let it drive the allocation.
+		m := make(map[string]any, min(size, uint(len(d.buf))-off))
+		for i := uint(0); i < size; i++ {
+			var k, v any
+			k, off, err = d.decodeDepth(off, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, 0, corrupt("map key is %T, not a string", k)
+			}
+			v, off, err = d.decodeDepth(off, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[key] = v
+		}
+		return m, off, nil
+	case typeSlice:
+		s := make([]any, 0, min(size, uint(len(d.buf))-off))
+		for i := uint(0); i < size; i++ {
+			var v any
+			v, off, err = d.decodeDepth(off, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			s = append(s, v)
+		}
+		return s, off, nil
+	case typeBool:
+		if size > 1 {
+			return nil, 0, corrupt("boolean of size %d", size)
+		}
+		return size == 1, off, nil
+	case typeContainer, typeEndMarker:
+		return nil, 0, corrupt("type %d is not a value", typ)
+	}
+
+	if off+size > uint(len(d.buf)) {
+		return nil, 0, corrupt("value of %d bytes at offset %d runs past the end of the section", size, off)
+	}
+	b := d.buf[off : off+size]
+	next := off + size
+
+	switch typ {
+	case typeString:
+		return string(b), next, nil
+	case typeBytes:
+		return append([]byte(nil), b...), next, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, corrupt("double of size %d", size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), next, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, corrupt("float of size %d", size)
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), next, nil
+	case typeUint16, typeUint32, typeUint64:
+		limit := map[uint]uint{typeUint16: 2, typeUint32: 4, typeUint64: 8}[typ]
+		if size > limit {
+			return nil, 0, corrupt("%d-byte value for a %d-byte unsigned type", size, limit)
+		}
+		return beUint(b), next, nil
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, corrupt("int32 of size %d", size)
+		}
+		return int64(int32(uint32(beUint(b)))), next, nil
+	case typeUint128:
+		if size > 16 {
+			return nil, 0, corrupt("uint128 of size %d", size)
+		}
+		return new(big.Int).SetBytes(b), next, nil
+	}
+	return nil, 0, corrupt("unknown type %d", typ)
+}
+
+// size decodes the payload size carried in the control byte and the bytes
+// that may follow it.
+func (d *decoder) size(ctrl byte, off uint) (uint, uint, error) {
+	size := uint(ctrl & 0x1f)
+	if size < 29 {
+		return size, off, nil
+	}
+	n := size - 28
+	if off+n > uint(len(d.buf)) {
+		return 0, 0, corrupt("truncated size")
+	}
+	extra := beUint(d.buf[off : off+n])
+	switch size {
+	case 29:
+		size = 29 + uint(extra)
+	case 30:
+		size = 285 + uint(extra)
+	default:
+		size = 65821 + uint(extra)
+	}
+	return size, off + n, nil
+}
+
+// pointer decodes a pointer whose control byte has already been read.
+func (d *decoder) pointer(ctrl byte, off uint) (uint, uint, error) {
+	n := uint(ctrl>>3)&0x3 + 1
+	if off+n > uint(len(d.buf)) {
+		return 0, 0, corrupt("truncated pointer")
+	}
+	b := d.buf[off : off+n]
+	low := uint(ctrl & 0x7)
+	var target uint
+	switch n {
+	case 1:
+		target = low<<8 | uint(b[0])
+	case 2:
+		target = (low<<16 | uint(beUint(b))) + 2048
+	case 3:
+		target = (low<<24 | uint(beUint(b))) + 526336
+	default:
+		target = uint(beUint(b))
+	}
+	return target, off + n, nil
+}
+
+func beUint(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+func corrupt(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidDatabase, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,9 @@
+package mmdb
+
+// DecodeSection decodes the value at off in a raw data section, so tests can
+// feed the decoder hand-assembled bytes.
+func DecodeSection(buf []byte, off uint) (any, error) {
+	d := decoder{buf: buf}
+	v, _, err := d.decode(off)
+	return v, err
+}
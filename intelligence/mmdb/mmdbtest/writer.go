@@ -0,0 +1,335 @@
+// Package mmdbtest builds small MaxMind DB files for tests, so code that
+// reads GeoLite2-style databases can be exercised without shipping binary
+// fixtures.
+package mmdbtest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Options describes the database to build. Zero values pick an IPv6 tree
+// with 28-bit records, the layout MaxMind ships.
+type Options struct {
+	DatabaseType string
+	IPVersion    int // 4 or 6
+	RecordSize   int // 24, 28 or 32
+}
+
+// Build encodes networks — CIDR to record — as a MaxMind DB. IPv4 networks
+// in an IPv6 database are placed under ::/96, where readers look for them.
+// More specific networks win over the networks containing them.
+//
+// Records may contain map[string]any, []any, string, []byte, float64,
+// float32, uint16, uint32, uint64, int (as uint32 or int32), int32 and bool.
+func Build(opts Options, networks map[string]any) ([]byte, error) {
+	if opts.IPVersion == 0 {
+		opts.IPVersion = 6
+	}
+	if opts.RecordSize == 0 {
+		opts.RecordSize = 28
+	}
+	if opts.DatabaseType == "" {
+		opts.DatabaseType = "Test"
+	}
+
+	type entry struct {
+		bits   []byte
+		prefix int
+		cidr   string
+	}
+	var entries []entry
+	for cidr := range networks {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		ones, _ := n.Mask.Size()
+		bits := []byte(n.IP)
+		if ip4 := n.IP.To4(); ip4 != nil {
+			bits = ip4
+			if opts.IPVersion == 6 {
+				bits = append(make([]byte, 12), ip4...)
+				ones += 96
+			}
+		} else if opts.IPVersion == 4 {
+			return nil, fmt.Errorf("mmdbtest: IPv6 network %s in an IPv4 database", cidr)
+		}
+		if ones == 0 {
+			return nil, fmt.Errorf("mmdbtest: %s covers the whole address space", cidr)
+		}
+		entries = append(entries, entry{bits: bits, prefix: ones, cidr: cidr})
+	}
+	// Shorter prefixes first, so a more specific network splits the leaf
+	// of the one containing it instead of being overwritten by it.
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].prefix != entries[j].prefix {
+			return entries[i].prefix < entries[j].prefix
+		}
+		return entries[i].cidr < entries[j].cidr
+	})
+
+	data := &encoder{strings: map[string]int{}}
+	t := &tree{nodes: []node{{}}}
+	for _, e := range entries {
+		off := data.buf.Len()
+		if err := data.encode(networks[e.cidr]); err != nil {
+			return nil, fmt.Errorf("mmdbtest: %s: %w", e.cidr, err)
+		}
+		t.insert(e.bits, e.prefix, ref{kind: refData, val: off})
+	}
+
+	var out bytes.Buffer
+	if err := t.write(&out, opts.RecordSize); err != nil {
+		return nil, err
+	}
+	out.Write(make([]byte, 16))
+	out.Write(data.buf.Bytes())
+	out.WriteString("\xAB\xCD\xEFMaxMind.com")
+
+	meta := &encoder{strings: map[string]int{}}
+	if err := meta.encode(map[string]any{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(time.Now().Unix()),
+		"database_type":               opts.DatabaseType,
+		"description":                 map[string]any{"en": "mmdbtest database"},
+		"ip_version":                  uint16(opts.IPVersion),
+		"languages":                   []any{"en"},
+		"node_count":                  uint32(len(t.nodes)),
+		"record_size":                 uint16(opts.RecordSize),
+	}); err != nil {
+		return nil, err
+	}
+	out.Write(meta.buf.Bytes())
+	return out.Bytes(), nil
+}
+
+// Write builds a database and writes it to path atomically (write to a
+// temporary file, then rename), the way database updaters replace files.
+func Write(path string, opts Options, networks map[string]any) error {
+	buf, err := Build(opts, networks)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".mmdbtest-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// --- search tree ---
+
+const (
+	refEmpty = iota
+	refNode
+	refData
+)
+
+type ref struct {
+	kind int
+	val  int
+}
+
+type node struct {
+	children [2]ref
+}
+
+type tree struct {
+	nodes []node
+}
+
+func (t *tree) insert(bits []byte, prefix int, leaf ref) {
+	bit := func(i int) int { return int(bits[i/8]>>(7-uint(i%8))) & 1 }
+	cur := 0
+	for i := 0; i < prefix-1; i++ {
+		b := bit(i)
+		next := t.nodes[cur].children[b]
+		if next.kind != refNode {
+			// Split an empty or data leaf: both halves inherit it.
+			t.nodes = append(t.nodes, node{children: [2]ref{next, next}})
+			next = ref{kind: refNode, val: len(t.nodes) - 1}
+			t.nodes[cur].children[b] = next
+		}
+		cur = next.val
+	}
+	t.nodes[cur].children[bit(prefix-1)] = leaf
+}
+
+func (t *tree) write(out *bytes.Buffer, recordSize int) error {
+	count := len(t.nodes)
+	value := func(r ref) uint32 {
+		switch r.kind {
+		case refNode:
+			return uint32(r.val)
+		case refData:
+			return uint32(count + 16 + r.val)
+		}
+		return uint32(count)
+	}
+	limit := uint64(1) << recordSize
+	for _, n := range t.nodes {
+		l, r := value(n.children[0]), value(n.children[1])
+		if uint64(l) >= limit || uint64(r) >= limit {
+			return fmt.Errorf("mmdbtest: database too large for %d-bit records", recordSize)
+		}
+		switch recordSize {
+		case 24:
+			out.Write([]byte{byte(l >> 16), byte(l >> 8), byte(l), byte(r >> 16), byte(r >> 8), byte(r)})
+		case 28:
+			out.Write([]byte{byte(l >> 16), byte(l >> 8), byte(l),
+				byte(l>>24)<<4 | byte(r>>24)&0x0f,
+				byte(r >> 16), byte(r >> 8), byte(r)})
+		case 32:
+			out.Write(binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, l), r))
+		default:
+			return fmt.Errorf("mmdbtest: unsupported record size %d", recordSize)
+		}
+	}
+	return nil
+}
+
+// --- data section ---
+
+type encoder struct {
+	buf bytes.Buffer
+	// strings maps strings already written to their offsets; repeats are
+	// written as pointers, as real databases do for map keys.
+	strings map[string]int
+}
+
+func (e *encoder) encode(v any) error {
+	switch v := v.(type) {
+	case string:
+		if off, ok := e.strings[v]; ok && len(v) > 3 {
+			e.pointer(off)
+			return nil
+		}
+		e.strings[v] = e.buf.Len()
+		e.control(2, len(v))
+		e.buf.WriteString(v)
+	case []byte:
+		e.control(4, len(v))
+		e.buf.Write(v)
+	case float64:
+		e.control(3, 8)
+		e.buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(v)))
+	case float32:
+		e.control(15, 4)
+		e.buf.Write(binary.BigEndian.AppendUint32(nil, math.Float32bits(v)))
+	case uint16:
+		e.uint(5, uint64(v))
+	case uint32:
+		e.uint(6, uint64(v))
+	case uint64:
+		e.uint(9, v)
+	case int:
+		if v >= 0 && v <= math.MaxUint32 {
+			e.uint(6, uint64(v))
+			return nil
+		}
+		return e.encode(int32(v))
+	case int32:
+		e.control(8, 4)
+		e.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(v)))
+	case bool:
+		n := 0
+		if v {
+			n = 1
+		}
+		e.control(14, n)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		e.control(7, len(v))
+		for _, k := range keys {
+			if err := e.encode(k); err != nil {
+				return err
+			}
+			if err := e.encode(v[k]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		e.control(11, len(v))
+		for _, x := range v {
+			if err := e.encode(x); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type %T", v)
+	}
+	return nil
+}
+
+func (e *encoder) uint(typ int, v uint64) {
+	var b []byte
+	for ; v > 0; v >>= 8 {
+		b = append([]byte{byte(v)}, b...)
+	}
+	e.control(typ, len(b))
+	e.buf.Write(b)
+}
+
+func (e *encoder) control(typ, size int) {
+	var ctrl byte
+	if typ <= 7 {
+		ctrl = byte(typ) << 5
+	}
+	var extra []byte
+	switch {
+	case size < 29:
+		ctrl |= byte(size)
+	case size < 285:
+		ctrl |= 29
+		extra = []byte{byte(size - 29)}
+	case size < 65821:
+		ctrl |= 30
+		s := size - 285
+		extra = []byte{byte(s >> 8), byte(s)}
+	default:
+		ctrl |= 31
+		s := size - 65821
+		extra = []byte{byte(s >> 16), byte(s >> 8), byte(s)}
+	}
+	e.buf.WriteByte(ctrl)
+	if typ > 7 {
+		e.buf.WriteByte(byte(typ - 7))
+	}
+	e.buf.Write(extra)
+}
+
+func (e *encoder) pointer(off int) {
+	switch {
+	case off < 2048:
+		e.buf.Write([]byte{0x20 | byte(off>>8)&0x7, byte(off)})
+	case off < 526336:
+		p := off - 2048
+		e.buf.Write([]byte{0x28 | byte(p>>16)&0x7, byte(p >> 8), byte(p)})
+	case off < 134744064:
+		p := off - 526336
+		e.buf.Write([]byte{0x30 | byte(p>>24)&0x7, byte(p >> 16), byte(p >> 8), byte(p)})
+	default:
+		e.buf.Write(append([]byte{0x38}, binary.BigEndian.AppendUint32(nil, uint32(off))...))
+	}
+}
@@ -0,0 +1,216 @@
+// Package mmdb reads MaxMind DB files — the .mmdb format used by GeoLite2,
+// GeoIP2 and the compatible databases other vendors ship — in pure Go, with
+// no cgo and no third-party dependencies.
+//
+// A Reader holds the whole file in memory and is safe for concurrent use.
+// Records decode into plain Go values:
+//
+//	map        map[string]any
+//	array      []any
+//	string     string
+//	bytes      []byte
+//	double     float64
+//	float      float64
+//	uint16/32/64 uint64
+//	int32      int64
+//	uint128    *big.Int
+//	boolean    bool
+package mmdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// ErrInvalidDatabase is wrapped by every error caused by a malformed file.
+var ErrInvalidDatabase = errors.New("mmdb: invalid database")
+
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// dataSectionSeparator is the run of zero bytes between the search tree and
+// the data section.
+const dataSectionSeparator = 16
+
+// Metadata describes a database, as read from the file's metadata section.
+type Metadata struct {
+	BinaryFormatMajorVersion uint
+	BinaryFormatMinorVersion uint
+	BuildEpoch               uint64
+	DatabaseType             string
+	Description              map[string]string
+	IPVersion                uint
+	Languages                []string
+	NodeCount                uint
+	RecordSize               uint
+}
+
+// Reader looks up IP addresses in a MaxMind DB.
+type Reader struct {
+	meta      Metadata
+	tree      []byte
+	data      decoder
+	ipv4Start uint
+}
+
+// Open reads the database at path into memory.
+func Open(path string) (*Reader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return FromBytes(buf)
+}
+
+// FromBytes parses a database already in memory. The Reader keeps buf; the
+// caller must not modify it afterwards.
+func FromBytes(buf []byte) (*Reader, error) {
+	i := bytes.LastIndex(buf, metadataMarker)
+	if i < 0 {
+		return nil, corrupt("metadata marker not found")
+	}
+	meta, err := parseMetadata(buf[i+len(metadataMarker):])
+	if err != nil {
+		return nil, err
+	}
+
+	if meta.NodeCount > uint(i) {
+		return nil, corrupt("search tree of %d nodes does not fit in the file", meta.NodeCount)
+	}
+	treeSize := meta.NodeCount * meta.RecordSize / 4
+	if treeSize+dataSectionSeparator > uint(i) {
+		return nil, corrupt("search tree of %d nodes does not fit in the file", meta.NodeCount)
+	}
+	r := &Reader{
+		meta: meta,
+		tree: buf[:treeSize],
+		data: decoder{buf: buf[treeSize+dataSectionSeparator : i]},
+	}
+
+	// IPv4 addresses live under ::/96 in an IPv6 tree; find that subtree
+	// once rather than walking 96 zero bits on every lookup.
+	if meta.IPVersion == 6 {
+		node := uint(0)
+		for depth := 0; depth < 96 && node < meta.NodeCount; depth++ {
+			node = r.record(node, 0)
+		}
+		r.ipv4Start = node
+	}
+	return r, nil
+}
+
+func parseMetadata(buf []byte) (Metadata, error) {
+	d := decoder{buf: buf}
+	v, _, err := d.decode(0)
+	if err != nil {
+		return Metadata{}, err
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return Metadata{}, corrupt("metadata is %T, not a map", v)
+	}
+
+	u := func(key string) uint {
+		n, _ := m[key].(uint64)
+		return uint(n)
+	}
+	meta := Metadata{
+		BinaryFormatMajorVersion: u("binary_format_major_version"),
+		BinaryFormatMinorVersion: u("binary_format_minor_version"),
+		IPVersion:                u("ip_version"),
+		NodeCount:                u("node_count"),
+		RecordSize:               u("record_size"),
+		Description:              map[string]string{},
+	}
+	meta.BuildEpoch, _ = m["build_epoch"].(uint64)
+	meta.DatabaseType, _ = m["database_type"].(string)
+	if desc, ok := m["description"].(map[string]any); ok {
+		for lang, text := range desc {
+			if s, ok := text.(string); ok {
+				meta.Description[lang] = s
+			}
+		}
+	}
+	if langs, ok := m["languages"].([]any); ok {
+		for _, l := range langs {
+			if s, ok := l.(string); ok {
+				meta.Languages = append(meta.Languages, s)
+			}
+		}
+	}
+
+	switch {
+	case meta.BinaryFormatMajorVersion != 2:
+		return Metadata{}, corrupt("unsupported binary format version %d", meta.BinaryFormatMajorVersion)
+	case meta.RecordSize != 24 && meta.RecordSize != 28 && meta.RecordSize != 32:
+		return Metadata{}, corrupt("unsupported record size %d", meta.RecordSize)
+	case meta.IPVersion != 4 && meta.IPVersion != 6:
+		return Metadata{}, corrupt("unsupported IP version %d", meta.IPVersion)
+	case meta.NodeCount == 0:
+		return Metadata{}, corrupt("empty search tree")
+	}
+	return meta, nil
+}
+
+// Metadata returns the database's metadata.
+func (r *Reader) Metadata() Metadata {
+	return r.meta
+}
+
+// Lookup returns the record for ip, or nil if the database has no network
+// containing it.
+func (r *Reader) Lookup(ip net.IP) (any, error) {
+	var bits []byte
+	node := uint(0)
+	if ip4 := ip.To4(); ip4 != nil {
+		bits = ip4
+		node = r.ipv4Start
+	} else if ip16 := ip.To16(); ip16 != nil {
+		if r.meta.IPVersion == 4 {
+			return nil, fmt.Errorf("mmdb: cannot look up IPv6 address %s in an IPv4-only database", ip)
+		}
+		bits = ip16
+	} else {
+		return nil, fmt.Errorf("mmdb: invalid IP address %q", []byte(ip))
+	}
+
+	count := r.meta.NodeCount
+	for i := 0; i < len(bits)*8 && node < count; i++ {
+		bit := uint(bits[i>>3]>>(7-uint(i&7))) & 1
+		node = r.record(node, bit)
+	}
+
+	switch {
+	case node == count:
+		return nil, nil
+	case node < count:
+		return nil, corrupt("search tree deeper than the address")
+	case node < count+dataSectionSeparator:
+		return nil, corrupt("record %d points into the section separator", node)
+	}
+	off := node - count - dataSectionSeparator
+	v, _, err := r.data.decode(off)
+	return v, err
+}
+
+// record returns the left (bit 0) or right (bit 1) record of node.
+func (r *Reader) record(node, bit uint) uint {
+	b := r.tree
+	switch r.meta.RecordSize {
+	case 24:
+		off := node*6 + bit*3
+		return uint(b[off])<<16 | uint(b[off+1])<<8 | uint(b[off+2])
+	case 28:
+		off := node * 7
+		if bit == 0 {
+			return uint(b[off+3]&0xf0)<<20 | uint(b[off])<<16 | uint(b[off+1])<<8 | uint(b[off+2])
+		}
+		return uint(b[off+3]&0x0f)<<24 | uint(b[off+4])<<16 | uint(b[off+5])<<8 | uint(b[off+6])
+	default:
+		off := node*8 + bit*4
+		return uint(binary.BigEndian.Uint32(b[off:]))
+	}
+}
@@ -0,0 +1,202 @@
+package mmdb_test
+
+import (
+	"errors"
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/MUKE-coder/sentinel/v2/intelligence/mmdb"
+	"github.com/MUKE-coder/sentinel/v2/intelligence/mmdb/mmdbtest"
+)
+
+func cityRecord(iso, city string) map[string]any {
+	return map[string]any{
+		"country": map[string]any{"iso_code": iso, "names": map[string]any{"en": iso + "-land"}},
+		"city":    map[string]any{"names": map[string]any{"en": city}},
+		"location": map[string]any{
+			"latitude":  12.5,
+			"longitude": -45.25,
+		},
+	}
+}
+
+func TestLookupAcrossRecordSizes(t *testing.T) {
+	networks := map[string]any{
+		"81.2.69.0/24":      cityRecord("GB", "London"),
+		"81.2.69.128/26":    cityRecord("GB", "Boxford"), // more specific wins
+		"2001:db8:100::/40": cityRecord("DE", "Berlin"),
+	}
+	for _, size := range []int{24, 28, 32} {
+		buf, err := mmdbtest.Build(mmdbtest.Options{DatabaseType: "GeoLite2-City", RecordSize: size}, networks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := mmdb.FromBytes(buf)
+		if err != nil {
+			t.Fatalf("record size %d: %v", size, err)
+		}
+		if m := r.Metadata(); m.RecordSize != uint(size) || m.IPVersion != 6 || m.DatabaseType != "GeoLite2-City" || m.Languages[0] != "en" {
+			t.Errorf("record size %d: unexpected metadata %+v", size, m)
+		}
+
+		cases := map[string]string{
+			"81.2.69.1":            "London",
+			"81.2.69.150":          "Boxford",
+			"81.2.69.200":          "London",
+			"2001:db8:100:1::7":    "Berlin",
+			"::ffff:81.2.69.1":     "London", // IPv4-mapped is an IPv4 address
+			"81.2.70.1":            "",
+			"2001:db8:200::1":      "",
+			"::51.2.69.1":          "",
+			"ffff:ffff::ffff:ffff": "",
+		}
+		for ip, want := range cases {
+			v, err := r.Lookup(net.ParseIP(ip))
+			if err != nil {
+				t.Fatalf("record size %d, %s: %v", size, ip, err)
+			}
+			got := ""
+			if v != nil {
+				got = v.(map[string]any)["city"].(map[string]any)["names"].(map[string]any)["en"].(string)
+			}
+			if got != want {
+				t.Errorf("record size %d, %s: got city %q, want %q", size, ip, got, want)
+			}
+		}
+	}
+}
+
+func TestLookupIPv4Database(t *testing.T) {
+	buf, err := mmdbtest.Build(mmdbtest.Options{IPVersion: 4, RecordSize: 24}, map[string]any{
+		"1.0.0.0/24": map[string]any{"autonomous_system_number": uint32(13335)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := mmdb.FromBytes(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := r.Lookup(net.ParseIP("1.0.0.1"))
+	if err != nil || v.(map[string]any)["autonomous_system_number"] != uint64(13335) {
+		t.Fatalf("got %v, %v", v, err)
+	}
+	if _, err := r.Lookup(net.ParseIP("2001:db8::1")); err == nil {
+		t.Error("expected an error looking up IPv6 in an IPv4 database")
+	}
+}
+
+func TestDecodeTypes(t *testing.T) {
+	record := map[string]any{
+		"string": "hello",
+		"bytes":  []byte{1, 2, 3},
+		"double": 3.25,
+		"float":  float32(1.5),
+		"u16":    uint16(65535),
+		"u32":    uint32(1 << 31),
+		"u64":    uint64(1 << 63),
+		"i32":    int32(-7),
+		"true":   true,
+		"false":  false,
+		"array":  []any{"a", uint16(1), []any{}},
+		"long":   string(make([]byte, 70000)), // largest size encoding
+		"empty":  map[string]any{},
+	}
+	buf, err := mmdbtest.Build(mmdbtest.Options{}, map[string]any{"10.0.0.0/8": record})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := mmdb.FromBytes(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := r.Lookup(net.ParseIP("10.1.2.3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"string": "hello",
+		"bytes":  []byte{1, 2, 3},
+		"double": 3.25,
+		"float":  1.5,
+		"u16":    uint64(65535),
+		"u32":    uint64(1 << 31),
+		"u64":    uint64(1 << 63),
+		"i32":    int64(-7),
+		"true":   true,
+		"false":  false,
+		"array":  []any{"a", uint64(1), []any{}},
+		"long":   string(make([]byte, 70000)),
+		"empty":  map[string]any{},
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("decoded record differs:\n got %#v\nwant %#v", v, want)
+	}
+}
+
+// The writer only emits short pointers, so the longer encodings and
+// uint128 are checked against hand-assembled bytes. The sections are
+// padded so each pointer lands on a string at its target offset.
+func TestDecodePointersAndUint128(t *testing.T) {
+	targets := []struct {
+		ptr    []byte
+		target int
+	}{
+		{[]byte{0x20 | 0x1, 0x02}, 0x102},
+		{[]byte{0x28 | 0x0, 0x00, 0x10}, 2048 + 0x10},
+		{[]byte{0x30 | 0x0, 0x00, 0x00, 0x05}, 526336 + 5},
+		{[]byte{0x38, 0x00, 0x00, 0x01, 0x00}, 0x100},
+	}
+	for _, tc := range targets {
+		section := make([]byte, tc.target+3)
+		copy(section, tc.ptr)
+		copy(section[tc.target:], []byte{0x42, 'o', 'k'}) // string "ok"
+		v, err := mmdb.DecodeSection(section, 0)
+		if err != nil || v != "ok" {
+			t.Errorf("pointer % x: got %v, %v", tc.ptr, v, err)
+		}
+	}
+
+	// uint128 is extended type 10 (7+3), 16 bytes.
+	u128 := append([]byte{0x10, 0x03}, make([]byte, 16)...)
+	u128[2] = 0x80
+	v, err := mmdb.DecodeSection(u128, 0)
+	want := new(big.Int).Lsh(big.NewInt(1), 127)
+	if err != nil || v.(*big.Int).Cmp(want) != 0 {
+		t.Errorf("uint128: got %v, %v", v, err)
+	}
+}
+
+func TestMalformedDatabases(t *testing.T) {
+	good, err := mmdbtest.Build(mmdbtest.Options{}, map[string]any{
+		"81.2.69.0/24": cityRecord("GB", "London"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mmdb.FromBytes([]byte("not a database")); !errors.Is(err, mmdb.ErrInvalidDatabase) {
+		t.Errorf("expected ErrInvalidDatabase, got %v", err)
+	}
+
+	// Every truncation and every single-byte corruption must fail cleanly,
+	// never panic.
+	for n := range good {
+		if r, err := mmdb.FromBytes(good[:n]); err == nil {
+			r.Lookup(net.ParseIP("81.2.69.1"))
+		}
+		bad := append([]byte(nil), good...)
+		bad[n] ^= 0xff
+		if r, err := mmdb.FromBytes(bad); err == nil {
+			r.Lookup(net.ParseIP("81.2.69.1"))
+			r.Lookup(net.ParseIP("2001:db8::1"))
+		}
+	}
+
+	// Self-referencing pointers hit the depth limit instead of recursing
+	// forever.
+	if _, err := mmdb.DecodeSection([]byte{0x20, 0x00}, 0); !errors.Is(err, mmdb.ErrInvalidDatabase) {
+		t.Errorf("pointer loop: expected ErrInvalidDatabase, got %v", err)
+	}
+}
@@ -0,0 +1,244 @@
+package intelligence
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/netip"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+)
+
+// NetworkDetector tells which kind of network a client IP belongs to, from
+// lists of Tor exit nodes and VPN and hosting ranges read from local files.
+// The files are reloaded when they change. Each list is held in a radix
+// tree, so a lookup costs the same with ten entries or a million. Safe for
+// concurrent use.
+type NetworkDetector struct {
+	lists   []*networkList // Tor, then VPN, then hosting: the lookup order
+	boosts  map[sentinel.NetworkType]int
+	refresh time.Duration
+	stopCh  chan struct{}
+	wg      sync.WaitGroup
+}
+
+type networkList struct {
+	typ     sentinel.NetworkType
+	path    string
+	data    atomic.Pointer[feedData]
+	matches atomic.Int64
+
+	mu      sync.Mutex // guards the fields below; held across a load
+	modTime time.Time
+	size    int64
+	loaded  time.Time
+	lastErr string
+}
+
+// defaultNetworkBoosts are the NetworkConfig.ScoreBoost defaults.
+var defaultNetworkBoosts = map[sentinel.NetworkType]int{
+	sentinel.NetworkTor:     20,
+	sentinel.NetworkVPN:     10,
+	sentinel.NetworkHosting: 5,
+}
+
+// NewNetworkDetector returns a detector for the lists in config, with its
+// defaults applied. Nothing is loaded until Start or Reload.
+func NewNetworkDetector(config sentinel.NetworkConfig) *NetworkDetector {
+	d := &NetworkDetector{
+		boosts:  make(map[sentinel.NetworkType]int),
+		refresh: config.Refresh,
+		stopCh:  make(chan struct{}),
+	}
+	if d.refresh <= 0 {
+		d.refresh = time.Hour
+	}
+	for t, boost := range defaultNetworkBoosts {
+		d.boosts[t] = boost
+	}
+	for t, boost := range config.ScoreBoost {
+		d.boosts[t] = max(boost, 0)
+	}
+	for _, set := range []struct {
+		typ   sentinel.NetworkType
+		paths []string
+	}{
+		{sentinel.NetworkTor, config.TorExitPaths},
+		{sentinel.NetworkVPN, config.VPNPaths},
+		{sentinel.NetworkHosting, config.HostingPaths},
+	} {
+		for _, path := range set.paths {
+			d.lists = append(d.lists, &networkList{typ: set.typ, path: path})
+		}
+	}
+	return d
+}
+
+// Start loads every list and keeps checking the files for changes until
+// Stop. The lists are loaded before Start returns.
+func (d *NetworkDetector) Start() {
+	for _, l := range d.lists {
+		l.load(false)
+	}
+	d.wg.Add(1)
+	go d.run()
+}
+
+// Stop ends the refresh loop.
+func (d *NetworkDetector) Stop() {
+	close(d.stopCh)
+	d.wg.Wait()
+}
+
+func (d *NetworkDetector) run() {
+	defer d.wg.Done()
+	ticker := time.NewTicker(d.refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stopCh:
+			return
+		case <-ticker.C:
+			for _, l := range d.lists {
+				l.load(false)
+			}
+		}
+	}
+}
+
+// Reload reads every list file now, changed or not. Lists that fail to
+// load keep their current entries; the errors are returned joined.
+func (d *NetworkDetector) Reload() error {
+	var errs []error
+	for _, l := range d.lists {
+		if err := l.load(true); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", l.path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// load reads the list's file if it changed since the last load, or
+// regardless when force is set.
+func (l *networkList) load(force bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	err := l.read(force)
+	if err != nil {
+		l.lastErr = err.Error()
+		log.Printf("[sentinel] network list %s: %v", l.path, err)
+		return err
+	}
+	l.lastErr = ""
+	return nil
+}
+
+func (l *networkList) read(force bool) error {
+	info, err := os.Stat(l.path)
+	if err != nil {
+		return err
+	}
+	if !force && l.data.Load() != nil && info.ModTime().Equal(l.modTime) && info.Size() == l.size {
+		return nil
+	}
+	file, err := os.Open(l.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	body, err := readLimited(file)
+	if err != nil {
+		return err
+	}
+	data := &feedData{domains: make(map[string]time.Time)}
+	if err := parseText(data, body); err != nil {
+		return err
+	}
+	// Domains mean nothing here; count them with the other unusable lines.
+	data.skipped += len(data.domains)
+	data.domains = nil
+
+	l.data.Store(data)
+	l.modTime, l.size, l.loaded = info.ModTime(), info.Size(), time.Now()
+	return nil
+}
+
+// --- Lookups ---
+
+// Classify returns the network type of ip and counts the match against the
+// list that decided it. It returns nil for an address that is not public —
+// private, loopback, link-local — and so has no network type.
+func (d *NetworkDetector) Classify(ip string) *sentinel.NetworkMatch {
+	match, from := d.lookup(ip)
+	if from != nil {
+		from.matches.Add(1)
+	}
+	return match
+}
+
+// Lookup is Classify without counting the match in the lists' stats.
+func (d *NetworkDetector) Lookup(ip string) *sentinel.NetworkMatch {
+	match, _ := d.lookup(ip)
+	return match
+}
+
+func (d *NetworkDetector) lookup(ip string) (*sentinel.NetworkMatch, *networkList) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, nil
+	}
+	addr = addr.Unmap().WithZone("")
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return nil, nil
+	}
+	for _, l := range d.lists {
+		data := l.data.Load()
+		if data == nil {
+			continue
+		}
+		if p, _, ok := data.prefixes.Lookup(addr); ok {
+			return &sentinel.NetworkMatch{
+				Type:       l.typ,
+				ScoreBoost: d.boosts[l.typ],
+				List:       l.path,
+				Indicator:  p.String(),
+			}, l
+		}
+	}
+	return &sentinel.NetworkMatch{
+		Type:       sentinel.NetworkResidential,
+		ScoreBoost: d.boosts[sentinel.NetworkResidential],
+	}, nil
+}
+
+// Stats returns the state of every list, in lookup order.
+func (d *NetworkDetector) Stats() []sentinel.NetworkListStats {
+	out := make([]sentinel.NetworkListStats, 0, len(d.lists))
+	for _, l := range d.lists {
+		s := sentinel.NetworkListStats{
+			Type:    l.typ,
+			Path:    l.path,
+			Matches: l.matches.Load(),
+		}
+		if data := l.data.Load(); data != nil {
+			s.Prefixes = data.prefixes.Len()
+			s.Skipped = data.skipped
+		}
+		// A load in progress holds the lock; report what was there before.
+		if l.mu.TryLock() {
+			if !l.loaded.IsZero() {
+				loaded := l.loaded
+				s.LastLoaded = &loaded
+			}
+			s.LastError = l.lastErr
+			l.mu.Unlock()
+		}
+		out = append(out, s)
+	}
+	return out
+}
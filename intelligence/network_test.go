@@ -0,0 +1,95 @@
+package intelligence
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+)
+
+func TestNetworkDetector_Classify(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	tor := write("tor-exits.txt", "# bulk exit list\n198.51.100.7\n2001:db8:7::1\n")
+	vpn := write("vpn.txt", "198.51.100.0/24\nvpn.example\n")
+	hosting := write("hosting.txt", "198.51.0.0/16\n203.0.113.0/24\n")
+
+	d := NewNetworkDetector(sentinel.NetworkConfig{
+		Enabled:      true,
+		TorExitPaths: []string{tor},
+		VPNPaths:     []string{vpn, filepath.Join(dir, "missing.txt")},
+		HostingPaths: []string{hosting},
+		ScoreBoost:   map[sentinel.NetworkType]int{sentinel.NetworkHosting: -1},
+	})
+	d.Start()
+	defer d.Stop()
+
+	cases := []struct {
+		ip    string
+		want  sentinel.NetworkType
+		boost int
+	}{
+		{"198.51.100.7", sentinel.NetworkTor, 20}, // on every list: Tor wins
+		{"::ffff:198.51.100.7", sentinel.NetworkTor, 20},
+		{"2001:db8:7::1", sentinel.NetworkTor, 20},
+		{"198.51.100.8", sentinel.NetworkVPN, 10},
+		{"198.51.7.7", sentinel.NetworkHosting, 0}, // negative boost disables it
+		{"192.0.2.1", sentinel.NetworkResidential, 0},
+		{"10.0.0.1", "", 0}, // private
+		{"127.0.0.1", "", 0},
+		{"not-an-ip", "", 0},
+	}
+	for _, tc := range cases {
+		m := d.Classify(tc.ip)
+		if tc.want == "" {
+			if m != nil {
+				t.Errorf("Classify(%s) = %+v, want nil", tc.ip, m)
+			}
+			continue
+		}
+		if m == nil || m.Type != tc.want || m.ScoreBoost != tc.boost {
+			t.Errorf("Classify(%s) = %+v, want %s with boost %d", tc.ip, m, tc.want, tc.boost)
+		}
+	}
+
+	stats := d.Stats()
+	if len(stats) != 4 {
+		t.Fatalf("expected 4 lists, got %d", len(stats))
+	}
+	if s := stats[0]; s.Type != sentinel.NetworkTor || s.Prefixes != 2 || s.Matches != 3 || s.LastLoaded == nil {
+		t.Errorf("tor stats: %+v", s)
+	}
+	if s := stats[1]; s.Prefixes != 1 || s.Skipped != 1 {
+		t.Errorf("vpn stats: %+v", s)
+	}
+	if s := stats[2]; s.LastError == "" || s.LastLoaded != nil {
+		t.Errorf("missing file should report an error: %+v", s)
+	}
+
+	// A replaced file is picked up; a broken one keeps its entries.
+	future := time.Now().Add(time.Minute)
+	write("tor-exits.txt", "203.0.113.9\n")
+	os.Chtimes(tor, future, future)
+	if err := d.Reload(); err == nil {
+		t.Error("Reload should report the missing file")
+	}
+	if m := d.Lookup("203.0.113.9"); m == nil || m.Type != sentinel.NetworkTor {
+		t.Errorf("reloaded entry: %+v", m)
+	}
+	if m := d.Lookup("198.51.100.7"); m == nil || m.Type != sentinel.NetworkVPN {
+		t.Errorf("removed Tor entry should fall through to the VPN list: %+v", m)
+	}
+	os.Remove(vpn)
+	d.Reload()
+	if m := d.Lookup("198.51.100.8"); m == nil || m.Type != sentinel.NetworkVPN {
+		t.Errorf("a list that fails to load should keep its entries: %+v", m)
+	}
+}
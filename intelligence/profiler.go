@@ -12,10 +12,14 @@ import (
 	"github.com/MUKE-coder/sentinel/v2/storage"
 )
 
+// maxActorFingerprints bounds ThreatActor.JA3 and JA4.
+const maxActorFingerprints = 10
+
 // Profiler processes threat events and maintains threat actor profiles.
 // It runs as a pipeline handler, updating actor profiles for every threat event.
 type Profiler struct {
-	store storage.Store
+	store     storage.Store
+	campaigns *CampaignEngine
 }
 
 // NewProfiler creates a new threat actor profiler.
@@ -23,6 +27,12 @@ func NewProfiler(store storage.Store) *Profiler {
 	return &Profiler{store: store}
 }
 
+// SetCampaignEngine makes the profiler group actors into campaigns. It must
+// be called before the pipeline starts.
+func (p *Profiler) SetCampaignEngine(e *CampaignEngine) {
+	p.campaigns = e
+}
+
 // Handle processes a pipeline event. Only EventThreat events are handled.
 func (p *Profiler) Handle(ctx context.Context, event pipeline.Event) error {
 	if event.Type != pipeline.EventThreat {
@@ -74,6 +84,22 @@ func (p *Profiler) ProcessThreat(ctx context.Context, te *sentinel.ThreatEvent)
 		actor.TargetedRoutes = append(actor.TargetedRoutes, route)
 	}
 
+	// Remember the TLS fingerprints the actor has used
+	actor.JA3 = appendRecent(actor.JA3, te.JA3, maxActorFingerprints)
+	actor.JA4 = appendRecent(actor.JA4, te.JA4, maxActorFingerprints)
+
+	// Remember the threat feeds listing the actor
+	if te.FeedSource != "" {
+		actor.ThreatFeeds = appendRecent(actor.ThreatFeeds, te.FeedSource, maxActorFingerprints)
+		actor.FeedScore = max(actor.FeedScore, te.FeedScore)
+	}
+
+	// Keep the network type the actor was last seen on
+	if te.NetworkType != "" {
+		actor.NetworkType = te.NetworkType
+		actor.NetworkScore = te.NetworkScore
+	}
+
 	// Copy geo data from threat if available and actor doesn't have it
 	if actor.Country == "" && te.Country != "" {
 		actor.Country = te.Country
@@ -85,6 +111,10 @@ func (p *Profiler) ProcessThreat(ctx context.Context, te *sentinel.ThreatEvent)
 	// Recompute risk score
 	actor.RiskScore = ComputeRiskScore(actor)
 
+	if p.campaigns != nil {
+		actor.CampaignID = p.campaigns.Observe(ctx, te, actor)
+	}
+
 	// Persist
 	if err := p.store.UpsertActor(ctx, actor); err != nil {
 		log.Printf("[sentinel] profiler: failed to upsert actor %s: %v", ip, err)
@@ -97,6 +127,8 @@ func (p *Profiler) ProcessThreat(ctx context.Context, te *sentinel.ThreatEvent)
 // ComputeRiskScore calculates a risk score (0-100) for a threat actor based on:
 //   - +10 for each unique attack type (max 50)
 //   - +20 if known bad actor (AbuseIPDB)
+//   - the largest ScoreBoost of the threat feeds listing the actor
+//   - the ScoreBoost of the actor's network type (Tor, VPN, hosting)
 //   - +10 if attacked in last hour
 //   - +20 if attack count > 100
 //   - Capped at 100
@@ -115,6 +147,12 @@ func ComputeRiskScore(actor *sentinel.ThreatActor) int {
 		score += 20
 	}
 
+	// Threat feed listings
+	score += actor.FeedScore
+
+	// Anonymizing or hosting network
+	score += actor.NetworkScore
+
 	// +10 if attacked in last hour
 	if time.Since(actor.LastSeen) < time.Hour {
 		score += 10
@@ -141,3 +179,22 @@ func containsStr(slice []string, s string) bool {
 	}
 	return false
 }
+
+// appendRecent moves s to the end of list, appending it if absent and
+// dropping the oldest entries beyond max. An empty s leaves list as is.
+func appendRecent(list []string, s string, max int) []string {
+	if s == "" {
+		return list
+	}
+	out := make([]string, 0, len(list)+1)
+	for _, v := range list {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	out = append(out, s)
+	if len(out) > max {
+		out = out[len(out)-max:]
+	}
+	return out
+}
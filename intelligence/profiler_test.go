@@ -221,6 +221,18 @@ func TestComputeRiskScore(t *testing.T) {
 			minScore: 10, // 10 (type only, not recent)
 			maxScore: 10,
 		},
+		{
+			name: "actor on a Tor exit node",
+			actor: &sentinel.ThreatActor{
+				AttackTypes:  []string{"SQLi"},
+				LastSeen:     time.Now().Add(-2 * time.Hour),
+				ThreatCount:  5,
+				NetworkType:  sentinel.NetworkTor,
+				NetworkScore: 20,
+			},
+			minScore: 30, // 10 (type) + 20 (Tor)
+			maxScore: 30,
+		},
 	}
 
 	for _, tt := range tests {
@@ -232,3 +244,57 @@ func TestComputeRiskScore(t *testing.T) {
 		})
 	}
 }
+
+func TestProfiler_RecordsRecentFingerprints(t *testing.T) {
+	store := memory.New()
+	store.Migrate(context.Background())
+	profiler := intelligence.NewProfiler(store)
+
+	for _, ja4 := range []string{"a", "b", "a", ""} {
+		te := &sentinel.ThreatEvent{
+			Timestamp:   time.Now(),
+			IP:          "10.0.0.7",
+			Method:      "GET",
+			Path:        "/",
+			ThreatTypes: []string{"SQLi"},
+			JA4:         ja4,
+		}
+		if err := profiler.ProcessThreat(context.Background(), te); err != nil {
+			t.Fatalf("ProcessThreat failed: %v", err)
+		}
+	}
+
+	actor, _ := store.GetActor(context.Background(), "10.0.0.7")
+	if len(actor.JA4) != 2 || actor.JA4[0] != "b" || actor.JA4[1] != "a" {
+		t.Errorf("expected JA4 [b a], most recent last, got %v", actor.JA4)
+	}
+	if len(actor.JA3) != 0 {
+		t.Errorf("expected no JA3, got %v", actor.JA3)
+	}
+}
+
+func TestProfiler_FeedListingRaisesRiskScore(t *testing.T) {
+	store := memory.New()
+	store.Migrate(context.Background())
+	profiler := intelligence.NewProfiler(store)
+
+	base := &sentinel.ThreatEvent{Timestamp: time.Now(), IP: "10.0.0.8", Method: "GET", Path: "/", ThreatTypes: []string{"SQLi"}}
+	if err := profiler.ProcessThreat(context.Background(), base); err != nil {
+		t.Fatalf("ProcessThreat failed: %v", err)
+	}
+	before, _ := store.GetActor(context.Background(), "10.0.0.8")
+	score := before.RiskScore
+
+	listed := *base
+	listed.FeedSource, listed.FeedScore = "drop", 20
+	if err := profiler.ProcessThreat(context.Background(), &listed); err != nil {
+		t.Fatalf("ProcessThreat failed: %v", err)
+	}
+	actor, _ := store.GetActor(context.Background(), "10.0.0.8")
+	if len(actor.ThreatFeeds) != 1 || actor.ThreatFeeds[0] != "drop" || actor.FeedScore != 20 {
+		t.Errorf("expected the feed recorded on the actor, got %v / %d", actor.ThreatFeeds, actor.FeedScore)
+	}
+	if actor.RiskScore != score+20 {
+		t.Errorf("expected risk score %d, got %d", score+20, actor.RiskScore)
+	}
+}
@@ -0,0 +1,387 @@
+package intelligence
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/storage"
+)
+
+// Session risk signals and their points; see sentinel.SessionRiskConfig.
+const (
+	SignalNewDevice        = "new_device"
+	SignalNewASN           = "new_asn"
+	SignalImpossibleTravel = "impossible_travel"
+	SignalSensitiveChange  = "sensitive_change"
+)
+
+var sessionSignalScores = map[string]int{
+	SignalNewDevice:        25,
+	SignalNewASN:           25,
+	SignalImpossibleTravel: 50,
+	SignalSensitiveChange:  30,
+}
+
+const (
+	// sessionHistory is how far back stored activity seeds a user's known
+	// devices and networks.
+	sessionHistory = 30 * 24 * time.Hour
+
+	// maxKnownDevices bounds the devices and networks kept per user; the
+	// least recently used are dropped.
+	maxKnownDevices = 50
+
+	// minTravelDistance is the distance in km below which travel is never
+	// impossible: IP geolocation is not more precise than that.
+	minTravelDistance = 500.0
+)
+
+// SessionRiskEngine scores authenticated sessions for account takeover. A
+// session is compared with the user's earlier sessions when it starts, and
+// its requests to sensitive routes are watched for a while after. State is
+// kept in memory; a user's known devices and networks are seeded from
+// stored user activity the first time the user is seen.
+type SessionRiskEngine struct {
+	store     storage.Store
+	geoLoc    *GeoLocator
+	config    sentinel.SessionRiskConfig
+	sensitive *sentinel.RouteMatcher
+
+	mu        sync.Mutex
+	users     map[string]*sessionProfile
+	sessions  map[string]*sessionState
+	lastSweep time.Time
+}
+
+// sessionProfile is what is known about a user's legitimate sessions.
+type sessionProfile struct {
+	devices  map[string]time.Time // device fingerprint → last used
+	asns     map[string]time.Time
+	lastLat  float64
+	lastLng  float64
+	lastAt   time.Time // zero when no location is known
+	lastSeen time.Time
+}
+
+type sessionState struct {
+	risk     sentinel.SessionRisk
+	device   string
+	lat, lng float64
+	located  bool
+	lastSeen time.Time
+}
+
+// NewSessionRiskEngine creates a session risk engine. geoLoc may be nil,
+// which disables the network and travel signals.
+func NewSessionRiskEngine(store storage.Store, geoLoc *GeoLocator, config sentinel.SessionRiskConfig) *SessionRiskEngine {
+	if config.Threshold <= 0 {
+		config.Threshold = 50
+	}
+	if config.Action == "" {
+		config.Action = sentinel.SessionActionLog
+	}
+	if config.SensitiveWindow <= 0 {
+		config.SensitiveWindow = 30 * time.Minute
+	}
+	if config.MaxTravelSpeed <= 0 {
+		config.MaxTravelSpeed = 1000
+	}
+	if config.SessionTTL <= 0 {
+		config.SessionTTL = 24 * time.Hour
+	}
+	return &SessionRiskEngine{
+		store:     store,
+		geoLoc:    geoLoc,
+		config:    config,
+		sensitive: sentinel.NewRouteMatcher(config.SensitiveRoutes),
+		users:     make(map[string]*sessionProfile),
+		sessions:  make(map[string]*sessionState),
+	}
+}
+
+// Assess scores one request of a session and returns the session's risk.
+// The returned copy has Flagging set on the request that made the session
+// reach the threshold, at which point the configured action is recorded
+// in StepUpRequired or Revoked.
+func (e *SessionRiskEngine) Assess(ctx context.Context, obs sentinel.SessionObservation) *sentinel.SessionRisk {
+	if obs.Timestamp.IsZero() {
+		obs.Timestamp = time.Now()
+	}
+
+	e.mu.Lock()
+	st := e.sessions[obs.SessionID]
+	_, profiled := e.users[obs.UserID]
+	e.mu.Unlock()
+
+	var geo *sentinel.GeoResult
+	var profile *sessionProfile
+	if st == nil || st.risk.UserID != obs.UserID {
+		// A new session: everything that needs storage or the geolocation
+		// database happens before taking the lock.
+		geo = e.lookup(ctx, obs.IP)
+		if !profiled {
+			profile = e.seedProfile(ctx, obs.UserID, obs.Timestamp)
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sweep(obs.Timestamp)
+	if profile != nil && e.users[obs.UserID] == nil {
+		e.users[obs.UserID] = profile
+	}
+	profile = e.users[obs.UserID]
+	if profile == nil {
+		profile = newSessionProfile()
+		e.users[obs.UserID] = profile
+	}
+	profile.lastSeen = obs.Timestamp
+
+	st = e.sessions[obs.SessionID]
+	if st == nil || st.risk.UserID != obs.UserID {
+		st = e.startSession(obs, geo, profile)
+		e.sessions[obs.SessionID] = st
+	}
+	st.lastSeen = obs.Timestamp
+
+	if obs.Method != "GET" && obs.Method != "HEAD" && e.sensitive.Matches(obs.Path) &&
+		obs.Timestamp.Sub(st.risk.StartedAt) <= e.config.SensitiveWindow {
+		e.addSignal(st, SignalSensitiveChange, fmt.Sprintf("%s %s %s after the session started",
+			obs.Method, obs.Path, obs.Timestamp.Sub(st.risk.StartedAt).Round(time.Second)))
+	}
+
+	risk := st.risk
+	risk.Signals = append([]sentinel.SessionSignal(nil), st.risk.Signals...)
+	if !st.risk.Flagged && st.risk.Score >= e.config.Threshold {
+		st.risk.Flagged = true
+		switch e.config.Action {
+		case sentinel.SessionActionChallenge:
+			st.risk.StepUpRequired = true
+		case sentinel.SessionActionRevoke:
+			st.risk.Revoked = true
+		}
+		risk.Flagged, risk.StepUpRequired, risk.Revoked = true, st.risk.StepUpRequired, st.risk.Revoked
+		risk.Flagging = true
+	}
+	return &risk
+}
+
+// StepUpPassed records that a session solved its step-up challenge. The
+// session continues, and its device and network are learned as the
+// user's.
+func (e *SessionRiskEngine) StepUpPassed(sessionID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st := e.sessions[sessionID]
+	if st == nil || !st.risk.StepUpRequired {
+		return
+	}
+	st.risk.StepUpRequired = false
+	if profile := e.users[st.risk.UserID]; profile != nil {
+		e.learn(profile, st)
+	}
+}
+
+// Sessions returns the risk of every remembered session of a user, or of
+// all users when userID is empty, riskiest first.
+func (e *SessionRiskEngine) Sessions(userID string) []*sentinel.SessionRisk {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]*sentinel.SessionRisk, 0)
+	for _, st := range e.sessions {
+		if userID != "" && st.risk.UserID != userID {
+			continue
+		}
+		risk := st.risk
+		risk.Signals = append([]sentinel.SessionSignal(nil), st.risk.Signals...)
+		out = append(out, &risk)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].StartedAt.After(out[j].StartedAt)
+	})
+	return out
+}
+
+// startSession compares a new session with the user's profile. A user
+// with no known devices gets no signals: there is nothing to compare with.
+func (e *SessionRiskEngine) startSession(obs sentinel.SessionObservation, geo *sentinel.GeoResult, profile *sessionProfile) *sessionState {
+	st := &sessionState{
+		risk: sentinel.SessionRisk{
+			SessionID: obs.SessionID,
+			UserID:    obs.UserID,
+			IP:        obs.IP,
+			UserAgent: obs.UserAgent,
+			StartedAt: obs.Timestamp,
+		},
+		device: deviceFingerprint(obs.UserAgent),
+	}
+	if geo != nil {
+		st.risk.ASN = asnNumber(geo.ASN)
+		st.risk.Country = geo.CountryCode
+		st.lat, st.lng = geo.Lat, geo.Lng
+		st.located = geo.Lat != 0 || geo.Lng != 0
+	}
+
+	if len(profile.devices) > 0 {
+		if _, known := profile.devices[st.device]; !known {
+			e.addSignal(st, SignalNewDevice, "first session from "+truncate(obs.UserAgent, 120))
+		}
+	}
+	if st.risk.ASN != "" && len(profile.asns) > 0 {
+		if _, known := profile.asns[st.risk.ASN]; !known {
+			e.addSignal(st, SignalNewASN, "first session from "+st.risk.ASN)
+		}
+	}
+	if st.located && !profile.lastAt.IsZero() {
+		km := haversineDistance(profile.lastLat, profile.lastLng, st.lat, st.lng)
+		hours := obs.Timestamp.Sub(profile.lastAt).Hours()
+		if km >= minTravelDistance && (hours <= 0 || km/hours > e.config.MaxTravelSpeed) {
+			e.addSignal(st, SignalImpossibleTravel, fmt.Sprintf("%.0f km from the previous session in %s",
+				km, obs.Timestamp.Sub(profile.lastAt).Round(time.Minute)))
+		}
+	}
+
+	if st.risk.Score < e.config.Threshold {
+		e.learn(profile, st)
+	}
+	return st
+}
+
+func (e *SessionRiskEngine) addSignal(st *sessionState, name, detail string) {
+	for _, s := range st.risk.Signals {
+		if s.Name == name {
+			return
+		}
+	}
+	score := sessionSignalScores[name]
+	st.risk.Signals = append(st.risk.Signals, sentinel.SessionSignal{Name: name, Score: score, Detail: detail})
+	if st.risk.Score += score; st.risk.Score > 100 {
+		st.risk.Score = 100
+	}
+}
+
+// learn adds a session's device, network and location to its user's profile.
+func (e *SessionRiskEngine) learn(profile *sessionProfile, st *sessionState) {
+	at := st.risk.StartedAt
+	profile.devices[st.device] = at
+	forgetStale(profile.devices, at, sessionHistory, maxKnownDevices)
+	if st.risk.ASN != "" {
+		profile.asns[st.risk.ASN] = at
+		forgetStale(profile.asns, at, sessionHistory, maxKnownDevices)
+	}
+	if st.located {
+		profile.lastLat, profile.lastLng, profile.lastAt = st.lat, st.lng, at
+	}
+}
+
+// seedProfile builds a user's profile from their stored activity. It runs
+// on a request, so past IPs are only located with a local database: with
+// ip-api.com, up to maxKnownDevices HTTP lookups would hold the request up,
+// and the user's networks are learned from new sessions instead.
+func (e *SessionRiskEngine) seedProfile(ctx context.Context, userID string, now time.Time) *sessionProfile {
+	profile := newSessionProfile()
+	if e.store == nil {
+		return profile
+	}
+	start := now.Add(-sessionHistory)
+	history, _, err := e.store.ListUserActivity(ctx, userID, sentinel.ActivityFilter{
+		StartTime: &start,
+		Page:      1,
+		PageSize:  1000,
+	})
+	if err != nil {
+		log.Printf("[sentinel] session risk: failed to load activity for user %s: %v", userID, err)
+		return profile
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].Timestamp.Before(history[j].Timestamp) })
+	local := e.geoLoc != nil && e.geoLoc.ResolvesASNs()
+	located := make(map[string]bool)
+	for i := len(history) - 1; i >= 0; i-- {
+		a := history[i]
+		if _, ok := profile.devices[deviceFingerprint(a.UserAgent)]; !ok {
+			profile.devices[deviceFingerprint(a.UserAgent)] = a.Timestamp
+		}
+		if !local || located[a.IP] || len(located) >= maxKnownDevices {
+			continue
+		}
+		located[a.IP] = true
+		geo := e.lookup(ctx, a.IP)
+		if geo == nil {
+			continue
+		}
+		if asn := asnNumber(geo.ASN); asn != "" {
+			if _, ok := profile.asns[asn]; !ok {
+				profile.asns[asn] = a.Timestamp
+			}
+		}
+		if profile.lastAt.IsZero() && (geo.Lat != 0 || geo.Lng != 0) {
+			profile.lastLat, profile.lastLng, profile.lastAt = geo.Lat, geo.Lng, a.Timestamp
+		}
+	}
+	forgetStale(profile.devices, now, sessionHistory, maxKnownDevices)
+	return profile
+}
+
+// sweep forgets idle sessions and users, at most once a minute.
+func (e *SessionRiskEngine) sweep(now time.Time) {
+	if now.Sub(e.lastSweep) < time.Minute {
+		return
+	}
+	e.lastSweep = now
+	for id, st := range e.sessions {
+		if now.Sub(st.lastSeen) > e.config.SessionTTL {
+			delete(e.sessions, id)
+		}
+	}
+	for id, p := range e.users {
+		if now.Sub(p.lastSeen) > sessionHistory {
+			delete(e.users, id)
+		}
+	}
+}
+
+func (e *SessionRiskEngine) lookup(ctx context.Context, ip string) *sentinel.GeoResult {
+	if e.geoLoc == nil {
+		return nil
+	}
+	geo, err := e.geoLoc.LookupIP(ctx, ip)
+	if err != nil {
+		return nil
+	}
+	return geo
+}
+
+func newSessionProfile() *sessionProfile {
+	return &sessionProfile{
+		devices: make(map[string]time.Time),
+		asns:    make(map[string]time.Time),
+	}
+}
+
+var uaVersion = regexp.MustCompile(`\d+(?:[._]\d+)*`)
+
+// deviceFingerprint identifies a device by its user agent with version
+// numbers removed, so browser and OS updates do not make a new device.
+func deviceFingerprint(ua string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(uaVersion.ReplaceAllString(ua, ""))))
+	return hex.EncodeToString(sum[:8])
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
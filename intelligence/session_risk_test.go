@@ -0,0 +1,185 @@
+package intelligence_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/intelligence"
+	"github.com/MUKE-coder/sentinel/v2/storage/memory"
+)
+
+const (
+	laptopUA = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 Chrome/120.0.0.0 Safari/537.36"
+	phoneUA  = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 Chrome/120.0.0.0 Mobile Safari/537.36"
+)
+
+func setupSessionRiskTest(t *testing.T, action sentinel.SessionRiskAction) *intelligence.SessionRiskEngine {
+	t.Helper()
+	ctx := context.Background()
+	store := memory.New()
+	store.Migrate(ctx)
+
+	// The user has only ever used their laptop.
+	for i := 0; i < 5; i++ {
+		store.SaveUserActivity(ctx, &sentinel.UserActivity{
+			ID:        "act-" + string(rune('a'+i)),
+			Timestamp: time.Now().Add(-time.Duration(i+1) * 24 * time.Hour),
+			UserID:    "user-1",
+			Path:      "/api/data",
+			Method:    "GET",
+			IP:        "10.0.0.1",
+			UserAgent: laptopUA,
+		})
+	}
+
+	geo := intelligence.NewGeoLocator(sentinel.GeoConfig{Enabled: false})
+	return intelligence.NewSessionRiskEngine(store, geo, sentinel.SessionRiskConfig{
+		Enabled:         true,
+		Threshold:       50,
+		Action:          action,
+		SensitiveRoutes: []string{"/api/account/*"},
+	})
+}
+
+func TestSessionRisk_NewDeviceThenSensitiveChange(t *testing.T) {
+	engine := setupSessionRiskTest(t, sentinel.SessionActionRevoke)
+	ctx := context.Background()
+	now := time.Now()
+
+	obs := sentinel.SessionObservation{
+		SessionID: "s-phone", UserID: "user-1", IP: "10.0.0.2",
+		UserAgent: phoneUA, Method: "GET", Path: "/api/data", Timestamp: now,
+	}
+	risk := engine.Assess(ctx, obs)
+	if risk.Score != 25 || risk.Flagged || len(risk.Signals) != 1 || risk.Signals[0].Name != intelligence.SignalNewDevice {
+		t.Fatalf("new device: got score %d flagged %v signals %+v", risk.Score, risk.Flagged, risk.Signals)
+	}
+
+	obs.Method, obs.Path, obs.Timestamp = "POST", "/api/account/email", now.Add(5*time.Minute)
+	risk = engine.Assess(ctx, obs)
+	if risk.Score != 55 || !risk.Flagging || !risk.Flagged || !risk.Revoked {
+		t.Fatalf("sensitive change: got score %d flagging %v flagged %v revoked %v", risk.Score, risk.Flagging, risk.Flagged, risk.Revoked)
+	}
+
+	// Flagging is reported once; the session stays revoked.
+	obs.Timestamp = now.Add(6 * time.Minute)
+	risk = engine.Assess(ctx, obs)
+	if risk.Flagging || !risk.Revoked {
+		t.Errorf("later request: got flagging %v revoked %v", risk.Flagging, risk.Revoked)
+	}
+
+	sessions := engine.Sessions("user-1")
+	if len(sessions) != 1 || sessions[0].SessionID != "s-phone" || !sessions[0].Flagged {
+		t.Errorf("Sessions: got %+v", sessions)
+	}
+	if got := engine.Sessions("someone-else"); got == nil || len(got) != 0 {
+		t.Errorf("Sessions of an unknown user: got %v, want an empty slice", got)
+	}
+}
+
+func TestSessionRisk_KnownDevice(t *testing.T) {
+	engine := setupSessionRiskTest(t, sentinel.SessionActionRevoke)
+	ctx := context.Background()
+	now := time.Now()
+
+	// A newer browser version on the same laptop is the same device.
+	ua := "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 Chrome/121.0.0.0 Safari/537.36"
+	obs := sentinel.SessionObservation{
+		SessionID: "s-laptop", UserID: "user-1", IP: "10.0.0.1",
+		UserAgent: ua, Method: "POST", Path: "/api/account/password", Timestamp: now,
+	}
+	risk := engine.Assess(ctx, obs)
+	if risk.Score != 30 || risk.Flagged {
+		t.Errorf("known device: got score %d flagged %v signals %+v", risk.Score, risk.Flagged, risk.Signals)
+	}
+
+	// Outside the window, sensitive changes are not scored.
+	engine = setupSessionRiskTest(t, sentinel.SessionActionRevoke)
+	obs.SessionID = "s-old"
+	obs.Method = "GET"
+	engine.Assess(ctx, obs)
+	obs.Method, obs.Timestamp = "POST", now.Add(time.Hour)
+	if risk = engine.Assess(ctx, obs); risk.Score != 0 {
+		t.Errorf("late sensitive change: got score %d, want 0", risk.Score)
+	}
+}
+
+func TestSessionRisk_StepUp(t *testing.T) {
+	engine := setupSessionRiskTest(t, sentinel.SessionActionChallenge)
+	ctx := context.Background()
+	now := time.Now()
+
+	obs := sentinel.SessionObservation{
+		SessionID: "s-phone", UserID: "user-1", IP: "10.0.0.2",
+		UserAgent: phoneUA, Method: "POST", Path: "/api/account/email", Timestamp: now,
+	}
+	risk := engine.Assess(ctx, obs)
+	if !risk.Flagging || !risk.StepUpRequired || risk.Revoked {
+		t.Fatalf("got flagging %v step-up %v revoked %v", risk.Flagging, risk.StepUpRequired, risk.Revoked)
+	}
+
+	engine.StepUpPassed("s-phone")
+	obs.Timestamp = now.Add(time.Minute)
+	if risk = engine.Assess(ctx, obs); risk.StepUpRequired {
+		t.Error("step-up should be cleared once passed")
+	}
+
+	// The phone is now a known device of the user.
+	obs.SessionID, obs.Method, obs.Path = "s-phone-2", "GET", "/api/data"
+	if risk = engine.Assess(ctx, obs); risk.Score != 0 {
+		t.Errorf("learned device: got score %d signals %+v", risk.Score, risk.Signals)
+	}
+}
+
+// countingTransport answers every request as ip-api.com would, counting them.
+type countingTransport struct{ n atomic.Int32 }
+
+func (c *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	c.n.Add(1)
+	body := `{"status":"success","countryCode":"GB","lat":51.5,"lon":-0.1,"as":"AS20712 Andrews & Arnold Ltd"}`
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+}
+
+func TestSessionRisk_SeedWithoutIPAPI(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	store.Migrate(ctx)
+	for i := 0; i < 20; i++ {
+		store.SaveUserActivity(ctx, &sentinel.UserActivity{
+			ID:        fmt.Sprintf("act-%d", i),
+			Timestamp: time.Now().Add(-time.Duration(i+1) * time.Hour),
+			UserID:    "user-1",
+			Path:      "/api/data",
+			Method:    "GET",
+			IP:        fmt.Sprintf("81.2.69.%d", i+1),
+			UserAgent: laptopUA,
+		})
+	}
+
+	transport := &countingTransport{}
+	saved := http.DefaultTransport
+	http.DefaultTransport = transport
+	t.Cleanup(func() { http.DefaultTransport = saved })
+
+	geo := intelligence.NewGeoLocator(sentinel.GeoConfig{Enabled: true, Provider: sentinel.GeoIPAPI})
+	engine := intelligence.NewSessionRiskEngine(store, geo, sentinel.SessionRiskConfig{Enabled: true})
+	risk := engine.Assess(ctx, sentinel.SessionObservation{
+		SessionID: "s-phone", UserID: "user-1", IP: "10.0.0.2",
+		UserAgent: phoneUA, Method: "GET", Path: "/api/data", Timestamp: time.Now(),
+	})
+
+	// Devices are still seeded; past IPs are not sent to ip-api.com.
+	if len(risk.Signals) != 1 || risk.Signals[0].Name != intelligence.SignalNewDevice {
+		t.Errorf("got signals %+v, want only new_device", risk.Signals)
+	}
+	if n := transport.n.Load(); n != 0 {
+		t.Errorf("seeding made %d ip-api lookups, want 0", n)
+	}
+}
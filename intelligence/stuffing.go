@@ -0,0 +1,292 @@
+package intelligence
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/google/uuid"
+)
+
+const (
+	// minDistributedIPs is the number of failing IPs the global failure
+	// rate needs before it is reported; fewer are AuthShield's per-IP
+	// lockout's to handle.
+	minDistributedIPs = 10
+
+	// maxReportedIPs bounds the events one finding emits while it lasts.
+	maxReportedIPs = 50
+
+	// globalStuffingKey names the group of all login attempts.
+	globalStuffingKey = "all"
+)
+
+// stuffingDetector looks for credential stuffing across client IPs: many
+// usernames failing from one network, a high failure rate spread thinly
+// over many IPs, and one password failing against many accounts. It keeps
+// the login attempts of the last window and counts them per group, so each
+// attempt is checked in constant time.
+type stuffingDetector struct {
+	config sentinel.CredentialStuffingConfig
+
+	mu       sync.Mutex
+	attempts []stuffingAttempt
+	groups   map[string]*stuffingGroup
+}
+
+type stuffingAttempt struct {
+	at                     time.Time
+	ip, username           string
+	success                bool
+	network, passwordGroup string
+}
+
+// stuffingGroup counts the attempts of one network, one password, or all
+// of them. created tells successive campaigns of the same group apart.
+type stuffingGroup struct {
+	created   time.Time
+	attempts  int
+	failures  int
+	usernames map[string]int // failed usernames
+	ips       map[string]int // IPs with failures
+	reported  map[string]bool
+}
+
+// stuffingFinding is a threshold crossed by a group, with the IPs involved
+// that have not been reported yet.
+type stuffingFinding struct {
+	signal  string
+	key     string
+	detail  string
+	actorID string
+	ips     []string
+}
+
+func newStuffingDetector(config sentinel.CredentialStuffingConfig) *stuffingDetector {
+	if config.Window <= 0 {
+		config.Window = 10 * time.Minute
+	}
+	if config.NetworkUsernames <= 0 {
+		config.NetworkUsernames = 20
+	}
+	if config.FailureRate <= 0 {
+		config.FailureRate = 0.8
+	}
+	if config.MinAttempts <= 0 {
+		config.MinAttempts = 50
+	}
+	if config.PasswordReuse <= 0 {
+		config.PasswordReuse = 5
+	}
+	return &stuffingDetector{
+		config: config,
+		groups: make(map[string]*stuffingGroup),
+	}
+}
+
+// observe records a login attempt and returns the thresholds it makes a
+// group cross. asn is the client's ASN, or "" if unknown.
+func (d *stuffingDetector) observe(a *sentinel.LoginAttempt, asn string) []stuffingFinding {
+	at := a.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	rec := stuffingAttempt{
+		at:       at,
+		ip:       a.IP,
+		username: a.Username,
+		success:  a.Success,
+		network:  stuffingNetwork(a.IP, asn),
+	}
+	if a.PasswordFingerprint != "" && !a.Success {
+		rec.passwordGroup = "password:" + a.PasswordFingerprint
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.expire(at)
+	d.attempts = append(d.attempts, rec)
+
+	var findings []stuffingFinding
+	if g := d.add(globalStuffingKey, rec); g.attempts >= d.config.MinAttempts && len(g.ips) >= minDistributedIPs {
+		if rate := float64(g.failures) / float64(g.attempts); rate >= d.config.FailureRate {
+			findings = d.report(findings, "distributed_failures", globalStuffingKey, g,
+				fmt.Sprintf("%d of %d logins failed (%.0f%%) from %d IPs in %s",
+					g.failures, g.attempts, rate*100, len(g.ips), d.config.Window))
+		}
+	}
+	if rec.network != "" {
+		if g := d.add(rec.network, rec); len(g.usernames) >= d.config.NetworkUsernames {
+			findings = d.report(findings, "network_usernames", rec.network, g,
+				fmt.Sprintf("%d usernames failed from %s (%d IPs) in %s",
+					len(g.usernames), rec.network, len(g.ips), d.config.Window))
+		}
+	}
+	if rec.passwordGroup != "" {
+		if g := d.add(rec.passwordGroup, rec); len(g.usernames) >= d.config.PasswordReuse {
+			findings = d.report(findings, "password_reuse", rec.passwordGroup, g,
+				fmt.Sprintf("one password failed for %d usernames from %d IPs in %s",
+					len(g.usernames), len(g.ips), d.config.Window))
+		}
+	}
+	return findings
+}
+
+// add counts an attempt in a group, creating it if needed.
+func (d *stuffingDetector) add(key string, rec stuffingAttempt) *stuffingGroup {
+	g := d.groups[key]
+	if g == nil {
+		g = &stuffingGroup{
+			created:   rec.at,
+			usernames: make(map[string]int),
+			ips:       make(map[string]int),
+			reported:  make(map[string]bool),
+		}
+		d.groups[key] = g
+	}
+	g.attempts++
+	if !rec.success {
+		g.failures++
+		g.ips[rec.ip]++
+		if rec.username != "" {
+			g.usernames[rec.username]++
+		}
+	}
+	return g
+}
+
+// report appends a finding for the group's failing IPs not reported yet.
+func (d *stuffingDetector) report(findings []stuffingFinding, signal, key string, g *stuffingGroup, detail string) []stuffingFinding {
+	var ips []string
+	for ip := range g.ips {
+		if len(g.reported) >= maxReportedIPs {
+			break
+		}
+		if !g.reported[ip] {
+			g.reported[ip] = true
+			ips = append(ips, ip)
+		}
+	}
+	if len(ips) == 0 {
+		return findings
+	}
+	sum := sha256.Sum256([]byte(key + "|" + strconv.FormatInt(g.created.UnixNano(), 10)))
+	return append(findings, stuffingFinding{
+		signal:  signal,
+		key:     key,
+		detail:  detail,
+		actorID: "stuffing_" + hex.EncodeToString(sum[:8]),
+		ips:     ips,
+	})
+}
+
+// expire drops the attempts older than the window from their groups.
+// Attempts arrive in about time order, so the oldest are at the front.
+func (d *stuffingDetector) expire(now time.Time) {
+	cutoff := now.Add(-d.config.Window)
+	n := 0
+	for n < len(d.attempts) && d.attempts[n].at.Before(cutoff) {
+		rec := d.attempts[n]
+		d.remove(globalStuffingKey, rec)
+		if rec.network != "" {
+			d.remove(rec.network, rec)
+		}
+		if rec.passwordGroup != "" {
+			d.remove(rec.passwordGroup, rec)
+		}
+		n++
+	}
+	d.attempts = append(d.attempts[:0], d.attempts[n:]...)
+}
+
+func (d *stuffingDetector) remove(key string, rec stuffingAttempt) {
+	g := d.groups[key]
+	if g == nil {
+		return
+	}
+	g.attempts--
+	if !rec.success {
+		g.failures--
+		if g.ips[rec.ip]--; g.ips[rec.ip] <= 0 {
+			delete(g.ips, rec.ip)
+			delete(g.reported, rec.ip)
+		}
+		if rec.username != "" {
+			if g.usernames[rec.username]--; g.usernames[rec.username] <= 0 {
+				delete(g.usernames, rec.username)
+			}
+		}
+	}
+	if g.attempts <= 0 {
+		delete(d.groups, key)
+	}
+}
+
+// threats turns a finding into one ThreatEvent per IP. The events share an
+// actor ID, so a campaign spread over many IPs reads as one actor.
+func (f stuffingFinding) threats(a *sentinel.LoginAttempt) []*sentinel.ThreatEvent {
+	cvss := sentinel.DefaultCVSSForType(string(sentinel.ThreatCredentialStuffing))
+	confidence := 80
+	if f.signal == "distributed_failures" {
+		// A failure spike is also what an outage of the login backend
+		// looks like.
+		confidence = 60
+	}
+	events := make([]*sentinel.ThreatEvent, 0, len(f.ips))
+	for _, ip := range f.ips {
+		te := &sentinel.ThreatEvent{
+			ID:          uuid.New().String(),
+			Timestamp:   a.Timestamp,
+			IP:          ip,
+			ActorID:     f.actorID,
+			Method:      a.Method,
+			Path:        a.Path,
+			ThreatTypes: []string{string(sentinel.ThreatCredentialStuffing)},
+			Severity:    sentinel.SeverityHigh,
+			Confidence:  confidence,
+			Evidence: []sentinel.Evidence{{
+				Pattern:   f.signal,
+				Matched:   f.detail,
+				Location:  "login",
+				Parameter: f.key,
+			}},
+			CVSS:       cvss.Score,
+			CVSSVector: cvss.Vector,
+		}
+		if ip == a.IP {
+			te.UserAgent = a.UserAgent
+		}
+		events = append(events, te)
+	}
+	return events
+}
+
+// stuffingNetwork names the network a client IP belongs to: its ASN when
+// known, else its /24 (IPv6: /48).
+func stuffingNetwork(ip, asn string) string {
+	if asn := asnNumber(asn); asn != "" {
+		return "asn:" + asn
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return "net:" + (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return "net:" + (&net.IPNet{IP: parsed.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
+
+// asnNumber returns the "AS15169" part of "AS15169 Google LLC".
+func asnNumber(asn string) string {
+	if f := strings.Fields(asn); len(f) > 0 {
+		return f[0]
+	}
+	return ""
+}
@@ -0,0 +1,276 @@
+// Package iptrie is a path-compressed binary radix tree of IP prefixes,
+// for longest-prefix-match lookups whose cost depends on the address
+// length, not on how many prefixes are stored.
+//
+// IPv4 and IPv6 prefixes live in separate trees. IPv4-mapped IPv6
+// addresses are looked up as IPv4.
+package iptrie
+
+import (
+	"math/bits"
+	"net/netip"
+)
+
+// Trie maps IP prefixes to values. The zero value is an empty trie. A Trie
+// is not safe for concurrent writes; concurrent Lookups without writers are
+// safe.
+type Trie[V any] struct {
+	v4, v6 *node[V]
+	size   int
+}
+
+type node[V any] struct {
+	prefix netip.Prefix
+	value  V
+	set    bool // false for the glue nodes joining two diverging branches
+	child  [2]*node[V]
+}
+
+// Len returns the number of prefixes stored.
+func (t *Trie[V]) Len() int {
+	return t.size
+}
+
+// Insert stores v under p, replacing any value already stored there. p is
+// masked to its prefix length first. Invalid prefixes are ignored.
+func (t *Trie[V]) Insert(p netip.Prefix, v V) {
+	p, ok := normalize(p)
+	if !ok {
+		return
+	}
+	np := t.root(p.Addr())
+	for {
+		n := *np
+		if n == nil {
+			*np = &node[V]{prefix: p, value: v, set: true}
+			t.size++
+			return
+		}
+		common := commonBits(n.prefix, p)
+		switch {
+		case common == n.prefix.Bits() && common == p.Bits():
+			if !n.set {
+				t.size++
+			}
+			n.value, n.set = v, true
+			return
+		case common == n.prefix.Bits():
+			// p lies inside n: descend.
+			np = &n.child[bitAt(p.Addr(), common)]
+		case common == p.Bits():
+			// n lies inside p: p goes above it.
+			nn := &node[V]{prefix: p, value: v, set: true}
+			nn.child[bitAt(n.prefix.Addr(), common)] = n
+			*np = nn
+			t.size++
+			return
+		default:
+			// The two diverge below their common bits: join them with
+			// a glue node.
+			glue := &node[V]{prefix: netip.PrefixFrom(p.Addr(), common).Masked()}
+			glue.child[bitAt(n.prefix.Addr(), common)] = n
+			glue.child[bitAt(p.Addr(), common)] = &node[V]{prefix: p, value: v, set: true}
+			*np = glue
+			t.size++
+			return
+		}
+	}
+}
+
+// Delete removes p and reports whether it was stored. Prefixes inside p
+// are kept.
+func (t *Trie[V]) Delete(p netip.Prefix) bool {
+	p, ok := normalize(p)
+	if !ok {
+		return false
+	}
+	np := t.root(p.Addr())
+	var parents []**node[V]
+	for {
+		n := *np
+		if n == nil || n.prefix.Bits() > p.Bits() || !n.prefix.Contains(p.Addr()) {
+			return false
+		}
+		if n.prefix.Bits() == p.Bits() {
+			if !n.set {
+				return false
+			}
+			var zero V
+			n.value, n.set = zero, false
+			t.size--
+			t.prune(np)
+			// Removing a leaf can leave its parent a glue node with a
+			// single child.
+			if len(parents) > 0 {
+				t.prune(parents[len(parents)-1])
+			}
+			return true
+		}
+		parents = append(parents, np)
+		np = &n.child[bitAt(p.Addr(), n.prefix.Bits())]
+	}
+}
+
+// prune removes the unset node at *np if it has fewer than two children.
+func (t *Trie[V]) prune(np **node[V]) {
+	n := *np
+	if n == nil || n.set {
+		return
+	}
+	switch {
+	case n.child[0] == nil:
+		*np = n.child[1]
+	case n.child[1] == nil:
+		*np = n.child[0]
+	}
+}
+
+// Lookup returns the value of the longest stored prefix containing addr.
+func (t *Trie[V]) Lookup(addr netip.Addr) (netip.Prefix, V, bool) {
+	var (
+		best  *node[V]
+		found bool
+	)
+	t.walk(addr, func(n *node[V]) bool {
+		best, found = n, true
+		return true
+	})
+	if !found {
+		var zero V
+		return netip.Prefix{}, zero, false
+	}
+	return best.prefix, best.value, true
+}
+
+// Matches calls fn for every stored prefix containing addr, from the
+// shortest to the longest, until fn returns false.
+func (t *Trie[V]) Matches(addr netip.Addr, fn func(netip.Prefix, V) bool) {
+	t.walk(addr, func(n *node[V]) bool {
+		return fn(n.prefix, n.value)
+	})
+}
+
+// Contains reports whether any stored prefix contains addr.
+func (t *Trie[V]) Contains(addr netip.Addr) bool {
+	_, _, ok := t.Lookup(addr)
+	return ok
+}
+
+// Get returns the value stored under exactly p.
+func (t *Trie[V]) Get(p netip.Prefix) (V, bool) {
+	var zero V
+	p, ok := normalize(p)
+	if !ok {
+		return zero, false
+	}
+	n := *t.root(p.Addr())
+	for n != nil && n.prefix.Bits() <= p.Bits() && n.prefix.Contains(p.Addr()) {
+		if n.prefix.Bits() == p.Bits() {
+			if n.set {
+				return n.value, true
+			}
+			break
+		}
+		n = n.child[bitAt(p.Addr(), n.prefix.Bits())]
+	}
+	return zero, false
+}
+
+// Walk calls fn for every stored prefix, IPv4 before IPv6 and in address
+// order within each, until fn returns false.
+func (t *Trie[V]) Walk(fn func(netip.Prefix, V) bool) {
+	if walkAll(t.v4, fn) {
+		walkAll(t.v6, fn)
+	}
+}
+
+func walkAll[V any](n *node[V], fn func(netip.Prefix, V) bool) bool {
+	if n == nil {
+		return true
+	}
+	if n.set && !fn(n.prefix, n.value) {
+		return false
+	}
+	return walkAll(n.child[0], fn) && walkAll(n.child[1], fn)
+}
+
+// walk visits the stored prefixes containing addr, shortest first.
+func (t *Trie[V]) walk(addr netip.Addr, fn func(*node[V]) bool) {
+	if !addr.IsValid() {
+		return
+	}
+	addr = addr.Unmap()
+	n := *t.root(addr)
+	for n != nil && n.prefix.Contains(addr) {
+		if n.set && !fn(n) {
+			return
+		}
+		if n.prefix.Bits() == addr.BitLen() {
+			return
+		}
+		n = n.child[bitAt(addr, n.prefix.Bits())]
+	}
+}
+
+func (t *Trie[V]) root(addr netip.Addr) **node[V] {
+	if addr.Is4() {
+		return &t.v4
+	}
+	return &t.v6
+}
+
+// normalize masks p and turns an IPv4-mapped IPv6 prefix of at least 96
+// bits into the IPv4 prefix it covers.
+func normalize(p netip.Prefix) (netip.Prefix, bool) {
+	if !p.IsValid() {
+		return p, false
+	}
+	addr := p.Addr()
+	if addr.Is4In6() && p.Bits() >= 96 {
+		p = netip.PrefixFrom(addr.Unmap(), p.Bits()-96)
+	}
+	return p.Masked(), true
+}
+
+// commonBits is the number of leading bits a and b share, up to the
+// shorter of their lengths.
+func commonBits(a, b netip.Prefix) int {
+	limit := min(a.Bits(), b.Bits())
+	x, y := a.Addr().As16(), b.Addr().As16()
+	offset := 0
+	if a.Addr().Is4() {
+		offset = 12 // As16 puts IPv4 in the last four bytes
+	}
+	n := 0
+	for i := offset; i < 16 && n < limit; i++ {
+		if d := x[i] ^ y[i]; d != 0 {
+			n += bits.LeadingZeros8(d)
+			break
+		}
+		n += 8
+	}
+	return min(n, limit)
+}
+
+// bitAt returns bit i of addr, counting from the most significant.
+func bitAt(addr netip.Addr, i int) int {
+	b := addr.As16()
+	if addr.Is4() {
+		i += 96
+	}
+	return int(b[i/8]>>(7-i%8)) & 1
+}
+
+// ParsePrefix parses an IP address or CIDR. A bare address becomes a
+// single-host prefix.
+func ParsePrefix(s string) (netip.Prefix, error) {
+	if p, err := netip.ParsePrefix(s); err == nil {
+		return p.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap().WithZone("")
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
@@ -0,0 +1,124 @@
+package iptrie
+
+import (
+	"fmt"
+	"math/rand"
+	"net/netip"
+	"testing"
+)
+
+func mustPrefix(t testing.TB, s string) netip.Prefix {
+	t.Helper()
+	p, err := ParsePrefix(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLongestPrefixMatch(t *testing.T) {
+	var tr Trie[string]
+	for _, s := range []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.3", "192.168.0.0/24", "2001:db8::/32", "2001:db8:1::/48"} {
+		tr.Insert(mustPrefix(t, s), s)
+	}
+	if tr.Len() != 6 {
+		t.Fatalf("Len = %d, want 6", tr.Len())
+	}
+
+	cases := []struct{ ip, want string }{
+		{"10.9.9.9", "10.0.0.0/8"},
+		{"10.1.9.9", "10.1.0.0/16"},
+		{"10.1.2.3", "10.1.2.3"},
+		{"::ffff:10.1.2.3", "10.1.2.3"},
+		{"192.168.0.200", "192.168.0.0/24"},
+		{"192.168.1.1", ""},
+		{"11.0.0.1", ""},
+		{"2001:db8:1::5", "2001:db8:1::/48"},
+		{"2001:db8:2::5", "2001:db8::/32"},
+		{"2001:db9::1", ""},
+	}
+	for _, tc := range cases {
+		_, got, ok := tr.Lookup(netip.MustParseAddr(tc.ip))
+		if !ok {
+			got = ""
+		}
+		if got != tc.want {
+			t.Errorf("Lookup(%s) = %q, want %q", tc.ip, got, tc.want)
+		}
+	}
+
+	var chain []string
+	tr.Matches(netip.MustParseAddr("10.1.2.3"), func(p netip.Prefix, v string) bool {
+		chain = append(chain, v)
+		return true
+	})
+	if fmt.Sprint(chain) != "[10.0.0.0/8 10.1.0.0/16 10.1.2.3]" {
+		t.Errorf("Matches = %v", chain)
+	}
+}
+
+func TestDeleteKeepsNestedPrefixes(t *testing.T) {
+	var tr Trie[int]
+	tr.Insert(mustPrefix(t, "10.0.0.0/8"), 1)
+	tr.Insert(mustPrefix(t, "10.1.0.0/16"), 2)
+	tr.Insert(mustPrefix(t, "10.2.0.0/16"), 3)
+
+	if !tr.Delete(mustPrefix(t, "10.0.0.0/8")) {
+		t.Fatal("Delete of a stored prefix returned false")
+	}
+	if tr.Delete(mustPrefix(t, "10.0.0.0/8")) || tr.Delete(mustPrefix(t, "10.3.0.0/16")) {
+		t.Fatal("Delete of an absent prefix returned true")
+	}
+	if tr.Contains(netip.MustParseAddr("10.9.0.1")) {
+		t.Error("deleted /8 still matches")
+	}
+	if _, v, _ := tr.Lookup(netip.MustParseAddr("10.2.0.1")); v != 3 {
+		t.Errorf("nested /16 lost, got %d", v)
+	}
+	tr.Delete(mustPrefix(t, "10.1.0.0/16"))
+	tr.Delete(mustPrefix(t, "10.2.0.0/16"))
+	if tr.Len() != 0 || tr.v4 != nil {
+		t.Errorf("trie not empty after deleting everything: len %d", tr.Len())
+	}
+}
+
+// TestAgainstLinearScan cross-checks random prefixes and lookups with a
+// brute-force search.
+func TestAgainstLinearScan(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var tr Trie[netip.Prefix]
+	var all []netip.Prefix
+	for i := 0; i < 2000; i++ {
+		b := [4]byte{10, byte(rng.Intn(4)), byte(rng.Intn(256)), byte(rng.Intn(256))}
+		p := netip.PrefixFrom(netip.AddrFrom4(b), 8+rng.Intn(25)).Masked()
+		tr.Insert(p, p)
+		all = append(all, p)
+	}
+	for i := 0; i < 5000; i++ {
+		addr := netip.AddrFrom4([4]byte{10, byte(rng.Intn(4)), byte(rng.Intn(256)), byte(rng.Intn(256))})
+		want := netip.Prefix{}
+		for _, p := range all {
+			if p.Contains(addr) && (!want.IsValid() || p.Bits() > want.Bits()) {
+				want = p
+			}
+		}
+		got, _, _ := tr.Lookup(addr)
+		if got != want {
+			t.Fatalf("Lookup(%s) = %s, want %s", addr, got, want)
+		}
+	}
+}
+
+func BenchmarkLookup(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	var tr Trie[bool]
+	for i := 0; i < 50000; i++ {
+		b := [4]byte{byte(rng.Intn(256)), byte(rng.Intn(256)), byte(rng.Intn(256)), byte(rng.Intn(256))}
+		tr.Insert(netip.PrefixFrom(netip.AddrFrom4(b), 16+rng.Intn(17)), true)
+	}
+	addr := netip.MustParseAddr("203.0.113.7")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tr.Lookup(addr)
+	}
+}
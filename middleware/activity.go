@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/MUKE-coder/sentinel/v2/captcha"
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// UserActivityOptions configures UserActivityMiddleware.
+type UserActivityOptions struct {
+	// Extractor returns the authenticated user of a request, or nil.
+	Extractor func(c *gin.Context) *sentinel.UserContext
+
+	// ExcludeRoutes are route patterns whose requests are neither recorded
+	// nor scored, such as the dashboard's.
+	ExcludeRoutes []string
+
+	// Assessor scores sessions when non-nil, as SessionRisk configures.
+	Assessor    SessionRiskAssessor
+	SessionRisk sentinel.SessionRiskConfig
+
+	// CAPTCHA verifies step-up challenges for SessionActionChallenge, with
+	// the token read from the X-Captcha-Token header or CAPTCHATokenField.
+	CAPTCHA           captcha.Provider
+	CAPTCHATokenField string
+}
+
+// UserActivityMiddleware records a UserActivity for every request of an
+// authenticated user and, with an Assessor, scores the user's session.
+// The activities feed the anomaly detector's baselines and the dashboard's
+// user list.
+//
+// The user is extracted before the handler runs and, failing that, again
+// after it, for host apps whose auth middleware runs after Sentinel's. A
+// session can only be stopped before the handler: a step-up or revocation
+// decided after it applies from the session's next request on.
+func UserActivityMiddleware(opts UserActivityOptions, pipe *pipeline.Pipeline) gin.HandlerFunc {
+	excludeRoutes := NewRouteMatcher(opts.ExcludeRoutes)
+	sessionID := opts.SessionRisk.SessionIDExtractor
+	if sessionID == nil {
+		cookie := opts.SessionRisk.SessionCookie
+		if cookie == "" {
+			cookie = "session"
+		}
+		sessionID = func(c *gin.Context) string {
+			if v := c.GetHeader("Authorization"); v != "" {
+				return v
+			}
+			v, _ := c.Cookie(cookie)
+			return v
+		}
+	}
+	extract := func(c *gin.Context) *sentinel.UserContext {
+		if user := opts.Extractor(c); user != nil && user.ID != "" {
+			return user
+		}
+		return nil
+	}
+
+	return func(c *gin.Context) {
+		if opts.Extractor == nil || excludeRoutes.Matches(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		start := time.Now()
+		clientIP := extractClientIP(c)
+
+		user := extract(c)
+		if user != nil && opts.Assessor != nil {
+			if !assessSession(c, opts, pipe, user, sessionID(c), clientIP, true) {
+				return
+			}
+		}
+
+		c.Next()
+
+		if user == nil {
+			if user = extract(c); user == nil {
+				return
+			}
+			if opts.Assessor != nil {
+				assessSession(c, opts, pipe, user, sessionID(c), clientIP, false)
+			}
+		}
+		if pipe == nil {
+			return
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		pipe.EmitUserActivity(&sentinel.UserActivity{
+			ID:         uuid.New().String(),
+			Timestamp:  start,
+			UserID:     user.ID,
+			UserEmail:  user.Email,
+			Action:     c.Request.Method + " " + route,
+			Path:       c.Request.URL.Path,
+			Method:     c.Request.Method,
+			IP:         clientIP,
+			UserAgent:  c.Request.UserAgent(),
+			StatusCode: c.Writer.Status(),
+			Duration:   time.Since(start).Milliseconds(),
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/intelligence"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/MUKE-coder/sentinel/v2/storage/memory"
+	"github.com/gin-gonic/gin"
+)
+
+func TestUserActivityFeedsBaseline(t *testing.T) {
+	store := memory.New()
+	pipe := pipeline.New(100)
+	pipe.AddHandler(intelligence.NewAnomalyDetector(store, pipe, nil, sentinel.AnomalyConfig{
+		Enabled:        true,
+		Sensitivity:    sentinel.AnomalySensitivityHigh,
+		LearningPeriod: 50 * time.Millisecond,
+		Checks:         []sentinel.AnomalyCheckType{sentinel.CheckUnusualAccess},
+	}))
+	threats := make(chan *sentinel.ThreatEvent, 10)
+	pipe.AddHandler(pipeline.HandlerFunc(func(ctx context.Context, event pipeline.Event) error {
+		if te, ok := event.Payload.(*sentinel.ThreatEvent); ok {
+			threats <- te
+		}
+		return nil
+	}))
+	pipe.Start(1)
+	defer pipe.Stop()
+
+	r := gin.New()
+	r.Use(UserActivityMiddleware(UserActivityOptions{
+		Extractor: func(c *gin.Context) *sentinel.UserContext {
+			if id := c.GetHeader("X-User"); id != "" {
+				return &sentinel.UserContext{ID: id}
+			}
+			return nil
+		},
+		ExcludeRoutes: []string{"/sentinel/**"},
+	}, pipe))
+	r.GET("/*path", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	do := func(user, path string) {
+		req := httptest.NewRequest("GET", path, nil)
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Learned while the baseline is still learning, so never reported.
+	do("alice", "/export")
+	for i := 0; i < 10; i++ {
+		do("alice", "/orders")
+	}
+	// Neither anonymous nor dashboard requests are recorded.
+	do("", "/orders")
+	do("alice", "/sentinel/api/threats")
+	time.Sleep(60 * time.Millisecond)
+	do("alice", "/orders")
+	do("alice", "/admin")
+
+	select {
+	case te := <-threats:
+		if te.ThreatTypes[0] != string(sentinel.ThreatAnomalyDetected) || te.Path != "/admin" {
+			t.Errorf("unexpected threat %+v", te)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no anomaly reported for an unusual route after learning")
+	}
+	select {
+	case te := <-threats:
+		t.Errorf("unexpected second threat %+v", te)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	b, err := store.GetBaseline(context.Background(), "alice")
+	if err != nil || b == nil {
+		t.Fatalf("GetBaseline: %v, %v", b, err)
+	}
+	if b.Samples != 13 {
+		t.Errorf("expected 13 samples, got %d", b.Samples)
+	}
+	for _, route := range []string{"GET /export", "GET /orders", "GET /admin"} {
+		if _, ok := b.Routes[route]; !ok {
+			t.Errorf("route %q not learned: %v", route, b.Routes)
+		}
+	}
+}
@@ -2,6 +2,10 @@ package middleware
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -28,6 +32,11 @@ type AuthShield struct {
 	userFails      map[string]*failTracker
 	ipUsers        map[string]*stuffingTracker // credential stuffing detection
 	captchaProvider captcha.Provider
+
+	// fingerprintKey keys the password fingerprints of LoginAttempt
+	// events. It is random per process, so a fingerprint cannot be
+	// checked against a list of common passwords offline.
+	fingerprintKey []byte
 }
 
 type failTracker struct {
@@ -51,6 +60,12 @@ func NewAuthShield(config sentinel.AuthShieldConfig, store storage.Store, pipe *
 		userFails: make(map[string]*failTracker),
 		ipUsers:   make(map[string]*stuffingTracker),
 	}
+	as.fingerprintKey = make([]byte, 32)
+	if _, err := rand.Read(as.fingerprintKey); err != nil {
+		// Without a secret key a fingerprint is an unsalted hash; leave
+		// fingerprints out rather than emit those.
+		as.fingerprintKey = nil
+	}
 	return as
 }
 
@@ -140,6 +155,17 @@ func (as *AuthShield) Middleware() gin.HandlerFunc {
 			}
 		}
 
+		// The handler consumes the body, so the password is fingerprinted
+		// before it runs.
+		fingerprint := ""
+		if as.pipe != nil {
+			field := as.config.PasswordField
+			if field == "" {
+				field = "password"
+			}
+			fingerprint = as.passwordFingerprint(requestField(c, field))
+		}
+
 		// Use a response writer wrapper to capture status code
 		rw := &authResponseWriter{ResponseWriter: c.Writer, statusCode: 200}
 		c.Writer = rw
@@ -153,13 +179,44 @@ func (as *AuthShield) Middleware() gin.HandlerFunc {
 		if statusCode >= 200 && statusCode < 300 {
 			// Successful login — reset IP failures
 			as.recordSuccess(clientIP, username)
+			as.emitLoginAttempt(c, clientIP, username, true, "")
 		} else if statusCode >= 400 && statusCode < 500 {
 			// Failed login attempt
 			as.recordFailure(clientIP, username)
+			as.emitLoginAttempt(c, clientIP, username, false, fingerprint)
 		}
 	}
 }
 
+// emitLoginAttempt sends the outcome of a login to the pipeline, where the
+// anomaly detector looks for credential stuffing across IPs.
+func (as *AuthShield) emitLoginAttempt(c *gin.Context, ip, username string, success bool, fingerprint string) {
+	if as.pipe == nil {
+		return
+	}
+	as.pipe.EmitLoginAttempt(&sentinel.LoginAttempt{
+		Timestamp:           time.Now(),
+		IP:                  ip,
+		Username:            username,
+		Method:              c.Request.Method,
+		Path:                c.Request.URL.Path,
+		UserAgent:           c.Request.UserAgent(),
+		Success:             success,
+		PasswordFingerprint: fingerprint,
+	})
+}
+
+// passwordFingerprint returns a truncated HMAC of the password under the
+// process key, or "" for an empty password.
+func (as *AuthShield) passwordFingerprint(password string) string {
+	if password == "" || as.fingerprintKey == nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, as.fingerprintKey)
+	mac.Write([]byte(password))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
 // isIPLocked checks if the IP is currently locked out.
 func (as *AuthShield) isIPLocked(ip string) bool {
 	as.mu.Lock()
@@ -259,7 +316,7 @@ func (as *AuthShield) recordFailure(ip, username string) {
 
 		if len(st.usernames) > 10 {
 			// Credential stuffing detected — emit threat outside lock
-			go as.emitThreat(ip, username, "CredentialStuffing",
+			go as.emitThreat(ip, username, string(sentinel.ThreatCredentialStuffing),
 				"Same IP tried >10 different usernames")
 		}
 	}
@@ -390,9 +447,8 @@ func (w *authResponseWriter) WriteHeader(code int) {
 }
 
 // extractCAPTCHAToken pulls the CAPTCHA token from the request. Checks
-// (in order): the X-Captcha-Token header, the named form field, and the
-// named field inside a JSON body. The body is consumed and restored so
-// downstream handlers still see it.
+// (in order): the X-Captcha-Token header and then the named field of the
+// body, as requestField reads it.
 func extractCAPTCHAToken(c *gin.Context, fieldName string) string {
 	if v := c.GetHeader("X-Captcha-Token"); v != "" {
 		return v
@@ -400,6 +456,16 @@ func extractCAPTCHAToken(c *gin.Context, fieldName string) string {
 	if fieldName == "" {
 		fieldName = "captcha_token"
 	}
+	return requestField(c, fieldName)
+}
+
+// requestField returns a string field of the request body: the named form
+// field, or the named field of a JSON body. The body is consumed and
+// restored so downstream handlers still see it.
+func requestField(c *gin.Context, fieldName string) string {
+	if fieldName == "" {
+		return ""
+	}
 	if v := c.PostForm(fieldName); v != "" {
 		return v
 	}
@@ -410,8 +476,11 @@ func extractCAPTCHAToken(c *gin.Context, fieldName string) string {
 	if err != nil {
 		return ""
 	}
-	c.Request.Body = io.NopCloser(bytes.NewReader(body))
-	if len(body) == 0 {
+	// Restore the body, preserving any bytes past the cap; those are not
+	// parsed, as a login body that large is not worth decoding.
+	remaining, _ := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(append(body, remaining...)))
+	if len(body) == 0 || len(remaining) > 0 {
 		return ""
 	}
 	var payload map[string]any
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -244,3 +245,61 @@ func TestAuthShield_SurvivesPathRewrite(t *testing.T) {
 		t.Errorf("expected 429 lockout despite path rewrite, got %d", w.Code)
 	}
 }
+
+func TestAuthShield_EmitsLoginAttempts(t *testing.T) {
+	pipe := pipeline.New(100)
+	attempts := make(chan *sentinel.LoginAttempt, 10)
+	pipe.AddHandler(pipeline.HandlerFunc(func(ctx context.Context, event pipeline.Event) error {
+		if a, ok := event.Payload.(*sentinel.LoginAttempt); ok {
+			attempts <- a
+		}
+		return nil
+	}))
+	pipe.Start(1)
+	defer pipe.Stop()
+
+	config := sentinel.AuthShieldConfig{
+		Enabled:           true,
+		LoginRoute:        "/api/login",
+		MaxFailedAttempts: 10,
+		LockoutDuration:   15 * time.Minute,
+	}
+	r, _ := setupAuthShieldRouter(config, pipe)
+
+	next := func() *sentinel.LoginAttempt {
+		t.Helper()
+		select {
+		case a := <-attempts:
+			return a
+		case <-time.After(2 * time.Second):
+			t.Fatal("no login attempt emitted")
+			return nil
+		}
+	}
+	for _, req := range []*http.Request{
+		loginRequest("alice", "hunter2"),
+		loginRequest("bob", "hunter2"),
+		loginRequest("carol", "letmein"),
+		loginRequest("admin", "secret"),
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	alice, bob, carol, admin := next(), next(), next(), next()
+	if alice.Success || alice.Username != "alice" || alice.IP == "" {
+		t.Errorf("unexpected attempt %+v", alice)
+	}
+	if alice.PasswordFingerprint == "" || alice.PasswordFingerprint != bob.PasswordFingerprint {
+		t.Errorf("same password should share a fingerprint: %q vs %q", alice.PasswordFingerprint, bob.PasswordFingerprint)
+	}
+	if carol.PasswordFingerprint == alice.PasswordFingerprint {
+		t.Error("different passwords should not share a fingerprint")
+	}
+	if strings.Contains(alice.PasswordFingerprint, "hunter2") {
+		t.Error("fingerprint must not contain the password")
+	}
+	// The handler still read the body, so the correct password logged in.
+	if !admin.Success || admin.PasswordFingerprint != "" {
+		t.Errorf("successful login should carry no fingerprint: %+v", admin)
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -15,21 +16,23 @@ import (
 func BenchmarkRateLimiter_Check(b *testing.B) {
 	rl := NewRateLimiter()
 	defer rl.Stop()
+	ctx := context.Background()
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		rl.check("bench:192.168.1.1", 1000, time.Minute)
+		rl.take(ctx, "bench:192.168.1.1", sentinel.SlidingWindow, sentinel.Limit{Requests: 1000, Window: time.Minute})
 	}
 }
 
 func BenchmarkRateLimiter_CheckParallel(b *testing.B) {
 	rl := NewRateLimiter()
 	defer rl.Stop()
+	ctx := context.Background()
 
 	b.ResetTimer()
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
-			rl.check("bench:parallel", 100000, time.Minute)
+			rl.take(ctx, "bench:parallel", sentinel.SlidingWindow, sentinel.Limit{Requests: 100000, Window: time.Minute})
 		}
 	})
 }
@@ -37,6 +40,7 @@ func BenchmarkRateLimiter_CheckParallel(b *testing.B) {
 func BenchmarkRateLimiter_MultipleKeys(b *testing.B) {
 	rl := NewRateLimiter()
 	defer rl.Stop()
+	ctx := context.Background()
 
 	keys := make([]string, 1000)
 	for i := range keys {
@@ -45,7 +49,7 @@ func BenchmarkRateLimiter_MultipleKeys(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		rl.check(keys[i%len(keys)], 1000, time.Minute)
+		rl.take(ctx, keys[i%len(keys)], sentinel.SlidingWindow, sentinel.Limit{Requests: 1000, Window: time.Minute})
 	}
 }
 
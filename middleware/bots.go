@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MUKE-coder/sentinel/v2/bots"
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// BotMiddleware classifies the client of each request with detector and
+// attaches the result to the request context (see bots.FromContext), for
+// WAFConfig.BotPolicy, RateLimitConfig.ByBot and threat events. Register
+// it ahead of the WAF and rate limiter.
+func BotMiddleware(detector *bots.Detector, excludeRoutes []string) gin.HandlerFunc {
+	exclude := NewRouteMatcher(excludeRoutes)
+	return func(c *gin.Context) {
+		if exclude.Matches(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		res := detector.Classify(c.Request.Context(), c.Request, extractClientIP(c))
+		c.Request = c.Request.WithContext(bots.NewContext(c.Request.Context(), res))
+		c.Next()
+	}
+}
+
+// RequestBot returns the bot result BotMiddleware attached to c's request,
+// or nil.
+func RequestBot(c *gin.Context) *sentinel.BotResult {
+	return bots.FromContext(c.Request.Context())
+}
+
+// emitBotEvent records a request the WAF's bot policy blocked or
+// challenged.
+func emitBotEvent(c *gin.Context, pipe *pipeline.Pipeline, clientIP string, bot *sentinel.BotResult, action sentinel.BotAction, blocked bool, status int, challenge *sentinel.ChallengeStats) {
+	if pipe == nil {
+		return
+	}
+	severity := sentinel.SeverityLow
+	if bot.Verdict == sentinel.BotBad {
+		severity = sentinel.SeverityMedium
+	}
+	matched := string(bot.Verdict)
+	if bot.Crawler != "" {
+		matched += " " + bot.Crawler
+	}
+	evidence := []sentinel.Evidence{{Pattern: "BotPolicy_" + string(action), Matched: matched, Location: "bot"}}
+	for _, s := range bot.Signals {
+		evidence = append(evidence, sentinel.Evidence{Pattern: s, Matched: c.Request.UserAgent(), Location: "bot"})
+	}
+	cvss := sentinel.DefaultCVSSForType(string(sentinel.ThreatBadBot))
+	te := &sentinel.ThreatEvent{
+		ID:          uuid.New().String(),
+		Timestamp:   time.Now(),
+		IP:          clientIP,
+		ActorID:     ActorIDFromIP(clientIP),
+		Method:      c.Request.Method,
+		Path:        c.Request.URL.Path,
+		UserAgent:   c.Request.UserAgent(),
+		Referer:     c.Request.Referer(),
+		ThreatTypes: []string{string(sentinel.ThreatBadBot)},
+		Severity:    severity,
+		Confidence:  bot.Score,
+		Evidence:    evidence,
+		Blocked:     blocked,
+		StatusCode:  status,
+		Challenge:   challenge,
+		CVSS:        cvss.Score,
+		CVSSVector:  cvss.Vector,
+	}
+	applyClientSignals(c, te)
+	pipe.EmitThreat(te)
+}
+
+// applyBotPolicy enforces WAFConfig.BotPolicy on the request. It reports
+// whether the WAF should go on to inspect it; when it returns false the
+// request has been answered, or passed on uninspected.
+func applyBotPolicy(c *gin.Context, policy map[sentinel.BotVerdict]sentinel.BotAction, mode sentinel.WAFMode, challenger *Challenger, pipe *pipeline.Pipeline, clientIP string) bool {
+	bot := RequestBot(c)
+	if bot == nil {
+		return true
+	}
+	action := policy[bot.Verdict]
+	switch action {
+	case sentinel.BotActionAllow:
+		c.Next()
+		return false
+
+	case sentinel.BotActionBlock, sentinel.BotActionChallenge:
+		if mode == sentinel.ModeLog {
+			// Monitoring only: record what the policy would have done.
+			emitBotEvent(c, pipe, clientIP, bot, action, false, 0, nil)
+			return true
+		}
+		if action == sentinel.BotActionBlock {
+			emitBotEvent(c, pipe, clientIP, bot, action, true, http.StatusForbidden, nil)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "Access denied",
+				"code":  "BOT_BLOCKED",
+			})
+			return false
+		}
+		return challenger.gate(c, clientIP, func(stats *sentinel.ChallengeStats) {
+			emitBotEvent(c, pipe, clientIP, bot, action, true, http.StatusTooManyRequests, stats)
+		})
+	}
+	return true
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MUKE-coder/sentinel/v2/bots"
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/gin-gonic/gin"
+)
+
+// googleOnlyResolver verifies 66.249.66.1 as Googlebot and knows no other IP.
+type googleOnlyResolver struct{}
+
+func (googleOnlyResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	if addr == "66.249.66.1" {
+		return []string{"crawl-66-249-66-1.googlebot.com."}, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: addr, IsNotFound: true}
+}
+
+func (googleOnlyResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if host == "crawl-66-249-66-1.googlebot.com" {
+		return []string{"66.249.66.1"}, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+const testGooglebotUA = "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+
+func botRequest(ip, ua, target string) *http.Request {
+	req := httptest.NewRequest("GET", target, nil)
+	req.RemoteAddr = ip + ":40000"
+	req.Header.Set("User-Agent", ua)
+	req.Header.Set("Accept", "*/*")
+	return req
+}
+
+func TestWAFBotPolicy(t *testing.T) {
+	pipe := pipeline.New(100)
+	threats := make(chan *sentinel.ThreatEvent, 10)
+	pipe.AddHandler(pipeline.HandlerFunc(func(ctx context.Context, event pipeline.Event) error {
+		if te, ok := event.Payload.(*sentinel.ThreatEvent); ok {
+			threats <- te
+		}
+		return nil
+	}))
+	pipe.Start(1)
+	defer pipe.Stop()
+
+	r := gin.New()
+	r.Use(BotMiddleware(bots.New(sentinel.BotConfig{Resolver: googleOnlyResolver{}}), nil))
+	r.Use(WAFMiddleware(sentinel.WAFConfig{
+		Enabled: true,
+		Mode:    sentinel.ModeBlock,
+		BotPolicy: map[sentinel.BotVerdict]sentinel.BotAction{
+			sentinel.BotVerified:   sentinel.BotActionAllow,
+			sentinel.BotUnverified: sentinel.BotActionChallenge,
+			sentinel.BotBad:        sentinel.BotActionBlock,
+		},
+	}, nil, pipe, nil))
+	r.GET("/search", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// A verified crawler is not inspected, so an odd URL does not trip the WAF.
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, botRequest("66.249.66.1", testGooglebotUA, "/search?q=1'+OR+'1'='1"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("verified crawler: expected 200, got %d", w.Code)
+	}
+
+	// The same User-Agent from another network is a spoofer.
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, botRequest("203.0.113.9", testGooglebotUA, "/search"))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("spoofed crawler: expected 403, got %d", w.Code)
+	}
+	select {
+	case te := <-threats:
+		if te.ThreatTypes[0] != string(sentinel.ThreatBadBot) || te.BotVerdict != sentinel.BotBad || !te.Blocked {
+			t.Errorf("unexpected threat %+v", te)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no threat emitted for the spoofed crawler")
+	}
+
+	// A script is challenged; without a challenger that is a 429.
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, botRequest("198.51.100.4", "python-requests/2.31", "/search"))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("unverified bot: expected 429, got %d", w.Code)
+	}
+}
+
+func TestRateLimitByBotVerdict(t *testing.T) {
+	limiter := NewRateLimiter()
+	defer limiter.Stop()
+
+	r := gin.New()
+	r.Use(BotMiddleware(bots.New(sentinel.BotConfig{Resolver: googleOnlyResolver{}}), nil))
+	r.Use(RateLimitMiddleware(sentinel.RateLimitConfig{
+		Enabled:    true,
+		ByIP:       &sentinel.Limit{Requests: 2, Window: time.Minute},
+		ByBot:      map[sentinel.BotVerdict]sentinel.Limit{sentinel.BotUnverified: {Requests: 1, Window: time.Minute}},
+		ExemptBots: []sentinel.BotVerdict{sentinel.BotVerified},
+	}, limiter, nil))
+	r.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// Verified crawlers skip every limit.
+	for i := 0; i < 5; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, botRequest("66.249.66.1", testGooglebotUA, "/"))
+		if w.Code != http.StatusOK {
+			t.Fatalf("verified crawler request %d: expected 200, got %d", i+1, w.Code)
+		}
+	}
+
+	// Unverified bots get the tighter budget.
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, botRequest("198.51.100.4", "curl/8.5.0", "/"))
+		if w.Code != want {
+			t.Fatalf("unverified request %d: expected %d, got %d", i+1, want, w.Code)
+		}
+	}
+}
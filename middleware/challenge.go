@@ -0,0 +1,378 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MUKE-coder/sentinel/v2/captcha"
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// challengeMaxFormBytes bounds the challenge form body. The largest
+	// legitimate field is a commercial provider's response token (~2 KB).
+	challengeMaxFormBytes = 16 * 1024
+
+	// challengeLockoutAfter consecutive wrong answers stop an IP's
+	// submissions from being verified for challengeLockout. The
+	// self-hosted challenge has under twenty possible answers and its
+	// stateless token can be resubmitted until it expires, so without
+	// this a bot simply enumerates them.
+	challengeLockoutAfter = 5
+	challengeLockout      = 10 * time.Minute
+
+	// Per-IP tallies are forgotten after a day of inactivity, and capped
+	// so a flood of distinct addresses cannot grow the map without bound.
+	challengeTallyTTL = 24 * time.Hour
+	challengeMaxIPs   = 100_000
+)
+
+// Challenger runs WAF challenge mode: it serves the interstitial CAPTCHA
+// page, verifies submissions, and issues and checks the clearance cookie.
+//
+// The clearance cookie is "<expiry>.<mac>", where mac is an HMAC-SHA256 over
+// the client IP and expiry under a key derived from the configured secret.
+// It is stateless — any replica sharing the secret accepts it — and useless
+// from any other IP.
+type Challenger struct {
+	provider captcha.Provider
+	widget   captcha.Widget // nil when the provider cannot render a widget
+	key      []byte
+	config   sentinel.ChallengeConfig
+
+	mu        sync.Mutex
+	tallies   map[string]*challengeTally
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+type challengeTally struct {
+	issued, solved, failed int64
+	consecutiveFails       int
+	lockedUntil            time.Time
+	lastSeen               time.Time
+}
+
+// NewChallenger creates a Challenger. secret signs clearance cookies —
+// typically Config.Dashboard.SecretKey. Zero fields in config take the
+// ApplyDefaults values.
+//
+// A provider that cannot render its widget (a commercial provider without a
+// site key, or a custom Provider that does not implement captcha.Widget)
+// still yields a working Challenger, but every challenge is answered with
+// the 429 JSON response since there is nothing for a browser to solve.
+func NewChallenger(provider captcha.Provider, secret string, config sentinel.ChallengeConfig) *Challenger {
+	if config.Path == "" {
+		config.Path = "/__sentinel/challenge"
+	}
+	if config.ClearanceTTL <= 0 {
+		config.ClearanceTTL = 30 * time.Minute
+	}
+	if config.CookieName == "" {
+		config.CookieName = "sentinel_clearance"
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("sentinel waf clearance"))
+
+	ch := &Challenger{
+		provider: provider,
+		key:      mac.Sum(nil),
+		config:   config,
+		tallies:  make(map[string]*challengeTally),
+		now:      time.Now,
+	}
+	if w, ok := provider.(captcha.Widget); ok {
+		if _, err := w.WidgetHTML(); err != nil {
+			log.Printf("[sentinel] WAF challenge: %s provider cannot render its widget (%v) — challenges fall back to 429 JSON", provider.Name(), err)
+		} else {
+			ch.widget = w
+		}
+	}
+	return ch
+}
+
+// Path returns the path the challenge form posts to.
+func (ch *Challenger) Path() string { return ch.config.Path }
+
+// CanRender reports whether challenges get the interstitial page rather than
+// the 429 JSON fallback.
+func (ch *Challenger) CanRender() bool { return ch.widget != nil }
+
+// --- clearance cookie ---
+
+func (ch *Challenger) clearanceMAC(ip string, expires int64) string {
+	mac := hmac.New(sha256.New, ch.key)
+	mac.Write([]byte(ip))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strconv.FormatInt(expires, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// cleared reports whether the request carries a valid, unexpired clearance
+// cookie issued to clientIP.
+func (ch *Challenger) cleared(c *gin.Context, clientIP string) bool {
+	value, err := c.Cookie(ch.config.CookieName)
+	if err != nil || value == "" {
+		return false
+	}
+	expStr, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return false
+	}
+	expires, err := strconv.ParseInt(expStr, 10, 64)
+	if err != nil || ch.now().Unix() >= expires {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(ch.clearanceMAC(clientIP, expires)))
+}
+
+func (ch *Challenger) setClearance(c *gin.Context, clientIP string) {
+	expires := ch.now().Add(ch.config.ClearanceTTL).Unix()
+	secure := c.Request.TLS != nil || strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https")
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     ch.config.CookieName,
+		Value:    strconv.FormatInt(expires, 10) + "." + ch.clearanceMAC(clientIP, expires),
+		Path:     "/",
+		MaxAge:   int(ch.config.ClearanceTTL / time.Second),
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// --- serving and verifying ---
+
+// challenge answers a request that tripped the WAF: the interstitial page
+// for browsers, 429 JSON for everything else.
+func (ch *Challenger) challenge(c *gin.Context) {
+	if ch.widget == nil || !strings.Contains(c.GetHeader("Accept"), "text/html") {
+		writeChallengeJSON(c)
+		return
+	}
+	redirect := "/"
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		redirect = c.Request.URL.RequestURI()
+	}
+	ch.renderPage(c, http.StatusTooManyRequests, redirect, "")
+}
+
+// gate challenges a request on behalf of a policy unless it carries a
+// clearance cookie, and reports whether it did not. ch may be nil; see issue.
+func (ch *Challenger) gate(c *gin.Context, clientIP string, emit func(*sentinel.ChallengeStats)) bool {
+	if ch != nil && ch.cleared(c, clientIP) {
+		return true
+	}
+	ch.issue(c, clientIP, emit)
+	return false
+}
+
+// issue records a challenge for clientIP, hands its stats to emit and
+// answers the request with it. With a nil ch, emit gets nil and the
+// client the 429 JSON fallback.
+func (ch *Challenger) issue(c *gin.Context, clientIP string, emit func(*sentinel.ChallengeStats)) {
+	if ch == nil {
+		emit(nil)
+		writeChallengeJSON(c)
+		return
+	}
+	emit(ch.record(clientIP, challengeIssued))
+	ch.challenge(c)
+}
+
+func writeChallengeJSON(c *gin.Context) {
+	c.Header("Retry-After", "30")
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error": "Request challenged by WAF",
+		"code":  "WAF_CHALLENGE",
+	})
+}
+
+func (ch *Challenger) renderPage(c *gin.Context, status int, redirect, errMsg string) {
+	widget, err := ch.widget.WidgetHTML()
+	if err != nil {
+		writeChallengeJSON(c)
+		return
+	}
+	var b strings.Builder
+	challengePage.Execute(&b, struct {
+		Action, Redirect, Error string
+		Widget                  template.HTML
+	}{ch.config.Path, redirect, errMsg, widget})
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Robots-Tag", "noindex")
+	c.Data(status, "text/html; charset=utf-8", []byte(b.String()))
+	c.Abort()
+}
+
+// handleSubmit verifies a posted challenge form. Success sets the clearance
+// cookie and redirects back to the page the client was challenged on;
+// failure re-renders the page with a fresh challenge.
+func (ch *Challenger) handleSubmit(c *gin.Context, clientIP string) {
+	if ch.widget == nil {
+		writeChallengeJSON(c)
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, challengeMaxFormBytes)
+	if err := c.Request.ParseForm(); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid challenge form", "code": "BAD_REQUEST"})
+		return
+	}
+	redirect := safeRedirect(c.Request.PostForm.Get("redirect"))
+
+	if ch.lockedOut(clientIP) {
+		ch.record(clientIP, challengeFailed)
+		ch.renderPage(c, http.StatusForbidden, redirect, "Too many incorrect answers. Try again later.")
+		return
+	}
+
+	token := ch.widget.TokenFromForm(c.Request.PostForm)
+	if err := ch.provider.Verify(c.Request.Context(), token, clientIP); err != nil {
+		ch.record(clientIP, challengeFailed)
+		ch.renderPage(c, http.StatusForbidden, redirect, "That didn't work. Please try again.")
+		return
+	}
+
+	ch.record(clientIP, challengeSolved)
+	ch.setClearance(c, clientIP)
+	c.Redirect(http.StatusSeeOther, redirect)
+	c.Abort()
+}
+
+// safeRedirect keeps the post-challenge redirect on this site: only
+// absolute paths are accepted, never "//host" or "/\host", which browsers
+// treat as protocol-relative URLs.
+func safeRedirect(target string) string {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+		return "/"
+	}
+	return target
+}
+
+// --- tallies ---
+
+type challengeEvent int
+
+const (
+	challengeIssued challengeEvent = iota
+	challengeCleared
+	challengeSolved
+	challengeFailed
+)
+
+// record counts one challenge event for ip and returns the IP's tallies.
+func (ch *Challenger) record(ip string, ev challengeEvent) *sentinel.ChallengeStats {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	now := ch.now()
+	ch.pruneLocked(now)
+
+	t, ok := ch.tallies[ip]
+	if !ok {
+		if len(ch.tallies) >= challengeMaxIPs {
+			for k := range ch.tallies {
+				delete(ch.tallies, k)
+				break
+			}
+		}
+		t = &challengeTally{}
+		ch.tallies[ip] = t
+	}
+	t.lastSeen = now
+
+	outcome := sentinel.ChallengeIssued
+	switch ev {
+	case challengeIssued:
+		t.issued++
+	case challengeCleared:
+		outcome = sentinel.ChallengeCleared
+	case challengeSolved:
+		t.solved++
+		t.consecutiveFails = 0
+	case challengeFailed:
+		t.failed++
+		t.consecutiveFails++
+		if t.consecutiveFails >= challengeLockoutAfter {
+			t.lockedUntil = now.Add(challengeLockout)
+			t.consecutiveFails = 0
+		}
+	}
+
+	stats := &sentinel.ChallengeStats{
+		Outcome: outcome,
+		Issued:  t.issued,
+		Solved:  t.solved,
+		Failed:  t.failed,
+	}
+	if t.issued > 0 {
+		stats.SolveRate = float64(t.solved) / float64(t.issued)
+	}
+	if attempts := t.solved + t.failed; attempts > 0 {
+		stats.FailRate = float64(t.failed) / float64(attempts)
+	}
+	return stats
+}
+
+func (ch *Challenger) lockedOut(ip string) bool {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	t, ok := ch.tallies[ip]
+	return ok && ch.now().Before(t.lockedUntil)
+}
+
+// pruneLocked drops idle tallies, at most once a minute. ch.mu must be held.
+func (ch *Challenger) pruneLocked(now time.Time) {
+	if now.Sub(ch.lastPrune) < time.Minute {
+		return
+	}
+	ch.lastPrune = now
+	for ip, t := range ch.tallies {
+		if now.Sub(t.lastSeen) > challengeTallyTTL && !now.Before(t.lockedUntil) {
+			delete(ch.tallies, ip)
+		}
+	}
+}
+
+var challengePage = template.Must(template.New("challenge").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Security check</title>
+<style>
+body{font-family:system-ui,-apple-system,sans-serif;background:#f5f5f5;color:#222;display:flex;min-height:100vh;margin:0;align-items:center;justify-content:center}
+main{background:#fff;border-radius:8px;box-shadow:0 1px 4px rgba(0,0,0,.12);padding:2rem;max-width:26rem;width:100%}
+h1{font-size:1.25rem;margin:0 0 .75rem}
+p{line-height:1.5;margin:0 0 1rem}
+.error{color:#b00020}
+label{display:block;font-weight:600;margin-bottom:.5rem}
+input[name=captcha_answer]{font-size:1rem;padding:.5rem;width:6rem;margin-bottom:1rem}
+button{display:block;margin-top:1rem;font-size:1rem;padding:.5rem 1.25rem;border:0;border-radius:4px;background:#222;color:#fff;cursor:pointer}
+</style>
+</head>
+<body>
+<main>
+<h1>Checking your request</h1>
+<p>This request looked unusual. Complete the check below to continue.</p>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<form method="post" action="{{.Action}}">
+<input type="hidden" name="redirect" value="{{.Redirect}}">
+{{.Widget}}
+<button type="submit">Continue</button>
+</form>
+</main>
+</body>
+</html>
+`))
@@ -0,0 +1,241 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MUKE-coder/sentinel/v2/captcha"
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/gin-gonic/gin"
+)
+
+type challengeHarness struct {
+	router     *gin.Engine
+	challenger *Challenger
+	pipe       *pipeline.Pipeline
+
+	mu     sync.Mutex
+	events []*sentinel.ThreatEvent
+}
+
+func newChallengeHarness(t *testing.T) *challengeHarness {
+	t.Helper()
+	h := &challengeHarness{pipe: pipeline.New(100)}
+	h.pipe.AddHandler(pipeline.HandlerFunc(func(_ context.Context, ev pipeline.Event) error {
+		if te, ok := ev.Payload.(*sentinel.ThreatEvent); ok {
+			h.mu.Lock()
+			h.events = append(h.events, te)
+			h.mu.Unlock()
+		}
+		return nil
+	}))
+	h.pipe.Start(1)
+	t.Cleanup(h.pipe.Stop)
+
+	h.challenger = NewChallenger(captcha.NewSelfHostedProvider("test-secret"), "jwt-secret", sentinel.ChallengeConfig{})
+	h.router = gin.New()
+	h.router.Use(WAFMiddlewareWithOptions(sentinel.WAFConfig{Enabled: true, Mode: sentinel.ModeChallenge},
+		nil, h.pipe, nil, WAFOptions{Challenger: h.challenger}))
+	h.router.GET("/api/products", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"ok": true}) })
+	return h
+}
+
+func (h *challengeHarness) do(method, target, ip string, header http.Header, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.RemoteAddr = ip + ":40000"
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	w := httptest.NewRecorder()
+	h.router.ServeHTTP(w, req)
+	return w
+}
+
+// event waits for the pipeline to deliver the nth threat event.
+func (h *challengeHarness) event(t *testing.T, n int) *sentinel.ThreatEvent {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		h.mu.Lock()
+		if len(h.events) >= n {
+			ev := h.events[n-1]
+			h.mu.Unlock()
+			return ev
+		}
+		h.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("threat event %d never arrived", n)
+	return nil
+}
+
+var (
+	challengeFieldRe    = regexp.MustCompile(`name="captcha_challenge" value="([^"]+)"`)
+	challengeQuestionRe = regexp.MustCompile(`(\d+) \+ (\d+) = \?`)
+)
+
+// solveFrom reads the self-hosted challenge off the page and builds the form
+// a human would submit after doing the arithmetic.
+func solveFrom(t *testing.T, page string, correct bool) url.Values {
+	t.Helper()
+	id := challengeFieldRe.FindStringSubmatch(page)
+	q := challengeQuestionRe.FindStringSubmatch(page)
+	if id == nil || q == nil {
+		t.Fatalf("challenge page has no self-hosted challenge:\n%s", page)
+	}
+	a, _ := strconv.Atoi(q[1])
+	b, _ := strconv.Atoi(q[2])
+	answer := strconv.Itoa(a + b)
+	if !correct {
+		answer = "999"
+	}
+	return url.Values{"captcha_challenge": {id[1]}, "captcha_answer": {answer}, "redirect": {sqliPath}}
+}
+
+var browserAccept = http.Header{"Accept": {"text/html,application/xhtml+xml"}}
+
+const sqliPath = "/api/products?id=1'+OR+1=1--"
+
+func TestChallengeServesInterstitialToBrowsers(t *testing.T) {
+	h := newChallengeHarness(t)
+
+	w := h.do("GET", sqliPath, "203.0.113.5", browserAccept, "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Fatalf("expected an HTML interstitial, got %q", ct)
+	}
+	if !strings.Contains(w.Body.String(), `action="/__sentinel/challenge"`) || !challengeFieldRe.MatchString(w.Body.String()) {
+		t.Errorf("page lacks the challenge form:\n%s", w.Body.String())
+	}
+
+	ev := h.event(t, 1)
+	if !ev.Blocked || ev.Challenge == nil || ev.Challenge.Outcome != sentinel.ChallengeIssued || ev.Challenge.Issued != 1 {
+		t.Errorf("unexpected event challenge fields: blocked=%v %+v", ev.Blocked, ev.Challenge)
+	}
+
+	// API clients keep the JSON contract.
+	w = h.do("GET", sqliPath, "203.0.113.5", http.Header{"Accept": {"application/json"}}, "")
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "WAF_CHALLENGE") {
+		t.Errorf("expected 429 WAF_CHALLENGE JSON, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestChallengeSolveIssuesIPBoundClearance(t *testing.T) {
+	h := newChallengeHarness(t)
+	const ip = "203.0.113.5"
+
+	page := h.do("GET", sqliPath, ip, browserAccept, "").Body.String()
+	w := h.do("POST", "/__sentinel/challenge", ip, nil, solveFrom(t, page, true).Encode())
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("expected 303 after solving, got %d: %s", w.Code, w.Body.String())
+	}
+	if loc := w.Header().Get("Location"); loc != "/api/products?id=1'+OR+1=1--" {
+		t.Errorf("unexpected redirect %q", loc)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "sentinel_clearance" || !cookies[0].HttpOnly {
+		t.Fatalf("expected one HttpOnly clearance cookie, got %+v", cookies)
+	}
+	withCookie := http.Header{"Accept": browserAccept["Accept"], "Cookie": {cookies[0].Name + "=" + cookies[0].Value}}
+
+	// The cleared client reaches the handler; the detection is still logged.
+	w = h.do("GET", sqliPath, ip, withCookie, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("cleared request: expected 200, got %d", w.Code)
+	}
+	ev := h.event(t, 2)
+	if ev.Blocked || ev.Challenge == nil || ev.Challenge.Outcome != sentinel.ChallengeCleared {
+		t.Errorf("unexpected cleared event: blocked=%v %+v", ev.Blocked, ev.Challenge)
+	}
+	if ev.Challenge.Solved != 1 || ev.Challenge.SolveRate != 1 || ev.Challenge.FailRate != 0 {
+		t.Errorf("unexpected tallies: %+v", ev.Challenge)
+	}
+
+	// The same cookie from another IP is worthless.
+	w = h.do("GET", sqliPath, "198.51.100.7", withCookie, "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("cookie replayed from another IP: expected 429, got %d", w.Code)
+	}
+
+	// And it stops working once it expires.
+	h.challenger.now = func() time.Time { return time.Now().Add(31 * time.Minute) }
+	w = h.do("GET", sqliPath, ip, withCookie, "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("expired clearance: expected 429, got %d", w.Code)
+	}
+}
+
+func TestChallengeFailuresAreTalliedAndLockOut(t *testing.T) {
+	h := newChallengeHarness(t)
+	const ip = "203.0.113.9"
+
+	page := h.do("GET", sqliPath, ip, browserAccept, "").Body.String()
+	w := h.do("POST", "/__sentinel/challenge", ip, nil, solveFrom(t, page, false).Encode())
+	if w.Code != http.StatusForbidden || len(w.Result().Cookies()) != 0 {
+		t.Fatalf("wrong answer: expected 403 without a cookie, got %d %v", w.Code, w.Result().Cookies())
+	}
+
+	h.do("GET", sqliPath, ip, browserAccept, "")
+	ev := h.event(t, 2)
+	if ev.Challenge.Issued != 2 || ev.Challenge.Failed != 1 || ev.Challenge.SolveRate != 0 || ev.Challenge.FailRate != 1 {
+		t.Errorf("unexpected tallies after one failure: %+v", ev.Challenge)
+	}
+
+	// Enumerating answers gets the IP locked out: even a correct answer is
+	// refused until the lockout ends.
+	for i := 0; i < challengeLockoutAfter; i++ {
+		h.do("POST", "/__sentinel/challenge", ip, nil, solveFrom(t, page, false).Encode())
+	}
+	w = h.do("POST", "/__sentinel/challenge", ip, nil, solveFrom(t, page, true).Encode())
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "Too many incorrect answers") {
+		t.Errorf("locked-out IP: expected 403 lockout page, got %d", w.Code)
+	}
+}
+
+func TestChallengeWithoutRenderableProviderFallsBackToJSON(t *testing.T) {
+	ch := NewChallenger(captcha.NewTurnstileProvider("secret"), "jwt-secret", sentinel.ChallengeConfig{})
+	if ch.CanRender() {
+		t.Fatal("a Turnstile provider without a site key cannot render")
+	}
+	r := gin.New()
+	r.Use(WAFMiddlewareWithOptions(sentinel.WAFConfig{Enabled: true, Mode: sentinel.ModeChallenge}, nil, nil, nil, WAFOptions{Challenger: ch}))
+	r.GET("/api/products", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest("GET", sqliPath, nil)
+	req.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "WAF_CHALLENGE") {
+		t.Errorf("expected the 429 JSON fallback, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSafeRedirect(t *testing.T) {
+	cases := map[string]string{
+		"/api/products?x=1":    "/api/products?x=1",
+		"":                     "/",
+		"https://evil.example": "/",
+		"//evil.example/x":     "/",
+		"/\\evil.example":      "/",
+		"javascript:alert(1)":  "/",
+	}
+	for in, want := range cases {
+		if got := safeRedirect(in); got != want {
+			t.Errorf("safeRedirect(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
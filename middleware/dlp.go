@@ -0,0 +1,347 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/detection"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// defaultDLPMaxBodyBytes is the response buffer cap when DLPConfig.MaxBodyBytes
+// is unset.
+const defaultDLPMaxBodyBytes int64 = 256 * 1024
+
+// maxLeakEvidence bounds the evidence recorded per response; a listing page
+// full of card numbers is one leak, not hundreds.
+const maxLeakEvidence = 10
+
+// dlpKeptHeaders are the only headers a replaced response keeps: those
+// Sentinel's security headers, rate limiter and a CORS middleware set.
+// Everything else — cookies, redirects, cache validators — belongs to the
+// response being withheld.
+var dlpKeptHeaders = canonicalHeaderSet(
+	"Content-Security-Policy", "Permissions-Policy", "Referrer-Policy",
+	"Strict-Transport-Security", "X-Content-Type-Options", "X-Frame-Options",
+	"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After",
+	"Access-Control-Allow-Origin", "Access-Control-Allow-Credentials", "Access-Control-Expose-Headers",
+)
+
+func canonicalHeaderSet(names ...string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[http.CanonicalHeaderKey(name)] = true
+	}
+	return set
+}
+
+// DLPMiddleware creates a Gin middleware that inspects outgoing responses
+// for leaked data — database errors, stack traces, card numbers and secret
+// keys — as configured by DLPConfig.
+//
+// Responses of the configured content types are held in a buffer of up to
+// MaxBodyBytes until the handler returns, then scanned. A response that
+// outgrows the buffer, is flushed or hijacked by the handler, or is
+// compressed is sent on as it is, uninspected. If the handler panics, the
+// buffered response is dropped, so a recovery middleware registered before
+// this one still gets to write its own error.
+func DLPMiddleware(config sentinel.DLPConfig, pipe *pipeline.Pipeline) gin.HandlerFunc {
+	excludeRoutes := NewRouteMatcher(config.ExcludeRoutes)
+	maxBody := config.MaxBodyBytes
+	if maxBody <= 0 {
+		maxBody = defaultDLPMaxBodyBytes
+	}
+	if config.ReplaceStatus < 100 || config.ReplaceStatus > 599 {
+		config.ReplaceStatus = http.StatusInternalServerError
+	}
+	contentTypes := make(map[string]bool, len(config.ContentTypes))
+	for _, ct := range config.ContentTypes {
+		contentTypes[strings.ToLower(strings.TrimSpace(ct))] = true
+	}
+	inspectable := func(h http.Header, firstChunk []byte) bool {
+		if enc := h.Get("Content-Encoding"); enc != "" && enc != "identity" {
+			return false
+		}
+		if cl, err := strconv.ParseInt(h.Get("Content-Length"), 10, 64); err == nil && cl > maxBody {
+			return false
+		}
+		ct := h.Get("Content-Type")
+		if ct == "" {
+			// net/http sniffs the same bytes when the body goes out.
+			ct = http.DetectContentType(firstChunk)
+		}
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil {
+			return false
+		}
+		major, _, _ := strings.Cut(mediaType, "/")
+		return contentTypes[mediaType] || contentTypes[major+"/*"]
+	}
+
+	return func(c *gin.Context) {
+		if !config.Enabled || c.Request.Method == http.MethodHead || excludeRoutes.Matches(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		w := &dlpWriter{ResponseWriter: c.Writer, maxBody: maxBody, inspectable: inspectable}
+		c.Writer = w
+		defer func() { c.Writer = w.ResponseWriter }()
+		c.Next()
+
+		switch w.state {
+		case dlpUndecided:
+			// Nothing was written; only the status, if any, goes out.
+			if w.status != 0 {
+				w.ResponseWriter.WriteHeader(w.status)
+			}
+			return
+		case dlpPassthrough:
+			return
+		}
+
+		body := w.buf.Bytes()
+		leaks := detection.ScanResponse(body, config.Classes)
+		if len(leaks) == 0 {
+			w.passthrough()
+			return
+		}
+
+		action := sentinel.DLPActionLog
+		for _, l := range leaks {
+			if a := dlpActionFor(config, l.Class); dlpActionRank(a) > dlpActionRank(action) {
+				action = a
+			}
+		}
+
+		status := w.Status()
+		te := newLeakEvent(c, body, leaks)
+		switch action {
+		case sentinel.DLPActionReplace:
+			te.Blocked = true
+			te.StatusCode = config.ReplaceStatus
+			h := w.ResponseWriter.Header()
+			for name := range h {
+				if !dlpKeptHeaders[name] {
+					delete(h, name)
+				}
+			}
+			h.Set("Content-Type", config.ReplaceContentType)
+			h.Set("Cache-Control", "no-store")
+			w.ResponseWriter.WriteHeader(config.ReplaceStatus)
+			w.ResponseWriter.WriteString(config.ReplaceBody)
+		case sentinel.DLPActionMask:
+			te.StatusCode = status
+			// Classes whose action is log stay readable.
+			var masked []detection.Leak
+			for _, l := range leaks {
+				if dlpActionFor(config, l.Class) == sentinel.DLPActionMask {
+					masked = append(masked, l)
+				}
+			}
+			detection.MaskLeaks(body, masked)
+			w.passthrough()
+		default:
+			te.StatusCode = status
+			w.passthrough()
+		}
+
+		if pipe != nil {
+			pipe.EmitThreat(te)
+		}
+	}
+}
+
+func dlpActionFor(config sentinel.DLPConfig, class sentinel.DLPClass) sentinel.DLPAction {
+	if a, ok := config.ClassActions[class]; ok {
+		return a
+	}
+	return config.Action
+}
+
+func dlpActionRank(a sentinel.DLPAction) int {
+	switch a {
+	case sentinel.DLPActionMask:
+		return 1
+	case sentinel.DLPActionReplace:
+		return 2
+	}
+	return 0
+}
+
+// newLeakEvent builds the DataLeak threat for a response. The request's
+// client is the actor: leaks are usually provoked. The response body is
+// never copied into the event beyond redacted evidence.
+func newLeakEvent(c *gin.Context, body []byte, leaks []detection.Leak) *sentinel.ThreatEvent {
+	clientIP := extractClientIP(c)
+	severity := sentinel.SeverityLow
+	evidence := make([]sentinel.Evidence, 0, min(len(leaks), maxLeakEvidence))
+	for i, l := range leaks {
+		if s := detection.LeakSeverity(l.Class); severityRank(s) > severityRank(severity) {
+			severity = s
+		}
+		if i < maxLeakEvidence {
+			evidence = append(evidence, sentinel.Evidence{
+				Pattern:   l.Name,
+				Matched:   detection.RedactLeak(body, l),
+				Location:  "response",
+				Parameter: string(l.Class),
+			})
+		}
+	}
+	cvss := sentinel.DefaultCVSSForType(string(sentinel.ThreatDataLeak))
+	te := &sentinel.ThreatEvent{
+		ID:          uuid.New().String(),
+		Timestamp:   time.Now(),
+		IP:          clientIP,
+		ActorID:     ActorIDFromIP(clientIP),
+		Method:      c.Request.Method,
+		Path:        c.Request.URL.Path,
+		UserAgent:   c.Request.UserAgent(),
+		Referer:     c.Request.Referer(),
+		ThreatTypes: []string{string(sentinel.ThreatDataLeak)},
+		Severity:    severity,
+		Confidence:  90, // the signatures are specific; cards are Luhn-checked
+		Evidence:    evidence,
+		CVSS:        cvss.Score,
+		CVSSVector:  cvss.Vector,
+	}
+	applyClientSignals(c, te)
+	return te
+}
+
+func severityRank(s sentinel.Severity) int {
+	switch s {
+	case sentinel.SeverityLow:
+		return 1
+	case sentinel.SeverityMedium:
+		return 2
+	case sentinel.SeverityHigh:
+		return 3
+	case sentinel.SeverityCritical:
+		return 4
+	}
+	return 0
+}
+
+type dlpState int
+
+const (
+	dlpUndecided   dlpState = iota // nothing written yet
+	dlpBuffering                   // body held for inspection
+	dlpPassthrough                 // writes go straight to the client
+)
+
+// dlpWriter holds a response back until DLPMiddleware has inspected it.
+// The status is recorded rather than sent, and the body buffered, so the
+// response can still be changed when the handler returns.
+type dlpWriter struct {
+	gin.ResponseWriter
+	maxBody     int64
+	inspectable func(http.Header, []byte) bool
+	state       dlpState
+	status      int
+	buf         bytes.Buffer
+}
+
+// passthrough sends what was held back and stops buffering.
+func (w *dlpWriter) passthrough() {
+	if w.state == dlpPassthrough {
+		return
+	}
+	w.state = dlpPassthrough
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if w.buf.Len() > 0 {
+		w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+}
+
+func (w *dlpWriter) WriteHeader(code int) {
+	if w.state == dlpPassthrough {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code > 0 {
+		w.status = code
+	}
+}
+
+func (w *dlpWriter) WriteHeaderNow() {
+	// A buffered response's headers go out with its body.
+	if w.state == dlpBuffering {
+		return
+	}
+	w.passthrough()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *dlpWriter) Write(b []byte) (int, error) {
+	if w.state == dlpUndecided {
+		if w.inspectable(w.Header(), b) {
+			w.state = dlpBuffering
+		} else {
+			w.passthrough()
+		}
+	}
+	if w.state == dlpBuffering && int64(w.buf.Len()+len(b)) > w.maxBody {
+		w.passthrough()
+	}
+	if w.state == dlpPassthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.buf.Write(b)
+}
+
+func (w *dlpWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *dlpWriter) Status() int {
+	if w.state == dlpPassthrough {
+		return w.ResponseWriter.Status()
+	}
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *dlpWriter) Size() int {
+	switch w.state {
+	case dlpPassthrough:
+		return w.ResponseWriter.Size()
+	case dlpBuffering:
+		return w.buf.Len()
+	}
+	return -1
+}
+
+func (w *dlpWriter) Written() bool {
+	if w.state == dlpPassthrough {
+		return w.ResponseWriter.Written()
+	}
+	return w.state == dlpBuffering
+}
+
+// Flush means the handler is streaming, which cannot wait for inspection.
+func (w *dlpWriter) Flush() {
+	w.passthrough()
+	w.ResponseWriter.Flush()
+}
+
+func (w *dlpWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.state = dlpPassthrough
+	return w.ResponseWriter.Hijack()
+}
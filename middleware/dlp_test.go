@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/gin-gonic/gin"
+)
+
+func TestDLPMiddleware(t *testing.T) {
+	const trace = "panic: boom\n\ngoroutine 7 [running]:\nmain.handler()"
+	const card = "4111 1111 1111 1111"
+
+	setup := func(config sentinel.DLPConfig) (*gin.Engine, chan *sentinel.ThreatEvent) {
+		config.Enabled = true
+		if config.ContentTypes == nil {
+			config.ContentTypes = []string{"application/json", "text/*"}
+		}
+		events := make(chan *sentinel.ThreatEvent, 10)
+		pipe := pipeline.New(100)
+		pipe.AddHandler(pipeline.HandlerFunc(func(_ context.Context, e pipeline.Event) error {
+			if te, ok := e.Payload.(*sentinel.ThreatEvent); ok {
+				events <- te
+			}
+			return nil
+		}))
+		pipe.Start(1)
+		t.Cleanup(pipe.Stop)
+
+		r := gin.New()
+		r.Use(DLPMiddleware(config, pipe))
+		r.GET("/card", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"card": card}) })
+		r.GET("/trace", func(c *gin.Context) {
+			c.Header("Set-Cookie", "session=abc")
+			c.Header("Location", "/next")
+			c.Header("ETag", `"v1"`)
+			c.Header("X-RateLimit-Remaining", "9")
+			c.String(http.StatusInternalServerError, trace)
+		})
+		r.GET("/binary", func(c *gin.Context) { c.Data(http.StatusOK, "application/octet-stream", []byte(trace)) })
+		r.GET("/large", func(c *gin.Context) { c.String(http.StatusOK, strings.Repeat("x", 64)+trace) })
+		r.GET("/clean", func(c *gin.Context) { c.JSON(http.StatusCreated, gin.H{"ok": true}) })
+		r.GET("/empty", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+		return r, events
+	}
+	get := func(r *gin.Engine, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+	expectEvent := func(events chan *sentinel.ThreatEvent) *sentinel.ThreatEvent {
+		select {
+		case te := <-events:
+			return te
+		case <-time.After(time.Second):
+			t.Fatal("expected a DataLeak event")
+			return nil
+		}
+	}
+	expectNoEvent := func(events chan *sentinel.ThreatEvent, path string) {
+		select {
+		case te := <-events:
+			t.Errorf("%s: unexpected event %v", path, te.Evidence)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	t.Run("log", func(t *testing.T) {
+		r, events := setup(sentinel.DLPConfig{Action: sentinel.DLPActionLog})
+		w := get(r, "/trace")
+		if w.Code != http.StatusInternalServerError || w.Body.String() != trace {
+			t.Fatalf("log mode changed the response: %d %q", w.Code, w.Body.String())
+		}
+		te := expectEvent(events)
+		if te.ThreatTypes[0] != string(sentinel.ThreatDataLeak) || te.Blocked || te.StatusCode != http.StatusInternalServerError {
+			t.Errorf("unexpected event: %+v", te)
+		}
+		if te.Evidence[0].Pattern != "GoPanic" || te.Evidence[0].Location != "response" {
+			t.Errorf("unexpected evidence: %+v", te.Evidence)
+		}
+
+		if w := get(r, "/clean"); w.Code != http.StatusCreated || w.Body.String() != `{"ok":true}` {
+			t.Errorf("clean response altered: %d %q", w.Code, w.Body.String())
+		}
+		if w := get(r, "/empty"); w.Code != http.StatusNoContent {
+			t.Errorf("status-only response: got %d", w.Code)
+		}
+		expectNoEvent(events, "/clean")
+	})
+
+	t.Run("mask", func(t *testing.T) {
+		r, events := setup(sentinel.DLPConfig{Action: sentinel.DLPActionMask})
+		w := get(r, "/card")
+		if want := `{"card":"**** **** **** 1111"}`; w.Body.String() != want {
+			t.Errorf("got %q, want %q", w.Body.String(), want)
+		}
+		te := expectEvent(events)
+		if te.Severity != sentinel.SeverityCritical || te.Evidence[0].Matched != "**** **** **** 1111" {
+			t.Errorf("unexpected event: %+v", te)
+		}
+	})
+
+	t.Run("replace per class", func(t *testing.T) {
+		r, events := setup(sentinel.DLPConfig{
+			Action:             sentinel.DLPActionLog,
+			ClassActions:       map[sentinel.DLPClass]sentinel.DLPAction{sentinel.DLPStackTrace: sentinel.DLPActionReplace},
+			ReplaceStatus:      http.StatusServiceUnavailable,
+			ReplaceContentType: "text/plain",
+			ReplaceBody:        "unavailable",
+		})
+		w := get(r, "/trace")
+		if w.Code != http.StatusServiceUnavailable || w.Body.String() != "unavailable" || strings.Contains(w.Body.String(), "goroutine") {
+			t.Errorf("expected the replacement, got %d %q", w.Code, w.Body.String())
+		}
+		for _, name := range []string{"Set-Cookie", "Location", "ETag"} {
+			if v := w.Header().Get(name); v != "" {
+				t.Errorf("replacement kept the handler's %s: %q", name, v)
+			}
+		}
+		if w.Header().Get("X-RateLimit-Remaining") != "9" || w.Header().Get("Content-Type") != "text/plain" ||
+			w.Header().Get("Cache-Control") != "no-store" {
+			t.Errorf("unexpected replacement headers: %v", w.Header())
+		}
+		if te := expectEvent(events); !te.Blocked || te.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("unexpected event: %+v", te)
+		}
+
+		// Card numbers stay on the class-less action: logged, not replaced.
+		if w := get(r, "/card"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), card) {
+			t.Errorf("card response altered: %d %q", w.Code, w.Body.String())
+		}
+		expectEvent(events)
+	})
+
+	t.Run("content type and size limits", func(t *testing.T) {
+		r, events := setup(sentinel.DLPConfig{Action: sentinel.DLPActionReplace, MaxBodyBytes: 64})
+		if w := get(r, "/binary"); w.Body.String() != trace {
+			t.Errorf("uninspected content type altered: %q", w.Body.String())
+		}
+		if w := get(r, "/large"); w.Code != http.StatusOK || !strings.HasSuffix(w.Body.String(), trace) {
+			t.Errorf("oversized response altered: %d %q", w.Code, w.Body.String())
+		}
+		expectNoEvent(events, "/binary, /large")
+	})
+}
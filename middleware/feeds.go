@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// feedMatchKey is the gin context key holding the request's
+// *sentinel.FeedMatch.
+const feedMatchKey = "sentinel_feed_match"
+
+// FeedMatcher matches requests against threat feeds.
+// *intelligence.FeedManager satisfies it.
+type FeedMatcher interface {
+	MatchRequest(ip string, hosts ...string) *sentinel.FeedMatch
+}
+
+// IPWhitelist answers "is this IP whitelisted?". *intelligence.IPManager
+// satisfies it.
+type IPWhitelist interface {
+	IsWhitelisted(ip string) bool
+}
+
+// FeedMiddleware matches the client IP, and the hosts of the Referer and
+// Origin headers, against threat feeds. A match on a blocking feed is
+// refused with 403; a match on a flagging feed is recorded as a
+// ThreatIntelMatch threat and let through. Either way the match tags every
+// threat event the request raises. Whitelisted IPs are never matched.
+// Register it ahead of the WAF and rate limiter.
+func FeedMiddleware(feeds FeedMatcher, whitelist IPWhitelist, excludeRoutes []string, pipe *pipeline.Pipeline) gin.HandlerFunc {
+	exclude := NewRouteMatcher(excludeRoutes)
+	return func(c *gin.Context) {
+		if exclude.Matches(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		clientIP := extractClientIP(c)
+		if whitelist != nil && whitelist.IsWhitelisted(clientIP) {
+			c.Next()
+			return
+		}
+		match := feeds.MatchRequest(clientIP, headerHost(c.GetHeader("Referer")), headerHost(c.GetHeader("Origin")))
+		if match == nil {
+			c.Next()
+			return
+		}
+		c.Set(feedMatchKey, match)
+
+		switch match.Action {
+		case sentinel.FeedActionBlock:
+			emitFeedEvent(c, pipe, clientIP, match, true)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "Access denied",
+				"code":  "IP_BLOCKED",
+			})
+			return
+		case sentinel.FeedActionFlag:
+			emitFeedEvent(c, pipe, clientIP, match, false)
+		}
+		c.Next()
+	}
+}
+
+// RequestFeedMatch returns the threat feed match FeedMiddleware attached to
+// c, or nil.
+func RequestFeedMatch(c *gin.Context) *sentinel.FeedMatch {
+	if v, ok := c.Get(feedMatchKey); ok {
+		match, _ := v.(*sentinel.FeedMatch)
+		return match
+	}
+	return nil
+}
+
+// headerHost returns the host of a Referer or Origin value.
+func headerHost(v string) string {
+	if v == "" {
+		return ""
+	}
+	u, err := url.Parse(v)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// emitFeedEvent records a request matching a blocking or flagging feed.
+func emitFeedEvent(c *gin.Context, pipe *pipeline.Pipeline, clientIP string, match *sentinel.FeedMatch, blocked bool) {
+	if pipe == nil {
+		return
+	}
+	severity := sentinel.SeverityLow
+	status := 0
+	if blocked {
+		severity = sentinel.SeverityMedium
+		status = http.StatusForbidden
+	}
+	cvss := sentinel.DefaultCVSSForType(string(sentinel.ThreatIntelMatch))
+	te := &sentinel.ThreatEvent{
+		ID:          uuid.New().String(),
+		Timestamp:   time.Now(),
+		IP:          clientIP,
+		ActorID:     ActorIDFromIP(clientIP),
+		Method:      c.Request.Method,
+		Path:        c.Request.URL.Path,
+		UserAgent:   c.Request.UserAgent(),
+		Referer:     c.Request.Referer(),
+		ThreatTypes: []string{string(sentinel.ThreatIntelMatch)},
+		Severity:    severity,
+		Confidence:  90,
+		Evidence: []sentinel.Evidence{{
+			Pattern:  "ThreatFeed_" + string(match.Action),
+			Matched:  match.Indicator,
+			Location: "feed:" + match.Feed,
+		}},
+		Blocked:    blocked,
+		StatusCode: status,
+		CVSS:       cvss.Score,
+		CVSSVector: cvss.Vector,
+	}
+	applyClientSignals(c, te)
+	pipe.EmitThreat(te)
+}
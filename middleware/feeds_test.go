@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/gin-gonic/gin"
+)
+
+// staticFeeds lists 198.51.100.1 on a blocking feed and 203.0.113.1 and
+// referrals from spam.example on a flagging one.
+type staticFeeds struct{}
+
+func (staticFeeds) MatchRequest(ip string, hosts ...string) *sentinel.FeedMatch {
+	switch ip {
+	case "198.51.100.1":
+		return &sentinel.FeedMatch{Feed: "drop", Action: sentinel.FeedActionBlock, ScoreBoost: 20, Indicator: ip + "/32"}
+	case "203.0.113.1":
+		return &sentinel.FeedMatch{Feed: "watch", Action: sentinel.FeedActionFlag, ScoreBoost: 10, Indicator: ip + "/32"}
+	}
+	for _, h := range hosts {
+		if h == "spam.example" {
+			return &sentinel.FeedMatch{Feed: "watch", Action: sentinel.FeedActionFlag, ScoreBoost: 10, Indicator: h}
+		}
+	}
+	return nil
+}
+
+type staticWhitelist map[string]bool
+
+func (w staticWhitelist) IsWhitelisted(ip string) bool { return w[ip] }
+
+func TestFeedMiddleware(t *testing.T) {
+	pipe := pipeline.New(100)
+	threats := make(chan *sentinel.ThreatEvent, 10)
+	pipe.AddHandler(pipeline.HandlerFunc(func(ctx context.Context, event pipeline.Event) error {
+		if te, ok := event.Payload.(*sentinel.ThreatEvent); ok {
+			threats <- te
+		}
+		return nil
+	}))
+	pipe.Start(1)
+	defer pipe.Stop()
+
+	r := gin.New()
+	r.Use(FeedMiddleware(staticFeeds{}, staticWhitelist{"198.51.100.1": false}, nil, pipe))
+	r.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	do := func(ip, referer string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = ip + ":40000"
+		if referer != "" {
+			req.Header.Set("Referer", referer)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	next := func() *sentinel.ThreatEvent {
+		select {
+		case te := <-threats:
+			return te
+		case <-time.After(2 * time.Second):
+			t.Fatal("no threat emitted")
+			return nil
+		}
+	}
+
+	if code := do("198.51.100.1", ""); code != http.StatusForbidden {
+		t.Fatalf("blocking feed: expected 403, got %d", code)
+	}
+	if te := next(); te.ThreatTypes[0] != string(sentinel.ThreatIntelMatch) || te.FeedSource != "drop" || !te.Blocked {
+		t.Errorf("unexpected threat %+v", te)
+	}
+
+	if code := do("203.0.113.1", ""); code != http.StatusOK {
+		t.Fatalf("flagging feed: expected 200, got %d", code)
+	}
+	if te := next(); te.FeedSource != "watch" || te.FeedScore != 10 || te.Blocked {
+		t.Errorf("unexpected threat %+v", te)
+	}
+
+	if code := do("192.0.2.1", "https://spam.example/offer"); code != http.StatusOK {
+		t.Fatalf("flagged referrer: expected 200, got %d", code)
+	}
+	if te := next(); te.Evidence[0].Matched != "spam.example" {
+		t.Errorf("unexpected evidence %+v", te.Evidence)
+	}
+
+	if code := do("192.0.2.2", ""); code != http.StatusOK {
+		t.Fatalf("unlisted IP: expected 200, got %d", code)
+	}
+	select {
+	case te := <-threats:
+		t.Errorf("unexpected threat for an unlisted IP: %+v", te)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestFeedMiddlewareSkipsWhitelistedIPs(t *testing.T) {
+	r := gin.New()
+	r.Use(FeedMiddleware(staticFeeds{}, staticWhitelist{"198.51.100.1": true}, nil, nil))
+	r.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "198.51.100.1:40000"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("whitelisted IP: expected 200, got %d", w.Code)
+	}
+}
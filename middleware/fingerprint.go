@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/fingerprint"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// fingerprintAllowedKey marks requests whose fingerprint is on
+// FingerprintConfig.Allow, which the rate limiter exempts from
+// ByFingerprint.
+const fingerprintAllowedKey = "sentinel_fingerprint_allowed"
+
+// FingerprintMiddleware attaches each request's TLS fingerprint, as
+// config.Source reports it, to the request context (see
+// fingerprint.FromContext) and refuses fingerprints on config.Deny with
+// 403. Register it ahead of the WAF and rate limiter so their threat events
+// carry the fingerprint.
+func FingerprintMiddleware(config sentinel.FingerprintConfig, pipe *pipeline.Pipeline) gin.HandlerFunc {
+	allow := newFingerprintList(config.Allow)
+	deny := newFingerprintList(config.Deny)
+
+	return func(c *gin.Context) {
+		if config.Source == nil {
+			c.Next()
+			return
+		}
+		fp := config.Source(c.Request)
+		if fp == nil || (fp.JA3 == "" && fp.JA4 == "") {
+			c.Next()
+			return
+		}
+		c.Request = c.Request.WithContext(fingerprint.NewContext(c.Request.Context(), fp))
+
+		if _, ok := allow.match(fp); ok {
+			c.Set(fingerprintAllowedKey, true)
+		} else if entry, ok := deny.match(fp); ok {
+			emitDeniedFingerprint(c, pipe, fp, entry)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "Access denied",
+				"code":  "FINGERPRINT_DENIED",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequestFingerprint returns the TLS fingerprint FingerprintMiddleware
+// attached to c's request, or nil.
+func RequestFingerprint(c *gin.Context) *sentinel.TLSFingerprint {
+	return fingerprint.FromContext(c.Request.Context())
+}
+
+// applyClientSignals copies the request's TLS fingerprint, bot verdict,
+// threat feed match and network type onto te.
+func applyClientSignals(c *gin.Context, te *sentinel.ThreatEvent) {
+	if fp := RequestFingerprint(c); fp != nil {
+		te.JA3 = fp.JA3
+		te.JA4 = fp.JA4
+	}
+	if bot := RequestBot(c); bot != nil {
+		te.BotVerdict = bot.Verdict
+		te.BotScore = bot.Score
+	}
+	if match := RequestFeedMatch(c); match != nil {
+		te.FeedSource = match.Feed
+		te.FeedScore = match.ScoreBoost
+	}
+	if network := RequestNetwork(c); network != nil {
+		te.NetworkType = network.Type
+		te.NetworkScore = network.ScoreBoost
+	}
+}
+
+// fingerprintList matches fingerprints against Allow or Deny entries:
+// exact JA3 or JA4 values, or prefixes ending in "*".
+type fingerprintList struct {
+	exact    map[string]bool
+	prefixes []string
+}
+
+func newFingerprintList(entries []string) fingerprintList {
+	l := fingerprintList{exact: make(map[string]bool)}
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		switch {
+		case e == "" || e == "*":
+			// A bare "*" would match every client; ValidateConfig flags it.
+		case strings.HasSuffix(e, "*"):
+			l.prefixes = append(l.prefixes, strings.TrimSuffix(e, "*"))
+		default:
+			l.exact[e] = true
+		}
+	}
+	return l
+}
+
+// match returns the entry fp matched, if any.
+func (l fingerprintList) match(fp *sentinel.TLSFingerprint) (string, bool) {
+	for _, v := range []string{fp.JA4, fp.JA3} {
+		if v == "" {
+			continue
+		}
+		if l.exact[v] {
+			return v, true
+		}
+		for _, p := range l.prefixes {
+			if strings.HasPrefix(v, p) {
+				return p + "*", true
+			}
+		}
+	}
+	return "", false
+}
+
+func emitDeniedFingerprint(c *gin.Context, pipe *pipeline.Pipeline, fp *sentinel.TLSFingerprint, entry string) {
+	if pipe == nil {
+		return
+	}
+	clientIP := extractClientIP(c)
+	cvss := sentinel.DefaultCVSSForType(string(sentinel.ThreatDeniedFingerprint))
+	pipe.EmitThreat(&sentinel.ThreatEvent{
+		ID:          uuid.New().String(),
+		Timestamp:   time.Now(),
+		IP:          clientIP,
+		ActorID:     ActorIDFromIP(clientIP),
+		Method:      c.Request.Method,
+		Path:        c.Request.URL.Path,
+		UserAgent:   c.Request.UserAgent(),
+		ThreatTypes: []string{string(sentinel.ThreatDeniedFingerprint)},
+		Severity:    sentinel.SeverityMedium,
+		Confidence:  100,
+		Blocked:     true,
+		StatusCode:  http.StatusForbidden,
+		Evidence: []sentinel.Evidence{
+			{Pattern: "FingerprintDeny", Matched: entry, Location: "tls"},
+		},
+		CVSS:       cvss.Score,
+		CVSSVector: cvss.Vector,
+		JA3:        fp.JA3,
+		JA4:        fp.JA4,
+	})
+}
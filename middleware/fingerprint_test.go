@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/fingerprint"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	curlJA4   = "t13d3112h2_e8f1e7e78f70_375ad8d4a6b8"
+	chromeJA4 = "t13d1516h2_8daaf6152771_e5627efa2ab1"
+)
+
+// fingerprintRequest returns a request from ip whose fingerprint, as
+// fingerprint.Headers reads it, is ja4.
+func fingerprintRequest(ip, ja4 string) *http.Request {
+	req := httptest.NewRequest("GET", "/api/test", nil)
+	req.RemoteAddr = ip + ":40000"
+	req.Header.Set("X-JA4", ja4)
+	return req
+}
+
+func TestFingerprintMiddleware_DenyEmitsThreat(t *testing.T) {
+	pipe := pipeline.New(100)
+	threats := make(chan *sentinel.ThreatEvent, 10)
+	pipe.AddHandler(pipeline.HandlerFunc(func(ctx context.Context, event pipeline.Event) error {
+		if te, ok := event.Payload.(*sentinel.ThreatEvent); ok {
+			threats <- te
+		}
+		return nil
+	}))
+	pipe.Start(1)
+	defer pipe.Stop()
+
+	var seen *sentinel.TLSFingerprint
+	r := gin.New()
+	r.Use(FingerprintMiddleware(sentinel.FingerprintConfig{
+		Source: fingerprint.Headers("", "X-JA4"),
+		Deny:   []string{"t13d3112h2_*"},
+	}, pipe))
+	r.GET("/api/test", func(c *gin.Context) {
+		seen = RequestFingerprint(c)
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, fingerprintRequest("198.51.100.1", chromeJA4))
+	if w.Code != http.StatusOK || seen == nil || seen.JA4 != chromeJA4 {
+		t.Fatalf("allowed client: status %d, fingerprint %+v", w.Code, seen)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, fingerprintRequest("198.51.100.2", curlJA4))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("denied client: expected 403, got %d", w.Code)
+	}
+	select {
+	case te := <-threats:
+		if te.ThreatTypes[0] != string(sentinel.ThreatDeniedFingerprint) || te.JA4 != curlJA4 ||
+			te.IP != "198.51.100.2" || te.Evidence[0].Matched != "t13d3112h2_*" {
+			t.Errorf("unexpected threat %+v", te)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no threat emitted")
+	}
+}
+
+func TestRateLimitByFingerprintAcrossIPs(t *testing.T) {
+	limiter := NewRateLimiter()
+	defer limiter.Stop()
+
+	r := gin.New()
+	r.Use(FingerprintMiddleware(sentinel.FingerprintConfig{
+		Source: fingerprint.Headers("", "X-JA4"),
+		Allow:  []string{chromeJA4},
+	}, nil))
+	r.Use(RateLimitMiddleware(sentinel.RateLimitConfig{
+		Enabled:       true,
+		ByFingerprint: &sentinel.Limit{Requests: 3, Window: time.Minute},
+	}, limiter, nil))
+	r.GET("/api/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// One client stack rotating IPs shares one budget.
+	for i, ip := range []string{"203.0.113.1", "203.0.113.2", "203.0.113.3", "203.0.113.4"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, fingerprintRequest(ip, curlJA4))
+		want := http.StatusOK
+		if i == 3 {
+			want = http.StatusTooManyRequests
+		}
+		if w.Code != want {
+			t.Fatalf("request %d from %s: expected %d, got %d", i+1, ip, want, w.Code)
+		}
+	}
+
+	// Allow-listed fingerprints are not counted.
+	for i := 0; i < 5; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, fingerprintRequest("203.0.113.9", chromeJA4))
+		if w.Code != http.StatusOK {
+			t.Fatalf("allow-listed request %d: expected 200, got %d", i+1, w.Code)
+		}
+	}
+}
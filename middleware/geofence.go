@@ -0,0 +1,283 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// locationCode matches an ISO 3166-1 alpha-2 country code or an ISO 3166-2
+// region code.
+var locationCode = regexp.MustCompile(`^[A-Z]{2}(-[A-Z0-9]{1,3})?$`)
+
+// GeoFence holds the geofencing policies GeoFenceMiddleware enforces. The
+// policies can be replaced while serving, e.g. from the dashboard. Safe
+// for concurrent use.
+type GeoFence struct {
+	policies atomic.Pointer[[]*geoPolicy]
+}
+
+// geoPolicy is a compiled sentinel.GeoPolicy.
+type geoPolicy struct {
+	sentinel.GeoPolicy
+	routes *RouteMatcher // nil covers every route
+	allow  map[string]bool
+	deny   map[string]bool
+}
+
+// NewGeoFence compiles policies — see SetPolicies.
+func NewGeoFence(policies []sentinel.GeoPolicy) (*GeoFence, error) {
+	f := &GeoFence{}
+	empty := []*geoPolicy{}
+	f.policies.Store(&empty)
+	if err := f.SetPolicies(policies); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// SetPolicies replaces the policies. If any policy is invalid (see
+// ValidateGeoPolicy) or two share an ID, it returns an error and keeps the
+// current ones.
+func (f *GeoFence) SetPolicies(policies []sentinel.GeoPolicy) error {
+	compiled := make([]*geoPolicy, 0, len(policies))
+	seen := make(map[string]bool, len(policies))
+	for _, p := range policies {
+		if err := ValidateGeoPolicy(p); err != nil {
+			return err
+		}
+		if seen[p.ID] {
+			return fmt.Errorf("geofence policy %q: duplicate ID", p.ID)
+		}
+		seen[p.ID] = true
+
+		gp := &geoPolicy{GeoPolicy: p, allow: locationSet(p.Allow), deny: locationSet(p.Deny)}
+		if gp.Mode == "" {
+			gp.Mode = sentinel.ModeBlock
+		}
+		if len(p.Routes) > 0 {
+			gp.routes = NewRouteMatcher(p.Routes)
+		}
+		compiled = append(compiled, gp)
+	}
+	f.policies.Store(&compiled)
+	return nil
+}
+
+// Policies returns the current policies.
+func (f *GeoFence) Policies() []sentinel.GeoPolicy {
+	compiled := *f.policies.Load()
+	out := make([]sentinel.GeoPolicy, len(compiled))
+	for i, p := range compiled {
+		out[i] = p.GeoPolicy
+	}
+	return out
+}
+
+// ValidateGeoPolicy reports why p cannot be enforced, or nil.
+func ValidateGeoPolicy(p sentinel.GeoPolicy) error {
+	if p.ID == "" {
+		return errors.New("geofence policy: ID is required")
+	}
+	if len(p.Allow) == 0 && len(p.Deny) == 0 {
+		return fmt.Errorf("geofence policy %q: needs an allow or deny list", p.ID)
+	}
+	for _, code := range append(append([]string(nil), p.Allow...), p.Deny...) {
+		if !locationCode.MatchString(strings.ToUpper(strings.TrimSpace(code))) {
+			return fmt.Errorf("geofence policy %q: %q is not an ISO 3166 country or region code", p.ID, code)
+		}
+	}
+	switch p.Mode {
+	case "", sentinel.ModeLog, sentinel.ModeBlock, sentinel.ModeChallenge:
+	default:
+		return fmt.Errorf("geofence policy %q: unknown mode %q", p.ID, p.Mode)
+	}
+	for _, route := range p.Routes {
+		if err := ValidateRoutePattern(route); err != nil {
+			return fmt.Errorf("geofence policy %q: %w", p.ID, err)
+		}
+	}
+	return nil
+}
+
+func locationSet(codes []string) map[string]bool {
+	set := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		set[strings.ToUpper(strings.TrimSpace(code))] = true
+	}
+	return set
+}
+
+// covers reports whether any policy covers path.
+func (f *GeoFence) covers(path string) bool {
+	for _, p := range *f.policies.Load() {
+		if p.routes == nil || p.routes.Matches(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns the strictest policy refusing a client located at geo on
+// path, or nil. A nil geo, or one without a country, is an unknown
+// location, refused only by fail-closed policies.
+func (f *GeoFence) Check(path string, geo *sentinel.GeoResult) *sentinel.GeoPolicy {
+	var refusing *geoPolicy
+	for _, p := range *f.policies.Load() {
+		if p.routes != nil && !p.routes.Matches(path) {
+			continue
+		}
+		if !p.refuses(geo) {
+			continue
+		}
+		if refusing == nil || geoModeRank(p.Mode) > geoModeRank(refusing.Mode) {
+			refusing = p
+		}
+	}
+	if refusing == nil {
+		return nil
+	}
+	policy := refusing.GeoPolicy
+	policy.Mode = refusing.Mode
+	return &policy
+}
+
+// refuses reports whether p refuses a client located at geo.
+func (p *geoPolicy) refuses(geo *sentinel.GeoResult) bool {
+	if geo == nil || geo.CountryCode == "" {
+		return p.FailClosed
+	}
+	country := strings.ToUpper(geo.CountryCode)
+	region := strings.ToUpper(geo.RegionCode)
+	if p.deny[country] || (region != "" && p.deny[region]) {
+		return true
+	}
+	if len(p.allow) > 0 {
+		return !p.allow[country] && (region == "" || !p.allow[region])
+	}
+	return false
+}
+
+func geoModeRank(m sentinel.WAFMode) int {
+	switch m {
+	case sentinel.ModeBlock:
+		return 2
+	case sentinel.ModeChallenge:
+		return 1
+	}
+	return 0
+}
+
+// GeoFenceMiddleware enforces fence's policies: a refused client is
+// answered with 403 (ModeBlock) or the challenge page (ModeChallenge), or
+// recorded and let through (ModeLog); every refusal raises a
+// GeoFenceViolation threat. Clients are located with geo only on routes a
+// policy covers. Whitelisted IPs and excludeRoutes are never fenced, so a
+// policy cannot lock admins out of the dashboard. challenger may be nil,
+// in which case challenges are answered with 429 JSON; when set, the
+// middleware also answers challenge submissions. Register it ahead of the
+// WAF and rate limiter.
+func GeoFenceMiddleware(fence *GeoFence, geo GeoLookup, whitelist IPWhitelist, challenger *Challenger, excludeRoutes []string, pipe *pipeline.Pipeline) gin.HandlerFunc {
+	exclude := NewRouteMatcher(excludeRoutes)
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if challenger != nil && c.Request.Method == http.MethodPost && path == challenger.Path() {
+			challenger.handleSubmit(c, extractClientIP(c))
+			return
+		}
+		if exclude.Matches(path) || !fence.covers(path) {
+			c.Next()
+			return
+		}
+		clientIP := extractClientIP(c)
+		if whitelist != nil && whitelist.IsWhitelisted(clientIP) {
+			c.Next()
+			return
+		}
+
+		location, err := geo.LookupIP(c.Request.Context(), clientIP)
+		if err != nil {
+			location = nil
+		}
+		policy := fence.Check(path, location)
+		if policy == nil {
+			c.Next()
+			return
+		}
+
+		switch policy.Mode {
+		case sentinel.ModeLog:
+			emitGeoFenceEvent(c, pipe, clientIP, location, policy, false, 0, nil)
+			c.Next()
+
+		case sentinel.ModeChallenge:
+			if challenger.gate(c, clientIP, func(stats *sentinel.ChallengeStats) {
+				emitGeoFenceEvent(c, pipe, clientIP, location, policy, true, http.StatusTooManyRequests, stats)
+			}) {
+				c.Next()
+			}
+
+		default:
+			emitGeoFenceEvent(c, pipe, clientIP, location, policy, true, http.StatusForbidden, nil)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "Access denied",
+				"code":  "GEO_BLOCKED",
+			})
+		}
+	}
+}
+
+// emitGeoFenceEvent records a request a geofencing policy refused.
+func emitGeoFenceEvent(c *gin.Context, pipe *pipeline.Pipeline, clientIP string, location *sentinel.GeoResult, policy *sentinel.GeoPolicy, blocked bool, status int, challenge *sentinel.ChallengeStats) {
+	if pipe == nil {
+		return
+	}
+	severity := sentinel.SeverityLow
+	if blocked {
+		severity = sentinel.SeverityMedium
+	}
+	matched, country := "unknown", ""
+	if location != nil && location.CountryCode != "" {
+		country = location.CountryCode
+		matched = country
+		if location.RegionCode != "" {
+			matched += " " + location.RegionCode
+		}
+	}
+	cvss := sentinel.DefaultCVSSForType(string(sentinel.ThreatGeoFenceViolation))
+	te := &sentinel.ThreatEvent{
+		ID:          uuid.New().String(),
+		Timestamp:   time.Now(),
+		IP:          clientIP,
+		ActorID:     ActorIDFromIP(clientIP),
+		Method:      c.Request.Method,
+		Path:        c.Request.URL.Path,
+		UserAgent:   c.Request.UserAgent(),
+		Referer:     c.Request.Referer(),
+		Country:     country,
+		ThreatTypes: []string{string(sentinel.ThreatGeoFenceViolation)},
+		Severity:    severity,
+		Confidence:  90,
+		Evidence: []sentinel.Evidence{{
+			Pattern:  "GeoFence_" + policy.ID,
+			Matched:  matched,
+			Location: "geo",
+		}},
+		Blocked:    blocked,
+		StatusCode: status,
+		Challenge:  challenge,
+		CVSS:       cvss.Score,
+		CVSSVector: cvss.Vector,
+	}
+	applyClientSignals(c, te)
+	pipe.EmitThreat(te)
+}
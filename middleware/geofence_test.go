@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/gin-gonic/gin"
+)
+
+// staticGeo locates clients from a fixed table; other IPs fail to resolve.
+type staticGeo map[string]*sentinel.GeoResult
+
+func (g staticGeo) LookupIP(ctx context.Context, ip string) (*sentinel.GeoResult, error) {
+	if r, ok := g[ip]; ok {
+		return r, nil
+	}
+	return nil, errors.New("no location")
+}
+
+func TestGeoFenceCheck(t *testing.T) {
+	fence, err := NewGeoFence([]sentinel.GeoPolicy{
+		{ID: "sanctions", Deny: []string{"KP", "UA-43"}},
+		{ID: "admin", Routes: []string{"/admin/**"}, Allow: []string{"DE", "US-CA"}, Mode: sentinel.ModeChallenge, FailClosed: true},
+		{ID: "reports", Routes: []string{"/reports/**"}, Allow: []string{"DE"}, Mode: sentinel.ModeLog},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	de := &sentinel.GeoResult{CountryCode: "DE", RegionCode: "DE-BE"}
+	caUS := &sentinel.GeoResult{CountryCode: "US", RegionCode: "US-CA"}
+	nyUS := &sentinel.GeoResult{CountryCode: "US", RegionCode: "US-NY"}
+	crimea := &sentinel.GeoResult{CountryCode: "UA", RegionCode: "UA-43"}
+	kyiv := &sentinel.GeoResult{CountryCode: "UA", RegionCode: "UA-30"}
+	kp := &sentinel.GeoResult{CountryCode: "KP"}
+
+	cases := []struct {
+		name string
+		path string
+		geo  *sentinel.GeoResult
+		want string // refusing policy ID, "" for none
+	}{
+		{"denied country anywhere", "/", kp, "sanctions"},
+		{"denied region", "/shop", crimea, "sanctions"},
+		{"other region of the country", "/shop", kyiv, ""},
+		{"allowed country on an admin route", "/admin/users", de, ""},
+		{"allowed region on an admin route", "/admin/users", caUS, ""},
+		{"other region on an admin route", "/admin/users", nyUS, "admin"},
+		{"unknown location fails closed", "/admin/users", nil, "admin"},
+		{"unknown location fails open", "/shop", nil, ""},
+		{"block outranks challenge", "/admin/users", kp, "sanctions"},
+		{"log-mode refusal", "/reports/q1", nyUS, "reports"},
+	}
+	for _, tc := range cases {
+		got := ""
+		if p := fence.Check(tc.path, tc.geo); p != nil {
+			got = p.ID
+		}
+		if got != tc.want {
+			t.Errorf("%s: Check(%s) refused by %q, want %q", tc.name, tc.path, got, tc.want)
+		}
+	}
+
+	if p := fence.Check("/", kp); p == nil || p.Mode != sentinel.ModeBlock {
+		t.Errorf("policy without a mode should block, got %+v", p)
+	}
+}
+
+func TestGeoFenceSetPoliciesKeepsValidSet(t *testing.T) {
+	fence, err := NewGeoFence([]sentinel.GeoPolicy{{ID: "sanctions", Deny: []string{"KP"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad := [][]sentinel.GeoPolicy{
+		{{ID: "x", Deny: []string{"North Korea"}}},
+		{{ID: "x"}},
+		{{ID: "x", Deny: []string{"KP"}, Mode: "deny"}},
+		{{ID: "x", Deny: []string{"KP"}}, {ID: "x", Allow: []string{"DE"}}},
+	}
+	for _, policies := range bad {
+		if err := fence.SetPolicies(policies); err == nil {
+			t.Errorf("SetPolicies(%+v) accepted", policies)
+		}
+	}
+	if got := fence.Policies(); len(got) != 1 || got[0].ID != "sanctions" {
+		t.Errorf("policies replaced by an invalid set: %+v", got)
+	}
+}
+
+func TestGeoFenceMiddleware(t *testing.T) {
+	pipe := pipeline.New(100)
+	threats := make(chan *sentinel.ThreatEvent, 10)
+	pipe.AddHandler(pipeline.HandlerFunc(func(ctx context.Context, event pipeline.Event) error {
+		if te, ok := event.Payload.(*sentinel.ThreatEvent); ok {
+			threats <- te
+		}
+		return nil
+	}))
+	pipe.Start(1)
+	defer pipe.Stop()
+
+	fence, err := NewGeoFence([]sentinel.GeoPolicy{
+		{ID: "sanctions", Deny: []string{"KP"}},
+		{ID: "admin", Routes: []string{"/admin/**"}, Allow: []string{"DE"}, Mode: sentinel.ModeChallenge, FailClosed: true},
+		{ID: "reports", Routes: []string{"/reports/**"}, Allow: []string{"DE"}, Mode: sentinel.ModeLog},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	geo := staticGeo{
+		"198.51.100.1": {CountryCode: "KP"},
+		"198.51.100.2": {CountryCode: "DE"},
+		"198.51.100.3": {CountryCode: "FR", RegionCode: "FR-IDF"},
+	}
+	r := gin.New()
+	r.Use(GeoFenceMiddleware(fence, geo, staticWhitelist{"198.51.100.9": true}, nil, []string{"/sentinel/**"}, pipe))
+	r.Any("/*path", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	do := func(ip, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = ip + ":40000"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	next := func() *sentinel.ThreatEvent {
+		select {
+		case te := <-threats:
+			return te
+		case <-time.After(2 * time.Second):
+			t.Fatal("no threat emitted")
+			return nil
+		}
+	}
+
+	if w := do("198.51.100.1", "/"); w.Code != http.StatusForbidden {
+		t.Fatalf("denied country: expected 403, got %d", w.Code)
+	}
+	te := next()
+	if te.ThreatTypes[0] != string(sentinel.ThreatGeoFenceViolation) || !te.Blocked || te.Country != "KP" ||
+		te.Evidence[0].Pattern != "GeoFence_sanctions" {
+		t.Errorf("unexpected threat %+v", te)
+	}
+
+	if w := do("198.51.100.3", "/admin/"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("challenge without a challenger: expected 429, got %d", w.Code)
+	}
+	if te := next(); te.Evidence[0].Matched != "FR FR-IDF" || te.Evidence[0].Pattern != "GeoFence_admin" {
+		t.Errorf("unexpected evidence %+v", te.Evidence)
+	}
+
+	// The lookup fails for an unknown IP, and the admin policy fails closed.
+	if w := do("192.0.2.1", "/admin/"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("unresolved location on a fail-closed route: expected 429, got %d", w.Code)
+	}
+	if te := next(); te.Evidence[0].Matched != "unknown" {
+		t.Errorf("unexpected evidence %+v", te.Evidence)
+	}
+
+	if w := do("198.51.100.3", "/reports/q1"); w.Code != http.StatusOK {
+		t.Fatalf("log mode: expected 200, got %d", w.Code)
+	}
+	if te := next(); te.Blocked || te.Severity != sentinel.SeverityLow {
+		t.Errorf("log mode threat should not be blocked: %+v", te)
+	}
+
+	for _, tc := range []struct{ ip, path string }{
+		{"198.51.100.2", "/admin/"},                  // allowed country
+		{"192.0.2.1", "/shop"},                       // unresolved location on a fail-open route
+		{"198.51.100.9", "/admin/"},                  // whitelisted
+		{"198.51.100.1", "/sentinel/api/auth/login"}, // excluded
+	} {
+		if w := do(tc.ip, tc.path); w.Code != http.StatusOK {
+			t.Errorf("%s %s: expected 200, got %d", tc.ip, tc.path, w.Code)
+		}
+	}
+	select {
+	case te := <-threats:
+		t.Errorf("unexpected threat for a permitted request: %+v", te)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
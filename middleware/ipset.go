@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/netip"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/iptrie"
+)
+
+// ipSet matches client IPs against the entry shapes WAFConfig.ExcludeIPs
+// accepts: addresses, CIDR prefixes and ASNs (see core.ParseIPEntry).
+type ipSet struct {
+	prefixes iptrie.Trie[struct{}]
+	asns     map[string]struct{}
+}
+
+// newIPSet compiles entries, logging and dropping any it cannot parse.
+func newIPSet(entries []string) *ipSet {
+	s := &ipSet{asns: make(map[string]struct{})}
+	for _, e := range entries {
+		entry, err := sentinel.ParseIPEntry(e)
+		if err != nil {
+			log.Printf("[sentinel] %v — entry ignored", err)
+			continue
+		}
+		if sentinel.IsASNEntry(entry) {
+			s.asns[entry] = struct{}{}
+		} else if p, err := iptrie.ParsePrefix(entry); err == nil {
+			s.prefixes.Insert(p, struct{}{})
+		}
+	}
+	return s
+}
+
+// ASNLookup resolves the ASN of an IP address without leaving the process,
+// as *intelligence.GeoLocator does from its local databases.
+type ASNLookup interface {
+	LookupASN(ip string) string
+}
+
+// contains reports whether an entry covers ip. ASN entries are resolved
+// with geo — locally when it implements ASNLookup — and never match
+// without one.
+func (s *ipSet) contains(ctx context.Context, ip string, geo GeoLookup) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	if s.prefixes.Contains(addr) {
+		return true
+	}
+	if len(s.asns) == 0 || geo == nil {
+		return false
+	}
+	if local, ok := geo.(ASNLookup); ok {
+		_, ok = s.asns[local.LookupASN(ip)]
+		return ok
+	}
+	result, _ := geo.LookupIP(ctx, ip)
+	if result == nil {
+		return false
+	}
+	_, ok := s.asns[sentinel.ASNEntry(result.ASN)]
+	return ok
+}
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// networkMatchKey is the gin context key holding the request's
+// *sentinel.NetworkMatch.
+const networkMatchKey = "sentinel_network_match"
+
+// NetworkClassifier tells which kind of network a client IP belongs to.
+// *intelligence.NetworkDetector satisfies it.
+type NetworkClassifier interface {
+	Classify(ip string) *sentinel.NetworkMatch
+}
+
+// NetworkMiddleware classifies the client IP of each request by network
+// type and attaches the result to the request context (see RequestNetwork),
+// for WAFConfig.NetworkPolicy, RateLimitConfig.ByNetwork and threat events.
+// Register it ahead of the WAF and rate limiter.
+func NetworkMiddleware(networks NetworkClassifier, excludeRoutes []string) gin.HandlerFunc {
+	exclude := NewRouteMatcher(excludeRoutes)
+	return func(c *gin.Context) {
+		if exclude.Matches(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		if match := networks.Classify(extractClientIP(c)); match != nil {
+			c.Set(networkMatchKey, match)
+		}
+		c.Next()
+	}
+}
+
+// RequestNetwork returns the network match NetworkMiddleware attached to c,
+// or nil.
+func RequestNetwork(c *gin.Context) *sentinel.NetworkMatch {
+	if v, ok := c.Get(networkMatchKey); ok {
+		match, _ := v.(*sentinel.NetworkMatch)
+		return match
+	}
+	return nil
+}
+
+// applyNetworkPolicy enforces WAFConfig.NetworkPolicy on the request. It
+// reports whether the WAF should go on to inspect it; when it returns false
+// the request has been answered.
+func applyNetworkPolicy(c *gin.Context, policy map[sentinel.NetworkType]sentinel.WAFMode, mode sentinel.WAFMode, challenger *Challenger, pipe *pipeline.Pipeline, clientIP string) bool {
+	network := RequestNetwork(c)
+	if network == nil {
+		return true
+	}
+	action := policy[network.Type]
+	switch action {
+	case sentinel.ModeLog, sentinel.ModeBlock, sentinel.ModeChallenge:
+	default:
+		return true
+	}
+	if action == sentinel.ModeLog || mode == sentinel.ModeLog {
+		// Monitoring only: record what the policy would have done.
+		emitNetworkEvent(c, pipe, clientIP, network, action, false, 0, nil)
+		return true
+	}
+
+	if action == sentinel.ModeBlock {
+		emitNetworkEvent(c, pipe, clientIP, network, action, true, http.StatusForbidden, nil)
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "Access denied",
+			"code":  "NETWORK_BLOCKED",
+		})
+		return false
+	}
+	return challenger.gate(c, clientIP, func(stats *sentinel.ChallengeStats) {
+		emitNetworkEvent(c, pipe, clientIP, network, action, true, http.StatusTooManyRequests, stats)
+	})
+}
+
+// emitNetworkEvent records a request the WAF's network policy acted on.
+func emitNetworkEvent(c *gin.Context, pipe *pipeline.Pipeline, clientIP string, network *sentinel.NetworkMatch, action sentinel.WAFMode, blocked bool, status int, challenge *sentinel.ChallengeStats) {
+	if pipe == nil {
+		return
+	}
+	severity := sentinel.SeverityLow
+	if blocked {
+		severity = sentinel.SeverityMedium
+	}
+	matched := string(network.Type)
+	if network.Indicator != "" {
+		matched += " " + network.Indicator
+	}
+	cvss := sentinel.DefaultCVSSForType(string(sentinel.ThreatNetworkPolicy))
+	te := &sentinel.ThreatEvent{
+		ID:          uuid.New().String(),
+		Timestamp:   time.Now(),
+		IP:          clientIP,
+		ActorID:     ActorIDFromIP(clientIP),
+		Method:      c.Request.Method,
+		Path:        c.Request.URL.Path,
+		UserAgent:   c.Request.UserAgent(),
+		Referer:     c.Request.Referer(),
+		ThreatTypes: []string{string(sentinel.ThreatNetworkPolicy)},
+		Severity:    severity,
+		Confidence:  90,
+		Evidence: []sentinel.Evidence{{
+			Pattern:  "NetworkPolicy_" + string(action),
+			Matched:  matched,
+			Location: "network",
+		}},
+		Blocked:    blocked,
+		StatusCode: status,
+		Challenge:  challenge,
+		CVSS:       cvss.Score,
+		CVSSVector: cvss.Vector,
+	}
+	applyClientSignals(c, te)
+	pipe.EmitThreat(te)
+}
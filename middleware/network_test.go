@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/gin-gonic/gin"
+)
+
+// staticNetworks puts 198.51.100.1 on Tor, 198.51.100.2 on a VPN and every
+// other IP on a residential network.
+type staticNetworks struct{}
+
+func (staticNetworks) Classify(ip string) *sentinel.NetworkMatch {
+	switch ip {
+	case "198.51.100.1":
+		return &sentinel.NetworkMatch{Type: sentinel.NetworkTor, ScoreBoost: 20, List: "tor.txt", Indicator: ip + "/32"}
+	case "198.51.100.2":
+		return &sentinel.NetworkMatch{Type: sentinel.NetworkVPN, ScoreBoost: 10, List: "vpn.txt", Indicator: "198.51.100.0/30"}
+	}
+	return &sentinel.NetworkMatch{Type: sentinel.NetworkResidential}
+}
+
+func TestWAFNetworkPolicy(t *testing.T) {
+	pipe := pipeline.New(100)
+	threats := make(chan *sentinel.ThreatEvent, 10)
+	pipe.AddHandler(pipeline.HandlerFunc(func(ctx context.Context, event pipeline.Event) error {
+		if te, ok := event.Payload.(*sentinel.ThreatEvent); ok {
+			threats <- te
+		}
+		return nil
+	}))
+	pipe.Start(1)
+	defer pipe.Stop()
+
+	r := gin.New()
+	r.Use(NetworkMiddleware(staticNetworks{}, nil))
+	r.Use(WAFMiddleware(sentinel.WAFConfig{
+		Enabled: true,
+		Mode:    sentinel.ModeBlock,
+		NetworkPolicy: map[sentinel.NetworkType]sentinel.WAFMode{
+			sentinel.NetworkTor: sentinel.ModeBlock,
+			sentinel.NetworkVPN: sentinel.ModeLog,
+		},
+	}, nil, pipe, nil))
+	r.GET("/search", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	do := func(ip, target string) int {
+		req := httptest.NewRequest("GET", target, nil)
+		req.RemoteAddr = ip + ":40000"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	next := func() *sentinel.ThreatEvent {
+		select {
+		case te := <-threats:
+			return te
+		case <-time.After(2 * time.Second):
+			t.Fatal("no threat emitted")
+			return nil
+		}
+	}
+
+	if code := do("198.51.100.1", "/search"); code != http.StatusForbidden {
+		t.Fatalf("Tor exit: expected 403, got %d", code)
+	}
+	te := next()
+	if te.ThreatTypes[0] != string(sentinel.ThreatNetworkPolicy) || !te.Blocked ||
+		te.NetworkType != sentinel.NetworkTor || te.NetworkScore != 20 || te.Evidence[0].Matched != "tor 198.51.100.1/32" {
+		t.Errorf("unexpected threat %+v", te)
+	}
+
+	// Log mode records the request and goes on to inspect it.
+	if code := do("198.51.100.2", "/search"); code != http.StatusOK {
+		t.Fatalf("VPN in log mode: expected 200, got %d", code)
+	}
+	if te := next(); te.Blocked || te.NetworkType != sentinel.NetworkVPN {
+		t.Errorf("unexpected threat %+v", te)
+	}
+	if code := do("198.51.100.2", "/search?q=1'+OR+'1'='1"); code != http.StatusForbidden {
+		t.Fatalf("VPN attack: expected 403, got %d", code)
+	}
+	next() // the network policy's log entry
+	if te := next(); te.ThreatTypes[0] == string(sentinel.ThreatNetworkPolicy) || te.NetworkType != sentinel.NetworkVPN {
+		t.Errorf("WAF threat should carry the network type: %+v", te)
+	}
+
+	if code := do("192.0.2.1", "/search"); code != http.StatusOK {
+		t.Fatalf("residential: expected 200, got %d", code)
+	}
+	select {
+	case te := <-threats:
+		t.Errorf("unexpected threat for a residential client: %+v", te)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRateLimitByNetwork(t *testing.T) {
+	limiter := NewRateLimiter()
+	defer limiter.Stop()
+
+	r := gin.New()
+	r.Use(NetworkMiddleware(staticNetworks{}, nil))
+	r.Use(RateLimitMiddleware(sentinel.RateLimitConfig{
+		Enabled:   true,
+		ByIP:      &sentinel.Limit{Requests: 3, Window: time.Minute},
+		ByNetwork: map[sentinel.NetworkType]sentinel.Limit{sentinel.NetworkTor: {Requests: 1, Window: time.Minute}},
+	}, limiter, nil))
+	r.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	do := func(ip string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = ip + ":40000"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		if code := do("198.51.100.1"); code != want {
+			t.Fatalf("Tor request %d: expected %d, got %d", i+1, want, code)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if code := do("192.0.2.1"); code != http.StatusOK {
+			t.Fatalf("residential request %d: expected 200, got %d", i+1, code)
+		}
+	}
+}
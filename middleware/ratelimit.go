@@ -1,11 +1,15 @@
 package middleware
 
 import (
+	"context"
+	"log"
+	"math"
 	"net/http"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
-	"sync"
+	"sync/atomic"
 	"time"
 
 	sentinel "github.com/MUKE-coder/sentinel/v2/core"
@@ -14,126 +18,87 @@ import (
 	"github.com/google/uuid"
 )
 
-// rateLimitEntry tracks request count within a sliding window.
-type rateLimitEntry struct {
-	count     int
-	windowEnd time.Time
-}
-
-// RateLimiter holds in-memory rate limit state.
+// RateLimiter enforces limits against a CounterStore. The default store is
+// in-process; pass a shared store (see package middleware/redislimit) to make
+// limits hold across replicas.
 type RateLimiter struct {
-	mu       sync.RWMutex
-	counters map[string]*rateLimitEntry
-	stopCh   chan struct{}
+	store  CounterStore
+	owned  *MemoryCounterStore // set when NewRateLimiter created the store itself
+	routes atomic.Pointer[compiledRouteLimits]
 }
 
-// NewRateLimiter creates a new rate limiter with automatic cleanup.
-func NewRateLimiter() *RateLimiter {
-	rl := &RateLimiter{
-		counters: make(map[string]*rateLimitEntry),
-		stopCh:   make(chan struct{}),
+// NewRateLimiter creates a new rate limiter. With no argument it keeps
+// counters in an in-process MemoryCounterStore with automatic cleanup; pass a
+// CounterStore to share counters between replicas.
+func NewRateLimiter(store ...CounterStore) *RateLimiter {
+	if len(store) > 0 && store[0] != nil {
+		return &RateLimiter{store: store[0]}
 	}
-	go rl.cleanup()
-	return rl
+	mem := NewMemoryCounterStore()
+	return &RateLimiter{store: mem, owned: mem}
 }
 
-// Stop stops the cleanup goroutine.
+// Stop stops the cleanup goroutine of the built-in memory store. A store
+// passed to NewRateLimiter is left for its owner to close.
 func (rl *RateLimiter) Stop() {
-	close(rl.stopCh)
+	if rl.owned != nil {
+		rl.owned.Stop()
+	}
 }
 
-func (rl *RateLimiter) check(key string, limit int, window time.Duration) bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	now := time.Now()
-	entry, exists := rl.counters[key]
-
-	if !exists || now.After(entry.windowEnd) {
-		rl.counters[key] = &rateLimitEntry{
-			count:     1,
-			windowEnd: now.Add(window),
-		}
-		return true
+// take charges one request against key. A store failure fails open — an
+// unreachable Redis must not take the whole application down with it — but
+// never silently: failures are logged, throttled to once per minute.
+func (rl *RateLimiter) take(ctx context.Context, key string, strategy sentinel.RateLimitStrategy, limit sentinel.Limit) CounterResult {
+	res, err := rl.store.Take(ctx, key, strategy, limit)
+	if err != nil {
+		logCounterStoreError(err)
+		return CounterResult{Allowed: true, Remaining: limit.Requests}
 	}
-
-	entry.count++
-	return entry.count <= limit
+	return res
 }
 
-func (rl *RateLimiter) remaining(key string, limit int) int {
-	rl.mu.RLock()
-	defer rl.mu.RUnlock()
-
-	entry, exists := rl.counters[key]
-	if !exists {
-		return limit
-	}
-	if time.Now().After(entry.windowEnd) {
-		return limit
-	}
-	rem := limit - entry.count
-	if rem < 0 {
-		return 0
-	}
-	return rem
+// States returns all live counters from the store.
+func (rl *RateLimiter) States(ctx context.Context) ([]RateLimitState, error) {
+	return rl.store.States(ctx)
 }
 
-// RateLimitState represents the current state of a rate limit entry.
-type RateLimitState struct {
-	Key       string    `json:"key"`
-	Count     int       `json:"count"`
-	Limit     int       `json:"limit,omitempty"`
-	WindowEnd time.Time `json:"window_end"`
-	Remaining int       `json:"remaining,omitempty"`
+// Reset deletes a counter from the store, reporting whether it existed.
+func (rl *RateLimiter) Reset(ctx context.Context, key string) (bool, error) {
+	return rl.store.Reset(ctx, key)
 }
 
-// GetCurrentStates returns all active rate limit counters.
+// GetCurrentStates returns all active rate limit counters. Store errors are
+// logged and yield an empty list; use States to handle them yourself.
 func (rl *RateLimiter) GetCurrentStates() []RateLimitState {
-	rl.mu.RLock()
-	defer rl.mu.RUnlock()
-
-	now := time.Now()
-	var states []RateLimitState
-	for key, entry := range rl.counters {
-		if now.Before(entry.windowEnd) {
-			states = append(states, RateLimitState{
-				Key:       key,
-				Count:     entry.count,
-				WindowEnd: entry.windowEnd,
-			})
-		}
+	states, err := rl.States(context.Background())
+	if err != nil {
+		logCounterStoreError(err)
+		return nil
 	}
 	return states
 }
 
-// ResetKey removes a specific rate limit counter.
+// ResetKey removes a specific rate limit counter. Store errors are logged and
+// reported as "not found"; use Reset to handle them yourself.
 func (rl *RateLimiter) ResetKey(key string) bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-	_, exists := rl.counters[key]
-	delete(rl.counters, key)
-	return exists
+	existed, err := rl.Reset(context.Background(), key)
+	if err != nil {
+		logCounterStoreError(err)
+		return false
+	}
+	return existed
 }
 
-func (rl *RateLimiter) cleanup() {
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
+// lastCounterStoreErrLog throttles counter-store failure logging the same way
+// lastBlockLookupErrLog does for the WAF.
+var lastCounterStoreErrLog atomic.Int64
 
-	for {
-		select {
-		case <-rl.stopCh:
-			return
-		case <-ticker.C:
-			rl.mu.Lock()
-			now := time.Now()
-			for key, entry := range rl.counters {
-				if now.After(entry.windowEnd) {
-					delete(rl.counters, key)
-				}
-			}
-			rl.mu.Unlock()
-		}
+func logCounterStoreError(err error) {
+	now := time.Now().Unix()
+	last := lastCounterStoreErrLog.Load()
+	if now-last >= 60 && lastCounterStoreErrLog.CompareAndSwap(last, now) {
+		log.Printf("[sentinel] rate-limit counter store failed (failing open, throttled 1/min): %v", err)
 	}
 }
 
@@ -148,6 +113,19 @@ type routeLimit struct {
 	limit   sentinel.Limit
 }
 
+// compiledRouteLimits is ByRoute ready for per-request resolution.
+type compiledRouteLimits struct {
+	exact    map[string]sentinel.Limit
+	patterns []routeLimit
+}
+
+// SetRouteLimits replaces the per-route limits at runtime, e.g. after a
+// dashboard edit. RateLimitMiddleware seeds them from RateLimitConfig.ByRoute.
+func (rl *RateLimiter) SetRouteLimits(byRoute map[string]sentinel.Limit) {
+	exact, patterns := compileRouteLimits(byRoute)
+	rl.routes.Store(&compiledRouteLimits{exact: exact, patterns: patterns})
+}
+
 // compileRouteLimits splits ByRoute into an exact-lookup map and ordered
 // pattern matchers (longest pattern first, so more specific wins).
 func compileRouteLimits(byRoute map[string]sentinel.Limit) (map[string]sentinel.Limit, []routeLimit) {
@@ -170,6 +148,8 @@ func compileRouteLimits(byRoute map[string]sentinel.Limit) (map[string]sentinel.
 }
 
 // RateLimitMiddleware creates a Gin middleware for multi-dimensional rate limiting.
+// Per-route limits are read from the limiter, so RateLimiter.SetRouteLimits
+// takes effect on the next request.
 func RateLimitMiddleware(config sentinel.RateLimitConfig, limiter *RateLimiter, pipe *pipeline.Pipeline) gin.HandlerFunc {
 	// Wildcard entries in ExcludeRoutes are compiled to real matchers; plain
 	// entries keep their long-standing prefix-match behavior so existing
@@ -184,7 +164,7 @@ func RateLimitMiddleware(config sentinel.RateLimitConfig, limiter *RateLimiter,
 		}
 	}
 	excludeMatcher := NewRouteMatcher(excludeWildcards)
-	exactLimits, patternLimits := compileRouteLimits(config.ByRoute)
+	limiter.SetRouteLimits(config.ByRoute)
 
 	return func(c *gin.Context) {
 		if !config.Enabled {
@@ -207,57 +187,76 @@ func RateLimitMiddleware(config sentinel.RateLimitConfig, limiter *RateLimiter,
 			return
 		}
 
+		bot := RequestBot(c)
+		if bot != nil && slices.Contains(config.ExemptBots, bot.Verdict) {
+			c.Next()
+			return
+		}
+
 		// Per-route limits (highest priority): exact key first, then the most
 		// specific matching wildcard pattern.
-		if limit, key, ok := resolveRouteLimit(exactLimits, patternLimits, path); ok {
+		routes := limiter.routes.Load()
+		if limit, key, ok := resolveRouteLimit(routes.exact, routes.patterns, path); ok {
 			counterKey := "route:" + key + ":" + clientIP
-			if !limiter.check(counterKey, limit.Requests, limit.Window) {
-				emitRateLimitEvent(pipe, clientIP, path, c, "route")
-				retryAfter := int(limit.Window.Seconds())
-				c.Header("Retry-After", strconv.Itoa(retryAfter))
-				c.Header("X-RateLimit-Limit", strconv.Itoa(limit.Requests))
-				c.Header("X-RateLimit-Remaining", "0")
-				c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
-					"error": "Rate limit exceeded",
-					"code":  "RATE_LIMITED",
-				})
+			res := limiter.take(c.Request.Context(), counterKey, config.Strategy, limit)
+			if !res.Allowed {
+				rejectRateLimited(c, pipe, clientIP, path, "route", limit, res)
 				return
 			}
 		}
 
 		// IP rate limit
 		if config.ByIP != nil {
-			key := "ip:" + clientIP
-			if !limiter.check(key, config.ByIP.Requests, config.ByIP.Window) {
-				emitRateLimitEvent(pipe, clientIP, path, c, "ip")
-				retryAfter := int(config.ByIP.Window.Seconds())
-				c.Header("Retry-After", strconv.Itoa(retryAfter))
-				c.Header("X-RateLimit-Limit", strconv.Itoa(config.ByIP.Requests))
-				c.Header("X-RateLimit-Remaining", "0")
-				c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
-					"error": "Rate limit exceeded",
-					"code":  "RATE_LIMITED",
-				})
+			res := limiter.take(c.Request.Context(), "ip:"+clientIP, config.Strategy, *config.ByIP)
+			if !res.Allowed {
+				rejectRateLimited(c, pipe, clientIP, path, "ip", *config.ByIP, res)
 				return
 			}
-			rem := limiter.remaining(key, config.ByIP.Requests)
-			c.Header("X-RateLimit-Limit", strconv.Itoa(config.ByIP.Requests))
-			c.Header("X-RateLimit-Remaining", strconv.Itoa(rem))
+			setRateLimitHeaders(c, *config.ByIP, res)
 		}
 
 		// User rate limit
 		if config.ByUser != nil && config.UserIDExtractor != nil {
 			userID := config.UserIDExtractor(c)
 			if userID != "" {
-				key := "user:" + userID
-				if !limiter.check(key, config.ByUser.Requests, config.ByUser.Window) {
-					emitRateLimitEvent(pipe, clientIP, path, c, "user")
-					retryAfter := int(config.ByUser.Window.Seconds())
-					c.Header("Retry-After", strconv.Itoa(retryAfter))
-					c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
-						"error": "Rate limit exceeded",
-						"code":  "RATE_LIMITED",
-					})
+				res := limiter.take(c.Request.Context(), "user:"+userID, config.Strategy, *config.ByUser)
+				if !res.Allowed {
+					rejectRateLimited(c, pipe, clientIP, path, "user", *config.ByUser, res)
+					return
+				}
+			}
+		}
+
+		// Bot rate limit: a per-IP budget for clients of one verdict.
+		if bot != nil {
+			if limit, ok := config.ByBot[bot.Verdict]; ok {
+				res := limiter.take(c.Request.Context(), "bot:"+string(bot.Verdict)+":"+clientIP, config.Strategy, limit)
+				if !res.Allowed {
+					rejectRateLimited(c, pipe, clientIP, path, "bot", limit, res)
+					return
+				}
+			}
+		}
+
+		// Network rate limit: a per-IP budget for clients on one network
+		// type.
+		if network := RequestNetwork(c); network != nil {
+			if limit, ok := config.ByNetwork[network.Type]; ok {
+				res := limiter.take(c.Request.Context(), "net:"+string(network.Type)+":"+clientIP, config.Strategy, limit)
+				if !res.Allowed {
+					rejectRateLimited(c, pipe, clientIP, path, "network", limit, res)
+					return
+				}
+			}
+		}
+
+		// Fingerprint rate limit: one budget for every client sharing a TLS
+		// stack, however many IPs it spreads over.
+		if config.ByFingerprint != nil && !c.GetBool(fingerprintAllowedKey) {
+			if key := fingerprintKey(RequestFingerprint(c)); key != "" {
+				res := limiter.take(c.Request.Context(), "fp:"+key, config.Strategy, *config.ByFingerprint)
+				if !res.Allowed {
+					rejectRateLimited(c, pipe, clientIP, path, "fingerprint", *config.ByFingerprint, res)
 					return
 				}
 			}
@@ -265,15 +264,9 @@ func RateLimitMiddleware(config sentinel.RateLimitConfig, limiter *RateLimiter,
 
 		// Global rate limit
 		if config.Global != nil {
-			key := "global"
-			if !limiter.check(key, config.Global.Requests, config.Global.Window) {
-				emitRateLimitEvent(pipe, clientIP, path, c, "global")
-				retryAfter := int(config.Global.Window.Seconds())
-				c.Header("Retry-After", strconv.Itoa(retryAfter))
-				c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
-					"error": "Rate limit exceeded",
-					"code":  "RATE_LIMITED",
-				})
+			res := limiter.take(c.Request.Context(), "global", config.Strategy, *config.Global)
+			if !res.Allowed {
+				rejectRateLimited(c, pipe, clientIP, path, "global", *config.Global, res)
 				return
 			}
 		}
@@ -282,6 +275,39 @@ func RateLimitMiddleware(config sentinel.RateLimitConfig, limiter *RateLimiter,
 	}
 }
 
+// setRateLimitHeaders writes the X-RateLimit-* headers for a counter.
+// X-RateLimit-Reset is the number of seconds until the full budget is back,
+// which depends on the strategy: the window end for fixed_window, the end of
+// the trailing interval for sliding_window, a full refill for token_bucket.
+func setRateLimitHeaders(c *gin.Context, limit sentinel.Limit, res CounterResult) {
+	c.Header("X-RateLimit-Limit", strconv.Itoa(limit.Requests))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(res.ResetAfter)))
+}
+
+// rejectRateLimited emits the threat event and aborts with 429. Retry-After
+// is how long until the strategy would admit the next request — e.g. one
+// refill interval for token_bucket rather than the whole window.
+func rejectRateLimited(c *gin.Context, pipe *pipeline.Pipeline, clientIP, path, dimension string, limit sentinel.Limit, res CounterResult) {
+	emitRateLimitEvent(pipe, clientIP, path, c, dimension)
+	c.Header("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+	setRateLimitHeaders(c, limit, res)
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error": "Rate limit exceeded",
+		"code":  "RATE_LIMITED",
+	})
+}
+
+// ceilSeconds rounds d up to whole seconds, minimum 1 — clients treat
+// "Retry-After: 0" as "retry immediately".
+func ceilSeconds(d time.Duration) int {
+	secs := int(math.Ceil(d.Seconds()))
+	if secs < 1 {
+		return 1
+	}
+	return secs
+}
+
 // resolveRouteLimit returns the limit and counter-key component for a path:
 // the exact ByRoute entry when one exists, else the first (most specific)
 // matching wildcard pattern.
@@ -297,11 +323,23 @@ func resolveRouteLimit(exact map[string]sentinel.Limit, patterns []routeLimit, p
 	return sentinel.Limit{}, "", false
 }
 
+// fingerprintKey is the counter key component for a fingerprint: JA4,
+// falling back to JA3.
+func fingerprintKey(fp *sentinel.TLSFingerprint) string {
+	if fp == nil {
+		return ""
+	}
+	if fp.JA4 != "" {
+		return fp.JA4
+	}
+	return fp.JA3
+}
+
 func emitRateLimitEvent(pipe *pipeline.Pipeline, ip, path string, c *gin.Context, dimension string) {
 	if pipe == nil {
 		return
 	}
-	pipe.EmitThreat(&sentinel.ThreatEvent{
+	te := &sentinel.ThreatEvent{
 		ID:          uuid.New().String(),
 		Timestamp:   time.Now(),
 		IP:          ip,
@@ -316,5 +354,7 @@ func emitRateLimitEvent(pipe *pipeline.Pipeline, ip, path string, c *gin.Context
 		Evidence: []sentinel.Evidence{
 			{Pattern: "RateLimit_" + dimension, Matched: dimension + " limit exceeded", Location: "rate_limiter"},
 		},
-	})
+	}
+	applyClientSignals(c, te)
+	pipe.EmitThreat(te)
 }
@@ -0,0 +1,345 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+)
+
+// CounterStore holds rate-limit counters. RateLimiter keeps no state of its
+// own — every check is one atomic Take against the store — so pointing all
+// replicas at one shared store (see package middleware/redislimit) makes a
+// limit hold across the whole fleet instead of N times over behind a load
+// balancer with N pods.
+//
+// Implementations must make Take atomic per key: two concurrent Takes on the
+// same key must never both observe the last unit of budget.
+type CounterStore interface {
+	// Take charges one request against key under the given strategy and
+	// limit, and reports whether it is allowed.
+	Take(ctx context.Context, key string, strategy sentinel.RateLimitStrategy, limit sentinel.Limit) (CounterResult, error)
+
+	// States returns every counter that still carries state.
+	States(ctx context.Context) ([]RateLimitState, error)
+
+	// Reset deletes a counter, reporting whether it existed.
+	Reset(ctx context.Context, key string) (bool, error)
+}
+
+// CounterResult is the outcome of charging one request against a counter.
+type CounterResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // time until the next request would be allowed; zero when allowed
+	ResetAfter time.Duration // time until the counter is back to its full budget
+}
+
+// CounterState is the complete state of one counter. It is exported so that
+// stores keeping counters outside the process can load it, call Take, and
+// write it back under their own atomicity guarantee — every backend then
+// runs the exact same algorithm. Which fields are live depends on Strategy:
+//
+//   - fixed_window:   Count within [WindowEnd-Window, WindowEnd)
+//   - sliding_window: Count in the current epoch-aligned window plus
+//     PrevCount from the one before it, weighted by how much of the previous
+//     window still overlaps the trailing interval
+//   - token_bucket:   Tokens remaining as of LastRefill
+type CounterState struct {
+	Strategy sentinel.RateLimitStrategy `json:"strategy"`
+	Limit    sentinel.Limit             `json:"limit"`
+
+	Count       int       `json:"count,omitempty"`
+	PrevCount   int       `json:"prev_count,omitempty"`
+	WindowStart time.Time `json:"window_start,omitempty"`
+	WindowEnd   time.Time `json:"window_end,omitempty"`
+
+	Tokens     float64   `json:"tokens,omitempty"`
+	LastRefill time.Time `json:"last_refill,omitempty"`
+
+	// Expires is when the counter carries no more state than a fresh one
+	// would, so stores can drop it.
+	Expires time.Time `json:"expires"`
+}
+
+// Take charges one request at time now. An empty strategy means
+// fixed_window — the behavior before strategies were honored, kept for
+// callers that build RateLimitConfig by hand without ApplyDefaults. A
+// non-positive window also degrades to fixed_window, whose counter simply
+// resets on every request (ValidateConfig reports that case). A counter
+// whose strategy or limit changed since it was created (e.g. a ByRoute edit
+// from the dashboard) restarts under the new parameters.
+func (s *CounterState) Take(now time.Time, strategy sentinel.RateLimitStrategy, limit sentinel.Limit) CounterResult {
+	if strategy == "" || limit.Window <= 0 {
+		strategy = sentinel.FixedWindow
+	}
+	if s.Strategy != strategy || s.Limit != limit {
+		*s = CounterState{Strategy: strategy, Limit: limit}
+	}
+
+	switch strategy {
+	case sentinel.SlidingWindow:
+		return s.takeSliding(now)
+	case sentinel.TokenBucket:
+		return s.takeToken(now)
+	default:
+		return s.takeFixed(now)
+	}
+}
+
+// Live reports whether the counter still differs from a fresh one at now.
+func (s *CounterState) Live(now time.Time) bool {
+	return now.Before(s.Expires)
+}
+
+// takeFixed counts requests in a window that opens on the first request.
+// Denied requests still count, so a client hammering through its limit
+// stays limited until the window closes.
+func (s *CounterState) takeFixed(now time.Time) CounterResult {
+	if s.WindowEnd.IsZero() || !now.Before(s.WindowEnd) {
+		s.Count = 0
+		s.WindowEnd = now.Add(s.Limit.Window)
+	}
+	s.Count++
+	s.Expires = s.WindowEnd
+
+	res := CounterResult{
+		Allowed:    s.Count <= s.Limit.Requests,
+		Remaining:  clampRemaining(s.Limit.Requests - s.Count),
+		ResetAfter: s.WindowEnd.Sub(now),
+	}
+	if !res.Allowed {
+		res.RetryAfter = s.WindowEnd.Sub(now)
+	}
+	return res
+}
+
+// takeSliding implements the sliding-window counter: the previous window's
+// count is weighted by its overlap with the trailing interval, so a burst at
+// the end of one window still counts against the start of the next. That
+// closes the 2x-at-the-boundary hole of a fixed window at O(1) memory per key.
+// Denied requests are not counted.
+func (s *CounterState) takeSliding(now time.Time) CounterResult {
+	window := s.Limit.Window
+	start := now.Truncate(window)
+	if !s.WindowStart.Equal(start) {
+		if !s.WindowStart.IsZero() && start.Sub(s.WindowStart) == window {
+			s.PrevCount = s.Count
+		} else {
+			s.PrevCount = 0
+		}
+		s.Count = 0
+		s.WindowStart = start
+	}
+	end := start.Add(window)
+	s.WindowEnd = end
+	s.Expires = end.Add(window)
+
+	weight := 1 - float64(now.Sub(start))/float64(window)
+	estimate := float64(s.PrevCount)*weight + float64(s.Count)
+	limit := float64(s.Limit.Requests)
+
+	if estimate+1 > limit {
+		res := CounterResult{ResetAfter: s.Expires.Sub(now)}
+		if s.PrevCount > 0 && float64(s.Count)+1 <= limit {
+			// Wait for the previous window's weight to decay enough.
+			need := 1 - (limit-float64(s.Count)-1)/float64(s.PrevCount)
+			res.RetryAfter = time.Duration(need*float64(window)) - now.Sub(start)
+		} else {
+			res.RetryAfter = end.Sub(now)
+		}
+		return res
+	}
+
+	s.Count++
+	return CounterResult{
+		Allowed:    true,
+		Remaining:  clampRemaining(int(limit - estimate - 1)),
+		ResetAfter: s.Expires.Sub(now),
+	}
+}
+
+// takeToken implements a token bucket holding up to Requests tokens that
+// refills continuously at Requests per Window. Bursts up to the bucket size
+// are allowed; sustained traffic is held to the refill rate. Denied requests
+// do not consume a token.
+func (s *CounterState) takeToken(now time.Time) CounterResult {
+	capacity := float64(s.Limit.Requests)
+	rate := capacity / float64(s.Limit.Window) // tokens per nanosecond
+	if s.LastRefill.IsZero() {
+		s.Tokens = capacity
+	} else if elapsed := now.Sub(s.LastRefill); elapsed > 0 {
+		s.Tokens = math.Min(capacity, s.Tokens+float64(elapsed)*rate)
+	}
+	s.LastRefill = now
+
+	res := CounterResult{}
+	if s.Tokens >= 1 {
+		s.Tokens--
+		res.Allowed = true
+	} else if rate > 0 {
+		res.RetryAfter = time.Duration((1 - s.Tokens) / rate)
+	} else {
+		res.RetryAfter = s.Limit.Window
+	}
+	if rate > 0 {
+		res.ResetAfter = time.Duration((capacity - s.Tokens) / rate)
+	}
+	res.Remaining = clampRemaining(int(s.Tokens))
+	s.WindowEnd = now.Add(res.ResetAfter)
+	s.Expires = s.WindowEnd
+	return res
+}
+
+// Snapshot reports the counter's state as of now without charging a request.
+func (s *CounterState) Snapshot(key string, now time.Time) RateLimitState {
+	limit := s.Limit.Requests
+	var used int
+	var reset time.Time
+	switch s.Strategy {
+	case sentinel.SlidingWindow:
+		weight := 0.0
+		count, prev := 0, s.PrevCount
+		switch {
+		case now.Before(s.WindowEnd):
+			count = s.Count
+			weight = 1 - float64(now.Sub(s.WindowStart))/float64(s.Limit.Window)
+		case now.Before(s.Expires):
+			prev = s.Count
+			weight = 1 - float64(now.Sub(s.WindowEnd))/float64(s.Limit.Window)
+		}
+		used = int(math.Ceil(float64(prev)*weight)) + count
+		reset = s.Expires
+	case sentinel.TokenBucket:
+		capacity := float64(limit)
+		tokens := s.Tokens
+		if elapsed := now.Sub(s.LastRefill); elapsed > 0 && s.Limit.Window > 0 {
+			tokens = math.Min(capacity, tokens+float64(elapsed)*capacity/float64(s.Limit.Window))
+		}
+		used = limit - int(tokens)
+		reset = s.Expires
+	default:
+		used = s.Count
+		reset = s.WindowEnd
+	}
+	return RateLimitState{
+		Key:       key,
+		Strategy:  s.Strategy,
+		Count:     used,
+		Limit:     limit,
+		WindowEnd: reset,
+		Remaining: clampRemaining(limit - used),
+	}
+}
+
+func clampRemaining(n int) int {
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// RateLimitState represents the current state of a rate limit entry.
+// Count is the budget consumed under the counter's strategy — requests in
+// the window for fixed_window, the weighted estimate for sliding_window, and
+// tokens drawn for token_bucket. WindowEnd is when the budget is fully
+// restored.
+type RateLimitState struct {
+	Key       string                     `json:"key"`
+	Strategy  sentinel.RateLimitStrategy `json:"strategy"`
+	Count     int                        `json:"count"`
+	Limit     int                        `json:"limit,omitempty"`
+	WindowEnd time.Time                  `json:"window_end"`
+	Remaining int                        `json:"remaining"`
+}
+
+// Ensure MemoryCounterStore implements CounterStore.
+var _ CounterStore = (*MemoryCounterStore)(nil)
+
+// MemoryCounterStore keeps counters in an in-process map. It is the default
+// store: correct for a single replica, but each replica behind a load
+// balancer enforces its own copy of every limit.
+type MemoryCounterStore struct {
+	mu       sync.Mutex
+	counters map[string]*CounterState
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	now      func() time.Time
+}
+
+// NewMemoryCounterStore creates an in-process counter store with a
+// background goroutine that drops expired counters every 30 seconds.
+func NewMemoryCounterStore() *MemoryCounterStore {
+	m := &MemoryCounterStore{
+		counters: make(map[string]*CounterState),
+		stopCh:   make(chan struct{}),
+		now:      time.Now,
+	}
+	go m.cleanup()
+	return m
+}
+
+// Stop stops the cleanup goroutine.
+func (m *MemoryCounterStore) Stop() {
+	m.stopOnce.Do(func() { close(m.stopCh) })
+}
+
+// Take charges one request against key.
+func (m *MemoryCounterStore) Take(_ context.Context, key string, strategy sentinel.RateLimitStrategy, limit sentinel.Limit) (CounterResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st, ok := m.counters[key]
+	if !ok {
+		st = &CounterState{}
+		m.counters[key] = st
+	}
+	return st.Take(m.now(), strategy, limit), nil
+}
+
+// States returns every live counter.
+func (m *MemoryCounterStore) States(_ context.Context) ([]RateLimitState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	var states []RateLimitState
+	for key, st := range m.counters {
+		if st.Live(now) {
+			states = append(states, st.Snapshot(key, now))
+		}
+	}
+	return states, nil
+}
+
+// Reset deletes a counter.
+func (m *MemoryCounterStore) Reset(_ context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, exists := m.counters[key]
+	delete(m.counters, key)
+	return exists, nil
+}
+
+func (m *MemoryCounterStore) cleanup() {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+			m.mu.Lock()
+			now := m.now()
+			for key, st := range m.counters {
+				if !st.Live(now) {
+					delete(m.counters, key)
+				}
+			}
+			m.mu.Unlock()
+		}
+	}
+}
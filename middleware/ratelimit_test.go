@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"sync"
@@ -166,3 +167,164 @@ func TestRateLimitPerRoute(t *testing.T) {
 		t.Errorf("products: expected 200, got %d", w.Code)
 	}
 }
+
+// fakeClock lets strategy tests step time deterministically.
+type fakeClock struct{ t time.Time }
+
+func (f *fakeClock) now() time.Time          { return f.t }
+func (f *fakeClock) advance(d time.Duration) { f.t = f.t.Add(d) }
+
+func newTestLimiter(t *testing.T) (*RateLimiter, *fakeClock) {
+	t.Helper()
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	mem := NewMemoryCounterStore()
+	mem.now = clock.now
+	t.Cleanup(mem.Stop)
+	return NewRateLimiter(mem), clock
+}
+
+func countAllowed(rl *RateLimiter, key string, strategy sentinel.RateLimitStrategy, limit sentinel.Limit, n int) int {
+	allowed := 0
+	for i := 0; i < n; i++ {
+		if rl.take(context.Background(), key, strategy, limit).Allowed {
+			allowed++
+		}
+	}
+	return allowed
+}
+
+// A fixed window lets a client spend its budget at the end of one window and
+// again at the start of the next — 2x the limit in a sliding interval. The
+// sliding window must not.
+func TestRateLimitSlidingWindowBoundaryBurst(t *testing.T) {
+	limit := sentinel.Limit{Requests: 10, Window: time.Minute}
+
+	// Spend 1 at the start of the window, the rest at its very end, then
+	// burst again two seconds later — just past the fixed boundary.
+	fixed, fclock := newTestLimiter(t)
+	got := countAllowed(fixed, "k", sentinel.FixedWindow, limit, 1)
+	fclock.advance(59 * time.Second)
+	got += countAllowed(fixed, "k", sentinel.FixedWindow, limit, 10)
+	fclock.advance(2 * time.Second)
+	got += countAllowed(fixed, "k", sentinel.FixedWindow, limit, 10)
+	if got != 20 {
+		t.Fatalf("fixed window: expected the boundary burst to let 20 through, got %d", got)
+	}
+
+	sliding, sclock := newTestLimiter(t)
+	got = countAllowed(sliding, "k", sentinel.SlidingWindow, limit, 1)
+	sclock.advance(59 * time.Second)
+	got += countAllowed(sliding, "k", sentinel.SlidingWindow, limit, 10)
+	sclock.advance(2 * time.Second)
+	got += countAllowed(sliding, "k", sentinel.SlidingWindow, limit, 10)
+	if got > 11 {
+		t.Fatalf("sliding window: boundary burst let %d through, want at most 11", got)
+	}
+
+	// Once the previous window has fully decayed the whole budget is back.
+	sclock.advance(time.Minute)
+	if n := countAllowed(sliding, "k", sentinel.SlidingWindow, limit, 10); n != 10 {
+		t.Fatalf("sliding window: expected full budget after decay, got %d", n)
+	}
+}
+
+func TestRateLimitTokenBucketRefill(t *testing.T) {
+	rl, clock := newTestLimiter(t)
+	limit := sentinel.Limit{Requests: 6, Window: time.Minute} // one token per 10s
+
+	if n := countAllowed(rl, "k", sentinel.TokenBucket, limit, 8); n != 6 {
+		t.Fatalf("expected the full bucket of 6 as a burst, got %d", n)
+	}
+
+	res := rl.take(context.Background(), "k", sentinel.TokenBucket, limit)
+	if res.Allowed {
+		t.Fatal("expected an empty bucket to deny")
+	}
+	if res.RetryAfter <= 0 || res.RetryAfter > 10*time.Second {
+		t.Fatalf("expected retryAfter within one refill interval, got %v", res.RetryAfter)
+	}
+
+	clock.advance(10 * time.Second)
+	if n := countAllowed(rl, "k", sentinel.TokenBucket, limit, 3); n != 1 {
+		t.Fatalf("expected exactly one refilled token after 10s, got %d", n)
+	}
+}
+
+func TestRateLimitStrategyChangeResetsCounter(t *testing.T) {
+	rl, _ := newTestLimiter(t)
+	limit := sentinel.Limit{Requests: 2, Window: time.Minute}
+
+	countAllowed(rl, "k", sentinel.FixedWindow, limit, 5)
+	if n := countAllowed(rl, "k", sentinel.TokenBucket, limit, 5); n != 2 {
+		t.Fatalf("expected a fresh token bucket after switching strategy, got %d allowed", n)
+	}
+}
+
+func TestRateLimitCurrentStatesReflectStrategy(t *testing.T) {
+	rl, clock := newTestLimiter(t)
+	limit := sentinel.Limit{Requests: 10, Window: time.Minute}
+
+	countAllowed(rl, "tb", sentinel.TokenBucket, limit, 4)
+	countAllowed(rl, "sw", sentinel.SlidingWindow, limit, 4)
+	clock.advance(15 * time.Second)
+
+	states := make(map[string]RateLimitState)
+	for _, st := range rl.GetCurrentStates() {
+		states[st.Key] = st
+	}
+
+	tb, ok := states["tb"]
+	if !ok || tb.Strategy != sentinel.TokenBucket {
+		t.Fatalf("missing token bucket state: %+v", states)
+	}
+	// 4 drawn, 2.5 refilled in 15s → 8 whole tokens left.
+	if tb.Remaining != 8 || tb.Count != 2 {
+		t.Errorf("token bucket: expected count=2 remaining=8, got count=%d remaining=%d", tb.Count, tb.Remaining)
+	}
+
+	sw, ok := states["sw"]
+	if !ok || sw.Strategy != sentinel.SlidingWindow || sw.Limit != 10 {
+		t.Fatalf("missing sliding window state: %+v", states)
+	}
+	if sw.Count+sw.Remaining != 10 {
+		t.Errorf("sliding window: count %d + remaining %d != limit", sw.Count, sw.Remaining)
+	}
+}
+
+func TestRateLimitMiddlewareTokenBucketHeaders(t *testing.T) {
+	limiter := NewRateLimiter()
+	defer limiter.Stop()
+
+	r := gin.New()
+	r.Use(RateLimitMiddleware(sentinel.RateLimitConfig{
+		Enabled:  true,
+		Strategy: sentinel.TokenBucket,
+		ByIP:     &sentinel.Limit{Requests: 3, Window: time.Minute},
+	}, limiter, nil))
+	r.GET("/api/test", func(c *gin.Context) {
+		c.JSON(200, gin.H{"ok": true})
+	})
+
+	var w *httptest.ResponseRecorder
+	for i := 0; i < 3; i++ {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/api/test", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i+1, w.Code)
+		}
+	}
+	if got := w.Header().Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("expected X-RateLimit-Remaining 0 after draining the bucket, got %q", got)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/test", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	// One token refills every 20s, so Retry-After must be one refill
+	// interval, not the whole 60s window.
+	if got := w.Header().Get("Retry-After"); got != "20" {
+		t.Errorf("expected Retry-After 20, got %q", got)
+	}
+}
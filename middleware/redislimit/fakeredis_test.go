@@ -0,0 +1,260 @@
+package redislimit_test
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is an in-process RESP2 server implementing just the commands
+// the counter store uses, with real WATCH/MULTI/EXEC semantics: EXEC aborts
+// when a watched key was written after WATCH.
+type fakeRedis struct {
+	ln       net.Listener
+	password string
+
+	mu       sync.Mutex
+	values   map[string]string
+	expires  map[string]time.Time
+	versions map[string]uint64
+}
+
+type fakeEntry struct {
+	key     string
+	version uint64
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	f := &fakeRedis{
+		ln:       ln,
+		password: password,
+		values:   make(map[string]string),
+		expires:  make(map[string]time.Time),
+		versions: make(map[string]uint64),
+	}
+	go f.serve()
+	t.Cleanup(func() { ln.Close() })
+	return f
+}
+
+func (f *fakeRedis) Addr() string { return f.ln.Addr().String() }
+
+func (f *fakeRedis) serve() {
+	for {
+		nc, err := f.ln.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(nc)
+	}
+}
+
+func (f *fakeRedis) handle(nc net.Conn) {
+	defer nc.Close()
+	br := bufio.NewReader(nc)
+	bw := bufio.NewWriter(nc)
+
+	authed := f.password == ""
+	var watched []fakeEntry
+	var queued [][]string
+	inMulti := false
+
+	for {
+		args, err := readCommand(br)
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(args[0])
+
+		switch {
+		case cmd == "AUTH":
+			if args[len(args)-1] == f.password {
+				authed = true
+				writeSimple(bw, "OK")
+			} else {
+				writeError(bw, "WRONGPASS invalid username-password pair")
+			}
+		case !authed:
+			writeError(bw, "NOAUTH Authentication required.")
+		case cmd == "MULTI":
+			inMulti = true
+			queued = nil
+			writeSimple(bw, "OK")
+		case cmd == "EXEC":
+			f.mu.Lock()
+			aborted := false
+			for _, w := range watched {
+				if f.versions[w.key] != w.version {
+					aborted = true
+				}
+			}
+			if aborted {
+				bw.WriteString("*-1\r\n")
+			} else {
+				bw.WriteString("*" + strconv.Itoa(len(queued)) + "\r\n")
+				for _, q := range queued {
+					f.exec(bw, q)
+				}
+			}
+			f.mu.Unlock()
+			inMulti, queued, watched = false, nil, nil
+		case cmd == "DISCARD":
+			inMulti, queued, watched = false, nil, nil
+			writeSimple(bw, "OK")
+		case inMulti:
+			queued = append(queued, args)
+			writeSimple(bw, "QUEUED")
+		case cmd == "WATCH":
+			f.mu.Lock()
+			for _, k := range args[1:] {
+				f.expire(k)
+				watched = append(watched, fakeEntry{key: k, version: f.versions[k]})
+			}
+			f.mu.Unlock()
+			writeSimple(bw, "OK")
+		case cmd == "UNWATCH":
+			watched = nil
+			writeSimple(bw, "OK")
+		default:
+			f.mu.Lock()
+			f.exec(bw, args)
+			f.mu.Unlock()
+		}
+		if br.Buffered() == 0 {
+			bw.Flush()
+		}
+	}
+}
+
+// exec runs one non-transactional command; f.mu must be held.
+func (f *fakeRedis) exec(bw *bufio.Writer, args []string) {
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		writeSimple(bw, "PONG")
+	case "SELECT":
+		writeSimple(bw, "OK")
+	case "TIME":
+		now := time.Now()
+		bw.WriteString("*2\r\n")
+		writeBulk(bw, strconv.FormatInt(now.Unix(), 10))
+		writeBulk(bw, strconv.FormatInt(int64(now.Nanosecond()/1000), 10))
+	case "GET":
+		f.expire(args[1])
+		if v, ok := f.values[args[1]]; ok {
+			writeBulk(bw, v)
+		} else {
+			bw.WriteString("$-1\r\n")
+		}
+	case "MGET":
+		bw.WriteString("*" + strconv.Itoa(len(args)-1) + "\r\n")
+		for _, k := range args[1:] {
+			f.expire(k)
+			if v, ok := f.values[k]; ok {
+				writeBulk(bw, v)
+			} else {
+				bw.WriteString("$-1\r\n")
+			}
+		}
+	case "SET":
+		k := args[1]
+		f.values[k] = args[2]
+		delete(f.expires, k)
+		if len(args) == 5 && strings.ToUpper(args[3]) == "PX" {
+			ms, _ := strconv.Atoi(args[4])
+			f.expires[k] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		f.versions[k]++
+		writeSimple(bw, "OK")
+	case "DEL":
+		n := 0
+		for _, k := range args[1:] {
+			f.expire(k)
+			if _, ok := f.values[k]; ok {
+				delete(f.values, k)
+				delete(f.expires, k)
+				f.versions[k]++
+				n++
+			}
+		}
+		bw.WriteString(":" + strconv.Itoa(n) + "\r\n")
+	case "SCAN":
+		// Single-pass SCAN: everything matching in one batch, cursor 0.
+		pattern := "*"
+		for i := 2; i+1 < len(args); i += 2 {
+			if strings.ToUpper(args[i]) == "MATCH" {
+				pattern = args[i+1]
+			}
+		}
+		var keys []string
+		for k := range f.values {
+			f.expire(k)
+			if _, ok := f.values[k]; !ok {
+				continue
+			}
+			if ok, _ := path.Match(pattern, k); ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		bw.WriteString("*2\r\n")
+		writeBulk(bw, "0")
+		bw.WriteString("*" + strconv.Itoa(len(keys)) + "\r\n")
+		for _, k := range keys {
+			writeBulk(bw, k)
+		}
+	default:
+		writeError(bw, "ERR unknown command '"+args[0]+"'")
+	}
+}
+
+// expire lazily drops k if its TTL has passed; f.mu must be held.
+func (f *fakeRedis) expire(k string) {
+	if at, ok := f.expires[k]; ok && !time.Now().Before(at) {
+		delete(f.values, k)
+		delete(f.expires, k)
+		f.versions[k]++
+	}
+}
+
+func readCommand(br *bufio.Reader) ([]string, error) {
+	line, err := br.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil || line[0] != '*' {
+		return nil, io.ErrUnexpectedEOF
+	}
+	args := make([]string, n)
+	for i := range args {
+		hdr, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(hdr[1:]))
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func writeSimple(bw *bufio.Writer, s string) { bw.WriteString("+" + s + "\r\n") }
+func writeError(bw *bufio.Writer, s string)  { bw.WriteString("-" + s + "\r\n") }
+func writeBulk(bw *bufio.Writer, s string) {
+	bw.WriteString("$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n")
+}
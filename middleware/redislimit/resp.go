@@ -0,0 +1,168 @@
+package redislimit
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// redisError is an error reply ("-ERR ...") from the server. It leaves the
+// connection usable, unlike an I/O or protocol error.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// errProtocol is returned for replies that are not valid RESP2.
+var errProtocol = errors.New("redis: protocol error")
+
+// conn is one RESP2 connection. Commands are buffered with send and written
+// with flush, so several commands go out in one round trip (pipelining).
+type conn struct {
+	nc net.Conn
+	br *bufio.Reader
+	bw *bufio.Writer
+}
+
+func dial(ctx context.Context, opts Options) (*conn, error) {
+	d := net.Dialer{Timeout: opts.Timeout}
+	var nc net.Conn
+	var err error
+	if opts.TLSConfig != nil {
+		td := tls.Dialer{NetDialer: &d, Config: opts.TLSConfig}
+		nc, err = td.DialContext(ctx, "tcp", opts.Addr)
+	} else {
+		nc, err = d.DialContext(ctx, "tcp", opts.Addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis: dial %s: %w", opts.Addr, err)
+	}
+	c := &conn{nc: nc, br: bufio.NewReader(nc), bw: bufio.NewWriter(nc)}
+	c.setDeadline(ctx, opts.Timeout)
+
+	if opts.Password != "" {
+		if opts.Username != "" {
+			c.send("AUTH", opts.Username, opts.Password)
+		} else {
+			c.send("AUTH", opts.Password)
+		}
+		if _, err := c.roundTrip(); err != nil {
+			c.close()
+			return nil, fmt.Errorf("redis: auth: %w", err)
+		}
+	}
+	if opts.DB != 0 {
+		c.send("SELECT", strconv.Itoa(opts.DB))
+		if _, err := c.roundTrip(); err != nil {
+			c.close()
+			return nil, fmt.Errorf("redis: select %d: %w", opts.DB, err)
+		}
+	}
+	return c, nil
+}
+
+// setDeadline bounds the next exchange by the context deadline, or timeout
+// when the context has none — a rate-limit check sits on the request hot
+// path and must never hang on a stalled server.
+func (c *conn) setDeadline(ctx context.Context, timeout time.Duration) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(timeout)
+	}
+	c.nc.SetDeadline(deadline)
+}
+
+func (c *conn) send(args ...string) {
+	c.bw.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, a := range args {
+		c.bw.WriteString("$" + strconv.Itoa(len(a)) + "\r\n")
+		c.bw.WriteString(a)
+		c.bw.WriteString("\r\n")
+	}
+}
+
+func (c *conn) flush() error {
+	return c.bw.Flush()
+}
+
+// roundTrip flushes a single buffered command and reads its reply.
+func (c *conn) roundTrip() (any, error) {
+	if err := c.flush(); err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+// read parses one reply. Simple strings come back as string, integers as
+// int64, bulk strings as []byte, arrays as []any; null bulk strings and null
+// arrays come back as nil. Error replies are returned as redisError.
+func (c *conn) read() (any, error) {
+	line, err := c.br.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errProtocol
+	}
+	body := line[1 : len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		n, err := strconv.ParseInt(body, 10, 64)
+		if err != nil {
+			return nil, errProtocol
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, errProtocol
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.br, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, errProtocol
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]any, n)
+		for i := range items {
+			// An error element (e.g. one failed command inside EXEC) is kept
+			// in place rather than aborting the whole array.
+			v, err := c.read()
+			var re redisError
+			if errors.As(err, &re) {
+				items[i] = re
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			items[i] = v
+		}
+		return items, nil
+	}
+	return nil, errProtocol
+}
+
+func (c *conn) close() error {
+	return c.nc.Close()
+}
@@ -0,0 +1,373 @@
+// Package redislimit provides a Redis-backed middleware.CounterStore so rate
+// limits hold across every replica of an application instead of N times over
+// behind a load balancer with N pods.
+//
+// It speaks plain RESP2 over TCP (optionally TLS) with no client library, so
+// it works against any RESP-compatible server — Redis, Valkey, KeyDB,
+// Dragonfly. Each counter is one string key holding a JSON-encoded
+// middleware.CounterState; Take updates it with an optimistic
+// WATCH/MULTI/EXEC transaction and the server's own TIME, so every replica
+// runs the same algorithm as the in-process store against one clock. No Lua
+// scripting is required.
+//
+// Counters on a single very hot key (RateLimitConfig.Global across many
+// replicas) contend on that key; Take retries a conflicting transaction with
+// jittered backoff up to Options.MaxRetries times and then reports
+// ErrContention, which the RateLimiter treats like any store failure — it
+// fails open and logs.
+//
+//	store, err := redislimit.New(redislimit.Options{Addr: "redis:6379"})
+//	limiter := middleware.NewRateLimiter(store)
+package redislimit
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/middleware"
+)
+
+// Ensure Store implements middleware.CounterStore.
+var _ middleware.CounterStore = (*Store)(nil)
+
+// ErrContention is returned by Take when the optimistic transaction kept
+// losing to concurrent writers of the same key.
+var ErrContention = errors.New("redislimit: too much contention on counter key")
+
+// Options configures the Redis counter store.
+type Options struct {
+	// Addr is the server's host:port.
+	Addr string
+	// Username is the ACL user (Redis 6+). Leave empty to authenticate
+	// with Password alone.
+	Username string
+	// Password, when set, is sent with AUTH on every new connection.
+	Password string
+	// DB selects a logical database. Default: 0.
+	DB int
+	// KeyPrefix namespaces counter keys. Default: "sentinel:rl:".
+	KeyPrefix string
+	// TLSConfig, when non-nil, dials with TLS.
+	TLSConfig *tls.Config
+	// PoolSize caps idle connections kept for reuse. Default: 16.
+	PoolSize int
+	// Timeout bounds dialing and each exchange when the request context has
+	// no deadline. Default: 1s.
+	Timeout time.Duration
+	// MaxRetries caps optimistic-transaction retries per Take. Default: 32.
+	MaxRetries int
+}
+
+// Store is a middleware.CounterStore backed by a Redis-protocol server.
+type Store struct {
+	opts Options
+	pool chan *conn
+}
+
+// New connects to the server and verifies it answers PING, so a bad address
+// or password fails at Mount instead of on the first request.
+func New(opts Options) (*Store, error) {
+	if opts.Addr == "" {
+		return nil, fmt.Errorf("redislimit: empty Addr")
+	}
+	if opts.KeyPrefix == "" {
+		opts.KeyPrefix = "sentinel:rl:"
+	}
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 16
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second
+	}
+	if opts.MaxRetries <= 0 {
+		opts.MaxRetries = 32
+	}
+
+	s := &Store{opts: opts, pool: make(chan *conn, opts.PoolSize)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+	c, err := s.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.send("PING")
+	if _, err := c.roundTrip(); err != nil {
+		c.close()
+		return nil, fmt.Errorf("redislimit: ping: %w", err)
+	}
+	s.put(c)
+	return s, nil
+}
+
+// Take charges one request against key atomically across all replicas.
+func (s *Store) Take(ctx context.Context, key string, strategy sentinel.RateLimitStrategy, limit sentinel.Limit) (middleware.CounterResult, error) {
+	k := s.opts.KeyPrefix + key
+	for attempt := 0; attempt < s.opts.MaxRetries; attempt++ {
+		c, err := s.get(ctx)
+		if err != nil {
+			return middleware.CounterResult{}, err
+		}
+		res, committed, err := s.tryTake(c, k, strategy, limit)
+		if err != nil {
+			c.close()
+			return middleware.CounterResult{}, err
+		}
+		s.put(c)
+		if committed {
+			return res, nil
+		}
+		if err := backoff(ctx, attempt); err != nil {
+			return middleware.CounterResult{}, err
+		}
+	}
+	return middleware.CounterResult{}, ErrContention
+}
+
+// backoff sleeps a random interval that grows with attempt (capped at 5ms),
+// so writers that just collided on a key don't collide again in lockstep.
+func backoff(ctx context.Context, attempt int) error {
+	ceiling := time.Duration(attempt+1) * 100 * time.Microsecond
+	if ceiling > 5*time.Millisecond {
+		ceiling = 5 * time.Millisecond
+	}
+	t := time.NewTimer(rand.N(ceiling))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// tryTake runs one WATCH / GET / TIME, computes the new state locally, and
+// commits it with MULTI / SET / EXEC. committed is false when another writer
+// touched the key in between and EXEC was aborted.
+func (s *Store) tryTake(c *conn, k string, strategy sentinel.RateLimitStrategy, limit sentinel.Limit) (middleware.CounterResult, bool, error) {
+	c.send("WATCH", k)
+	c.send("GET", k)
+	c.send("TIME")
+	if err := c.flush(); err != nil {
+		return middleware.CounterResult{}, false, err
+	}
+	if _, err := c.read(); err != nil {
+		return middleware.CounterResult{}, false, err
+	}
+	raw, err := c.read()
+	if err != nil {
+		return middleware.CounterResult{}, false, err
+	}
+	now, err := readTime(c)
+	if err != nil {
+		return middleware.CounterResult{}, false, err
+	}
+
+	var st middleware.CounterState
+	if b, ok := raw.([]byte); ok {
+		// A corrupt value is treated as a fresh counter rather than an
+		// error: it is overwritten below either way.
+		_ = json.Unmarshal(b, &st)
+	}
+	res := st.Take(now, strategy, limit)
+
+	ttl := st.Expires.Sub(now)
+	if ttl < time.Millisecond {
+		ttl = time.Millisecond
+	}
+	data, err := json.Marshal(&st)
+	if err != nil {
+		return middleware.CounterResult{}, false, err
+	}
+
+	c.send("MULTI")
+	c.send("SET", k, string(data), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	c.send("EXEC")
+	if err := c.flush(); err != nil {
+		return middleware.CounterResult{}, false, err
+	}
+	if _, err := c.read(); err != nil { // OK
+		return middleware.CounterResult{}, false, err
+	}
+	if _, err := c.read(); err != nil { // QUEUED
+		return middleware.CounterResult{}, false, err
+	}
+	exec, err := c.read()
+	if err != nil {
+		return middleware.CounterResult{}, false, err
+	}
+	if exec == nil {
+		return middleware.CounterResult{}, false, nil
+	}
+	return res, true, nil
+}
+
+// States returns every live counter under KeyPrefix. It walks the keyspace
+// with SCAN, so it is meant for the dashboard, not the request path.
+func (s *Store) States(ctx context.Context) ([]middleware.RateLimitState, error) {
+	c, err := s.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	states, err := s.states(c)
+	if err != nil {
+		c.close()
+		return nil, err
+	}
+	s.put(c)
+	return states, nil
+}
+
+func (s *Store) states(c *conn) ([]middleware.RateLimitState, error) {
+	var keys []string
+	cursor := "0"
+	for {
+		c.send("SCAN", cursor, "MATCH", escapeGlob(s.opts.KeyPrefix)+"*", "COUNT", "100")
+		reply, err := c.roundTrip()
+		if err != nil {
+			return nil, err
+		}
+		parts, ok := reply.([]any)
+		if !ok || len(parts) != 2 {
+			return nil, errProtocol
+		}
+		next, _ := parts[0].([]byte)
+		batch, _ := parts[1].([]any)
+		for _, k := range batch {
+			if b, ok := k.([]byte); ok {
+				keys = append(keys, string(b))
+			}
+		}
+		cursor = string(next)
+		if cursor == "0" || cursor == "" {
+			break
+		}
+	}
+
+	c.send("TIME")
+	if err := c.flush(); err != nil {
+		return nil, err
+	}
+	now, err := readTime(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var states []middleware.RateLimitState
+	for start := 0; start < len(keys); start += 100 {
+		end := start + 100
+		if end > len(keys) {
+			end = len(keys)
+		}
+		c.send(append([]string{"MGET"}, keys[start:end]...)...)
+		reply, err := c.roundTrip()
+		if err != nil {
+			return nil, err
+		}
+		values, _ := reply.([]any)
+		for i, v := range values {
+			b, ok := v.([]byte)
+			if !ok {
+				continue // expired between SCAN and MGET
+			}
+			var st middleware.CounterState
+			if json.Unmarshal(b, &st) != nil || !st.Live(now) {
+				continue
+			}
+			key := strings.TrimPrefix(keys[start+i], s.opts.KeyPrefix)
+			states = append(states, st.Snapshot(key, now))
+		}
+	}
+	return states, nil
+}
+
+// Reset deletes a counter for every replica at once.
+func (s *Store) Reset(ctx context.Context, key string) (bool, error) {
+	c, err := s.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	c.send("DEL", s.opts.KeyPrefix+key)
+	reply, err := c.roundTrip()
+	if err != nil {
+		c.close()
+		return false, err
+	}
+	s.put(c)
+	n, _ := reply.(int64)
+	return n > 0, nil
+}
+
+// Close closes all idle connections.
+func (s *Store) Close() error {
+	for {
+		select {
+		case c := <-s.pool:
+			c.close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (s *Store) get(ctx context.Context) (*conn, error) {
+	select {
+	case c := <-s.pool:
+		c.setDeadline(ctx, s.opts.Timeout)
+		return c, nil
+	default:
+		return dial(ctx, s.opts)
+	}
+}
+
+// put returns a healthy connection to the pool, closing it when the pool is
+// full. Connections that hit an I/O or protocol error are closed by the
+// caller instead — their read buffer may hold half a reply.
+func (s *Store) put(c *conn) {
+	select {
+	case s.pool <- c:
+	default:
+		c.close()
+	}
+}
+
+// readTime reads a TIME reply ([seconds, microseconds]).
+func readTime(c *conn) (time.Time, error) {
+	reply, err := c.read()
+	if err != nil {
+		return time.Time{}, err
+	}
+	parts, ok := reply.([]any)
+	if !ok || len(parts) != 2 {
+		return time.Time{}, errProtocol
+	}
+	secB, _ := parts[0].([]byte)
+	usecB, _ := parts[1].([]byte)
+	sec, err1 := strconv.ParseInt(string(secB), 10, 64)
+	usec, err2 := strconv.ParseInt(string(usecB), 10, 64)
+	if err1 != nil || err2 != nil {
+		return time.Time{}, errProtocol
+	}
+	return time.Unix(sec, usec*int64(time.Microsecond)), nil
+}
+
+// escapeGlob escapes SCAN MATCH metacharacters in a literal prefix.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,170 @@
+package redislimit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/middleware"
+	"github.com/MUKE-coder/sentinel/v2/middleware/redislimit"
+	"github.com/gin-gonic/gin"
+)
+
+func newStore(t *testing.T, fake *fakeRedis, opts redislimit.Options) *redislimit.Store {
+	t.Helper()
+	opts.Addr = fake.Addr()
+	store, err := redislimit.New(opts)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// Three replicas, one shared store: the limit must hold across all of them,
+// not once per replica.
+func TestRedisStore_LimitHoldsAcrossReplicas(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fake := newFakeRedis(t, "")
+
+	var routers []*gin.Engine
+	for i := 0; i < 3; i++ {
+		limiter := middleware.NewRateLimiter(newStore(t, fake, redislimit.Options{}))
+		r := gin.New()
+		r.Use(middleware.RateLimitMiddleware(sentinel.RateLimitConfig{
+			Enabled:  true,
+			Strategy: sentinel.SlidingWindow,
+			ByIP:     &sentinel.Limit{Requests: 5, Window: time.Minute},
+		}, limiter, nil))
+		r.GET("/api/test", func(c *gin.Context) { c.Status(http.StatusOK) })
+		routers = append(routers, r)
+	}
+
+	var succeeded, limited atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 60; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			routers[i%3].ServeHTTP(w, httptest.NewRequest("GET", "/api/test", nil))
+			switch w.Code {
+			case http.StatusOK:
+				succeeded.Add(1)
+			case http.StatusTooManyRequests:
+				limited.Add(1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if succeeded.Load() != 5 {
+		t.Errorf("expected exactly 5 successes across replicas, got %d", succeeded.Load())
+	}
+	if limited.Load() != 55 {
+		t.Errorf("expected 55 rate limited, got %d", limited.Load())
+	}
+}
+
+func TestRedisStore_StrategiesMatchMemoryStore(t *testing.T) {
+	fake := newFakeRedis(t, "")
+	store := newStore(t, fake, redislimit.Options{})
+	ctx := context.Background()
+	limit := sentinel.Limit{Requests: 3, Window: time.Minute}
+
+	for _, strategy := range []sentinel.RateLimitStrategy{sentinel.FixedWindow, sentinel.SlidingWindow, sentinel.TokenBucket} {
+		allowed := 0
+		var last middleware.CounterResult
+		for i := 0; i < 5; i++ {
+			res, err := store.Take(ctx, "k:"+string(strategy), strategy, limit)
+			if err != nil {
+				t.Fatalf("%s: take: %v", strategy, err)
+			}
+			if res.Allowed {
+				allowed++
+			}
+			last = res
+		}
+		if allowed != 3 {
+			t.Errorf("%s: expected 3 allowed, got %d", strategy, allowed)
+		}
+		if last.Allowed || last.RetryAfter <= 0 {
+			t.Errorf("%s: expected a denial with a positive RetryAfter, got %+v", strategy, last)
+		}
+	}
+}
+
+func TestRedisStore_StatesAndReset(t *testing.T) {
+	fake := newFakeRedis(t, "s3cret")
+	store := newStore(t, fake, redislimit.Options{Password: "s3cret", KeyPrefix: "app1:"})
+	other := newStore(t, fake, redislimit.Options{Password: "s3cret", KeyPrefix: "app2:"})
+	ctx := context.Background()
+	limit := sentinel.Limit{Requests: 10, Window: time.Minute}
+
+	for i := 0; i < 4; i++ {
+		store.Take(ctx, "ip:203.0.113.5", sentinel.TokenBucket, limit)
+	}
+	other.Take(ctx, "ip:198.51.100.7", sentinel.FixedWindow, limit)
+
+	states, err := store.States(ctx)
+	if err != nil {
+		t.Fatalf("states: %v", err)
+	}
+	if len(states) != 1 {
+		t.Fatalf("expected 1 state under this prefix, got %+v", states)
+	}
+	st := states[0]
+	if st.Key != "ip:203.0.113.5" || st.Strategy != sentinel.TokenBucket || st.Limit != 10 || st.Count != 4 || st.Remaining != 6 {
+		t.Errorf("unexpected state: %+v", st)
+	}
+
+	existed, err := store.Reset(ctx, "ip:203.0.113.5")
+	if err != nil || !existed {
+		t.Fatalf("reset: existed=%v err=%v", existed, err)
+	}
+	existed, _ = store.Reset(ctx, "ip:203.0.113.5")
+	if existed {
+		t.Error("second reset should report the key as gone")
+	}
+	res, _ := store.Take(ctx, "ip:203.0.113.5", sentinel.TokenBucket, limit)
+	if res.Remaining != 9 {
+		t.Errorf("expected a full bucket after reset, got remaining=%d", res.Remaining)
+	}
+}
+
+func TestRedisStore_BadPasswordFailsAtConnect(t *testing.T) {
+	fake := newFakeRedis(t, "s3cret")
+	if _, err := redislimit.New(redislimit.Options{Addr: fake.Addr(), Password: "wrong"}); err == nil {
+		t.Fatal("expected an auth error from New")
+	}
+}
+
+// An unreachable store must fail open — rate limiting degrades, the
+// application keeps serving.
+func TestRedisStore_FailsOpen(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fake := newFakeRedis(t, "")
+	store := newStore(t, fake, redislimit.Options{Timeout: 200 * time.Millisecond})
+	fake.ln.Close()
+	store.Close() // drop the pooled connection so the next Take has to dial
+
+	r := gin.New()
+	r.Use(middleware.RateLimitMiddleware(sentinel.RateLimitConfig{
+		Enabled: true,
+		ByIP:    &sentinel.Limit{Requests: 1, Window: time.Minute},
+	}, middleware.NewRateLimiter(store), nil))
+	r.GET("/api/test", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/api/test", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected fail-open 200, got %d", i+1, w.Code)
+		}
+	}
+}
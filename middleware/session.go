@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SessionRiskAssessor scores authenticated sessions for account takeover.
+// *intelligence.SessionRiskEngine satisfies it.
+type SessionRiskAssessor interface {
+	Assess(ctx context.Context, obs sentinel.SessionObservation) *sentinel.SessionRisk
+	StepUpPassed(sessionID string)
+}
+
+// assessSession scores the request's session and enforces its state. It
+// returns false if the request was aborted, which happens only before the
+// handler.
+func assessSession(c *gin.Context, opts UserActivityOptions, pipe *pipeline.Pipeline, user *sentinel.UserContext, token, clientIP string, before bool) bool {
+	if token == "" {
+		return true
+	}
+	sum := sha256.Sum256([]byte(token))
+	id := hex.EncodeToString(sum[:16])
+	risk := opts.Assessor.Assess(c.Request.Context(), sentinel.SessionObservation{
+		SessionID: id,
+		UserID:    user.ID,
+		IP:        clientIP,
+		UserAgent: c.Request.UserAgent(),
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.Path,
+		Timestamp: time.Now(),
+	})
+	if risk == nil {
+		return true
+	}
+	if risk.Flagging {
+		emitSessionThreat(c, pipe, risk, clientIP, opts.SessionRisk.Action, before)
+		if risk.Revoked && opts.SessionRisk.OnRevoke != nil {
+			opts.SessionRisk.OnRevoke(c, risk)
+		}
+	}
+	if !before {
+		return true
+	}
+
+	switch {
+	case risk.Revoked:
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "Session revoked",
+			"code":  "SESSION_REVOKED",
+		})
+		return false
+	case risk.StepUpRequired && opts.CAPTCHA != nil:
+		token := extractCAPTCHAToken(c, opts.CAPTCHATokenField)
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":            "Verification required",
+				"code":             "SESSION_STEP_UP_REQUIRED",
+				"captcha_provider": opts.CAPTCHA.Name(),
+			})
+			return false
+		}
+		if err := opts.CAPTCHA.Verify(c.Request.Context(), token, clientIP); err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":            "Verification failed",
+				"code":             "SESSION_STEP_UP_INVALID",
+				"captcha_provider": opts.CAPTCHA.Name(),
+			})
+			return false
+		}
+		opts.Assessor.StepUpPassed(id)
+	}
+	return true
+}
+
+// emitSessionThreat records the request that made a session risky.
+func emitSessionThreat(c *gin.Context, pipe *pipeline.Pipeline, risk *sentinel.SessionRisk, clientIP string, action sentinel.SessionRiskAction, before bool) {
+	if pipe == nil {
+		return
+	}
+	evidence := make([]sentinel.Evidence, 0, len(risk.Signals))
+	for _, s := range risk.Signals {
+		evidence = append(evidence, sentinel.Evidence{
+			Pattern:   s.Name,
+			Matched:   s.Detail,
+			Location:  "session",
+			Parameter: fmt.Sprintf("score=%d", s.Score),
+		})
+	}
+	severity := sentinel.SeverityMedium
+	switch {
+	case risk.Score >= 80:
+		severity = sentinel.SeverityCritical
+	case risk.Score >= 60:
+		severity = sentinel.SeverityHigh
+	}
+	cvss := sentinel.DefaultCVSSForType(string(sentinel.ThreatAccountTakeover))
+	te := &sentinel.ThreatEvent{
+		ID:          uuid.New().String(),
+		Timestamp:   time.Now(),
+		IP:          clientIP,
+		ActorID:     ActorIDFromIP(clientIP),
+		UserID:      risk.UserID,
+		Method:      c.Request.Method,
+		Path:        c.Request.URL.Path,
+		UserAgent:   c.Request.UserAgent(),
+		ThreatTypes: []string{string(sentinel.ThreatAccountTakeover)},
+		Severity:    severity,
+		Confidence:  risk.Score,
+		Evidence:    evidence,
+		Blocked:     before && action != sentinel.SessionActionLog,
+		Country:     risk.Country,
+		CVSS:        cvss.Score,
+		CVSSVector:  cvss.Vector,
+	}
+	applyClientSignals(c, te)
+	pipe.EmitThreat(te)
+}
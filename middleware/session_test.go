@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/pipeline"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeAssessor flags every session with the given state on its first request.
+type fakeAssessor struct {
+	mu       sync.Mutex
+	risk     sentinel.SessionRisk
+	seen     map[string]bool
+	passedUp []string
+}
+
+func (f *fakeAssessor) Assess(ctx context.Context, obs sentinel.SessionObservation) *sentinel.SessionRisk {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	risk := f.risk
+	risk.SessionID, risk.UserID = obs.SessionID, obs.UserID
+	if f.seen == nil {
+		f.seen = make(map[string]bool)
+	}
+	risk.Flagging = risk.Flagged && !f.seen[obs.SessionID]
+	f.seen[obs.SessionID] = true
+	return &risk
+}
+
+func (f *fakeAssessor) StepUpPassed(sessionID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.passedUp = append(f.passedUp, sessionID)
+	f.risk.StepUpRequired = false
+}
+
+type fakeCAPTCHA struct{}
+
+func (fakeCAPTCHA) Name() string { return "fake" }
+
+func (fakeCAPTCHA) Verify(ctx context.Context, token, clientIP string) error {
+	if token != "good" {
+		return errors.New("bad token")
+	}
+	return nil
+}
+
+func setupUserActivityRouter(opts UserActivityOptions, pipe *pipeline.Pipeline) *gin.Engine {
+	if opts.Extractor == nil {
+		opts.Extractor = func(c *gin.Context) *sentinel.UserContext {
+			if c.GetHeader("Authorization") == "" {
+				return nil
+			}
+			return &sentinel.UserContext{ID: "user-1", Email: "user@example.com"}
+		}
+	}
+	r := gin.New()
+	r.Use(UserActivityMiddleware(opts, pipe))
+	r.GET("/api/items/:id", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": "ok"})
+	})
+	return r
+}
+
+func authedRequest(method, path string) *http.Request {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer token-1")
+	return req
+}
+
+func TestUserActivityMiddleware_RecordsActivity(t *testing.T) {
+	pipe := pipeline.New(100)
+	activities := make(chan *sentinel.UserActivity, 10)
+	pipe.AddHandler(pipeline.HandlerFunc(func(ctx context.Context, event pipeline.Event) error {
+		if a, ok := event.Payload.(*sentinel.UserActivity); ok {
+			activities <- a
+		}
+		return nil
+	}))
+	pipe.Start(1)
+	defer pipe.Stop()
+
+	r := setupUserActivityRouter(UserActivityOptions{}, pipe)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/items/1", nil))
+	r.ServeHTTP(httptest.NewRecorder(), authedRequest("GET", "/api/items/42"))
+
+	select {
+	case a := <-activities:
+		if a.UserID != "user-1" || a.Action != "GET /api/items/:id" || a.Path != "/api/items/42" || a.StatusCode != http.StatusOK {
+			t.Errorf("unexpected activity %+v", a)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no activity emitted")
+	}
+	select {
+	case a := <-activities:
+		t.Errorf("anonymous request should not be recorded, got %+v", a)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestUserActivityMiddleware_RevokedSession(t *testing.T) {
+	pipe := pipeline.New(100)
+	threats := make(chan *sentinel.ThreatEvent, 10)
+	pipe.AddHandler(pipeline.HandlerFunc(func(ctx context.Context, event pipeline.Event) error {
+		if te, ok := event.Payload.(*sentinel.ThreatEvent); ok {
+			threats <- te
+		}
+		return nil
+	}))
+	pipe.Start(1)
+	defer pipe.Stop()
+
+	var revoked []string
+	assessor := &fakeAssessor{risk: sentinel.SessionRisk{
+		Score:   75,
+		Flagged: true,
+		Revoked: true,
+		Signals: []sentinel.SessionSignal{{Name: "new_device", Score: 25}, {Name: "impossible_travel", Score: 50}},
+	}}
+	r := setupUserActivityRouter(UserActivityOptions{
+		Assessor: assessor,
+		SessionRisk: sentinel.SessionRiskConfig{
+			Action: sentinel.SessionActionRevoke,
+			OnRevoke: func(c *gin.Context, risk *sentinel.SessionRisk) {
+				revoked = append(revoked, risk.SessionID)
+			},
+		},
+	}, pipe)
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, authedRequest("GET", "/api/items/1"))
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("request %d: expected 401, got %d", i+1, w.Code)
+		}
+	}
+	if len(revoked) != 1 || revoked[0] == "" || revoked[0] == "Bearer token-1" {
+		t.Errorf("OnRevoke should run once with a hashed session ID, got %v", revoked)
+	}
+
+	select {
+	case te := <-threats:
+		if len(te.ThreatTypes) != 1 || te.ThreatTypes[0] != string(sentinel.ThreatAccountTakeover) ||
+			!te.Blocked || te.Severity != sentinel.SeverityHigh || len(te.Evidence) != 2 || te.UserID != "user-1" {
+			t.Errorf("unexpected threat %+v", te)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no threat emitted")
+	}
+}
+
+func TestUserActivityMiddleware_StepUp(t *testing.T) {
+	assessor := &fakeAssessor{risk: sentinel.SessionRisk{Score: 55, Flagged: true, StepUpRequired: true}}
+	r := setupUserActivityRouter(UserActivityOptions{
+		Assessor:    assessor,
+		SessionRisk: sentinel.SessionRiskConfig{Action: sentinel.SessionActionChallenge},
+		CAPTCHA:     fakeCAPTCHA{},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest("GET", "/api/items/1"))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 without a token, got %d", w.Code)
+	}
+
+	req := authedRequest("GET", "/api/items/1")
+	req.Header.Set("X-Captcha-Token", "bad")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 with a bad token, got %d", w.Code)
+	}
+
+	req = authedRequest("GET", "/api/items/1")
+	req.Header.Set("X-Captcha-Token", "good")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 with a good token, got %d", w.Code)
+	}
+	if len(assessor.passedUp) != 1 {
+		t.Errorf("StepUpPassed should be called once, got %v", assessor.passedUp)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authedRequest("GET", "/api/items/1"))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 after the step-up, got %d", w.Code)
+	}
+}
@@ -2,11 +2,14 @@ package middleware
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"io"
 	"log"
 	"net/http"
+	"sort"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -31,6 +34,22 @@ func ActorIDFromIP(ip string) string {
 	return "actor_" + hex.EncodeToString(sum[:8])
 }
 
+// HeaderFingerprint returns a hash of the names of the headers in h. net/http
+// does not keep the order headers arrived in, so only the set of names is
+// hashed; it still tells apart the header sets of different HTTP clients.
+func HeaderFingerprint(h http.Header) string {
+	if len(h) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+	sum := sha256.Sum256([]byte(strings.Join(names, ",")))
+	return hex.EncodeToString(sum[:8])
+}
+
 // IPBlockChecker answers "is this IP blocked?" from an in-memory cache.
 // *intelligence.IPManager satisfies it: it syncs from storage every 30s and
 // is updated immediately on block/unblock, so lookups never hit the database
@@ -50,16 +69,70 @@ type IPBlockChecker interface {
 // Mount always supplies the checker; the store fallback exists for callers
 // wiring the middleware directly.
 func WAFMiddleware(config sentinel.WAFConfig, store storage.Store, pipe *pipeline.Pipeline, customEngine *detection.CustomRuleEngine, blockChecker ...IPBlockChecker) gin.HandlerFunc {
-	customRuleEngine := customEngine
-	excludeRoutes := NewRouteMatcher(config.ExcludeRoutes)
-	var checker IPBlockChecker
+	var opts WAFOptions
 	if len(blockChecker) > 0 {
-		checker = blockChecker[0]
-	}
-	excludeIPSet := make(map[string]bool)
-	for _, ip := range config.ExcludeIPs {
-		excludeIPSet[ip] = true
+		opts.BlockChecker = blockChecker[0]
 	}
+	return WAFMiddlewareWithOptions(config, store, pipe, customEngine, opts)
+}
+
+// WAFOptions carries the WAF's optional collaborators.
+type WAFOptions struct {
+	// BlockChecker answers blocklist lookups from memory; see WAFMiddleware.
+	BlockChecker IPBlockChecker
+
+	// Challenger runs ModeChallenge's interstitial page and clearance
+	// cookie. Without one, challenge mode answers every detection with a
+	// 429 JSON response, which a browser user cannot get past.
+	Challenger *Challenger
+
+	// Settings, when set, overrides WAFConfig.Mode and can be changed
+	// while serving.
+	Settings *WAFSettings
+
+	// Geo resolves client countries for custom rules with country
+	// conditions. Without one, those conditions never match.
+	Geo GeoLookup
+}
+
+// GeoLookup resolves an IP to its location. *intelligence.GeoLocator
+// satisfies it.
+type GeoLookup interface {
+	LookupIP(ctx context.Context, ip string) (*sentinel.GeoResult, error)
+}
+
+// WAFSettings holds the WAF settings an operator can change at runtime,
+// e.g. from the dashboard. Safe for concurrent use.
+type WAFSettings struct {
+	mode atomic.Value // sentinel.WAFMode
+}
+
+// NewWAFSettings returns settings starting in the given mode.
+func NewWAFSettings(mode sentinel.WAFMode) *WAFSettings {
+	s := &WAFSettings{}
+	s.SetMode(mode)
+	return s
+}
+
+// Mode returns the current WAF mode.
+func (s *WAFSettings) Mode() sentinel.WAFMode {
+	return s.mode.Load().(sentinel.WAFMode)
+}
+
+// SetMode switches the WAF mode; it applies from the next request.
+func (s *WAFSettings) SetMode(mode sentinel.WAFMode) {
+	s.mode.Store(mode)
+}
+
+// WAFMiddlewareWithOptions is WAFMiddleware with every optional collaborator
+// passed explicitly.
+func WAFMiddlewareWithOptions(config sentinel.WAFConfig, store storage.Store, pipe *pipeline.Pipeline, customEngine *detection.CustomRuleEngine, opts WAFOptions) gin.HandlerFunc {
+	customRuleEngine := customEngine
+	excludeRoutes := NewRouteMatcher(config.ExcludeRoutes)
+	checker := opts.BlockChecker
+	challenger := opts.Challenger
+	scoring := newAnomalyScoring(config.Scoring)
+	excludeIPs := newIPSet(config.ExcludeIPs)
 
 	return func(c *gin.Context) {
 		if !config.Enabled {
@@ -77,8 +150,8 @@ func WAFMiddleware(config sentinel.WAFConfig, store storage.Store, pipe *pipelin
 
 		clientIP := extractClientIP(c)
 
-		// Check if IP is excluded
-		if excludeIPSet[clientIP] {
+		// Check if IP is excluded (address, CIDR prefix or ASN)
+		if excludeIPs.contains(c.Request.Context(), clientIP, opts.Geo) {
 			c.Next()
 			return
 		}
@@ -105,6 +178,36 @@ func WAFMiddleware(config sentinel.WAFConfig, store storage.Store, pipe *pipelin
 			return
 		}
 
+		// Challenge submissions are answered by the WAF itself, ahead of
+		// inspection — the form carries CAPTCHA tokens, not user input.
+		if challenger != nil && c.Request.Method == http.MethodPost && path == challenger.Path() {
+			challenger.handleSubmit(c, clientIP)
+			return
+		}
+
+		// Bot policy, ahead of inspection: allowed bots skip it, and
+		// blocked or challenged ones never reach it.
+		if len(config.BotPolicy) > 0 {
+			botMode := config.Mode
+			if opts.Settings != nil {
+				botMode = opts.Settings.Mode()
+			}
+			if !applyBotPolicy(c, config.BotPolicy, botMode, challenger, pipe, clientIP) {
+				return
+			}
+		}
+
+		// Network policy, likewise ahead of inspection.
+		if len(config.NetworkPolicy) > 0 {
+			networkMode := config.Mode
+			if opts.Settings != nil {
+				networkMode = opts.Settings.Mode()
+			}
+			if !applyNetworkPolicy(c, config.NetworkPolicy, networkMode, challenger, pipe, clientIP) {
+				return
+			}
+		}
+
 		// Determine inspection cap
 		maxBody := config.MaxBodyBytes
 		if maxBody <= 0 {
@@ -123,6 +226,7 @@ func WAFMiddleware(config sentinel.WAFConfig, store storage.Store, pipe *pipelin
 
 		// Read and restore request body up to the inspection cap.
 		var bodyStr string
+		var bodySize int64
 		if c.Request.Body != nil && c.Request.ContentLength != 0 {
 			bodyBytes, err := io.ReadAll(io.LimitReader(c.Request.Body, maxBody))
 			if err == nil {
@@ -130,6 +234,7 @@ func WAFMiddleware(config sentinel.WAFConfig, store storage.Store, pipe *pipelin
 				// Restore body for downstream handlers, preserving any bytes
 				// past the inspection cap so legitimate large uploads still work.
 				remaining, _ := io.ReadAll(c.Request.Body)
+				bodySize = int64(len(bodyBytes) + len(remaining))
 				c.Request.Body = io.NopCloser(bytes.NewReader(append(bodyBytes, remaining...)))
 			}
 		}
@@ -143,6 +248,12 @@ func WAFMiddleware(config sentinel.WAFConfig, store storage.Store, pipe *pipelin
 			Body:      bodyStr,
 			IP:        clientIP,
 			UserAgent: c.Request.UserAgent(),
+			BodySize:  bodySize,
+		}
+		if opts.Geo != nil && customRuleEngine != nil && customRuleEngine.UsesCountry() {
+			if geo, _ := opts.Geo.LookupIP(c.Request.Context(), clientIP); geo != nil {
+				inspected.Country = geo.CountryCode
+			}
 		}
 
 		// Classify and score
@@ -192,9 +303,23 @@ func WAFMiddleware(config sentinel.WAFConfig, store storage.Store, pipe *pipelin
 			Evidence:    evidence,
 			CVSS:        cvss.Score,
 			CVSSVector:  cvss.Vector,
+
+			HeaderFingerprint: HeaderFingerprint(c.Request.Header),
+		}
+		applyClientSignals(c, threatEvent)
+
+		mode := config.Mode
+		if opts.Settings != nil {
+			mode = opts.Settings.Mode()
 		}
 
-		switch config.Mode {
+		// Under anomaly scoring, a request below its route's threshold is
+		// only recorded, whatever the mode.
+		if scoring != nil && !scoring.score(threatEvent, matches) {
+			mode = sentinel.ModeLog
+		}
+
+		switch mode {
 		case sentinel.ModeBlock:
 			threatEvent.Blocked = true
 			threatEvent.StatusCode = http.StatusForbidden
@@ -211,17 +336,25 @@ func WAFMiddleware(config sentinel.WAFConfig, store storage.Store, pipe *pipelin
 			return
 
 		case sentinel.ModeChallenge:
-			threatEvent.Blocked = true
-			threatEvent.StatusCode = http.StatusTooManyRequests
-
-			if pipe != nil {
-				pipe.EmitThreat(threatEvent)
+			// A client that already solved a challenge passes, but the
+			// detection is still recorded, as in log mode.
+			if challenger != nil && challenger.cleared(c, clientIP) {
+				threatEvent.Challenge = challenger.record(clientIP, challengeCleared)
+				c.Next()
+				threatEvent.StatusCode = c.Writer.Status()
+				if pipe != nil {
+					pipe.EmitThreat(threatEvent)
+				}
+				return
 			}
 
-			c.Header("Retry-After", "30")
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
-				"error": "Request challenged by WAF",
-				"code":  "WAF_CHALLENGE",
+			threatEvent.Blocked = true
+			threatEvent.StatusCode = http.StatusTooManyRequests
+			challenger.issue(c, clientIP, func(stats *sentinel.ChallengeStats) {
+				threatEvent.Challenge = stats
+				if pipe != nil {
+					pipe.EmitThreat(threatEvent)
+				}
 			})
 			return
 
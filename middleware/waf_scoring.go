@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"sort"
+	"strings"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/detection"
+)
+
+// defaultInboundThreshold applies when AnomalyScoringConfig leaves
+// InboundThreshold unset — one critical match, or any two lesser ones.
+const defaultInboundThreshold = 5
+
+// anomalyScoring is WAFConfig.Scoring ready for per-request use. Route
+// thresholds resolve like RateLimitConfig.ByRoute: exact key first, then
+// the longest matching pattern.
+type anomalyScoring struct {
+	severityPoints map[sentinel.Severity]int
+	inbound        int
+	exact          map[string]int
+	patterns       []routeThreshold
+}
+
+type routeThreshold struct {
+	pattern   string
+	matcher   *RouteMatcher
+	threshold int
+}
+
+func newAnomalyScoring(cfg *sentinel.AnomalyScoringConfig) *anomalyScoring {
+	if cfg == nil {
+		return nil
+	}
+	a := &anomalyScoring{
+		severityPoints: cfg.SeverityPoints,
+		inbound:        cfg.InboundThreshold,
+		exact:          make(map[string]int),
+	}
+	if a.inbound <= 0 {
+		a.inbound = defaultInboundThreshold
+	}
+	for k, v := range cfg.RouteThresholds {
+		if strings.ContainsAny(k, "*?[") {
+			a.patterns = append(a.patterns, routeThreshold{pattern: k, matcher: NewRouteMatcher([]string{k}), threshold: v})
+		} else {
+			a.exact[k] = v
+		}
+	}
+	sort.Slice(a.patterns, func(i, j int) bool {
+		if len(a.patterns[i].pattern) != len(a.patterns[j].pattern) {
+			return len(a.patterns[i].pattern) > len(a.patterns[j].pattern)
+		}
+		return a.patterns[i].pattern < a.patterns[j].pattern
+	})
+	return a
+}
+
+// threshold returns the inbound threshold for a request path.
+func (a *anomalyScoring) threshold(path string) int {
+	if t, ok := a.exact[path]; ok {
+		return t
+	}
+	for _, p := range a.patterns {
+		if p.matcher.Matches(path) {
+			return p.threshold
+		}
+	}
+	return a.inbound
+}
+
+// AnomalyThreshold returns the inbound threshold cfg applies to a request
+// path, resolved as the WAF resolves it at runtime.
+func AnomalyThreshold(cfg *sentinel.AnomalyScoringConfig, path string) int {
+	if a := newAnomalyScoring(cfg); a != nil {
+		return a.threshold(path)
+	}
+	return 0
+}
+
+// score records the request's anomaly score on the event, with each
+// match's points on its evidence entry, and reports whether the score
+// reached the route's threshold.
+func (a *anomalyScoring) score(event *sentinel.ThreatEvent, matches []detection.ThreatMatch) bool {
+	total, points := detection.AnomalyScore(matches, a.severityPoints)
+	for i := range event.Evidence {
+		event.Evidence[i].Score = points[i]
+	}
+	event.AnomalyScore = total
+	event.AnomalyThreshold = a.threshold(event.Path)
+	return total >= event.AnomalyThreshold
+}
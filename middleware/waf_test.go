@@ -175,6 +175,33 @@ func TestWAFBlockedIP(t *testing.T) {
 	pipe.Stop()
 }
 
+func TestWAFExcludedIPPrefix(t *testing.T) {
+	r := gin.New()
+	r.Use(WAFMiddleware(sentinel.WAFConfig{
+		Enabled:    true,
+		Mode:       sentinel.ModeBlock,
+		ExcludeIPs: []string{"10.0.0.0/8", "2001:db8::/32", "not-an-ip"},
+	}, memory.New(), nil, nil))
+	r.GET("/search", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for addr, want := range map[string]int{
+		"10.9.8.7:1234":      http.StatusOK,
+		"[2001:db8::1]:1234": http.StatusOK,
+		"192.0.2.1:1234":     http.StatusForbidden,
+		"[2001:db9::1]:1234": http.StatusForbidden,
+	} {
+		req := httptest.NewRequest("GET", "/search?q=1'+OR+'1'='1", nil)
+		req.RemoteAddr = addr
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("%s: expected %d, got %d", addr, want, w.Code)
+		}
+	}
+}
+
 func TestWAFExcludedRoute(t *testing.T) {
 	store := memory.New()
 	pipe := pipeline.New(100)
@@ -252,3 +279,63 @@ func TestWAFLatencyOverhead(t *testing.T) {
 	}
 	t.Logf("WAF avg latency overhead: %v per request", avgLatency)
 }
+
+func TestWAFAnomalyScoring(t *testing.T) {
+	pipe := pipeline.New(100)
+	events := make(chan *sentinel.ThreatEvent, 10)
+	pipe.AddHandler(pipeline.HandlerFunc(func(ctx context.Context, ev pipeline.Event) error {
+		if te, ok := ev.Payload.(*sentinel.ThreatEvent); ok {
+			events <- te
+		}
+		return nil
+	}))
+	pipe.Start(1)
+	defer pipe.Stop()
+
+	r := gin.New()
+	r.Use(WAFMiddleware(sentinel.WAFConfig{
+		Enabled: true,
+		Mode:    sentinel.ModeBlock,
+		Scoring: &sentinel.AnomalyScoringConfig{
+			InboundThreshold: 5,
+			RouteThresholds:  map[string]int{"/api/cms/**": 100},
+		},
+	}, memory.New(), pipe, nil))
+	r.GET("/api/products", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/api/cms/pages", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	cases := []struct {
+		name, url     string
+		want          int
+		blocked       bool
+		minScore      int
+		wantThreshold int
+	}{
+		// A lone medium-severity match scores 3 and is only recorded.
+		{"single weak match passes", "/api/products?next=https://example.com", http.StatusOK, false, 3, 5},
+		// Several SQLi patterns together cross the threshold.
+		{"cumulative score blocks", "/api/products?id=1'%20UNION%20SELECT%20password%20FROM%20users--%20", http.StatusForbidden, true, 5, 5},
+		// The same payload passes on a route group with a higher threshold.
+		{"route threshold", "/api/cms/pages?id=1'%20UNION%20SELECT%20password%20FROM%20users--%20", http.StatusOK, false, 5, 100},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.url, nil))
+		if w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, w.Code)
+		}
+		select {
+		case ev := <-events:
+			sum := 0
+			for _, e := range ev.Evidence {
+				sum += e.Score
+			}
+			if ev.Blocked != tc.blocked || ev.AnomalyScore < tc.minScore || ev.AnomalyThreshold != tc.wantThreshold || sum != ev.AnomalyScore {
+				t.Errorf("%s: unexpected event blocked=%v score=%d threshold=%d evidence=%+v",
+					tc.name, ev.Blocked, ev.AnomalyScore, ev.AnomalyThreshold, ev.Evidence)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s: no threat event", tc.name)
+		}
+	}
+}
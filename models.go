@@ -8,9 +8,24 @@ type (
 	InspectedRequest    = core.InspectedRequest
 	Evidence            = core.Evidence
 	ThreatEvent         = core.ThreatEvent
+	ChallengeStats      = core.ChallengeStats
 	ThreatActor         = core.ThreatActor
+	TLSFingerprint      = core.TLSFingerprint
+	BotResult           = core.BotResult
+	BotStats            = core.BotStats
+	FeedMatch           = core.FeedMatch
+	FeedStats           = core.FeedStats
+	NetworkMatch        = core.NetworkMatch
+	NetworkListStats    = core.NetworkListStats
+	Campaign            = core.Campaign
+	CampaignSignal      = core.CampaignSignal
 	AuditLog            = core.AuditLog
 	UserActivity        = core.UserActivity
+	LoginAttempt        = core.LoginAttempt
+	SessionRisk         = core.SessionRisk
+	SessionSignal       = core.SessionSignal
+	SessionObservation  = core.SessionObservation
+	UserBaseline        = core.UserBaseline
 	SubScore            = core.SubScore
 	Recommendation      = core.Recommendation
 	SecurityScore       = core.SecurityScore
@@ -23,6 +38,7 @@ type (
 	AttackTypeStat      = core.AttackTypeStat
 	ThreatFilter        = core.ThreatFilter
 	ActorFilter         = core.ActorFilter
+	CampaignFilter      = core.CampaignFilter
 	ActivityFilter      = core.ActivityFilter
 	AuditFilter         = core.AuditFilter
 	ThreatUpdate        = core.ThreatUpdate
@@ -33,4 +49,7 @@ type (
 	AttackTrend         = core.AttackTrend
 	GeoStats            = core.GeoStats
 	TopTarget           = core.TopTarget
+	ConfigVersion       = core.ConfigVersion
+	DashboardUser       = core.DashboardUser
+	APIKey              = core.APIKey
 )
@@ -2,6 +2,8 @@ package sentinel_test
 
 import (
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/MUKE-coder/sentinel/v2"
@@ -49,3 +51,28 @@ func TestMountE_TestModeAllowsDefaults(t *testing.T) {
 		t.Fatalf("TestMode should tolerate defaults, got %v", err)
 	}
 }
+
+func TestMount_GeoFenceSparesDashboard(t *testing.T) {
+	r := gin.New()
+	if err := sentinel.MountE(r, nil, sentinel.Config{
+		Storage: sentinel.StorageConfig{Driver: sentinel.Memory},
+		GeoFence: sentinel.GeoFenceConfig{Policies: []sentinel.GeoPolicy{
+			{ID: "de-only", Allow: []string{"DE"}, FailClosed: true},
+		}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	r.GET("/shop", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	// Without geolocation no client resolves, so the policy refuses all.
+	for path, fenced := range map[string]bool{
+		"/shop":         true,
+		"/sentinel/ui/": false,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if got := w.Code == http.StatusForbidden; got != fenced {
+			t.Errorf("%s: status %d, fenced %v, want %v", path, w.Code, got, fenced)
+		}
+	}
+}
@@ -22,6 +22,9 @@ const (
 
 	// EventAudit is an audit log entry.
 	EventAudit EventType = "audit"
+
+	// EventLoginAttempt is an attempt on the AuthShield login route.
+	EventLoginAttempt EventType = "login_attempt"
 )
 
 // Event is the envelope for all events flowing through the pipeline.
@@ -120,6 +120,15 @@ func (p *Pipeline) EmitAudit(payload interface{}) {
 	})
 }
 
+// EmitLoginAttempt is a convenience method to emit a login attempt event.
+func (p *Pipeline) EmitLoginAttempt(payload interface{}) {
+	p.Emit(Event{
+		Type:      EventLoginAttempt,
+		Timestamp: time.Now(),
+		Payload:   payload,
+	})
+}
+
 // DroppedCount returns the total number of events dropped due to a full
 // buffer. Useful as an alerting signal — drops happen exactly when an
 // attack is overwhelming the pipeline.
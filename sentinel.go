@@ -18,21 +18,25 @@ import (
 	"fmt"
 	"io/fs"
 	"log"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/MUKE-coder/sentinel/v2/ai"
 	"github.com/MUKE-coder/sentinel/v2/alerting"
 	"github.com/MUKE-coder/sentinel/v2/api"
+	"github.com/MUKE-coder/sentinel/v2/bots"
 	"github.com/MUKE-coder/sentinel/v2/captcha"
 	"github.com/MUKE-coder/sentinel/v2/core"
 	"github.com/MUKE-coder/sentinel/v2/detection"
 	sentinelgorm "github.com/MUKE-coder/sentinel/v2/gorm"
 	"github.com/MUKE-coder/sentinel/v2/intelligence"
 	"github.com/MUKE-coder/sentinel/v2/middleware"
+	"github.com/MUKE-coder/sentinel/v2/middleware/redislimit"
 	"github.com/MUKE-coder/sentinel/v2/pipeline"
 	"github.com/MUKE-coder/sentinel/v2/storage"
 	"github.com/MUKE-coder/sentinel/v2/storage/memory"
+	"github.com/MUKE-coder/sentinel/v2/storage/mysql"
 	"github.com/MUKE-coder/sentinel/v2/storage/postgres"
 	"github.com/MUKE-coder/sentinel/v2/storage/sqlite"
 	"github.com/MUKE-coder/sentinel/v2/ui"
@@ -88,9 +92,11 @@ func MountE(router *gin.Engine, db *gorm.DB, config Config) error {
 
 	// Refuse to start with built-in default credentials in release mode unless
 	// the operator has explicitly opted in. This stops zero-config deployments
-	// from shipping with forgeable admin tokens and a known password.
+	// from shipping with forgeable admin tokens and a known password. The
+	// password does not matter once OIDC has replaced password login.
 	if gin.Mode() == gin.ReleaseMode && !config.Dashboard.AllowInsecureDefaults {
-		if config.Dashboard.Password == core.DefaultInsecurePassword {
+		passwordLogin := config.Dashboard.OIDC == nil || !config.Dashboard.OIDC.DisablePasswordLogin
+		if passwordLogin && config.Dashboard.Password == core.DefaultInsecurePassword {
 			return fmt.Errorf("%w: default dashboard password — set Dashboard.Password or AllowInsecureDefaults", ErrInsecureDefaults)
 		}
 		if config.Dashboard.SecretKey == core.DefaultInsecureSecretKey {
@@ -120,26 +126,93 @@ func MountE(router *gin.Engine, db *gorm.DB, config Config) error {
 		if err != nil {
 			return fmt.Errorf("initialize Postgres storage: %w", err)
 		}
+	case MySQL:
+		store, err = mysql.New(config.Storage.DSN, mysql.Options{
+			MaxOpenConns: config.Storage.MaxOpenConns,
+			MaxIdleConns: config.Storage.MaxIdleConns,
+		})
+		if err != nil {
+			return fmt.Errorf("initialize MySQL storage: %w", err)
+		}
 	case Memory:
 		store = memory.New()
 	default:
 		store = memory.New()
 	}
 
+	// 1a. Connect the shared rate-limit counter store, if configured. Done
+	// before anything starts goroutines so a bad address fails cleanly.
+	var counterStore middleware.CounterStore
+	if config.RateLimit.Enabled && config.RateLimit.Redis != nil {
+		rc := config.RateLimit.Redis
+		counterStore, err = redislimit.New(redislimit.Options{
+			Addr:      rc.Addr,
+			Username:  rc.Username,
+			Password:  rc.Password,
+			DB:        rc.DB,
+			KeyPrefix: rc.KeyPrefix,
+			TLSConfig: rc.TLS,
+		})
+		if err != nil {
+			return fmt.Errorf("initialize Redis rate-limit store: %w", err)
+		}
+	}
+
+	// 1b. Load the geolocation databases. A configured file that is missing
+	// or corrupt fails Mount rather than silently disabling geolocation.
+	geoLocator := intelligence.NewGeoLocator(config.Geo)
+	if err := geoLocator.Reload(); err != nil {
+		return fmt.Errorf("initialize geolocation: %w", err)
+	}
+
 	// 2. Run migrations
 	ctx := context.Background()
 	if err := store.Migrate(ctx); err != nil {
 		return fmt.Errorf("run migrations: %w", err)
 	}
 
+	// 2a. Layer the dashboard edits saved by earlier runs over the
+	// code-supplied config. baseConfig is kept so later edits and rollbacks
+	// are layered over the code config, not over each other.
+	baseConfig := config
+	latest, err := store.LatestConfigVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("load persisted config: %w", err)
+	}
+	if latest != nil {
+		latest.Overrides.Apply(&config)
+	}
+
 	// 3. Initialize IP manager
 	ipManager := intelligence.NewIPManager(store)
+	ipManager.SetGeoLocator(geoLocator)
+
+	// 3a. Load the threat feeds. The IP manager consults them, so a
+	// blocking feed holds wherever blocked IPs are refused.
+	var feeds *intelligence.FeedManager
+	if len(config.IPReputation.Feeds) > 0 {
+		feeds = intelligence.NewFeedManager(config.IPReputation.Feeds)
+		feeds.Start()
+		ipManager.SetFeeds(feeds)
+	}
+
+	// 3b. Load the network type lists (Tor exits, VPN and hosting ranges).
+	var networks *intelligence.NetworkDetector
+	if config.Networks.Enabled {
+		networks = intelligence.NewNetworkDetector(config.Networks)
+		networks.Start()
+	}
 
 	// 4. Initialize event pipeline
 	pipe := pipeline.New(pipeline.DefaultBufferSize)
 
 	// Add threat actor profiler to pipeline
 	profiler := intelligence.NewProfiler(store)
+	var campaigns *intelligence.CampaignEngine
+	if config.Campaigns.Enabled {
+		campaigns = intelligence.NewCampaignEngine(store, geoLocator, ipManager, config.Campaigns)
+		profiler.SetCampaignEngine(campaigns)
+	}
 	pipe.AddHandler(profiler)
 
 	// Add storage handler to pipeline
@@ -167,18 +240,62 @@ func MountE(router *gin.Engine, db *gorm.DB, config Config) error {
 
 	pipe.Start(4) // 4 worker goroutines
 
+	// 4a. Attach TLS fingerprints first, so every middleware below and the
+	// threat events it raises can use them.
+	if config.Fingerprint.Source != nil {
+		router.Use(middleware.FingerprintMiddleware(config.Fingerprint, pipe))
+	}
+
+	// 4b. Match requests against the threat feeds, ahead of everything
+	// that could raise an event, so those events name the feed. The
+	// dashboard's own requests are left alone.
+	if feeds != nil {
+		router.Use(middleware.FeedMiddleware(feeds, ipManager, []string{config.Dashboard.Prefix + "/**"}, pipe))
+	}
+
+	// 4c. Classify bots, for the WAF's and rate limiter's bot policies. The
+	// dashboard's own requests are left alone.
+	var botDetector *bots.Detector
+	if config.Bots.Enabled {
+		botDetector = bots.New(config.Bots)
+		excludes := append(slices.Clone(config.Bots.ExcludeRoutes), config.Dashboard.Prefix+"/**")
+		router.Use(middleware.BotMiddleware(botDetector, excludes))
+	}
+
+	// 4d. Tag requests with their client's network type, for the WAF's
+	// and rate limiter's network policies and for threat events. The
+	// dashboard's own requests are left alone.
+	if networks != nil {
+		excludes := append(slices.Clone(config.Networks.ExcludeRoutes), config.Dashboard.Prefix+"/**")
+		router.Use(middleware.NetworkMiddleware(networks, excludes))
+	}
+
+	// 4e. Enforce geofencing policies ahead of the WAF and rate limiter.
+	// The fence is mounted even without policies so ones added from the
+	// dashboard take effect straight away. The challenger is shared with
+	// the WAF, so a client cleared by either passes both. The dashboard
+	// is never fenced, so a policy cannot lock its admins out.
+	var challenger *middleware.Challenger
+	if cp := buildCAPTCHAProvider(config); cp != nil {
+		challenger = middleware.NewChallenger(cp, config.Dashboard.SecretKey, config.WAF.Challenge)
+	}
+	geoFence, err := middleware.NewGeoFence(config.GeoFence.Policies)
+	if err != nil {
+		log.Printf("[sentinel] geofence: %v — policies disabled", err)
+		geoFence, _ = middleware.NewGeoFence(nil)
+	}
+	router.Use(middleware.GeoFenceMiddleware(geoFence, geoLocator, ipManager, challenger, []string{config.Dashboard.Prefix + "/**"}, pipe))
+
 	// 5. Initialize security score engine
 	scoreEngine := intelligence.NewScoreEngine(store, config)
 
-	// 5a. Initialize geolocation
-	geoLocator := intelligence.NewGeoLocator(config.Geo)
-
 	// 5b. Initialize IP reputation checker
 	repChecker := intelligence.NewReputationChecker(config.IPReputation, ipManager)
 
 	// 5c. Initialize anomaly detector and add to pipeline
+	var anomalyDetector *intelligence.AnomalyDetector
 	if config.Anomaly.Enabled {
-		anomalyDetector := intelligence.NewAnomalyDetector(store, pipe, geoLocator, config.Anomaly)
+		anomalyDetector = intelligence.NewAnomalyDetector(store, pipe, geoLocator, config.Anomaly)
 		pipe.AddHandler(anomalyDetector)
 	}
 
@@ -213,20 +330,76 @@ func MountE(router *gin.Engine, db *gorm.DB, config Config) error {
 
 	// 5f. Initialize custom rule engine
 	customRuleEngine := detection.NewCustomRuleEngine(config.WAF.CustomRules)
+	for _, path := range config.WAF.RuleFiles {
+		rules, issues, err := detection.ParseSecLangFile(path)
+		if err != nil {
+			log.Printf("[sentinel] rule file %s: %v", path, err)
+			continue
+		}
+		for _, issue := range issues {
+			log.Printf("[sentinel] rule file %s: %s", path, issue)
+		}
+		if err := customRuleEngine.ImportRules(rules); err != nil {
+			log.Printf("[sentinel] rule file %s: %v", path, err)
+			continue
+		}
+		log.Printf("[sentinel] imported %d rules from %s", len(rules), path)
+	}
 
 	// 6. Register middleware. The IP manager's synced cache answers blocklist
 	// lookups so the WAF never queries storage on the request hot path.
+	var wafSettings *middleware.WAFSettings
 	if config.WAF.Enabled {
-		router.Use(middleware.WAFMiddleware(config.WAF, store, pipe, customRuleEngine, ipManager))
+		wafSettings = middleware.NewWAFSettings(config.WAF.Mode)
+		wafOpts := middleware.WAFOptions{BlockChecker: ipManager, Settings: wafSettings, Geo: geoLocator}
+		// The challenger is built whenever a CAPTCHA provider is configured,
+		// not only in challenge mode, so switching the mode from the
+		// dashboard serves the interstitial straight away.
+		wafOpts.Challenger = challenger
+		router.Use(middleware.WAFMiddlewareWithOptions(config.WAF, store, pipe, customRuleEngine, wafOpts))
 	}
 
 	// 7. Register rate limiter
 	var rateLimiter *middleware.RateLimiter
 	if config.RateLimit.Enabled {
-		rateLimiter = middleware.NewRateLimiter()
+		if counterStore != nil {
+			rateLimiter = middleware.NewRateLimiter(counterStore)
+		} else {
+			rateLimiter = middleware.NewRateLimiter()
+		}
 		router.Use(middleware.RateLimitMiddleware(config.RateLimit, rateLimiter, pipe))
 	}
 
+	// 7a. Record the activity of authenticated users and score their
+	// sessions for account takeover.
+	var sessionRisk *intelligence.SessionRiskEngine
+	if config.UserExtractor != nil {
+		opts := middleware.UserActivityOptions{
+			Extractor:         config.UserExtractor,
+			ExcludeRoutes:     []string{config.Dashboard.Prefix + "/**"},
+			SessionRisk:       config.SessionRisk,
+			CAPTCHATokenField: config.AuthShield.CAPTCHATokenField,
+		}
+		if config.SessionRisk.Enabled {
+			opts.CAPTCHA = buildCAPTCHAProvider(config)
+			if config.SessionRisk.Action == SessionActionChallenge && opts.CAPTCHA == nil {
+				log.Printf("[sentinel] session risk: challenge action needs a CAPTCHA provider; logging instead")
+				opts.SessionRisk.Action = SessionActionLog
+			}
+			sessionRisk = intelligence.NewSessionRiskEngine(store, geoLocator, opts.SessionRisk)
+			opts.Assessor = sessionRisk
+		}
+		router.Use(middleware.UserActivityMiddleware(opts, pipe))
+	}
+
+	// 7b. Register response inspection. The dashboard's own responses carry
+	// recorded payloads and evidence, so they are never inspected.
+	if config.DLP.Enabled {
+		dlpConfig := config.DLP
+		dlpConfig.ExcludeRoutes = append(slices.Clone(dlpConfig.ExcludeRoutes), config.Dashboard.Prefix+"/**")
+		router.Use(middleware.DLPMiddleware(dlpConfig, pipe))
+	}
+
 	// 8. Register security headers
 	router.Use(middleware.HeadersMiddleware(config.Headers))
 
@@ -235,8 +408,13 @@ func MountE(router *gin.Engine, db *gorm.DB, config Config) error {
 
 	// 10. Register API routes
 	apiServer := api.NewServer(store, pipe, ipManager, scoreEngine, config)
+	apiServer.SetConfigBase(baseConfig)
+	if wafSettings != nil {
+		apiServer.SetWAFSettings(wafSettings)
+	}
 	apiServer.SetReputationChecker(repChecker)
 	apiServer.SetGeoLocator(geoLocator)
+	apiServer.SetGeoFence(geoFence)
 	if alertDispatcher != nil {
 		apiServer.SetAlertDispatcher(alertDispatcher)
 	}
@@ -244,6 +422,12 @@ func MountE(router *gin.Engine, db *gorm.DB, config Config) error {
 		apiServer.SetAuthShield(authShield)
 	}
 	apiServer.SetCustomRuleEngine(customRuleEngine)
+	apiServer.SetAnomalyDetector(anomalyDetector)
+	apiServer.SetSessionRiskEngine(sessionRisk)
+	apiServer.SetCampaignEngine(campaigns)
+	apiServer.SetBotDetector(botDetector)
+	apiServer.SetFeedManager(feeds)
+	apiServer.SetNetworkDetector(networks)
 	if rateLimiter != nil {
 		apiServer.SetRateLimiter(rateLimiter)
 	}
@@ -329,11 +513,17 @@ func MountE(router *gin.Engine, db *gorm.DB, config Config) error {
 func buildCAPTCHAProvider(config Config) captcha.Provider {
 	switch {
 	case config.CAPTCHA.HCaptchaSecret != "":
-		return captcha.NewHCaptchaProvider(config.CAPTCHA.HCaptchaSecret)
+		p := captcha.NewHCaptchaProvider(config.CAPTCHA.HCaptchaSecret)
+		p.SetSiteKey(config.CAPTCHA.HCaptchaSiteKey)
+		return p
 	case config.CAPTCHA.TurnstileSecret != "":
-		return captcha.NewTurnstileProvider(config.CAPTCHA.TurnstileSecret)
+		p := captcha.NewTurnstileProvider(config.CAPTCHA.TurnstileSecret)
+		p.SetSiteKey(config.CAPTCHA.TurnstileSiteKey)
+		return p
 	case config.CAPTCHA.RecaptchaSecret != "":
-		return captcha.NewRecaptchaProvider(config.CAPTCHA.RecaptchaSecret)
+		p := captcha.NewRecaptchaProvider(config.CAPTCHA.RecaptchaSecret)
+		p.SetSiteKey(config.CAPTCHA.RecaptchaSiteKey)
+		return p
 	case config.CAPTCHA.SelfHostedSecret != "":
 		return captcha.NewSelfHostedProvider(config.CAPTCHA.SelfHostedSecret)
 	}
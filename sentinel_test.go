@@ -242,3 +242,85 @@ func TestIntegration_DefaultConfig(t *testing.T) {
 		t.Errorf("default config failed: status %d", w.Code)
 	}
 }
+
+// Dashboard edits are saved as config versions and layered over the code
+// config on the next Mount; rolling back to version 0 returns to the code
+// config.
+func TestIntegration_ConfigEditsSurviveRestart(t *testing.T) {
+	dsn := t.TempDir() + "/sentinel.db"
+	mount := func() (*gin.Engine, string) {
+		r := gin.New()
+		if err := sentinel.MountE(r, nil, sentinel.Config{
+			Storage: sentinel.StorageConfig{Driver: sentinel.SQLite, DSN: dsn},
+			WAF:     sentinel.WAFConfig{Enabled: true, Mode: sentinel.ModeLog},
+			Dashboard: sentinel.DashboardConfig{
+				Prefix:    "/sentinel",
+				Username:  "admin",
+				Password:  "testpass",
+				SecretKey: "test-secret-key",
+			},
+		}); err != nil {
+			t.Fatalf("MountE: %v", err)
+		}
+		r.GET("/api/users", func(c *gin.Context) { c.JSON(200, gin.H{}) })
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/sentinel/api/auth/login", strings.NewReader(`{"username":"admin","password":"testpass"}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		var res struct{ Token string }
+		json.Unmarshal(w.Body.Bytes(), &res)
+		if res.Token == "" {
+			t.Fatalf("login failed: %s", w.Body.String())
+		}
+		return r, res.Token
+	}
+	call := func(r *gin.Engine, token, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	attack := "/api/users?id=1%27%20OR%20%271%27=%271"
+
+	r, token := mount()
+	if w := call(r, token, "GET", attack, ""); w.Code != http.StatusOK {
+		t.Fatalf("log mode should pass the request, got %d", w.Code)
+	}
+	if w := call(r, token, "PUT", "/sentinel/api/waf/rules", `{"mode":"block"}`); w.Code != http.StatusOK {
+		t.Fatalf("update WAF rules: %d %s", w.Code, w.Body.String())
+	}
+	if w := call(r, token, "GET", attack, ""); w.Code != http.StatusForbidden {
+		t.Errorf("mode change should apply without a restart, got %d", w.Code)
+	}
+	if w := call(r, token, "PUT", "/sentinel/api/waf/rules", `{"mode":"deny"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown mode, got %d", w.Code)
+	}
+
+	// A fresh Mount on the same database picks the edit up.
+	r, token = mount()
+	if w := call(r, token, "GET", attack, ""); w.Code != http.StatusForbidden {
+		t.Errorf("persisted block mode not restored, got %d", w.Code)
+	}
+
+	w := call(r, token, "GET", "/sentinel/api/config/versions", "")
+	var versions struct {
+		Data []sentinel.ConfigVersion `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &versions)
+	if len(versions.Data) != 1 || versions.Data[0].Version != 1 || versions.Data[0].Overrides.WAFMode != sentinel.ModeBlock {
+		t.Fatalf("unexpected versions: %s", w.Body.String())
+	}
+
+	if w := call(r, token, "POST", "/sentinel/api/config/versions/7/rollback", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 rolling back to a missing version, got %d", w.Code)
+	}
+	if w := call(r, token, "POST", "/sentinel/api/config/versions/0/rollback", ""); w.Code != http.StatusOK {
+		t.Fatalf("rollback: %d %s", w.Code, w.Body.String())
+	}
+	if w := call(r, token, "GET", attack, ""); w.Code != http.StatusOK {
+		t.Errorf("rollback to the code config should restore log mode, got %d", w.Code)
+	}
+}
@@ -5,10 +5,10 @@ package storage
 
 // Store is the union of every storage capability Sentinel needs.
 // It composes focused sub-interfaces (ThreatStore, ActorStore, IPStore,
-// AuditStore, MetricStore, UserActivityStore, AnalyticsStore, ScoreStore,
-// LifecycleStore) so callers that need only one capability can depend on
-// just that sub-interface — e.g. a Redis-backed IPStore can be swapped in
-// without re-implementing the whole world. Existing implementations
+// AuditStore, MetricStore, UserActivityStore, BaselineStore, CampaignStore, AnalyticsStore, ScoreStore,
+// ConfigStore, DashboardUserStore, APIKeyStore, LifecycleStore) so callers
+// that need only one capability can depend on just that sub-interface — e.g. a Redis-backed
+// IPStore can be swapped in without re-implementing the whole world. Existing implementations
 // (memory, sqlite, postgres) keep working unchanged because Store is the
 // same surface area as before.
 type Store interface {
@@ -18,7 +18,12 @@ type Store interface {
 	AuditStore
 	MetricStore
 	UserActivityStore
+	BaselineStore
+	CampaignStore
 	AnalyticsStore
 	ScoreStore
+	ConfigStore
+	DashboardUserStore
+	APIKeyStore
 	LifecycleStore
 }
@@ -4,6 +4,7 @@ package memory
 
 import (
 	"context"
+	"maps"
 	"sort"
 	"strings"
 	"sync"
@@ -27,7 +28,12 @@ type Store struct {
 	blockedIPs     map[string]*sentinel.BlockedIP
 	whitelistedIPs map[string]*sentinel.WhitelistedIP
 	securityScore  *sentinel.SecurityScore
-	threatList     []string // ordered threat IDs by timestamp desc
+	threatList     []string                  // ordered threat IDs by timestamp desc
+	configVersions []*sentinel.ConfigVersion // oldest first
+	dashboardUsers map[string]*sentinel.DashboardUser
+	apiKeys        map[string]*sentinel.APIKey
+	baselines      map[string]*sentinel.UserBaseline
+	campaigns      map[string]*sentinel.Campaign
 }
 
 // New creates a new in-memory store.
@@ -38,6 +44,10 @@ func New() *Store {
 		userActivities: make(map[string][]*sentinel.UserActivity),
 		blockedIPs:     make(map[string]*sentinel.BlockedIP),
 		whitelistedIPs: make(map[string]*sentinel.WhitelistedIP),
+		dashboardUsers: make(map[string]*sentinel.DashboardUser),
+		apiKeys:        make(map[string]*sentinel.APIKey),
+		baselines:      make(map[string]*sentinel.UserBaseline),
+		campaigns:      make(map[string]*sentinel.Campaign),
 	}
 }
 
@@ -368,6 +378,7 @@ func (s *Store) BlockIP(ctx context.Context, ip string, reason string, expiry *t
 		Reason:    reason,
 		BlockedAt: time.Now(),
 		ExpiresAt: expiry,
+		CIDR:      strings.Contains(ip, "/"),
 	}
 	return nil
 }
@@ -387,30 +398,48 @@ func (s *Store) WhitelistIP(ctx context.Context, ip string) error {
 	s.whitelistedIPs[ip] = &sentinel.WhitelistedIP{
 		IP:          ip,
 		WhitelistAt: time.Now(),
+		CIDR:        strings.Contains(ip, "/"),
 	}
 	return nil
 }
 
-// IsIPBlocked checks if an IP address is blocked.
+// UnwhitelistIP removes an IP address from the whitelist.
+func (s *Store) UnwhitelistIP(ctx context.Context, ip string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.whitelistedIPs, ip)
+	return nil
+}
+
+// IsIPBlocked checks if the most specific entry covering an IP address is a
+// live block.
 func (s *Store) IsIPBlocked(ctx context.Context, ip string) (bool, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	b, ok := s.blockedIPs[ip]
-	if !ok {
-		return false, nil
-	}
-	if b.ExpiresAt != nil && b.ExpiresAt.Before(time.Now()) {
-		return false, nil
-	}
-	return true, nil
+	blocked, _ := s.ipDecision(ip)
+	return blocked, nil
 }
 
-// IsIPWhitelisted checks if an IP address is whitelisted.
+// IsIPWhitelisted checks if the most specific entry covering an IP address
+// is a whitelist entry.
 func (s *Store) IsIPWhitelisted(ctx context.Context, ip string) (bool, error) {
+	_, whitelisted := s.ipDecision(ip)
+	return whitelisted, nil
+}
+
+// ipDecision walks the entries covering ip from the most specific one out
+// and reports which list the first match is on; the whitelist wins ties.
+func (s *Store) ipDecision(ip string) (blocked, whitelisted bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	_, ok := s.whitelistedIPs[ip]
-	return ok, nil
+	now := time.Now()
+	for _, entry := range sentinel.CoveringIPEntries(ip) {
+		if _, ok := s.whitelistedIPs[entry]; ok {
+			return false, true
+		}
+		if b, ok := s.blockedIPs[entry]; ok && (b.ExpiresAt == nil || b.ExpiresAt.After(now)) {
+			return true, false
+		}
+	}
+	return false, false
 }
 
 // ListBlockedIPs returns all blocked IP addresses.
@@ -428,6 +457,17 @@ func (s *Store) ListBlockedIPs(ctx context.Context) ([]*sentinel.BlockedIP, erro
 	return result, nil
 }
 
+// ListWhitelistedIPs returns all whitelisted IP addresses.
+func (s *Store) ListWhitelistedIPs(ctx context.Context) ([]*sentinel.WhitelistedIP, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]*sentinel.WhitelistedIP, 0, len(s.whitelistedIPs))
+	for _, w := range s.whitelistedIPs {
+		result = append(result, w)
+	}
+	return result, nil
+}
+
 // GetThreatStats returns aggregated threat statistics for the given time window.
 func (s *Store) GetThreatStats(ctx context.Context, window time.Duration) (*sentinel.ThreatStats, error) {
 	s.mu.RLock()
@@ -493,6 +533,60 @@ func (s *Store) SaveSecurityScore(ctx context.Context, score *sentinel.SecurityS
 	return nil
 }
 
+// SaveConfigVersion appends a config version, numbering it after the last.
+func (s *Store) SaveConfigVersion(ctx context.Context, v *sentinel.ConfigVersion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v.Version = len(s.configVersions) + 1
+	if v.CreatedAt.IsZero() {
+		v.CreatedAt = time.Now()
+	}
+	saved := *v
+	saved.Overrides = v.Overrides.Clone()
+	s.configVersions = append(s.configVersions, &saved)
+	return nil
+}
+
+// GetConfigVersion returns a config version by number.
+func (s *Store) GetConfigVersion(ctx context.Context, version int) (*sentinel.ConfigVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if version < 1 || version > len(s.configVersions) {
+		return nil, nil
+	}
+	return copyConfigVersion(s.configVersions[version-1]), nil
+}
+
+// LatestConfigVersion returns the newest config version.
+func (s *Store) LatestConfigVersion(ctx context.Context) (*sentinel.ConfigVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.configVersions) == 0 {
+		return nil, nil
+	}
+	return copyConfigVersion(s.configVersions[len(s.configVersions)-1]), nil
+}
+
+// ListConfigVersions returns up to limit config versions, newest first.
+func (s *Store) ListConfigVersions(ctx context.Context, limit int) ([]*sentinel.ConfigVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result []*sentinel.ConfigVersion
+	for i := len(s.configVersions) - 1; i >= 0; i-- {
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+		result = append(result, copyConfigVersion(s.configVersions[i]))
+	}
+	return result, nil
+}
+
+func copyConfigVersion(v *sentinel.ConfigVersion) *sentinel.ConfigVersion {
+	c := *v
+	c.Overrides = v.Overrides.Clone()
+	return &c
+}
+
 // Migrate is a no-op for the memory store.
 func (s *Store) Migrate(ctx context.Context) error {
 	return nil
@@ -740,6 +834,9 @@ func matchesThreatFilter(t *sentinel.ThreatEvent, f sentinel.ThreatFilter) bool
 	if f.IP != "" && t.IP != f.IP {
 		return false
 	}
+	if f.ActorID != "" && t.ActorID != f.ActorID {
+		return false
+	}
 	if f.StartTime != nil && t.Timestamp.Before(*f.StartTime) {
 		return false
 	}
@@ -814,3 +911,233 @@ func percentile(sorted []int64, p int) int64 {
 	}
 	return sorted[idx]
 }
+
+// SaveDashboardUser creates or updates a dashboard account.
+func (s *Store) SaveDashboardUser(ctx context.Context, u *sentinel.DashboardUser) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, existing := range s.dashboardUsers {
+		if id != u.ID && existing.Username == u.Username {
+			return storage.ErrDuplicateUsername
+		}
+	}
+	saved := *u
+	s.dashboardUsers[u.ID] = &saved
+	return nil
+}
+
+// GetDashboardUser returns a dashboard account by ID.
+func (s *Store) GetDashboardUser(ctx context.Context, id string) (*sentinel.DashboardUser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if u, ok := s.dashboardUsers[id]; ok {
+		cp := *u
+		return &cp, nil
+	}
+	return nil, nil
+}
+
+// GetDashboardUserByUsername returns a dashboard account by username.
+func (s *Store) GetDashboardUserByUsername(ctx context.Context, username string) (*sentinel.DashboardUser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, u := range s.dashboardUsers {
+		if u.Username == username {
+			cp := *u
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+// ListDashboardUsers returns every dashboard account ordered by username.
+func (s *Store) ListDashboardUsers(ctx context.Context) ([]*sentinel.DashboardUser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]*sentinel.DashboardUser, 0, len(s.dashboardUsers))
+	for _, u := range s.dashboardUsers {
+		cp := *u
+		result = append(result, &cp)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Username < result[j].Username })
+	return result, nil
+}
+
+// DeleteDashboardUser removes a dashboard account.
+func (s *Store) DeleteDashboardUser(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.dashboardUsers[id]
+	delete(s.dashboardUsers, id)
+	return ok, nil
+}
+
+// SaveAPIKey creates or updates an API key.
+func (s *Store) SaveAPIKey(ctx context.Context, k *sentinel.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apiKeys[k.ID] = copyAPIKey(k)
+	return nil
+}
+
+// GetAPIKey returns an API key by ID.
+func (s *Store) GetAPIKey(ctx context.Context, id string) (*sentinel.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if k, ok := s.apiKeys[id]; ok {
+		return copyAPIKey(k), nil
+	}
+	return nil, nil
+}
+
+// ListAPIKeys returns every API key, newest first.
+func (s *Store) ListAPIKeys(ctx context.Context) ([]*sentinel.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]*sentinel.APIKey, 0, len(s.apiKeys))
+	for _, k := range s.apiKeys {
+		result = append(result, copyAPIKey(k))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.After(result[j].CreatedAt) })
+	return result, nil
+}
+
+// TouchAPIKey sets an API key's last-used time.
+func (s *Store) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if k, ok := s.apiKeys[id]; ok {
+		k.LastUsedAt = &at
+	}
+	return nil
+}
+
+func copyAPIKey(k *sentinel.APIKey) *sentinel.APIKey {
+	cp := *k
+	cp.Scopes = append([]string(nil), k.Scopes...)
+	cp.AllowedIPs = append([]string(nil), k.AllowedIPs...)
+	return &cp
+}
+
+// GetBaseline returns a user's behavioral baseline, or nil.
+func (s *Store) GetBaseline(ctx context.Context, userID string) (*sentinel.UserBaseline, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if b, ok := s.baselines[userID]; ok {
+		return copyBaseline(b), nil
+	}
+	return nil, nil
+}
+
+// SaveBaseline creates or replaces a user's behavioral baseline if its
+// stored version still matches b.Version.
+func (s *Store) SaveBaseline(ctx context.Context, b *sentinel.UserBaseline) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var version int64
+	if cur, ok := s.baselines[b.UserID]; ok {
+		version = cur.Version
+	}
+	if version != b.Version {
+		return storage.ErrBaselineConflict
+	}
+	b.Version++
+	s.baselines[b.UserID] = copyBaseline(b)
+	return nil
+}
+
+func copyBaseline(b *sentinel.UserBaseline) *sentinel.UserBaseline {
+	cp := *b
+	cp.Routes = maps.Clone(b.Routes)
+	cp.SourceIPs = maps.Clone(b.SourceIPs)
+	cp.Countries = maps.Clone(b.Countries)
+	return &cp
+}
+
+// SaveCampaign creates or replaces a campaign.
+func (s *Store) SaveCampaign(ctx context.Context, c *sentinel.Campaign) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.campaigns[c.ID] = copyCampaign(c)
+	return nil
+}
+
+// GetCampaign returns the campaign with the given ID, or nil.
+func (s *Store) GetCampaign(ctx context.Context, id string) (*sentinel.Campaign, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if c, ok := s.campaigns[id]; ok {
+		return copyCampaign(c), nil
+	}
+	return nil, nil
+}
+
+// ListCampaigns returns a paginated, filtered list of campaigns, riskiest
+// first.
+func (s *Store) ListCampaigns(ctx context.Context, filter sentinel.CampaignFilter) ([]*sentinel.Campaign, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if filter.PageSize <= 0 {
+		filter.PageSize = 20
+	}
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+
+	var filtered []*sentinel.Campaign
+	for _, c := range s.campaigns {
+		if filter.Status != "" && c.Status != filter.Status {
+			continue
+		}
+		if filter.MinRisk > 0 && c.RiskScore < filter.MinRisk {
+			continue
+		}
+		filtered = append(filtered, c)
+	}
+
+	sort.Slice(filtered, func(i, j int) bool {
+		if filtered[i].RiskScore != filtered[j].RiskScore {
+			return filtered[i].RiskScore > filtered[j].RiskScore
+		}
+		return filtered[i].LastSeen.After(filtered[j].LastSeen)
+	})
+
+	total := int64(len(filtered))
+	start := (filter.Page - 1) * filter.PageSize
+	if start >= int(total) {
+		return nil, total, nil
+	}
+	end := start + filter.PageSize
+	if end > int(total) {
+		end = int(total)
+	}
+
+	page := make([]*sentinel.Campaign, 0, end-start)
+	for _, c := range filtered[start:end] {
+		page = append(page, copyCampaign(c))
+	}
+	return page, total, nil
+}
+
+// DeleteCampaign removes a campaign.
+func (s *Store) DeleteCampaign(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.campaigns, id)
+	return nil
+}
+
+func copyCampaign(c *sentinel.Campaign) *sentinel.Campaign {
+	cp := *c
+	cp.ActorIPs = append([]string(nil), c.ActorIPs...)
+	cp.AttackTypes = append([]string(nil), c.AttackTypes...)
+	cp.TargetedRoutes = append([]string(nil), c.TargetedRoutes...)
+	cp.Countries = append([]string(nil), c.Countries...)
+	cp.Signals = append([]sentinel.CampaignSignal(nil), c.Signals...)
+	if c.BlockExpiry != nil {
+		t := *c.BlockExpiry
+		cp.BlockExpiry = &t
+	}
+	return &cp
+}
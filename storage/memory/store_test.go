@@ -2,11 +2,13 @@ package memory
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/storage"
 )
 
 func TestSaveThreatAndGet(t *testing.T) {
@@ -315,6 +317,34 @@ func TestUserActivity(t *testing.T) {
 	}
 }
 
+func TestBaselines(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	b := &sentinel.UserBaseline{UserID: "user-1", Routes: map[string]float64{"GET /a": 1}, Samples: 1}
+	s.SaveBaseline(ctx, b)
+	b.Routes["GET /b"] = 1 // the store must not share the caller's maps
+
+	got, _ := s.GetBaseline(ctx, "user-1")
+	if got == nil || got.Samples != 1 || len(got.Routes) != 1 {
+		t.Fatalf("GetBaseline = %+v", got)
+	}
+	if got, _ := s.GetBaseline(ctx, "user-2"); got != nil {
+		t.Errorf("expected nil for an unknown user, got %+v", got)
+	}
+
+	// got was read before this save, so saving it again must fail.
+	if err := s.SaveBaseline(ctx, b); err != nil {
+		t.Fatalf("SaveBaseline (update): %v", err)
+	}
+	if err := s.SaveBaseline(ctx, got); !errors.Is(err, storage.ErrBaselineConflict) {
+		t.Errorf("expected ErrBaselineConflict for a stale baseline, got %v", err)
+	}
+	if err := s.SaveBaseline(ctx, &sentinel.UserBaseline{UserID: "user-1"}); !errors.Is(err, storage.ErrBaselineConflict) {
+		t.Errorf("expected ErrBaselineConflict for a new baseline of a known user, got %v", err)
+	}
+}
+
 func TestAuditLogs(t *testing.T) {
 	s := New()
 	ctx := context.Background()
@@ -350,3 +380,42 @@ func TestAuditLogs(t *testing.T) {
 		t.Errorf("expected 1 result, got %d", len(logs))
 	}
 }
+
+func TestConfigVersions(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	if v, err := s.LatestConfigVersion(ctx); err != nil || v != nil {
+		t.Fatalf("expected no versions, got %v, %v", v, err)
+	}
+
+	first := &sentinel.ConfigVersion{Change: "set mode", Overrides: sentinel.ConfigOverrides{WAFMode: sentinel.ModeBlock}}
+	second := &sentinel.ConfigVersion{Change: "add rule", Overrides: sentinel.ConfigOverrides{
+		CustomRules: map[string]sentinel.WAFRule{"r1": {ID: "r1", Pattern: "x"}},
+	}}
+	if err := s.SaveConfigVersion(ctx, first); err != nil {
+		t.Fatalf("SaveConfigVersion: %v", err)
+	}
+	s.SaveConfigVersion(ctx, second)
+	if first.Version != 1 || second.Version != 2 || first.CreatedAt.IsZero() {
+		t.Fatalf("versions not assigned: %d, %d", first.Version, second.Version)
+	}
+
+	// The store keeps its own copy.
+	second.Overrides.CustomRules["r1"] = sentinel.WAFRule{ID: "r1", Pattern: "changed"}
+
+	latest, _ := s.LatestConfigVersion(ctx)
+	if latest.Version != 2 || latest.Overrides.CustomRules["r1"].Pattern != "x" {
+		t.Errorf("unexpected latest version %+v", latest)
+	}
+	list, _ := s.ListConfigVersions(ctx, 10)
+	if len(list) != 2 || list[0].Version != 2 || list[1].Version != 1 {
+		t.Errorf("expected versions newest first, got %d", len(list))
+	}
+	if v, _ := s.GetConfigVersion(ctx, 1); v == nil || v.Overrides.WAFMode != sentinel.ModeBlock {
+		t.Errorf("GetConfigVersion(1) = %+v", v)
+	}
+	if v, _ := s.GetConfigVersion(ctx, 3); v != nil {
+		t.Errorf("expected nil for a missing version, got %+v", v)
+	}
+}
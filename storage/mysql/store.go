@@ -0,0 +1,103 @@
+// Package mysql provides a MySQL / MariaDB storage adapter for Sentinel.
+//
+// Like storage/postgres, it reuses the GORM-based store from storage/sqlite —
+// the schema, queries, and behaviour are shared across every backend. The
+// only MySQL-specific pieces are the driver, the connection settings New
+// enforces, and a column-type fix applied during migrations.
+package mysql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MUKE-coder/sentinel/v2/storage/sqlite"
+	gomysql "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// Options configures the MySQL adapter beyond the DSN.
+type Options struct {
+	// MaxOpenConns sets the maximum number of open connections to the database.
+	// Default: 25.
+	MaxOpenConns int
+	// MaxIdleConns sets the maximum number of idle connections in the pool.
+	// Default: 5.
+	MaxIdleConns int
+}
+
+// New opens a MySQL connection at dsn (go-sql-driver format,
+// "user:pass@tcp(host:3306)/dbname") and returns a Sentinel store ready for
+// use. Schema migrations are NOT run here — call store.Migrate(ctx) after
+// construction.
+//
+// New always sets parseTime=true and loc=UTC on the DSN: DATETIME columns
+// carry no zone, so every replica must read and write them in the same one
+// for time-window queries and trend buckets to line up.
+func New(dsn string, opts ...Options) (*sqlite.Store, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("mysql: empty DSN")
+	}
+
+	cfg, err := gomysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("mysql: parse DSN: %w", err)
+	}
+	cfg.ParseTime = true
+	cfg.Loc = time.UTC
+
+	db, err := gorm.Open(dialector{mysql.New(mysql.Config{DSN: cfg.FormatDSN()}).(*mysql.Dialector)}, &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("mysql: open: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("mysql: get sql.DB: %w", err)
+	}
+
+	maxOpen, maxIdle := 25, 5
+	if len(opts) > 0 {
+		if opts[0].MaxOpenConns > 0 {
+			maxOpen = opts[0].MaxOpenConns
+		}
+		if opts[0].MaxIdleConns > 0 {
+			maxIdle = opts[0].MaxIdleConns
+		}
+	}
+	sqlDB.SetMaxOpenConns(maxOpen)
+	sqlDB.SetMaxIdleConns(maxIdle)
+
+	return sqlite.NewFromGormDB(db), nil
+}
+
+// dialector is the GORM MySQL dialector with one column-type fix. The
+// upstream driver maps an unsized string to LONGTEXT unless the field is a
+// primary key or carries an "index"/"unique" tag, so a `uniqueIndex` string
+// column (threat_actors.ip) becomes LONGTEXT — which MySQL refuses to index
+// without a prefix length (error 1170), failing the migration.
+type dialector struct {
+	*mysql.Dialector
+}
+
+// DataTypeOf sizes indexed strings the way the upstream driver sizes its
+// own indexed strings: VARCHAR(191), the longest utf8mb4 key that fits the
+// 767-byte index limit of older InnoDB row formats.
+func (d dialector) DataTypeOf(field *schema.Field) string {
+	if field.DataType == schema.String && field.Size == 0 && field.TagSettings["UNIQUEINDEX"] != "" {
+		return "varchar(191)"
+	}
+	return d.Dialector.DataTypeOf(field)
+}
+
+// Migrator returns the upstream migrator with its column types resolved
+// through DataTypeOf above.
+func (d dialector) Migrator(db *gorm.DB) gorm.Migrator {
+	m := d.Dialector.Migrator(db).(mysql.Migrator)
+	m.Migrator.Dialector = d
+	return m
+}
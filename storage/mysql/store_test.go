@@ -0,0 +1,111 @@
+package mysql
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm/schema"
+)
+
+// TestMySQLDialector_IndexedStringColumns checks the migration fix without a
+// server: every indexed string must get a bounded VARCHAR, never LONGTEXT.
+func TestMySQLDialector_IndexedStringColumns(t *testing.T) {
+	type row struct {
+		ID    string `gorm:"primaryKey;column:id"`
+		IP    string `gorm:"uniqueIndex;column:ip"`
+		Route string `gorm:"index;column:route"`
+		Body  string `gorm:"column:body"`
+	}
+	s, err := schema.Parse(&row{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatalf("parse schema: %v", err)
+	}
+	d := dialector{mysql.New(mysql.Config{}).(*mysql.Dialector)}
+
+	for _, name := range []string{"id", "ip", "route"} {
+		if got := d.DataTypeOf(s.LookUpField(name)); !strings.HasPrefix(got, "varchar(") {
+			t.Errorf("indexed column %q mapped to %q, want varchar", name, got)
+		}
+	}
+	if got := d.DataTypeOf(s.LookUpField("body")); got != "longtext" {
+		t.Errorf("unindexed column mapped to %q, want longtext", got)
+	}
+}
+
+func TestMySQLStore_EmptyDSN(t *testing.T) {
+	if _, err := New(""); err == nil {
+		t.Fatal("expected an error for an empty DSN")
+	}
+}
+
+// TestMySQLStore_Smoke runs the same minimal interface check the SQLite
+// adapter passes, plus the dialect-specific analytics queries. It only runs
+// when SENTINEL_TEST_MYSQL_DSN points at a reachable MySQL or MariaDB
+// ("user:pass@tcp(127.0.0.1:3306)/sentinel_test").
+func TestMySQLStore_Smoke(t *testing.T) {
+	dsn := os.Getenv("SENTINEL_TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("SENTINEL_TEST_MYSQL_DSN not set — skipping MySQL integration test")
+	}
+
+	store, err := New(dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	if err := store.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	threat := &sentinel.ThreatEvent{
+		ID:          "mysql-test-" + time.Now().Format("150405.000000"),
+		Timestamp:   time.Now().UTC(),
+		IP:          "203.0.113.5",
+		ActorID:     "actor_test",
+		Method:      "GET",
+		Path:        "/api/users",
+		ThreatTypes: []string{"SQLi"},
+		Severity:    sentinel.SeverityHigh,
+		Confidence:  90,
+		Country:     "DE",
+	}
+	if err := store.SaveThreat(ctx, threat); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	got, err := store.GetThreat(ctx, threat.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got == nil || got.ID != threat.ID {
+		t.Fatalf("round-trip mismatch: got %+v", got)
+	}
+
+	if err := store.UpsertActor(ctx, &sentinel.ThreatActor{ID: "actor_test", IP: "203.0.113.5", LastSeen: time.Now()}); err != nil {
+		t.Fatalf("upsert actor: %v", err)
+	}
+
+	trends, err := store.GetAttackTrends(ctx, time.Hour, "hour")
+	if err != nil {
+		t.Fatalf("trends: %v", err)
+	}
+	if len(trends) == 0 || trends[len(trends)-1].Period != threat.Timestamp.Truncate(time.Hour).Format("2006-01-02T15:00") {
+		t.Fatalf("unexpected trend buckets: %+v", trends)
+	}
+
+	geo, err := store.GetGeoStats(ctx, time.Hour)
+	if err != nil {
+		t.Fatalf("geo stats: %v", err)
+	}
+	if len(geo) == 0 {
+		t.Fatal("geo stats returned empty")
+	}
+}
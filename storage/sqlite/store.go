@@ -5,6 +5,7 @@ package sqlite
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"sort"
 	"strings"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/MUKE-coder/sentinel/v2/storage"
 	gsqlite "github.com/glebarez/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -49,11 +51,11 @@ func New(dsn string) (*Store, error) {
 }
 
 // NewFromGormDB wraps an already-opened *gorm.DB into a Store. Exposed so
-// other adapter packages (notably storage/postgres) can reuse this entire
-// implementation: the GORM models defined here are dialect-agnostic, so
-// the only difference between SQLite and Postgres is the driver passed
-// to gorm.Open. Keeping the codepath shared means migrations, queries,
-// and tests cover both backends.
+// other adapter packages (storage/postgres, storage/mysql) can reuse this
+// entire implementation: the GORM models defined here are dialect-agnostic,
+// so the only difference between the backends is the driver passed to
+// gorm.Open, plus the few date expressions in periodExpr. Keeping the
+// codepath shared means migrations, queries, and tests cover every backend.
 func NewFromGormDB(db *gorm.DB) *Store {
 	return &Store{db: db}
 }
@@ -85,6 +87,20 @@ type threatEventRow struct {
 	Lng           float64   `gorm:"column:lng"`
 	Resolved      bool      `gorm:"column:resolved"`
 	FalsePositive bool      `gorm:"column:false_positive"`
+	Challenge     string    `gorm:"column:challenge"`
+
+	AnomalyScore     int `gorm:"column:anomaly_score"`
+	AnomalyThreshold int `gorm:"column:anomaly_threshold"`
+
+	HeaderFingerprint string `gorm:"column:header_fingerprint"`
+	JA3               string `gorm:"index;column:ja3"`
+	JA4               string `gorm:"index;column:ja4"`
+	BotVerdict        string `gorm:"index;column:bot_verdict"`
+	BotScore          int    `gorm:"column:bot_score"`
+	FeedSource        string `gorm:"index;column:feed_source"`
+	FeedScore         int    `gorm:"column:feed_score"`
+	NetworkType       string `gorm:"index;column:network_type"`
+	NetworkScore      int    `gorm:"column:network_score"`
 }
 
 func (threatEventRow) TableName() string { return "sentinel_threats" }
@@ -107,6 +123,13 @@ type threatActorRow struct {
 	AbuseScore      int       `gorm:"column:abuse_score"`
 	Lat             float64   `gorm:"column:lat"`
 	Lng             float64   `gorm:"column:lng"`
+	CampaignID      string    `gorm:"index;column:campaign_id"`
+	JA3             string    `gorm:"column:ja3"`
+	JA4             string    `gorm:"column:ja4"`
+	ThreatFeeds     string    `gorm:"column:threat_feeds"`
+	FeedScore       int       `gorm:"column:feed_score"`
+	NetworkType     string    `gorm:"column:network_type"`
+	NetworkScore    int       `gorm:"column:network_score"`
 }
 
 func (threatActorRow) TableName() string { return "sentinel_actors" }
@@ -176,6 +199,7 @@ func (blockedIPRow) TableName() string { return "sentinel_blocked_ips" }
 type whitelistedIPRow struct {
 	IP          string    `gorm:"primaryKey;column:ip"`
 	WhitelistAt time.Time `gorm:"column:whitelisted_at"`
+	CIDR        bool      `gorm:"column:cidr"`
 }
 
 func (whitelistedIPRow) TableName() string { return "sentinel_whitelisted_ips" }
@@ -188,6 +212,16 @@ type securityScoreRow struct {
 
 func (securityScoreRow) TableName() string { return "sentinel_security_scores" }
 
+type configVersionRow struct {
+	Version   int       `gorm:"primaryKey;autoIncrement;column:version"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	Author    string    `gorm:"column:author"`
+	Change    string    `gorm:"column:change_summary"`
+	Overrides string    `gorm:"column:overrides"` // JSON blob
+}
+
+func (configVersionRow) TableName() string { return "sentinel_config_versions" }
+
 // Migrate runs database schema migrations.
 func (s *Store) Migrate(ctx context.Context) error {
 	return s.db.WithContext(ctx).AutoMigrate(
@@ -199,6 +233,11 @@ func (s *Store) Migrate(ctx context.Context) error {
 		&blockedIPRow{},
 		&whitelistedIPRow{},
 		&securityScoreRow{},
+		&configVersionRow{},
+		&dashboardUserRow{},
+		&apiKeyRow{},
+		&baselineRow{},
+		&campaignRow{},
 	)
 }
 
@@ -241,6 +280,9 @@ func (s *Store) ListThreats(ctx context.Context, filter sentinel.ThreatFilter) (
 	if filter.IP != "" {
 		query = query.Where("ip = ?", filter.IP)
 	}
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
 	if filter.StartTime != nil {
 		query = query.Where("timestamp >= ?", *filter.StartTime)
 	}
@@ -548,24 +590,70 @@ func (s *Store) WhitelistIP(ctx context.Context, ip string) error {
 	row := whitelistedIPRow{
 		IP:          ip,
 		WhitelistAt: time.Now(),
+		CIDR:        strings.Contains(ip, "/"),
 	}
 	return s.db.WithContext(ctx).Save(&row).Error
 }
 
-// IsIPBlocked checks if an IP address is blocked.
+// UnwhitelistIP removes an IP address from the whitelist.
+func (s *Store) UnwhitelistIP(ctx context.Context, ip string) error {
+	return s.db.WithContext(ctx).Where("ip = ?", ip).Delete(&whitelistedIPRow{}).Error
+}
+
+// IsIPBlocked checks if the most specific entry covering an IP address is a
+// live block.
 func (s *Store) IsIPBlocked(ctx context.Context, ip string) (bool, error) {
-	var count int64
-	s.db.WithContext(ctx).Model(&blockedIPRow{}).
-		Where("ip = ? AND (expires_at IS NULL OR expires_at > ?)", ip, time.Now()).
-		Count(&count)
-	return count > 0, nil
+	blocked, _, err := s.ipDecision(ctx, ip)
+	return blocked, err
 }
 
-// IsIPWhitelisted checks if an IP address is whitelisted.
+// IsIPWhitelisted checks if the most specific entry covering an IP address
+// is a whitelist entry.
 func (s *Store) IsIPWhitelisted(ctx context.Context, ip string) (bool, error) {
-	var count int64
-	s.db.WithContext(ctx).Model(&whitelistedIPRow{}).Where("ip = ?", ip).Count(&count)
-	return count > 0, nil
+	_, whitelisted, err := s.ipDecision(ctx, ip)
+	return whitelisted, err
+}
+
+// ipDecision fetches the entries covering ip — the address and each
+// enclosing prefix, one indexed IN lookup per list — and reports which list
+// the most specific one is on; the whitelist wins ties.
+func (s *Store) ipDecision(ctx context.Context, ip string) (blocked, whitelisted bool, err error) {
+	candidates := sentinel.CoveringIPEntries(ip)
+	if len(candidates) == 0 {
+		return false, false, nil
+	}
+	var blockedRows []string
+	err = s.db.WithContext(ctx).Model(&blockedIPRow{}).
+		Where("ip IN ? AND (expires_at IS NULL OR expires_at > ?)", candidates, time.Now()).
+		Pluck("ip", &blockedRows).Error
+	if err != nil {
+		return false, false, err
+	}
+	var whitelistedRows []string
+	err = s.db.WithContext(ctx).Model(&whitelistedIPRow{}).
+		Where("ip IN ?", candidates).
+		Pluck("ip", &whitelistedRows).Error
+	if err != nil {
+		return false, false, err
+	}
+
+	isBlocked := make(map[string]bool, len(blockedRows))
+	for _, e := range blockedRows {
+		isBlocked[e] = true
+	}
+	isWhitelisted := make(map[string]bool, len(whitelistedRows))
+	for _, e := range whitelistedRows {
+		isWhitelisted[e] = true
+	}
+	for _, entry := range candidates {
+		if isWhitelisted[entry] {
+			return false, true, nil
+		}
+		if isBlocked[entry] {
+			return true, false, nil
+		}
+	}
+	return false, false, nil
 }
 
 // ListBlockedIPs returns all blocked IP addresses.
@@ -591,6 +679,24 @@ func (s *Store) ListBlockedIPs(ctx context.Context) ([]*sentinel.BlockedIP, erro
 	return result, nil
 }
 
+// ListWhitelistedIPs returns all whitelisted IP addresses.
+func (s *Store) ListWhitelistedIPs(ctx context.Context) ([]*sentinel.WhitelistedIP, error) {
+	var rows []whitelistedIPRow
+	if err := s.db.WithContext(ctx).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]*sentinel.WhitelistedIP, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, &sentinel.WhitelistedIP{
+			IP:          row.IP,
+			WhitelistAt: row.WhitelistAt,
+			CIDR:        row.CIDR,
+		})
+	}
+	return result, nil
+}
+
 // GetThreatStats returns aggregated threat statistics for the given time window.
 func (s *Store) GetThreatStats(ctx context.Context, window time.Duration) (*sentinel.ThreatStats, error) {
 	cutoff := time.Now().Add(-window)
@@ -654,6 +760,408 @@ func (s *Store) SaveSecurityScore(ctx context.Context, score *sentinel.SecurityS
 	return s.db.WithContext(ctx).Create(&row).Error
 }
 
+type dashboardUserRow struct {
+	ID           string     `gorm:"primaryKey;column:id"`
+	Username     string     `gorm:"uniqueIndex;size:191;column:username"`
+	Email        string     `gorm:"column:email"`
+	Role         string     `gorm:"column:role"`
+	PasswordHash string     `gorm:"column:password_hash"`
+	Disabled     bool       `gorm:"column:disabled"`
+	CreatedAt    time.Time  `gorm:"column:created_at"`
+	UpdatedAt    time.Time  `gorm:"column:updated_at"`
+	LastLoginAt  *time.Time `gorm:"column:last_login_at"`
+}
+
+func (dashboardUserRow) TableName() string { return "sentinel_dashboard_users" }
+
+type apiKeyRow struct {
+	ID         string     `gorm:"primaryKey;column:id"`
+	Name       string     `gorm:"column:name"`
+	Hash       string     `gorm:"column:hash"`
+	Scopes     string     `gorm:"column:scopes"`      // JSON array
+	AllowedIPs string     `gorm:"column:allowed_ips"` // JSON array
+	CreatedBy  string     `gorm:"column:created_by"`
+	CreatedAt  time.Time  `gorm:"index;column:created_at"`
+	ExpiresAt  *time.Time `gorm:"column:expires_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at"`
+}
+
+func (apiKeyRow) TableName() string { return "sentinel_api_keys" }
+
+type baselineRow struct {
+	UserID    string    `gorm:"primaryKey;size:191;column:user_id"`
+	Baseline  string    `gorm:"column:baseline"` // JSON blob
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+	Version   int64     `gorm:"column:version;not null;default:1"`
+}
+
+func (baselineRow) TableName() string { return "sentinel_user_baselines" }
+
+type campaignRow struct {
+	ID        string    `gorm:"primaryKey;size:191;column:id"`
+	Status    string    `gorm:"index;column:status"`
+	RiskScore int       `gorm:"index;column:risk_score"`
+	LastSeen  time.Time `gorm:"index;column:last_seen"`
+	Campaign  string    `gorm:"column:campaign"` // JSON blob
+}
+
+func (campaignRow) TableName() string { return "sentinel_campaigns" }
+
+// SaveConfigVersion appends a config version. The database assigns the
+// version number.
+func (s *Store) SaveConfigVersion(ctx context.Context, v *sentinel.ConfigVersion) error {
+	data, err := json.Marshal(v.Overrides)
+	if err != nil {
+		return err
+	}
+	if v.CreatedAt.IsZero() {
+		v.CreatedAt = time.Now()
+	}
+	row := configVersionRow{
+		CreatedAt: v.CreatedAt,
+		Author:    v.Author,
+		Change:    v.Change,
+		Overrides: string(data),
+	}
+	if err := s.db.WithContext(ctx).Create(&row).Error; err != nil {
+		return err
+	}
+	v.Version = row.Version
+	return nil
+}
+
+// GetConfigVersion returns a config version by number.
+func (s *Store) GetConfigVersion(ctx context.Context, version int) (*sentinel.ConfigVersion, error) {
+	var row configVersionRow
+	err := s.db.WithContext(ctx).Where("version = ?", version).First(&row).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return rowToConfigVersion(row)
+}
+
+// LatestConfigVersion returns the newest config version.
+func (s *Store) LatestConfigVersion(ctx context.Context) (*sentinel.ConfigVersion, error) {
+	var row configVersionRow
+	err := s.db.WithContext(ctx).Order("version DESC").First(&row).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return rowToConfigVersion(row)
+}
+
+// ListConfigVersions returns up to limit config versions, newest first.
+func (s *Store) ListConfigVersions(ctx context.Context, limit int) ([]*sentinel.ConfigVersion, error) {
+	q := s.db.WithContext(ctx).Order("version DESC")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	var rows []configVersionRow
+	if err := q.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	result := make([]*sentinel.ConfigVersion, 0, len(rows))
+	for _, row := range rows {
+		v, err := rowToConfigVersion(row)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+func rowToConfigVersion(row configVersionRow) (*sentinel.ConfigVersion, error) {
+	v := &sentinel.ConfigVersion{
+		Version:   row.Version,
+		CreatedAt: row.CreatedAt,
+		Author:    row.Author,
+		Change:    row.Change,
+	}
+	if err := json.Unmarshal([]byte(row.Overrides), &v.Overrides); err != nil {
+		return nil, fmt.Errorf("config version %d: %w", row.Version, err)
+	}
+	return v, nil
+}
+
+// SaveDashboardUser creates or updates a dashboard account.
+func (s *Store) SaveDashboardUser(ctx context.Context, u *sentinel.DashboardUser) error {
+	var taken int64
+	err := s.db.WithContext(ctx).Model(&dashboardUserRow{}).
+		Where("username = ? AND id <> ?", u.Username, u.ID).Count(&taken).Error
+	if err != nil {
+		return err
+	}
+	if taken > 0 {
+		return storage.ErrDuplicateUsername
+	}
+	row := dashboardUserRow{
+		ID:           u.ID,
+		Username:     u.Username,
+		Email:        u.Email,
+		Role:         string(u.Role),
+		PasswordHash: u.PasswordHash,
+		Disabled:     u.Disabled,
+		CreatedAt:    u.CreatedAt,
+		UpdatedAt:    u.UpdatedAt,
+		LastLoginAt:  u.LastLoginAt,
+	}
+	return s.db.WithContext(ctx).Save(&row).Error
+}
+
+// GetDashboardUser returns a dashboard account by ID.
+func (s *Store) GetDashboardUser(ctx context.Context, id string) (*sentinel.DashboardUser, error) {
+	return s.findDashboardUser(ctx, "id = ?", id)
+}
+
+// GetDashboardUserByUsername returns a dashboard account by username.
+func (s *Store) GetDashboardUserByUsername(ctx context.Context, username string) (*sentinel.DashboardUser, error) {
+	return s.findDashboardUser(ctx, "username = ?", username)
+}
+
+func (s *Store) findDashboardUser(ctx context.Context, query string, arg string) (*sentinel.DashboardUser, error) {
+	var row dashboardUserRow
+	err := s.db.WithContext(ctx).Where(query, arg).First(&row).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return rowToDashboardUser(row), nil
+}
+
+// ListDashboardUsers returns every dashboard account ordered by username.
+func (s *Store) ListDashboardUsers(ctx context.Context) ([]*sentinel.DashboardUser, error) {
+	var rows []dashboardUserRow
+	if err := s.db.WithContext(ctx).Order("username ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	result := make([]*sentinel.DashboardUser, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, rowToDashboardUser(row))
+	}
+	return result, nil
+}
+
+// DeleteDashboardUser removes a dashboard account.
+func (s *Store) DeleteDashboardUser(ctx context.Context, id string) (bool, error) {
+	res := s.db.WithContext(ctx).Where("id = ?", id).Delete(&dashboardUserRow{})
+	return res.RowsAffected > 0, res.Error
+}
+
+func rowToDashboardUser(row dashboardUserRow) *sentinel.DashboardUser {
+	return &sentinel.DashboardUser{
+		ID:           row.ID,
+		Username:     row.Username,
+		Email:        row.Email,
+		Role:         sentinel.DashboardRole(row.Role),
+		PasswordHash: row.PasswordHash,
+		Disabled:     row.Disabled,
+		CreatedAt:    row.CreatedAt,
+		UpdatedAt:    row.UpdatedAt,
+		LastLoginAt:  row.LastLoginAt,
+	}
+}
+
+// SaveAPIKey creates or updates an API key.
+func (s *Store) SaveAPIKey(ctx context.Context, k *sentinel.APIKey) error {
+	scopes, _ := json.Marshal(k.Scopes)
+	allowed, _ := json.Marshal(k.AllowedIPs)
+	row := apiKeyRow{
+		ID:         k.ID,
+		Name:       k.Name,
+		Hash:       k.Hash,
+		Scopes:     string(scopes),
+		AllowedIPs: string(allowed),
+		CreatedBy:  k.CreatedBy,
+		CreatedAt:  k.CreatedAt,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+	}
+	return s.db.WithContext(ctx).Save(&row).Error
+}
+
+// GetAPIKey returns an API key by ID.
+func (s *Store) GetAPIKey(ctx context.Context, id string) (*sentinel.APIKey, error) {
+	var row apiKeyRow
+	err := s.db.WithContext(ctx).Where("id = ?", id).First(&row).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return rowToAPIKey(row), nil
+}
+
+// ListAPIKeys returns every API key, newest first.
+func (s *Store) ListAPIKeys(ctx context.Context) ([]*sentinel.APIKey, error) {
+	var rows []apiKeyRow
+	if err := s.db.WithContext(ctx).Order("created_at DESC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	result := make([]*sentinel.APIKey, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, rowToAPIKey(row))
+	}
+	return result, nil
+}
+
+// TouchAPIKey sets an API key's last-used time.
+func (s *Store) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+	return s.db.WithContext(ctx).Model(&apiKeyRow{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
+
+func rowToAPIKey(row apiKeyRow) *sentinel.APIKey {
+	k := &sentinel.APIKey{
+		ID:         row.ID,
+		Name:       row.Name,
+		Hash:       row.Hash,
+		CreatedBy:  row.CreatedBy,
+		CreatedAt:  row.CreatedAt,
+		ExpiresAt:  row.ExpiresAt,
+		LastUsedAt: row.LastUsedAt,
+		RevokedAt:  row.RevokedAt,
+	}
+	json.Unmarshal([]byte(row.Scopes), &k.Scopes)
+	json.Unmarshal([]byte(row.AllowedIPs), &k.AllowedIPs)
+	return k
+}
+
+// GetBaseline returns a user's behavioral baseline, or nil.
+func (s *Store) GetBaseline(ctx context.Context, userID string) (*sentinel.UserBaseline, error) {
+	var row baselineRow
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).First(&row).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var b sentinel.UserBaseline
+	if err := json.Unmarshal([]byte(row.Baseline), &b); err != nil {
+		return nil, err
+	}
+	b.Version = row.Version
+	return &b, nil
+}
+
+// SaveBaseline creates or replaces a user's behavioral baseline if its
+// stored version still matches b.Version.
+func (s *Store) SaveBaseline(ctx context.Context, b *sentinel.UserBaseline) error {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	var res *gorm.DB
+	if b.Version == 0 {
+		row := baselineRow{UserID: b.UserID, Baseline: string(data), UpdatedAt: b.UpdatedAt, Version: 1}
+		res = s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&row)
+	} else {
+		res = s.db.WithContext(ctx).Model(&baselineRow{}).
+			Where("user_id = ? AND version = ?", b.UserID, b.Version).
+			Updates(map[string]interface{}{
+				"baseline":   string(data),
+				"updated_at": b.UpdatedAt,
+				"version":    b.Version + 1,
+			})
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return storage.ErrBaselineConflict
+	}
+	b.Version++
+	return nil
+}
+
+// SaveCampaign creates or replaces a campaign.
+func (s *Store) SaveCampaign(ctx context.Context, c *sentinel.Campaign) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	row := campaignRow{
+		ID:        c.ID,
+		Status:    string(c.Status),
+		RiskScore: c.RiskScore,
+		LastSeen:  c.LastSeen,
+		Campaign:  string(data),
+	}
+	return s.db.WithContext(ctx).Save(&row).Error
+}
+
+// GetCampaign returns the campaign with the given ID, or nil.
+func (s *Store) GetCampaign(ctx context.Context, id string) (*sentinel.Campaign, error) {
+	var row campaignRow
+	err := s.db.WithContext(ctx).Where("id = ?", id).First(&row).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var c sentinel.Campaign
+	if err := json.Unmarshal([]byte(row.Campaign), &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListCampaigns returns a paginated, filtered list of campaigns, riskiest
+// first.
+func (s *Store) ListCampaigns(ctx context.Context, filter sentinel.CampaignFilter) ([]*sentinel.Campaign, int64, error) {
+	if filter.PageSize <= 0 {
+		filter.PageSize = 20
+	}
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+
+	query := s.db.WithContext(ctx).Model(&campaignRow{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", string(filter.Status))
+	}
+	if filter.MinRisk > 0 {
+		query = query.Where("risk_score >= ?", filter.MinRisk)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var rows []campaignRow
+	offset := (filter.Page - 1) * filter.PageSize
+	err := query.Order("risk_score DESC, last_seen DESC").Offset(offset).Limit(filter.PageSize).Find(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	campaigns := make([]*sentinel.Campaign, 0, len(rows))
+	for _, row := range rows {
+		var c sentinel.Campaign
+		if err := json.Unmarshal([]byte(row.Campaign), &c); err != nil {
+			return nil, 0, err
+		}
+		campaigns = append(campaigns, &c)
+	}
+	return campaigns, total, nil
+}
+
+// DeleteCampaign removes a campaign.
+func (s *Store) DeleteCampaign(ctx context.Context, id string) error {
+	return s.db.WithContext(ctx).Where("id = ?", id).Delete(&campaignRow{}).Error
+}
+
 // Cleanup removes events older than the specified duration.
 func (s *Store) Cleanup(ctx context.Context, olderThan time.Duration) error {
 	cutoff := time.Now().Add(-olderThan)
@@ -678,14 +1186,115 @@ func (s *Store) ListUsers(ctx context.Context) ([]*sentinel.UserSummary, error)
 	return []*sentinel.UserSummary{}, nil
 }
 
-// GetAttackTrends returns attack trend data for the given window and interval (stub).
+// GetAttackTrends returns attack counts bucketed by UTC hour ("hour") or day
+// (anything else) within the given window, oldest first. Periods use the
+// same "2006-01-02T15:00" / "2006-01-02" keys as the memory store.
+//
+// Bucketing runs in the database; the per-type breakdown is decoded from
+// the threat_types JSON of each distinct (period, threat_types) group, so
+// rows are never loaded one by one.
 func (s *Store) GetAttackTrends(ctx context.Context, window time.Duration, interval string) ([]*sentinel.AttackTrend, error) {
-	return []*sentinel.AttackTrend{}, nil
+	cutoff := time.Now().Add(-window)
+	period := s.periodExpr(interval)
+
+	var groups []struct {
+		Period      string
+		ThreatTypes string
+		Total       int64
+	}
+	err := s.db.WithContext(ctx).Model(&threatEventRow{}).
+		Select(period+" AS period, threat_types, COUNT(*) AS total").
+		Where("timestamp >= ?", cutoff).
+		Group(period + ", threat_types").
+		Scan(&groups).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byPeriod := make(map[string]*sentinel.AttackTrend)
+	for _, g := range groups {
+		trend, ok := byPeriod[g.Period]
+		if !ok {
+			trend = &sentinel.AttackTrend{Period: g.Period, ByType: make(map[string]int64)}
+			byPeriod[g.Period] = trend
+		}
+		trend.Total += g.Total
+		var types []string
+		json.Unmarshal([]byte(g.ThreatTypes), &types)
+		for _, tt := range types {
+			trend.ByType[tt] += g.Total
+		}
+	}
+
+	result := make([]*sentinel.AttackTrend, 0, len(byPeriod))
+	for _, trend := range byPeriod {
+		result = append(result, trend)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Period < result[j].Period
+	})
+	return result, nil
 }
 
-// GetGeoStats returns geographic statistics for threats in the given window (stub).
+// periodExpr returns the SQL expression formatting the timestamp column as a
+// UTC trend period. Date functions are the one place the SQLite, Postgres
+// and MySQL dialects disagree.
+func (s *Store) periodExpr(interval string) string {
+	hourly := interval == "hour"
+	switch s.db.Dialector.Name() {
+	case "postgres":
+		if hourly {
+			return `to_char("timestamp" AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:00')`
+		}
+		return `to_char("timestamp" AT TIME ZONE 'UTC', 'YYYY-MM-DD')`
+	case "mysql":
+		// DATETIME carries no zone; storage/mysql pins the connection to
+		// UTC, so stored values already are UTC.
+		if hourly {
+			return "DATE_FORMAT(`timestamp`, '%Y-%m-%dT%H:00')"
+		}
+		return "DATE_FORMAT(`timestamp`, '%Y-%m-%d')"
+	default:
+		if hourly {
+			return "strftime('%Y-%m-%dT%H:00', timestamp)"
+		}
+		return "strftime('%Y-%m-%d', timestamp)"
+	}
+}
+
+// GetGeoStats returns per-country threat counts within the given window,
+// most active first. Events without a resolved country are skipped; the
+// coordinates are the average of the country's events.
 func (s *Store) GetGeoStats(ctx context.Context, window time.Duration) ([]*sentinel.GeoStats, error) {
-	return []*sentinel.GeoStats{}, nil
+	cutoff := time.Now().Add(-window)
+
+	var rows []struct {
+		Country string
+		Total   int64
+		Lat     float64
+		Lng     float64
+	}
+	err := s.db.WithContext(ctx).Model(&threatEventRow{}).
+		Select("country, COUNT(*) AS total, AVG(lat) AS lat, AVG(lng) AS lng").
+		Where("timestamp >= ? AND country <> ?", cutoff, "").
+		Group("country").
+		Order("total DESC, country").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*sentinel.GeoStats, 0, len(rows))
+	for _, r := range rows {
+		result = append(result, &sentinel.GeoStats{
+			Country:     r.Country,
+			CountryCode: r.Country,
+			Count:       r.Total,
+			Lat:         r.Lat,
+			Lng:         r.Lng,
+		})
+	}
+	return result, nil
 }
 
 // GetTopTargets returns the most targeted routes in the given window (stub).
@@ -699,6 +1308,10 @@ func threatToRow(e *sentinel.ThreatEvent) threatEventRow {
 	headers, _ := json.Marshal(e.Headers)
 	types, _ := json.Marshal(e.ThreatTypes)
 	evidence, _ := json.Marshal(e.Evidence)
+	var challenge []byte
+	if e.Challenge != nil {
+		challenge, _ = json.Marshal(e.Challenge)
+	}
 
 	return threatEventRow{
 		ID:            e.ID,
@@ -725,6 +1338,20 @@ func threatToRow(e *sentinel.ThreatEvent) threatEventRow {
 		Lng:           e.Lng,
 		Resolved:      e.Resolved,
 		FalsePositive: e.FalsePositive,
+		Challenge:     string(challenge),
+
+		AnomalyScore:     e.AnomalyScore,
+		AnomalyThreshold: e.AnomalyThreshold,
+
+		HeaderFingerprint: e.HeaderFingerprint,
+		JA3:               e.JA3,
+		JA4:               e.JA4,
+		BotVerdict:        string(e.BotVerdict),
+		BotScore:          e.BotScore,
+		FeedSource:        e.FeedSource,
+		FeedScore:         e.FeedScore,
+		NetworkType:       string(e.NetworkType),
+		NetworkScore:      e.NetworkScore,
 	}
 }
 
@@ -738,6 +1365,14 @@ func rowToThreat(r *threatEventRow) *sentinel.ThreatEvent {
 	var evidence []sentinel.Evidence
 	json.Unmarshal([]byte(r.Evidence), &evidence)
 
+	var challenge *sentinel.ChallengeStats
+	if r.Challenge != "" {
+		challenge = &sentinel.ChallengeStats{}
+		if json.Unmarshal([]byte(r.Challenge), challenge) != nil {
+			challenge = nil
+		}
+	}
+
 	return &sentinel.ThreatEvent{
 		ID:            r.ID,
 		Timestamp:     r.Timestamp,
@@ -763,12 +1398,37 @@ func rowToThreat(r *threatEventRow) *sentinel.ThreatEvent {
 		Lng:           r.Lng,
 		Resolved:      r.Resolved,
 		FalsePositive: r.FalsePositive,
+		Challenge:     challenge,
+
+		AnomalyScore:     r.AnomalyScore,
+		AnomalyThreshold: r.AnomalyThreshold,
+
+		HeaderFingerprint: r.HeaderFingerprint,
+		JA3:               r.JA3,
+		JA4:               r.JA4,
+		BotVerdict:        sentinel.BotVerdict(r.BotVerdict),
+		BotScore:          r.BotScore,
+		FeedSource:        r.FeedSource,
+		FeedScore:         r.FeedScore,
+		NetworkType:       sentinel.NetworkType(r.NetworkType),
+		NetworkScore:      r.NetworkScore,
 	}
 }
 
 func actorToRow(a *sentinel.ThreatActor) threatActorRow {
 	types, _ := json.Marshal(a.AttackTypes)
 	routes, _ := json.Marshal(a.TargetedRoutes)
+	var ja3, ja4 []byte
+	if len(a.JA3) > 0 {
+		ja3, _ = json.Marshal(a.JA3)
+	}
+	if len(a.JA4) > 0 {
+		ja4, _ = json.Marshal(a.JA4)
+	}
+	var feeds []byte
+	if len(a.ThreatFeeds) > 0 {
+		feeds, _ = json.Marshal(a.ThreatFeeds)
+	}
 
 	return threatActorRow{
 		ID:              a.ID,
@@ -788,6 +1448,13 @@ func actorToRow(a *sentinel.ThreatActor) threatActorRow {
 		AbuseScore:      a.AbuseScore,
 		Lat:             a.Lat,
 		Lng:             a.Lng,
+		CampaignID:      a.CampaignID,
+		JA3:             string(ja3),
+		JA4:             string(ja4),
+		ThreatFeeds:     string(feeds),
+		FeedScore:       a.FeedScore,
+		NetworkType:     string(a.NetworkType),
+		NetworkScore:    a.NetworkScore,
 	}
 }
 
@@ -798,6 +1465,18 @@ func rowToActor(r *threatActorRow) *sentinel.ThreatActor {
 	var routes []string
 	json.Unmarshal([]byte(r.TargetedRoutes), &routes)
 
+	var ja3, ja4 []string
+	if r.JA3 != "" {
+		json.Unmarshal([]byte(r.JA3), &ja3)
+	}
+	if r.JA4 != "" {
+		json.Unmarshal([]byte(r.JA4), &ja4)
+	}
+	var feeds []string
+	if r.ThreatFeeds != "" {
+		json.Unmarshal([]byte(r.ThreatFeeds), &feeds)
+	}
+
 	return &sentinel.ThreatActor{
 		ID:              r.ID,
 		IP:              r.IP,
@@ -816,6 +1495,13 @@ func rowToActor(r *threatActorRow) *sentinel.ThreatActor {
 		AbuseScore:      r.AbuseScore,
 		Lat:             r.Lat,
 		Lng:             r.Lng,
+		CampaignID:      r.CampaignID,
+		JA3:             ja3,
+		JA4:             ja4,
+		ThreatFeeds:     feeds,
+		FeedScore:       r.FeedScore,
+		NetworkType:     sentinel.NetworkType(r.NetworkType),
+		NetworkScore:    r.NetworkScore,
 	}
 }
 
@@ -2,6 +2,7 @@ package sqlite
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	sentinel "github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/storage"
 )
 
 func newTestStore(t *testing.T) *Store {
@@ -44,8 +46,11 @@ func TestSQLiteSaveThreatAndGet(t *testing.T) {
 		Confidence:  85,
 		Blocked:     true,
 		Evidence: []sentinel.Evidence{
-			{Pattern: "SQLi_Basic", Matched: "' OR 1=1--", Location: "query"},
+			{Pattern: "SQLi_Basic", Matched: "' OR 1=1--", Location: "query", Score: 4},
 		},
+		Challenge:        &sentinel.ChallengeStats{Outcome: sentinel.ChallengeIssued, Issued: 4, Solved: 1, SolveRate: 0.25},
+		AnomalyScore:     4,
+		AnomalyThreshold: 5,
 	}
 
 	if err := s.SaveThreat(ctx, event); err != nil {
@@ -68,8 +73,14 @@ func TestSQLiteSaveThreatAndGet(t *testing.T) {
 	if len(got.ThreatTypes) != 1 || got.ThreatTypes[0] != "SQLi" {
 		t.Errorf("expected threat types [SQLi], got %v", got.ThreatTypes)
 	}
-	if len(got.Evidence) != 1 {
-		t.Errorf("expected 1 evidence, got %d", len(got.Evidence))
+	if len(got.Evidence) != 1 || got.Evidence[0].Score != 4 {
+		t.Errorf("expected 1 scored evidence, got %+v", got.Evidence)
+	}
+	if got.AnomalyScore != 4 || got.AnomalyThreshold != 5 {
+		t.Errorf("anomaly score not round-tripped: %d/%d", got.AnomalyScore, got.AnomalyThreshold)
+	}
+	if got.Challenge == nil || *got.Challenge != *event.Challenge {
+		t.Errorf("challenge stats not round-tripped: %+v", got.Challenge)
 	}
 }
 
@@ -216,6 +227,46 @@ func TestSQLiteIPManagement(t *testing.T) {
 	}
 }
 
+func TestSQLiteIPPrefixes(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	s.BlockIP(ctx, "10.1.0.0/16", "range", nil)
+	s.WhitelistIP(ctx, "10.1.2.3")
+	s.WhitelistIP(ctx, "2001:db8::/32")
+	s.BlockIP(ctx, "2001:db8::bad", "host", nil)
+
+	cases := []struct {
+		ip                   string
+		blocked, whitelisted bool
+	}{
+		{"10.1.9.9", true, false},
+		{"10.1.2.3", false, true},
+		{"10.2.0.1", false, false},
+		{"2001:db8::1", false, true},
+		{"2001:db8::bad", true, false},
+	}
+	for _, tc := range cases {
+		blocked, err := s.IsIPBlocked(ctx, tc.ip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		whitelisted, _ := s.IsIPWhitelisted(ctx, tc.ip)
+		if blocked != tc.blocked || whitelisted != tc.whitelisted {
+			t.Errorf("%s: blocked %v whitelisted %v, want %v %v", tc.ip, blocked, whitelisted, tc.blocked, tc.whitelisted)
+		}
+	}
+
+	s.UnwhitelistIP(ctx, "10.1.2.3")
+	if blocked, _ := s.IsIPBlocked(ctx, "10.1.2.3"); !blocked {
+		t.Error("expected the /16 to apply once the whitelist entry is gone")
+	}
+	list, _ := s.ListWhitelistedIPs(ctx)
+	if len(list) != 1 || list[0].IP != "2001:db8::/32" || !list[0].CIDR {
+		t.Errorf("unexpected whitelist %+v", list)
+	}
+}
+
 func TestSQLiteThreatStats(t *testing.T) {
 	s := newTestStore(t)
 	ctx := context.Background()
@@ -252,6 +303,72 @@ func TestSQLiteThreatStats(t *testing.T) {
 	}
 }
 
+func TestSQLiteAttackTrendsAndGeoStats(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	// Non-UTC timestamps must land in their UTC bucket.
+	east := time.FixedZone("UTC+2", 2*60*60)
+	hour := time.Now().UTC().Truncate(time.Hour).Add(-2 * time.Hour)
+	events := []*sentinel.ThreatEvent{
+		{ID: "a", Timestamp: hour.Add(5 * time.Minute).In(east), IP: "1.1.1.1", ThreatTypes: []string{"SQLi"}, Country: "DE", Lat: 50, Lng: 8},
+		{ID: "b", Timestamp: hour.Add(10 * time.Minute), IP: "1.1.1.2", ThreatTypes: []string{"SQLi", "XSS"}, Country: "DE", Lat: 52, Lng: 10},
+		{ID: "c", Timestamp: hour.Add(70 * time.Minute), IP: "2.2.2.2", ThreatTypes: []string{"SQLi"}, Country: "US"},
+		{ID: "d", Timestamp: hour.Add(75 * time.Minute), IP: "3.3.3.3", ThreatTypes: []string{"XSS"}},
+		{ID: "old", Timestamp: time.Now().Add(-48 * time.Hour), IP: "4.4.4.4", ThreatTypes: []string{"SQLi"}, Country: "FR"},
+	}
+	for _, e := range events {
+		if err := s.SaveThreat(ctx, e); err != nil {
+			t.Fatalf("SaveThreat: %v", err)
+		}
+	}
+
+	trends, err := s.GetAttackTrends(ctx, 24*time.Hour, "hour")
+	if err != nil {
+		t.Fatalf("GetAttackTrends: %v", err)
+	}
+	if len(trends) != 2 {
+		t.Fatalf("expected 2 hourly buckets, got %d: %+v", len(trends), trends)
+	}
+	first := trends[0]
+	if first.Period != hour.Format("2006-01-02T15:00") || first.Total != 2 ||
+		first.ByType["SQLi"] != 2 || first.ByType["XSS"] != 1 {
+		t.Errorf("unexpected first bucket: %+v", first)
+	}
+	if trends[1].Total != 2 || trends[1].ByType["SQLi"] != 1 || trends[1].ByType["XSS"] != 1 {
+		t.Errorf("unexpected second bucket: %+v", trends[1])
+	}
+
+	daily, err := s.GetAttackTrends(ctx, 72*time.Hour, "day")
+	if err != nil {
+		t.Fatalf("GetAttackTrends(day): %v", err)
+	}
+	var total int64
+	for _, d := range daily {
+		if len(d.Period) != len("2006-01-02") {
+			t.Errorf("unexpected daily period %q", d.Period)
+		}
+		total += d.Total
+	}
+	if total != 5 {
+		t.Errorf("expected 5 events across daily buckets, got %d", total)
+	}
+
+	geo, err := s.GetGeoStats(ctx, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("GetGeoStats: %v", err)
+	}
+	if len(geo) != 2 {
+		t.Fatalf("expected DE and US, got %+v", geo)
+	}
+	if geo[0].Country != "DE" || geo[0].Count != 2 || geo[0].Lat != 51 || geo[0].Lng != 9 {
+		t.Errorf("unexpected top country: %+v", geo[0])
+	}
+	if geo[1].Country != "US" || geo[1].Count != 1 {
+		t.Errorf("unexpected second country: %+v", geo[1])
+	}
+}
+
 func TestSQLitePerformance(t *testing.T) {
 	s := newTestStore(t)
 	ctx := context.Background()
@@ -331,3 +448,224 @@ func TestSQLiteCleanup(t *testing.T) {
 		t.Error("expected new threat to still exist")
 	}
 }
+
+func TestSQLiteConfigVersions(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	if v, err := s.LatestConfigVersion(ctx); err != nil || v != nil {
+		t.Fatalf("expected no versions, got %v, %v", v, err)
+	}
+
+	for i, mode := range []sentinel.WAFMode{sentinel.ModeBlock, sentinel.ModeLog} {
+		v := &sentinel.ConfigVersion{
+			Author: "admin",
+			Change: fmt.Sprintf("edit %d", i),
+			Overrides: sentinel.ConfigOverrides{
+				WAFMode:     mode,
+				RouteLimits: map[string]sentinel.Limit{"/api/login": {Requests: 5, Window: time.Minute}},
+			},
+		}
+		if err := s.SaveConfigVersion(ctx, v); err != nil {
+			t.Fatalf("SaveConfigVersion: %v", err)
+		}
+		if v.Version != i+1 {
+			t.Errorf("expected version %d, got %d", i+1, v.Version)
+		}
+	}
+
+	latest, err := s.LatestConfigVersion(ctx)
+	if err != nil {
+		t.Fatalf("LatestConfigVersion: %v", err)
+	}
+	if latest.Version != 2 || latest.Overrides.WAFMode != sentinel.ModeLog || latest.Author != "admin" || latest.Change != "edit 1" {
+		t.Errorf("unexpected latest version %+v", latest)
+	}
+	if l := latest.Overrides.RouteLimits["/api/login"]; l.Requests != 5 || l.Window != time.Minute {
+		t.Errorf("route limit not round-tripped: %+v", l)
+	}
+
+	list, _ := s.ListConfigVersions(ctx, 1)
+	if len(list) != 1 || list[0].Version != 2 {
+		t.Errorf("expected only the newest version, got %d", len(list))
+	}
+	if v, _ := s.GetConfigVersion(ctx, 1); v == nil || v.Overrides.WAFMode != sentinel.ModeBlock {
+		t.Errorf("GetConfigVersion(1) = %+v", v)
+	}
+	if v, _ := s.GetConfigVersion(ctx, 9); v != nil {
+		t.Errorf("expected nil for a missing version, got %+v", v)
+	}
+}
+
+func TestSQLiteDashboardUsers(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Second)
+	u := &sentinel.DashboardUser{ID: "u1", Username: "ana", Role: sentinel.RoleAnalyst, PasswordHash: "hash", CreatedAt: now, UpdatedAt: now}
+	if err := s.SaveDashboardUser(ctx, u); err != nil {
+		t.Fatalf("SaveDashboardUser: %v", err)
+	}
+	s.SaveDashboardUser(ctx, &sentinel.DashboardUser{ID: "u2", Username: "bob", Role: sentinel.RoleViewer})
+
+	dup := &sentinel.DashboardUser{ID: "u3", Username: "ana", Role: sentinel.RoleAdmin}
+	if err := s.SaveDashboardUser(ctx, dup); !errors.Is(err, storage.ErrDuplicateUsername) {
+		t.Errorf("expected ErrDuplicateUsername, got %v", err)
+	}
+
+	u.Role = sentinel.RoleAdmin
+	u.LastLoginAt = &now
+	if err := s.SaveDashboardUser(ctx, u); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	got, err := s.GetDashboardUserByUsername(ctx, "ana")
+	if err != nil || got == nil || got.ID != "u1" || got.Role != sentinel.RoleAdmin || got.PasswordHash != "hash" || got.LastLoginAt == nil {
+		t.Fatalf("GetDashboardUserByUsername = %+v, %v", got, err)
+	}
+	if got, _ := s.GetDashboardUser(ctx, "missing"); got != nil {
+		t.Errorf("expected nil for a missing account, got %+v", got)
+	}
+
+	list, _ := s.ListDashboardUsers(ctx)
+	if len(list) != 2 || list[0].Username != "ana" || list[1].Username != "bob" {
+		t.Errorf("unexpected account list %+v", list)
+	}
+	if ok, _ := s.DeleteDashboardUser(ctx, "u2"); !ok {
+		t.Error("expected the delete to report an existing account")
+	}
+	if ok, _ := s.DeleteDashboardUser(ctx, "u2"); ok {
+		t.Error("second delete should report a missing account")
+	}
+}
+
+func TestSQLiteAPIKeys(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Second)
+	k := &sentinel.APIKey{ID: "k1", Name: "siem", Hash: "h1", Scopes: []string{"threats:read"}, AllowedIPs: []string{"10.0.0.0/8"}, CreatedAt: now}
+	if err := s.SaveAPIKey(ctx, k); err != nil {
+		t.Fatalf("SaveAPIKey: %v", err)
+	}
+	s.SaveAPIKey(ctx, &sentinel.APIKey{ID: "k2", Name: "ci", Hash: "h2", Scopes: []string{"ip:write"}, CreatedAt: now.Add(time.Second)})
+
+	if err := s.TouchAPIKey(ctx, "k1", now); err != nil {
+		t.Fatalf("TouchAPIKey: %v", err)
+	}
+	k.RevokedAt = &now
+	s.SaveAPIKey(ctx, k)
+
+	got, err := s.GetAPIKey(ctx, "k1")
+	if err != nil || got == nil || got.Hash != "h1" || len(got.Scopes) != 1 || got.AllowedIPs[0] != "10.0.0.0/8" || got.RevokedAt == nil {
+		t.Fatalf("GetAPIKey = %+v, %v", got, err)
+	}
+	if got, _ := s.GetAPIKey(ctx, "missing"); got != nil {
+		t.Errorf("expected nil for a missing key, got %+v", got)
+	}
+	list, _ := s.ListAPIKeys(ctx)
+	if len(list) != 2 || list[0].ID != "k2" {
+		t.Errorf("expected newest first, got %+v", list)
+	}
+}
+
+func TestSQLiteBaselines(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	if got, err := s.GetBaseline(ctx, "u1"); err != nil || got != nil {
+		t.Fatalf("expected no baseline, got %+v, %v", got, err)
+	}
+	now := time.Now().Truncate(time.Second)
+	b := &sentinel.UserBaseline{
+		UserID:    "u1",
+		Weight:    2,
+		Routes:    map[string]float64{"GET /api/data": 2},
+		SourceIPs: map[string]time.Time{"10.0.0.1": now},
+		Samples:   2,
+		UpdatedAt: now,
+	}
+	b.HourWeights[9] = 2
+	if err := s.SaveBaseline(ctx, b); err != nil {
+		t.Fatalf("SaveBaseline: %v", err)
+	}
+	b.Samples = 3
+	if err := s.SaveBaseline(ctx, b); err != nil {
+		t.Fatalf("SaveBaseline (update): %v", err)
+	}
+
+	got, err := s.GetBaseline(ctx, "u1")
+	if err != nil || got == nil || got.Samples != 3 || got.HourWeights[9] != 2 ||
+		got.Routes["GET /api/data"] != 2 || !got.SourceIPs["10.0.0.1"].Equal(now) || got.Version != 2 {
+		t.Fatalf("GetBaseline = %+v, %v", got, err)
+	}
+
+	// b was saved since got was read, so got is stale.
+	b.Samples = 4
+	if err := s.SaveBaseline(ctx, b); err != nil {
+		t.Fatalf("SaveBaseline (second update): %v", err)
+	}
+	got.Samples = 10
+	if err := s.SaveBaseline(ctx, got); !errors.Is(err, storage.ErrBaselineConflict) {
+		t.Errorf("expected ErrBaselineConflict for a stale baseline, got %v", err)
+	}
+	if err := s.SaveBaseline(ctx, &sentinel.UserBaseline{UserID: "u1"}); !errors.Is(err, storage.ErrBaselineConflict) {
+		t.Errorf("expected ErrBaselineConflict for a new baseline of a known user, got %v", err)
+	}
+	if got, _ := s.GetBaseline(ctx, "u1"); got == nil || got.Samples != 4 || got.Version != 3 {
+		t.Errorf("stale saves must not change the baseline, got %+v", got)
+	}
+}
+
+func TestSQLiteCampaigns(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	if got, err := s.GetCampaign(ctx, "c1"); err != nil || got != nil {
+		t.Fatalf("expected no campaign, got %+v, %v", got, err)
+	}
+	now := time.Now().Truncate(time.Second)
+	for i, risk := range []int{40, 90, 60} {
+		c := &sentinel.Campaign{
+			ID:         fmt.Sprintf("c%d", i+1),
+			FirstSeen:  now,
+			LastSeen:   now,
+			ActorIPs:   []string{"10.0.0.1", "10.0.0.2"},
+			ActorCount: 2,
+			Signals:    []sentinel.CampaignSignal{{Kind: "user_agent", Value: "sqlmap/1.7", Links: 1}},
+			RiskScore:  risk,
+			Status:     sentinel.CampaignActive,
+		}
+		if err := s.SaveCampaign(ctx, c); err != nil {
+			t.Fatalf("SaveCampaign: %v", err)
+		}
+	}
+
+	c, err := s.GetCampaign(ctx, "c1")
+	if err != nil || c == nil || len(c.ActorIPs) != 2 || len(c.Signals) != 1 {
+		t.Fatalf("GetCampaign = %+v, %v", c, err)
+	}
+	c.Status = sentinel.CampaignBlocked
+	if err := s.SaveCampaign(ctx, c); err != nil {
+		t.Fatalf("SaveCampaign (update): %v", err)
+	}
+
+	list, total, err := s.ListCampaigns(ctx, sentinel.CampaignFilter{Page: 1, PageSize: 10})
+	if err != nil || total != 3 || len(list) != 3 || list[0].ID != "c2" || list[2].ID != "c1" {
+		t.Fatalf("ListCampaigns = %v, %d, %v", list, total, err)
+	}
+	list, total, _ = s.ListCampaigns(ctx, sentinel.CampaignFilter{Status: sentinel.CampaignBlocked})
+	if total != 1 || len(list) != 1 || list[0].ID != "c1" {
+		t.Errorf("ListCampaigns(blocked) = %v, %d", list, total)
+	}
+	_, total, _ = s.ListCampaigns(ctx, sentinel.CampaignFilter{MinRisk: 50})
+	if total != 2 {
+		t.Errorf("ListCampaigns(min_risk=50) total = %d, want 2", total)
+	}
+
+	if err := s.DeleteCampaign(ctx, "c1"); err != nil {
+		t.Fatalf("DeleteCampaign: %v", err)
+	}
+	if got, _ := s.GetCampaign(ctx, "c1"); got != nil {
+		t.Error("campaign should be deleted")
+	}
+}
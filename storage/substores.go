@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	sentinel "github.com/MUKE-coder/sentinel/v2/core"
@@ -29,13 +30,21 @@ type ActorStore interface {
 // IPStore handles IP block / whitelist state. Splitting this out lets users
 // back the hot-path block check with Redis while keeping threat history in
 // SQLite/Postgres.
+//
+// Entries are canonical addresses, CIDR prefixes or ASNs (see
+// sentinel.ParseIPEntry). IsIPBlocked and IsIPWhitelisted resolve
+// overlapping address and prefix entries the same way IPManager does — the
+// most specific live entry decides, the whitelist winning ties — and ignore
+// ASN entries, which need geolocation to match.
 type IPStore interface {
 	BlockIP(ctx context.Context, ip string, reason string, expiry *time.Time) error
 	UnblockIP(ctx context.Context, ip string) error
 	WhitelistIP(ctx context.Context, ip string) error
+	UnwhitelistIP(ctx context.Context, ip string) error
 	IsIPBlocked(ctx context.Context, ip string) (bool, error)
 	IsIPWhitelisted(ctx context.Context, ip string) (bool, error)
 	ListBlockedIPs(ctx context.Context) ([]*sentinel.BlockedIP, error)
+	ListWhitelistedIPs(ctx context.Context) ([]*sentinel.WhitelistedIP, error)
 }
 
 // AuditStore handles immutable audit log persistence and retrieval.
@@ -58,6 +67,36 @@ type UserActivityStore interface {
 	ListUsers(ctx context.Context) ([]*sentinel.UserSummary, error)
 }
 
+// ErrBaselineConflict is returned by SaveBaseline when the baseline was
+// saved by someone else since b was read.
+var ErrBaselineConflict = errors.New("storage: baseline changed concurrently")
+
+// BaselineStore persists the per-user behavioral baselines the anomaly
+// detector updates on every activity, so they survive restarts and are
+// shared by replicas.
+type BaselineStore interface {
+	// GetBaseline returns a user's baseline, or nil if none was saved.
+	GetBaseline(ctx context.Context, userID string) (*sentinel.UserBaseline, error)
+	// SaveBaseline creates or replaces b, keyed by b.UserID, and advances
+	// b.Version. It returns ErrBaselineConflict unless the stored version
+	// still equals b.Version, or no baseline is stored and b.Version is 0.
+	SaveBaseline(ctx context.Context, b *sentinel.UserBaseline) error
+}
+
+// CampaignStore persists campaigns: groups of threat actors linked by
+// shared signals.
+type CampaignStore interface {
+	// SaveCampaign creates or replaces c, keyed by c.ID.
+	SaveCampaign(ctx context.Context, c *sentinel.Campaign) error
+	// GetCampaign returns the campaign with the given ID, or nil.
+	GetCampaign(ctx context.Context, id string) (*sentinel.Campaign, error)
+	// ListCampaigns returns a page of campaigns, riskiest first, and the
+	// number of campaigns matching the filter.
+	ListCampaigns(ctx context.Context, filter sentinel.CampaignFilter) ([]*sentinel.Campaign, int64, error)
+	// DeleteCampaign removes a campaign, as when it is merged into another.
+	DeleteCampaign(ctx context.Context, id string) error
+}
+
 // AnalyticsStore handles aggregated analytics queries.
 type AnalyticsStore interface {
 	GetAttackTrends(ctx context.Context, window time.Duration, interval string) ([]*sentinel.AttackTrend, error)
@@ -77,3 +116,51 @@ type LifecycleStore interface {
 	Cleanup(ctx context.Context, olderThan time.Duration) error
 	Close() error
 }
+
+// ConfigStore persists dashboard edits to the runtime configuration as an
+// append-only list of versions. Mount layers the latest version's overrides
+// over the code-supplied Config; rolling back appends a new version carrying
+// an older version's overrides, so history is never rewritten.
+type ConfigStore interface {
+	// SaveConfigVersion appends v, assigning v.Version (and v.CreatedAt
+	// when zero).
+	SaveConfigVersion(ctx context.Context, v *sentinel.ConfigVersion) error
+	// GetConfigVersion returns the given version, or nil if it does not exist.
+	GetConfigVersion(ctx context.Context, version int) (*sentinel.ConfigVersion, error)
+	// LatestConfigVersion returns the newest version, or nil if none was saved.
+	LatestConfigVersion(ctx context.Context) (*sentinel.ConfigVersion, error)
+	// ListConfigVersions returns up to limit versions, newest first.
+	ListConfigVersions(ctx context.Context, limit int) ([]*sentinel.ConfigVersion, error)
+}
+
+// ErrDuplicateUsername is returned by SaveDashboardUser when the username is
+// taken by another account.
+var ErrDuplicateUsername = errors.New("storage: username already exists")
+
+// DashboardUserStore persists dashboard accounts. Usernames are unique.
+type DashboardUserStore interface {
+	// SaveDashboardUser creates or updates u, keyed by u.ID. It returns
+	// ErrDuplicateUsername if another account already has u.Username.
+	SaveDashboardUser(ctx context.Context, u *sentinel.DashboardUser) error
+	// GetDashboardUser returns the account with the given ID, or nil.
+	GetDashboardUser(ctx context.Context, id string) (*sentinel.DashboardUser, error)
+	// GetDashboardUserByUsername returns the account with the given
+	// username, or nil.
+	GetDashboardUserByUsername(ctx context.Context, username string) (*sentinel.DashboardUser, error)
+	// ListDashboardUsers returns every account ordered by username.
+	ListDashboardUsers(ctx context.Context) ([]*sentinel.DashboardUser, error)
+	// DeleteDashboardUser removes an account and reports whether it existed.
+	DeleteDashboardUser(ctx context.Context, id string) (bool, error)
+}
+
+// APIKeyStore persists API keys. Revoked keys are kept for the audit trail.
+type APIKeyStore interface {
+	// SaveAPIKey creates or updates a key, keyed by k.ID.
+	SaveAPIKey(ctx context.Context, k *sentinel.APIKey) error
+	// GetAPIKey returns the key with the given ID, or nil.
+	GetAPIKey(ctx context.Context, id string) (*sentinel.APIKey, error)
+	// ListAPIKeys returns every key, newest first.
+	ListAPIKeys(ctx context.Context) ([]*sentinel.APIKey, error)
+	// TouchAPIKey sets a key's last-used time.
+	TouchAPIKey(ctx context.Context, id string, at time.Time) error
+}
@@ -1,11 +1,22 @@
 /* eslint-disable react-refresh/only-export-components -- context file intentionally co-exports the hook with the provider; splitting that just to satisfy fast-refresh would scatter related code across two files. */
-import { createContext, useContext, useState, useCallback } from 'react';
+import { createContext, useContext, useState, useCallback, useEffect } from 'react';
 
 const AuthContext = createContext(null);
 
 export function AuthProvider({ children }) {
   const [token, setToken] = useState(null);
 
+  // After an SSO login the server redirects here with a one-time session
+  // cookie; trade it for the dashboard token.
+  useEffect(() => {
+    fetch('/sentinel/api/auth/oidc/session', { credentials: 'same-origin' })
+      .then((res) => (res.ok ? res.json() : null))
+      .then((data) => {
+        if (data?.token) setToken(data.token);
+      })
+      .catch(() => {});
+  }, []);
+
   const login = useCallback(async (username, password) => {
     const res = await fetch('/sentinel/api/auth/login', {
       method: 'POST',
@@ -1,4 +1,4 @@
-import { useState } from 'react';
+import { useEffect, useState } from 'react';
 import { useAuth } from '../context/AuthContext';
 
 export default function Login() {
@@ -7,6 +7,16 @@ export default function Login() {
   const [password, setPassword] = useState('');
   const [error, setError] = useState('');
   const [loading, setLoading] = useState(false);
+  const [providers, setProviders] = useState({ password: true, oidc: false });
+
+  useEffect(() => {
+    fetch('/sentinel/api/auth/providers')
+      .then((res) => (res.ok ? res.json() : null))
+      .then((data) => {
+        if (data?.data) setProviders(data.data);
+      })
+      .catch(() => {});
+  }, []);
 
   const handleSubmit = async (e) => {
     e.preventDefault();
@@ -29,42 +39,53 @@ export default function Login() {
           <p className="text-[#8892a0] text-sm mt-2">Security Dashboard</p>
         </div>
 
-        <form onSubmit={handleSubmit} className="bg-[#0d1526] border border-[#1e2d4a] rounded-lg p-6 space-y-4">
-          {error && (
-            <div className="bg-[#ff2d5520] border border-[#ff2d5540] text-[#ff2d55] px-4 py-2 rounded text-sm">
-              {error}
-            </div>
-          )}
+        {providers.oidc && (
+          <a
+            href="/sentinel/api/auth/oidc/login"
+            className="block w-full mb-4 text-center border border-[#00d4ff] text-[#00d4ff] font-semibold py-2 rounded hover:bg-[#00d4ff20] transition-colors text-sm"
+          >
+            Sign in with SSO
+          </a>
+        )}
 
-          <div>
-            <label className="block text-[#8892a0] text-xs uppercase tracking-wider mb-1">Username</label>
-            <input
-              type="text"
-              value={username}
-              onChange={(e) => setUsername(e.target.value)}
-              className="w-full bg-[#0a0f1e] border border-[#1e2d4a] rounded px-3 py-2 text-[#e0e0e0] text-sm focus:border-[#00d4ff] focus:outline-none transition-colors"
-              autoFocus
-            />
-          </div>
+        {providers.password && (
+          <form onSubmit={handleSubmit} className="bg-[#0d1526] border border-[#1e2d4a] rounded-lg p-6 space-y-4">
+            {error && (
+              <div className="bg-[#ff2d5520] border border-[#ff2d5540] text-[#ff2d55] px-4 py-2 rounded text-sm">
+                {error}
+              </div>
+            )}
 
-          <div>
-            <label className="block text-[#8892a0] text-xs uppercase tracking-wider mb-1">Password</label>
-            <input
-              type="password"
-              value={password}
-              onChange={(e) => setPassword(e.target.value)}
-              className="w-full bg-[#0a0f1e] border border-[#1e2d4a] rounded px-3 py-2 text-[#e0e0e0] text-sm focus:border-[#00d4ff] focus:outline-none transition-colors"
-            />
-          </div>
+            <div>
+              <label className="block text-[#8892a0] text-xs uppercase tracking-wider mb-1">Username</label>
+              <input
+                type="text"
+                value={username}
+                onChange={(e) => setUsername(e.target.value)}
+                className="w-full bg-[#0a0f1e] border border-[#1e2d4a] rounded px-3 py-2 text-[#e0e0e0] text-sm focus:border-[#00d4ff] focus:outline-none transition-colors"
+                autoFocus
+              />
+            </div>
 
-          <button
-            type="submit"
-            disabled={loading}
-            className="w-full bg-[#00d4ff] text-[#0a0f1e] font-semibold py-2 rounded hover:bg-[#00b8e0] disabled:opacity-50 transition-colors text-sm"
-          >
-            {loading ? 'Signing in...' : 'Sign In'}
-          </button>
-        </form>
+            <div>
+              <label className="block text-[#8892a0] text-xs uppercase tracking-wider mb-1">Password</label>
+              <input
+                type="password"
+                value={password}
+                onChange={(e) => setPassword(e.target.value)}
+                className="w-full bg-[#0a0f1e] border border-[#1e2d4a] rounded px-3 py-2 text-[#e0e0e0] text-sm focus:border-[#00d4ff] focus:outline-none transition-colors"
+              />
+            </div>
+
+            <button
+              type="submit"
+              disabled={loading}
+              className="w-full bg-[#00d4ff] text-[#0a0f1e] font-semibold py-2 rounded hover:bg-[#00b8e0] disabled:opacity-50 transition-colors text-sm"
+            >
+              {loading ? 'Signing in...' : 'Sign In'}
+            </button>
+          </form>
+        )}
       </div>
     </div>
   );
@@ -2,11 +2,16 @@ package sentinel
 
 import (
 	"fmt"
+	"mime"
 	"net/netip"
+	"net/url"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/MUKE-coder/sentinel/v2/core"
+	"github.com/MUKE-coder/sentinel/v2/detection"
 	"github.com/MUKE-coder/sentinel/v2/middleware"
 )
 
@@ -61,6 +66,13 @@ func ValidateRoutePattern(pattern string) error {
 	return middleware.ValidateRoutePattern(pattern)
 }
 
+// ParseIPEntry canonicalizes a block, whitelist or WAF.ExcludeIPs entry —
+// see core.ParseIPEntry for the accepted shapes and how overlapping entries
+// are resolved.
+func ParseIPEntry(entry string) (string, error) {
+	return core.ParseIPEntry(entry)
+}
+
 // ValidateConfig inspects a Config for entries that would be silently
 // ignored or silently disable a feature at runtime. It never rejects a
 // config — Mount accepts everything it always accepted — it only reports.
@@ -89,10 +101,7 @@ func ValidateConfig(config Config) []ConfigIssue {
 
 	// --- Storage ---
 	switch config.Storage.Driver {
-	case SQLite, Postgres, Memory:
-	case MySQL:
-		report(IssueError, "Storage.Driver",
-			"the MySQL driver is not implemented — Mount silently falls back to in-memory storage and all security data is lost on restart; use sqlite or postgres")
+	case SQLite, Postgres, MySQL, Memory:
 	default:
 		report(IssueError, "Storage.Driver",
 			"unknown driver %q — Mount silently falls back to in-memory storage and all security data is lost on restart", config.Storage.Driver)
@@ -103,7 +112,36 @@ func ValidateConfig(config Config) []ConfigIssue {
 		report(IssueError, "Dashboard.Prefix",
 			"%q does not start with \"/\" — routes will be registered under a malformed path", config.Dashboard.Prefix)
 	}
-	if config.Dashboard.Password == core.DefaultInsecurePassword {
+	if o := config.Dashboard.OIDC; o != nil {
+		if o.IssuerURL == "" || o.ClientID == "" || o.RedirectURL == "" {
+			report(IssueError, "Dashboard.OIDC",
+				"IssuerURL, ClientID and RedirectURL are all required — SSO login fails on every attempt")
+		}
+		if u, err := url.Parse(o.RedirectURL); o.RedirectURL != "" && (err != nil || !u.IsAbs()) {
+			report(IssueError, "Dashboard.OIDC.RedirectURL",
+				"%q is not an absolute URL — providers reject relative redirect URIs", o.RedirectURL)
+		}
+		for value, role := range o.RoleMapping {
+			if !role.Valid() {
+				report(IssueError, "Dashboard.OIDC.RoleMapping",
+					"%q maps to unknown role %q — use viewer, analyst or admin", value, role)
+			}
+		}
+		if o.DefaultRole != "" && !o.DefaultRole.Valid() {
+			report(IssueError, "Dashboard.OIDC.DefaultRole",
+				"unknown role %q — use viewer, analyst or admin", o.DefaultRole)
+		}
+		if len(o.RoleMapping) == 0 && o.DefaultRole == "" {
+			report(IssueWarning, "Dashboard.OIDC.RoleMapping",
+				"no RoleMapping and no DefaultRole — every SSO login is refused")
+		}
+		if o.SessionTTL > 24*time.Hour {
+			report(IssueWarning, "Dashboard.OIDC.SessionTTL",
+				"%s is longer than a day — a user removed from a mapped group keeps their role until the token expires", o.SessionTTL)
+		}
+	}
+	passwordLogin := config.Dashboard.OIDC == nil || !config.Dashboard.OIDC.DisablePasswordLogin
+	if passwordLogin && config.Dashboard.Password == core.DefaultInsecurePassword {
 		report(IssueWarning, "Dashboard.Password",
 			"using the built-in default password — fine for local development; Mount refuses to start with it in release mode")
 	}
@@ -126,22 +164,61 @@ func ValidateConfig(config Config) []ConfigIssue {
 		}
 	}
 	validateRoutePatterns(report, "WAF.ExcludeRoutes", config.WAF.ExcludeRoutes)
+	validateExcludeIPs(report, config)
 	validateCustomRules(report, config.WAF.CustomRules)
+	for _, path := range config.WAF.RuleFiles {
+		field := fmt.Sprintf("WAF.RuleFiles[%q]", path)
+		_, issues, err := detection.ParseSecLangFile(path)
+		if err != nil {
+			report(IssueError, field, "cannot be read (%v) — none of its rules are imported", err)
+			continue
+		}
+		for _, issue := range issues {
+			report(IssueWarning, field, "%s", issue)
+		}
+	}
+	if config.WAF.Enabled && config.WAF.Mode == ModeChallenge {
+		validateChallenge(report, config)
+	}
+	if sc := config.WAF.Scoring; sc != nil {
+		if sc.InboundThreshold < 0 {
+			report(IssueError, "WAF.Scoring.InboundThreshold",
+				"negative threshold %d — every detection would be acted on, as without scoring", sc.InboundThreshold)
+		}
+		for pattern, threshold := range sc.RouteThresholds {
+			if err := middleware.ValidateRoutePattern(pattern); err != nil {
+				report(IssueError, "WAF.Scoring.RouteThresholds", "%v — the entry applies to no route", err)
+			}
+			if threshold <= 0 {
+				report(IssueError, "WAF.Scoring.RouteThresholds",
+					"%q has threshold %d — every detection on the route would be acted on", pattern, threshold)
+			}
+		}
+	}
 
 	// --- Rate limiting ---
 	if config.RateLimit.Enabled {
-		if config.RateLimit.ByIP == nil && config.RateLimit.ByUser == nil &&
+		if config.RateLimit.ByIP == nil && config.RateLimit.ByUser == nil && config.RateLimit.ByFingerprint == nil &&
 			config.RateLimit.Global == nil && len(config.RateLimit.ByRoute) == 0 {
 			report(IssueWarning, "RateLimit",
-				"rate limiting is enabled but no limit is configured (ByIP, ByUser, ByRoute, Global all unset) — the middleware does nothing")
+				"rate limiting is enabled but no limit is configured (ByIP, ByUser, ByFingerprint, ByRoute, Global all unset) — the middleware does nothing")
 		}
 		if config.RateLimit.ByUser != nil && config.RateLimit.UserIDExtractor == nil {
 			report(IssueError, "RateLimit.ByUser",
 				"a per-user limit is set but UserIDExtractor is nil — the limit never applies")
 		}
+		if config.RateLimit.ByFingerprint != nil && config.Fingerprint.Source == nil {
+			report(IssueError, "RateLimit.ByFingerprint",
+				"a per-fingerprint limit is set but Fingerprint.Source is nil — no request has a fingerprint and the limit never applies")
+		}
+		if config.RateLimit.Redis != nil && config.RateLimit.Redis.Addr == "" {
+			report(IssueError, "RateLimit.Redis.Addr",
+				"a Redis counter store is configured without an address — Mount will fail to connect")
+		}
 	}
 	validateLimit(report, "RateLimit.ByIP", config.RateLimit.ByIP)
 	validateLimit(report, "RateLimit.ByUser", config.RateLimit.ByUser)
+	validateLimit(report, "RateLimit.ByFingerprint", config.RateLimit.ByFingerprint)
 	validateLimit(report, "RateLimit.Global", config.RateLimit.Global)
 	validateRoutePatterns(report, "RateLimit.ExcludeRoutes", config.RateLimit.ExcludeRoutes)
 	for pattern, limit := range config.RateLimit.ByRoute {
@@ -165,6 +242,38 @@ func ValidateConfig(config Config) []ConfigIssue {
 		}
 	}
 
+	// --- Campaigns ---
+	if config.Campaigns.Enabled {
+		switch ls := config.Campaigns.LinkScore; {
+		case ls > 7:
+			report(IssueError, "Campaigns.LinkScore",
+				"link score %d exceeds the total weight of all signals (7) — no actors are ever grouped", ls)
+		case ls == 1:
+			report(IssueWarning, "Campaigns.LinkScore",
+				"a link score of 1 groups actors on one shared User-Agent or ASN — unrelated attackers end up in one campaign")
+		}
+	}
+
+	// --- TLS fingerprinting ---
+	validateFingerprint(report, config.Fingerprint)
+
+	// --- DLP ---
+	if config.DLP.Enabled {
+		validateDLP(report, config.DLP)
+	}
+
+	// --- Anomaly detection ---
+	if config.Anomaly.Enabled && slices.Contains(config.Anomaly.Checks, CheckCredentialStuffing) {
+		if !config.AuthShield.Enabled {
+			report(IssueWarning, "Anomaly.Checks",
+				"CheckCredentialStuffing is enabled but AuthShield is not — the check runs on AuthShield's login attempts and never sees any")
+		}
+		if rate := config.Anomaly.CredentialStuffing.FailureRate; rate < 0 || rate > 1 {
+			report(IssueError, "Anomaly.CredentialStuffing.FailureRate",
+				"FailureRate %g is not a share between 0 and 1 — distributed stuffing is never reported", rate)
+		}
+	}
+
 	// --- CAPTCHA ---
 	captchaProviders := 0
 	for _, secret := range []string{
@@ -180,6 +289,20 @@ func ValidateConfig(config Config) []ConfigIssue {
 			"%d CAPTCHA providers configured — only the first by precedence is used (hCaptcha > Turnstile > reCAPTCHA > self-hosted)", captchaProviders)
 	}
 
+	// --- Session risk ---
+	if config.SessionRisk.Enabled {
+		validateSessionRisk(report, config, captchaProviders > 0)
+	}
+
+	// --- Bots ---
+	validateBots(report, config, captchaProviders > 0)
+
+	// --- Network types ---
+	validateNetworks(report, config, captchaProviders > 0)
+
+	// --- Geofencing ---
+	validateGeoFence(report, config, captchaProviders > 0)
+
 	// --- Alerts ---
 	if config.Alerts.Slack != nil && config.Alerts.Slack.WebhookURL == "" {
 		report(IssueError, "Alerts.Slack",
@@ -217,11 +340,32 @@ func ValidateConfig(config Config) []ConfigIssue {
 		}
 	}
 
+	// --- Geolocation ---
+	if config.Geo.Enabled {
+		switch config.Geo.Provider {
+		case GeoIPFree, GeoIPPaid:
+			if config.Geo.DatabasePath == "" && config.Geo.ASNDatabasePath == "" {
+				report(IssueWarning, "Geo.DatabasePath",
+					"geolocation is enabled with provider %q but no MMDB file is configured — every lookup returns nothing; set DatabasePath, or opt in to ip-api.com with Provider: GeoIPAPI",
+					config.Geo.Provider)
+			}
+		case GeoIPAPI:
+			if config.Geo.DatabasePath != "" || config.Geo.ASNDatabasePath != "" {
+				report(IssueWarning, "Geo.Provider",
+					"provider is GeoIPAPI, so the configured MMDB files are ignored and every looked-up IP is sent to ip-api.com")
+			}
+		default:
+			report(IssueError, "Geo.Provider",
+				"unknown provider %q — use GeoIPFree, GeoIPPaid or GeoIPAPI", config.Geo.Provider)
+		}
+	}
+
 	// --- IP reputation ---
 	if config.IPReputation.Enabled && config.IPReputation.AbuseIPDBKey == "" {
 		report(IssueError, "IPReputation.AbuseIPDBKey",
 			"IP reputation is enabled but AbuseIPDBKey is empty — every reputation check silently returns nothing")
 	}
+	validateFeeds(report, config.IPReputation.Feeds)
 
 	return issues
 }
@@ -239,6 +383,31 @@ func validateRoutePatterns(report func(IssueSeverity, string, string, ...any), f
 	}
 }
 
+func validateExcludeIPs(report func(IssueSeverity, string, string, ...any), config Config) {
+	for _, entry := range config.WAF.ExcludeIPs {
+		parsed, err := core.ParseIPEntry(entry)
+		if err != nil {
+			report(IssueError, "WAF.ExcludeIPs",
+				"%v — the entry is silently dropped and that client is still inspected", err)
+			continue
+		}
+		switch {
+		case parsed == "0.0.0.0/0" || parsed == "::/0":
+			report(IssueWarning, "WAF.ExcludeIPs",
+				"%s excludes every client of that address family from the WAF", parsed)
+		case core.IsASNEntry(parsed) && !config.Geo.Enabled:
+			report(IssueError, "WAF.ExcludeIPs",
+				"%s is an ASN entry but geolocation is disabled — ASNs cannot be resolved and the entry never matches", parsed)
+		case core.IsASNEntry(parsed) && config.Geo.Provider == GeoIPAPI:
+			report(IssueError, "WAF.ExcludeIPs",
+				"%s is an ASN entry but the provider is GeoIPAPI — ASNs are only resolved from a local database, so the entry never matches; set Geo.ASNDatabasePath", parsed)
+		case core.IsASNEntry(parsed) && config.Geo.Provider != GeoIPAPI && config.Geo.ASNDatabasePath == "":
+			report(IssueWarning, "WAF.ExcludeIPs",
+				"%s is an ASN entry but Geo.ASNDatabasePath is empty — unless the city database carries ASN data, the entry never matches", parsed)
+		}
+	}
+}
+
 func validateLimit(report func(IssueSeverity, string, string, ...any), field string, limit *Limit) {
 	if limit == nil {
 		return
@@ -263,11 +432,16 @@ func validateCustomRules(report func(IssueSeverity, string, string, ...any), rul
 		}
 		seen[rule.ID] = true
 
-		if rule.Pattern == "" {
-			report(IssueError, field, "empty Pattern compiles to a regex that matches every request — in block mode this takes down every route the WAF inspects")
+		if rule.Pattern == "" && rule.When == nil {
+			report(IssueError, field, "neither a Pattern nor a When condition — the rule is dropped at mount")
 		} else if _, err := regexp.Compile(rule.Pattern); err != nil {
 			report(IssueError, field, "Pattern does not compile (%v) — the rule is silently dropped at mount", err)
 		}
+		if rule.When != nil {
+			if err := detection.ValidateCondition(*rule.When); err != nil {
+				report(IssueError, field, "When condition is invalid (%v) — the rule is dropped at mount", err)
+			}
+		}
 
 		for _, loc := range rule.AppliesTo {
 			if !validLocations[loc] {
@@ -275,5 +449,303 @@ func validateCustomRules(report func(IssueSeverity, string, string, ...any), rul
 					"AppliesTo location %q is not one of path/query/header/body — that location is silently never scanned", loc)
 			}
 		}
+		for _, t := range rule.Transforms {
+			if !t.Valid() {
+				report(IssueError, field, "unknown transform %q — the rule is dropped at mount", t)
+			}
+		}
+	}
+}
+
+// validateChallenge checks that challenge mode has something a browser can
+// solve: a CAPTCHA provider that can render its widget. Without one every
+// challenge is a 429 JSON response, which for a browser user is a block.
+func validateDLP(report func(IssueSeverity, string, string, ...any), dlp DLPConfig) {
+	for _, class := range dlp.Classes {
+		if !class.Valid() {
+			report(IssueError, "DLP.Classes",
+				"unknown class %q — use db_error, stack_trace, card_number or secret_key", class)
+		}
+	}
+	if !dlp.Action.Valid() {
+		report(IssueError, "DLP.Action",
+			"unknown action %q — leaks are only logged; use log, mask or replace", dlp.Action)
+	}
+	for class, action := range dlp.ClassActions {
+		if !class.Valid() {
+			report(IssueError, "DLP.ClassActions", "unknown class %q — the entry applies to nothing", class)
+		}
+		if !action.Valid() {
+			report(IssueError, "DLP.ClassActions",
+				"%q has unknown action %q — its leaks are only logged; use log, mask or replace", class, action)
+		}
+	}
+	if len(dlp.ContentTypes) == 0 {
+		report(IssueError, "DLP.ContentTypes",
+			"no content types are inspected — every response streams through and DLP does nothing")
+	}
+	for _, ct := range dlp.ContentTypes {
+		if _, _, err := mime.ParseMediaType(ct); err != nil || strings.Contains(ct, ";") {
+			report(IssueError, "DLP.ContentTypes",
+				"%q is not a bare media type — no response matches it", ct)
+		}
+	}
+	if dlp.MaxBodyBytes < 0 {
+		report(IssueError, "DLP.MaxBodyBytes",
+			"negative limit %d — the middleware falls back to 256 KB", dlp.MaxBodyBytes)
+	}
+	if dlp.ReplaceStatus < 100 || dlp.ReplaceStatus > 599 {
+		report(IssueError, "DLP.ReplaceStatus",
+			"%d is not an HTTP status — replaced responses are sent as 500", dlp.ReplaceStatus)
+	}
+	validateRoutePatterns(report, "DLP.ExcludeRoutes", dlp.ExcludeRoutes)
+}
+
+func validateBots(report func(IssueSeverity, string, string, ...any), config Config, hasCAPTCHA bool) {
+	if !config.Bots.Enabled {
+		if len(config.WAF.BotPolicy) > 0 {
+			report(IssueError, "WAF.BotPolicy", "a bot policy is set but Bots is not enabled — no request has a verdict and the policy never applies")
+		}
+		if len(config.RateLimit.ByBot) > 0 || len(config.RateLimit.ExemptBots) > 0 {
+			report(IssueError, "RateLimit.ByBot", "bot limits are set but Bots is not enabled — no request has a verdict and they never apply")
+		}
+		return
+	}
+	if t := config.Bots.Threshold; t < 0 || t > 100 {
+		report(IssueError, "Bots.Threshold", "threshold %d is outside 1–100 — bot scores run from 0 to 100", t)
+	}
+	for i, c := range config.Bots.Crawlers {
+		if len(c.UserAgents) == 0 || len(c.Domains) == 0 {
+			report(IssueError, fmt.Sprintf("Bots.Crawlers[%d]", i),
+				"crawler %q needs both UserAgents and Domains — without them it is never claimed or never verified", c.Name)
+		}
+	}
+	validateRoutePatterns(report, "Bots.ExcludeRoutes", config.Bots.ExcludeRoutes)
+	if len(config.WAF.BotPolicy) > 0 && !config.WAF.Enabled {
+		report(IssueError, "WAF.BotPolicy", "a bot policy is set but the WAF is not enabled — the policy never applies")
+	}
+	for verdict, action := range config.WAF.BotPolicy {
+		if !verdict.Valid() {
+			report(IssueError, "WAF.BotPolicy", "unknown verdict %q — the entry matches no request", verdict)
+		}
+		if !action.Valid() {
+			report(IssueError, "WAF.BotPolicy", "unknown action %q for %s — the verdict is inspected as usual", action, verdict)
+		}
+		if action == BotActionAllow && verdict != BotVerified {
+			report(IssueError, "WAF.BotPolicy",
+				"%s bots cannot be allowed — allow skips the WAF and the network policy, so only verified crawlers may have it", verdict)
+		}
+		if action == BotActionChallenge && !hasCAPTCHA {
+			report(IssueWarning, "WAF.BotPolicy",
+				"%s bots are challenged but no CAPTCHA provider is configured — browsers get a 429 they cannot get past", verdict)
+		}
+	}
+	for verdict, limit := range config.RateLimit.ByBot {
+		if !verdict.Valid() {
+			report(IssueError, "RateLimit.ByBot", "unknown verdict %q — the limit applies to no request", verdict)
+		}
+		l := limit
+		validateLimit(report, fmt.Sprintf("RateLimit.ByBot[%q]", verdict), &l)
+	}
+	for _, verdict := range config.RateLimit.ExemptBots {
+		if !verdict.Valid() {
+			report(IssueError, "RateLimit.ExemptBots", "unknown verdict %q — no request is exempted", verdict)
+		}
+		if verdict == BotHuman {
+			report(IssueWarning, "RateLimit.ExemptBots", "exempting %q takes most traffic out of rate limiting", verdict)
+		}
+	}
+}
+
+func validateNetworks(report func(IssueSeverity, string, string, ...any), config Config, hasCAPTCHA bool) {
+	n := config.Networks
+	if !n.Enabled {
+		if len(config.WAF.NetworkPolicy) > 0 {
+			report(IssueError, "WAF.NetworkPolicy", "a network policy is set but Networks is not enabled — no request has a network type and the policy never applies")
+		}
+		if len(config.RateLimit.ByNetwork) > 0 {
+			report(IssueError, "RateLimit.ByNetwork", "network limits are set but Networks is not enabled — no request has a network type and the limits never apply")
+		}
+		return
+	}
+	if len(n.TorExitPaths)+len(n.VPNPaths)+len(n.HostingPaths) == 0 {
+		report(IssueWarning, "Networks",
+			"network type detection is enabled without any list files — every public client is classified residential")
+	}
+	for t := range n.ScoreBoost {
+		if !t.Valid() {
+			report(IssueError, "Networks.ScoreBoost", "unknown network type %q — the boost applies to no actor", t)
+		}
+	}
+	validateRoutePatterns(report, "Networks.ExcludeRoutes", n.ExcludeRoutes)
+
+	for t, mode := range config.WAF.NetworkPolicy {
+		if !t.Valid() {
+			report(IssueError, "WAF.NetworkPolicy", "unknown network type %q — the policy applies to no request", t)
+		}
+		switch mode {
+		case ModeLog, ModeBlock, ModeChallenge:
+		default:
+			report(IssueError, "WAF.NetworkPolicy", "unknown mode %q for %s — the network is inspected as usual", mode, t)
+		}
+		if mode == ModeChallenge && !hasCAPTCHA {
+			report(IssueWarning, "WAF.NetworkPolicy",
+				"%s clients are challenged but no CAPTCHA provider is configured — browsers get a 429 they cannot get past", t)
+		}
+		if t == NetworkResidential && mode == ModeBlock {
+			report(IssueWarning, "WAF.NetworkPolicy", "blocking %q refuses every client not on a network list", t)
+		}
+	}
+	for t, limit := range config.RateLimit.ByNetwork {
+		if !t.Valid() {
+			report(IssueError, "RateLimit.ByNetwork", "unknown network type %q — the limit applies to no request", t)
+		}
+		l := limit
+		validateLimit(report, fmt.Sprintf("RateLimit.ByNetwork[%q]", t), &l)
+	}
+}
+
+func validateGeoFence(report func(IssueSeverity, string, string, ...any), config Config, hasCAPTCHA bool) {
+	policies := config.GeoFence.Policies
+	if len(policies) > 0 && !config.Geo.Enabled {
+		report(IssueError, "GeoFence.Policies",
+			"geofencing policies are set but Geo is not enabled — every client has an unknown location, so the policies only refuse anyone when fail-closed")
+	}
+	ids := make(map[string]bool, len(policies))
+	for i, p := range policies {
+		field := fmt.Sprintf("GeoFence.Policies[%d]", i)
+		if err := middleware.ValidateGeoPolicy(p); err != nil {
+			report(IssueError, field, "%v — no geofencing policy is enforced", err)
+			continue
+		}
+		if ids[p.ID] {
+			report(IssueError, field+".ID", "policy ID %q is used twice — no geofencing policy is enforced", p.ID)
+		}
+		ids[p.ID] = true
+		if len(p.Allow) > 0 && !p.FailClosed {
+			report(IssueWarning, field+".FailClosed",
+				"policy %q has an allow list but fails open — clients whose location cannot be resolved (private addresses, lookup errors) are let through", p.ID)
+		}
+		if p.Mode == ModeChallenge && !hasCAPTCHA {
+			report(IssueWarning, field+".Mode",
+				"policy %q challenges clients but no CAPTCHA provider is configured — every challenge is a 429 JSON response that browser users cannot get past", p.ID)
+		}
+	}
+}
+
+func validateFeeds(report func(IssueSeverity, string, string, ...any), feeds []ThreatFeed) {
+	names := make(map[string]bool, len(feeds))
+	for i, f := range feeds {
+		field := fmt.Sprintf("IPReputation.Feeds[%d]", i)
+		switch {
+		case f.Name == "":
+			report(IssueError, field+".Name", "feed has no name — its matches cannot be told apart from other feeds'")
+		case names[f.Name]:
+			report(IssueError, field+".Name", "feed name %q is used twice — stats and refreshes reach only the first", f.Name)
+		}
+		names[f.Name] = true
+
+		switch {
+		case (f.URL == "") == (f.Path == ""):
+			report(IssueError, field, "set exactly one of URL and Path — the feed has nowhere to load from")
+		case f.URL != "":
+			if u, err := url.Parse(f.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				report(IssueError, field+".URL", "%q is not an http or https URL — the feed never loads", f.URL)
+			} else if u.Scheme == "http" {
+				report(IssueWarning, field+".URL", "feed is fetched over plain HTTP — anyone on the path can add or remove indicators")
+			}
+			if f.Refresh > 0 && f.Refresh < time.Minute {
+				report(IssueWarning, field+".Refresh", "refreshing every %s hammers the feed's server — most publish hourly or daily", f.Refresh)
+			}
+		}
+
+		if f.Format != "" && !f.Format.Valid() {
+			report(IssueError, field+".Format", "unknown format %q — use FeedText, FeedCSV or FeedSTIX", f.Format)
+		}
+		if f.Column < 0 {
+			report(IssueError, field+".Column", "column %d is negative — no record has it and every line is skipped", f.Column)
+		} else if f.Column > 0 && f.Format != FeedCSV {
+			report(IssueWarning, field+".Column", "Column only applies to FeedCSV and is ignored for format %q", f.Format)
+		}
+		if f.Action != "" && !f.Action.Valid() {
+			report(IssueError, field+".Action", "unknown action %q — use FeedActionBlock, FeedActionFlag or FeedActionScore", f.Action)
+		}
+		refresh := f.Refresh
+		if refresh <= 0 {
+			refresh = time.Hour
+		}
+		if f.Expiry > 0 && f.Expiry <= refresh {
+			report(IssueError, field+".Expiry",
+				"expiry %s is not longer than the refresh interval %s — the indicators lapse before every reload", f.Expiry, refresh)
+		}
+	}
+}
+
+func validateFingerprint(report func(IssueSeverity, string, string, ...any), fp FingerprintConfig) {
+	if fp.Source == nil && (len(fp.Allow) > 0 || len(fp.Deny) > 0) {
+		report(IssueError, "Fingerprint.Source",
+			"Allow or Deny is set but Source is nil — no request has a fingerprint and the lists never apply")
+	}
+	allowed := make(map[string]bool, len(fp.Allow))
+	for _, e := range fp.Allow {
+		e = strings.TrimSpace(e)
+		allowed[e] = true
+		if e == "*" {
+			report(IssueError, "Fingerprint.Allow", `"*" is ignored — list fingerprints or prefixes such as "t13d1516h2_*"`)
+		}
+	}
+	for _, e := range fp.Deny {
+		e = strings.TrimSpace(e)
+		switch {
+		case e == "*":
+			report(IssueError, "Fingerprint.Deny", `"*" is ignored — denying every TLS client would refuse all traffic`)
+		case allowed[e]:
+			report(IssueWarning, "Fingerprint.Deny", "%q is also on Allow, which wins — the entry denies nothing", e)
+		}
+	}
+}
+
+func validateSessionRisk(report func(IssueSeverity, string, string, ...any), config Config, hasCAPTCHA bool) {
+	sr := config.SessionRisk
+	if config.UserExtractor == nil {
+		report(IssueError, "SessionRisk",
+			"session risk scoring is enabled but UserExtractor is nil — no request has a user and no session is scored")
+	}
+	if sr.Action != "" && !sr.Action.Valid() {
+		report(IssueError, "SessionRisk.Action",
+			"unknown action %q — risky sessions are only logged; use log, challenge or revoke", sr.Action)
+	}
+	if sr.Action == SessionActionChallenge && !hasCAPTCHA {
+		report(IssueWarning, "SessionRisk.Action",
+			"the challenge action has no CAPTCHA provider — risky sessions are only logged; configure a CAPTCHA provider")
+	}
+	validateRoutePatterns(report, "SessionRisk.SensitiveRoutes", sr.SensitiveRoutes)
+}
+
+func validateChallenge(report func(IssueSeverity, string, string, ...any), config Config) {
+	if p := config.WAF.Challenge.Path; p != "" && !strings.HasPrefix(p, "/") {
+		report(IssueError, "WAF.Challenge.Path",
+			"%q does not start with \"/\" — challenge submissions never reach the WAF and nobody can clear a challenge", p)
+	}
+
+	c := config.CAPTCHA
+	var provider, siteKey string
+	switch {
+	case c.HCaptchaSecret != "":
+		provider, siteKey = "hCaptcha", c.HCaptchaSiteKey
+	case c.TurnstileSecret != "":
+		provider, siteKey = "Turnstile", c.TurnstileSiteKey
+	case c.RecaptchaSecret != "":
+		provider, siteKey = "reCAPTCHA", c.RecaptchaSiteKey
+	case c.SelfHostedSecret != "":
+		return
+	default:
+		report(IssueWarning, "CAPTCHA",
+			"WAF challenge mode has no CAPTCHA provider — every challenge is a 429 JSON response that browser users cannot get past; configure a CAPTCHA provider")
+		return
+	}
+	if siteKey == "" {
+		report(IssueWarning, "CAPTCHA",
+			"WAF challenge mode uses %s but its site key is empty — the widget cannot render and every challenge is a 429 JSON response", provider)
 	}
 }
@@ -69,6 +69,17 @@ func TestValidateConfigCleanConfig(t *testing.T) {
 	}
 }
 
+// Every implemented driver must validate cleanly; only unknown ones fall
+// back to memory.
+func TestValidateConfigKnownStorageDrivers(t *testing.T) {
+	for _, driver := range []StorageDriver{SQLite, Postgres, MySQL, Memory} {
+		issues := ValidateConfig(Config{Storage: StorageConfig{Driver: driver}})
+		if hasIssue(issues, IssueError, "Storage.Driver") {
+			t.Errorf("driver %q reported as unsupported: %v", driver, issueFields(issues))
+		}
+	}
+}
+
 func TestValidateConfigCatchesSilentTraps(t *testing.T) {
 	cases := []struct {
 		name     string
@@ -82,15 +93,47 @@ func TestValidateConfigCatchesSilentTraps(t *testing.T) {
 			IssueError, "Storage.Driver",
 		},
 		{
-			"mysql driver not implemented",
-			Config{Storage: StorageConfig{Driver: MySQL}},
-			IssueError, "Storage.Driver",
+			"custom rule with an unknown transform",
+			Config{WAF: WAFConfig{CustomRules: []WAFRule{{ID: "r1", Pattern: "x", Transforms: []Transform{"base64"}}}}},
+			IssueError, `WAF.CustomRules["r1"]`,
+		},
+		{
+			"anomaly route threshold of zero",
+			Config{WAF: WAFConfig{Scoring: &AnomalyScoringConfig{RouteThresholds: map[string]int{"/api/cms/**": 0}}}},
+			IssueError, "WAF.Scoring.RouteThresholds",
+		},
+		{
+			"DLP class action with a typo",
+			Config{DLP: DLPConfig{Enabled: true, ClassActions: map[DLPClass]DLPAction{DLPCardNumber: "redact"}}},
+			IssueError, "DLP.ClassActions",
 		},
 		{
 			"malformed dashboard prefix",
 			Config{Dashboard: DashboardConfig{Prefix: "sentinel"}},
 			IssueError, "Dashboard.Prefix",
 		},
+		{
+			"OIDC without a client ID",
+			Config{Dashboard: DashboardConfig{OIDC: &OIDCConfig{IssuerURL: "https://idp.example.com", RedirectURL: "https://app.example.com/cb", DefaultRole: RoleViewer}}},
+			IssueError, "Dashboard.OIDC",
+		},
+		{
+			"OIDC role mapping to an unknown role",
+			Config{Dashboard: DashboardConfig{OIDC: &OIDCConfig{IssuerURL: "https://idp.example.com", ClientID: "c", RedirectURL: "https://app.example.com/cb",
+				RoleMapping: map[string]DashboardRole{"secops": "superuser"}}}},
+			IssueError, "Dashboard.OIDC.RoleMapping",
+		},
+		{
+			"OIDC that maps nobody to a role",
+			Config{Dashboard: DashboardConfig{OIDC: &OIDCConfig{IssuerURL: "https://idp.example.com", ClientID: "c", RedirectURL: "https://app.example.com/cb"}}},
+			IssueWarning, "Dashboard.OIDC.RoleMapping",
+		},
+		{
+			"OIDC tokens that outlive group changes for days",
+			Config{Dashboard: DashboardConfig{OIDC: &OIDCConfig{IssuerURL: "https://idp.example.com", ClientID: "c", RedirectURL: "https://app.example.com/cb",
+				DefaultRole: RoleViewer, SessionTTL: 7 * 24 * time.Hour}}},
+			IssueWarning, "Dashboard.OIDC.SessionTTL",
+		},
 		{
 			"invalid trusted proxy silently dropped",
 			Config{WAF: WAFConfig{TrustedProxies: []string{"not-an-ip"}}},
@@ -112,10 +155,15 @@ func TestValidateConfigCatchesSilentTraps(t *testing.T) {
 			IssueError, `WAF.CustomRules["bad"]`,
 		},
 		{
-			"custom rule with empty pattern matches everything",
+			"custom rule with neither pattern nor condition",
 			Config{WAF: WAFConfig{CustomRules: []WAFRule{{ID: "empty", Pattern: ""}}}},
 			IssueError, `WAF.CustomRules["empty"]`,
 		},
+		{
+			"custom rule with a condition missing its header name",
+			Config{WAF: WAFConfig{CustomRules: []WAFRule{{ID: "patch", When: &RuleCondition{Type: ConditionHeader}}}}},
+			IssueError, `WAF.CustomRules["patch"]`,
+		},
 		{
 			"custom rule with unknown location",
 			Config{WAF: WAFConfig{CustomRules: []WAFRule{{ID: "loc", Pattern: "x", AppliesTo: []string{"cookie"}}}}},
@@ -126,6 +174,109 @@ func TestValidateConfigCatchesSilentTraps(t *testing.T) {
 			Config{WAF: WAFConfig{CustomRules: []WAFRule{{ID: "dup", Pattern: "a"}, {ID: "dup", Pattern: "b"}}}},
 			IssueError, `WAF.CustomRules["dup"]`,
 		},
+		{
+			"missing rule file",
+			Config{WAF: WAFConfig{RuleFiles: []string{"/nonexistent/rules.conf"}}},
+			IssueError, `WAF.RuleFiles["/nonexistent/rules.conf"]`,
+		},
+		{
+			"credential stuffing check without AuthShield",
+			Config{Anomaly: AnomalyConfig{Enabled: true, Checks: []AnomalyCheckType{CheckCredentialStuffing}}},
+			IssueWarning, "Anomaly.Checks",
+		},
+		{
+			"credential stuffing failure rate as a percentage",
+			Config{AuthShield: AuthShieldConfig{Enabled: true, LoginRoute: "/login"},
+				Anomaly: AnomalyConfig{Enabled: true, Checks: []AnomalyCheckType{CheckCredentialStuffing},
+					CredentialStuffing: CredentialStuffingConfig{FailureRate: 80}}},
+			IssueError, "Anomaly.CredentialStuffing.FailureRate",
+		},
+		{
+			"session risk without a user extractor",
+			Config{SessionRisk: SessionRiskConfig{Enabled: true}},
+			IssueError, "SessionRisk",
+		},
+		{
+			"session risk step-up without a CAPTCHA provider",
+			Config{SessionRisk: SessionRiskConfig{Enabled: true, Action: SessionActionChallenge}},
+			IssueWarning, "SessionRisk.Action",
+		},
+		{
+			"campaign link score no pair of actors can reach",
+			Config{Campaigns: CampaignConfig{Enabled: true, LinkScore: 8}},
+			IssueError, "Campaigns.LinkScore",
+		},
+		{
+			"fingerprint deny list without a source",
+			Config{Fingerprint: FingerprintConfig{Deny: []string{"t13d3112h2_*"}}},
+			IssueError, "Fingerprint.Source",
+		},
+		{
+			"bot policy without bot detection",
+			Config{WAF: WAFConfig{Enabled: true, BotPolicy: map[BotVerdict]BotAction{BotBad: BotActionBlock}}},
+			IssueError, "WAF.BotPolicy",
+		},
+		{
+			"bots challenged without a captcha provider",
+			Config{Bots: BotConfig{Enabled: true}, WAF: WAFConfig{Enabled: true, BotPolicy: map[BotVerdict]BotAction{BotUnverified: BotActionChallenge}}},
+			IssueWarning, "WAF.BotPolicy",
+		},
+		{
+			"unverified bots allowed past the WAF",
+			Config{Bots: BotConfig{Enabled: true}, WAF: WAFConfig{Enabled: true, BotPolicy: map[BotVerdict]BotAction{BotUnverified: BotActionAllow}}},
+			IssueError, "WAF.BotPolicy",
+		},
+		{
+			"WAF excluded IP that is not an address, prefix or ASN",
+			Config{WAF: WAFConfig{ExcludeIPs: []string{"10.0.0.0/33"}}},
+			IssueError, "WAF.ExcludeIPs",
+		},
+		{
+			"WAF excluded ASN without geolocation",
+			Config{WAF: WAFConfig{ExcludeIPs: []string{"AS64496"}}},
+			IssueError, "WAF.ExcludeIPs",
+		},
+		{
+			"WAF excluded ASN resolved by ip-api",
+			Config{Geo: GeoConfig{Enabled: true, Provider: GeoIPAPI}, WAF: WAFConfig{ExcludeIPs: []string{"AS64496"}}},
+			IssueError, "WAF.ExcludeIPs",
+		},
+		{
+			"network policy without network type detection",
+			Config{WAF: WAFConfig{NetworkPolicy: map[NetworkType]WAFMode{NetworkTor: ModeBlock}}},
+			IssueError, "WAF.NetworkPolicy",
+		},
+		{
+			"network limit for an unknown network type",
+			Config{Networks: NetworkConfig{Enabled: true, TorExitPaths: []string{"tor.txt"}},
+				RateLimit: RateLimitConfig{ByNetwork: map[NetworkType]Limit{"proxy": {Requests: 10, Window: time.Minute}}}},
+			IssueError, "RateLimit.ByNetwork",
+		},
+		{
+			"geofence policy with a country name instead of a code",
+			Config{Geo: GeoConfig{Enabled: true}, GeoFence: GeoFenceConfig{Policies: []GeoPolicy{{ID: "admin", Allow: []string{"Germany"}, FailClosed: true}}}},
+			IssueError, "GeoFence.Policies[0]",
+		},
+		{
+			"geofence allow list that fails open",
+			Config{Geo: GeoConfig{Enabled: true}, GeoFence: GeoFenceConfig{Policies: []GeoPolicy{{ID: "admin", Allow: []string{"DE"}}}}},
+			IssueWarning, "GeoFence.Policies[0].FailClosed",
+		},
+		{
+			"threat feed with both a URL and a path",
+			Config{IPReputation: IPReputationConfig{Feeds: []ThreatFeed{{Name: "drop", URL: "https://example.com/drop.txt", Path: "drop.txt"}}}},
+			IssueError, "IPReputation.Feeds[0]",
+		},
+		{
+			"threat feed expiring before its next refresh",
+			Config{IPReputation: IPReputationConfig{Feeds: []ThreatFeed{{Name: "drop", Path: "drop.txt", Refresh: time.Hour, Expiry: 30 * time.Minute}}}},
+			IssueError, "IPReputation.Feeds[0].Expiry",
+		},
+		{
+			"per-fingerprint limit without a source",
+			Config{RateLimit: RateLimitConfig{Enabled: true, ByFingerprint: &Limit{Requests: 10, Window: time.Minute}}},
+			IssueError, "RateLimit.ByFingerprint",
+		},
 		{
 			"rate limiting enabled with no limits",
 			Config{RateLimit: RateLimitConfig{Enabled: true}},
@@ -136,6 +287,26 @@ func TestValidateConfigCatchesSilentTraps(t *testing.T) {
 			Config{RateLimit: RateLimitConfig{Enabled: true, ByUser: &Limit{Requests: 10, Window: time.Minute}}},
 			IssueError, "RateLimit.ByUser",
 		},
+		{
+			"challenge mode without a captcha provider",
+			Config{WAF: WAFConfig{Enabled: true, Mode: ModeChallenge}},
+			IssueWarning, "CAPTCHA",
+		},
+		{
+			"challenge mode with a commercial provider missing its site key",
+			Config{WAF: WAFConfig{Enabled: true, Mode: ModeChallenge}, CAPTCHA: CAPTCHAConfig{TurnstileSecret: "s"}},
+			IssueWarning, "CAPTCHA",
+		},
+		{
+			"challenge path without leading slash",
+			Config{WAF: WAFConfig{Enabled: true, Mode: ModeChallenge, Challenge: ChallengeConfig{Path: "challenge"}}, CAPTCHA: CAPTCHAConfig{SelfHostedSecret: "s"}},
+			IssueError, "WAF.Challenge.Path",
+		},
+		{
+			"redis counter store without address",
+			Config{RateLimit: RateLimitConfig{Enabled: true, ByIP: &Limit{Requests: 10, Window: time.Minute}, Redis: &RedisConfig{}}},
+			IssueError, "RateLimit.Redis.Addr",
+		},
 		{
 			"limit with zero window",
 			Config{RateLimit: RateLimitConfig{Enabled: true, ByIP: &Limit{Requests: 10}}},
@@ -201,6 +372,21 @@ func TestValidateConfigCatchesSilentTraps(t *testing.T) {
 			Config{IPReputation: IPReputationConfig{Enabled: true}},
 			IssueError, "IPReputation.AbuseIPDBKey",
 		},
+		{
+			"geolocation without a database",
+			Config{Geo: GeoConfig{Enabled: true}},
+			IssueWarning, "Geo.DatabasePath",
+		},
+		{
+			"ip-api provider ignores the database",
+			Config{Geo: GeoConfig{Enabled: true, Provider: GeoIPAPI, DatabasePath: "/var/lib/GeoIP/GeoLite2-City.mmdb"}},
+			IssueWarning, "Geo.Provider",
+		},
+		{
+			"unknown geo provider",
+			Config{Geo: GeoConfig{Enabled: true, Provider: "maxmind"}},
+			IssueError, "Geo.Provider",
+		},
 	}
 
 	for _, tc := range cases {